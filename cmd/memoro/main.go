@@ -11,7 +11,15 @@ import (
 	"memoro/internal/config"
 	"memoro/internal/handlers"
 	"memoro/internal/logger"
+	"memoro/internal/services/content"
+	"memoro/internal/services/email"
+	"memoro/internal/services/feed"
+	"memoro/internal/services/importer"
+	"memoro/internal/services/jobs"
+	"memoro/internal/services/language"
 	"memoro/internal/services/vector"
+	"memoro/internal/storage"
+	"memoro/internal/wechat"
 
 	"github.com/gin-gonic/gin"
 )
@@ -35,6 +43,27 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 启用日志字段脱敏
+	if cfg.Logging.Redaction.Enabled {
+		redactor, err := logger.NewRedactor(cfg.Logging.Redaction.Keys, cfg.Logging.Redaction.Patterns, cfg.Logging.Redaction.Mask)
+		if err != nil {
+			mainLogger.Error("Failed to initialize log redaction", logger.Fields{
+				"error": err.Error(),
+			})
+			os.Exit(1)
+		}
+		logger.EnableRedaction(redactor)
+	}
+
+	// 初始化审计日志
+	if _, err := logger.InitAuditLogger(cfg.Logging.Audit.Enabled, cfg.Logging.Audit.Output); err != nil {
+		mainLogger.Error("Failed to initialize audit logger", logger.Fields{
+			"error":  err.Error(),
+			"output": cfg.Logging.Audit.Output,
+		})
+		os.Exit(1)
+	}
+
 	mainLogger.Info("Application starting", logger.Fields{
 		"version":     "v0.1.0",
 		"mode":        cfg.Server.Mode,
@@ -42,6 +71,9 @@ func main() {
 		"config_path": *configPath,
 	})
 
+	// 后台加载中日文分词词典（耗时数秒，加载完成前分词逐字切分）
+	language.PreloadSegmenter()
+
 	// 设置Gin模式
 	if config.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
@@ -56,8 +88,11 @@ func main() {
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
 
+	// 后台运维任务管理器（任务类型在注册路由时按可用的服务注册）
+	jobManager := jobs.NewJobManager()
+
 	// 注册路由
-	err = setupRoutes(r, cfg)
+	processor, contentImporter, err := setupRoutes(r, cfg, jobManager)
 	if err != nil {
 		mainLogger.Error("Failed to setup routes", logger.Fields{
 			"error": err.Error(),
@@ -65,6 +100,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 启动订阅源采集（依赖内容处理器）
+	var feedPoller *feed.Poller
+	if cfg.Feeds.Enabled && processor != nil {
+		feedPoller = startFeedPoller(processor)
+	}
+
+	// 启动邮件采集（依赖内容处理器）
+	var emailPoller *email.Poller
+	if cfg.Email.Enabled && processor != nil {
+		emailPoller = startEmailPoller(processor)
+	}
+
 	// 创建HTTP服务器
 	serverAddr := config.GetServerAddress()
 	srv := &http.Server{
@@ -102,49 +149,225 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
+	serverErr := srv.Shutdown(ctx)
+	if serverErr != nil {
 		mainLogger.Error("Server forced to shutdown", logger.Fields{
-			"error":   err.Error(),
+			"error":   serverErr.Error(),
 			"timeout": cfg.Server.ShutdownTimeout,
 		})
+	}
+
+	// 停止订阅和邮件采集，不再向处理队列提交新条目
+	if feedPoller != nil {
+		feedPoller.Stop()
+	}
+	if emailPoller != nil {
+		emailPoller.Stop()
+	}
+
+	// 暂停执行中的导入任务并保存进度，重启后可继续
+	if contentImporter != nil {
+		if err := contentImporter.Close(ctx); err != nil {
+			mainLogger.Error("Importer shutdown failed", logger.Fields{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	// 取消执行中的后台任务
+	if err := jobManager.Close(ctx); err != nil {
+		mainLogger.Error("Background jobs shutdown failed", logger.Fields{
+			"error": err.Error(),
+		})
+	}
+
+	// HTTP请求结束后在剩余期限内排空内容处理队列
+	if processor != nil {
+		if err := processor.Close(ctx); err != nil {
+			mainLogger.Error("Content processor shutdown failed", logger.Fields{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	if serverErr != nil {
 		os.Exit(1)
 	}
-	
+
 	mainLogger.Info("Server exited gracefully")
 }
 
-// setupRoutes 设置路由
-func setupRoutes(r *gin.Engine, cfg *config.Config) error {
+// startFeedPoller 创建并启动订阅源采集任务，失败时记录警告并返回nil
+func startFeedPoller(processor *content.Processor) *feed.Poller {
+	feedLogger := logger.NewLogger("main")
+
+	store, err := storage.NewContentStore()
+	if err != nil {
+		feedLogger.Warn("Feed state store initialization failed, feed ingestion will be unavailable", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil
+	}
+
+	poller, err := feed.NewPoller(processor, store)
+	if err != nil {
+		feedLogger.Warn("Feed poller initialization failed, feed ingestion will be unavailable", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil
+	}
+
+	poller.Start()
+	return poller
+}
+
+// newImporter 创建在任务管理器中执行导入任务的书签和稍后读导入服务，失败时记录警告并返回nil
+func newImporter(processor *content.Processor, jobManager *jobs.JobManager) *importer.Importer {
+	importLogger := logger.NewLogger("main")
+
+	store, err := storage.NewContentStore()
+	if err != nil {
+		importLogger.Warn("Import job store initialization failed, import API will be unavailable", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil
+	}
+
+	contentImporter, err := importer.NewImporter(processor, store, jobManager)
+	if err != nil {
+		importLogger.Warn("Importer initialization failed, import API will be unavailable", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil
+	}
+
+	return contentImporter
+}
+
+// startEmailPoller 创建并启动邮件采集任务，失败时记录警告并返回nil
+func startEmailPoller(processor *content.Processor) *email.Poller {
+	poller, err := email.NewPoller(processor)
+	if err != nil {
+		logger.NewLogger("main").Warn("Email poller initialization failed, email ingestion will be unavailable", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil
+	}
+
+	// 图片和二进制附件与上传文件保存在同一存储中
+	if fileStore, err := storage.NewFileStore(); err != nil {
+		logger.NewLogger("main").Warn("File store initialization failed, email attachments other than text will be skipped", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		poller.SetFileStore(fileStore)
+	}
+
+	poller.Start()
+	return poller
+}
+
+// setupRoutes 设置路由并向任务管理器注册可用的后台任务，返回初始化成功的内容处理器和导入服务（不可用时为nil）用于关闭时排空
+func setupRoutes(r *gin.Engine, cfg *config.Config, jobManager *jobs.JobManager) (*content.Processor, *importer.Importer, error) {
+	routesLogger := logger.NewLogger("main")
+
 	// 初始化服务（仅用于路由注册，如果服务不可用会graceful降级）
 	var searchEngine handlers.SearchEngineInterface
 	var recommender handlers.RecommenderInterface
 
-	// 尝试初始化向量搜索引擎
+	// 尝试初始化向量搜索引擎；搜索API、推荐API和内容处理共用同一个引擎，
+	// 使内容查看记录的交互同时用于个性化推荐、已看排除和按访问调整重要性，缓存也只有一份
+	var sharedEngine *vector.SearchEngine
 	if cfg.VectorDB.Host != "" && cfg.VectorDB.Port > 0 {
 		if engine, err := vector.NewSearchEngine(); err != nil {
 			// 搜索引擎不可用，记录警告但继续启动
-			logger := logger.NewLogger("main")
-			logger.Warn("Search engine initialization failed, search API will be unavailable", map[string]interface{}{
+			routesLogger.Warn("Search engine initialization failed, search API will be unavailable", map[string]interface{}{
 				"error": err.Error(),
 			})
 		} else {
+			engine.SetInteractionStore(vector.NewInteractionStoreWithConfig(cfg))
+			sharedEngine = engine
 			searchEngine = engine
-
-			// 尝试初始化推荐器
-			if rec, err := vector.NewRecommender(); err != nil {
-				logger := logger.NewLogger("main")
-				logger.Warn("Recommender initialization failed, recommendation API will be unavailable", map[string]interface{}{
-					"error": err.Error(),
-				})
-			} else {
-				recommender = rec
-			}
+			recommender = engine.Recommender()
 		}
 	}
 
+	// 尝试初始化内容处理器（依赖LLM和数据库，向量库不可用时仅关闭向量化、搜索和推荐）
+	var contentService handlers.ContentServiceInterface
+	var scopeService handlers.SearchScopeServiceInterface
+	processor, err := content.NewProcessorWithSearchEngine(sharedEngine)
+	if err != nil {
+		processor = nil
+		routesLogger.Warn("Content processor initialization failed, content API will be unavailable", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		contentService = processor
+		scopeService = processor
+	}
+
 	// 创建API处理器
 	searchHandler := handlers.NewSearchHandler(searchEngine)
+	searchHandler.SetScopeService(scopeService)
+	contentHandler := handlers.NewContentHandler(contentService)
+	if fileStore, err := storage.NewFileStore(); err != nil {
+		routesLogger.Warn("File store initialization failed, content upload API will be unavailable", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		contentHandler.SetFileStore(fileStore)
+	}
+	var contentImporter *importer.Importer
+	var importService handlers.ImporterInterface
+	if processor != nil {
+		contentImporter = newImporter(processor, jobManager)
+		if contentImporter != nil {
+			importService = contentImporter
+		}
+	}
+	importHandler := handlers.NewImportHandler(importService)
 	recommendationHandler := handlers.NewRecommendationHandler(recommender)
+	wechatClient := wechat.NewClient()
+	wechatHandler := handlers.NewWeChatHandler(wechatClient, wechat.NewStatusChecker(wechatClient))
+	openAPIHandler := handlers.NewOpenAPIHandler("Memoro API", "v0.1.0")
+
+	// 持有缓存的服务（共享引擎只有一份缓存，只在处理器自行初始化引擎时单独清空处理器缓存）
+	var cacheFlushers []handlers.CacheFlusherInterface
+	if sharedEngine != nil {
+		cacheFlushers = append(cacheFlushers, sharedEngine)
+	} else if processor != nil {
+		cacheFlushers = append(cacheFlushers, processor)
+	}
+	adminHandler := handlers.NewAdminHandler(cacheFlushers...)
+	if processor != nil {
+		adminHandler.SetTagEditor(processor)
+	}
+	if engine, ok := searchEngine.(*vector.SearchEngine); ok {
+		engineJobs := []struct {
+			jobType string
+			fn      jobs.JobFunc
+		}{
+			{"archive", func(ctx context.Context, progress jobs.ProgressFunc) (interface{}, error) {
+				return engine.ArchiveStale(ctx, progress)
+			}},
+			{"purge_evicted", func(ctx context.Context, progress jobs.ProgressFunc) (interface{}, error) {
+				return engine.PurgeEvicted(ctx, "", progress)
+			}},
+			{"trending", func(ctx context.Context, progress jobs.ProgressFunc) (interface{}, error) {
+				return nil, engine.Recommender().RefreshTrending(ctx, progress)
+			}},
+		}
+		for _, job := range engineJobs {
+			if err := jobManager.Register(job.jobType, job.fn); err != nil {
+				routesLogger.Error("Failed to register background job", map[string]interface{}{
+					"job_type": job.jobType,
+					"error":    err.Error(),
+				})
+				return nil, nil, err
+			}
+		}
+	}
+	adminHandler.SetJobManager(jobManager)
 
 	// API v1 路由组
 	v1 := r.Group("/api/v1")
@@ -152,13 +375,47 @@ func setupRoutes(r *gin.Engine, cfg *config.Config) error {
 		// 健康检查
 		v1.GET("/health", handlers.HealthHandler)
 
+		// API文档
+		v1.GET("/openapi.json", openAPIHandler.GetSpec)
+
 		// 搜索API
 		v1.POST("/search", searchHandler.Search)
 		v1.GET("/search/stats", searchHandler.GetStats)
+		v1.POST("/search/scopes", searchHandler.SaveScope)
+		v1.GET("/search/scopes", searchHandler.ListScopes)
 
 		// 推荐API
 		v1.POST("/recommendations", recommendationHandler.GetRecommendations)
 
+		// 内容API
+		v1.GET("/content/:id", contentHandler.GetContent)
+		v1.PATCH("/content/:id", contentHandler.PatchContent)
+		v1.GET("/content/:id/summary", contentHandler.GetSummary)
+		v1.POST("/content/:id/refresh", contentHandler.RefreshContent)
+		v1.GET("/content/:id/events", contentHandler.StreamEvents)
+		v1.POST("/content/validate", contentHandler.ValidateContent)
+		v1.POST("/content/upload", contentHandler.UploadContent)
+		v1.GET("/timeline", contentHandler.ListTimeline)
+
+		// 导入API
+		v1.POST("/import", importHandler.StartImport)
+		v1.GET("/import/:id", importHandler.GetImport)
+		v1.POST("/import/:id/resume", importHandler.ResumeImport)
+
+		// 微信登录API（需要管理令牌）
+		wechatGroup := v1.Group("/wechat", handlers.AdminAuth(cfg.Server.AdminToken))
+		wechatGroup.GET("/login", wechatHandler.Login)
+		wechatGroup.GET("/status", wechatHandler.Status)
+
+		// 运维管理API（需要管理令牌）
+		adminGroup := v1.Group("/admin", handlers.AdminAuth(cfg.Server.AdminToken))
+		adminGroup.POST("/cache/flush", adminHandler.FlushCache)
+		adminGroup.POST("/tags/rename", adminHandler.RenameTag)
+		adminGroup.POST("/tags/delete", adminHandler.DeleteTag)
+		adminGroup.POST("/jobs/:type", adminHandler.StartJob)
+		adminGroup.GET("/jobs/:id", adminHandler.GetJob)
+		adminGroup.DELETE("/jobs/:id", adminHandler.CancelJob)
+
 		// 预留其他API端点
 		// TODO: 添加内容管理API
 		// TODO: 添加WebHook API
@@ -167,5 +424,8 @@ func setupRoutes(r *gin.Engine, cfg *config.Config) error {
 	// 直接健康检查路由 (向后兼容)
 	r.GET("/health", handlers.HealthHandler)
 
-	return nil
+	// 所有路由注册完成后生成OpenAPI文档
+	openAPIHandler.Build(r.Routes())
+
+	return processor, contentImporter, nil
 }
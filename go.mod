@@ -29,7 +29,7 @@ require (
 	golang.org/x/crypto v0.37.0 // indirect
 	golang.org/x/net v0.39.0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
-	golang.org/x/text v0.24.0 // indirect
+	golang.org/x/text v0.24.0
 	google.golang.org/protobuf v1.35.2 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -44,7 +44,12 @@ require (
 	gorm.io/gorm v1.25.4
 )
 
-require github.com/amikos-tech/chroma-go v0.2.3
+require (
+	github.com/abadojack/whatlanggo v1.0.1
+	github.com/amikos-tech/chroma-go v0.2.3
+	github.com/go-ego/gse v0.80.3
+	github.com/mdp/qrterminal/v3 v3.2.1
+)
 
 require (
 	github.com/Masterminds/semver v1.5.0 // indirect
@@ -55,7 +60,6 @@ require (
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/magiconair/properties v1.8.9 // indirect
 	github.com/mattn/go-sqlite3 v1.14.17 // indirect
-	github.com/mdp/qrterminal/v3 v3.2.1 // indirect
 	github.com/mitchellh/mapstructure v1.5.0 // indirect
 	github.com/oklog/ulid v1.3.1 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
@@ -66,6 +70,7 @@ require (
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
 	github.com/subosito/gotenv v1.4.2 // indirect
+	github.com/vcaesar/cedar v0.20.2 // indirect
 	github.com/yalue/onnxruntime_go v1.19.0 // indirect
 	golang.org/x/term v0.31.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
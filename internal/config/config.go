@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -23,6 +25,59 @@ type Config struct {
 	Cache      CacheConfig      `mapstructure:"cache"`
 	Security   SecurityConfig   `mapstructure:"security"`
 	Monitoring MonitoringConfig `mapstructure:"monitoring"`
+	Feeds      FeedsConfig      `mapstructure:"feeds"`
+	Email      EmailConfig      `mapstructure:"email"`
+	Import     ImportConfig     `mapstructure:"import"`
+}
+
+// FeedsConfig RSS/Atom订阅源采集配置
+type FeedsConfig struct {
+	Enabled           bool               `mapstructure:"enabled"`              // 是否启用订阅采集
+	PollInterval      time.Duration      `mapstructure:"poll_interval"`        // 轮询间隔，默认30分钟
+	FetchTimeout      time.Duration      `mapstructure:"fetch_timeout"`        // 单个订阅源的抓取超时，默认30秒
+	MaxEntriesPerPoll int                `mapstructure:"max_entries_per_poll"` // 每个订阅源单次最多提交的新条目数，默认50
+	Sources           []FeedSourceConfig `mapstructure:"sources"`              // 订阅源列表
+}
+
+// FeedSourceConfig 单个订阅源配置
+type FeedSourceConfig struct {
+	URL    string   `mapstructure:"url"`     // 订阅源地址
+	UserID string   `mapstructure:"user_id"` // 条目归属的用户
+	Tags   []string `mapstructure:"tags"`    // 附加到条目的标签（写入feed_tags元数据，并作为标签生成的参考）
+}
+
+// EmailConfig 邮件转发采集配置：轮询IMAP邮箱，将邮件和附件提交为内容处理请求
+type EmailConfig struct {
+	Enabled            bool                `mapstructure:"enabled"`               // 是否启用邮件采集
+	Host               string              `mapstructure:"host"`                  // IMAP服务器地址
+	Port               int                 `mapstructure:"port"`                  // IMAP端口，默认993（TLS）或143
+	UseTLS             bool                `mapstructure:"use_tls"`               // 是否使用TLS连接
+	Username           string              `mapstructure:"username"`              // 邮箱账号
+	Password           string              `mapstructure:"password"`              // 邮箱密码，建议通过MEMORO_EMAIL_PASSWORD设置
+	Mailbox            string              `mapstructure:"mailbox"`               // 采集的邮件夹，默认INBOX
+	ProcessedMailbox   string              `mapstructure:"processed_mailbox"`     // 处理后移动到的邮件夹（需要服务器支持MOVE），为空时只标记为已读
+	PollInterval       time.Duration       `mapstructure:"poll_interval"`         // 轮询间隔，默认5分钟
+	Timeout            time.Duration       `mapstructure:"timeout"`               // 单次连接的读写超时，默认30秒
+	MaxMessagesPerPoll int                 `mapstructure:"max_messages_per_poll"` // 单次最多处理的邮件数，默认20
+	MaxAttachmentSize  int64               `mapstructure:"max_attachment_size"`   // 附件大小上限（字节），超出的附件被忽略，默认10MB（图片和其他附件同时受storage.max_file_size限制）
+	DefaultUserID      string              `mapstructure:"default_user_id"`       // 发件人未映射时的归属用户，为空时忽略未映射发件人的邮件
+	Senders            []EmailSenderConfig `mapstructure:"senders"`               // 发件人地址到用户的映射
+}
+
+// EmailSenderConfig 发件人地址到用户的映射
+type EmailSenderConfig struct {
+	Address string   `mapstructure:"address"` // 发件人邮箱地址（忽略大小写）
+	UserID  string   `mapstructure:"user_id"` // 邮件归属的用户
+	Tags    []string `mapstructure:"tags"`    // 附加到邮件内容的标签（作为标签生成的参考）
+}
+
+// ImportConfig 书签和稍后读导出文件的导入配置
+type ImportConfig struct {
+	Throttle     time.Duration `mapstructure:"throttle"`      // 相邻两个条目提交处理的最小间隔，避免大批量导入占满处理队列，默认200毫秒
+	MaxEntries   int           `mapstructure:"max_entries"`   // 单次导入的最大条目数（去重后），默认5000
+	MaxFileSize  int64         `mapstructure:"max_file_size"` // 导出文件大小上限（字节），默认10MB
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"` // 提交遇到暂时性错误（队列已满、刷屏、额度用尽）后首次重试的等待时间，之后每次翻倍，默认1秒
+	MaxRetries   int           `mapstructure:"max_retries"`   // 同一条目遇到暂时性错误的最大重试次数，用尽后暂停任务，默认5
 }
 
 // ServerConfig 服务器配置
@@ -33,6 +88,8 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
 	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+
+	AdminToken string `mapstructure:"admin_token"` // 管理接口（如微信登录）访问令牌，为空时禁用管理接口
 }
 
 // WeChatConfig 微信配置
@@ -49,13 +106,22 @@ type WeChatConfig struct {
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
-	Type            string        `mapstructure:"type"`
-	Path            string        `mapstructure:"path"`
-	AutoMigrate     bool          `mapstructure:"auto_migrate"`
-	MaxOpenConns    int           `mapstructure:"max_open_conns"`
-	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
-	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
-	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+	Type            string           `mapstructure:"type"`
+	Path            string           `mapstructure:"path"`
+	AutoMigrate     bool             `mapstructure:"auto_migrate"`
+	MaxOpenConns    int              `mapstructure:"max_open_conns"`
+	MaxIdleConns    int              `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration    `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration    `mapstructure:"conn_max_idle_time"`
+	Encryption      EncryptionConfig `mapstructure:"encryption"` // 内容静态加密
+}
+
+// EncryptionConfig 内容静态加密配置（加密数据库中的原始内容和可选的摘要，向量和元数据不加密以保持可搜索）
+type EncryptionConfig struct {
+	Mode             string `mapstructure:"mode"`              // off（默认）、sensitive（仅加密标记为敏感的内容）或all（加密全部内容）
+	KeyProvider      string `mapstructure:"key_provider"`      // 密钥来源：env（默认）或通过storage.RegisterKeyProvider注册的提供者（如KMS）
+	KeyEnv           string `mapstructure:"key_env"`           // env密钥来源读取的环境变量名，值为base64或十六进制编码的AES密钥（默认MEMORO_ENCRYPTION_KEY）
+	EncryptSummaries bool   `mapstructure:"encrypt_summaries"` // 同时加密摘要
 }
 
 // VectorDBConfig 向量数据库配置
@@ -69,6 +135,180 @@ type VectorDBConfig struct {
 	BatchSize   int                       `mapstructure:"batch_size"`
 	CacheConfig *VectorCacheConfig        `mapstructure:"cache"`
 	PoolConfig  *ConnectionPoolConfig     `mapstructure:"connection_pool"`
+
+	DefaultRankingStrategy       string  `mapstructure:"default_ranking_strategy"`       // 默认排序策略，为空时使用简单相关性排序
+	DuplicateSimilarityThreshold float64 `mapstructure:"duplicate_similarity_threshold"` // 搜索结果折叠重复项的相似度阈值，默认0.95
+	SimilarityFloor              float64 `mapstructure:"similarity_floor"`               // 搜索结果展示的相似度下限（在最小相似度过滤之后截断，不影响过滤），默认0
+	MinPerContentType            int     `mapstructure:"min_per_content_type"`           // 搜索结果前top_k中每种内容类型至少保留的数量（有匹配时），0表示不保证
+
+	Trending       *TrendingConfig       `mapstructure:"trending"`       // 热门分数后台计算配置
+	Recommendation *RecommendationConfig `mapstructure:"recommendation"` // 推荐配置
+
+	Warmup *WarmupConfig `mapstructure:"warmup"` // 启动时的缓存预热配置
+
+	FieldBoosts *FieldBoostsConfig `mapstructure:"field_boosts"` // 关键词命中标题/摘要/标签时的额外加分
+	QueryTerms  *QueryTermsConfig  `mapstructure:"query_terms"`  // 关键词匹配分数中查询词的过滤和加权
+	TagBoosts   map[string]float64 `mapstructure:"tag_boosts"`   // 按标签调整排序分数（-1到1，负值降权，如archived），标签忽略大小写
+
+	ContentTypeWeights map[string]float64 `mapstructure:"content_type_weights"` // 按内容类型调整相关性分数的乘数（如link: 1.2），未配置的类型为1.0
+
+	HybridSearch *HybridSearchConfig `mapstructure:"hybrid_search"` // 向量检索与关键词检索的融合配置
+
+	Eviction *EvictionConfig `mapstructure:"eviction"` // 用户文档数超出配额时的淘汰策略
+
+	Archive *ArchiveConfig `mapstructure:"archive"` // 旧的低重要性内容归档到冷存储的策略
+
+	SearchLimits *SearchLimitsConfig `mapstructure:"search_limits"` // 服务端强制的搜索规模上限
+
+	SimilarityNormalization *SimilarityNormalizationConfig `mapstructure:"similarity_normalization"` // 各相似度类型映射到0-1的参数
+
+	AccessImportance *AccessImportanceConfig `mapstructure:"access_importance"` // 按访问频率和最近访问时间调整重要性
+
+	LLMRerank *LLMRerankConfig `mapstructure:"llm_rerank"` // 搜索请求启用LLM重排序时的成本控制
+
+	DistanceFunction string `mapstructure:"distance_function"` // 新建集合的距离函数（l2、cosine、ip），为空时使用cosine；已有集合沿用创建时的距离函数
+	SimilarityType   string `mapstructure:"similarity_type"`   // 搜索默认的相似度类型（cosine、euclidean、dot），为空时由集合距离函数推导
+
+	NormalizeVectors bool `mapstructure:"normalize_vectors"` // 索引前和查询时将向量L2归一化为单位长度，使cosine、dot和l2的排序一致（开启前已索引的文档需重新索引）
+
+	SnippetSource string `mapstructure:"snippet_source"` // 搜索结果片段的默认来源：computed（从原文截取含查询词的片段，默认）或summary（使用已保存的摘要，没有摘要时回退到computed）
+}
+
+// LLMRerankConfig LLM重排序配置：启发式排序后由LLM为前N个结果打相关性分数（0表示使用默认值）
+type LLMRerankConfig struct {
+	MaxCandidates    int           `mapstructure:"max_candidates"`     // 交给LLM打分的结果数上限，默认10
+	MaxDocumentChars int           `mapstructure:"max_document_chars"` // 每个结果发送给LLM的最大字符数，默认500
+	Timeout          time.Duration `mapstructure:"timeout"`            // LLM打分超时，超时后退回启发式排序，0表示只受请求超时限制
+}
+
+// AccessImportanceConfig 查询时将存储的重要性分数与访问信号混合，不修改存储的重要性（0表示使用默认值）
+type AccessImportanceConfig struct {
+	Weight   float64       `mapstructure:"weight"`    // 访问信号所占权重（0-1），0表示关闭
+	Window   time.Duration `mapstructure:"window"`    // 统计访问次数的时间窗口，默认30天
+	HalfLife time.Duration `mapstructure:"half_life"` // 最近访问时间的半衰期，默认7天
+}
+
+// SimilarityNormalizationConfig 相似度归一化参数，使不同相似度类型的分数可与同一min_similarity比较（0表示使用默认值）
+type SimilarityNormalizationConfig struct {
+	VectorNorm     float64 `mapstructure:"vector_norm"`     // 向量模长，点积和欧氏距离据此对齐到余弦相似度的尺度，默认1（单位向量）
+	ManhattanScale float64 `mapstructure:"manhattan_scale"` // 曼哈顿距离的衰减尺度，相似度为1/(1+距离/尺度)，默认1
+}
+
+// SearchLimitsConfig 搜索规模上限，超出时按上限截断并在响应元数据中提示（0表示使用默认值）
+type SearchLimitsConfig struct {
+	MaxTopK               int     `mapstructure:"max_top_k"`               // top_k上限，默认100
+	MaxResults            int     `mapstructure:"max_results"`             // 从向量库获取的候选结果数上限，默认500
+	EarlyTerminationScore float64 `mapstructure:"early_termination_score"` // 高置信结果的相关性阈值，已有top_k个达到该值时只重排序这些结果，默认0.85
+
+	MaxConcurrent int           `mapstructure:"max_concurrent"` // 同时执行的搜索数上限（每个搜索包含一次向量化和一次向量库查询），0表示不限制
+	MaxQueue      int           `mapstructure:"max_queue"`      // 达到并发上限时排队等待的搜索数上限，队列已满时直接返回服务繁忙，0表示不排队
+	QueueTimeout  time.Duration `mapstructure:"queue_timeout"`  // 排队的最长等待时间，超时返回服务繁忙，0表示等到请求取消
+}
+
+// ArchiveConfig 归档策略：超过指定时长且重要性低于下限的文档移出活跃集合，存入冷存储集合（0表示使用默认值）
+type ArchiveConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`          // 是否启用归档
+	MaxAge          time.Duration `mapstructure:"max_age"`          // 创建时间超过该时长的文档才归档，默认180天
+	ImportanceFloor float64       `mapstructure:"importance_floor"` // 只归档重要性低于该值的文档（0-1），0表示不按重要性限制
+	Collection      string        `mapstructure:"collection"`       // 冷存储集合名，默认为活跃集合名加_archive后缀
+	Interval        time.Duration `mapstructure:"interval"`         // 自动归档的执行间隔，0表示不自动执行
+	BatchSize       int           `mapstructure:"batch_size"`       // 单次归档扫描的文档数上限，默认500
+}
+
+// EvictionConfig 用户文档数超出配额时选择软删除文档的策略配置
+type EvictionConfig struct {
+	MaxDocumentsPerUser int    `mapstructure:"max_documents_per_user"` // 每个用户保留的最大文档数，0表示不限制
+	Strategy            string `mapstructure:"strategy"`               // 淘汰策略: lowest_importance, oldest, least_interacted, weighted，默认lowest_importance
+
+	ImportanceWeight  float64 `mapstructure:"importance_weight"`  // weighted策略中重要性的权重，默认0.5
+	RecencyWeight     float64 `mapstructure:"recency_weight"`     // weighted策略中新鲜度的权重，默认0.3
+	InteractionWeight float64 `mapstructure:"interaction_weight"` // weighted策略中最近交互的权重，默认0.2
+
+	PurgeAfter time.Duration `mapstructure:"purge_after"` // 软删除的文档保留在索引中的时长，之后由清理任务彻底删除，默认7天
+}
+
+// FieldBoostsConfig 查询词命中各元数据字段时在正文关键词分数之外的加分（0表示使用默认值，负数表示关闭）
+type FieldBoostsConfig struct {
+	Title   float64 `mapstructure:"title"`   // 命中标题，默认0.1
+	Summary float64 `mapstructure:"summary"` // 命中一句话摘要，默认0.05
+	Tags    float64 `mapstructure:"tags"`    // 命中标签，默认0.05
+}
+
+// HybridSearchConfig 混合检索配置：向量和关键词两路结果按倒数排名融合（RRF），0表示使用默认值
+type HybridSearchConfig struct {
+	DefaultMode       string  `mapstructure:"default_mode"`       // 请求未指定时的检索模式（vector、hybrid、keyword），默认vector
+	VectorWeight      float64 `mapstructure:"vector_weight"`      // 向量检索结果的融合权重，默认1
+	KeywordWeight     float64 `mapstructure:"keyword_weight"`     // 关键词检索结果的融合权重，默认1
+	RRFK              int     `mapstructure:"rrf_k"`              // 倒数排名融合的平滑常数k（分数为权重/(k+排名)），默认60
+	KeywordCandidates int     `mapstructure:"keyword_candidates"` // 关键词检索的候选文档数上限，默认与向量检索的候选数相同
+}
+
+// QueryTermsConfig 关键词匹配分数中查询词的处理方式，默认排除停用词且各词等权
+type QueryTermsConfig struct {
+	KeepStopwords  bool     `mapstructure:"keep_stopwords"`  // 停用词也计入关键词匹配（旧行为）
+	ExtraStopwords []string `mapstructure:"extra_stopwords"` // 在内置停用词表之外额外排除的词
+	IDFWeighting   bool     `mapstructure:"idf_weighting"`   // 按查询词在候选结果中的文档频率加权，越少见的词权重越高
+}
+
+// WarmupConfig 搜索引擎启动预热配置（后台执行，不阻塞启动）
+type WarmupConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`          // 是否启用预热
+	Queries         []string      `mapstructure:"queries"`          // 预先生成查询向量的常用查询
+	RecentDocuments int           `mapstructure:"recent_documents"` // 预取最近文档元数据的数量，0表示不预取
+	RecentWindow    time.Duration `mapstructure:"recent_window"`    // 最近文档的时间范围，默认7天
+	Timeout         time.Duration `mapstructure:"timeout"`          // 预热总超时，默认5分钟
+}
+
+// RecommendationConfig 推荐配置
+type RecommendationConfig struct {
+	SimilarityFactors SimilarityFactorsConfig    `mapstructure:"similarity_factors"`
+	Hybrid            HybridRecommendationConfig `mapstructure:"hybrid"`          // 混合推荐配置
+	Personalization   PersonalizationConfig      `mapstructure:"personalization"` // 个性化推荐配置
+	MinConfidence     float64                    `mapstructure:"min_confidence"`  // 返回前去掉归一化置信度（0-1）低于该值的推荐，0表示不过滤
+	IncludeSeen       bool                       `mapstructure:"include_seen"`    // 推荐结果是否保留用户已交互过的文档，默认false（自动排除），请求可覆盖
+
+	MinScores map[string]float64 `mapstructure:"min_scores"` // 各推荐类型（similar、related、personalized、trending、collaborative、hybrid、tag_based）的最小推荐分数，低于阈值的推荐被丢弃，未配置的类型不过滤
+}
+
+// PersonalizationConfig 个性化推荐配置：请求未提供个性化上下文时根据用户交互历史构建，交互不足时降级
+type PersonalizationConfig struct {
+	MinInteractions int    `mapstructure:"min_interactions"` // 构建个性化上下文所需的最少交互次数，默认3
+	HistorySize     int    `mapstructure:"history_size"`     // 参与构建的最近交互文档数量，默认20
+	Fallback        string `mapstructure:"fallback"`         // 交互不足时的降级方式: auto（有源文档或查询时related，否则trending）, related, trending, none（返回错误），默认auto
+}
+
+// HybridRecommendationConfig 混合推荐配置
+type HybridRecommendationConfig struct {
+	Strategies     []string      `mapstructure:"strategies"`      // 参与混合的子策略: similar, personalized, trending, collaborative，为空表示全部
+	MaxConcurrency int           `mapstructure:"max_concurrency"` // 同时执行的子策略数量上限，默认4
+	Timeout        time.Duration `mapstructure:"timeout"`         // 所有子策略共享的超时时间，默认10秒
+}
+
+// SimilarityFactorsConfig 各推荐类型的最小相似度调整系数（实际阈值 = 请求的min_similarity × 系数，0表示使用默认值）
+type SimilarityFactorsConfig struct {
+	Similar      float64 `mapstructure:"similar"`      // 相似推荐，默认1.0
+	Related      float64 `mapstructure:"related"`      // 相关推荐，默认0.7
+	Personalized float64 `mapstructure:"personalized"` // 个性化推荐，默认0.8
+}
+
+// TrendingConfig 热门分数后台计算配置
+type TrendingConfig struct {
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	TimeWindow      time.Duration `mapstructure:"time_window"`
+	MaxDocuments    int           `mapstructure:"max_documents"`
+
+	DecayCurve             string                          `mapstructure:"decay_curve"`              // 默认窗口的新鲜度衰减曲线: linear, exponential, gaussian
+	DecayScale             time.Duration                   `mapstructure:"decay_scale"`              // 衰减尺度（指数为半衰期，高斯为标准差），默认时间窗口的1/4
+	UseInteractionVelocity bool                            `mapstructure:"use_interaction_velocity"` // 参与度是否计入交互速度（每小时交互次数）
+	VelocityWindow         time.Duration                   `mapstructure:"velocity_window"`          // 交互速度统计窗口，默认24小时
+	Windows                map[string]TrendingWindowConfig `mapstructure:"windows"`                  // 可在请求中选择的命名时间窗口
+}
+
+// TrendingWindowConfig 命名热门时间窗口配置
+type TrendingWindowConfig struct {
+	TimeWindow time.Duration `mapstructure:"time_window"`
+	DecayCurve string        `mapstructure:"decay_curve"`
+	DecayScale time.Duration `mapstructure:"decay_scale"`
 }
 
 // VectorCacheConfig 向量缓存配置
@@ -80,6 +320,8 @@ type VectorCacheConfig struct {
 	UserPreferenceTTL     time.Duration `mapstructure:"user_preference_ttl"`
 	UserPreferenceMaxSize int           `mapstructure:"user_preference_max_size"`
 	CleanupInterval       time.Duration `mapstructure:"cleanup_interval"`
+	SearchResultTTL       time.Duration `mapstructure:"search_result_ttl"`      // 完整搜索结果缓存时间，0表示不缓存
+	SearchResultMaxSize   int           `mapstructure:"search_result_max_size"` // 最多缓存的搜索结果数量
 }
 
 // ConnectionPoolConfig 连接池配置
@@ -103,6 +345,72 @@ type LLMConfig struct {
 	RetryTimes  int             `mapstructure:"retry_times"`
 	RetryDelay  time.Duration   `mapstructure:"retry_delay"`
 	RateLimit   RateLimitConfig `mapstructure:"rate_limit"`
+
+	EmbeddingTruncation string            `mapstructure:"embedding_truncation"` // 向量化文本截断策略: head, tail, head_tail, sample（采样开头、中间和结尾的代表性片段）
+	TokenBudget         TokenBudgetConfig `mapstructure:"token_budget"`         // token预算限制
+
+	EmbeddingModel  string            `mapstructure:"embedding_model"`  // 默认向量化模型，为空时使用text-embedding-ada-002
+	EmbeddingModels map[string]string `mapstructure:"embedding_models"` // 按内容类型指定向量化模型（如code），所有模型的向量维度必须一致
+
+	EmbeddingMinInput EmbeddingMinInputConfig `mapstructure:"embedding_min_input"` // 向量化输入的最低要求，不满足时跳过向量化
+	EmbeddingReuse    EmbeddingReuseConfig    `mapstructure:"embedding_reuse"`     // 相同文本在短时间内复用已生成的向量
+
+	EmbeddingProviders []EmbeddingProviderConfig `mapstructure:"embedding_providers"` // 主向量化服务（api_base）失败时按顺序尝试的备用服务，向量维度必须与主服务一致；检索时查询向量只与同一服务生成的文档向量比较
+
+	AllowedModels []string `mapstructure:"allowed_models"` // 请求可指定的对话模型（如重新生成摘要时），为空时只能使用model
+
+	Stages LLMStagesConfig `mapstructure:"stages"` // 按处理阶段覆盖temperature和max_tokens，未设置的阶段使用全局值
+}
+
+// LLMStagesConfig 各处理阶段的LLM调用参数覆盖
+type LLMStagesConfig struct {
+	Summary        LLMStageConfig `mapstructure:"summary"`        // 摘要生成（通常使用较低的temperature以保证稳定）
+	Tag            LLMStageConfig `mapstructure:"tag"`            // 标签生成
+	Classification LLMStageConfig `mapstructure:"classification"` // 内容分类
+}
+
+// LLMStageConfig 单个阶段的LLM调用参数，未设置的字段使用llm.temperature和llm.max_tokens
+type LLMStageConfig struct {
+	Temperature *float64 `mapstructure:"temperature"` // 0-2，未设置时使用全局值（指针用于区分未设置和0）
+	MaxTokens   int      `mapstructure:"max_tokens"`  // 0表示使用全局值
+}
+
+// EmbeddingProviderConfig 备用向量化服务配置（OpenAI兼容的embeddings接口）
+type EmbeddingProviderConfig struct {
+	Name    string        `mapstructure:"name"`     // 服务名称，记录在向量元数据中
+	APIBase string        `mapstructure:"api_base"` // 接口地址
+	APIKey  string        `mapstructure:"api_key"`  // API密钥，本地服务可为空
+	Model   string        `mapstructure:"model"`    // 向量化模型，为空时使用与主服务相同的模型
+	Timeout time.Duration `mapstructure:"timeout"`  // 请求超时，0时使用llm.timeout
+}
+
+// EmbeddingReuseConfig 近期向量复用配置（按内容哈希，覆盖同一批次和短时间内的重复文本）
+type EmbeddingReuseConfig struct {
+	Disabled   bool          `mapstructure:"disabled"`    // 是否关闭复用
+	TTL        time.Duration `mapstructure:"ttl"`         // 复用时间窗口，0时使用5分钟
+	MaxEntries int           `mapstructure:"max_entries"` // 最多保存的向量数，0时使用1000
+}
+
+// EmbeddingMinInputConfig 向量化输入最低要求（按预处理后、添加内容类型前缀前的文本计算）
+type EmbeddingMinInputConfig struct {
+	MinChars     int                                `mapstructure:"min_chars"`     // 最少非空白字符数，0时使用1
+	MinTokens    int                                `mapstructure:"min_tokens"`    // 最少估算token数，0表示不检查
+	ContentTypes map[string]EmbeddingInputThreshold `mapstructure:"content_types"` // 按内容类型覆盖（如image、file）
+}
+
+// EmbeddingInputThreshold 单个内容类型的向量化输入阈值（0表示使用全局值）
+type EmbeddingInputThreshold struct {
+	MinChars  int `mapstructure:"min_chars"`
+	MinTokens int `mapstructure:"min_tokens"`
+}
+
+// TokenBudgetConfig token预算配置（0表示不限制）
+type TokenBudgetConfig struct {
+	Window            time.Duration `mapstructure:"window"`              // 周期额度的统计窗口，默认24小时
+	UserWindowLimit   int64         `mapstructure:"user_window_limit"`   // 单用户每个窗口的token上限
+	UserTotalLimit    int64         `mapstructure:"user_total_limit"`    // 单用户累计token上限
+	GlobalWindowLimit int64         `mapstructure:"global_window_limit"` // 全局每个窗口的token上限
+	GlobalTotalLimit  int64         `mapstructure:"global_total_limit"`  // 全局累计token上限
 }
 
 // RateLimitConfig 速率限制配置
@@ -114,8 +422,8 @@ type RateLimitConfig struct {
 // StorageConfig 存储配置
 type StorageConfig struct {
 	FilePath     string   `mapstructure:"file_path"`
-	MaxFileSize  string   `mapstructure:"max_file_size"`
-	AllowedTypes []string `mapstructure:"allowed_types"`
+	MaxFileSize  string   `mapstructure:"max_file_size"` // 上传文件大小上限（如50MB），为空时使用50MB
+	AllowedTypes []string `mapstructure:"allowed_types"` // 允许上传的类型：MIME类型（image/png）、通配（image/*）或扩展名（.pdf），为空时不限制
 }
 
 // LoggingConfig 日志配置
@@ -128,6 +436,24 @@ type LoggingConfig struct {
 	MaxBackups int    `mapstructure:"max_backups"`
 	MaxAge     int    `mapstructure:"max_age"`
 	Compress   bool   `mapstructure:"compress"`
+
+	Audit AuditLogConfig `mapstructure:"audit"` // 审计日志配置
+
+	Redaction RedactionConfig `mapstructure:"redaction"` // 日志字段脱敏配置
+}
+
+// RedactionConfig 日志字段脱敏配置（日志级别为debug时不脱敏）
+type RedactionConfig struct {
+	Enabled  bool     `mapstructure:"enabled"`  // 是否启用脱敏
+	Keys     []string `mapstructure:"keys"`     // 敏感字段名（不区分大小写），为空时使用默认列表
+	Patterns []string `mapstructure:"patterns"` // 敏感值正则，为空时使用默认列表
+	Mask     string   `mapstructure:"mask"`     // 替换后的占位值，默认[REDACTED]
+}
+
+// AuditLogConfig 审计日志配置（记录索引、更新、删除等变更操作）
+type AuditLogConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // 是否启用审计日志
+	Output  string `mapstructure:"output"`  // 输出文件路径，空或stdout时输出到标准输出
 }
 
 // ProcessingConfig 处理配置
@@ -138,6 +464,155 @@ type ProcessingConfig struct {
 	MaxContentSize int                 `mapstructure:"max_content_size"` // 最大内容大小(字节)
 	SummaryLevels  SummaryLevelsConfig `mapstructure:"summary_levels"`
 	TagLimits      TagLimitsConfig     `mapstructure:"tag_limits"`
+
+	LanguageDetection LanguageDetectionConfig `mapstructure:"language_detection"` // 语言检测配置
+	ReadingSpeed      ReadingSpeedConfig      `mapstructure:"reading_speed"`      // 估算阅读时间使用的阅读速度
+
+	MinContentLength int `mapstructure:"min_content_length"` // 最小内容长度(字符)，0表示不限制
+
+	AllowAnonymous bool   `mapstructure:"allow_anonymous"` // 是否接受不指定用户的请求（单用户部署和演示），默认关闭，多用户部署必须显式指定用户
+	DefaultUserID  string `mapstructure:"default_user_id"` // 允许匿名时未指定用户的请求归属的用户ID，为空时使用"default"
+
+	DefaultImportanceScore float64 `mapstructure:"default_importance_score"` // 无法计算重要性时使用的默认评分(0-1)，为空时使用0.5
+
+	Preprocessing PreprocessingConfig `mapstructure:"preprocessing"` // 文本预处理配置（提取、向量化和查询共用）
+
+	StageTimeouts StageTimeoutsConfig `mapstructure:"stage_timeouts"` // 各处理阶段的超时（总超时仍为上限）
+
+	StageConcurrency int `mapstructure:"stage_concurrency"` // 同时执行的独立处理阶段数（分类、摘要、标签），0表示全部并行，1表示顺序执行
+
+	NeighborTags NeighborTagsConfig `mapstructure:"neighbor_tags"` // 从相似文档继承标签的配置
+
+	ExtractionQuality ExtractionQualityConfig `mapstructure:"extraction_quality"` // 提取质量检测配置
+
+	LinkFetch LinkFetchConfig `mapstructure:"link_fetch"` // 链接抓取配置（重试、缓存、robots.txt、限速）
+
+	TextMarkup TextMarkupConfig `mapstructure:"text_markup"` // 文本内容中Markdown/HTML标记的处理
+
+	Callbacks CallbackConfig `mapstructure:"callbacks"` // 异步处理完成回调的发送配置
+
+	Entities EntityExtractionConfig `mapstructure:"entities"` // 分类阶段的实体抽取配置
+
+	Dedup DedupConfig `mapstructure:"dedup"` // 索引时的近似重复检测
+
+	FloodProtection FloodProtectionConfig `mapstructure:"flood_protection"` // 提交时的相似内容刷屏检测
+
+	Threads ThreadConfig `mapstructure:"threads"` // 会话线程（同一thread_id的聊天消息）的汇总
+
+	StageMetrics bool `mapstructure:"stage_metrics"` // 是否为所有请求收集阶段指标（耗时、token消耗、缓存命中），默认关闭，单个请求可通过enable_metrics开启
+}
+
+// EntityExtractionConfig 实体抽取配置：在分类阶段按规则抽取人名、组织、地点和日期，写入向量元数据用于过滤
+type EntityExtractionConfig struct {
+	Enabled    bool `mapstructure:"enabled"`      // 是否抽取实体，默认关闭
+	MaxPerType int  `mapstructure:"max_per_type"` // 每种实体保留的数量上限（控制元数据大小），0表示使用默认值10
+}
+
+// DedupConfig 索引时去重配置：新内容与范围和时间窗口内的已有内容相似度达到阈值时不再索引
+type DedupConfig struct {
+	Enabled             bool          `mapstructure:"enabled"`              // 是否启用索引时去重，默认关闭
+	Scope               string        `mapstructure:"scope"`                // 去重范围：user只与同一用户的内容比较（默认），global与所有用户的内容比较
+	Window              time.Duration `mapstructure:"window"`               // 只与该时间窗口内创建的内容比较，0表示不限
+	SimilarityThreshold float64       `mapstructure:"similarity_threshold"` // 判定为重复的相似度阈值（0-1），0表示使用默认值0.95
+
+	Concurrent bool `mapstructure:"concurrent"` // 同一用户相同内容（按内容哈希）的并发请求串行处理，后完成的请求复用先完成的结果而不重复向量化和索引（与enabled相互独立），默认关闭
+}
+
+// FloodProtectionConfig 刷屏检测配置：同一用户在时间窗口内提交的高度相似内容超过上限时限流或隔离，
+// 在提交时按文本相似度判断，不调用LLM和向量化
+type FloodProtectionConfig struct {
+	Enabled             bool          `mapstructure:"enabled"`              // 是否启用刷屏检测，默认关闭
+	SimilarityThreshold float64       `mapstructure:"similarity_threshold"` // 判定为相似的文本相似度阈值（0-1），0表示使用默认值0.9
+	MaxSimilar          int           `mapstructure:"max_similar"`          // 时间窗口内允许的相似提交数，超过后触发，0表示使用默认值5
+	Window              time.Duration `mapstructure:"window"`               // 统计时间窗口，0表示使用默认值1m
+	Action              string        `mapstructure:"action"`               // 触发后的处理：throttle拒绝提交（默认），quarantine保存并标记但不分析和索引
+}
+
+// ThreadConfig 会话线程汇总配置：启用时同一线程的消息各自索引，并合并为一个线程文档生成摘要和向量，
+// 同一线程两次汇总之间至少间隔rollup_interval，间隔内的后续消息合并为一次延迟汇总
+type ThreadConfig struct {
+	Aggregate      bool          `mapstructure:"aggregate"`       // 是否生成线程汇总文档，默认关闭
+	MaxMessages    int           `mapstructure:"max_messages"`    // 参与汇总的最近消息数上限，0表示使用默认值50
+	RollupInterval time.Duration `mapstructure:"rollup_interval"` // 同一线程两次汇总的最小间隔，0表示使用默认值30s
+}
+
+// CallbackConfig 异步处理完成回调的发送配置，回调由有限的发送协程从有界队列中取出发送（0表示使用默认值）
+type CallbackConfig struct {
+	Concurrency int           `mapstructure:"concurrency"` // 同时发送的回调数上限，默认4
+	MaxPending  int           `mapstructure:"max_pending"` // 等待发送的回调数上限，队列已满时丢弃并记录警告，默认100
+	Timeout     time.Duration `mapstructure:"timeout"`     // 单次回调请求超时，默认10s
+
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"` // 允许回调到回环、私有和链路本地地址（默认拒绝，防止通过回调访问内网服务）
+}
+
+// TextMarkupConfig 文本内容中Markdown/HTML标记的处理，默认转换为纯文本用于向量化和摘要，原始内容另行保存
+type TextMarkupConfig struct {
+	KeepMarkup bool `mapstructure:"keep_markup"` // 保留标记不做转换（仍记录检测到的格式）
+}
+
+// LinkFetchConfig 链接抓取配置
+type LinkFetchConfig struct {
+	Timeout         time.Duration `mapstructure:"timeout"`           // 单次请求超时，0时使用30s
+	RetryTimes      int           `mapstructure:"retry_times"`       // 暂时性错误（5xx、429、超时、DNS）的重试次数，0表示不重试
+	RetryDelay      time.Duration `mapstructure:"retry_delay"`       // 首次重试前的等待时间（之后每次翻倍），0时使用500ms
+	MaxRetryDelay   time.Duration `mapstructure:"max_retry_delay"`   // 重试等待时间上限，0时使用10s
+	CacheTTL        time.Duration `mapstructure:"cache_ttl"`         // 响应缓存时间（按规范URL），0表示不缓存
+	MaxRedirects    int           `mapstructure:"max_redirects"`     // 最多跟随的重定向次数，0时使用10
+	IgnoreRobots    bool          `mapstructure:"ignore_robots"`     // 是否忽略robots.txt（默认遵守）
+	HostMinInterval time.Duration `mapstructure:"host_min_interval"` // 同一主机两次请求的最小间隔，0表示不限制（robots.txt的Crawl-delay更大时以其为准）
+	ConnectTimeout  time.Duration `mapstructure:"connect_timeout"`   // 建立连接（含TLS握手）的超时，0时使用10s
+	MaxBodySize     int64         `mapstructure:"max_body_size"`     // 响应体读取上限（字节），超出部分被截断，0时使用5MB
+	DisablePreview  bool          `mapstructure:"disable_preview"`   // 是否不提取链接预览（og:image、站点名、favicon、作者和发布时间），默认提取
+	CacheMaxBytes   int64         `mapstructure:"cache_max_bytes"`   // 响应缓存的总字节上限，超出时淘汰最久未使用的响应，0时使用64MB
+	MaxCrawlDelay   time.Duration `mapstructure:"max_crawl_delay"`   // robots.txt中Crawl-delay的上限，避免站点设置过大的间隔拖住抓取，0时使用30s
+
+	HostOverrides map[string]LinkFetchHostConfig `mapstructure:"host_overrides"` // 按主机名覆盖超时和响应体上限
+}
+
+// LinkFetchHostConfig 单个主机的抓取参数覆盖，0表示使用全局配置
+type LinkFetchHostConfig struct {
+	Timeout     time.Duration `mapstructure:"timeout"`       // 单次请求超时
+	MaxBodySize int64         `mapstructure:"max_body_size"` // 响应体读取上限（字节）
+}
+
+// ExtractionQualityConfig 提取质量检测配置（识别OCR或提取失败产生的乱码）
+type ExtractionQualityConfig struct {
+	MinScore          float64 `mapstructure:"min_score"`           // 低于该质量分数(0-1)时标记为低质量，0时使用0.5
+	ExcludeFromSearch bool    `mapstructure:"exclude_from_search"` // 默认搜索是否排除低质量内容
+}
+
+// NeighborTagsConfig 相似文档标签继承配置
+type NeighborTagsConfig struct {
+	TopK          int     `mapstructure:"top_k"`          // 参与投票的相似文档数量，0时使用5
+	MinSimilarity float64 `mapstructure:"min_similarity"` // 参与投票的最小相似度(0-1)
+	MinScore      float64 `mapstructure:"min_score"`      // 建议标签的最小加权分数(0-1)
+}
+
+// StageTimeoutsConfig 处理阶段超时配置，0表示只受总超时限制
+type StageTimeoutsConfig struct {
+	Extract   time.Duration `mapstructure:"extract"`   // 内容提取
+	Classify  time.Duration `mapstructure:"classify"`  // 分类和重要性评分
+	Summarize time.Duration `mapstructure:"summarize"` // 摘要生成
+	Tag       time.Duration `mapstructure:"tag"`       // 标签生成
+	Vectorize time.Duration `mapstructure:"vectorize"` // 向量化和索引
+}
+
+// PreprocessingConfig 文本预处理配置
+type PreprocessingConfig struct {
+	Steps        []string            `mapstructure:"steps"`         // 默认步骤（查询和未单独配置的内容类型使用），为空时使用内置默认步骤
+	ContentTypes map[string][]string `mapstructure:"content_types"` // 按内容类型覆盖的步骤
+}
+
+// LanguageDetectionConfig 语言检测配置
+type LanguageDetectionConfig struct {
+	MinTextLength int     `mapstructure:"min_text_length"` // 拉丁字母文本参与识别的最少字母数，默认12
+	MinConfidence float64 `mapstructure:"min_confidence"`  // 低于该置信度时返回unknown，默认0.5
+}
+
+// ReadingSpeedConfig 阅读速度配置（每分钟词数，0表示使用默认值）
+type ReadingSpeedConfig struct {
+	WordsPerMinute int            `mapstructure:"words_per_minute"` // 没有语言默认值时使用的阅读速度，默认200
+	Languages      map[string]int `mapstructure:"languages"`        // 按语言代码覆盖，内置默认en 230、zh 250、ja 250、ko 220
 }
 
 // SummaryLevelsConfig 摘要级别配置
@@ -145,6 +620,8 @@ type SummaryLevelsConfig struct {
 	OneLineMaxLength   int `mapstructure:"one_line_max_length"`
 	ParagraphMaxLength int `mapstructure:"paragraph_max_length"`
 	DetailedMaxLength  int `mapstructure:"detailed_max_length"`
+
+	MinContentWords int `mapstructure:"min_content_words"` // 内容词数低于该值时不调用LLM，直接使用内容作为一句话摘要，0表示总是生成
 }
 
 // TagLimitsConfig 标签限制配置
@@ -152,6 +629,8 @@ type TagLimitsConfig struct {
 	MaxTags           int     `mapstructure:"max_tags"`
 	MaxTagLength      int     `mapstructure:"max_tag_length"`
 	DefaultConfidence float64 `mapstructure:"default_confidence"` // 默认置信度
+
+	Aliases map[string]string `mapstructure:"aliases"` // 标签别名到规范标签的映射（如"golang": "Go"），合并标签时视为同一标签
 }
 
 // CacheConfig 缓存配置
@@ -205,6 +684,7 @@ func Load(configPath string) (*Config, error) {
 	viper.BindEnv("llm.api_key", "MEMORO_LLM_API_KEY")
 	viper.BindEnv("wechat.admin_key", "MEMORO_WECHAT_ADMIN_KEY")
 	viper.BindEnv("database.path", "MEMORO_DATABASE_PATH")
+	viper.BindEnv("email.password", "MEMORO_EMAIL_PASSWORD")
 
 	configLogger.Info("Loading configuration", logger.Fields{
 		"config_path": configPath,
@@ -278,6 +758,12 @@ func validateConfig(config *Config) error {
 		return errors.ErrConfigMissing("database.path")
 	}
 
+	switch config.Database.Encryption.Mode {
+	case "", "off", "sensitive", "all":
+	default:
+		return errors.ErrConfigInvalid("database.encryption.mode", "must be 'off', 'sensitive' or 'all'")
+	}
+
 	// 验证LLM配置
 	if config.LLM.APIBase == "" {
 		return errors.ErrConfigMissing("llm.api_base")
@@ -295,6 +781,74 @@ func validateConfig(config *Config) error {
 		return errors.ErrConfigInvalid("llm.temperature", "must be between 0 and 2")
 	}
 
+	stages := []struct {
+		name  string
+		stage LLMStageConfig
+	}{
+		{"summary", config.LLM.Stages.Summary},
+		{"tag", config.LLM.Stages.Tag},
+		{"classification", config.LLM.Stages.Classification},
+	}
+	for _, s := range stages {
+		name, stage := s.name, s.stage
+		if stage.Temperature != nil && (*stage.Temperature < 0 || *stage.Temperature > 2) {
+			return errors.ErrConfigInvalid("llm.stages."+name+".temperature", "must be between 0 and 2")
+		}
+		if stage.MaxTokens < 0 {
+			return errors.ErrConfigInvalid("llm.stages."+name+".max_tokens", "must not be negative")
+		}
+	}
+
+	switch config.LLM.EmbeddingTruncation {
+	case "", "head", "tail", "head_tail", "sample":
+	default:
+		return errors.ErrConfigInvalid("llm.embedding_truncation", "must be 'head', 'tail', 'head_tail' or 'sample'")
+	}
+
+	providerNames := make(map[string]bool)
+	for i, provider := range config.LLM.EmbeddingProviders {
+		path := fmt.Sprintf("llm.embedding_providers[%d]", i)
+		if strings.TrimSpace(provider.Name) == "" {
+			return errors.ErrConfigInvalid(path+".name", "cannot be empty")
+		}
+		if provider.Name == "primary" || providerNames[provider.Name] {
+			return errors.ErrConfigInvalid(path+".name", "must be unique and not 'primary'")
+		}
+		providerNames[provider.Name] = true
+		if strings.TrimSpace(provider.APIBase) == "" {
+			return errors.ErrConfigInvalid(path+".api_base", "cannot be empty")
+		}
+		if provider.Timeout < 0 {
+			return errors.ErrConfigInvalid(path+".timeout", "must not be negative")
+		}
+	}
+
+	for contentType, model := range config.LLM.EmbeddingModels {
+		if strings.TrimSpace(model) == "" {
+			return errors.ErrConfigInvalid("llm.embedding_models."+contentType, "model cannot be empty")
+		}
+	}
+
+	minInput := config.LLM.EmbeddingMinInput
+	if minInput.MinChars < 0 || minInput.MinTokens < 0 {
+		return errors.ErrConfigInvalid("llm.embedding_min_input", "thresholds must not be negative")
+	}
+	for contentType, threshold := range minInput.ContentTypes {
+		if threshold.MinChars < 0 || threshold.MinTokens < 0 {
+			return errors.ErrConfigInvalid("llm.embedding_min_input.content_types."+contentType, "thresholds must not be negative")
+		}
+	}
+
+	if config.LLM.EmbeddingReuse.TTL < 0 || config.LLM.EmbeddingReuse.MaxEntries < 0 {
+		return errors.ErrConfigInvalid("llm.embedding_reuse", "ttl and max_entries must not be negative")
+	}
+
+	budget := config.LLM.TokenBudget
+	if budget.Window < 0 || budget.UserWindowLimit < 0 || budget.UserTotalLimit < 0 ||
+		budget.GlobalWindowLimit < 0 || budget.GlobalTotalLimit < 0 {
+		return errors.ErrConfigInvalid("llm.token_budget", "limits and window must not be negative")
+	}
+
 	// 验证向量数据库配置
 	if config.VectorDB.Type != "chroma" {
 		return errors.ErrConfigInvalid("vector_db.type", "only 'chroma' is supported")
@@ -304,10 +858,418 @@ func validateConfig(config *Config) error {
 		return errors.ErrConfigMissing("vector_db.collection")
 	}
 
+	if config.VectorDB.DuplicateSimilarityThreshold < 0 || config.VectorDB.DuplicateSimilarityThreshold > 1 {
+		return errors.ErrConfigInvalid("vector_db.duplicate_similarity_threshold", "must be between 0 and 1")
+	}
+	if config.VectorDB.SimilarityFloor < 0 || config.VectorDB.SimilarityFloor >= 1 {
+		return errors.ErrConfigInvalid("vector_db.similarity_floor", "must be at least 0 and less than 1")
+	}
+
+	if trending := config.VectorDB.Trending; trending != nil {
+		if !isValidDecayCurve(trending.DecayCurve) {
+			return errors.ErrConfigInvalid("vector_db.trending.decay_curve", "must be one of: linear, exponential, gaussian")
+		}
+		if trending.DecayScale < 0 || trending.VelocityWindow < 0 {
+			return errors.ErrConfigInvalid("vector_db.trending", "decay_scale and velocity_window must not be negative")
+		}
+		for name, window := range trending.Windows {
+			if window.TimeWindow <= 0 {
+				return errors.ErrConfigInvalid("vector_db.trending.windows."+name+".time_window", "must be positive")
+			}
+			if !isValidDecayCurve(window.DecayCurve) {
+				return errors.ErrConfigInvalid("vector_db.trending.windows."+name+".decay_curve", "must be one of: linear, exponential, gaussian")
+			}
+			if window.DecayScale < 0 {
+				return errors.ErrConfigInvalid("vector_db.trending.windows."+name+".decay_scale", "must not be negative")
+			}
+		}
+	}
+
+	if rec := config.VectorDB.Recommendation; rec != nil {
+		factors := rec.SimilarityFactors
+		if factors.Similar < 0 || factors.Related < 0 || factors.Personalized < 0 {
+			return errors.ErrConfigInvalid("vector_db.recommendation.similarity_factors", "factors must not be negative")
+		}
+		for _, strategy := range rec.Hybrid.Strategies {
+			switch strategy {
+			case "similar", "personalized", "trending", "collaborative":
+			default:
+				return errors.ErrConfigInvalid("vector_db.recommendation.hybrid.strategies", "must be one of: similar, personalized, trending, collaborative")
+			}
+		}
+		if rec.Hybrid.MaxConcurrency < 0 || rec.Hybrid.Timeout < 0 {
+			return errors.ErrConfigInvalid("vector_db.recommendation.hybrid", "max_concurrency and timeout must not be negative")
+		}
+		if rec.Personalization.MinInteractions < 0 || rec.Personalization.HistorySize < 0 {
+			return errors.ErrConfigInvalid("vector_db.recommendation.personalization", "min_interactions and history_size must not be negative")
+		}
+		switch rec.Personalization.Fallback {
+		case "", "auto", "related", "trending", "none":
+		default:
+			return errors.ErrConfigInvalid("vector_db.recommendation.personalization.fallback", "must be one of: auto, related, trending, none")
+		}
+		if rec.MinConfidence < 0 || rec.MinConfidence > 1 {
+			return errors.ErrConfigInvalid("vector_db.recommendation.min_confidence", "must be between 0 and 1")
+		}
+		for recType, minScore := range rec.MinScores {
+			switch recType {
+			case "similar", "related", "personalized", "trending", "collaborative", "hybrid", "tag_based":
+			default:
+				return errors.ErrConfigInvalid("vector_db.recommendation.min_scores", "keys must be one of: similar, related, personalized, trending, collaborative, hybrid, tag_based")
+			}
+			if minScore < 0 {
+				return errors.ErrConfigInvalid("vector_db.recommendation.min_scores", "must not be negative")
+			}
+		}
+	}
+
+	if archive := config.VectorDB.Archive; archive != nil {
+		if archive.MaxAge < 0 || archive.Interval < 0 || archive.BatchSize < 0 {
+			return errors.ErrConfigInvalid("vector_db.archive", "max_age, interval and batch_size must not be negative")
+		}
+		if archive.ImportanceFloor < 0 || archive.ImportanceFloor > 1 {
+			return errors.ErrConfigInvalid("vector_db.archive.importance_floor", "must be between 0 and 1")
+		}
+		if archive.Collection != "" && archive.Collection == config.VectorDB.Collection {
+			return errors.ErrConfigInvalid("vector_db.archive.collection", "must differ from the active collection")
+		}
+	}
+
+	if warmup := config.VectorDB.Warmup; warmup != nil {
+		if warmup.RecentDocuments < 0 || warmup.RecentWindow < 0 || warmup.Timeout < 0 {
+			return errors.ErrConfigInvalid("vector_db.warmup", "recent_documents, recent_window and timeout must not be negative")
+		}
+	}
+
+	if eviction := config.VectorDB.Eviction; eviction != nil {
+		switch eviction.Strategy {
+		case "", "lowest_importance", "oldest", "least_interacted", "weighted":
+		default:
+			return errors.ErrConfigInvalid("vector_db.eviction.strategy", "must be one of lowest_importance, oldest, least_interacted, weighted")
+		}
+		if eviction.MaxDocumentsPerUser < 0 {
+			return errors.ErrConfigInvalid("vector_db.eviction.max_documents_per_user", "must not be negative")
+		}
+		if eviction.ImportanceWeight < 0 || eviction.RecencyWeight < 0 || eviction.InteractionWeight < 0 {
+			return errors.ErrConfigInvalid("vector_db.eviction", "weights must not be negative")
+		}
+		if eviction.PurgeAfter < 0 {
+			return errors.ErrConfigInvalid("vector_db.eviction.purge_after", "must not be negative")
+		}
+	}
+
+	if limits := config.VectorDB.SearchLimits; limits != nil {
+		if limits.MaxTopK < 0 || limits.MaxResults < 0 {
+			return errors.ErrConfigInvalid("vector_db.search_limits", "max_top_k and max_results must not be negative")
+		}
+		if limits.EarlyTerminationScore < 0 || limits.EarlyTerminationScore > 1 {
+			return errors.ErrConfigInvalid("vector_db.search_limits.early_termination_score", "must be between 0 and 1")
+		}
+		if limits.MaxConcurrent < 0 || limits.MaxQueue < 0 || limits.QueueTimeout < 0 {
+			return errors.ErrConfigInvalid("vector_db.search_limits", "max_concurrent, max_queue and queue_timeout must not be negative")
+		}
+	}
+
+	if normalization := config.VectorDB.SimilarityNormalization; normalization != nil {
+		if normalization.VectorNorm < 0 || normalization.ManhattanScale < 0 {
+			return errors.ErrConfigInvalid("vector_db.similarity_normalization", "vector_norm and manhattan_scale must not be negative")
+		}
+	}
+
+	if access := config.VectorDB.AccessImportance; access != nil {
+		if access.Weight < 0 || access.Weight > 1 {
+			return errors.ErrConfigInvalid("vector_db.access_importance.weight", "must be between 0 and 1")
+		}
+		if access.Window < 0 || access.HalfLife < 0 {
+			return errors.ErrConfigInvalid("vector_db.access_importance", "window and half_life must not be negative")
+		}
+	}
+
+	if rerank := config.VectorDB.LLMRerank; rerank != nil {
+		if rerank.MaxCandidates < 0 || rerank.MaxDocumentChars < 0 || rerank.Timeout < 0 {
+			return errors.ErrConfigInvalid("vector_db.llm_rerank", "max_candidates, max_document_chars and timeout must not be negative")
+		}
+	}
+
+	if boosts := config.VectorDB.FieldBoosts; boosts != nil {
+		if boosts.Title > 1 || boosts.Summary > 1 || boosts.Tags > 1 {
+			return errors.ErrConfigInvalid("vector_db.field_boosts", "boosts must not exceed 1")
+		}
+	}
+
+	for tag, boost := range config.VectorDB.TagBoosts {
+		if strings.TrimSpace(tag) == "" {
+			return errors.ErrConfigInvalid("vector_db.tag_boosts", "tag must not be empty")
+		}
+		if boost < -1 || boost > 1 {
+			return errors.ErrConfigInvalid("vector_db.tag_boosts."+tag, "must be between -1 and 1")
+		}
+	}
+
+	for contentType, weight := range config.VectorDB.ContentTypeWeights {
+		if weight <= 0 || weight > 2 {
+			return errors.ErrConfigInvalid("vector_db.content_type_weights."+contentType, "must be greater than 0 and at most 2")
+		}
+	}
+
+	if hybrid := config.VectorDB.HybridSearch; hybrid != nil {
+		switch hybrid.DefaultMode {
+		case "", "vector", "hybrid", "keyword":
+		default:
+			return errors.ErrConfigInvalid("vector_db.hybrid_search.default_mode", "must be one of: vector, hybrid, keyword")
+		}
+		if hybrid.VectorWeight < 0 || hybrid.KeywordWeight < 0 {
+			return errors.ErrConfigInvalid("vector_db.hybrid_search", "weights must not be negative")
+		}
+		if hybrid.RRFK < 0 || hybrid.KeywordCandidates < 0 {
+			return errors.ErrConfigInvalid("vector_db.hybrid_search", "rrf_k and keyword_candidates must not be negative")
+		}
+	}
+
+	// 验证处理配置
+	if config.Processing.MinContentLength < 0 {
+		return errors.ErrConfigInvalid("processing.min_content_length", "must not be negative")
+	}
+	switch strings.ToLower(config.VectorDB.DistanceFunction) {
+	case "", "l2", "cosine", "ip":
+	default:
+		return errors.ErrConfigInvalid("vector_db.distance_function", "must be one of l2, cosine, ip")
+	}
+	switch strings.ToLower(config.VectorDB.SimilarityType) {
+	case "", "cosine", "euclidean", "dot":
+	default:
+		// 曼哈顿距离无法由任何集合距离函数换算，存储不返回向量时所有结果相似度为0，不能作为默认类型
+		return errors.ErrConfigInvalid("vector_db.similarity_type", "must be one of cosine, euclidean, dot")
+	}
+	switch config.VectorDB.SnippetSource {
+	case "", "computed", "summary":
+	default:
+		return errors.ErrConfigInvalid("vector_db.snippet_source", "must be 'computed' or 'summary'")
+	}
+	if config.VectorDB.MinPerContentType < 0 {
+		return errors.ErrConfigInvalid("vector_db.min_per_content_type", "must not be negative")
+	}
+	if config.Processing.SummaryLevels.MinContentWords < 0 {
+		return errors.ErrConfigInvalid("processing.summary_levels.min_content_words", "must not be negative")
+	}
+	if config.Processing.Callbacks.Concurrency < 0 || config.Processing.Callbacks.MaxPending < 0 || config.Processing.Callbacks.Timeout < 0 {
+		return errors.ErrConfigInvalid("processing.callbacks", "concurrency, max_pending and timeout must not be negative")
+	}
+	if config.Processing.DefaultUserID != "" && strings.TrimSpace(config.Processing.DefaultUserID) != config.Processing.DefaultUserID {
+		return errors.ErrConfigInvalid("processing.default_user_id", "must not contain leading or trailing whitespace")
+	}
+	if config.Processing.Entities.MaxPerType < 0 {
+		return errors.ErrConfigInvalid("processing.entities.max_per_type", "must not be negative")
+	}
+	if flood := config.Processing.FloodProtection; flood.Enabled {
+		if flood.SimilarityThreshold < 0 || flood.SimilarityThreshold > 1 {
+			return errors.ErrConfigInvalid("processing.flood_protection.similarity_threshold", "must be between 0 and 1")
+		}
+		if flood.MaxSimilar < 0 {
+			return errors.ErrConfigInvalid("processing.flood_protection.max_similar", "must not be negative")
+		}
+		if flood.Window < 0 {
+			return errors.ErrConfigInvalid("processing.flood_protection.window", "must not be negative")
+		}
+		switch flood.Action {
+		case "", "throttle", "quarantine":
+		default:
+			return errors.ErrConfigInvalid("processing.flood_protection.action", "must be throttle or quarantine")
+		}
+	}
+
+	if config.Processing.Threads.MaxMessages < 0 {
+		return errors.ErrConfigInvalid("processing.threads.max_messages", "must not be negative")
+	}
+	if config.Processing.Threads.RollupInterval < 0 {
+		return errors.ErrConfigInvalid("processing.threads.rollup_interval", "must not be negative")
+	}
+
+	if dedup := config.Processing.Dedup; dedup.Enabled {
+		switch dedup.Scope {
+		case "", "user", "global":
+		default:
+			return errors.ErrConfigInvalid("processing.dedup.scope", "must be user or global")
+		}
+		if dedup.Window < 0 {
+			return errors.ErrConfigInvalid("processing.dedup.window", "must not be negative")
+		}
+		if dedup.SimilarityThreshold < 0 || dedup.SimilarityThreshold > 1 {
+			return errors.ErrConfigInvalid("processing.dedup.similarity_threshold", "must be between 0 and 1")
+		}
+	}
+	if config.Processing.LinkFetch.Timeout < 0 {
+		return errors.ErrConfigInvalid("processing.link_fetch.timeout", "must not be negative")
+	}
+	if config.Processing.LinkFetch.RetryTimes < 0 {
+		return errors.ErrConfigInvalid("processing.link_fetch.retry_times", "must not be negative")
+	}
+	if config.Processing.LinkFetch.RetryDelay < 0 || config.Processing.LinkFetch.MaxRetryDelay < 0 {
+		return errors.ErrConfigInvalid("processing.link_fetch.retry_delay", "must not be negative")
+	}
+	if config.Processing.LinkFetch.CacheTTL < 0 {
+		return errors.ErrConfigInvalid("processing.link_fetch.cache_ttl", "must not be negative")
+	}
+	if config.Processing.LinkFetch.MaxRedirects < 0 {
+		return errors.ErrConfigInvalid("processing.link_fetch.max_redirects", "must not be negative")
+	}
+	if config.Processing.LinkFetch.HostMinInterval < 0 {
+		return errors.ErrConfigInvalid("processing.link_fetch.host_min_interval", "must not be negative")
+	}
+	if config.Processing.LinkFetch.ConnectTimeout < 0 {
+		return errors.ErrConfigInvalid("processing.link_fetch.connect_timeout", "must not be negative")
+	}
+	if config.Processing.LinkFetch.MaxBodySize < 0 {
+		return errors.ErrConfigInvalid("processing.link_fetch.max_body_size", "must not be negative")
+	}
+	if config.Processing.LinkFetch.CacheMaxBytes < 0 {
+		return errors.ErrConfigInvalid("processing.link_fetch.cache_max_bytes", "must not be negative")
+	}
+	if config.Processing.LinkFetch.MaxCrawlDelay < 0 {
+		return errors.ErrConfigInvalid("processing.link_fetch.max_crawl_delay", "must not be negative")
+	}
+	for host, override := range config.Processing.LinkFetch.HostOverrides {
+		if override.Timeout < 0 || override.MaxBodySize < 0 {
+			return errors.ErrConfigInvalid("processing.link_fetch.host_overrides."+host, "timeout and max_body_size must not be negative")
+		}
+	}
+	if config.Processing.ReadingSpeed.WordsPerMinute < 0 {
+		return errors.ErrConfigInvalid("processing.reading_speed.words_per_minute", "must not be negative")
+	}
+	for lang, speed := range config.Processing.ReadingSpeed.Languages {
+		if speed < 0 {
+			return errors.ErrConfigInvalid("processing.reading_speed.languages."+lang, "must not be negative")
+		}
+	}
+
+	if config.Processing.DefaultImportanceScore < 0 || config.Processing.DefaultImportanceScore > 1 {
+		return errors.ErrConfigInvalid("processing.default_importance_score", "must be between 0 and 1")
+	}
+
+	stageTimeouts := config.Processing.StageTimeouts
+	if stageTimeouts.Extract < 0 || stageTimeouts.Classify < 0 || stageTimeouts.Summarize < 0 ||
+		stageTimeouts.Tag < 0 || stageTimeouts.Vectorize < 0 {
+		return errors.ErrConfigInvalid("processing.stage_timeouts", "timeouts must not be negative")
+	}
+
+	if config.Processing.StageConcurrency < 0 {
+		return errors.ErrConfigInvalid("processing.stage_concurrency", "must not be negative")
+	}
+
+	if quality := config.Processing.ExtractionQuality; quality.MinScore < 0 || quality.MinScore > 1 {
+		return errors.ErrConfigInvalid("processing.extraction_quality.min_score", "must be between 0 and 1")
+	}
+
+	neighborTags := config.Processing.NeighborTags
+	if neighborTags.TopK < 0 {
+		return errors.ErrConfigInvalid("processing.neighbor_tags.top_k", "must not be negative")
+	}
+	if neighborTags.MinSimilarity < 0 || neighborTags.MinSimilarity > 1 {
+		return errors.ErrConfigInvalid("processing.neighbor_tags.min_similarity", "must be between 0 and 1")
+	}
+	if neighborTags.MinScore < 0 || neighborTags.MinScore > 1 {
+		return errors.ErrConfigInvalid("processing.neighbor_tags.min_score", "must be between 0 and 1")
+	}
+
+	preprocessing := config.Processing.Preprocessing
+	for _, step := range preprocessing.Steps {
+		if !isValidPreprocessingStep(step) {
+			return errors.ErrConfigInvalid("processing.preprocessing.steps", "unknown step: "+step)
+		}
+	}
+	for contentType, steps := range preprocessing.ContentTypes {
+		for _, step := range steps {
+			if !isValidPreprocessingStep(step) {
+				return errors.ErrConfigInvalid("processing.preprocessing.content_types."+contentType, "unknown step: "+step)
+			}
+		}
+	}
+
+	// 验证订阅采集配置
+	if feeds := config.Feeds; feeds.Enabled {
+		if feeds.PollInterval < 0 || feeds.FetchTimeout < 0 {
+			return errors.ErrConfigInvalid("feeds", "intervals must not be negative")
+		}
+		if feeds.MaxEntriesPerPoll < 0 {
+			return errors.ErrConfigInvalid("feeds.max_entries_per_poll", "must not be negative")
+		}
+		seen := make(map[string]bool, len(feeds.Sources))
+		for i, source := range feeds.Sources {
+			field := fmt.Sprintf("feeds.sources[%d]", i)
+			parsed, err := url.Parse(source.URL)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return errors.ErrConfigInvalid(field+".url", "must be an absolute http(s) URL")
+			}
+			if source.UserID == "" {
+				return errors.ErrConfigMissing(field + ".user_id")
+			}
+			if seen[source.URL] {
+				return errors.ErrConfigInvalid(field+".url", "duplicate feed URL")
+			}
+			seen[source.URL] = true
+		}
+	}
+
+	// 验证邮件采集配置
+	if email := config.Email; email.Enabled {
+		if email.Host == "" {
+			return errors.ErrConfigMissing("email.host")
+		}
+		if email.Port < 0 || email.Port > 65535 {
+			return errors.ErrConfigInvalid("email.port", "must be between 0 and 65535")
+		}
+		if email.Username == "" {
+			return errors.ErrConfigMissing("email.username")
+		}
+		if email.PollInterval < 0 || email.Timeout < 0 {
+			return errors.ErrConfigInvalid("email", "intervals must not be negative")
+		}
+		if email.MaxMessagesPerPoll < 0 || email.MaxAttachmentSize < 0 {
+			return errors.ErrConfigInvalid("email", "max_messages_per_poll and max_attachment_size must not be negative")
+		}
+		if email.DefaultUserID == "" && len(email.Senders) == 0 {
+			return errors.ErrConfigMissing("email.senders")
+		}
+		seen := make(map[string]bool, len(email.Senders))
+		for i, sender := range email.Senders {
+			field := fmt.Sprintf("email.senders[%d]", i)
+			address := strings.ToLower(strings.TrimSpace(sender.Address))
+			if !strings.Contains(address, "@") {
+				return errors.ErrConfigInvalid(field+".address", "must be an email address")
+			}
+			if sender.UserID == "" {
+				return errors.ErrConfigMissing(field + ".user_id")
+			}
+			if seen[address] {
+				return errors.ErrConfigInvalid(field+".address", "duplicate sender address")
+			}
+			seen[address] = true
+		}
+	}
+
+	// 验证导入配置
+	if config.Import.Throttle < 0 {
+		return errors.ErrConfigInvalid("import.throttle", "must not be negative")
+	}
+	if config.Import.MaxEntries < 0 || config.Import.MaxFileSize < 0 {
+		return errors.ErrConfigInvalid("import", "max_entries and max_file_size must not be negative")
+	}
+	if config.Import.RetryBackoff < 0 {
+		return errors.ErrConfigInvalid("import.retry_backoff", "must not be negative")
+	}
+	if config.Import.MaxRetries < 0 {
+		return errors.ErrConfigInvalid("import.max_retries", "must not be negative")
+	}
+
 	// 验证存储配置
 	if config.Storage.FilePath == "" {
 		return errors.ErrConfigMissing("storage.file_path")
 	}
+	if config.Storage.MaxFileSize != "" {
+		if size, err := ParseByteSize(config.Storage.MaxFileSize); err != nil || size <= 0 {
+			return errors.ErrConfigInvalid("storage.max_file_size", "must be a positive size such as 512KB or 50MB")
+		}
+	}
 
 	// 验证日志配置
 	if config.Logging.Level == "" {
@@ -326,9 +1288,56 @@ func validateConfig(config *Config) error {
 		return errors.ErrConfigInvalid("logging.level", "must be one of: debug, info, warn, error")
 	}
 
+	if redaction := config.Logging.Redaction; redaction.Enabled {
+		if _, err := logger.NewRedactor(redaction.Keys, redaction.Patterns, redaction.Mask); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// isValidPreprocessingStep 检查文本预处理步骤名称是否有效
+func isValidPreprocessingStep(step string) bool {
+	switch step {
+	case "trim", "collapse_whitespace", "normalize_unicode", "strip_control_chars", "lowercase":
+		return true
+	default:
+		return false
+	}
+}
+
+// isValidDecayCurve 检查热门衰减曲线是否有效（空值使用默认曲线）
+func isValidDecayCurve(curve string) bool {
+	switch curve {
+	case "", "linear", "exponential", "gaussian":
+		return true
+	}
+	return false
+}
+
+// ParseByteSize 解析带单位的字节大小（B、KB、MB、GB，按1024进位，不区分大小写，无单位时为字节）
+func ParseByteSize(value string) (int64, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(normalized, unit.suffix) {
+			normalized = strings.TrimSpace(strings.TrimSuffix(normalized, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	var number int64
+	if _, err := fmt.Sscanf(normalized, "%d", &number); err != nil || fmt.Sprint(number) != normalized {
+		return 0, fmt.Errorf("invalid size: %q", value)
+	}
+	return number * multiplier, nil
+}
+
 // processEnvironmentOverrides 处理环境变量覆盖
 func processEnvironmentOverrides(config *Config) error {
 	// 处理LLM API Key
@@ -343,6 +1352,18 @@ func processEnvironmentOverrides(config *Config) error {
 		configLogger.Debug("WeChat admin key loaded from environment variable")
 	}
 
+	// 处理管理接口令牌
+	if adminToken := os.Getenv("MEMORO_ADMIN_TOKEN"); adminToken != "" {
+		config.Server.AdminToken = adminToken
+		configLogger.Debug("Admin token loaded from environment variable")
+	}
+
+	// 处理邮箱密码
+	if emailPassword := os.Getenv("MEMORO_EMAIL_PASSWORD"); emailPassword != "" {
+		config.Email.Password = emailPassword
+		configLogger.Debug("Email password loaded from environment variable")
+	}
+
 	// 处理数据库路径
 	if dbPath := os.Getenv("MEMORO_DATABASE_PATH"); dbPath != "" {
 		config.Database.Path = dbPath
@@ -172,6 +172,7 @@ monitoring:
 }
 
 func TestConfigValidation(t *testing.T) {
+	stageTemperature := 2.5
 	tests := []struct {
 		name        string
 		config      *Config
@@ -381,6 +382,1237 @@ func TestConfigValidation(t *testing.T) {
 			expectError: true,
 			errorField:  "logging.level",
 		},
+		{
+			name: "Invalid embedding truncation strategy",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:1239/ws",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:             "https://api.test.com/v1",
+					Model:               "gpt-4",
+					MaxTokens:           1000,
+					Temperature:         0.5,
+					EmbeddingTruncation: "middle", // Invalid
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "llm.embedding_truncation",
+		},
+		{
+			name: "Negative token budget limit",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:1239/ws",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+					TokenBudget: TokenBudgetConfig{
+						UserWindowLimit: -1, // Invalid
+					},
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "llm.token_budget",
+		},
+		{
+			name: "Invalid duplicate similarity threshold",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:1239/ws",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:                         "chroma",
+					Collection:                   "test",
+					DuplicateSimilarityThreshold: 1.5, // Invalid
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.duplicate_similarity_threshold",
+		},
+		{
+			name: "Negative recommendation similarity factor",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:1239/ws",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					Recommendation: &RecommendationConfig{
+						SimilarityFactors: SimilarityFactorsConfig{Related: -0.5}, // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.recommendation.similarity_factors",
+		},
+		{
+			name: "Unknown hybrid recommendation strategy",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:1239/ws",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					Recommendation: &RecommendationConfig{
+						Hybrid: HybridRecommendationConfig{Strategies: []string{"trending", "random"}}, // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.recommendation.hybrid.strategies",
+		},
+		{
+			name: "Invalid trending decay curve",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:1239/ws",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					Trending: &TrendingConfig{
+						DecayCurve: "cubic", // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.trending.decay_curve",
+		},
+		{
+			name: "Negative min content length",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Processing: ProcessingConfig{
+					MinContentLength: -1, // Invalid
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "processing.min_content_length",
+		},
+		{
+			name: "Negative reading speed",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Processing: ProcessingConfig{
+					ReadingSpeed: ReadingSpeedConfig{
+						Languages: map[string]int{"zh": -1}, // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "processing.reading_speed.languages.zh",
+		},
+		{
+			name: "Default importance score out of range",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Processing: ProcessingConfig{
+					DefaultImportanceScore: 1.5, // Invalid
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "processing.default_importance_score",
+		},
+		{
+			name: "Unknown preprocessing step",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Processing: ProcessingConfig{
+					Preprocessing: PreprocessingConfig{
+						Steps: []string{"trim", "stem"}, // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "processing.preprocessing.steps",
+		},
+		{
+			name: "Negative stage timeout",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Processing: ProcessingConfig{
+					StageTimeouts: StageTimeoutsConfig{
+						Summarize: -time.Second, // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "processing.stage_timeouts",
+		},
+		{
+			name: "Unknown eviction strategy",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					Eviction: &EvictionConfig{
+						MaxDocumentsPerUser: 100,
+						Strategy:            "random", // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.eviction.strategy",
+		},
+		{
+			name: "Tag boost out of range",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					TagBoosts: map[string]float64{
+						"important": 0.2,
+						"archived":  -1.5, // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.tag_boosts.archived",
+		},
+		{
+			name: "Email sender without user",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+				Email: EmailConfig{
+					Enabled:  true,
+					Host:     "imap.example.com",
+					Username: "inbox@example.com",
+					Senders:  []EmailSenderConfig{{Address: "alice@example.com"}}, // Invalid
+				},
+			},
+			expectError: true,
+			errorField:  "email.senders[0].user_id",
+		},
+		{
+			name: "Invalid dedup scope",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+				Processing: ProcessingConfig{
+					Dedup: DedupConfig{
+						Enabled: true,
+						Scope:   "team", // Invalid
+					},
+				},
+			},
+			expectError: true,
+			errorField:  "processing.dedup.scope",
+		},
+		{
+			name: "Invalid personalization fallback",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					Recommendation: &RecommendationConfig{
+						Personalization: PersonalizationConfig{Fallback: "random"}, // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.recommendation.personalization.fallback",
+		},
+		{
+			name: "Invalid hybrid search mode",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					HybridSearch: &HybridSearchConfig{
+						DefaultMode: "sparse", // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.hybrid_search.default_mode",
+		},
+		{
+			name: "Archive collection same as active collection",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					Archive: &ArchiveConfig{
+						Enabled:    true,
+						Collection: "test", // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.archive.collection",
+		},
+		{
+			name: "Invalid recommendation min confidence",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					Recommendation: &RecommendationConfig{
+						MinConfidence: 1.5, // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.recommendation.min_confidence",
+		},
+		{
+			name: "Negative search max queue",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					SearchLimits: &SearchLimitsConfig{
+						MaxConcurrent: 4,
+						MaxQueue:      -1, // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.search_limits",
+		},
+		{
+			name: "Invalid flood protection action",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Processing: ProcessingConfig{
+					FloodProtection: FloodProtectionConfig{
+						Enabled: true,
+						Action:  "block", // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "processing.flood_protection.action",
+		},
+		{
+			name: "Embedding provider without API base",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+					EmbeddingProviders: []EmbeddingProviderConfig{
+						{Name: "local"}, // Invalid: missing api_base
+					},
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "llm.embedding_providers[0].api_base",
+		},
+		{
+			name: "Invalid max file size",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Storage: StorageConfig{
+					FilePath:    "./files",
+					MaxFileSize: "50 megabytes", // Invalid: unknown unit
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "storage.max_file_size",
+		},
+		{
+			name: "Negative thread max messages",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Processing: ProcessingConfig{
+					Threads: ThreadConfig{
+						Aggregate:   true,
+						MaxMessages: -1, // Invalid
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "processing.threads.max_messages",
+		},
+		{
+			name: "Unknown recommendation min score type",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+					Recommendation: &RecommendationConfig{
+						MinScores: map[string]float64{"popular": 0.5}, // Invalid: unknown type
+					},
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.recommendation.min_scores",
+		},
+		{
+			name: "Negative import throttle",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Import: ImportConfig{
+					Throttle: -time.Second, // Invalid
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "import.throttle",
+		},
+		{
+			name: "Negative import retries",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Import: ImportConfig{
+					MaxRetries: -1, // Invalid
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "import.max_retries",
+		},
+		{
+			name: "Unknown database encryption mode",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+					Encryption: EncryptionConfig{
+						Mode: "everything", // Invalid
+					},
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "database.encryption.mode",
+		},
+		{
+			name: "Unknown snippet source",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:          "chroma",
+					Collection:    "test",
+					SnippetSource: "highlight", // Invalid
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.snippet_source",
+		},
+		{
+			name: "Negative thread rollup interval",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Processing: ProcessingConfig{
+					Threads: ThreadConfig{RollupInterval: -time.Second}, // Invalid
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "processing.threads.rollup_interval",
+		},
+		{
+			name: "Manhattan similarity type",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:           "chroma",
+					Collection:     "test",
+					SimilarityType: "manhattan", // Not derivable from any distance function
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.similarity_type",
+		},
+		{
+			name: "Stage temperature out of range",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+					Stages: LLMStagesConfig{
+						Summary: LLMStageConfig{Temperature: &stageTemperature}, // Invalid
+					},
+				},
+				VectorDB: VectorDBConfig{
+					Type:       "chroma",
+					Collection: "test",
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "llm.stages.summary.temperature",
+		},
+		{
+			name: "Content type weight out of range",
+			config: &Config{
+				Server: ServerConfig{
+					Port: 8080,
+					Mode: "development",
+				},
+				WeChat: WeChatConfig{
+					WebSocketURL: "ws://localhost:8080",
+				},
+				Database: DatabaseConfig{
+					Type: "sqlite",
+					Path: "./test.db",
+				},
+				LLM: LLMConfig{
+					APIBase:     "https://api.test.com/v1",
+					Model:       "gpt-4",
+					MaxTokens:   1000,
+					Temperature: 0.5,
+				},
+				VectorDB: VectorDBConfig{
+					Type:               "chroma",
+					Collection:         "test",
+					ContentTypeWeights: map[string]float64{"link": 3}, // Invalid
+				},
+				Storage: StorageConfig{
+					FilePath: "./files",
+				},
+				Logging: LoggingConfig{
+					Level: "info",
+				},
+			},
+			expectError: true,
+			errorField:  "vector_db.content_type_weights.link",
+		},
 	}
 
 	for _, tt := range tests {
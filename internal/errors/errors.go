@@ -39,16 +39,28 @@ const (
 	ErrCodeConfigInvalid   ErrorCode = "E1005"
 
 	// 业务错误码 (E2xxx)
-	ErrCodeValidationFailed  ErrorCode = "E2001"
-	ErrCodeResourceNotFound  ErrorCode = "E2002"
-	ErrCodeDuplicateResource ErrorCode = "E2003"
-	ErrCodeInvalidInput      ErrorCode = "E2004"
+	ErrCodeValidationFailed   ErrorCode = "E2001"
+	ErrCodeResourceNotFound   ErrorCode = "E2002"
+	ErrCodeDuplicateResource  ErrorCode = "E2003"
+	ErrCodeInvalidInput       ErrorCode = "E2004"
+	ErrCodeBudgetExceeded     ErrorCode = "E2005"
+	ErrCodeStageTimeout       ErrorCode = "E2006"
+	ErrCodeInsufficientInput  ErrorCode = "E2007"
+	ErrCodeServerBusy         ErrorCode = "E2008"
+	ErrCodeFloodDetected      ErrorCode = "E2009"
+	ErrCodeFeatureUnavailable ErrorCode = "E2010"
 
 	// 集成错误码 (E3xxx)
 	ErrCodeWebSocketConnect ErrorCode = "E3001"
 	ErrCodeWebSocketMessage ErrorCode = "E3002"
 	ErrCodeLLMAPICall       ErrorCode = "E3003"
 	ErrCodeVectorStorage    ErrorCode = "E3004"
+
+	// LLM/embedding接口按响应状态细分的错误码
+	ErrCodeLLMAuth           ErrorCode = "E3005" // 认证失败（401/403），不重试
+	ErrCodeLLMRateLimited    ErrorCode = "E3006" // 限流（429），退避后重试
+	ErrCodeLLMInvalidRequest ErrorCode = "E3007" // 请求无效（其他4xx），不重试
+	ErrCodeLLMServerError    ErrorCode = "E3008" // 服务端错误（5xx），退避后重试
 )
 
 // MemoroError 统一错误结构
@@ -152,3 +164,52 @@ func ErrResourceNotFound(resourceType, resourceID string) *MemoroError {
 	return NewMemoroError(ErrorTypeBusiness, ErrCodeResourceNotFound, "Resource not found").
 		WithDetails(fmt.Sprintf("%s with ID '%s' not found", resourceType, resourceID))
 }
+
+// ErrBudgetExceeded token预算超限错误
+func ErrBudgetExceeded(scope string, used, limit int64) *MemoroError {
+	return NewMemoroError(ErrorTypeBusiness, ErrCodeBudgetExceeded, "Token budget exceeded").
+		WithDetails(fmt.Sprintf("%s budget exhausted: used %d of %d tokens", scope, used, limit))
+}
+
+// ErrStageTimeout 处理阶段超时错误
+func ErrStageTimeout(stage string, timeout time.Duration) *MemoroError {
+	return NewMemoroError(ErrorTypeBusiness, ErrCodeStageTimeout, "Processing stage timed out").
+		WithDetails(fmt.Sprintf("stage '%s' exceeded %s", stage, timeout)).
+		WithContext(map[string]interface{}{"stage": stage, "timeout": timeout.String()})
+}
+
+// ErrInsufficientEmbeddingInput 向量化输入不足错误（内容实际上没有可向量化的文本）
+func ErrInsufficientEmbeddingInput(contentType string, chars, tokens, minChars, minTokens int) *MemoroError {
+	return NewMemoroError(ErrorTypeValidation, ErrCodeInsufficientInput, "Insufficient content for embedding").
+		WithDetails(fmt.Sprintf("%s content has %d characters (~%d tokens), requires at least %d characters and %d tokens",
+			contentType, chars, tokens, minChars, minTokens)).
+		WithContext(map[string]interface{}{"content_type": contentType, "chars": chars, "tokens": tokens})
+}
+
+// ErrServerBusy 服务繁忙错误（并发和排队都已达到上限）
+func ErrServerBusy(resource string, limit, queueLimit int) *MemoroError {
+	return NewMemoroError(ErrorTypeBusiness, ErrCodeServerBusy, "Server busy").
+		WithDetails(fmt.Sprintf("too many concurrent %s requests: %d running and %d queued, retry later", resource, limit, queueLimit)).
+		WithContext(map[string]interface{}{"resource": resource, "max_concurrent": limit, "max_queue": queueLimit})
+}
+
+// ErrFloodDetected 刷屏错误（用户在时间窗口内提交了过多高度相似的内容）
+func ErrFloodDetected(userID string, similar int, window time.Duration) *MemoroError {
+	return NewMemoroError(ErrorTypeBusiness, ErrCodeFloodDetected, "Too many similar submissions").
+		WithDetails(fmt.Sprintf("user '%s' submitted %d similar items within %s, retry later", userID, similar, window)).
+		WithContext(map[string]interface{}{"user_id": userID, "similar": similar, "window": window.String()})
+}
+
+// ErrFeatureUnavailable 功能不可用错误（依赖的服务未初始化，如向量库不可用时的搜索和推荐）
+func ErrFeatureUnavailable(feature, reason string) *MemoroError {
+	return NewMemoroError(ErrorTypeBusiness, ErrCodeFeatureUnavailable, "Feature unavailable").
+		WithDetails(fmt.Sprintf("%s is unavailable: %s", feature, reason)).
+		WithContext(map[string]interface{}{"feature": feature})
+}
+
+// ErrLLMAPIStatus LLM/embedding接口错误状态，错误码由调用方根据状态码和响应体分类得出
+func ErrLLMAPIStatus(code ErrorCode, service string, statusCode int, providerMessage string) *MemoroError {
+	return NewMemoroError(ErrorTypeLLM, code, fmt.Sprintf("%s API returned error status", service)).
+		WithDetails(fmt.Sprintf("Status: %d, Error: %s", statusCode, providerMessage)).
+		WithContext(map[string]interface{}{"service": service, "status_code": statusCode})
+}
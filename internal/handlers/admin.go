@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/services/jobs"
+)
+
+// CacheFlusherInterface 可按类型清空缓存的服务接口
+type CacheFlusherInterface interface {
+	FlushCache(cacheType string) (map[string]int, error)
+}
+
+// TagEditorInterface 批量修改标签的服务接口
+type TagEditorInterface interface {
+	RetagBulk(ctx context.Context, userID, from, to string) (int, error)
+	DeleteTagBulk(ctx context.Context, userID, tag string) (int, error)
+}
+
+// JobManagerInterface 后台运维任务管理接口
+type JobManagerInterface interface {
+	Start(jobType string) (*jobs.Job, error)
+	Get(id string) (*jobs.Job, error)
+	Cancel(ctx context.Context, id string) (*jobs.Job, error)
+}
+
+// AdminHandler 运维管理API处理器
+type AdminHandler struct {
+	cacheFlushers []CacheFlusherInterface
+	tagEditor     TagEditorInterface  // 批量标签修改（可选）
+	jobManager    JobManagerInterface // 后台任务管理（可选）
+	logger        *logger.Logger
+}
+
+// CacheFlushRequest 清空缓存请求结构
+type CacheFlushRequest struct {
+	CacheType string `json:"cache_type" binding:"required"` // query_vector, recommendation, user_preference, search_result, all
+}
+
+// CacheFlushResponse 清空缓存响应结构
+type CacheFlushResponse struct {
+	Success   bool           `json:"success"`
+	CacheType string         `json:"cache_type"`
+	Cleared   map[string]int `json:"cleared"` // 各类缓存清除的条目数（所有服务合计）
+	FlushedAt time.Time      `json:"flushed_at"`
+}
+
+// TagRenameRequest 批量重命名标签请求结构
+type TagRenameRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	From   string `json:"from" binding:"required"` // 原标签
+	To     string `json:"to" binding:"required"`   // 新标签（文档已有时合并）
+}
+
+// TagDeleteRequest 批量删除标签请求结构
+type TagDeleteRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Tag    string `json:"tag" binding:"required"`
+}
+
+// TagBulkEditResponse 批量修改标签响应结构
+type TagBulkEditResponse struct {
+	Success bool   `json:"success"`
+	UserID  string `json:"user_id"`
+	Tag     string `json:"tag"`               // 被修改的标签
+	NewTag  string `json:"new_tag,omitempty"` // 重命名后的标签（删除时为空）
+	Changed int    `json:"changed"`           // 修改的文档数
+}
+
+// JobResponse 后台任务响应结构
+type JobResponse struct {
+	Success bool      `json:"success"`
+	Job     *jobs.Job `json:"job"`
+}
+
+// NewAdminHandler 创建管理处理器，cacheFlushers为各自持有缓存的服务（搜索引擎、推荐系统、内容处理器）
+func NewAdminHandler(cacheFlushers ...CacheFlusherInterface) *AdminHandler {
+	return &AdminHandler{
+		cacheFlushers: cacheFlushers,
+		logger:        logger.NewLogger("admin-handler"),
+	}
+}
+
+// FlushCache 清空指定类型的缓存
+// @Summary 清空缓存
+// @Description 清空查询向量、推荐、用户偏好或搜索结果缓存（all表示全部），返回清除的条目数（需要管理令牌）
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param request body CacheFlushRequest true "清空缓存请求"
+// @Success 200 {object} CacheFlushResponse "清空成功"
+// @Failure 400 {object} ErrorResponse "缓存类型无效"
+// @Failure 401 {object} ErrorResponse "管理令牌无效"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/admin/cache/flush [post]
+func (h *AdminHandler) FlushCache(c *gin.Context) {
+	if len(h.cacheFlushers) == 0 {
+		h.logger.Error("No cache services are initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Cache services are not available",
+		})
+		return
+	}
+
+	var req CacheFlushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	cleared := make(map[string]int)
+	for _, flusher := range h.cacheFlushers {
+		counts, err := flusher.FlushCache(req.CacheType)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeValidationFailed) {
+				status = http.StatusBadRequest
+			}
+			h.logger.Error("Failed to flush cache", logger.Fields{
+				"cache_type": req.CacheType,
+				"error":      err.Error(),
+			})
+			c.JSON(status, ErrorResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		for cacheType, count := range counts {
+			cleared[cacheType] += count
+		}
+	}
+
+	h.logger.Info("Caches flushed by admin request", logger.Fields{
+		"cache_type": req.CacheType,
+		"cleared":    cleared,
+	})
+
+	c.JSON(http.StatusOK, CacheFlushResponse{
+		Success:   true,
+		CacheType: req.CacheType,
+		Cleared:   cleared,
+		FlushedAt: time.Now(),
+	})
+}
+
+// SetTagEditor 设置批量标签修改服务，未设置时标签管理接口不可用
+func (h *AdminHandler) SetTagEditor(tagEditor TagEditorInterface) {
+	h.tagEditor = tagEditor
+}
+
+// RenameTag 批量重命名用户的标签
+// @Summary 批量重命名标签
+// @Description 将用户所有带有原标签的文档改为新标签（已有新标签的文档合并），同时更新数据库和索引元数据，不重新向量化（需要管理令牌）
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param request body TagRenameRequest true "重命名标签请求"
+// @Success 200 {object} TagBulkEditResponse "修改成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 401 {object} ErrorResponse "管理令牌无效"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/admin/tags/rename [post]
+func (h *AdminHandler) RenameTag(c *gin.Context) {
+	var req TagRenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	if !h.requireTagEditor(c) {
+		return
+	}
+
+	changed, err := h.tagEditor.RetagBulk(c.Request.Context(), req.UserID, req.From, req.To)
+	if err != nil {
+		h.respondTagEditError(c, req.UserID, req.From, changed, err)
+		return
+	}
+
+	h.logger.Info("Tag renamed by admin request", logger.Fields{
+		"user_id": req.UserID,
+		"from":    req.From,
+		"to":      req.To,
+		"changed": changed,
+	})
+
+	c.JSON(http.StatusOK, TagBulkEditResponse{
+		Success: true,
+		UserID:  req.UserID,
+		Tag:     req.From,
+		NewTag:  req.To,
+		Changed: changed,
+	})
+}
+
+// DeleteTag 批量删除用户的标签
+// @Summary 批量删除标签
+// @Description 从用户所有文档中删除指定标签，同时更新数据库和索引元数据（需要管理令牌）
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param request body TagDeleteRequest true "删除标签请求"
+// @Success 200 {object} TagBulkEditResponse "删除成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 401 {object} ErrorResponse "管理令牌无效"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/admin/tags/delete [post]
+func (h *AdminHandler) DeleteTag(c *gin.Context) {
+	var req TagDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	if !h.requireTagEditor(c) {
+		return
+	}
+
+	changed, err := h.tagEditor.DeleteTagBulk(c.Request.Context(), req.UserID, req.Tag)
+	if err != nil {
+		h.respondTagEditError(c, req.UserID, req.Tag, changed, err)
+		return
+	}
+
+	h.logger.Info("Tag deleted by admin request", logger.Fields{
+		"user_id": req.UserID,
+		"tag":     req.Tag,
+		"changed": changed,
+	})
+
+	c.JSON(http.StatusOK, TagBulkEditResponse{
+		Success: true,
+		UserID:  req.UserID,
+		Tag:     req.Tag,
+		Changed: changed,
+	})
+}
+
+// requireTagEditor 检查批量标签修改服务是否可用，不可用时返回错误响应
+func (h *AdminHandler) requireTagEditor(c *gin.Context) bool {
+	if h.tagEditor != nil {
+		return true
+	}
+
+	h.logger.Error("Tag editor service is not initialized")
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Success: false,
+		Message: "Tag editing is not available",
+	})
+	return false
+}
+
+// respondTagEditError 返回批量标签修改的错误响应（参数错误为400，向量库不可用为503，部分修改后失败时在日志中记录已修改数）
+func (h *AdminHandler) respondTagEditError(c *gin.Context, userID, tag string, changed int, err error) {
+	status := http.StatusInternalServerError
+	if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeValidationFailed) {
+		status = http.StatusBadRequest
+	} else if ok && memoErr.IsCode(errors.ErrCodeFeatureUnavailable) {
+		// 向量库不可用时标签管理不可用
+		status = http.StatusServiceUnavailable
+	} else {
+		h.logger.Error("Bulk tag edit failed", logger.Fields{
+			"user_id": userID,
+			"tag":     tag,
+			"changed": changed,
+			"error":   err.Error(),
+		})
+	}
+
+	c.JSON(status, ErrorResponse{
+		Success: false,
+		Message: err.Error(),
+	})
+}
+
+// SetJobManager 设置后台任务管理器，未设置时任务接口不可用
+func (h *AdminHandler) SetJobManager(jobManager JobManagerInterface) {
+	h.jobManager = jobManager
+}
+
+// StartJob 启动后台运维任务
+// @Summary 启动后台任务
+// @Description 启动指定类型的长时间运维任务（如archive），立即返回任务ID，通过任务状态接口查询进度；同类型任务同时只能执行一个（需要管理令牌）
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param type path string true "任务类型"
+// @Success 202 {object} JobResponse "任务已启动"
+// @Failure 401 {object} ErrorResponse "管理令牌无效"
+// @Failure 404 {object} ErrorResponse "任务类型不存在"
+// @Failure 409 {object} ErrorResponse "同类型任务正在执行"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/admin/jobs/{type} [post]
+func (h *AdminHandler) StartJob(c *gin.Context) {
+	if !h.requireJobManager(c) {
+		return
+	}
+
+	job, err := h.jobManager.Start(c.Param("type"))
+	if err != nil {
+		h.respondJobError(c, err)
+		return
+	}
+
+	h.logger.Info("Job started by admin request", logger.Fields{
+		"job_id": job.ID,
+		"type":   job.Type,
+	})
+
+	c.JSON(http.StatusAccepted, JobResponse{Success: true, Job: job})
+}
+
+// GetJob 查询后台任务状态
+// @Summary 查询后台任务
+// @Description 获取任务的状态、进度百分比、错误信息和结果（需要管理令牌）
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path string true "任务ID"
+// @Success 200 {object} JobResponse "任务状态"
+// @Failure 401 {object} ErrorResponse "管理令牌无效"
+// @Failure 404 {object} ErrorResponse "任务不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/admin/jobs/{id} [get]
+func (h *AdminHandler) GetJob(c *gin.Context) {
+	if !h.requireJobManager(c) {
+		return
+	}
+
+	job, err := h.jobManager.Get(c.Param("id"))
+	if err != nil {
+		h.respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, JobResponse{Success: true, Job: job})
+}
+
+// CancelJob 取消后台任务
+// @Summary 取消后台任务
+// @Description 取消执行中的任务并等待其退出，任务已结束时返回其最终状态（需要管理令牌）
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path string true "任务ID"
+// @Success 200 {object} JobResponse "取消后的任务状态"
+// @Failure 401 {object} ErrorResponse "管理令牌无效"
+// @Failure 404 {object} ErrorResponse "任务不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/admin/jobs/{id} [delete]
+func (h *AdminHandler) CancelJob(c *gin.Context) {
+	if !h.requireJobManager(c) {
+		return
+	}
+
+	job, err := h.jobManager.Cancel(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondJobError(c, err)
+		return
+	}
+
+	h.logger.Info("Job cancelled by admin request", logger.Fields{
+		"job_id": job.ID,
+		"type":   job.Type,
+		"status": string(job.Status),
+	})
+
+	c.JSON(http.StatusOK, JobResponse{Success: true, Job: job})
+}
+
+// requireJobManager 检查后台任务管理器是否可用，不可用时返回错误响应
+func (h *AdminHandler) requireJobManager(c *gin.Context) bool {
+	if h.jobManager != nil {
+		return true
+	}
+
+	h.logger.Error("Job manager is not initialized")
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Success: false,
+		Message: "Background jobs are not available",
+	})
+	return false
+}
+
+// respondJobError 返回任务接口的错误响应（不存在为404，同类型任务执行中为409）
+func (h *AdminHandler) respondJobError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if memoErr, ok := err.(*errors.MemoroError); ok {
+		switch memoErr.Code {
+		case errors.ErrCodeResourceNotFound:
+			status = http.StatusNotFound
+		case errors.ErrCodeDuplicateResource:
+			status = http.StatusConflict
+		}
+	}
+	if status == http.StatusInternalServerError {
+		h.logger.Error("Job request failed", logger.Fields{
+			"error": err.Error(),
+		})
+	}
+
+	c.JSON(status, ErrorResponse{
+		Success: false,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/errors"
+	"memoro/internal/services/jobs"
+)
+
+// MockCacheFlusher 模拟持有缓存的服务（用于测试）
+type MockCacheFlusher struct {
+	Cleared map[string]int
+	Err     error
+	flushed []string
+}
+
+func (m *MockCacheFlusher) FlushCache(cacheType string) (map[string]int, error) {
+	m.flushed = append(m.flushed, cacheType)
+	return m.Cleared, m.Err
+}
+
+// MockTagEditor 模拟批量标签修改服务（用于测试）
+type MockTagEditor struct {
+	Changed int
+	Err     error
+	calls   []string
+}
+
+func (m *MockTagEditor) RetagBulk(ctx context.Context, userID, from, to string) (int, error) {
+	m.calls = append(m.calls, "rename:"+userID+":"+from+":"+to)
+	return m.Changed, m.Err
+}
+
+func (m *MockTagEditor) DeleteTagBulk(ctx context.Context, userID, tag string) (int, error) {
+	m.calls = append(m.calls, "delete:"+userID+":"+tag)
+	return m.Changed, m.Err
+}
+
+// newAdminTestRouter 创建带管理令牌鉴权的管理路由
+func newAdminTestRouter(handler *AdminHandler, token string) *gin.Engine {
+	router := gin.New()
+	group := router.Group("/api/v1/admin", AdminAuth(token))
+	group.POST("/cache/flush", handler.FlushCache)
+	group.POST("/tags/rename", handler.RenameTag)
+	group.POST("/tags/delete", handler.DeleteTag)
+	group.POST("/jobs/:type", handler.StartJob)
+	group.GET("/jobs/:id", handler.GetJob)
+	group.DELETE("/jobs/:id", handler.CancelJob)
+	return router
+}
+
+// TestAdminHandler_FlushCache 测试清空缓存API
+func TestAdminHandler_FlushCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	flush := func(router *gin.Engine, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/cache/flush", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(AdminTokenHeader, token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("合计各服务清除的条目数", func(t *testing.T) {
+		engine := &MockCacheFlusher{Cleared: map[string]int{"query_vector": 3}}
+		recommender := &MockCacheFlusher{Cleared: map[string]int{"query_vector": 2}}
+		router := newAdminTestRouter(NewAdminHandler(engine, recommender), "secret")
+
+		w := flush(router, "secret", `{"cache_type":"query_vector"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response CacheFlushResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, "query_vector", response.CacheType)
+		assert.Equal(t, map[string]int{"query_vector": 5}, response.Cleared)
+		assert.Equal(t, []string{"query_vector"}, engine.flushed)
+		assert.Equal(t, []string{"query_vector"}, recommender.flushed)
+	})
+
+	t.Run("需要管理令牌", func(t *testing.T) {
+		engine := &MockCacheFlusher{Cleared: map[string]int{}}
+		router := newAdminTestRouter(NewAdminHandler(engine), "secret")
+
+		w := flush(router, "", `{"cache_type":"all"}`)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, engine.flushed)
+	})
+
+	t.Run("无效的缓存类型返回400", func(t *testing.T) {
+		engine := &MockCacheFlusher{Err: errors.ErrValidationFailed("cache_type", "must be one of: query_vector, recommendation, user_preference, search_result, all")}
+		router := newAdminTestRouter(NewAdminHandler(engine), "secret")
+
+		assert.Equal(t, http.StatusBadRequest, flush(router, "secret", `{"cache_type":"embeddings"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, flush(router, "secret", `{}`).Code)
+	})
+
+	t.Run("没有可用的缓存服务", func(t *testing.T) {
+		router := newAdminTestRouter(NewAdminHandler(), "secret")
+
+		assert.Equal(t, http.StatusInternalServerError, flush(router, "secret", `{"cache_type":"all"}`).Code)
+	})
+}
+
+// TestAdminHandler_BulkTagEdit 测试批量重命名和删除标签API
+func TestAdminHandler_BulkTagEdit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	post := func(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/tags/"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(AdminTokenHeader, "secret")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("重命名标签返回修改数", func(t *testing.T) {
+		editor := &MockTagEditor{Changed: 3}
+		handler := NewAdminHandler()
+		handler.SetTagEditor(editor)
+		router := newAdminTestRouter(handler, "secret")
+
+		w := post(router, "rename", `{"user_id":"user-1","from":"ml","to":"machine-learning"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response TagBulkEditResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, "ml", response.Tag)
+		assert.Equal(t, "machine-learning", response.NewTag)
+		assert.Equal(t, 3, response.Changed)
+		assert.Equal(t, []string{"rename:user-1:ml:machine-learning"}, editor.calls)
+	})
+
+	t.Run("删除标签", func(t *testing.T) {
+		editor := &MockTagEditor{Changed: 2}
+		handler := NewAdminHandler()
+		handler.SetTagEditor(editor)
+		router := newAdminTestRouter(handler, "secret")
+
+		w := post(router, "delete", `{"user_id":"user-1","tag":"draft"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"delete:user-1:draft"}, editor.calls)
+	})
+
+	t.Run("参数错误返回400", func(t *testing.T) {
+		editor := &MockTagEditor{Err: errors.ErrValidationFailed("to", "must differ from the tag being renamed")}
+		handler := NewAdminHandler()
+		handler.SetTagEditor(editor)
+		router := newAdminTestRouter(handler, "secret")
+
+		assert.Equal(t, http.StatusBadRequest, post(router, "rename", `{"user_id":"user-1","from":"ml","to":"ml"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, post(router, "rename", `{"user_id":"user-1","from":"ml"}`).Code)
+	})
+
+	t.Run("未配置标签服务", func(t *testing.T) {
+		router := newAdminTestRouter(NewAdminHandler(), "secret")
+
+		assert.Equal(t, http.StatusInternalServerError, post(router, "delete", `{"user_id":"user-1","tag":"draft"}`).Code)
+	})
+}
+
+// TestAdminHandler_Jobs 测试后台任务的启动、进度查询和取消API
+func TestAdminHandler_Jobs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	request := func(router *gin.Engine, method, path string) (*httptest.ResponseRecorder, *JobResponse) {
+		req := httptest.NewRequest(method, "/api/v1/admin/jobs/"+path, nil)
+		req.Header.Set(AdminTokenHeader, "secret")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response JobResponse
+		if w.Code < http.StatusBadRequest {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w, &response
+	}
+
+	// 模拟长任务：上报一半进度后等待取消
+	manager := jobs.NewJobManager()
+	defer manager.Close(context.Background())
+	require.NoError(t, manager.Register("reindex", func(ctx context.Context, progress jobs.ProgressFunc) (interface{}, error) {
+		progress(50)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}))
+	handler := NewAdminHandler()
+	handler.SetJobManager(manager)
+	router := newAdminTestRouter(handler, "secret")
+
+	w, started := request(router, http.MethodPost, "reindex")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NotNil(t, started.Job)
+	assert.Equal(t, "reindex", started.Job.Type)
+	jobID := started.Job.ID
+
+	t.Run("轮询任务进度", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			w, polled := request(router, http.MethodGet, jobID)
+			return w.Code == http.StatusOK && polled.Job.Progress == 50
+		}, 2*time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("同类型任务执行中返回409", func(t *testing.T) {
+		w, _ := request(router, http.MethodPost, "reindex")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("取消任务", func(t *testing.T) {
+		w, cancelled := request(router, http.MethodDelete, jobID)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, jobs.JobStatusCancelled, cancelled.Job.Status)
+
+		_, polled := request(router, http.MethodGet, jobID)
+		assert.Equal(t, jobs.JobStatusCancelled, polled.Job.Status)
+		assert.NotNil(t, polled.Job.FinishedAt)
+	})
+
+	t.Run("未知任务类型和任务ID返回404", func(t *testing.T) {
+		w, _ := request(router, http.MethodPost, "unknown")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w, _ = request(router, http.MethodGet, "missing")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("未配置任务管理器", func(t *testing.T) {
+		w, _ := request(newAdminTestRouter(NewAdminHandler(), "secret"), http.MethodPost, "reindex")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenHeader 管理接口令牌请求头（也可使用 Authorization: Bearer <token>）
+const AdminTokenHeader = "X-Admin-Token"
+
+// AdminAuth 管理接口鉴权中间件，未配置令牌时拒绝所有请求
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Message: "Admin API is disabled: server.admin_token is not configured",
+			})
+			return
+		}
+
+		provided := c.GetHeader(AdminTokenHeader)
+		if provided == "" {
+			provided = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+				Success: false,
+				Message: "Invalid or missing admin token",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,843 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/content"
+	"memoro/internal/services/llm"
+	"memoro/internal/storage"
+)
+
+// ContentHandler 内容API处理器
+type ContentHandler struct {
+	contentService ContentServiceInterface
+	fileStore      FileStoreInterface
+	logger         *logger.Logger
+}
+
+// ContentServiceInterface 内容服务接口
+type ContentServiceInterface interface {
+	GetContent(ctx context.Context, id string, userID string) (*content.ContentDetail, error)
+	PatchContent(ctx context.Context, id string, userID string, patch *content.ContentMetadataPatch) (*content.ContentDetail, error)
+	RefreshContent(ctx context.Context, id string, userID string, options content.RefreshOptions) (*content.ContentDetail, error)
+	GetSummary(ctx context.Context, id string, userID string, level content.SummaryLevel) (*models.Summary, error)
+	ValidateContent(ctx context.Context, request *content.ProcessingRequest) (*content.ValidationVerdict, error)
+	ListRecent(ctx context.Context, userID string, limit int, before time.Time) (*content.TimelinePage, error)
+	SubscribeEvents(requestID string) (<-chan *content.ProcessingEvent, func(), error)
+	ProcessContentAsync(request *content.ProcessingRequest) error
+}
+
+// FileStoreInterface 上传文件存储接口
+type FileStoreInterface interface {
+	MaxFileSize() int64
+	IsAllowed(mimeType, filename string) bool
+	Save(filename string, data []byte) (string, error)
+	Remove(path string) error
+}
+
+// ContentResponse 内容详情响应结构
+type ContentResponse struct {
+	Success bool                   `json:"success"`
+	Content *content.ContentDetail `json:"content,omitempty"`
+}
+
+// ContentPatchRequest 内容补丁请求结构：只包含需要修改的字段，未出现的字段保持不变
+type ContentPatchRequest struct {
+	Tags            []string `json:"tags,omitempty"`             // 新的标签列表（空列表表示清除标签）
+	ImportanceScore *float64 `json:"importance_score,omitempty"` // 新的重要性评分(0-1)
+	Pinned          *bool    `json:"pinned,omitempty"`           // 是否置顶
+}
+
+// SummaryResponse 内容摘要响应结构
+type SummaryResponse struct {
+	Success   bool            `json:"success"`
+	ContentID string          `json:"content_id"`
+	Level     string          `json:"level,omitempty"` // 请求的摘要级别，为空表示全部级别
+	Summary   *models.Summary `json:"summary,omitempty"`
+}
+
+// ContentValidationRequest 内容预检请求结构
+type ContentValidationRequest struct {
+	Content     string `json:"content"`
+	ContentType string `json:"content_type"`
+	UserID      string `json:"user_id"`
+}
+
+// ContentValidationResponse 内容预检响应结构
+type ContentValidationResponse struct {
+	Success bool                       `json:"success"`
+	Verdict *content.ValidationVerdict `json:"verdict,omitempty"`
+}
+
+// TimelineResponse 内容时间线响应结构
+type TimelineResponse struct {
+	Success    bool                     `json:"success"`
+	Items      []*content.ContentDetail `json:"items"`
+	HasMore    bool                     `json:"has_more"`
+	NextBefore *time.Time               `json:"next_before,omitempty"`
+}
+
+// ContentUploadResponse 文件上传响应结构
+type ContentUploadResponse struct {
+	Success     bool               `json:"success"`
+	RequestID   string             `json:"request_id"`   // 处理请求ID，可用于订阅处理事件
+	ContentType models.ContentType `json:"content_type"` // 提交处理的内容类型
+	MimeType    string             `json:"mime_type"`    // 检测到的文件MIME类型
+	Filename    string             `json:"filename"`
+	Size        int64              `json:"size"`
+}
+
+// NewContentHandler 创建内容处理器
+func NewContentHandler(contentService ContentServiceInterface) *ContentHandler {
+	return &ContentHandler{
+		contentService: contentService,
+		logger:         logger.NewLogger("content-handler"),
+	}
+}
+
+// SetFileStore 设置上传文件存储，未设置时文件上传接口不可用
+func (h *ContentHandler) SetFileStore(fileStore FileStoreInterface) {
+	h.fileStore = fileStore
+}
+
+// GetContent 获取单个内容项详情
+// @Summary 获取内容详情
+// @Description 获取已处理内容的原文、摘要、标签、重要性和向量索引状态
+// @Tags content
+// @Produce json
+// @Param id path string true "内容ID"
+// @Param user_id query string false "用户ID，指定时仅返回该用户的内容"
+// @Success 200 {object} ContentResponse "获取成功"
+// @Failure 404 {object} ErrorResponse "内容不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/content/{id} [get]
+func (h *ContentHandler) GetContent(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.Query("user_id")
+
+	if h.contentService == nil {
+		h.logger.Error("Content service is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Content service is not available",
+		})
+		return
+	}
+
+	detail, err := h.contentService.GetContent(c.Request.Context(), id, userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if memoErr, ok := err.(*errors.MemoroError); ok {
+			switch memoErr.Code {
+			case errors.ErrCodeResourceNotFound:
+				status = http.StatusNotFound
+			case errors.ErrCodeValidationFailed:
+				status = http.StatusBadRequest
+			}
+		}
+
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to get content", logger.Fields{
+				"content_id": id,
+				"user_id":    userID,
+				"error":      err.Error(),
+			})
+		}
+
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ContentResponse{
+		Success: true,
+		Content: detail,
+	})
+}
+
+// PatchContent 部分更新内容项
+// @Summary 部分更新内容
+// @Description 只修改请求中出现的字段（tags、importance_score、pinned），同时更新数据库和向量元数据，不重新向量化，返回修改后的内容详情
+// @Tags content
+// @Accept json
+// @Produce json
+// @Param id path string true "内容ID"
+// @Param user_id query string false "用户ID，指定时仅能修改该用户的内容"
+// @Param request body ContentPatchRequest true "需要修改的字段"
+// @Success 200 {object} ContentResponse "更新成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "内容不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/content/{id} [patch]
+func (h *ContentHandler) PatchContent(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.Query("user_id")
+
+	// 只接受可修改的字段，拼错或不可修改的字段直接拒绝而不是静默忽略
+	var req ContentPatchRequest
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	if h.contentService == nil {
+		h.logger.Error("Content service is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Content service is not available",
+		})
+		return
+	}
+
+	detail, err := h.contentService.PatchContent(c.Request.Context(), id, userID, &content.ContentMetadataPatch{
+		Tags:            req.Tags,
+		ImportanceScore: req.ImportanceScore,
+		Pinned:          req.Pinned,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if memoErr, ok := err.(*errors.MemoroError); ok {
+			switch memoErr.Code {
+			case errors.ErrCodeResourceNotFound:
+				status = http.StatusNotFound
+			case errors.ErrCodeValidationFailed:
+				status = http.StatusBadRequest
+			}
+		}
+
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to patch content", logger.Fields{
+				"content_id": id,
+				"user_id":    userID,
+				"error":      err.Error(),
+			})
+		}
+
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ContentResponse{
+		Success: true,
+		Content: detail,
+	})
+}
+
+// ContentRefreshRequest 重新生成摘要和标签的请求结构，字段均可省略
+type ContentRefreshRequest struct {
+	Style string `json:"style,omitempty"` // 摘要风格: concise, detailed, bullet, technical，为空时使用默认风格
+	Model string `json:"model,omitempty"` // 使用的对话模型，须为配置允许的模型，为空时使用默认模型
+}
+
+// RefreshContent 重新生成内容的摘要和标签
+// @Summary 重新生成摘要和标签
+// @Description 对已保存的原始内容重新生成摘要和标签（可指定摘要风格和模型），更新数据库和索引元数据，不重新向量化；用户提供的标签保留
+// @Tags content
+// @Accept json
+// @Produce json
+// @Param id path string true "内容ID"
+// @Param user_id query string false "用户ID，指定时仅刷新该用户的内容"
+// @Param request body ContentRefreshRequest false "摘要风格和模型"
+// @Success 200 {object} ContentResponse "刷新成功，返回新的摘要和标签"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "内容不存在"
+// @Failure 429 {object} ErrorResponse "超出token额度或模型服务限流"
+// @Failure 502 {object} ErrorResponse "模型服务调用失败"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/content/{id}/refresh [post]
+func (h *ContentHandler) RefreshContent(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.Query("user_id")
+
+	// 请求体可省略，省略时使用默认风格和模型
+	var req ContentRefreshRequest
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil && !stderrors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	if h.contentService == nil {
+		h.logger.Error("Content service is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Content service is not available",
+		})
+		return
+	}
+
+	detail, err := h.contentService.RefreshContent(c.Request.Context(), id, userID, content.RefreshOptions{
+		Style: llm.SummaryStyle(req.Style),
+		Model: req.Model,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if memoErr, ok := err.(*errors.MemoroError); ok {
+			switch memoErr.Code {
+			case errors.ErrCodeResourceNotFound:
+				status = http.StatusNotFound
+			case errors.ErrCodeValidationFailed:
+				status = http.StatusBadRequest
+			case errors.ErrCodeLLMAPICall, errors.ErrCodeLLMAuth, errors.ErrCodeLLMInvalidRequest, errors.ErrCodeLLMServerError:
+				status = http.StatusBadGateway
+			}
+		}
+		if isThrottled(err) {
+			status = http.StatusTooManyRequests
+		}
+
+		if status == http.StatusInternalServerError || status == http.StatusBadGateway {
+			h.logger.Error("Failed to refresh content", logger.Fields{
+				"content_id": id,
+				"user_id":    userID,
+				"error":      err.Error(),
+			})
+		}
+
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ContentResponse{
+		Success: true,
+		Content: detail,
+	})
+}
+
+// GetSummary 获取内容项已保存的多级摘要
+// @Summary 获取内容摘要
+// @Description 获取内容的一句话、段落和详细摘要，可通过level只获取其中一级，便于界面按需逐级展开
+// @Tags content
+// @Produce json
+// @Param id path string true "内容ID"
+// @Param level query string false "摘要级别：one_line、paragraph、detailed，为空时返回全部级别"
+// @Param user_id query string false "用户ID，指定时仅返回该用户的内容"
+// @Success 200 {object} SummaryResponse "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "内容或摘要不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/content/{id}/summary [get]
+func (h *ContentHandler) GetSummary(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.Query("user_id")
+	level := c.Query("level")
+
+	if h.contentService == nil {
+		h.logger.Error("Content service is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Content service is not available",
+		})
+		return
+	}
+
+	summary, err := h.contentService.GetSummary(c.Request.Context(), id, userID, content.SummaryLevel(level))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if memoErr, ok := err.(*errors.MemoroError); ok {
+			switch memoErr.Code {
+			case errors.ErrCodeResourceNotFound:
+				status = http.StatusNotFound
+			case errors.ErrCodeValidationFailed:
+				status = http.StatusBadRequest
+			}
+		}
+
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to get content summary", logger.Fields{
+				"content_id": id,
+				"user_id":    userID,
+				"level":      level,
+				"error":      err.Error(),
+			})
+		}
+
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SummaryResponse{
+		Success:   true,
+		ContentID: id,
+		Level:     level,
+		Summary:   summary,
+	})
+}
+
+// StreamEvents 以SSE推送处理请求的状态变化和阶段完成事件
+// @Summary 处理进度事件流
+// @Description 以Server-Sent Events推送异步处理请求的当前状态、状态变化（pending→processing→completed）和阶段完成事件（extract、classify、summarize、tag、vectorize），请求结束后关闭连接
+// @Tags content
+// @Produce text/event-stream
+// @Param id path string true "处理请求ID"
+// @Success 200 {object} content.ProcessingEvent "事件流（event为status或stage）"
+// @Failure 404 {object} ErrorResponse "请求不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/content/{id}/events [get]
+func (h *ContentHandler) StreamEvents(c *gin.Context) {
+	requestID := c.Param("id")
+
+	if h.contentService == nil {
+		h.logger.Error("Content service is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Content service is not available",
+		})
+		return
+	}
+
+	events, unsubscribe, err := h.contentService.SubscribeEvents(requestID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeResourceNotFound) {
+			status = http.StatusNotFound
+		} else {
+			h.logger.Error("Failed to subscribe processing events", logger.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			})
+		}
+
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.Status(http.StatusOK)
+
+	// 不使用c.Stream：它依赖已废弃的CloseNotifier，这里以请求上下文感知客户端断开
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			c.SSEvent(string(event.Type), event)
+			c.Writer.Flush()
+			if event.Terminal() {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// ValidateContent 预检内容是否会被接受处理
+// @Summary 内容预检
+// @Description 执行与内容处理相同的校验（大小、长度、类型和token额度），返回结构化结论，不进入处理队列也不消耗LLM额度
+// @Tags content
+// @Accept json
+// @Produce json
+// @Param request body ContentValidationRequest true "预检请求"
+// @Success 200 {object} ContentValidationResponse "预检完成（是否通过见verdict.accepted）"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/content/validate [post]
+func (h *ContentHandler) ValidateContent(c *gin.Context) {
+	var req ContentValidationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	if h.contentService == nil {
+		h.logger.Error("Content service is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Content service is not available",
+		})
+		return
+	}
+
+	contentType := models.ContentType(req.ContentType)
+	if contentType == "" {
+		contentType = models.ContentTypeText
+	}
+
+	verdict, err := h.contentService.ValidateContent(c.Request.Context(), &content.ProcessingRequest{
+		Content:     req.Content,
+		ContentType: contentType,
+		UserID:      req.UserID,
+	})
+	if err != nil {
+		h.logger.Error("Failed to validate content", logger.Fields{
+			"user_id": req.UserID,
+			"error":   err.Error(),
+		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ContentValidationResponse{
+		Success: true,
+		Verdict: verdict,
+	})
+}
+
+// uploadFormOverhead 请求体大小上限中为表单字段和multipart边界预留的空间
+const uploadFormOverhead = 1 << 20
+
+// UploadContent 上传文件并提交异步处理
+// @Summary 上传文件
+// @Description 接收multipart文件上传，检测文件类型并按存储配置检查大小和类型，保存文件后提交异步处理；文本文件按内容处理，图片按图片、其他文件按文件处理并引用保存的文件
+// @Tags content
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "上传的文件"
+// @Param user_id formData string false "用户ID"
+// @Param sensitive formData bool false "敏感内容，保存时加密原始内容且不保留原始文件（需要配置静态加密，只支持文本文件）"
+// @Param thread_id formData string false "会话线程ID，同一线程的内容可折叠搜索并汇总为线程文档"
+// @Success 202 {object} ContentUploadResponse "已提交处理"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 413 {object} ErrorResponse "文件超过大小上限"
+// @Failure 415 {object} ErrorResponse "文件类型不允许上传"
+// @Failure 429 {object} ErrorResponse "超出额度、提交过于频繁或处理队列已满"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/content/upload [post]
+func (h *ContentHandler) UploadContent(c *gin.Context) {
+	if h.contentService == nil || h.fileStore == nil {
+		h.logger.Error("Content upload is not configured")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Content upload is not available",
+		})
+		return
+	}
+
+	maxSize := h.fileStore.MaxFileSize()
+	tooLarge := func() {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Success: false,
+			Message: fmt.Sprintf("File too large (max %d bytes)", maxSize),
+		})
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+uploadFormOverhead)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			tooLarge()
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid upload, multipart field file is required: " + err.Error(),
+		})
+		return
+	}
+	if fileHeader.Size > maxSize {
+		tooLarge()
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Failed to read uploaded file: " + err.Error(),
+		})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	file.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Failed to read uploaded file: " + err.Error(),
+		})
+		return
+	}
+	if int64(len(data)) > maxSize {
+		tooLarge()
+		return
+	}
+
+	filename := fileHeader.Filename
+	mimeType := storage.DetectMIMEType(filename, data)
+	if !h.fileStore.IsAllowed(mimeType, filename) {
+		c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+			Success: false,
+			Message: fmt.Sprintf("File type %s is not allowed", mimeType),
+		})
+		return
+	}
+
+	sensitive := false
+	if value := c.PostForm("sensitive"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid sensitive value, expected a boolean: " + value,
+			})
+			return
+		}
+		sensitive = parsed
+	}
+
+	contentType := uploadContentType(mimeType, data)
+	if sensitive && contentType != models.ContentTypeText {
+		// 敏感内容只加密保存在数据库中，非文本文件需要保留原始文件才能处理
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: fmt.Sprintf("Sensitive uploads must be text files, got %s", mimeType),
+		})
+		return
+	}
+
+	// 敏感内容不在存储目录保留未加密的原始文件
+	var path string
+	if !sensitive {
+		path, err = h.fileStore.Save(filename, data)
+		if err != nil {
+			h.logger.Error("Failed to store uploaded file", logger.Fields{
+				"filename": filename,
+				"error":    err.Error(),
+			})
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Message: "Failed to store uploaded file",
+			})
+			return
+		}
+	}
+
+	// 文本文件按内容处理，其他文件以原始文件名、类型和大小为内容交给对应的提取器，并在元数据中引用保存的文件；
+	// 只记录存储目录下的文件名，不暴露服务器上的存储路径
+	request := &content.ProcessingRequest{
+		ID:          uuid.New().String(),
+		Content:     string(data),
+		ContentType: contentType,
+		UserID:      c.PostForm("user_id"),
+		Context: map[string]interface{}{
+			content.TitleContextKey: filename,
+		},
+		Metadata: map[string]interface{}{
+			"source":           "upload",
+			"upload_filename":  filename,
+			"upload_mime_type": mimeType,
+		},
+		Sensitive: sensitive,
+		ThreadID:  strings.TrimSpace(c.PostForm("thread_id")),
+	}
+	if path != "" {
+		request.Metadata[content.StoredFileMetadataKey] = filepath.Base(path)
+		if contentType != models.ContentTypeText {
+			request.Content = content.StoredFileContent(filename, mimeType, len(data))
+		}
+	}
+
+	if err := h.contentService.ProcessContentAsync(request); err != nil {
+		if path != "" {
+			if removeErr := h.fileStore.Remove(path); removeErr != nil {
+				h.logger.Warn("Failed to remove rejected upload", logger.Fields{
+					"path":  path,
+					"error": removeErr.Error(),
+				})
+			}
+		}
+
+		status := http.StatusInternalServerError
+		if memoErr, ok := err.(*errors.MemoroError); ok {
+			switch memoErr.Code {
+			case errors.ErrCodeValidationFailed:
+				status = http.StatusBadRequest
+			}
+		}
+		if isThrottled(err) {
+			status = http.StatusTooManyRequests
+		}
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to submit uploaded file", logger.Fields{
+				"filename": filename,
+				"error":    err.Error(),
+			})
+		}
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.logger.Info("Uploaded file submitted for processing", logger.Fields{
+		"request_id":   request.ID,
+		"filename":     filename,
+		"mime_type":    mimeType,
+		"content_type": string(contentType),
+		"size":         len(data),
+	})
+
+	c.JSON(http.StatusAccepted, ContentUploadResponse{
+		Success:     true,
+		RequestID:   request.ID,
+		ContentType: contentType,
+		MimeType:    mimeType,
+		Filename:    filename,
+		Size:        int64(len(data)),
+	})
+}
+
+// textUploadTypes 按文本提取内容的非text/*类型
+var textUploadTypes = map[string]bool{
+	"application/json":       true,
+	"application/xml":        true,
+	"application/yaml":       true,
+	"application/x-yaml":     true,
+	"application/javascript": true,
+}
+
+// isTextUpload 检查上传文件能否按文本提取内容（文本类MIME类型且为有效的UTF-8）
+func isTextUpload(mimeType string, data []byte) bool {
+	return (strings.HasPrefix(mimeType, "text/") || textUploadTypes[mimeType]) && utf8.Valid(data)
+}
+
+// uploadContentType 根据MIME类型确定提交处理的内容类型（音视频等没有专用提取器的按文件处理）
+func uploadContentType(mimeType string, data []byte) models.ContentType {
+	switch {
+	case isTextUpload(mimeType, data):
+		return models.ContentTypeText
+	case strings.HasPrefix(mimeType, "image/"):
+		return models.ContentTypeImage
+	default:
+		return models.ContentTypeFile
+	}
+}
+
+// ListTimeline 按时间倒序列出用户的内容
+// @Summary 内容时间线
+// @Description 按创建时间倒序列出用户的内容，使用before游标分页（不经过语义搜索）
+// @Tags content
+// @Produce json
+// @Param user_id query string true "用户ID"
+// @Param before query string false "游标（RFC3339时间），只返回早于该时间的内容，取上一页的next_before"
+// @Param limit query int false "每页数量，默认20，最大100"
+// @Success 200 {object} TimelineResponse "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/timeline [get]
+func (h *ContentHandler) ListTimeline(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "user_id is required",
+		})
+		return
+	}
+
+	var before time.Time
+	if value := c.Query("before"); value != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid before cursor, expected RFC3339 time: " + value,
+			})
+			return
+		}
+		before = parsed
+	}
+
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid limit: " + value,
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	if h.contentService == nil {
+		h.logger.Error("Content service is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Content service is not available",
+		})
+		return
+	}
+
+	page, err := h.contentService.ListRecent(c.Request.Context(), userID, limit, before)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeValidationFailed) {
+			status = http.StatusBadRequest
+		}
+
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to list timeline", logger.Fields{
+				"user_id": userID,
+				"error":   err.Error(),
+			})
+		}
+
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, TimelineResponse{
+		Success:    true,
+		Items:      page.Items,
+		HasMore:    page.HasMore,
+		NextBefore: page.NextBefore,
+	})
+}
@@ -0,0 +1,791 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/models"
+	"memoro/internal/services/content"
+	"memoro/internal/services/llm"
+	"memoro/internal/storage"
+)
+
+// MockContentService 模拟内容服务（用于测试）
+type MockContentService struct {
+	GetContentFunc          func(ctx context.Context, id string, userID string) (*content.ContentDetail, error)
+	PatchContentFunc        func(ctx context.Context, id string, userID string, patch *content.ContentMetadataPatch) (*content.ContentDetail, error)
+	RefreshContentFunc      func(ctx context.Context, id string, userID string, options content.RefreshOptions) (*content.ContentDetail, error)
+	GetSummaryFunc          func(ctx context.Context, id string, userID string, level content.SummaryLevel) (*models.Summary, error)
+	ValidateContentFunc     func(ctx context.Context, request *content.ProcessingRequest) (*content.ValidationVerdict, error)
+	ListRecentFunc          func(ctx context.Context, userID string, limit int, before time.Time) (*content.TimelinePage, error)
+	SubscribeEventsFunc     func(requestID string) (<-chan *content.ProcessingEvent, func(), error)
+	ProcessContentAsyncFunc func(request *content.ProcessingRequest) error
+}
+
+func (m *MockContentService) GetContent(ctx context.Context, id string, userID string) (*content.ContentDetail, error) {
+	if m.GetContentFunc != nil {
+		return m.GetContentFunc(ctx, id, userID)
+	}
+	return nil, nil
+}
+
+func (m *MockContentService) PatchContent(ctx context.Context, id string, userID string, patch *content.ContentMetadataPatch) (*content.ContentDetail, error) {
+	if m.PatchContentFunc != nil {
+		return m.PatchContentFunc(ctx, id, userID, patch)
+	}
+	return nil, errors.ErrResourceNotFound("content", id)
+}
+
+func (m *MockContentService) RefreshContent(ctx context.Context, id string, userID string, options content.RefreshOptions) (*content.ContentDetail, error) {
+	if m.RefreshContentFunc != nil {
+		return m.RefreshContentFunc(ctx, id, userID, options)
+	}
+	return nil, errors.ErrResourceNotFound("content", id)
+}
+
+func (m *MockContentService) GetSummary(ctx context.Context, id string, userID string, level content.SummaryLevel) (*models.Summary, error) {
+	if m.GetSummaryFunc != nil {
+		return m.GetSummaryFunc(ctx, id, userID, level)
+	}
+	return nil, errors.ErrResourceNotFound("summary", id)
+}
+
+func (m *MockContentService) ValidateContent(ctx context.Context, request *content.ProcessingRequest) (*content.ValidationVerdict, error) {
+	if m.ValidateContentFunc != nil {
+		return m.ValidateContentFunc(ctx, request)
+	}
+	return &content.ValidationVerdict{Accepted: true}, nil
+}
+
+func (m *MockContentService) ListRecent(ctx context.Context, userID string, limit int, before time.Time) (*content.TimelinePage, error) {
+	if m.ListRecentFunc != nil {
+		return m.ListRecentFunc(ctx, userID, limit, before)
+	}
+	return &content.TimelinePage{}, nil
+}
+
+func (m *MockContentService) SubscribeEvents(requestID string) (<-chan *content.ProcessingEvent, func(), error) {
+	if m.SubscribeEventsFunc != nil {
+		return m.SubscribeEventsFunc(requestID)
+	}
+	return nil, nil, errors.ErrResourceNotFound("processing_request", requestID)
+}
+
+func (m *MockContentService) ProcessContentAsync(request *content.ProcessingRequest) error {
+	if m.ProcessContentAsyncFunc != nil {
+		return m.ProcessContentAsyncFunc(request)
+	}
+	return nil
+}
+
+// TestContentHandler_GetContent 测试获取内容详情API
+func TestContentHandler_GetContent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &MockContentService{
+		GetContentFunc: func(ctx context.Context, id string, userID string) (*content.ContentDetail, error) {
+			if id != "content-1" || (userID != "" && userID != "user-1") {
+				return nil, errors.ErrResourceNotFound("content", id)
+			}
+			return &content.ContentDetail{
+				ID:              "content-1",
+				Type:            models.ContentTypeText,
+				RawContent:      "Go语言并发编程实践",
+				UserID:          "user-1",
+				Summary:         models.Summary{OneLine: "Go并发"},
+				Tags:            []string{"go", "并发"},
+				ImportanceScore: 0.8,
+				Vector:          &content.VectorStatus{Indexed: true, VectorDimension: 1536},
+			}, nil
+		},
+	}
+
+	router := gin.New()
+	router.GET("/api/v1/content/:id", NewContentHandler(service).GetContent)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("返回内容详情", func(t *testing.T) {
+		w := get("/api/v1/content/content-1?user_id=user-1")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response ContentResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		require.NotNil(t, response.Content)
+		assert.Equal(t, "Go语言并发编程实践", response.Content.RawContent)
+		assert.Equal(t, []string{"go", "并发"}, response.Content.Tags)
+		assert.Equal(t, "Go并发", response.Content.Summary.OneLine)
+		assert.True(t, response.Content.Vector.Indexed)
+	})
+
+	t.Run("内容不存在返回404", func(t *testing.T) {
+		w := get("/api/v1/content/missing")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("其他用户的内容返回404", func(t *testing.T) {
+		w := get("/api/v1/content/content-1?user_id=user-2")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("服务不可用返回500", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/api/v1/content/:id", NewContentHandler(nil).GetContent)
+
+		req, _ := http.NewRequest("GET", "/api/v1/content/content-1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+// TestContentHandler_PatchContent 测试部分更新内容API
+func TestContentHandler_PatchContent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 模拟服务：按补丁修改内存中的内容，未设置的字段保持不变
+	newService := func() (*MockContentService, *content.ContentDetail) {
+		item := &content.ContentDetail{
+			ID:              "content-1",
+			UserID:          "user-1",
+			Tags:            []string{"go"},
+			ImportanceScore: 0.5,
+		}
+		return &MockContentService{
+			PatchContentFunc: func(ctx context.Context, id string, userID string, patch *content.ContentMetadataPatch) (*content.ContentDetail, error) {
+				if id != item.ID {
+					return nil, errors.ErrResourceNotFound("content", id)
+				}
+				if patch.ImportanceScore != nil && (*patch.ImportanceScore < 0 || *patch.ImportanceScore > 1) {
+					return nil, errors.ErrValidationFailed("importance_score", "must be between 0.0 and 1.0")
+				}
+				if patch.Tags != nil {
+					item.Tags = patch.Tags
+				}
+				if patch.ImportanceScore != nil {
+					item.ImportanceScore = *patch.ImportanceScore
+				}
+				return item, nil
+			},
+		}, item
+	}
+
+	patch := func(service ContentServiceInterface, path, body string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.PATCH("/api/v1/content/:id", NewContentHandler(service).PatchContent)
+
+		req, _ := http.NewRequest("PATCH", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("只修改标签", func(t *testing.T) {
+		service, _ := newService()
+		w := patch(service, "/api/v1/content/content-1", `{"tags":["go","并发"]}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response ContentResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []string{"go", "并发"}, response.Content.Tags)
+		assert.Equal(t, 0.5, response.Content.ImportanceScore)
+	})
+
+	t.Run("只修改重要性", func(t *testing.T) {
+		service, _ := newService()
+		var received *content.ContentMetadataPatch
+		patchFunc := service.PatchContentFunc
+		service.PatchContentFunc = func(ctx context.Context, id string, userID string, patch *content.ContentMetadataPatch) (*content.ContentDetail, error) {
+			received = patch
+			return patchFunc(ctx, id, userID, patch)
+		}
+
+		w := patch(service, "/api/v1/content/content-1?user_id=user-1", `{"importance_score":0.9}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response ContentResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 0.9, response.Content.ImportanceScore)
+		assert.Equal(t, []string{"go"}, response.Content.Tags)
+		require.NotNil(t, received)
+		assert.Nil(t, received.Tags)
+		assert.Nil(t, received.Pinned)
+	})
+
+	t.Run("无效的重要性返回400", func(t *testing.T) {
+		service, item := newService()
+		w := patch(service, "/api/v1/content/content-1", `{"importance_score":1.5}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 0.5, item.ImportanceScore)
+	})
+
+	t.Run("不可修改的字段返回400", func(t *testing.T) {
+		service, _ := newService()
+		w := patch(service, "/api/v1/content/content-1", `{"raw_content":"覆盖原文"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("内容不存在返回404", func(t *testing.T) {
+		service, _ := newService()
+		w := patch(service, "/api/v1/content/missing", `{"pinned":true}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// TestContentHandler_RefreshContent 测试重新生成摘要和标签API
+func TestContentHandler_RefreshContent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 模拟服务：按风格重新生成摘要，风格校验与服务一致
+	newService := func() (*MockContentService, *content.ContentDetail) {
+		item := &content.ContentDetail{
+			ID:      "content-1",
+			UserID:  "user-1",
+			Summary: models.Summary{OneLine: "一个不太好的摘要"},
+			Tags:    []string{"go"},
+		}
+		return &MockContentService{
+			RefreshContentFunc: func(ctx context.Context, id string, userID string, options content.RefreshOptions) (*content.ContentDetail, error) {
+				if id != item.ID {
+					return nil, errors.ErrResourceNotFound("content", id)
+				}
+				if !llm.ValidSummaryStyle(options.Style) {
+					return nil, errors.ErrValidationFailed("style", "unsupported style")
+				}
+				item.Summary = models.Summary{OneLine: "Go并发编程要点（" + string(options.Style) + "）"}
+				item.Tags = []string{"go", "并发"}
+				return item, nil
+			},
+		}, item
+	}
+
+	refresh := func(service ContentServiceInterface, path, body string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.POST("/api/v1/content/:id/refresh", NewContentHandler(service).RefreshContent)
+
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("刷新后返回新的摘要和标签", func(t *testing.T) {
+		service, _ := newService()
+		var received content.RefreshOptions
+		refreshFunc := service.RefreshContentFunc
+		service.RefreshContentFunc = func(ctx context.Context, id string, userID string, options content.RefreshOptions) (*content.ContentDetail, error) {
+			received = options
+			return refreshFunc(ctx, id, userID, options)
+		}
+
+		w := refresh(service, "/api/v1/content/content-1/refresh?user_id=user-1", `{"style":"bullet","model":"gpt-4o"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response ContentResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, "Go并发编程要点（bullet）", response.Content.Summary.OneLine)
+		assert.Equal(t, []string{"go", "并发"}, response.Content.Tags)
+		assert.Equal(t, llm.SummaryStyleBullet, received.Style)
+		assert.Equal(t, "gpt-4o", received.Model)
+	})
+
+	t.Run("请求体可省略", func(t *testing.T) {
+		service, _ := newService()
+		w := refresh(service, "/api/v1/content/content-1/refresh", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("无效的摘要风格返回400", func(t *testing.T) {
+		service, item := newService()
+		w := refresh(service, "/api/v1/content/content-1/refresh", `{"style":"poetic"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "一个不太好的摘要", item.Summary.OneLine)
+	})
+
+	t.Run("未知字段返回400", func(t *testing.T) {
+		service, _ := newService()
+		w := refresh(service, "/api/v1/content/content-1/refresh", `{"temperature":0.2}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("内容不存在返回404", func(t *testing.T) {
+		service, _ := newService()
+		w := refresh(service, "/api/v1/content/missing/refresh", `{}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("模型服务限流返回429", func(t *testing.T) {
+		service := &MockContentService{
+			RefreshContentFunc: func(ctx context.Context, id string, userID string, options content.RefreshOptions) (*content.ContentDetail, error) {
+				return nil, errors.ErrLLMAPIStatus(errors.ErrCodeLLMRateLimited, "chat", http.StatusTooManyRequests, "rate limit reached")
+			},
+		}
+		w := refresh(service, "/api/v1/content/content-1/refresh", `{}`)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+}
+
+// TestContentHandler_GetSummary 测试获取内容摘要API
+func TestContentHandler_GetSummary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	stored := models.Summary{OneLine: "Go并发", Paragraph: "介绍goroutine和channel", Detailed: "详细介绍调度器和内存模型"}
+	service := &MockContentService{
+		GetSummaryFunc: func(ctx context.Context, id string, userID string, level content.SummaryLevel) (*models.Summary, error) {
+			if id != "content-1" {
+				return nil, errors.ErrResourceNotFound("content", id)
+			}
+			switch level {
+			case "":
+				return &stored, nil
+			case content.SummaryLevelOneLine:
+				return &models.Summary{OneLine: stored.OneLine}, nil
+			case content.SummaryLevelParagraph:
+				return &models.Summary{Paragraph: stored.Paragraph}, nil
+			case content.SummaryLevelDetailed:
+				return &models.Summary{Detailed: stored.Detailed}, nil
+			}
+			return nil, errors.ErrValidationFailed("level", "must be one of one_line, paragraph, detailed")
+		},
+	}
+
+	router := gin.New()
+	router.GET("/api/v1/content/:id/summary", NewContentHandler(service).GetSummary)
+
+	get := func(path string) (*httptest.ResponseRecorder, SummaryResponse) {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response SummaryResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w, response
+	}
+
+	t.Run("返回全部级别", func(t *testing.T) {
+		w, response := get("/api/v1/content/content-1/summary")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "content-1", response.ContentID)
+		assert.Equal(t, stored, *response.Summary)
+	})
+
+	t.Run("按级别返回", func(t *testing.T) {
+		w, response := get("/api/v1/content/content-1/summary?level=one_line")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "one_line", response.Level)
+		assert.Equal(t, models.Summary{OneLine: "Go并发"}, *response.Summary)
+
+		w, response = get("/api/v1/content/content-1/summary?level=paragraph")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.Summary{Paragraph: "介绍goroutine和channel"}, *response.Summary)
+
+		w, response = get("/api/v1/content/content-1/summary?level=detailed")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.Summary{Detailed: "详细介绍调度器和内存模型"}, *response.Summary)
+	})
+
+	t.Run("无效级别返回400", func(t *testing.T) {
+		w, _ := get("/api/v1/content/content-1/summary?level=full")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("内容不存在返回404", func(t *testing.T) {
+		w, _ := get("/api/v1/content/missing/summary")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// TestContentHandler_ValidateContent 测试内容预检API
+func TestContentHandler_ValidateContent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received *content.ProcessingRequest
+	service := &MockContentService{
+		ValidateContentFunc: func(ctx context.Context, request *content.ProcessingRequest) (*content.ValidationVerdict, error) {
+			received = request
+			verdict := &content.ValidationVerdict{Accepted: true, ContentSize: len(request.Content), MaxContentSize: 10}
+			if request.Content == "" {
+				verdict.Accepted = false
+				verdict.Reasons = []*content.ValidationReason{{Field: "content", Code: content.ReasonEmpty, Message: "cannot be empty"}}
+			} else if len(request.Content) > verdict.MaxContentSize {
+				verdict.Accepted = false
+				verdict.Reasons = []*content.ValidationReason{{Field: "content", Code: content.ReasonTooLarge, Message: "content too large"}}
+			}
+			return verdict, nil
+		},
+	}
+
+	router := gin.New()
+	router.POST("/api/v1/content/validate", NewContentHandler(service).ValidateContent)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/content/validate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) *content.ValidationVerdict {
+		require.Equal(t, http.StatusOK, w.Code)
+		var response ContentValidationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		require.NotNil(t, response.Verdict)
+		return response.Verdict
+	}
+
+	t.Run("有效内容", func(t *testing.T) {
+		verdict := decode(t, post(`{"content":"Go并发","user_id":"user-1"}`))
+		assert.True(t, verdict.Accepted)
+		assert.Equal(t, models.ContentTypeText, received.ContentType)
+		assert.Equal(t, "user-1", received.UserID)
+	})
+
+	t.Run("空内容", func(t *testing.T) {
+		verdict := decode(t, post(`{"content":"","user_id":"user-1"}`))
+		assert.False(t, verdict.Accepted)
+		require.Len(t, verdict.Reasons, 1)
+		assert.Equal(t, content.ReasonEmpty, verdict.Reasons[0].Code)
+	})
+
+	t.Run("内容过大", func(t *testing.T) {
+		verdict := decode(t, post(`{"content":"Go语言并发编程实践","content_type":"text","user_id":"user-1"}`))
+		assert.False(t, verdict.Accepted)
+		require.Len(t, verdict.Reasons, 1)
+		assert.Equal(t, content.ReasonTooLarge, verdict.Reasons[0].Code)
+	})
+
+	t.Run("请求格式错误返回400", func(t *testing.T) {
+		w := post(`{invalid`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("服务不可用返回500", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/api/v1/content/validate", NewContentHandler(nil).ValidateContent)
+
+		req, _ := http.NewRequest("POST", "/api/v1/content/validate", strings.NewReader(`{"content":"Go"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+// TestContentHandler_ListTimeline 测试内容时间线API
+func TestContentHandler_ListTimeline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotLimit int
+	var gotBefore time.Time
+	cursor := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service := &MockContentService{
+		ListRecentFunc: func(ctx context.Context, userID string, limit int, before time.Time) (*content.TimelinePage, error) {
+			gotLimit, gotBefore = limit, before
+			return &content.TimelinePage{
+				Items:      []*content.ContentDetail{{ID: "content-2", UserID: userID}},
+				HasMore:    true,
+				NextBefore: &cursor,
+			}, nil
+		},
+	}
+
+	router := gin.New()
+	router.GET("/api/v1/timeline", NewContentHandler(service).ListTimeline)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("传递游标并返回下一页游标", func(t *testing.T) {
+		w := get("/api/v1/timeline?user_id=user-1&limit=1&before=2024-05-02T08:00:00Z")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response TimelineResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Items, 1)
+		assert.True(t, response.HasMore)
+		require.NotNil(t, response.NextBefore)
+		assert.True(t, cursor.Equal(*response.NextBefore))
+		assert.Equal(t, 1, gotLimit)
+		assert.True(t, time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC).Equal(gotBefore))
+	})
+
+	t.Run("参数错误", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("/api/v1/timeline").Code)
+		assert.Equal(t, http.StatusBadRequest, get("/api/v1/timeline?user_id=user-1&before=yesterday").Code)
+		assert.Equal(t, http.StatusBadRequest, get("/api/v1/timeline?user_id=user-1&limit=-1").Code)
+	})
+}
+
+// TestContentHandler_StreamEvents 测试处理进度事件流API
+func TestContentHandler_StreamEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &MockContentService{
+		SubscribeEventsFunc: func(requestID string) (<-chan *content.ProcessingEvent, func(), error) {
+			if requestID != "req-1" {
+				return nil, nil, errors.ErrResourceNotFound("processing_request", requestID)
+			}
+			events := make(chan *content.ProcessingEvent, 3)
+			events <- &content.ProcessingEvent{RequestID: requestID, Type: content.EventTypeStatus, Status: content.StatusProcessing}
+			events <- &content.ProcessingEvent{RequestID: requestID, Type: content.EventTypeStage, Status: content.StatusProcessing, Stage: content.StageExtract}
+			events <- &content.ProcessingEvent{RequestID: requestID, Type: content.EventTypeStatus, Status: content.StatusCompleted}
+			close(events)
+			return events, func() {}, nil
+		},
+	}
+
+	router := gin.New()
+	router.GET("/api/v1/content/:id/events", NewContentHandler(service).StreamEvents)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("推送事件直到请求结束", func(t *testing.T) {
+		w := get("/api/v1/content/req-1/events")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+		body := w.Body.String()
+		assert.Equal(t, 2, strings.Count(body, "event:status"))
+		assert.Equal(t, 1, strings.Count(body, "event:stage"))
+		assert.Contains(t, body, `"stage":"extract"`)
+		assert.Less(t, strings.Index(body, `"status":"processing"`), strings.Index(body, `"status":"completed"`))
+	})
+
+	t.Run("请求不存在返回404", func(t *testing.T) {
+		w := get("/api/v1/content/missing/events")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// TestContentHandler_UploadContent 测试文件上传API
+func TestContentHandler_UploadContent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 最小的PNG文件头，足以被识别为image/png
+	pngData := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+
+	newRouterIn := func(t *testing.T, dir, maxFileSize string, submitted *[]*content.ProcessingRequest) *gin.Engine {
+		store, err := storage.OpenFileStore(config.StorageConfig{
+			FilePath:     dir,
+			MaxFileSize:  maxFileSize,
+			AllowedTypes: []string{"image/*", "text/plain", ".pdf"},
+		})
+		require.NoError(t, err)
+
+		handler := NewContentHandler(&MockContentService{
+			ProcessContentAsyncFunc: func(request *content.ProcessingRequest) error {
+				*submitted = append(*submitted, request)
+				return nil
+			},
+		})
+		handler.SetFileStore(store)
+
+		router := gin.New()
+		router.POST("/api/v1/content/upload", handler.UploadContent)
+		return router
+	}
+	newRouter := func(t *testing.T, maxFileSize string, submitted *[]*content.ProcessingRequest) *gin.Engine {
+		return newRouterIn(t, t.TempDir(), maxFileSize, submitted)
+	}
+
+	uploadWithFields := func(router *gin.Engine, filename string, data []byte, fields map[string]string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		require.NoError(t, writer.WriteField("user_id", "user-1"))
+		for name, value := range fields {
+			require.NoError(t, writer.WriteField(name, value))
+		}
+		part, err := writer.CreateFormFile("file", filename)
+		require.NoError(t, err)
+		_, err = part.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		req, _ := http.NewRequest("POST", "/api/v1/content/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	upload := func(router *gin.Engine, filename string, data []byte) *httptest.ResponseRecorder {
+		return uploadWithFields(router, filename, data, nil)
+	}
+
+	t.Run("文本文件保存并按内容提交", func(t *testing.T) {
+		var submitted []*content.ProcessingRequest
+		router := newRouter(t, "", &submitted)
+
+		w := upload(router, "notes.txt", []byte("Go语言并发编程笔记"))
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		var response ContentUploadResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, models.ContentTypeText, response.ContentType)
+		assert.Equal(t, "text/plain", response.MimeType)
+
+		require.Len(t, submitted, 1)
+		request := submitted[0]
+		assert.Equal(t, response.RequestID, request.ID)
+		assert.Equal(t, "user-1", request.UserID)
+		assert.Equal(t, models.ContentTypeText, request.ContentType)
+		assert.Equal(t, "Go语言并发编程笔记", request.Content)
+		assert.Equal(t, "notes.txt", request.Context[content.TitleContextKey])
+
+		// 元数据只记录存储文件名，不包含服务器上的存储路径
+		assert.Equal(t, "notes.txt", request.Metadata["upload_filename"])
+		assert.NotContains(t, request.Metadata, "upload_path")
+		storedName, _ := request.Metadata["upload_file"].(string)
+		assert.NotContains(t, storedName, string(filepath.Separator))
+		assert.True(t, strings.HasSuffix(storedName, "notes.txt"))
+	})
+
+	t.Run("图片保存并按图片提交", func(t *testing.T) {
+		dir := t.TempDir()
+		var submitted []*content.ProcessingRequest
+		router := newRouterIn(t, dir, "", &submitted)
+
+		w := upload(router, "photo.png", pngData)
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		var response ContentUploadResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, models.ContentTypeImage, response.ContentType)
+		assert.Equal(t, "image/png", response.MimeType)
+
+		require.Len(t, submitted, 1)
+		request := submitted[0]
+		assert.Equal(t, models.ContentTypeImage, request.ContentType)
+		assert.Equal(t, content.StoredFileContent("photo.png", "image/png", len(pngData)), request.Content)
+		assert.Equal(t, "photo.png", request.Context[content.TitleContextKey])
+		storedName, _ := request.Metadata[content.StoredFileMetadataKey].(string)
+		require.NotEmpty(t, storedName)
+		assert.NotContains(t, request.Content, storedName)
+
+		stored, err := os.ReadFile(filepath.Join(dir, "uploads", storedName))
+		require.NoError(t, err)
+		assert.Equal(t, pngData, stored)
+	})
+
+	t.Run("其他二进制文件按文件提交", func(t *testing.T) {
+		var submitted []*content.ProcessingRequest
+		router := newRouter(t, "", &submitted)
+
+		w := upload(router, "report.pdf", []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"))
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.Len(t, submitted, 1)
+		assert.Equal(t, models.ContentTypeFile, submitted[0].ContentType)
+		assert.True(t, strings.HasPrefix(submitted[0].Content, "report.pdf (application/pdf, "))
+	})
+
+	t.Run("不允许的文件类型返回415", func(t *testing.T) {
+		var submitted []*content.ProcessingRequest
+		router := newRouter(t, "", &submitted)
+
+		w := upload(router, "page.html", []byte("<html><body>hello</body></html>"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Empty(t, submitted)
+	})
+
+	t.Run("敏感内容和被拒绝的提交不保留原始文件", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := storage.OpenFileStore(config.StorageConfig{FilePath: dir})
+		require.NoError(t, err)
+		var submitted []*content.ProcessingRequest
+		rejected := false
+		handler := NewContentHandler(&MockContentService{
+			ProcessContentAsyncFunc: func(request *content.ProcessingRequest) error {
+				if rejected {
+					return errors.ErrServerBusy("processing_queue", 1, 1)
+				}
+				submitted = append(submitted, request)
+				return nil
+			},
+		})
+		handler.SetFileStore(store)
+		router := gin.New()
+		router.POST("/api/v1/content/upload", handler.UploadContent)
+
+		w := uploadWithFields(router, "notes.txt", []byte("体检报告"), map[string]string{"sensitive": "true"})
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.Len(t, submitted, 1)
+		assert.True(t, submitted[0].Sensitive)
+		assert.NotContains(t, submitted[0].Metadata, "upload_file")
+
+		// 敏感的非文本文件需要保留原始文件才能处理，直接拒绝
+		w = uploadWithFields(router, "scan.png", pngData, map[string]string{"sensitive": "true"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		require.Len(t, submitted, 1)
+
+		rejected = true
+		w = upload(router, "notes.txt", []byte("Go语言并发编程笔记"))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		entries, err := os.ReadDir(filepath.Join(dir, "uploads"))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("无效的sensitive参数返回400", func(t *testing.T) {
+		var submitted []*content.ProcessingRequest
+		router := newRouter(t, "", &submitted)
+
+		w := uploadWithFields(router, "notes.txt", []byte("体检报告"), map[string]string{"sensitive": "maybe"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, submitted)
+	})
+
+	t.Run("超过大小上限返回413", func(t *testing.T) {
+		var submitted []*content.ProcessingRequest
+		router := newRouter(t, "32B", &submitted)
+
+		w := upload(router, "photo.png", pngData)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Empty(t, submitted)
+	})
+
+	t.Run("未配置文件存储返回500", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/api/v1/content/upload", NewContentHandler(&MockContentService{}).UploadContent)
+
+		w := upload(router, "photo.png", pngData)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
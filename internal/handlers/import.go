@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/importer"
+)
+
+// ImportHandler 书签和稍后读导出文件导入API处理器
+type ImportHandler struct {
+	importer ImporterInterface
+	logger   *logger.Logger
+}
+
+// ImporterInterface 导入服务接口
+type ImporterInterface interface {
+	MaxFileSize() int64
+	Start(ctx context.Context, userID string, format importer.Format, data []byte) (*models.ImportJob, error)
+	Resume(ctx context.Context, id string, userID string) (*models.ImportJob, error)
+	Get(ctx context.Context, id string, userID string) (*models.ImportJob, error)
+}
+
+// ImportJobResponse 导入任务响应结构
+type ImportJobResponse struct {
+	Success  bool              `json:"success"`
+	Job      *models.ImportJob `json:"job"`
+	Progress float64           `json:"progress"` // 进度百分比（0-100）
+}
+
+// NewImportHandler 创建导入处理器
+func NewImportHandler(importer ImporterInterface) *ImportHandler {
+	return &ImportHandler{
+		importer: importer,
+		logger:   logger.NewLogger("import-handler"),
+	}
+}
+
+// StartImport 上传导出文件并启动导入
+// @Summary 导入书签或稍后读列表
+// @Description 解析浏览器书签HTML或Pocket导出的CSV/JSON，按规范URL去重后在后台限速提交为链接处理请求，立即返回导入任务；文件可以multipart字段file上传或直接作为请求体
+// @Tags import
+// @Accept multipart/form-data
+// @Produce json
+// @Param format query string true "文件格式：bookmarks、pocket_csv或pocket_json（multipart上传时也可作为表单字段）"
+// @Param user_id query string false "用户ID（multipart上传时也可作为表单字段）"
+// @Param file formData file false "导出文件"
+// @Success 202 {object} ImportJobResponse "导入已开始"
+// @Failure 400 {object} ErrorResponse "格式不支持、文件无法解析或条目过多"
+// @Failure 413 {object} ErrorResponse "文件超过大小上限"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/import [post]
+func (h *ImportHandler) StartImport(c *gin.Context) {
+	if !h.requireImporter(c) {
+		return
+	}
+
+	maxSize := h.importer.MaxFileSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+uploadFormOverhead)
+	data, err := h.readImportFile(c, maxSize)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) || stderrors.Is(err, errImportFileTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+				Success: false,
+				Message: fmt.Sprintf("File too large (max %d bytes)", maxSize),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Failed to read import file: " + err.Error(),
+		})
+		return
+	}
+
+	format := importer.Format(strings.ToLower(strings.TrimSpace(formValue(c, "format"))))
+	userID := formValue(c, "user_id")
+	job, err := h.importer.Start(c.Request.Context(), userID, format, data)
+	if err != nil {
+		h.respondImportError(c, err)
+		return
+	}
+
+	h.logger.Info("Import started by request", logger.Fields{
+		"job_id":  job.ID,
+		"user_id": userID,
+		"format":  string(format),
+		"entries": job.Total,
+	})
+
+	c.JSON(http.StatusAccepted, ImportJobResponse{Success: true, Job: job, Progress: job.Progress()})
+}
+
+// GetImport 查询导入进度
+// @Summary 查询导入任务
+// @Description 获取导入任务的状态和进度（已提交、失败和重复的条目数）
+// @Tags import
+// @Produce json
+// @Param id path string true "导入任务ID"
+// @Param user_id query string false "用户ID，指定时仅返回该用户的任务"
+// @Success 200 {object} ImportJobResponse "导入任务"
+// @Failure 404 {object} ErrorResponse "任务不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/import/{id} [get]
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	if !h.requireImporter(c) {
+		return
+	}
+
+	job, err := h.importer.Get(c.Request.Context(), c.Param("id"), c.Query("user_id"))
+	if err != nil {
+		h.respondImportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ImportJobResponse{Success: true, Job: job, Progress: job.Progress()})
+}
+
+// ResumeImport 继续暂停的导入
+// @Summary 继续导入
+// @Description 从保存的进度处继续因服务关闭或处理队列繁忙而暂停的导入任务
+// @Tags import
+// @Produce json
+// @Param id path string true "导入任务ID"
+// @Param user_id query string false "用户ID，指定时仅能继续该用户的任务"
+// @Success 202 {object} ImportJobResponse "导入已继续"
+// @Failure 400 {object} ErrorResponse "任务已完成"
+// @Failure 404 {object} ErrorResponse "任务不存在"
+// @Failure 409 {object} ErrorResponse "任务正在执行"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/import/{id}/resume [post]
+func (h *ImportHandler) ResumeImport(c *gin.Context) {
+	if !h.requireImporter(c) {
+		return
+	}
+
+	job, err := h.importer.Resume(c.Request.Context(), c.Param("id"), c.Query("user_id"))
+	if err != nil {
+		h.respondImportError(c, err)
+		return
+	}
+
+	h.logger.Info("Import resumed by request", logger.Fields{
+		"job_id": job.ID,
+		"cursor": job.Cursor,
+		"total":  job.Total,
+	})
+
+	c.JSON(http.StatusAccepted, ImportJobResponse{Success: true, Job: job, Progress: job.Progress()})
+}
+
+// errImportFileTooLarge 导出文件超过大小上限
+var errImportFileTooLarge = stderrors.New("import file too large")
+
+// readImportFile 读取导出文件：multipart请求读取file字段，其他请求读取整个请求体
+func (h *ImportHandler) readImportFile(c *gin.Context, maxSize int64) ([]byte, error) {
+	reader := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errImportFileTooLarge
+	}
+	if len(data) == 0 {
+		return nil, stderrors.New("file is empty")
+	}
+	return data, nil
+}
+
+// formValue 读取查询参数，未提供时读取multipart表单字段
+func formValue(c *gin.Context, key string) string {
+	if value := c.Query(key); value != "" {
+		return value
+	}
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		return c.PostForm(key)
+	}
+	return ""
+}
+
+// requireImporter 检查导入服务是否可用，不可用时返回错误响应
+func (h *ImportHandler) requireImporter(c *gin.Context) bool {
+	if h.importer != nil {
+		return true
+	}
+
+	h.logger.Error("Importer is not initialized")
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Success: false,
+		Message: "Import is not available",
+	})
+	return false
+}
+
+// respondImportError 返回导入接口的错误响应（参数错误为400，不存在为404，任务执行中为409）
+func (h *ImportHandler) respondImportError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if memoErr, ok := err.(*errors.MemoroError); ok {
+		switch memoErr.Code {
+		case errors.ErrCodeValidationFailed:
+			status = http.StatusBadRequest
+		case errors.ErrCodeResourceNotFound:
+			status = http.StatusNotFound
+		case errors.ErrCodeDuplicateResource:
+			status = http.StatusConflict
+		}
+	}
+	if isThrottled(err) {
+		status = http.StatusTooManyRequests
+	}
+	if status == http.StatusInternalServerError {
+		h.logger.Error("Import request failed", logger.Fields{
+			"error": err.Error(),
+		})
+	}
+
+	c.JSON(status, ErrorResponse{
+		Success: false,
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/errors"
+	"memoro/internal/models"
+	"memoro/internal/services/importer"
+)
+
+// MockImporter 模拟导入服务（用于测试）
+type MockImporter struct {
+	MaxSize    int64
+	StartFunc  func(ctx context.Context, userID string, format importer.Format, data []byte) (*models.ImportJob, error)
+	ResumeFunc func(ctx context.Context, id string, userID string) (*models.ImportJob, error)
+	GetFunc    func(ctx context.Context, id string, userID string) (*models.ImportJob, error)
+}
+
+func (m *MockImporter) MaxFileSize() int64 {
+	return m.MaxSize
+}
+
+func (m *MockImporter) Start(ctx context.Context, userID string, format importer.Format, data []byte) (*models.ImportJob, error) {
+	return m.StartFunc(ctx, userID, format, data)
+}
+
+func (m *MockImporter) Resume(ctx context.Context, id string, userID string) (*models.ImportJob, error) {
+	return m.ResumeFunc(ctx, id, userID)
+}
+
+func (m *MockImporter) Get(ctx context.Context, id string, userID string) (*models.ImportJob, error) {
+	return m.GetFunc(ctx, id, userID)
+}
+
+// newImportTestRouter 创建导入路由
+func newImportTestRouter(handler *ImportHandler) *gin.Engine {
+	router := gin.New()
+	router.POST("/api/v1/import", handler.StartImport)
+	router.GET("/api/v1/import/:id", handler.GetImport)
+	router.POST("/api/v1/import/:id/resume", handler.ResumeImport)
+	return router
+}
+
+// TestImportHandler_StartImport 测试导入API的文件读取、参数传递和错误映射
+func TestImportHandler_StartImport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotUser string
+	var gotFormat importer.Format
+	var gotData string
+	mock := &MockImporter{
+		MaxSize: 64,
+		StartFunc: func(ctx context.Context, userID string, format importer.Format, data []byte) (*models.ImportJob, error) {
+			gotUser, gotFormat, gotData = userID, format, string(data)
+			if !importer.ValidFormat(format) {
+				return nil, errors.ErrValidationFailed("format", "unsupported")
+			}
+			return &models.ImportJob{ID: "import-1", UserID: userID, Format: string(format), Status: models.ImportStatusRunning, Total: 4}, nil
+		},
+	}
+	router := newImportTestRouter(NewImportHandler(mock))
+
+	t.Run("请求体作为文件", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/import?format=pocket_csv&user_id=user-1", strings.NewReader("title,url\nA,https://a.example"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		var response ImportJobResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "import-1", response.Job.ID)
+		assert.Equal(t, 4, response.Job.Total)
+		assert.Equal(t, "user-1", gotUser)
+		assert.Equal(t, importer.FormatPocketCSV, gotFormat)
+		assert.Contains(t, gotData, "https://a.example")
+	})
+
+	t.Run("multipart上传", func(t *testing.T) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		require.NoError(t, writer.WriteField("format", "bookmarks"))
+		require.NoError(t, writer.WriteField("user_id", "user-2"))
+		part, err := writer.CreateFormFile("file", "bookmarks.html")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte(`<A HREF="https://b.example">B</A>`))
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/import", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, "user-2", gotUser)
+		assert.Equal(t, importer.FormatBookmarks, gotFormat)
+	})
+
+	t.Run("不支持的格式", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/import?format=opml", strings.NewReader("<opml/>"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("文件超过大小上限", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/import?format=bookmarks", strings.NewReader(strings.Repeat("x", 65)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+// TestImportHandler_ResumeImport 测试继续导入的错误映射
+func TestImportHandler_ResumeImport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mock := &MockImporter{
+		ResumeFunc: func(ctx context.Context, id string, userID string) (*models.ImportJob, error) {
+			switch id {
+			case "running":
+				return nil, errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeDuplicateResource, "Import already running")
+			case "missing":
+				return nil, errors.ErrResourceNotFound("import_job", id)
+			}
+			return &models.ImportJob{ID: id, Status: models.ImportStatusRunning, Total: 4, Cursor: 2}, nil
+		},
+	}
+	router := newImportTestRouter(NewImportHandler(mock))
+
+	resume := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/import/"+id+"/resume", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("继续暂停的任务", func(t *testing.T) {
+		w := resume("paused")
+		require.Equal(t, http.StatusAccepted, w.Code)
+		var response ImportJobResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(50), response.Progress)
+	})
+
+	t.Run("任务正在执行", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, resume("running").Code)
+	})
+
+	t.Run("任务不存在", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, resume("missing").Code)
+	})
+}
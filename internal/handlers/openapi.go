@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"memoro/internal/services/content"
+)
+
+// OpenAPISpec OpenAPI 3 文档
+type OpenAPISpec struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+// OpenAPIInfo 文档基本信息
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIComponents 可复用组件
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas"`
+}
+
+// OpenAPIOperation 单个接口操作
+type OpenAPIOperation struct {
+	Summary     string                      `json:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter 路径参数
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIRequestBody 请求体
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse 响应
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType 媒体类型
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPISchema JSON Schema
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// APIOperation 接口文档描述（请求和响应使用对应结构体的零值）
+type APIOperation struct {
+	Summary  string
+	Tags     []string
+	Request  interface{}
+	Response interface{}
+}
+
+// apiOperations 已注册路由的文档描述，键为 "METHOD path"
+var apiOperations = map[string]APIOperation{
+	"GET /health": {
+		Summary:  "健康检查",
+		Tags:     []string{"health"},
+		Response: HealthResponse{},
+	},
+	"GET /api/v1/health": {
+		Summary:  "健康检查",
+		Tags:     []string{"health"},
+		Response: HealthResponse{},
+	},
+	"GET /api/v1/openapi.json": {
+		Summary:  "OpenAPI文档",
+		Tags:     []string{"docs"},
+		Response: map[string]interface{}{},
+	},
+	"POST /api/v1/search": {
+		Summary:  "语义搜索",
+		Tags:     []string{"search"},
+		Request:  SearchRequest{},
+		Response: SearchResponse{},
+	},
+	"GET /api/v1/content/:id": {
+		Summary:  "获取内容详情",
+		Tags:     []string{"content"},
+		Response: ContentResponse{},
+	},
+	"PATCH /api/v1/content/:id": {
+		Summary:  "部分更新内容",
+		Tags:     []string{"content"},
+		Request:  ContentPatchRequest{},
+		Response: ContentResponse{},
+	},
+	"GET /api/v1/content/:id/summary": {
+		Summary:  "获取内容摘要",
+		Tags:     []string{"content"},
+		Response: SummaryResponse{},
+	},
+	"POST /api/v1/content/:id/refresh": {
+		Summary:  "重新生成摘要和标签",
+		Tags:     []string{"content"},
+		Request:  ContentRefreshRequest{},
+		Response: ContentResponse{},
+	},
+	"GET /api/v1/content/:id/events": {
+		Summary:  "处理进度事件流（SSE）",
+		Tags:     []string{"content"},
+		Response: content.ProcessingEvent{},
+	},
+	"POST /api/v1/content/validate": {
+		Summary:  "内容预检",
+		Tags:     []string{"content"},
+		Request:  ContentValidationRequest{},
+		Response: ContentValidationResponse{},
+	},
+	"POST /api/v1/content/upload": {
+		Summary:  "上传文件（multipart/form-data）",
+		Tags:     []string{"content"},
+		Response: ContentUploadResponse{},
+	},
+	"GET /api/v1/timeline": {
+		Summary:  "内容时间线",
+		Tags:     []string{"content"},
+		Response: TimelineResponse{},
+	},
+	"POST /api/v1/import": {
+		Summary:  "导入书签或稍后读列表（format查询参数指定bookmarks、pocket_csv或pocket_json）",
+		Tags:     []string{"import"},
+		Response: ImportJobResponse{},
+	},
+	"GET /api/v1/import/:id": {
+		Summary:  "查询导入任务",
+		Tags:     []string{"import"},
+		Response: ImportJobResponse{},
+	},
+	"POST /api/v1/import/:id/resume": {
+		Summary:  "继续暂停的导入任务",
+		Tags:     []string{"import"},
+		Response: ImportJobResponse{},
+	},
+	"GET /api/v1/wechat/login": {
+		Summary:  "微信登录（需要管理令牌）",
+		Tags:     []string{"wechat"},
+		Response: WeChatLoginResponse{},
+	},
+	"GET /api/v1/wechat/status": {
+		Summary:  "微信登录状态（需要管理令牌）",
+		Tags:     []string{"wechat"},
+		Response: WeChatStatusResponse{},
+	},
+	"POST /api/v1/admin/cache/flush": {
+		Summary:  "清空缓存（需要管理令牌）",
+		Tags:     []string{"admin"},
+		Request:  CacheFlushRequest{},
+		Response: CacheFlushResponse{},
+	},
+	"POST /api/v1/admin/tags/rename": {
+		Summary:  "批量重命名标签（需要管理令牌）",
+		Tags:     []string{"admin"},
+		Request:  TagRenameRequest{},
+		Response: TagBulkEditResponse{},
+	},
+	"POST /api/v1/admin/tags/delete": {
+		Summary:  "批量删除标签（需要管理令牌）",
+		Tags:     []string{"admin"},
+		Request:  TagDeleteRequest{},
+		Response: TagBulkEditResponse{},
+	},
+	"POST /api/v1/admin/jobs/:type": {
+		Summary:  "启动后台任务（需要管理令牌）",
+		Tags:     []string{"admin"},
+		Response: JobResponse{},
+	},
+	"GET /api/v1/admin/jobs/:id": {
+		Summary:  "查询后台任务（需要管理令牌）",
+		Tags:     []string{"admin"},
+		Response: JobResponse{},
+	},
+	"DELETE /api/v1/admin/jobs/:id": {
+		Summary:  "取消后台任务（需要管理令牌）",
+		Tags:     []string{"admin"},
+		Response: JobResponse{},
+	},
+	"POST /api/v1/search/scopes": {
+		Summary:  "保存搜索范围",
+		Tags:     []string{"search"},
+		Request:  SearchScopeRequest{},
+		Response: SearchScopeResponse{},
+	},
+	"GET /api/v1/search/scopes": {
+		Summary:  "列出搜索范围",
+		Tags:     []string{"search"},
+		Response: SearchScopeResponse{},
+	},
+	"GET /api/v1/search/stats": {
+		Summary:  "获取搜索统计",
+		Tags:     []string{"search"},
+		Response: map[string]interface{}{},
+	},
+	"POST /api/v1/recommendations": {
+		Summary:  "获取推荐内容",
+		Tags:     []string{"recommendations"},
+		Request:  RecommendationRequest{},
+		Response: RecommendationResponse{},
+	},
+}
+
+// OpenAPIHandler OpenAPI文档处理器
+type OpenAPIHandler struct {
+	title   string
+	version string
+
+	mu   sync.RWMutex
+	spec *OpenAPISpec
+}
+
+// NewOpenAPIHandler 创建OpenAPI文档处理器
+func NewOpenAPIHandler(title, version string) *OpenAPIHandler {
+	return &OpenAPIHandler{
+		title:   title,
+		version: version,
+	}
+}
+
+// Build 根据已注册的路由生成文档（在所有路由注册完成后调用）
+func (h *OpenAPIHandler) Build(routes gin.RoutesInfo) {
+	spec := BuildOpenAPISpec(h.title, h.version, routes)
+
+	h.mu.Lock()
+	h.spec = spec
+	h.mu.Unlock()
+}
+
+// GetSpec 返回OpenAPI文档
+// @Summary OpenAPI文档
+// @Description 根据已注册路由和请求/响应结构体生成的OpenAPI 3文档
+// @Tags docs
+// @Produce json
+// @Success 200 {object} OpenAPISpec "OpenAPI文档"
+// @Router /api/v1/openapi.json [get]
+func (h *OpenAPIHandler) GetSpec(c *gin.Context) {
+	h.mu.RLock()
+	spec := h.spec
+	h.mu.RUnlock()
+
+	if spec == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Success: false,
+			Message: "OpenAPI spec is not available",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, spec)
+}
+
+// BuildOpenAPISpec 通过反射请求/响应结构体生成OpenAPI文档
+func BuildOpenAPISpec(title, version string, routes gin.RoutesInfo) *OpenAPISpec {
+	builder := &schemaBuilder{
+		schemas: make(map[string]*OpenAPISchema),
+		names:   make(map[reflect.Type]string),
+	}
+
+	spec := &OpenAPISpec{
+		OpenAPI:    "3.0.3",
+		Info:       OpenAPIInfo{Title: title, Version: version},
+		Paths:      make(map[string]map[string]*OpenAPIOperation),
+		Components: OpenAPIComponents{Schemas: builder.schemas},
+	}
+
+	sorted := make(gin.RoutesInfo, len(routes))
+	copy(sorted, routes)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	for _, route := range sorted {
+		path, params := convertRoutePath(route.Path)
+		operation := builder.buildOperation(apiOperations[route.Method+" "+route.Path], params)
+
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		spec.Paths[path][strings.ToLower(route.Method)] = operation
+	}
+
+	return spec
+}
+
+// convertRoutePath 将gin路径参数(:id、*path)转换为OpenAPI格式({id})
+func convertRoutePath(routePath string) (string, []*OpenAPIParameter) {
+	segments := strings.Split(routePath, "/")
+	params := make([]*OpenAPIParameter, 0)
+
+	for i, segment := range segments {
+		if len(segment) < 2 || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, &OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &OpenAPISchema{Type: "string"},
+		})
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+// schemaBuilder 基于反射的Schema生成器
+type schemaBuilder struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+// buildOperation 构建接口操作描述
+func (b *schemaBuilder) buildOperation(doc APIOperation, params []*OpenAPIParameter) *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		Summary:   doc.Summary,
+		Tags:      doc.Tags,
+		Responses: make(map[string]*OpenAPIResponse),
+	}
+	if len(params) > 0 {
+		operation.Parameters = params
+	}
+
+	if doc.Request != nil {
+		operation.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  jsonContent(b.schemaFor(reflect.TypeOf(doc.Request))),
+		}
+		operation.Responses["400"] = &OpenAPIResponse{
+			Description: "请求参数错误",
+			Content:     jsonContent(b.schemaFor(reflect.TypeOf(ErrorResponse{}))),
+		}
+		operation.Responses["500"] = &OpenAPIResponse{
+			Description: "服务器内部错误",
+			Content:     jsonContent(b.schemaFor(reflect.TypeOf(ErrorResponse{}))),
+		}
+	}
+
+	success := &OpenAPIResponse{Description: "成功"}
+	if doc.Response != nil {
+		success.Content = jsonContent(b.schemaFor(reflect.TypeOf(doc.Response)))
+	}
+	operation.Responses["200"] = success
+
+	return operation
+}
+
+// jsonContent 构建application/json内容描述
+func jsonContent(schema *OpenAPISchema) map[string]*OpenAPIMediaType {
+	return map[string]*OpenAPIMediaType{
+		"application/json": {Schema: schema},
+	}
+}
+
+// schemaFor 生成类型对应的Schema，结构体注册到components并返回引用
+func (b *schemaBuilder) schemaFor(t reflect.Type) *OpenAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(time.Duration(0)):
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &OpenAPISchema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return &OpenAPISchema{Ref: "#/components/schemas/" + b.registerStruct(t)}
+	default:
+		// interface{} 等任意类型
+		return &OpenAPISchema{}
+	}
+}
+
+// registerStruct 注册结构体Schema并返回组件名称
+func (b *schemaBuilder) registerStruct(t reflect.Type) string {
+	if name, exists := b.names[t]; exists {
+		return name
+	}
+
+	// 不同包的同名类型使用包名前缀区分
+	name := t.Name()
+	if _, exists := b.schemas[name]; exists || name == "" {
+		pkg := t.PkgPath()
+		if idx := strings.LastIndex(pkg, "/"); idx >= 0 {
+			pkg = pkg[idx+1:]
+		}
+		name = pkg + "." + t.Name()
+	}
+
+	// 先占位，避免递归类型无限展开
+	schema := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	b.names[t] = name
+	b.schemas[name] = schema
+
+	b.addStructFields(schema, t)
+	return name
+}
+
+// addStructFields 按json标签添加结构体字段（展开匿名嵌入字段）
+func (b *schemaBuilder) addStructFields(schema *OpenAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addStructFields(schema, embedded)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = b.schemaFor(field.Type)
+		if strings.Contains(field.Tag.Get("binding"), "required") && !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOpenAPITestRouter 创建注册了API路由和文档端点的测试路由
+func newOpenAPITestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	searchHandler := NewSearchHandler(nil)
+	recommendationHandler := NewRecommendationHandler(nil)
+	openAPIHandler := NewOpenAPIHandler("Memoro API", "test")
+
+	v1 := router.Group("/api/v1")
+	v1.GET("/health", HealthHandler)
+	v1.GET("/openapi.json", openAPIHandler.GetSpec)
+	v1.POST("/search", searchHandler.Search)
+	v1.GET("/search/stats", searchHandler.GetStats)
+	v1.POST("/recommendations", recommendationHandler.GetRecommendations)
+	v1.GET("/content/:id", HealthHandler)
+
+	openAPIHandler.Build(router.Routes())
+	return router
+}
+
+// fetchOpenAPISpec 请求并解析OpenAPI文档
+func fetchOpenAPISpec(t *testing.T, router *gin.Engine) map[string]interface{} {
+	req, _ := http.NewRequest("GET", "/api/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var spec map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	return spec
+}
+
+// TestOpenAPIHandler_GetSpec 测试OpenAPI文档生成
+func TestOpenAPIHandler_GetSpec(t *testing.T) {
+	router := newOpenAPITestRouter()
+	spec := fetchOpenAPISpec(t, router)
+	paths := spec["paths"].(map[string]interface{})
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	t.Run("文档基本信息", func(t *testing.T) {
+		assert.Equal(t, "3.0.3", spec["openapi"])
+		assert.Contains(t, paths, "/api/v1/health")
+		assert.Contains(t, paths, "/api/v1/recommendations")
+		assert.Contains(t, paths, "/api/v1/openapi.json")
+	})
+
+	t.Run("搜索接口包含请求体字段", func(t *testing.T) {
+		require.Contains(t, paths, "/api/v1/search")
+		operation := paths["/api/v1/search"].(map[string]interface{})["post"].(map[string]interface{})
+
+		schema := operation["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+		assert.Equal(t, "#/components/schemas/SearchRequest", schema["$ref"])
+
+		searchRequest := schemas["SearchRequest"].(map[string]interface{})
+		properties := searchRequest["properties"].(map[string]interface{})
+		for _, field := range []string{"query", "query_vector", "top_k", "min_similarity", "content_types", "user_id", "ranking"} {
+			assert.Contains(t, properties, field)
+		}
+		assert.Equal(t, "array", properties["content_types"].(map[string]interface{})["type"])
+		assert.Equal(t, "array", properties["query_vector"].(map[string]interface{})["type"])
+		// query与query_vector二选一，均不是必填字段
+		assert.NotContains(t, searchRequest, "required")
+	})
+
+	t.Run("响应引用嵌套类型", func(t *testing.T) {
+		searchResponse := schemas["SearchResponse"].(map[string]interface{})
+		properties := searchResponse["properties"].(map[string]interface{})
+
+		results := properties["results"].(map[string]interface{})
+		assert.Equal(t, "#/components/schemas/SearchResultItem", results["items"].(map[string]interface{})["$ref"])
+		assert.Equal(t, "date-time", properties["timestamp"].(map[string]interface{})["format"])
+		assert.Contains(t, schemas, "SearchResultItem")
+	})
+
+	t.Run("路径参数转换", func(t *testing.T) {
+		require.Contains(t, paths, "/api/v1/content/{id}")
+		operation := paths["/api/v1/content/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+		params := operation["parameters"].([]interface{})
+		require.Len(t, params, 1)
+		assert.Equal(t, "id", params[0].(map[string]interface{})["name"])
+	})
+}
+
+// TestOpenAPIHandler_NotBuilt 测试文档未生成时的响应
+func TestOpenAPIHandler_NotBuilt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/openapi.json", NewOpenAPIHandler("Memoro API", "test").GetSpec)
+
+	req, _ := http.NewRequest("GET", "/api/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
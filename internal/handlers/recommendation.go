@@ -77,6 +77,15 @@ func (h *RecommendationHandler) GetRecommendations(c *gin.Context) {
 		return
 	}
 
+	// 验证元数据过滤条件的值类型
+	if err := vector.ValidateMetadataFilters(req.MetadataFilters); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// 设置默认值
 	if req.MaxRecommendations <= 0 {
 		req.MaxRecommendations = 5
@@ -92,7 +101,14 @@ func (h *RecommendationHandler) GetRecommendations(c *gin.Context) {
 		SourceDocumentID:   req.SourceDocumentID,
 		MaxRecommendations: req.MaxRecommendations,
 		MinSimilarity:      float32(req.MinSimilarity),
+		MinConfidence:      req.MinConfidence,
+		MinScore:           req.MinScore,
 		ContentTypes:       stringSliceToContentTypes(req.ContentTypes),
+		TrendingWindow:     req.TrendingWindow,
+
+		IncludeExplanations: req.IncludeExplanations,
+		IncludeSeen:         req.IncludeSeen,
+		MetadataFilters:     req.MetadataFilters,
 	}
 
 	// 执行推荐
@@ -112,7 +128,11 @@ func (h *RecommendationHandler) GetRecommendations(c *gin.Context) {
 			"type":    req.Type,
 			"user_id": req.UserID,
 		})
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		status := http.StatusInternalServerError
+		if isThrottled(err) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, ErrorResponse{
 			Success: false,
 			Message: "Recommendation failed: " + err.Error(),
 		})
@@ -149,7 +169,17 @@ type RecommendationRequest struct {
 	SourceDocumentID   string   `json:"source_document_id,omitempty"`
 	MaxRecommendations int      `json:"max_recommendations,omitempty"`
 	MinSimilarity      float64  `json:"min_similarity,omitempty"`
+	MinConfidence      float64  `json:"min_confidence,omitempty"` // 最小归一化置信度（0-1），为空时使用配置默认值
+	MinScore           float64  `json:"min_score,omitempty"`      // 最小推荐分数，为空时使用该推荐类型的配置值
 	ContentTypes       []string `json:"content_types,omitempty"`
+
+	TrendingWindow string `json:"trending_window,omitempty"` // 热门推荐的命名时间窗口，如hot、week
+
+	IncludeExplanations bool `json:"include_explanations,omitempty"` // 是否在推荐项中返回解释
+
+	IncludeSeen *bool `json:"include_seen,omitempty"` // 是否保留已交互过的文档（重温场景），为空时使用配置默认值（排除）
+
+	MetadataFilters map[string]interface{} `json:"metadata_filters,omitempty"` // 自定义元数据过滤，如 {"project_id": 42}
 }
 
 // RecommendationResponse 推荐响应结构
@@ -171,6 +201,7 @@ func isValidRecommendationType(recType string) bool {
 		"popular",     // 热门内容推荐
 		"trending",    // 趋势内容推荐
 		"personalized", // 个性化推荐
+		"tag_based",   // 基于标签重叠的快速推荐
 	}
 
 	recType = strings.ToLower(recType)
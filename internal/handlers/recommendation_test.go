@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"memoro/internal/config"
 	"memoro/internal/services/vector"
 )
 
@@ -221,4 +222,118 @@ func TestRecommendationHandler_WithMockRecommender(t *testing.T) {
 		assert.False(t, response.Success)
 		assert.Contains(t, response.Message, "Invalid recommendation type")
 	})
-}
\ No newline at end of file
+}
+
+// TestRecommendationHandler_Explanations 测试请求推荐解释时解释随结果返回
+func TestRecommendationHandler_Explanations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRecommender := &MockRecommender{
+		GetRecommendationsFunc: func(ctx context.Context, request *vector.RecommendationRequest) (*vector.RecommendationResponse, error) {
+			item := &vector.RecommendationItem{DocumentID: "rec-doc-1", Similarity: 0.92, Rank: 1}
+			// 与推荐引擎一致：仅在请求解释时填充
+			if request.IncludeExplanations {
+				item.Explanation = &vector.RecommendationExplanation{
+					Reason:          "与源文档内容相似",
+					SimilarityScore: 0.92,
+					MatchedFeatures: []string{"go", "并发"},
+				}
+			}
+			return &vector.RecommendationResponse{
+				Recommendations:    []*vector.RecommendationItem{item},
+				RecommendationType: vector.RecommendationTypeSimilar,
+			}, nil
+		},
+	}
+
+	router := gin.New()
+	router.POST("/api/v1/recommendations", NewRecommendationHandler(mockRecommender).GetRecommendations)
+
+	recommend := func(includeExplanations bool) *RecommendationResponse {
+		body, _ := json.Marshal(RecommendationRequest{
+			Type:                "similar",
+			SourceDocumentID:    "doc-123",
+			IncludeExplanations: includeExplanations,
+		})
+		req, _ := http.NewRequest("POST", "/api/v1/recommendations", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response RecommendationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Recommendations, 1)
+		return &response
+	}
+
+	t.Run("请求解释时返回解释", func(t *testing.T) {
+		explanation := recommend(true).Recommendations[0].Explanation
+		require.NotNil(t, explanation)
+		assert.Equal(t, "与源文档内容相似", explanation.Reason)
+		assert.Equal(t, []string{"go", "并发"}, explanation.MatchedFeatures)
+	})
+
+	t.Run("未请求时不返回解释", func(t *testing.T) {
+		assert.Nil(t, recommend(false).Recommendations[0].Explanation)
+	})
+}
+
+// TestRecommendationHandler_RecommenderExplanations 测试推荐引擎生成的解释字段完整出现在接口的JSON响应中
+func TestRecommendationHandler_RecommenderExplanations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: "http://127.0.0.1:1", APIKey: "test-key", Timeout: time.Second},
+	}))
+
+	ctx := context.Background()
+	store := vector.NewMemoryStore()
+	for _, doc := range []*vector.VectorDocument{
+		{ID: "source", Content: "Go并发编程", Embedding: []float32{1, 0, 0}, Metadata: map[string]interface{}{"content_type": "text", "keywords": []interface{}{"go", "并发"}}},
+		{ID: "similar", Content: "goroutine调度", Embedding: []float32{0.9, 0.1, 0}, Metadata: map[string]interface{}{"content_type": "text", "keywords": []interface{}{"go", "调度"}}},
+	} {
+		doc.CreatedAt = time.Now()
+		require.NoError(t, store.AddDocument(ctx, doc))
+	}
+
+	engine, err := vector.NewSearchEngineWithStore(store)
+	require.NoError(t, err)
+	defer engine.Close()
+
+	router := gin.New()
+	router.POST("/api/v1/recommendations", NewRecommendationHandler(engine.Recommender()).GetRecommendations)
+
+	body, _ := json.Marshal(RecommendationRequest{
+		Type:                "similar",
+		SourceDocumentID:    "source",
+		IncludeExplanations: true,
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/recommendations", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Recommendations []struct {
+			DocumentID  string `json:"document_id"`
+			Explanation struct {
+				Reason          string             `json:"reason"`
+				SimilarityScore float64            `json:"similarity_score"`
+				FactorBreakdown map[string]float64 `json:"factor_breakdown"`
+				MatchedFeatures []string           `json:"matched_features"`
+			} `json:"explanation"`
+		} `json:"recommendations"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Recommendations, 1)
+
+	recommendation := response.Recommendations[0]
+	assert.Equal(t, "similar", recommendation.DocumentID)
+	explanation := recommendation.Explanation
+	assert.NotEmpty(t, explanation.Reason)
+	assert.Greater(t, explanation.SimilarityScore, 0.9)
+	assert.InDelta(t, explanation.SimilarityScore, explanation.FactorBreakdown["vector_similarity"], 1e-9)
+	assert.Contains(t, explanation.MatchedFeatures, "content_type:text")
+	assert.Contains(t, explanation.MatchedFeatures, "go")
+}
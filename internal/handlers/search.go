@@ -7,24 +7,35 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"memoro/internal/errors"
 	"memoro/internal/logger"
 	"memoro/internal/models"
+	"memoro/internal/services/content"
 	"memoro/internal/services/vector"
 )
 
 // SearchHandler 搜索API处理器
 type SearchHandler struct {
 	searchEngine SearchEngineInterface
+	scopes       SearchScopeServiceInterface // 保存的搜索范围（可选）
 	logger       *logger.Logger
 }
 
 // SearchEngineInterface 搜索引擎接口
 type SearchEngineInterface interface {
 	Search(ctx context.Context, options *vector.SearchOptions) (*vector.SearchResponse, error)
+	SearchByVector(ctx context.Context, queryVector []float32, options *vector.SearchOptions) (*vector.SearchResponse, error)
 	GetSearchStats(ctx context.Context) (map[string]interface{}, error)
 	Close() error
 }
 
+// SearchScopeServiceInterface 搜索范围服务接口
+type SearchScopeServiceInterface interface {
+	SaveSearchScope(ctx context.Context, userID, name string, options *vector.SearchOptions) (*content.SearchScope, error)
+	GetSearchScope(ctx context.Context, userID, name string) (*content.SearchScope, error)
+	ListSearchScopes(ctx context.Context, userID string) ([]*content.SearchScope, error)
+}
+
 // NewSearchHandler 创建搜索处理器
 func NewSearchHandler(searchEngine SearchEngineInterface) *SearchHandler {
 	return &SearchHandler{
@@ -33,15 +44,22 @@ func NewSearchHandler(searchEngine SearchEngineInterface) *SearchHandler {
 	}
 }
 
+// SetScopeService 设置搜索范围服务，未设置时scope参数和范围管理接口不可用
+func (h *SearchHandler) SetScopeService(scopes SearchScopeServiceInterface) {
+	h.scopes = scopes
+}
+
 // Search 执行语义搜索
 // @Summary 语义搜索
-// @Description 基于向量相似度的智能内容搜索
+// @Description 基于向量相似度的智能内容搜索，可传入query_vector代替query直接按向量检索，传入scope时合并已保存的过滤条件（请求中显式设置的字段优先）
 // @Tags search
 // @Accept json
 // @Produce json
 // @Param request body SearchRequest true "搜索请求"
 // @Success 200 {object} SearchResponse "搜索成功"
 // @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "搜索范围不存在"
+// @Failure 429 {object} ErrorResponse "并发搜索过多，服务繁忙"
 // @Failure 500 {object} ErrorResponse "服务器内部错误"
 // @Router /api/v1/search [post]
 func (h *SearchHandler) Search(c *gin.Context) {
@@ -61,31 +79,57 @@ func (h *SearchHandler) Search(c *gin.Context) {
 	}
 
 	// 验证必需参数
-	if req.Query == "" {
+	if req.Query == "" && len(req.QueryVector) == 0 {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Success: false,
-			Message: "Query parameter is required",
+			Message: "Query or query_vector parameter is required",
 		})
 		return
 	}
 
-	// 设置默认值
-	if req.TopK <= 0 {
-		req.TopK = 10
+	// 验证排序策略
+	if req.Ranking != "" && !vector.IsValidRankingStrategy(vector.RankingStrategy(req.Ranking)) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ranking strategy: " + req.Ranking,
+		})
+		return
+	}
+
+	// 验证检索模式
+	if req.Mode != "" && !vector.IsValidSearchMode(vector.SearchMode(req.Mode)) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid search mode: " + req.Mode,
+		})
+		return
 	}
-	if req.MinSimilarity <= 0 {
-		req.MinSimilarity = 0.7
+
+	// 验证片段来源
+	if req.SnippetSource != "" && !vector.IsValidSnippetSource(vector.SnippetSource(req.SnippetSource)) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid snippet source: " + req.SnippetSource,
+		})
+		return
 	}
 
 	// 构建搜索选项
-	searchOptions := &vector.SearchOptions{
-		Query:           req.Query,
-		TopK:            req.TopK,
-		MinSimilarity:   float32(req.MinSimilarity),
-		ContentTypes:    stringSliceToContentTypes(req.ContentTypes),
-		UserID:          req.UserID,
-		IncludeContent:  true,
-		SimilarityType:  vector.SimilarityTypeCosine,
+	searchOptions := searchOptionsFrom(&req)
+
+	// 合并保存的搜索范围（请求中显式设置的字段优先）
+	if req.Scope != "" {
+		if !h.applyScope(c, &req, searchOptions) {
+			return
+		}
+	}
+
+	// 设置默认值
+	if searchOptions.TopK <= 0 {
+		searchOptions.TopK = 10
+	}
+	if searchOptions.MinSimilarity <= 0 {
+		searchOptions.MinSimilarity = 0.7
 	}
 
 	// 执行搜索
@@ -98,14 +142,27 @@ func (h *SearchHandler) Search(c *gin.Context) {
 		return
 	}
 	
-	response, err := h.searchEngine.Search(c.Request.Context(), searchOptions)
+	var response *vector.SearchResponse
+	var err error
+	if len(req.QueryVector) > 0 {
+		response, err = h.searchEngine.SearchByVector(c.Request.Context(), req.QueryVector, searchOptions)
+	} else {
+		response, err = h.searchEngine.Search(c.Request.Context(), searchOptions)
+	}
 	if err != nil {
 		h.logger.Error("Search failed", logger.Fields{
-			"error":   err.Error(),
-			"query":   req.Query,
-			"user_id": req.UserID,
+			"error":         err.Error(),
+			"query":         req.Query,
+			"vector_length": len(req.QueryVector),
+			"user_id":       req.UserID,
 		})
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		status := http.StatusInternalServerError
+		if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeValidationFailed) {
+			status = http.StatusBadRequest
+		} else if isThrottled(err) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, ErrorResponse{
 			Success: false,
 			Message: "Search failed: " + err.Error(),
 		})
@@ -134,6 +191,171 @@ func (h *SearchHandler) Search(c *gin.Context) {
 	c.JSON(http.StatusOK, apiResponse)
 }
 
+// applyScope 加载请求指定的搜索范围并合并到搜索选项，失败时写入错误响应并返回false
+func (h *SearchHandler) applyScope(c *gin.Context, req *SearchRequest, searchOptions *vector.SearchOptions) bool {
+	if req.UserID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "user_id is required when scope is specified",
+		})
+		return false
+	}
+
+	if h.scopes == nil {
+		h.logger.Error("Search scope service is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Search scopes are not available",
+		})
+		return false
+	}
+
+	scope, err := h.scopes.GetSearchScope(c.Request.Context(), req.UserID, req.Scope)
+	if err != nil {
+		status := scopeErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to load search scope", logger.Fields{
+				"user_id": req.UserID,
+				"scope":   req.Scope,
+				"error":   err.Error(),
+			})
+		}
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return false
+	}
+
+	content.ApplySearchScope(searchOptions, scope)
+	return true
+}
+
+// SaveScope 保存搜索范围
+// @Summary 保存搜索范围
+// @Description 将常用的过滤条件保存为命名范围，搜索时通过scope参数引用，同名范围会被覆盖
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param request body SearchScopeRequest true "搜索范围"
+// @Success 200 {object} SearchScopeResponse "保存成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/search/scopes [post]
+func (h *SearchHandler) SaveScope(c *gin.Context) {
+	var req SearchScopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	if req.UserID == "" || req.Name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "user_id and name are required",
+		})
+		return
+	}
+
+	if h.scopes == nil {
+		h.logger.Error("Search scope service is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Search scopes are not available",
+		})
+		return
+	}
+
+	scope, err := h.scopes.SaveSearchScope(c.Request.Context(), req.UserID, req.Name, searchOptionsFrom(&req.Filters))
+	if err != nil {
+		status := scopeErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to save search scope", logger.Fields{
+				"user_id": req.UserID,
+				"scope":   req.Name,
+				"error":   err.Error(),
+			})
+		}
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SearchScopeResponse{
+		Success: true,
+		Scopes:  []*content.SearchScope{scope},
+	})
+}
+
+// ListScopes 列出用户保存的搜索范围
+// @Summary 列出搜索范围
+// @Description 列出用户保存的全部搜索范围
+// @Tags search
+// @Produce json
+// @Param user_id query string true "用户ID"
+// @Success 200 {object} SearchScopeResponse "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/search/scopes [get]
+func (h *SearchHandler) ListScopes(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "user_id is required",
+		})
+		return
+	}
+
+	if h.scopes == nil {
+		h.logger.Error("Search scope service is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Search scopes are not available",
+		})
+		return
+	}
+
+	scopes, err := h.scopes.ListSearchScopes(c.Request.Context(), userID)
+	if err != nil {
+		status := scopeErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			h.logger.Error("Failed to list search scopes", logger.Fields{
+				"user_id": userID,
+				"error":   err.Error(),
+			})
+		}
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SearchScopeResponse{
+		Success: true,
+		Scopes:  scopes,
+	})
+}
+
+// scopeErrorStatus 搜索范围错误对应的HTTP状态码
+func scopeErrorStatus(err error) int {
+	if memoErr, ok := err.(*errors.MemoroError); ok {
+		switch memoErr.Code {
+		case errors.ErrCodeResourceNotFound:
+			return http.StatusNotFound
+		case errors.ErrCodeValidationFailed:
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
 // GetStats 获取搜索统计信息
 // @Summary 获取搜索统计
 // @Description 获取搜索引擎的统计信息和性能指标
@@ -169,11 +391,51 @@ func (h *SearchHandler) GetStats(c *gin.Context) {
 
 // SearchRequest 搜索请求结构
 type SearchRequest struct {
-	Query         string   `json:"query" binding:"required"`
+	Query         string   `json:"query,omitempty"` // 查询文本，与query_vector二选一
 	TopK          int      `json:"top_k,omitempty"`
 	MinSimilarity float64  `json:"min_similarity,omitempty"`
 	ContentTypes  []string `json:"content_types,omitempty"`
 	UserID        string   `json:"user_id,omitempty"`
+	Ranking       string   `json:"ranking,omitempty"` // 排序策略: similarity, relevance, time, importance, hybrid, personalized
+	Mode          string   `json:"mode,omitempty"`    // 检索模式: vector, hybrid(向量与关键词融合), keyword，为空时使用配置默认值
+
+	IncludeArchived bool `json:"include_archived,omitempty"` // 同时检索已归档到冷存储的旧内容
+
+	CollapseDuplicates bool `json:"collapse_duplicates,omitempty"` // 折叠近似重复的结果
+	MinPerContentType  int  `json:"min_per_content_type,omitempty"` // 前top_k中每种内容类型至少保留的数量（有匹配时），如保证笔记不被链接挤出
+	CollapseThreads    bool `json:"collapse_threads,omitempty"`     // 同一会话线程的消息折叠为一个结果，结果中列出被折叠的消息ID
+
+	Languages              []string `json:"languages,omitempty"`                // 语言过滤，如 ["en"] 只返回英文内容
+	IncludeUnknownLanguage bool     `json:"include_unknown_language,omitempty"` // 语言过滤时包含没有语言信息的旧内容
+
+	EnableLLMRerank bool `json:"enable_llm_rerank,omitempty"` // 由LLM为排名靠前的结果打相关性分数并重排（精度更高，消耗token）
+
+	Fields []string `json:"fields,omitempty"` // 只返回指定字段，如 ["document_id","similarity","title","summary"]，不含content时不获取原文
+
+	SnippetSource string `json:"snippet_source,omitempty"` // 结果片段来源: computed(从原文截取), summary(使用已保存的摘要，无需原文)，为空时使用配置默认值
+
+	QueryVector []float32 `json:"query_vector,omitempty"` // 查询向量，提供时跳过文本向量化直接检索
+
+	MetadataFilters map[string]interface{} `json:"metadata_filters,omitempty"` // 自定义元数据过滤，如 {"project_id": 42}
+
+	RequireSummary *bool `json:"require_summary,omitempty"` // true只返回有摘要的内容，false只返回缺少摘要的内容
+	RequireTags    *bool `json:"require_tags,omitempty"`    // true只返回有标签的内容，false只返回缺少标签的内容
+	RequireIndexed *bool `json:"require_indexed,omitempty"` // true只返回已向量化的内容，false只返回未向量化的内容
+
+	Scope string `json:"scope,omitempty"` // 保存的搜索范围名称（需要user_id），请求中显式设置的字段优先
+}
+
+// SearchScopeRequest 保存搜索范围请求结构
+type SearchScopeRequest struct {
+	UserID  string        `json:"user_id"`
+	Name    string        `json:"name"`
+	Filters SearchRequest `json:"filters"` // 过滤条件（query、query_vector和scope不保存）
+}
+
+// SearchScopeResponse 搜索范围响应结构
+type SearchScopeResponse struct {
+	Success bool                   `json:"success"`
+	Scopes  []*content.SearchScope `json:"scopes"`
 }
 
 // SearchResponse 搜索响应结构
@@ -192,6 +454,46 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
+// isThrottled 检查错误是否表示需要稍后重试（额度用尽、提交刷屏、服务繁忙、LLM限流），各处理器统一返回429
+func isThrottled(err error) bool {
+	memoErr, ok := err.(*errors.MemoroError)
+	if !ok {
+		return false
+	}
+	switch memoErr.Code {
+	case errors.ErrCodeBudgetExceeded, errors.ErrCodeFloodDetected, errors.ErrCodeServerBusy, errors.ErrCodeLLMRateLimited:
+		return true
+	}
+	return false
+}
+
+// searchOptionsFrom 将搜索请求的过滤条件转换为搜索选项（不设置默认值）
+func searchOptionsFrom(req *SearchRequest) *vector.SearchOptions {
+	return &vector.SearchOptions{
+		Query:                  req.Query,
+		TopK:                   req.TopK,
+		MinSimilarity:          float32(req.MinSimilarity),
+		ContentTypes:           stringSliceToContentTypes(req.ContentTypes),
+		UserID:                 req.UserID,
+		IncludeContent:         true,
+		RankingStrategy:        vector.RankingStrategy(req.Ranking),
+		Mode:                   vector.SearchMode(req.Mode),
+		IncludeArchived:        req.IncludeArchived,
+		CollapseDuplicates:     req.CollapseDuplicates,
+		CollapseThreads:        req.CollapseThreads,
+		MinPerContentType:      req.MinPerContentType,
+		Languages:              req.Languages,
+		IncludeUnknownLanguage: req.IncludeUnknownLanguage,
+		EnableLLMRerank:        req.EnableLLMRerank,
+		Fields:                 req.Fields,
+		SnippetSource:          vector.SnippetSource(req.SnippetSource),
+		MetadataFilters:        req.MetadataFilters,
+		RequireSummary:         req.RequireSummary,
+		RequireTags:            req.RequireTags,
+		RequireIndexed:         req.RequireIndexed,
+	}
+}
+
 // stringSliceToContentTypes 将字符串切片转换为ContentType切片
 func stringSliceToContentTypes(strs []string) []models.ContentType {
 	if len(strs) == 0 {
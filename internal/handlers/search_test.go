@@ -13,6 +13,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"memoro/internal/errors"
+	"memoro/internal/models"
+	"memoro/internal/services/content"
 	"memoro/internal/services/vector"
 )
 
@@ -111,6 +114,7 @@ type TestSearchRequest struct {
 	MinSimilarity float64  `json:"min_similarity,omitempty"`
 	ContentTypes  []string `json:"content_types,omitempty"`
 	UserID        string   `json:"user_id,omitempty"`
+	Ranking       string   `json:"ranking,omitempty"`
 }
 
 // TestSearchResponse 测试用搜索响应结构
@@ -127,6 +131,8 @@ type TestSearchResponse struct {
 type MockSearchEngine struct {
 	SearchFunc    func(ctx context.Context, options *vector.SearchOptions) (*vector.SearchResponse, error)
 	GetStatsFunc  func(ctx context.Context) (map[string]interface{}, error)
+
+	SearchByVectorFunc func(ctx context.Context, queryVector []float32, options *vector.SearchOptions) (*vector.SearchResponse, error)
 }
 
 func (m *MockSearchEngine) Search(ctx context.Context, options *vector.SearchOptions) (*vector.SearchResponse, error) {
@@ -136,6 +142,13 @@ func (m *MockSearchEngine) Search(ctx context.Context, options *vector.SearchOpt
 	return nil, nil
 }
 
+func (m *MockSearchEngine) SearchByVector(ctx context.Context, queryVector []float32, options *vector.SearchOptions) (*vector.SearchResponse, error) {
+	if m.SearchByVectorFunc != nil {
+		return m.SearchByVectorFunc(ctx, queryVector, options)
+	}
+	return nil, nil
+}
+
 func (m *MockSearchEngine) GetSearchStats(ctx context.Context) (map[string]interface{}, error) {
 	if m.GetStatsFunc != nil {
 		return m.GetStatsFunc(ctx)
@@ -249,4 +262,216 @@ func TestSearchHandler_WithMockEngine(t *testing.T) {
 		assert.Equal(t, float64(150), response["total_searches"])
 		assert.Equal(t, float64(120), response["cache_hits"])
 	})
-}
\ No newline at end of file
+}
+// TestSearchHandler_Ranking 测试排序策略参数
+func TestSearchHandler_Ranking(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("传递有效排序策略", func(t *testing.T) {
+		var receivedStrategy vector.RankingStrategy
+		mockEngine := &MockSearchEngine{
+			SearchFunc: func(ctx context.Context, options *vector.SearchOptions) (*vector.SearchResponse, error) {
+				receivedStrategy = options.RankingStrategy
+				return &vector.SearchResponse{}, nil
+			},
+		}
+
+		router := gin.New()
+		router.POST("/api/v1/search", NewSearchHandler(mockEngine).Search)
+
+		body, _ := json.Marshal(TestSearchRequest{Query: "人工智能", Ranking: "time"})
+		req, _ := http.NewRequest("POST", "/api/v1/search", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, vector.RankingStrategyTime, receivedStrategy)
+	})
+
+	t.Run("拒绝未知排序策略", func(t *testing.T) {
+		called := false
+		mockEngine := &MockSearchEngine{
+			SearchFunc: func(ctx context.Context, options *vector.SearchOptions) (*vector.SearchResponse, error) {
+				called = true
+				return &vector.SearchResponse{}, nil
+			},
+		}
+
+		router := gin.New()
+		router.POST("/api/v1/search", NewSearchHandler(mockEngine).Search)
+
+		body, _ := json.Marshal(TestSearchRequest{Query: "人工智能", Ranking: "random"})
+		req, _ := http.NewRequest("POST", "/api/v1/search", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, called)
+	})
+}
+
+// TestSearchHandler_QueryVector 测试使用query_vector检索
+func TestSearchHandler_QueryVector(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("提供query_vector时按向量检索", func(t *testing.T) {
+		var receivedVector []float32
+		textSearchCalled := false
+		mockEngine := &MockSearchEngine{
+			SearchFunc: func(ctx context.Context, options *vector.SearchOptions) (*vector.SearchResponse, error) {
+				textSearchCalled = true
+				return &vector.SearchResponse{}, nil
+			},
+			SearchByVectorFunc: func(ctx context.Context, queryVector []float32, options *vector.SearchOptions) (*vector.SearchResponse, error) {
+				receivedVector = queryVector
+				return &vector.SearchResponse{}, nil
+			},
+		}
+
+		router := gin.New()
+		router.POST("/api/v1/search", NewSearchHandler(mockEngine).Search)
+
+		body, _ := json.Marshal(SearchRequest{QueryVector: []float32{0.1, 0.2, 0.3}})
+		req, _ := http.NewRequest("POST", "/api/v1/search", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, receivedVector)
+		assert.False(t, textSearchCalled)
+	})
+
+	t.Run("维度不匹配返回400", func(t *testing.T) {
+		mockEngine := &MockSearchEngine{
+			SearchByVectorFunc: func(ctx context.Context, queryVector []float32, options *vector.SearchOptions) (*vector.SearchResponse, error) {
+				return nil, errors.ErrValidationFailed("query_vector", "dimension mismatch: expected 3, got 2")
+			},
+		}
+
+		router := gin.New()
+		router.POST("/api/v1/search", NewSearchHandler(mockEngine).Search)
+
+		body, _ := json.Marshal(SearchRequest{QueryVector: []float32{0.1, 0.2}})
+		req, _ := http.NewRequest("POST", "/api/v1/search", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// MockSearchScopeService 模拟搜索范围服务（用于测试）
+type MockSearchScopeService struct {
+	scopes map[string]*content.SearchScope
+}
+
+func (m *MockSearchScopeService) SaveSearchScope(ctx context.Context, userID, name string, options *vector.SearchOptions) (*content.SearchScope, error) {
+	scope := &content.SearchScope{Name: name, UserID: userID, Filters: options}
+	m.scopes[userID+"/"+name] = scope
+	return scope, nil
+}
+
+func (m *MockSearchScopeService) GetSearchScope(ctx context.Context, userID, name string) (*content.SearchScope, error) {
+	if scope, exists := m.scopes[userID+"/"+name]; exists {
+		return scope, nil
+	}
+	return nil, errors.ErrResourceNotFound("search_scope", name)
+}
+
+func (m *MockSearchScopeService) ListSearchScopes(ctx context.Context, userID string) ([]*content.SearchScope, error) {
+	scopes := make([]*content.SearchScope, 0)
+	for _, scope := range m.scopes {
+		if scope.UserID == userID {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// TestSearchHandler_Scope 测试保存搜索范围并在搜索时合并
+func TestSearchHandler_Scope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received *vector.SearchOptions
+	mockEngine := &MockSearchEngine{
+		SearchFunc: func(ctx context.Context, options *vector.SearchOptions) (*vector.SearchResponse, error) {
+			received = options
+			return &vector.SearchResponse{}, nil
+		},
+	}
+	handler := NewSearchHandler(mockEngine)
+	handler.SetScopeService(&MockSearchScopeService{scopes: make(map[string]*content.SearchScope)})
+
+	router := gin.New()
+	router.POST("/api/v1/search", handler.Search)
+	router.POST("/api/v1/search/scopes", handler.SaveScope)
+	router.GET("/api/v1/search/scopes", handler.ListScopes)
+
+	post := func(path string, payload interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/api/v1/search/scopes", map[string]interface{}{
+		"user_id": "user-1",
+		"name":    "my-links",
+		"filters": map[string]interface{}{
+			"content_types":  []string{"link"},
+			"top_k":          5,
+			"min_similarity": 0.5,
+			"ranking":        "time",
+		},
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	t.Run("列出保存的范围", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/search/scopes?user_id=user-1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response SearchScopeResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Scopes, 1)
+		assert.Equal(t, "my-links", response.Scopes[0].Name)
+	})
+
+	t.Run("搜索时合并范围，显式字段优先", func(t *testing.T) {
+		w := post("/api/v1/search", map[string]interface{}{
+			"query":   "数据库索引",
+			"user_id": "user-1",
+			"scope":   "my-links",
+			"top_k":   20,
+		})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, received)
+		assert.Equal(t, "数据库索引", received.Query)
+		assert.Equal(t, 20, received.TopK)
+		assert.Equal(t, float32(0.5), received.MinSimilarity)
+		assert.Equal(t, vector.RankingStrategyTime, received.RankingStrategy)
+		assert.Equal(t, []models.ContentType{models.ContentTypeLink}, received.ContentTypes)
+	})
+
+	t.Run("范围不存在返回404", func(t *testing.T) {
+		w := post("/api/v1/search", map[string]interface{}{"query": "数据库", "user_id": "user-1", "scope": "unknown"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("指定范围时需要user_id", func(t *testing.T) {
+		w := post("/api/v1/search", map[string]interface{}{"query": "数据库", "scope": "my-links"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
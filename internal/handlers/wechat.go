@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"memoro/internal/logger"
+	"memoro/internal/wechat"
+)
+
+// WeChatLoginInterface 微信登录接口
+type WeChatLoginInterface interface {
+	StartLogin() (*wechat.LoginSession, error)
+	WaitForLogin(session *wechat.LoginSession) (string, error)
+}
+
+// WeChatStatusInterface 微信登录状态查询接口
+type WeChatStatusInterface interface {
+	CheckCurrentStatus() (bool, error)
+}
+
+// WeChatHandler 微信登录API处理器
+type WeChatHandler struct {
+	client        WeChatLoginInterface
+	statusChecker WeChatStatusInterface
+	logger        *logger.Logger
+
+	mu      sync.Mutex
+	pending *wechat.LoginSession // 正在等待扫码的登录会话（同一时间只有一个）
+}
+
+// WeChatLoginResponse 微信登录响应结构
+type WeChatLoginResponse struct {
+	Success      bool      `json:"success"`
+	QRCode       string    `json:"qr_code"`       // 二维码内容（URL），由前端渲染为二维码
+	SessionToken string    `json:"session_token"` // 本次登录会话的授权码
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WeChatStatusResponse 微信登录状态响应结构
+type WeChatStatusResponse struct {
+	Success   bool      `json:"success"`
+	LoggedIn  bool      `json:"logged_in"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// NewWeChatHandler 创建微信登录处理器
+func NewWeChatHandler(client WeChatLoginInterface, statusChecker WeChatStatusInterface) *WeChatHandler {
+	return &WeChatHandler{
+		client:        client,
+		statusChecker: statusChecker,
+		logger:        logger.NewLogger("wechat-handler"),
+	}
+}
+
+// Login 发起微信登录并返回二维码
+// @Summary 微信登录
+// @Description 生成登录二维码和会话授权码，后台等待扫码完成；已有会话在等待扫码时返回该会话（需要管理令牌）
+// @Tags wechat
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} WeChatLoginResponse "二维码已生成"
+// @Failure 401 {object} ErrorResponse "管理令牌无效"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/wechat/login [get]
+func (h *WeChatHandler) Login(c *gin.Context) {
+	if h.client == nil {
+		h.logger.Error("WeChat client is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "WeChat client is not available",
+		})
+		return
+	}
+
+	session, err := h.pendingSession()
+	if err != nil {
+		h.logger.Error("Failed to start WeChat login", logger.Fields{
+			"error": err.Error(),
+		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, WeChatLoginResponse{
+		Success:      true,
+		QRCode:       session.QRCode,
+		SessionToken: session.Token,
+		CreatedAt:    session.CreatedAt,
+	})
+}
+
+// pendingSession 返回正在等待扫码的登录会话，没有时发起新的登录并在后台等待扫码完成
+func (h *WeChatHandler) pendingSession() (*wechat.LoginSession, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending != nil {
+		return h.pending, nil
+	}
+
+	session, err := h.client.StartLogin()
+	if err != nil {
+		return nil, err
+	}
+	h.pending = session
+	go h.awaitLogin(session)
+	return session, nil
+}
+
+// awaitLogin 等待登录会话完成并记录结果
+func (h *WeChatHandler) awaitLogin(session *wechat.LoginSession) {
+	wxid, err := h.client.WaitForLogin(session)
+
+	h.mu.Lock()
+	if h.pending == session {
+		h.pending = nil
+	}
+	h.mu.Unlock()
+
+	if err != nil {
+		h.logger.Warn("WeChat login did not complete", logger.Fields{
+			"uuid":  session.UUID,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.logger.Info("WeChat login completed", logger.Fields{
+		"uuid": session.UUID,
+		"wxid": wxid,
+	})
+}
+
+// Status 查询微信登录状态
+// @Summary 微信登录状态
+// @Description 查询当前微信是否处于登录状态（需要管理令牌）
+// @Tags wechat
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} WeChatStatusResponse "查询成功"
+// @Failure 401 {object} ErrorResponse "管理令牌无效"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/wechat/status [get]
+func (h *WeChatHandler) Status(c *gin.Context) {
+	if h.statusChecker == nil {
+		h.logger.Error("WeChat status checker is not initialized")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "WeChat status checker is not available",
+		})
+		return
+	}
+
+	loggedIn, err := h.statusChecker.CheckCurrentStatus()
+	if err != nil {
+		h.logger.Error("Failed to check WeChat login status", logger.Fields{
+			"error": err.Error(),
+		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, WeChatStatusResponse{
+		Success:   true,
+		LoggedIn:  loggedIn,
+		CheckedAt: time.Now(),
+	})
+}
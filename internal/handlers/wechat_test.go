@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/wechat"
+)
+
+// MockWeChatClient 模拟微信客户端（用于测试）
+type MockWeChatClient struct {
+	StartLoginFunc func() (*wechat.LoginSession, error)
+	waited         chan *wechat.LoginSession
+	release        chan struct{} // 不为nil时等待扫码阻塞到关闭
+}
+
+func (m *MockWeChatClient) StartLogin() (*wechat.LoginSession, error) {
+	return m.StartLoginFunc()
+}
+
+func (m *MockWeChatClient) WaitForLogin(session *wechat.LoginSession) (string, error) {
+	m.waited <- session
+	if m.release != nil {
+		<-m.release
+	}
+	return "wxid_test", nil
+}
+
+// MockWeChatStatusChecker 模拟微信状态检查器（用于测试）
+type MockWeChatStatusChecker struct {
+	LoggedIn bool
+	Err      error
+}
+
+func (m *MockWeChatStatusChecker) CheckCurrentStatus() (bool, error) {
+	return m.LoggedIn, m.Err
+}
+
+// newWeChatTestRouter 创建带管理令牌鉴权的微信路由
+func newWeChatTestRouter(handler *WeChatHandler, token string) *gin.Engine {
+	router := gin.New()
+	group := router.Group("/api/v1/wechat", AdminAuth(token))
+	group.GET("/login", handler.Login)
+	group.GET("/status", handler.Status)
+	return router
+}
+
+// TestWeChatHandler 测试微信登录API
+func TestWeChatHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client := &MockWeChatClient{
+		StartLoginFunc: func() (*wechat.LoginSession, error) {
+			return &wechat.LoginSession{Token: "auth-key-1", QRCode: "http://weixin.qq.com/x/abc", UUID: "abc", CreatedAt: time.Now()}, nil
+		},
+		waited: make(chan *wechat.LoginSession, 1),
+	}
+	checker := &MockWeChatStatusChecker{}
+	router := newWeChatTestRouter(NewWeChatHandler(client, checker), "secret")
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("未登录时返回二维码和会话令牌", func(t *testing.T) {
+		w := get("/api/v1/wechat/status", "secret")
+		require.Equal(t, http.StatusOK, w.Code)
+		var status WeChatStatusResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.False(t, status.LoggedIn)
+
+		w = get("/api/v1/wechat/login", "secret")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response WeChatLoginResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.Equal(t, "http://weixin.qq.com/x/abc", response.QRCode)
+		assert.Equal(t, "auth-key-1", response.SessionToken)
+
+		// 后台等待同一会话扫码
+		select {
+		case session := <-client.waited:
+			assert.Equal(t, "abc", session.UUID)
+		case <-time.After(time.Second):
+			t.Fatal("login session was not awaited")
+		}
+	})
+
+	t.Run("等待扫码期间复用同一会话", func(t *testing.T) {
+		var started int32
+		pendingClient := &MockWeChatClient{
+			StartLoginFunc: func() (*wechat.LoginSession, error) {
+				n := atomic.AddInt32(&started, 1)
+				return &wechat.LoginSession{Token: "auth-key-1", QRCode: fmt.Sprintf("http://weixin.qq.com/x/%d", n), UUID: fmt.Sprint(n), CreatedAt: time.Now()}, nil
+			},
+			waited:  make(chan *wechat.LoginSession, 1),
+			release: make(chan struct{}),
+		}
+		router := newWeChatTestRouter(NewWeChatHandler(pendingClient, checker), "secret")
+		login := func() WeChatLoginResponse {
+			req, _ := http.NewRequest("GET", "/api/v1/wechat/login", nil)
+			req.Header.Set(AdminTokenHeader, "secret")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+			var response WeChatLoginResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			return response
+		}
+
+		var wg sync.WaitGroup
+		responses := make([]WeChatLoginResponse, 5)
+		for i := range responses {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				responses[idx] = login()
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&started))
+		for _, response := range responses {
+			assert.Equal(t, "http://weixin.qq.com/x/1", response.QRCode)
+		}
+
+		// 扫码结束后发起新的会话
+		<-pendingClient.waited
+		close(pendingClient.release)
+		require.Eventually(t, func() bool {
+			return login().QRCode == "http://weixin.qq.com/x/2"
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&started))
+	})
+
+	t.Run("已登录状态", func(t *testing.T) {
+		checker.LoggedIn = true
+		defer func() { checker.LoggedIn = false }()
+
+		w := get("/api/v1/wechat/status", "secret")
+		require.Equal(t, http.StatusOK, w.Code)
+		var status WeChatStatusResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.True(t, status.Success)
+		assert.True(t, status.LoggedIn)
+	})
+
+	t.Run("状态检查失败返回500", func(t *testing.T) {
+		checker.Err = fmt.Errorf("请求失败")
+		defer func() { checker.Err = nil }()
+
+		w := get("/api/v1/wechat/status", "secret")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("生成二维码失败返回500", func(t *testing.T) {
+		failing := &MockWeChatClient{StartLoginFunc: func() (*wechat.LoginSession, error) {
+			return nil, fmt.Errorf("生成授权码失败")
+		}}
+		router := newWeChatTestRouter(NewWeChatHandler(failing, checker), "secret")
+
+		req, _ := http.NewRequest("GET", "/api/v1/wechat/login", nil)
+		req.Header.Set(AdminTokenHeader, "secret")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("缺少或错误的管理令牌返回401", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get("/api/v1/wechat/login", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, get("/api/v1/wechat/status", "wrong").Code)
+	})
+
+	t.Run("未配置管理令牌时禁用", func(t *testing.T) {
+		router := newWeChatTestRouter(NewWeChatHandler(client, checker), "")
+
+		req, _ := http.NewRequest("GET", "/api/v1/wechat/status", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"memoro/internal/errors"
+)
+
+// AuditSchemaVersion 审计记录JSON结构版本（字段变更时递增）
+const AuditSchemaVersion = 1
+
+// AuditAction 审计操作类型
+type AuditAction string
+
+const (
+	AuditActionIndex      AuditAction = "index"
+	AuditActionUpdate     AuditAction = "update"
+	AuditActionDelete     AuditAction = "delete"
+	AuditActionReprocess  AuditAction = "reprocess"
+	AuditActionSoftDelete AuditAction = "soft_delete"
+	AuditActionArchive    AuditAction = "archive"
+	AuditActionRestore    AuditAction = "restore"
+)
+
+// DefaultAuditActor 上下文中未携带操作者时使用的默认操作者
+const DefaultAuditActor = "system"
+
+// AuditRecord 审计记录（每条记录序列化为一行JSON）
+type AuditRecord struct {
+	SchemaVersion int                    `json:"schema_version"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Action        AuditAction            `json:"action"`
+	DocumentID    string                 `json:"document_id"`
+	UserID        string                 `json:"user_id"`
+	Actor         string                 `json:"actor"`
+	Before        map[string]interface{} `json:"before"` // 变更前关键字段摘要（新建时为null）
+	After         map[string]interface{} `json:"after"`  // 变更后关键字段摘要（删除时为null）
+}
+
+// AuditLogger 审计日志器，独立于应用日志输出
+type AuditLogger struct {
+	enabled bool
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+var (
+	// 全局审计日志器（默认关闭）
+	defaultAuditLogger = &AuditLogger{}
+)
+
+// NewAuditLogger 创建写入指定输出的审计日志器
+func NewAuditLogger(output io.Writer) *AuditLogger {
+	return &AuditLogger{
+		enabled: output != nil,
+		encoder: json.NewEncoder(output),
+	}
+}
+
+// InitAuditLogger 初始化全局审计日志器，output为文件路径，空或stdout时输出到标准输出
+func InitAuditLogger(enabled bool, output string) (*AuditLogger, error) {
+	if !enabled {
+		defaultAuditLogger = &AuditLogger{}
+		return defaultAuditLogger, nil
+	}
+
+	var writer io.Writer = os.Stdout
+	if output != "" && output != "stdout" {
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			return nil, errors.ErrConfigInvalid("audit log directory", err.Error()).WithCause(err)
+		}
+
+		file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			return nil, errors.ErrConfigInvalid("audit log file", err.Error()).WithCause(err)
+		}
+		writer = file
+	}
+
+	defaultAuditLogger = NewAuditLogger(writer)
+	return defaultAuditLogger, nil
+}
+
+// GetAuditLogger 获取全局审计日志器
+func GetAuditLogger() *AuditLogger {
+	return defaultAuditLogger
+}
+
+// Enabled 是否启用审计
+func (a *AuditLogger) Enabled() bool {
+	return a != nil && a.enabled
+}
+
+// Record 写入一条审计记录，未设置的时间戳和操作者自动补全
+func (a *AuditLogger) Record(ctx context.Context, record AuditRecord) {
+	if !a.Enabled() {
+		return
+	}
+
+	record.SchemaVersion = AuditSchemaVersion
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now().UTC()
+	}
+	if record.Actor == "" {
+		record.Actor = auditActorFromContext(ctx)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.encoder.Encode(record); err != nil {
+		GetDefaultLogger().WithFields(Fields{
+			"action":      string(record.Action),
+			"document_id": record.DocumentID,
+			"error":       err.Error(),
+		}).Error("Failed to write audit record")
+	}
+}
+
+// auditActorFromContext 从上下文中提取操作者
+func auditActorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return DefaultAuditActor
+	}
+	if actor, ok := ctx.Value("actor").(string); ok && actor != "" {
+		return actor
+	}
+	if userID, ok := ctx.Value("user_id").(string); ok && userID != "" {
+		return userID
+	}
+	return DefaultAuditActor
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"memoro/internal/errors"
+)
+
+// DefaultRedactionMask 脱敏后的占位值
+const DefaultRedactionMask = "[REDACTED]"
+
+// DefaultSensitiveKeys 默认脱敏的字段名（不区分大小写）
+var DefaultSensitiveKeys = []string{
+	"api_key", "apikey", "authorization", "password", "secret", "token", "admin_token",
+	"content", "raw_content",
+}
+
+// DefaultSensitivePatterns 默认脱敏的值模式（匹配部分替换为占位值）
+var DefaultSensitivePatterns = []string{
+	`sk-[A-Za-z0-9]{16,}`,
+	`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`,
+}
+
+// Redactor 日志字段脱敏器：敏感字段名的值整体替换，字符串值中匹配敏感模式的部分替换
+type Redactor struct {
+	keys     map[string]bool
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+// NewRedactor 创建脱敏器，keys和patterns为空时使用默认值
+func NewRedactor(keys []string, patterns []string, mask string) (*Redactor, error) {
+	if len(keys) == 0 {
+		keys = DefaultSensitiveKeys
+	}
+	if len(patterns) == 0 {
+		patterns = DefaultSensitivePatterns
+	}
+	if mask == "" {
+		mask = DefaultRedactionMask
+	}
+
+	redactor := &Redactor{
+		keys:     make(map[string]bool, len(keys)),
+		patterns: make([]*regexp.Regexp, 0, len(patterns)),
+		mask:     mask,
+	}
+	for _, key := range keys {
+		redactor.keys[strings.ToLower(strings.TrimSpace(key))] = true
+	}
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.ErrConfigInvalid("logging.redaction.patterns", err.Error()).WithCause(err)
+		}
+		redactor.patterns = append(redactor.patterns, compiled)
+	}
+
+	return redactor, nil
+}
+
+// RedactFields 返回脱敏后的字段副本（递归处理嵌套map，不修改原字段）
+func (r *Redactor) RedactFields(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+
+	redacted := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if r.keys[strings.ToLower(key)] {
+			redacted[key] = r.mask
+			continue
+		}
+		redacted[key] = r.redactValue(value)
+	}
+	return redacted
+}
+
+// redactValue 脱敏单个值
+func (r *Redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return r.redactString(v)
+	case map[string]interface{}:
+		return r.RedactFields(v)
+	case Fields:
+		return Fields(r.RedactFields(v))
+	case logrus.Fields:
+		return logrus.Fields(r.RedactFields(v))
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for key, s := range v {
+			if r.keys[strings.ToLower(key)] {
+				redacted[key] = r.mask
+			} else {
+				redacted[key] = r.redactString(s)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = r.redactValue(item)
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(v))
+		for i, s := range v {
+			redacted[i] = r.redactString(s)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// redactString 替换字符串中匹配敏感模式的部分
+func (r *Redactor) redactString(s string) string {
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllString(s, r.mask)
+	}
+	return s
+}
+
+// redactionHook 输出前对日志字段脱敏的logrus钩子
+type redactionHook struct {
+	redactor *Redactor
+}
+
+// Levels 对所有级别生效
+func (h *redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 替换日志条目的字段（日志级别为debug及以上详细级别时保留原值，便于排查）
+func (h *redactionHook) Fire(entry *logrus.Entry) error {
+	if entry.Logger != nil && entry.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return nil
+	}
+	entry.Data = logrus.Fields(h.redactor.RedactFields(entry.Data))
+	return nil
+}
+
+// EnableRedaction 为默认日志器（及所有组件日志器）启用字段脱敏
+func EnableRedaction(redactor *Redactor) {
+	GetDefaultLogger().EnableRedaction(redactor)
+}
+
+// EnableRedaction 为日志器启用字段脱敏
+func (l *Logger) EnableRedaction(redactor *Redactor) {
+	if redactor == nil {
+		return
+	}
+	l.Logger.AddHook(&redactionHook{redactor: redactor})
+}
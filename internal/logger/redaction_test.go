@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRedactedTestLogger 创建输出JSON到缓冲区并启用脱敏的日志器
+func newRedactedTestLogger(t *testing.T, level logrus.Level) (*Logger, *bytes.Buffer) {
+	redactor, err := NewRedactor(nil, nil, "")
+	require.NoError(t, err)
+
+	base := logrus.New()
+	buffer := &bytes.Buffer{}
+	base.SetOutput(buffer)
+	base.SetFormatter(&logrus.JSONFormatter{})
+	base.SetLevel(level)
+
+	testLogger := &Logger{Logger: base, component: "redaction-test"}
+	testLogger.EnableRedaction(redactor)
+	return testLogger, buffer
+}
+
+// decodeLogLine 解析一行JSON日志
+func decodeLogLine(t *testing.T, buffer *bytes.Buffer) map[string]interface{} {
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &line))
+	buffer.Reset()
+	return line
+}
+
+// TestRedactor 测试日志字段脱敏
+func TestRedactor(t *testing.T) {
+	t.Run("敏感字段被替换，普通字段保留", func(t *testing.T) {
+		testLogger, buffer := newRedactedTestLogger(t, logrus.InfoLevel)
+		testLogger.Info("LLM client configured", Fields{
+			"api_key": "sk-testkey0000000000000",
+			"model":   "gpt-4",
+		})
+
+		line := decodeLogLine(t, buffer)
+		assert.Equal(t, DefaultRedactionMask, line["api_key"])
+		assert.Equal(t, "gpt-4", line["model"])
+		assert.Equal(t, "redaction-test", line["component"])
+	})
+
+	t.Run("递归处理嵌套map且不修改原字段", func(t *testing.T) {
+		testLogger, buffer := newRedactedTestLogger(t, logrus.InfoLevel)
+		headers := map[string]interface{}{"Authorization": "Bearer abc.def", "accept": "application/json"}
+		testLogger.Info("Request sent", Fields{
+			"request": map[string]interface{}{"headers": headers, "content": "私人笔记"},
+		})
+
+		request := decodeLogLine(t, buffer)["request"].(map[string]interface{})
+		assert.Equal(t, DefaultRedactionMask, request["content"])
+		assert.Equal(t, DefaultRedactionMask, request["headers"].(map[string]interface{})["Authorization"])
+		assert.Equal(t, "application/json", request["headers"].(map[string]interface{})["accept"])
+		assert.Equal(t, "Bearer abc.def", headers["Authorization"])
+	})
+
+	t.Run("字符串值中匹配敏感模式的部分被替换", func(t *testing.T) {
+		testLogger, buffer := newRedactedTestLogger(t, logrus.InfoLevel)
+		testLogger.Warn("Request failed", Fields{"error": "invalid key sk-testkey0000000000000 rejected"})
+
+		assert.Equal(t, "invalid key [REDACTED] rejected", decodeLogLine(t, buffer)["error"])
+	})
+
+	t.Run("debug级别保留原值", func(t *testing.T) {
+		testLogger, buffer := newRedactedTestLogger(t, logrus.DebugLevel)
+		testLogger.Info("LLM client configured", Fields{"api_key": "sk-test"})
+
+		assert.Equal(t, "sk-test", decodeLogLine(t, buffer)["api_key"])
+	})
+
+	t.Run("自定义字段名和无效模式", func(t *testing.T) {
+		redactor, err := NewRedactor([]string{"Phone"}, []string{`\d{11}`}, "***")
+		require.NoError(t, err)
+		redacted := redactor.RedactFields(map[string]interface{}{"phone": "13800000000", "note": "call 13800000000", "api_key": "k"})
+		assert.Equal(t, "***", redacted["phone"])
+		assert.Equal(t, "call ***", redacted["note"])
+		assert.Equal(t, "k", redacted["api_key"])
+
+		_, err = NewRedactor(nil, []string{"("}, "")
+		assert.Error(t, err)
+	})
+}
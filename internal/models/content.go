@@ -38,6 +38,9 @@ func IsValidContentType(ct ContentType) bool {
 	return false
 }
 
+// ThreadDocumentIDPrefix 会话线程汇总文档ID的前缀，汇总文档与线程内的消息内容重复，不出现在时间线中
+const ThreadDocumentIDPrefix = "thread-"
+
 // Summary 摘要结构
 type Summary struct {
 	OneLine   string `json:"one_line" gorm:"column:summary_one_line"`   // 一句话摘要
@@ -71,7 +74,9 @@ type ContentItem struct {
 	VectorID        string      `json:"vector_id"`                      // 向量数据库ID
 	CreatedAt       time.Time   `json:"created_at"`
 	UpdatedAt       time.Time   `json:"updated_at"`
-	UserID          string      `json:"user_id"` // 用户ID
+	UserID          string      `json:"user_id"`                                                   // 用户ID
+	Sensitive       bool        `json:"sensitive,omitempty"`                                       // 敏感内容，保存时始终加密原始内容（需要配置静态加密）
+	CanonicalURL    string      `json:"canonical_url,omitempty" gorm:"column:canonical_url;index"` // 链接内容的规范URL（按用户去重相同文章的变体）
 
 	// 内存中的字段，不存储到数据库
 	processedDataMap map[string]interface{} `json:"processed_data" gorm:"-"`
@@ -223,6 +228,10 @@ func (c *ContentItem) SetTags(tags []string) error {
 	}
 
 	c.tagsList = cleanTags
+	if len(cleanTags) == 0 {
+		// 标签被清空时同步清空列值（序列化只写入非空列表）
+		c.Tags = ""
+	}
 	c.UpdatedAt = time.Now()
 
 	c.logger.Debug("Tags updated", logger.Fields{
@@ -383,6 +392,12 @@ func (c *ContentItem) BeforeCreate(tx *gorm.DB) error {
 		return err
 	}
 
+	// 按加密设置加密原始内容和摘要
+	if err := c.encryptFields(tx); err != nil {
+		c.logger.LogMemoroError(err.(*errors.MemoroError), "Content encryption failed")
+		return err
+	}
+
 	c.logger.Debug("ContentItem ready for database creation", logger.Fields{
 		"content_id": c.ID,
 		"type":       string(c.Type),
@@ -412,6 +427,12 @@ func (c *ContentItem) BeforeUpdate(tx *gorm.DB) error {
 		return err
 	}
 
+	// 按加密设置加密原始内容和摘要
+	if err := c.encryptFields(tx); err != nil {
+		c.logger.LogMemoroError(err.(*errors.MemoroError), "Content encryption failed")
+		return err
+	}
+
 	c.logger.Debug("ContentItem ready for database update", logger.Fields{
 		"content_id": c.ID,
 		"updated_at": c.UpdatedAt,
@@ -426,6 +447,12 @@ func (c *ContentItem) AfterFind(tx *gorm.DB) error {
 		c.logger = logger.NewLogger("content-model")
 	}
 
+	// 解密加密保存的原始内容和摘要
+	if err := c.decryptFields(contentEncryptionFrom(tx)); err != nil {
+		c.logger.LogMemoroError(err.(*errors.MemoroError), "Content decryption failed")
+		return err
+	}
+
 	// 反序列化ProcessedData字段到processedDataMap
 	if err := c.deserializeProcessedData(); err != nil {
 		return err
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+	"memoro/internal/errors"
+)
+
+// EncryptedValuePrefix 加密字段的前缀，用于区分密文和未加密的历史数据
+const EncryptedValuePrefix = "enc:v1:"
+
+// ContentCipher 内容字段加密器（由存储层按配置的密钥创建）
+type ContentCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// ContentEncryption 内容静态加密设置，由存储层放入查询上下文，GORM钩子据此加解密内容字段
+type ContentEncryption struct {
+	Cipher           ContentCipher // 加密器
+	EncryptAll       bool          // 加密全部内容（否则仅加密标记为敏感的内容）
+	EncryptSummaries bool          // 同时加密摘要
+}
+
+// contentEncryptionKey 上下文中内容加密设置的键
+type contentEncryptionKey struct{}
+
+// WithContentEncryption 返回携带内容加密设置的上下文
+func WithContentEncryption(ctx context.Context, encryption *ContentEncryption) context.Context {
+	return context.WithValue(ctx, contentEncryptionKey{}, encryption)
+}
+
+// contentEncryptionFrom 从GORM语句的上下文中读取内容加密设置，未设置时返回nil
+func contentEncryptionFrom(tx *gorm.DB) *ContentEncryption {
+	if tx == nil || tx.Statement == nil || tx.Statement.Context == nil {
+		return nil
+	}
+	encryption, _ := tx.Statement.Context.Value(contentEncryptionKey{}).(*ContentEncryption)
+	return encryption
+}
+
+// IsEncryptedValue 判断字段值是否为加密后的密文
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, EncryptedValuePrefix)
+}
+
+// encryptFields 保存前按加密设置加密原始内容、处理数据（标题、描述、原始标记等均取自正文）和摘要，已加密的字段不重复加密
+func (c *ContentItem) encryptFields(tx *gorm.DB) error {
+	encryption := contentEncryptionFrom(tx)
+	if encryption == nil {
+		if c.Sensitive {
+			return errors.ErrValidationFailed("sensitive", "encryption at rest is not configured")
+		}
+		return nil
+	}
+	if !encryption.EncryptAll && !c.Sensitive {
+		return nil
+	}
+
+	fields := []*string{&c.RawContent, &c.ProcessedData}
+	if encryption.EncryptSummaries {
+		fields = append(fields, &c.Summary.OneLine, &c.Summary.Paragraph, &c.Summary.Detailed)
+	}
+	for _, field := range fields {
+		if *field == "" || IsEncryptedValue(*field) {
+			continue
+		}
+		ciphertext, err := encryption.Cipher.Encrypt(*field)
+		if err != nil {
+			return errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to encrypt content").
+				WithCause(err).
+				WithContext(map[string]interface{}{
+					"content_id": c.ID,
+				})
+		}
+		*field = EncryptedValuePrefix + ciphertext
+	}
+	return nil
+}
+
+// RestorePlaintext 将保存时加密的字段恢复为明文（Save在不存在的记录上会跳过钩子重新插入，因此由存储层在保存结束后调用而不是在AfterSave钩子中恢复）
+func (c *ContentItem) RestorePlaintext(encryption *ContentEncryption) error {
+	return c.decryptFields(encryption)
+}
+
+// decryptFields 解密带加密前缀的字段（未加密的历史数据保持不变）
+func (c *ContentItem) decryptFields(encryption *ContentEncryption) error {
+	for _, field := range []*string{&c.RawContent, &c.ProcessedData, &c.Summary.OneLine, &c.Summary.Paragraph, &c.Summary.Detailed} {
+		if !IsEncryptedValue(*field) {
+			continue
+		}
+		if encryption == nil {
+			return errors.NewMemoroError(errors.ErrorTypeConfig, errors.ErrCodeConfigMissing, "Content is encrypted but no encryption key is configured").
+				WithContext(map[string]interface{}{
+					"content_id": c.ID,
+				})
+		}
+		plaintext, err := encryption.Cipher.Decrypt(strings.TrimPrefix(*field, EncryptedValuePrefix))
+		if err != nil {
+			return errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to decrypt content").
+				WithCause(err).
+				WithContext(map[string]interface{}{
+					"content_id": c.ID,
+				})
+		}
+		*field = plaintext
+	}
+	return nil
+}
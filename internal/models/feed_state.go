@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// FeedState 订阅源的采集进度，记录已提交处理的条目以避免重复处理
+type FeedState struct {
+	FeedURL      string    `json:"feed_url" gorm:"primaryKey"`
+	SeenKeys     string    `json:"-" gorm:"column:seen_keys"` // 已提交条目标识（GUID或规范URL）的JSON数组，按提交顺序保留最近的条目
+	LastPolledAt time.Time `json:"last_polled_at"`            // 最近一次成功抓取的时间
+	UpdatedAt    time.Time `json:"updated_at"`
+}
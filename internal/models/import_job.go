@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// ImportStatus 导入任务状态
+type ImportStatus string
+
+const (
+	ImportStatusRunning   ImportStatus = "running"   // 正在提交条目
+	ImportStatusPaused    ImportStatus = "paused"    // 服务关闭或提交遇到暂时性错误（如处理队列已满）时中断，可继续导入
+	ImportStatusCompleted ImportStatus = "completed" // 全部条目已处理
+)
+
+// ImportJob 书签或稍后读导出文件的导入任务，保存去重后的条目和提交进度，中断后可从进度处继续
+type ImportJob struct {
+	ID         string       `json:"id" gorm:"primaryKey"`
+	UserID     string       `json:"user_id" gorm:"index"`
+	Format     string       `json:"format"` // 导出文件格式
+	Status     ImportStatus `json:"status"`
+	Entries    string       `json:"-" gorm:"type:text"` // 去重后待提交条目的JSON数组
+	Total      int          `json:"total"`              // 去重后的条目数
+	Duplicates int          `json:"duplicates"`         // 文件内重复（规范URL相同）而跳过的条目数
+	Cursor     int          `json:"cursor"`             // 已处理（提交或失败）的条目数，继续导入时从此处开始
+	Submitted  int          `json:"submitted"`          // 已提交处理的条目数
+	Failed     int          `json:"failed"`             // 因条目无效被拒绝的条目数
+	Error      string       `json:"error,omitempty"`    // 最近一次中断的原因
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+// Progress 导入进度百分比（0-100），没有条目时视为已完成
+func (j *ImportJob) Progress() float64 {
+	if j.Total == 0 {
+		return 100
+	}
+	return float64(j.Cursor) * 100 / float64(j.Total)
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchScope 用户保存的搜索范围（常用过滤条件，同一用户内按名称唯一）
+type SearchScope struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"uniqueIndex:idx_search_scope_user_name"`
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_search_scope_user_name"`
+	Filters   string    `json:"-" gorm:"column:filters"` // 过滤条件JSON字符串
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewSearchScope 创建搜索范围
+func NewSearchScope(userID, name, filters string) *SearchScope {
+	return &SearchScope{
+		ID:      uuid.New().String(),
+		UserID:  userID,
+		Name:    name,
+		Filters: filters,
+	}
+}
@@ -0,0 +1,123 @@
+package content
+
+import (
+	"context"
+	"sync"
+
+	"memoro/internal/services/llm"
+)
+
+// analysisOutcome 独立分析阶段（分类、摘要、标签）的结果，未启用的阶段结果和错误均为空
+type analysisOutcome struct {
+	classification *ClassificationResult
+	classifyErr    error
+
+	summary       *llm.SummaryResult
+	summarySource SummarySource
+	summaryErr    error
+
+	tags   *llm.TagResult
+	tagErr error
+}
+
+// stageErrors 按阶段汇总失败的分析阶段
+func (o *analysisOutcome) stageErrors() map[ProcessingStage]error {
+	stageErrors := make(map[ProcessingStage]error)
+	if o.classifyErr != nil {
+		stageErrors[StageClassify] = o.classifyErr
+	}
+	if o.summaryErr != nil {
+		stageErrors[StageSummarize] = o.summaryErr
+	}
+	if o.tagErr != nil {
+		stageErrors[StageTag] = o.tagErr
+	}
+	return stageErrors
+}
+
+// runAnalysisStages 执行分类、摘要和标签阶段。三个阶段只依赖提取结果，按StageConcurrency并发执行，
+// 全部结束后返回；各阶段使用自己的阶段超时，一个阶段失败不会取消其他阶段
+func (p *Processor) runAnalysisStages(ctx context.Context, request *ProcessingRequest, extractedContent *ExtractedContent, metrics *stageMetricsCollector) *analysisOutcome {
+	outcome := &analysisOutcome{}
+	options := request.Options
+
+	stages := make([]func(), 0, 3)
+	if options.EnableClassification || options.EnableImportanceScore {
+		stages = append(stages, func() {
+			ctx, done := metrics.begin(ctx, StageClassify)
+			defer done()
+
+			stageCtx, cancel := p.withStageTimeout(ctx, StageClassify)
+			defer cancel()
+			outcome.classification, outcome.classifyErr = p.classifier.Classify(stageCtx, extractedContent)
+			outcome.classifyErr = p.stageError(ctx, stageCtx, StageClassify, outcome.classifyErr)
+		})
+	}
+	if options.EnableSummary {
+		stages = append(stages, func() {
+			ctx, done := metrics.begin(ctx, StageSummarize)
+			defer done()
+
+			// 内容过短时不调用LLM，直接使用内容作为一句话摘要
+			if shouldDeriveSummary(p.config.SummaryLevels, extractedContent.Content) {
+				outcome.summary = deriveSummary(p.config.SummaryLevels, extractedContent.Content)
+				outcome.summarySource = SummarySourceDerived
+				return
+			}
+
+			stageCtx, cancel := p.withStageTimeout(ctx, StageSummarize)
+			defer cancel()
+			outcome.summary, outcome.summaryErr = p.summarizer.GenerateSummary(stageCtx, llm.SummaryRequest{
+				Content:     extractedContent.Content,
+				ContentType: request.ContentType,
+				Context:     request.Context,
+			})
+			outcome.summaryErr = p.stageError(ctx, stageCtx, StageSummarize, outcome.summaryErr)
+			outcome.summarySource = SummarySourceGenerated
+		})
+	}
+	if options.EnableTags {
+		stages = append(stages, func() {
+			ctx, done := metrics.begin(ctx, StageTag)
+			defer done()
+
+			stageCtx, cancel := p.withStageTimeout(ctx, StageTag)
+			defer cancel()
+			outcome.tags, outcome.tagErr = p.tagger.GenerateTags(stageCtx, llm.TagRequest{
+				Content:      extractedContent.Content,
+				ContentType:  request.ContentType,
+				Context:      request.Context,
+				ExistingTags: options.ExistingTags,
+				MaxTags:      options.MaxTags,
+			})
+			outcome.tagErr = p.stageError(ctx, stageCtx, StageTag, outcome.tagErr)
+		})
+	}
+
+	concurrency := p.config.StageConcurrency
+	if concurrency <= 0 || concurrency > len(stages) {
+		concurrency = len(stages)
+	}
+	if concurrency <= 1 {
+		for _, stage := range stages {
+			stage()
+		}
+		return outcome
+	}
+
+	// 每个阶段只写入自己的结果字段，等待全部结束后再由调用方统一应用
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, stage := range stages {
+		wg.Add(1)
+		go func(stage func()) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			stage()
+		}(stage)
+	}
+	wg.Wait()
+
+	return outcome
+}
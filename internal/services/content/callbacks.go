@@ -0,0 +1,264 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+)
+
+// 未配置时的回调发送参数
+const (
+	defaultCallbackConcurrency = 4
+	defaultCallbackMaxPending  = 100
+	defaultCallbackTimeout     = 10 * time.Second
+)
+
+// callbackDelivery 待发送的回调
+type callbackDelivery struct {
+	url       string
+	requestID string
+	payload   []byte
+}
+
+// callbackDispatcher 异步处理完成回调的发送器：固定数量的发送协程从有界队列中取出回调发送，
+// 突发大量完成时不会无限制地发起外部连接，队列已满时丢弃回调并记录警告。
+// 回调地址由调用方提供，只允许http/https，且默认在建立连接时拒绝回环、私有和链路本地地址（包括重定向和DNS解析后的地址）
+type callbackDispatcher struct {
+	httpClient *http.Client
+	logger     *logger.Logger
+
+	queue    chan *callbackDelivery
+	workerWg sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	attempted atomic.Int64
+	succeeded atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+}
+
+// newCallbackDispatcher 创建回调发送器并启动发送协程
+func newCallbackDispatcher(cfg config.CallbackConfig) *callbackDispatcher {
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultCallbackConcurrency
+	}
+	maxPending := cfg.MaxPending
+	if maxPending <= 0 {
+		maxPending = defaultCallbackMaxPending
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultCallbackTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = rejectInternalAddress
+	}
+
+	dispatcher := &callbackDispatcher{
+		httpClient: &http.Client{
+			Timeout: timeout,
+			// 连接数与发送协程数一致，空闲连接可复用
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				MaxConnsPerHost:     concurrency,
+				MaxIdleConnsPerHost: concurrency,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if !isHTTPScheme(req.URL) {
+					return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+				}
+				if len(via) >= 5 {
+					return fmt.Errorf("stopped after %d redirects", len(via))
+				}
+				return nil
+			},
+		},
+		logger: logger.NewLogger("callback-dispatcher"),
+		queue:  make(chan *callbackDelivery, maxPending),
+	}
+
+	for i := 0; i < concurrency; i++ {
+		dispatcher.workerWg.Add(1)
+		go dispatcher.worker()
+	}
+
+	return dispatcher
+}
+
+// enqueue 提交处理结果回调，不阻塞调用方；发送器已关闭或队列已满时丢弃并返回false
+func (d *callbackDispatcher) enqueue(url string, result *ProcessingResult) bool {
+	if d == nil || url == "" || result == nil {
+		return false
+	}
+
+	payload, err := json.Marshal(result)
+	if err != nil {
+		d.logger.Error("Failed to marshal callback payload", logger.Fields{
+			"request_id": result.RequestID,
+			"error":      err.Error(),
+		})
+		return false
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		d.dropped.Add(1)
+		d.logger.Warn("Callback dropped, dispatcher is closed", logger.Fields{
+			"request_id": result.RequestID,
+		})
+		return false
+	}
+
+	select {
+	case d.queue <- &callbackDelivery{url: url, requestID: result.RequestID, payload: payload}:
+		return true
+	default:
+		d.dropped.Add(1)
+		d.logger.Warn("Callback dropped, delivery queue is full", logger.Fields{
+			"request_id":  result.RequestID,
+			"max_pending": cap(d.queue),
+		})
+		return false
+	}
+}
+
+// worker 发送协程
+func (d *callbackDispatcher) worker() {
+	defer d.workerWg.Done()
+
+	for delivery := range d.queue {
+		d.deliver(delivery)
+	}
+}
+
+// deliver 发送一次回调，2xx响应视为成功
+func (d *callbackDispatcher) deliver(delivery *callbackDelivery) {
+	d.attempted.Add(1)
+
+	err := func() error {
+		req, err := http.NewRequest(http.MethodPost, delivery.url, bytes.NewReader(delivery.payload))
+		if err != nil {
+			return err
+		}
+		if !isHTTPScheme(req.URL) {
+			return fmt.Errorf("unsupported callback scheme %q", req.URL.Scheme)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := d.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		// 读完响应体以便复用连接（最多读取64KB，避免超大响应占用发送协程）
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return nil
+	}()
+
+	if err != nil {
+		d.failed.Add(1)
+		d.logger.Warn("Callback delivery failed", logger.Fields{
+			"request_id": delivery.requestID,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	d.succeeded.Add(1)
+	d.logger.Debug("Callback delivered", logger.Fields{
+		"request_id": delivery.requestID,
+	})
+}
+
+// isHTTPScheme 检查地址是否为http/https
+func isHTTPScheme(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// rejectInternalAddress 建立连接前检查解析后的目标地址，拒绝回环、私有、链路本地、未指定和组播地址
+func rejectInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("callback address %q is not an IP address", host)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("callback address %s is not allowed", ip)
+	}
+	return nil
+}
+
+// stats 获取回调发送统计
+func (d *callbackDispatcher) stats() map[string]interface{} {
+	if d == nil {
+		return map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"attempted": d.attempted.Load(),
+		"succeeded": d.succeeded.Load(),
+		"failed":    d.failed.Load(),
+		"dropped":   d.dropped.Load(),
+		"pending":   len(d.queue),
+	}
+}
+
+// Close 停止接收新回调，等待队列中的回调发送完成；ctx到期时不再等待
+func (d *callbackDispatcher) Close(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.queue)
+	d.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		d.workerWg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		d.logger.Warn("Callback drain deadline exceeded", logger.Fields{
+			"pending": len(d.queue),
+		})
+		return ctx.Err()
+	}
+}
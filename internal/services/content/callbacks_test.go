@@ -0,0 +1,135 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+)
+
+func TestCallbackDispatcher(t *testing.T) {
+	t.Run("突发完成时并发连接数不超过配置", func(t *testing.T) {
+		var inFlight, maxInFlight, received atomic.Int64
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := inFlight.Add(1)
+			for {
+				observed := maxInFlight.Load()
+				if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			inFlight.Add(-1)
+			received.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		dispatcher := newCallbackDispatcher(config.CallbackConfig{
+			Concurrency:          5,
+			MaxPending:           200,
+			Timeout:              5 * time.Second,
+			AllowPrivateNetworks: true,
+		})
+
+		for i := 0; i < 200; i++ {
+			accepted := dispatcher.enqueue(server.URL, &ProcessingResult{
+				RequestID: fmt.Sprintf("req-%d", i),
+				Status:    StatusCompleted,
+			})
+			require.True(t, accepted)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		require.NoError(t, dispatcher.Close(ctx))
+
+		assert.LessOrEqual(t, maxInFlight.Load(), int64(5))
+		assert.Equal(t, int64(200), received.Load())
+
+		stats := dispatcher.stats()
+		assert.Equal(t, int64(200), stats["attempted"])
+		assert.Equal(t, int64(200), stats["succeeded"])
+		assert.Equal(t, int64(0), stats["failed"])
+		assert.Equal(t, int64(0), stats["dropped"])
+	})
+
+	t.Run("队列已满时丢弃回调", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		dispatcher := newCallbackDispatcher(config.CallbackConfig{
+			Concurrency:          1,
+			MaxPending:           2,
+			Timeout:              5 * time.Second,
+			AllowPrivateNetworks: true,
+		})
+
+		// 第一个回调被发送协程取走后阻塞，队列再容纳两个
+		require.True(t, dispatcher.enqueue(server.URL, &ProcessingResult{RequestID: "req-0"}))
+		require.Eventually(t, func() bool {
+			return dispatcher.stats()["attempted"] == int64(1)
+		}, time.Second, 5*time.Millisecond)
+
+		assert.True(t, dispatcher.enqueue(server.URL, &ProcessingResult{RequestID: "req-1"}))
+		assert.True(t, dispatcher.enqueue(server.URL, &ProcessingResult{RequestID: "req-2"}))
+		assert.False(t, dispatcher.enqueue(server.URL, &ProcessingResult{RequestID: "req-3"}))
+		assert.Equal(t, int64(1), dispatcher.stats()["dropped"])
+
+		close(release)
+		require.NoError(t, dispatcher.Close(context.Background()))
+		assert.Equal(t, int64(3), dispatcher.stats()["succeeded"])
+
+		// 关闭后提交的回调也被丢弃
+		assert.False(t, dispatcher.enqueue(server.URL, &ProcessingResult{RequestID: "req-4"}))
+		assert.Equal(t, int64(2), dispatcher.stats()["dropped"])
+	})
+
+	t.Run("非2xx响应计为失败", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		dispatcher := newCallbackDispatcher(config.CallbackConfig{AllowPrivateNetworks: true})
+		require.True(t, dispatcher.enqueue(server.URL, &ProcessingResult{RequestID: "req-0", Status: StatusFailed}))
+		require.NoError(t, dispatcher.Close(context.Background()))
+
+		stats := dispatcher.stats()
+		assert.Equal(t, int64(1), stats["attempted"])
+		assert.Equal(t, int64(1), stats["failed"])
+		assert.Equal(t, int64(0), stats["succeeded"])
+	})
+
+	t.Run("默认拒绝回调到内网地址和非http地址", func(t *testing.T) {
+		var received atomic.Int64
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		dispatcher := newCallbackDispatcher(config.CallbackConfig{})
+		require.True(t, dispatcher.enqueue(server.URL, &ProcessingResult{RequestID: "req-0"}))
+		require.True(t, dispatcher.enqueue("http://169.254.169.254/latest/meta-data", &ProcessingResult{RequestID: "req-1"}))
+		require.True(t, dispatcher.enqueue("file:///etc/passwd", &ProcessingResult{RequestID: "req-2"}))
+		require.NoError(t, dispatcher.Close(context.Background()))
+
+		assert.Equal(t, int64(0), received.Load())
+		stats := dispatcher.stats()
+		assert.Equal(t, int64(3), stats["failed"])
+		assert.Equal(t, int64(0), stats["succeeded"])
+	})
+}
@@ -0,0 +1,154 @@
+package content
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// trackingParamPrefixes 需要剔除的跟踪参数前缀
+var trackingParamPrefixes = []string{
+	"utm_",
+}
+
+// trackingParams 需要剔除的已知跟踪参数（只包含不影响页面内容的参数，ref、from等通用参数可能决定页面内容，予以保留）
+var trackingParams = map[string]bool{
+	"fbclid":         true,
+	"gclid":          true,
+	"dclid":          true,
+	"msclkid":        true,
+	"yclid":          true,
+	"mc_cid":         true,
+	"mc_eid":         true,
+	"igshid":         true,
+	"ref_src":        true,
+	"spm":            true,
+	"isappinstalled": true,
+}
+
+// hostTrackingParams 只在特定站点上剔除的跟踪参数（如微信公众号文章的分享场景和会话参数）
+var hostTrackingParams = map[string]map[string]bool{
+	"mp.weixin.qq.com": {
+		"scene":       true,
+		"from":        true,
+		"chksm":       true,
+		"sessionid":   true,
+		"share_token": true,
+		"clicktime":   true,
+		"enterid":     true,
+	},
+}
+
+// canonicalLinkPatterns 匹配HTML中的rel=canonical链接
+var canonicalLinkPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)<link[^>]*rel=["']canonical["'][^>]*href=["']([^"']+)["']`),
+	regexp.MustCompile(`(?i)<link[^>]*href=["']([^"']+)["'][^>]*rel=["']canonical["']`),
+}
+
+// CanonicalizeURL 规范化URL（剔除已知跟踪参数、小写域名、去除默认端口、排序查询参数）；
+// 协议和子域名（如www.、m.）保持不变，不同的页面不会被合并
+func CanonicalizeURL(rawURL string) string {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+
+	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
+
+	// 统一域名（小写、去除与协议对应的默认端口）
+	host := strings.ToLower(parsedURL.Hostname())
+	port := parsedURL.Port()
+	if port != "" && !(parsedURL.Scheme == "http" && port == "80") && !(parsedURL.Scheme == "https" && port == "443") {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6地址需要保留方括号
+		host = "[" + host + "]"
+	}
+	parsedURL.Host = host
+
+	// 剔除跟踪参数并排序
+	query := parsedURL.Query()
+	for key := range query {
+		if isTrackingParam(parsedURL.Hostname(), key) {
+			query.Del(key)
+		}
+	}
+	parsedURL.RawQuery = encodeSortedQuery(query)
+
+	// 去除锚点和末尾斜杠
+	parsedURL.Fragment = ""
+	parsedURL.RawFragment = ""
+	if len(parsedURL.Path) > 1 {
+		parsedURL.Path = strings.TrimRight(parsedURL.Path, "/")
+	}
+	if parsedURL.Path == "/" {
+		parsedURL.Path = ""
+	}
+	parsedURL.RawPath = ""
+
+	return parsedURL.String()
+}
+
+// isTrackingParam 检查是否为通用或该站点已知的跟踪参数
+func isTrackingParam(host, key string) bool {
+	lowerKey := strings.ToLower(key)
+	if trackingParams[lowerKey] || hostTrackingParams[host][lowerKey] {
+		return true
+	}
+	for _, prefix := range trackingParamPrefixes {
+		if strings.HasPrefix(lowerKey, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeSortedQuery 按键排序编码查询参数
+func encodeSortedQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// extractCanonicalLink 从HTML中提取rel=canonical链接，并基于页面URL解析相对地址
+func extractCanonicalLink(html string, pageURL *url.URL) string {
+	for _, pattern := range canonicalLinkPatterns {
+		matches := pattern.FindStringSubmatch(html)
+		if len(matches) < 2 {
+			continue
+		}
+
+		href := strings.TrimSpace(matches[1])
+		if href == "" {
+			continue
+		}
+
+		canonicalURL, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		if pageURL != nil {
+			canonicalURL = pageURL.ResolveReference(canonicalURL)
+		}
+		return canonicalURL.String()
+	}
+	return ""
+}
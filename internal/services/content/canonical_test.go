@@ -0,0 +1,63 @@
+package content
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCanonicalizeURL 测试URL规范化
+func TestCanonicalizeURL(t *testing.T) {
+	t.Run("剔除跟踪参数", func(t *testing.T) {
+		a := CanonicalizeURL("https://example.com/post/1?utm_source=wechat&utm_medium=share&id=7")
+		b := CanonicalizeURL("https://example.com/post/1?id=7&fbclid=xyz&gclid=abc")
+
+		assert.Equal(t, "https://example.com/post/1?id=7", a)
+		assert.Equal(t, a, b)
+	})
+
+	t.Run("保留协议、子域名和可能决定页面内容的参数", func(t *testing.T) {
+		assert.NotEqual(t,
+			CanonicalizeURL("https://www.example.com/news/42"),
+			CanonicalizeURL("https://m.example.com/news/42"))
+		assert.Equal(t, "http://example.com/news/42", CanonicalizeURL("http://example.com:80/news/42/"))
+		assert.Equal(t, "https://example.com/list?from=2024-01-01&ref=main",
+			CanonicalizeURL("https://example.com/list?ref=main&from=2024-01-01"))
+	})
+
+	t.Run("只在对应站点剔除站点专用的跟踪参数", func(t *testing.T) {
+		assert.Equal(t, "https://mp.weixin.qq.com/s?__biz=abc&mid=1",
+			CanonicalizeURL("https://mp.weixin.qq.com/s?__biz=abc&mid=1&scene=21&chksm=xyz"))
+		assert.Equal(t, "https://example.com/game?scene=2", CanonicalizeURL("https://example.com/game?scene=2"))
+	})
+
+	t.Run("去除锚点并排序参数", func(t *testing.T) {
+		assert.Equal(t,
+			"https://example.com/search?a=1&b=2",
+			CanonicalizeURL("https://EXAMPLE.com/search?b=2&a=1#top"))
+	})
+
+	t.Run("无效URL原样返回", func(t *testing.T) {
+		assert.Equal(t, "not a url", CanonicalizeURL("  not a url "))
+	})
+}
+
+// TestExtractCanonicalLink 测试提取rel=canonical
+func TestExtractCanonicalLink(t *testing.T) {
+	pageURL, _ := url.Parse("https://m.example.com/a/1?utm_source=x")
+
+	t.Run("解析相对地址", func(t *testing.T) {
+		html := `<head><link rel="canonical" href="/articles/1"></head>`
+		assert.Equal(t, "https://m.example.com/articles/1", extractCanonicalLink(html, pageURL))
+	})
+
+	t.Run("属性顺序颠倒", func(t *testing.T) {
+		html := `<link href="https://example.com/articles/1" rel="canonical" />`
+		assert.Equal(t, "https://example.com/articles/1", extractCanonicalLink(html, pageURL))
+	})
+
+	t.Run("无canonical链接", func(t *testing.T) {
+		assert.Empty(t, extractCanonicalLink(`<head><title>x</title></head>`, pageURL))
+	})
+}
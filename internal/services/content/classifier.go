@@ -10,6 +10,7 @@ import (
 	"memoro/internal/errors"
 	"memoro/internal/logger"
 	"memoro/internal/models"
+	"memoro/internal/services/language"
 	"memoro/internal/services/llm"
 )
 
@@ -107,7 +108,7 @@ func (cc *ContentClassifier) Classify(ctx context.Context, content *ExtractedCon
 		MaxTags:     20, // 增加标签数量限制
 	}
 
-	tagResult, err := cc.tagger.GenerateTags(ctx, tagRequest)
+	tagResult, err := cc.tagger.GenerateTags(llm.WithStage(ctx, llm.StageClassification), tagRequest)
 	if err != nil {
 		cc.logger.Error("Failed to generate tags", logger.Fields{
 			"error":        err.Error(),
@@ -117,12 +118,15 @@ func (cc *ContentClassifier) Classify(ctx context.Context, content *ExtractedCon
 	}
 
 	// 计算重要性评分
+	importanceSource := ImportanceSourceComputed
 	importanceScore, err := cc.CalculateImportance(ctx, content)
 	if err != nil {
+		importanceScore = defaultImportanceScore(cc.config)
+		importanceSource = ImportanceSourceDefault
 		cc.logger.Warn("Failed to calculate importance score, using default", logger.Fields{
-			"error": err.Error(),
+			"error":         err.Error(),
+			"default_score": importanceScore,
 		})
-		importanceScore = 0.5 // 默认中等重要性
 	}
 
 	// 提取关键词
@@ -145,9 +149,15 @@ func (cc *ContentClassifier) Classify(ctx context.Context, content *ExtractedCon
 			"category_count":      len(tagResult.Categories),
 			"keyword_count":       len(keywords),
 			"tag_confidence":      tagResult.Confidence,
+			ImportanceSourceKey:   string(importanceSource),
 		},
 	}
 
+	// 按规则抽取实体（不调用LLM，配置开启时才执行）
+	if cc.config.Entities.Enabled {
+		result.Metadata[EntitiesKey] = extractEntities(content.Content, cc.config.Entities.MaxPerType)
+	}
+
 	// 验证分类结果
 	if err := cc.validateClassificationResult(result); err != nil {
 		return nil, err
@@ -212,26 +222,26 @@ func (cc *ContentClassifier) CalculateImportance(ctx context.Context, content *E
 	return totalScore, nil
 }
 
-// calculateLengthScore 计算长度得分
+// calculateLengthScore 计算长度得分（按词数计算，中英文内容的长度可比）
 func (cc *ContentClassifier) calculateLengthScore(content string) float64 {
-	length := len(content)
-	
-	// 短内容 (<100字符) - 较低分数
-	if length < 100 {
+	words := language.CountWords(content)
+
+	// 短内容 (<20词) - 较低分数
+	if words < 20 {
 		return 0.05
 	}
-	
-	// 中等内容 (100-1000字符) - 递增分数
-	if length < 1000 {
-		return 0.05 + (float64(length-100)/900)*0.15
+
+	// 中等内容 (20-200词) - 递增分数
+	if words < 200 {
+		return 0.05 + (float64(words-20)/180)*0.15
 	}
-	
-	// 长内容 (1000-5000字符) - 高分数
-	if length < 5000 {
-		return 0.20 + (float64(length-1000)/4000)*0.10
+
+	// 长内容 (200-1000词) - 高分数
+	if words < 1000 {
+		return 0.20 + (float64(words-200)/800)*0.10
 	}
-	
-	// 超长内容 (>5000字符) - 最高分数
+
+	// 超长内容 (>1000词) - 最高分数
 	return 0.30
 }
 
@@ -325,18 +335,6 @@ func (cc *ContentClassifier) extractKeywords(content string) []string {
 	// 移除标点符号并转换为小写
 	cleanContent := strings.ToLower(content)
 	
-	// 定义停用词
-	stopWords := map[string]bool{
-		"的": true, "了": true, "在": true, "是": true, "我": true, "有": true, "和": true,
-		"就": true, "不": true, "人": true, "都": true, "一": true, "个": true, "上": true,
-		"也": true, "很": true, "到": true, "说": true, "要": true, "去": true, "你": true,
-		"会": true, "着": true, "没": true, "看": true, "好": true, "自": true, "己": true,
-		"可以": true, "这个": true, "那个": true, "什么": true, "怎么": true, "为什么": true,
-		"the": true, "and": true, "for": true, "are": true, "but": true, "not": true,
-		"you": true, "all": true, "can": true, "had": true, "her": true, "was": true,
-		"one": true, "our": true, "out": true, "day": true, "get": true, "has": true,
-	}
-
 	// 简单的关键词提取 - 查找重要的词汇模式
 	keywordPatterns := []*regexp.Regexp{
 		regexp.MustCompile(`[一-龠]{2,}`),     // 中文词汇
@@ -349,7 +347,7 @@ func (cc *ContentClassifier) extractKeywords(content string) []string {
 	for _, pattern := range keywordPatterns {
 		matches := pattern.FindAllString(cleanContent, -1)
 		for _, match := range matches {
-			if len(match) > 2 && !stopWords[match] {
+			if len(match) > 2 && !language.IsStopword(match) {
 				keywords[match]++
 			}
 		}
@@ -447,23 +445,8 @@ func (cc *ContentClassifier) hasCompleteSentences(content string) bool {
 }
 
 func (cc *ContentClassifier) hasRichVocabulary(content string) bool {
-	// 简单的词汇丰富度检查
-	words := strings.Fields(content)
-	uniqueWords := make(map[string]bool)
-	
-	for _, word := range words {
-		if len(word) > 2 {
-			uniqueWords[strings.ToLower(word)] = true
-		}
-	}
-	
-	// 词汇多样性比例
-	if len(words) == 0 {
-		return false
-	}
-	
-	diversity := float64(len(uniqueWords)) / float64(len(words))
-	return diversity > 0.5
+	// 简单的词汇丰富度检查：词汇多样性比例
+	return vocabularyDiversity(content) > 0.5
 }
 
 func (cc *ContentClassifier) hasLogicalCoherence(content string) bool {
@@ -0,0 +1,30 @@
+package content
+
+import (
+	"memoro/internal/services/vector"
+)
+
+// DedupDecision 索引时去重的判断结果
+type DedupDecision struct {
+	Duplicate bool                   `json:"duplicate"`       // 是否判定为重复（重复时不索引也不保存）
+	Scope     vector.DedupScope      `json:"scope"`           // 生效的去重范围
+	Match     *vector.DuplicateMatch `json:"match,omitempty"` // 匹配到的已有文档
+}
+
+// dedupPolicy 根据配置构建索引时去重策略，未启用时返回nil
+func (p *Processor) dedupPolicy() *vector.DedupPolicy {
+	dedup := p.config.Dedup
+	if !dedup.Enabled {
+		return nil
+	}
+
+	scope := vector.DedupScope(dedup.Scope)
+	if scope == "" {
+		scope = vector.DedupScopeUser
+	}
+	return &vector.DedupPolicy{
+		Scope:     scope,
+		Window:    dedup.Window,
+		Threshold: dedup.SimilarityThreshold,
+	}
+}
@@ -0,0 +1,293 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/models"
+	"memoro/internal/services/vector"
+)
+
+// TestProcessor_IndexTimeDedup 测试去重范围和时间窗口：按用户去重时不同用户的相同内容都索引，全局去重时后提交的被跳过
+func TestProcessor_IndexTimeDedup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: server.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+	}))
+	ctx := context.Background()
+	const text = "Weekly notes about the Go 1.23 release and range-over-func iterators."
+
+	newProcessor := func(t *testing.T, dedup config.DedupConfig) (*Processor, *vector.MemoryStore) {
+		store := vector.NewMemoryStore()
+		engine, err := vector.NewSearchEngineWithStore(store)
+		require.NoError(t, err)
+		t.Cleanup(func() { engine.Close() })
+
+		processor := newTestProcessor(t)
+		processor.searchEngine = engine
+		processor.config.Dedup = dedup
+		return processor, store
+	}
+	process := func(t *testing.T, processor *Processor, id, userID string) *ProcessingResult {
+		result, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID:          id,
+			Content:     text,
+			ContentType: models.ContentTypeText,
+			UserID:      userID,
+			Options:     ProcessingOptions{EnableVectorization: true},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("按用户去重时不同用户的相同内容都索引", func(t *testing.T) {
+		processor, store := newProcessor(t, config.DedupConfig{Enabled: true, Scope: "user"})
+
+		first := process(t, processor, "req-1", "user-1")
+		second := process(t, processor, "req-2", "user-2")
+		assert.True(t, second.VectorResult.Indexed)
+		assert.Empty(t, second.DuplicateOf)
+		require.NotNil(t, second.Dedup)
+		assert.False(t, second.Dedup.Duplicate)
+		assert.Equal(t, vector.DedupScopeUser, second.Dedup.Scope)
+
+		// 同一用户再次提交时判定为重复
+		third := process(t, processor, "req-3", "user-1")
+		assert.False(t, third.VectorResult.Indexed)
+		assert.True(t, third.VectorResult.Skipped)
+		assert.Equal(t, first.ContentItem.ID, third.DuplicateOf)
+		require.NotNil(t, third.Dedup.Match)
+		assert.Equal(t, first.ContentItem.ID, third.Dedup.Match.DocumentID)
+		assert.InDelta(t, 1.0, third.Dedup.Match.Similarity, 1e-6)
+
+		docs, err := store.GetDocumentsByFilter(ctx, map[string]interface{}{"content_type": "text"}, 10)
+		require.NoError(t, err)
+		assert.Len(t, docs, 2)
+	})
+
+	t.Run("全局去重时其他用户的相同内容被跳过", func(t *testing.T) {
+		processor, store := newProcessor(t, config.DedupConfig{Enabled: true, Scope: "global"})
+
+		first := process(t, processor, "req-1", "user-1")
+		second := process(t, processor, "req-2", "user-2")
+		assert.False(t, second.VectorResult.Indexed)
+		assert.True(t, second.Dedup.Duplicate)
+		assert.Equal(t, vector.DedupScopeGlobal, second.Dedup.Scope)
+		assert.False(t, second.Persisted)
+
+		// 不向提交者暴露其他用户的文档ID
+		assert.Empty(t, second.DuplicateOf)
+		require.NotNil(t, second.Dedup.Match)
+		assert.Empty(t, second.Dedup.Match.DocumentID)
+		assert.True(t, second.Dedup.Match.OtherUser)
+		assert.NotContains(t, second.VectorResult.SkipReason, first.ContentItem.ID)
+
+		// 同一用户的重复内容仍返回已有文档ID
+		third := process(t, processor, "req-3", "user-1")
+		assert.Equal(t, first.ContentItem.ID, third.DuplicateOf)
+		assert.False(t, third.Dedup.Match.OtherUser)
+
+		docs, err := store.GetDocumentsByFilter(ctx, map[string]interface{}{"content_type": "text"}, 10)
+		require.NoError(t, err)
+		assert.Len(t, docs, 1)
+	})
+
+	t.Run("时间窗口外的已有内容不参与去重", func(t *testing.T) {
+		processor, store := newProcessor(t, config.DedupConfig{Enabled: true, Scope: "global", Window: time.Hour})
+		require.NoError(t, store.AddDocument(ctx, &vector.VectorDocument{
+			ID:        "old-doc",
+			Content:   text,
+			Embedding: []float32{1, 0, 0},
+			Metadata:  map[string]interface{}{"user_id": "user-1"},
+			CreatedAt: time.Now().Add(-2 * time.Hour),
+		}))
+
+		result := process(t, processor, "req-1", "user-1")
+		assert.True(t, result.VectorResult.Indexed)
+		assert.False(t, result.Dedup.Duplicate)
+	})
+
+	t.Run("并发提交的相同内容只索引一次", func(t *testing.T) {
+		store := vector.NewMemoryStore()
+		// 放慢重复检查，使并发请求的检查和写入交错
+		engine, err := vector.NewSearchEngineWithStore(slowSearchStore{store})
+		require.NoError(t, err)
+		t.Cleanup(func() { engine.Close() })
+		processor := newTestProcessor(t)
+		processor.searchEngine = engine
+		processor.config.Dedup = config.DedupConfig{Enabled: true, Scope: "user"}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := processor.doProcessing(ctx, &ProcessingRequest{
+					ID:          fmt.Sprintf("req-%d", i),
+					Content:     text,
+					ContentType: models.ContentTypeText,
+					UserID:      "user-1",
+					Options:     ProcessingOptions{EnableVectorization: true},
+				})
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+
+		docs, err := store.GetDocumentsByFilter(ctx, map[string]interface{}{"content_type": "text"}, 10)
+		require.NoError(t, err)
+		assert.Len(t, docs, 1)
+	})
+
+	t.Run("未启用时不检查重复", func(t *testing.T) {
+		processor, _ := newProcessor(t, config.DedupConfig{})
+		process(t, processor, "req-1", "user-1")
+		result := process(t, processor, "req-2", "user-1")
+		assert.True(t, result.VectorResult.Indexed)
+		assert.Nil(t, result.Dedup)
+	})
+}
+
+// slowSearchStore 搜索后等待一段时间才返回的向量存储，用于测试并发去重
+type slowSearchStore struct {
+	*vector.MemoryStore
+}
+
+func (s slowSearchStore) Search(ctx context.Context, query *vector.SearchQuery) (*vector.SearchResult, error) {
+	result, err := s.MemoryStore.Search(ctx, query)
+	time.Sleep(20 * time.Millisecond)
+	return result, err
+}
+
+// TestProcessor_ConcurrentDuplicates 测试相同内容的并发请求只向量化和索引一次，后完成的请求引用先完成的内容
+func TestProcessor_ConcurrentDuplicates(t *testing.T) {
+	var embeddingCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&embeddingCalls, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: server.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+	}))
+	ctx := context.Background()
+	const text = "Notes from the design review about the new ingestion pipeline."
+
+	processConcurrently := func(t *testing.T, dedup config.DedupConfig) ([]*ProcessingResult, *vector.MemoryStore) {
+		store := vector.NewMemoryStore()
+		engine, err := vector.NewSearchEngineWithStore(store)
+		require.NoError(t, err)
+		t.Cleanup(func() { engine.Close() })
+
+		processor := newTestProcessor(t)
+		processor.searchEngine = engine
+		processor.inflight = newInflightLocks(dedup)
+
+		results := make([]*ProcessingResult, 2)
+		var wg sync.WaitGroup
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				result, err := processor.doProcessing(ctx, &ProcessingRequest{
+					ID:          fmt.Sprintf("req-%d", i),
+					Content:     text,
+					ContentType: models.ContentTypeText,
+					UserID:      "user-1",
+					Options:     ProcessingOptions{EnableVectorization: true},
+				})
+				assert.NoError(t, err)
+				results[i] = result
+			}(i)
+		}
+		wg.Wait()
+		return results, store
+	}
+
+	t.Run("启用时只处理一次", func(t *testing.T) {
+		atomic.StoreInt32(&embeddingCalls, 0)
+		results, store := processConcurrently(t, config.DedupConfig{Concurrent: true})
+		require.NotNil(t, results[0])
+		require.NotNil(t, results[1])
+
+		first, second := results[0], results[1]
+		if first.DuplicateOf != "" {
+			first, second = second, first
+		}
+		assert.Empty(t, first.DuplicateOf)
+		assert.Equal(t, first.ContentItem.ID, second.DuplicateOf)
+		assert.Equal(t, "req-1", results[1].RequestID)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&embeddingCalls))
+
+		docs, err := store.GetDocumentsByFilter(ctx, map[string]interface{}{"content_type": "text"}, 10)
+		require.NoError(t, err)
+		assert.Len(t, docs, 1)
+	})
+
+	t.Run("未启用时都被索引", func(t *testing.T) {
+		atomic.StoreInt32(&embeddingCalls, 0)
+		results, store := processConcurrently(t, config.DedupConfig{})
+		assert.Empty(t, results[0].DuplicateOf)
+		assert.Empty(t, results[1].DuplicateOf)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&embeddingCalls))
+
+		docs, err := store.GetDocumentsByFilter(ctx, map[string]interface{}{"content_type": "text"}, 10)
+		require.NoError(t, err)
+		assert.Len(t, docs, 2)
+	})
+}
+
+// TestInflightLocks 测试并发去重键区分处理选项、敏感标记和线程，等待锁时响应ctx取消
+func TestInflightLocks(t *testing.T) {
+	base := &ProcessingRequest{
+		Content:     "same content",
+		ContentType: models.ContentTypeText,
+		UserID:      "user-1",
+		Options:     ProcessingOptions{EnableSummary: true},
+	}
+	key := inflightKey(base)
+
+	withOptions := *base
+	withOptions.Options.EnableTags = true
+	sensitive := *base
+	sensitive.Sensitive = true
+	threaded := *base
+	threaded.ThreadID = "thread-1"
+	for _, request := range []*ProcessingRequest{&withOptions, &sensitive, &threaded} {
+		assert.NotEqual(t, key, inflightKey(request))
+	}
+	same := *base
+	same.ID = "another-request"
+	assert.Equal(t, key, inflightKey(&same))
+
+	locks := newInflightLocks(config.DedupConfig{Concurrent: true})
+	entry, err := locks.acquire(context.Background(), key)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = locks.acquire(ctx, key)
+	require.Error(t, err)
+
+	locks.release(key, entry)
+	assert.Empty(t, locks.entries)
+}
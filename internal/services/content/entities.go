@@ -0,0 +1,189 @@
+package content
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"memoro/internal/services/vector"
+)
+
+// EntitiesKey 实体在分类元数据中的键
+const EntitiesKey = "entities"
+
+// defaultEntitiesPerType 未配置时每种实体保留的数量上限
+const defaultEntitiesPerType = 10
+
+// EntityType 实体类型
+type EntityType string
+
+const (
+	EntityPerson       EntityType = "persons"
+	EntityOrganization EntityType = "organizations"
+	EntityLocation     EntityType = "locations"
+	EntityDate         EntityType = "dates"
+)
+
+// entityMetadataKeys 各实体类型对应的向量元数据字段
+var entityMetadataKeys = map[EntityType]string{
+	EntityPerson:       vector.MetadataKeyEntityPersons,
+	EntityOrganization: vector.MetadataKeyEntityOrganizations,
+	EntityLocation:     vector.MetadataKeyEntityLocations,
+	EntityDate:         vector.MetadataKeyEntityDates,
+}
+
+// 英文月份名（含缩写）
+const monthPattern = `(January|February|March|April|May|June|July|August|September|October|November|December|Jan|Feb|Mar|Apr|Jun|Jul|Aug|Sep|Sept|Oct|Nov|Dec)`
+
+var (
+	isoDateRegex       = regexp.MustCompile(`\b(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})\b`)
+	chineseDateRegex   = regexp.MustCompile(`(\d{4})\s*年\s*(\d{1,2})\s*月\s*(\d{1,2})\s*[日号]`)
+	monthDayYearRegex  = regexp.MustCompile(`\b` + monthPattern + `\.?\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})\b`)
+	dayMonthYearRegex  = regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)?\s+` + monthPattern + `\.?,?\s+(\d{4})\b`)
+	englishOrgRegex    = regexp.MustCompile(`\b((?:[A-Z][\w&'-]*\s+){0,3}[A-Z][\w&'-]*),?\s+(Inc|Corp|Corporation|Ltd|LLC|Co|Company|Group|Bank|University|Institute|Foundation)\b\.?`)
+	chineseOrgRegex    = regexp.MustCompile(`([\p{Han}A-Za-z]{2,10}(?:有限公司|股份公司|公司|集团|大学|研究院|研究所|银行))`)
+	englishPersonRegex = regexp.MustCompile(`\b(?:Mr|Mrs|Ms|Dr|Prof|CEO|CTO|President|founder)\.?\s+([A-Z][a-z]+(?:\s+[A-Z][a-z]+)?)`)
+	chinesePersonRegex = regexp.MustCompile(`([\p{Han}]{2,3})(?:先生|女士|教授|博士|总裁|经理)`)
+	englishPlaceRegex  = regexp.MustCompile(`\b(?:in|at|near|from)\s+([A-Z][a-z]+(?:\s+[A-Z][a-z]+)?)`)
+	chinesePlaceRegex  = regexp.MustCompile(`(?:在|于|位于|来自|前往)([\p{Han}]{2,6}?(?:省|市|县|自治区))`)
+	monthNameRegex     = regexp.MustCompile(`^` + monthPattern + `\b`)
+)
+
+// notPlaceWords 介词后常见的非地点大写词
+var notPlaceWords = map[string]bool{
+	"The": true, "This": true, "That": true, "These": true, "Those": true, "Our": true, "My": true,
+	"Monday": true, "Tuesday": true, "Wednesday": true, "Thursday": true, "Friday": true, "Saturday": true, "Sunday": true,
+}
+
+// extractEntities 按规则抽取人名、组织、地点和日期（不调用LLM），结果经过规范化和去重，
+// 每种类型最多保留maxPerType个，没有抽取到的类型不出现在结果中
+func extractEntities(text string, maxPerType int) map[EntityType][]string {
+	if maxPerType <= 0 {
+		maxPerType = defaultEntitiesPerType
+	}
+
+	candidates := map[EntityType][]string{
+		EntityDate:         extractDates(text),
+		EntityOrganization: append(submatches(englishOrgRegex, text, 0), submatches(chineseOrgRegex, text, 1)...),
+		EntityPerson:       append(submatches(englishPersonRegex, text, 1), submatches(chinesePersonRegex, text, 1)...),
+	}
+
+	// 组织名中的大写词不再作为地点
+	var places []string
+	for _, place := range append(submatches(englishPlaceRegex, text, 1), submatches(chinesePlaceRegex, text, 1)...) {
+		if notPlaceWords[strings.Fields(place)[0]] || monthNameRegex.MatchString(place) {
+			continue
+		}
+		if containsFold(candidates[EntityOrganization], place) {
+			continue
+		}
+		places = append(places, place)
+	}
+	candidates[EntityLocation] = places
+
+	entities := make(map[EntityType][]string)
+	for entityType, values := range candidates {
+		if normalized := normalizeEntities(values, maxPerType); len(normalized) > 0 {
+			entities[entityType] = normalized
+		}
+	}
+	return entities
+}
+
+// extractDates 抽取日期并统一为YYYY-MM-DD，无效日期（如2月30日）被忽略
+func extractDates(text string) []string {
+	var dates []string
+	add := func(year, month, day string) {
+		y, _ := strconv.Atoi(year)
+		m, _ := strconv.Atoi(month)
+		d, _ := strconv.Atoi(day)
+		date := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+		if date.Year() != y || int(date.Month()) != m || date.Day() != d {
+			return
+		}
+		dates = append(dates, date.Format("2006-01-02"))
+	}
+
+	for _, match := range isoDateRegex.FindAllStringSubmatch(text, -1) {
+		add(match[1], match[2], match[3])
+	}
+	for _, match := range chineseDateRegex.FindAllStringSubmatch(text, -1) {
+		add(match[1], match[2], match[3])
+	}
+	for _, match := range monthDayYearRegex.FindAllStringSubmatch(text, -1) {
+		add(match[3], monthNumber(match[1]), match[2])
+	}
+	for _, match := range dayMonthYearRegex.FindAllStringSubmatch(text, -1) {
+		add(match[3], monthNumber(match[2]), match[1])
+	}
+	return dates
+}
+
+// monthNumber 英文月份名转换为月份数字
+func monthNumber(name string) string {
+	for month := time.January; month <= time.December; month++ {
+		if strings.HasPrefix(month.String(), name[:3]) {
+			return strconv.Itoa(int(month))
+		}
+	}
+	return "0"
+}
+
+// submatches 获取所有匹配中指定分组的文本
+func submatches(re *regexp.Regexp, text string, group int) []string {
+	var values []string
+	for _, match := range re.FindAllStringSubmatch(text, -1) {
+		values = append(values, match[group])
+	}
+	return values
+}
+
+// normalizeEntities 规范化实体（合并空白、去掉首尾标点和末尾句点），忽略大小写去重，保留首次出现的写法，结果排序
+func normalizeEntities(values []string, maxCount int) []string {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, value := range values {
+		value = strings.Join(strings.Fields(value), " ")
+		value = strings.TrimSuffix(strings.Trim(value, ` ,;:"'()`), ".")
+		key := strings.ToLower(value)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, value)
+		if len(normalized) >= maxCount {
+			break
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// containsFold 忽略大小写检查列表中是否有包含value的元素
+func containsFold(values []string, value string) bool {
+	value = strings.ToLower(value)
+	for _, v := range values {
+		if strings.Contains(strings.ToLower(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// entityMetadataFrom 将分类元数据中的实体转换为向量元数据字段
+func entityMetadataFrom(metadata map[string]interface{}) map[string]interface{} {
+	entities, ok := metadata[EntitiesKey].(map[EntityType][]string)
+	if !ok {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(entities))
+	for entityType, values := range entities {
+		if key, exists := entityMetadataKeys[entityType]; exists && len(values) > 0 {
+			fields[key] = values
+		}
+	}
+	return fields
+}
@@ -0,0 +1,121 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/models"
+	"memoro/internal/services/vector"
+)
+
+func TestExtractEntities(t *testing.T) {
+	t.Run("抽取并规范化各类实体", func(t *testing.T) {
+		entities := extractEntities("Acme Corp. announced on March 5, 2024 that Dr. Alice Chen will lead the new lab in Seattle. "+
+			"The contract with Acme Corp was signed on 2024-03-05. 阿里巴巴集团于2023年11月2日在杭州市发布了新模型，张伟先生出席。", 0)
+
+		assert.Equal(t, []string{"Acme Corp", "阿里巴巴集团"}, entities[EntityOrganization])
+		assert.Equal(t, []string{"2023-11-02", "2024-03-05"}, entities[EntityDate])
+		assert.Equal(t, []string{"Alice Chen", "张伟"}, entities[EntityPerson])
+		assert.Equal(t, []string{"Seattle", "杭州市"}, entities[EntityLocation])
+	})
+
+	t.Run("无效日期被忽略", func(t *testing.T) {
+		entities := extractEntities("The release moved from 2024-02-30 to February 28, 2024.", 0)
+		assert.Equal(t, []string{"2024-02-28"}, entities[EntityDate])
+	})
+
+	t.Run("每种实体数量受上限约束", func(t *testing.T) {
+		entities := extractEntities("Dates: 2024-01-01, 2024-01-02, 2024-01-03.", 2)
+		assert.Len(t, entities[EntityDate], 2)
+	})
+
+	t.Run("没有实体时返回空结果", func(t *testing.T) {
+		assert.Empty(t, extractEntities("just some lowercase notes without names", 0))
+	})
+}
+
+// TestProcessor_EntityMetadata 测试分类阶段抽取的实体写入向量元数据并可通过元数据过滤搜索
+func TestProcessor_EntityMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			io.WriteString(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+			return
+		}
+		answer := `{"tags":["合作"],"categories":["商业"],"keywords":["合同"],"confidence":{"合作":0.9}}`
+		response, _ := json.Marshal(map[string]interface{}{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"model":   "test-model",
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": answer}, "finish_reason": "stop"}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 10, "total_tokens": 20},
+		})
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: server.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+		Processing: config.ProcessingConfig{
+			MaxContentSize: 100000,
+			TagLimits:      config.TagLimitsConfig{MaxTags: 10, MaxTagLength: 50, DefaultConfidence: 0.5},
+			Entities:       config.EntityExtractionConfig{Enabled: true},
+		},
+	}))
+	ctx := context.Background()
+
+	classifier, err := NewClassifier()
+	require.NoError(t, err)
+	engine, err := vector.NewSearchEngineWithStore(vector.NewMemoryStore())
+	require.NoError(t, err)
+	defer engine.Close()
+
+	processor := newTestProcessor(t)
+	processor.classifier = classifier
+	processor.searchEngine = engine
+
+	process := func(id, content string) *ProcessingResult {
+		result, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID:          id,
+			Content:     content,
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options:     ProcessingOptions{EnableClassification: true, EnableVectorization: true},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	result := process("req-1", "Acme Corp signed the partnership contract on March 5, 2024, expanding its cloud business.")
+	process("req-2", "Notes about the weekly team meeting and the remaining tasks for the sprint.")
+
+	processedData := result.ContentItem.GetProcessedData()
+	assert.Equal(t, []string{"Acme Corp"}, processedData[vector.MetadataKeyEntityOrganizations])
+	assert.Equal(t, []string{"2024-03-05"}, processedData[vector.MetadataKeyEntityDates])
+
+	search := func(filters map[string]interface{}) []string {
+		response, err := engine.Search(ctx, &vector.SearchOptions{
+			Query: "contract", UserID: "user-1", TopK: 10, MetadataFilters: filters,
+		})
+		require.NoError(t, err)
+		ids := make([]string, 0, len(response.Results))
+		for _, item := range response.Results {
+			ids = append(ids, item.DocumentID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{result.ContentItem.ID}, search(map[string]interface{}{vector.MetadataKeyEntityOrganizations: "Acme Corp"}))
+	assert.Equal(t, []string{result.ContentItem.ID}, search(map[string]interface{}{vector.MetadataKeyEntityDates: []string{"2024-03-05"}}))
+	assert.Empty(t, search(map[string]interface{}{vector.MetadataKeyEntityOrganizations: "Globex Inc"}))
+}
@@ -0,0 +1,151 @@
+package content
+
+import (
+	"time"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+)
+
+// eventBufferSize 每个订阅者的事件缓冲大小（一次处理最多产生三次状态变化和五个阶段事件）
+const eventBufferSize = 32
+
+// ProcessingEventType 处理事件类型
+type ProcessingEventType string
+
+const (
+	EventTypeStatus ProcessingEventType = "status" // 请求状态变化
+	EventTypeStage  ProcessingEventType = "stage"  // 处理阶段完成
+)
+
+// ProcessingEvent 请求处理进度事件
+type ProcessingEvent struct {
+	RequestID string              `json:"request_id"`      // 请求ID
+	Type      ProcessingEventType `json:"type"`            // 事件类型
+	Status    ProcessingStatus    `json:"status"`          // 事件发生时的请求状态
+	Stage     ProcessingStage     `json:"stage,omitempty"` // 完成的处理阶段（阶段事件）
+	Error     string              `json:"error,omitempty"` // 失败原因
+	Timestamp time.Time           `json:"timestamp"`       // 事件时间
+}
+
+// Terminal 事件是否表示请求已结束（之后不会再有事件）
+func (e *ProcessingEvent) Terminal() bool {
+	return e.Type == EventTypeStatus && isTerminalStatus(e.Status)
+}
+
+// isTerminalStatus 是否为终止状态
+func isTerminalStatus(status ProcessingStatus) bool {
+	return status == StatusCompleted || status == StatusFailed || status == StatusCancelled
+}
+
+// SubscribeEvents 订阅请求的处理事件：先推送当前状态，随后推送状态变化和阶段完成事件，请求结束后关闭通道
+// 返回的函数用于提前退订（如客户端断开连接）
+func (p *Processor) SubscribeEvents(requestID string) (<-chan *ProcessingEvent, func(), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result, exists := p.results[requestID]
+	if !exists {
+		return nil, nil, errors.ErrResourceNotFound("processing_request", requestID)
+	}
+
+	events := make(chan *ProcessingEvent, eventBufferSize)
+	events <- &ProcessingEvent{
+		RequestID: requestID,
+		Type:      EventTypeStatus,
+		Status:    result.Status,
+		Error:     result.Error,
+		Timestamp: time.Now(),
+	}
+
+	// 已结束的请求只推送当前状态
+	if isTerminalStatus(result.Status) {
+		close(events)
+		return events, func() {}, nil
+	}
+
+	if p.eventSubscribers == nil {
+		p.eventSubscribers = make(map[string][]chan *ProcessingEvent)
+	}
+	p.eventSubscribers[requestID] = append(p.eventSubscribers[requestID], events)
+
+	unsubscribe := func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		subscribers := p.eventSubscribers[requestID]
+		for i, subscriber := range subscribers {
+			if subscriber == events {
+				p.eventSubscribers[requestID] = append(subscribers[:i], subscribers[i+1:]...)
+				if len(p.eventSubscribers[requestID]) == 0 {
+					delete(p.eventSubscribers, requestID)
+				}
+				close(events)
+				return
+			}
+		}
+	}
+
+	return events, unsubscribe, nil
+}
+
+// publishStatusLocked 推送状态变化事件（调用方需持有p.mu）
+func (p *Processor) publishStatusLocked(requestID string, status ProcessingStatus, errMsg string) {
+	p.publishLocked(&ProcessingEvent{
+		RequestID: requestID,
+		Type:      EventTypeStatus,
+		Status:    status,
+		Error:     errMsg,
+		Timestamp: time.Now(),
+	})
+}
+
+// publishStage 推送阶段完成事件
+func (p *Processor) publishStage(requestID string, stage ProcessingStage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.eventSubscribers[requestID]) == 0 {
+		return
+	}
+
+	status := StatusProcessing
+	if result, exists := p.results[requestID]; exists {
+		status = result.Status
+	}
+	p.publishLocked(&ProcessingEvent{
+		RequestID: requestID,
+		Type:      EventTypeStage,
+		Status:    status,
+		Stage:     stage,
+		Timestamp: time.Now(),
+	})
+}
+
+// publishLocked 向请求的所有订阅者推送事件，终止事件后关闭订阅（调用方需持有p.mu）
+func (p *Processor) publishLocked(event *ProcessingEvent) {
+	subscribers := p.eventSubscribers[event.RequestID]
+	if len(subscribers) == 0 {
+		return
+	}
+
+	for _, subscriber := range subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			// 订阅者未及时读取，丢弃事件而不阻塞处理
+			p.logger.Warn("Processing event dropped for slow subscriber", logger.Fields{
+				"request_id": event.RequestID,
+				"type":       string(event.Type),
+				"status":     string(event.Status),
+			})
+		}
+	}
+
+	if event.Terminal() {
+		for _, subscriber := range subscribers {
+			close(subscriber)
+		}
+		delete(p.eventSubscribers, event.RequestID)
+	}
+}
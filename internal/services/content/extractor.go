@@ -3,17 +3,16 @@ package content
 import (
 	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
-	"time"
 
 	"memoro/internal/config"
 	"memoro/internal/errors"
 	"memoro/internal/logger"
 	"memoro/internal/models"
+	"memoro/internal/services/language"
+	"memoro/internal/services/preprocess"
 )
 
 // ExtractedContent 提取的内容结构
@@ -25,6 +24,10 @@ type ExtractedContent struct {
 	Type        models.ContentType     `json:"type"`         // 内容类型
 	Size        int64                  `json:"size"`         // 内容大小
 	Language    string                 `json:"language"`     // 语言
+
+	LowQuality bool `json:"low_quality,omitempty"` // 提取质量低于阈值（疑似乱码），建议人工复核后重新处理
+
+	OriginalMarkup string `json:"original_markup,omitempty"` // Markdown/HTML转换为纯文本前的原始内容
 }
 
 // Extractor 内容提取器接口
@@ -47,6 +50,8 @@ type ExtractorManager struct {
 	extractors map[models.ContentType]Extractor
 	config     config.ProcessingConfig
 	logger     *logger.Logger
+
+	preprocessor *preprocess.Preprocessor // 文本预处理（nil时使用默认步骤）
 }
 
 // NewExtractorManager 创建提取器管理器
@@ -56,10 +61,16 @@ func NewExtractorManager() (*ExtractorManager, error) {
 		return nil, errors.ErrConfigMissing("processing config")
 	}
 
+	preprocessor, err := preprocess.NewPreprocessor(cfg.Processing.Preprocessing)
+	if err != nil {
+		return nil, err
+	}
+
 	manager := &ExtractorManager{
-		extractors: make(map[models.ContentType]Extractor),
-		config:     cfg.Processing,
-		logger:     logger.NewLogger("extractor-manager"),
+		extractors:   make(map[models.ContentType]Extractor),
+		config:       cfg.Processing,
+		logger:       logger.NewLogger("extractor-manager"),
+		preprocessor: preprocessor,
 	}
 
 	// 注册各类型提取器
@@ -77,17 +88,20 @@ func NewExtractorManager() (*ExtractorManager, error) {
 // registerExtractors 注册提取器
 func (em *ExtractorManager) registerExtractors() error {
 	// 注册文本提取器
+	languageDetector := language.NewDetector(em.config.LanguageDetection)
 	textExtractor := &TextExtractor{
-		config: em.config,
-		logger: logger.NewLogger("text-extractor"),
+		config:           em.config,
+		languageDetector: languageDetector,
+		logger:           logger.NewLogger("text-extractor"),
 	}
 	em.extractors[models.ContentTypeText] = textExtractor
 
 	// 注册链接提取器
 	linkExtractor := &LinkExtractor{
-		config:     em.config,
-		logger:     logger.NewLogger("link-extractor"),
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		config:           em.config,
+		languageDetector: languageDetector,
+		logger:           logger.NewLogger("link-extractor"),
+		fetcher:          newLinkFetcher(em.config.LinkFetch),
 	}
 	em.extractors[models.ContentTypeLink] = linkExtractor
 
@@ -142,11 +156,23 @@ func (em *ExtractorManager) Extract(ctx context.Context, rawContent string, cont
 		return nil, err
 	}
 
+	// 统一预处理提取的文本
+	if result != nil {
+		pipeline := em.preprocessor.ForContentType(contentType)
+		result.Content = pipeline.Apply(result.Content)
+		result.Title = pipeline.Apply(result.Title)
+		result.Description = pipeline.Apply(result.Description)
+		result.Size = int64(len(result.Content))
+	}
+
 	// 验证提取结果
 	if err := em.validateExtractedContent(result); err != nil {
 		return nil, err
 	}
 
+	// 评估提取质量
+	em.applyExtractionQuality(result)
+
 	em.logger.Debug("Content extraction completed", logger.Fields{
 		"content_type":   string(contentType),
 		"extracted_size": len(result.Content),
@@ -211,20 +237,38 @@ func (em *ExtractorManager) Close() error {
 
 // TextExtractor 文本内容提取器
 type TextExtractor struct {
-	config config.ProcessingConfig
-	logger *logger.Logger
+	config           config.ProcessingConfig
+	languageDetector *language.Detector
+	logger           *logger.Logger
 }
 
 // Extract 提取文本内容
 func (te *TextExtractor) Extract(ctx context.Context, rawContent string, contentType models.ContentType) (*ExtractedContent, error) {
 	// 文本内容直接返回，进行基本清理
 	cleanContent := strings.TrimSpace(rawContent)
+
+	// Markdown/HTML转换为纯文本，标题取自原始标记中的标题行
+	format := detectTextFormat(cleanContent)
+	title := ""
+	originalMarkup := ""
+	if format != TextFormatPlain && !te.config.TextMarkup.KeepMarkup {
+		if format == TextFormatMarkdown {
+			title = te.extractMarkdownHeading(cleanContent)
+		}
+		// 只有标记没有文字时保留原内容
+		if plain := stripMarkup(cleanContent, format); plain != "" {
+			originalMarkup = cleanContent
+			cleanContent = plain
+		}
+	}
 	
-	// 检测语言（简单实现）
-	language := te.detectLanguage(cleanContent)
+	// 检测语言
+	lang, langConfidence := detectLanguage(te.languageDetector, cleanContent)
 	
 	// 尝试提取标题（如果内容包含明显的标题格式）
-	title := te.extractTitle(cleanContent)
+	if title == "" {
+		title = te.extractTitle(cleanContent)
+	}
 	
 	result := &ExtractedContent{
 		Content:     cleanContent,
@@ -232,20 +276,25 @@ func (te *TextExtractor) Extract(ctx context.Context, rawContent string, content
 		Description: te.generateDescription(cleanContent),
 		Type:        models.ContentTypeText,
 		Size:        int64(len(cleanContent)),
-		Language:    language,
+		Language:    lang,
 		Metadata: map[string]interface{}{
 			"word_count":      te.countWords(cleanContent),
 			"line_count":      strings.Count(cleanContent, "\n") + 1,
 			"has_urls":        te.containsURLs(cleanContent),
-			"estimated_read_time": te.estimateReadTime(cleanContent),
+			"estimated_read_time": te.estimateReadTime(cleanContent, lang),
+			"language":            lang,
+			"language_confidence": langConfidence,
+			TextFormatKey:         string(format),
 		},
+		OriginalMarkup: originalMarkup,
 	}
 
 	te.logger.Debug("Text extraction completed", logger.Fields{
 		"content_length": len(cleanContent),
 		"word_count":     result.Metadata["word_count"],
-		"language":       language,
+		"language":       lang,
 		"has_title":      title != "",
+		"text_format":    string(format),
 	})
 
 	return result, nil
@@ -261,21 +310,12 @@ func (te *TextExtractor) GetSupportedTypes() []models.ContentType {
 	return []models.ContentType{models.ContentTypeText}
 }
 
-// detectLanguage 检测语言
-func (te *TextExtractor) detectLanguage(content string) string {
-	// 简单的中英文检测
-	chinesePattern := regexp.MustCompile(`[\p{Han}]`)
-	englishPattern := regexp.MustCompile(`[a-zA-Z]`)
-
-	chineseCount := len(chinesePattern.FindAllString(content, -1))
-	englishCount := len(englishPattern.FindAllString(content, -1))
-
-	if chineseCount > englishCount {
-		return "zh"
-	} else if englishCount > 0 {
-		return "en"
+// detectLanguage 检测语言，未配置检测器时使用默认配置
+func detectLanguage(detector *language.Detector, content string) (string, float64) {
+	if detector == nil {
+		return language.Detect(content)
 	}
-	return "unknown"
+	return detector.Detect(content)
 }
 
 // extractTitle 提取标题
@@ -303,6 +343,16 @@ func (te *TextExtractor) extractTitle(content string) string {
 	return ""
 }
 
+// extractMarkdownHeading 提取Markdown的第一个标题行（去除行内标记）
+func (te *TextExtractor) extractMarkdownHeading(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if matches := markdownHeadingLine.FindStringSubmatch(line); matches != nil {
+			return strings.TrimSpace(stripInlineMarkdown(matches[1]))
+		}
+	}
+	return ""
+}
+
 // generateDescription 生成描述
 func (te *TextExtractor) generateDescription(content string) string {
 	// 取前200字符作为描述
@@ -323,16 +373,9 @@ func (te *TextExtractor) generateDescription(content string) string {
 	return truncated + "..."
 }
 
-// countWords 计算词数
+// countWords 计算词数（中文按词典切分为词，而不是逐字计数）
 func (te *TextExtractor) countWords(content string) int {
-	// 中文按字符计算，英文按单词计算
-	chinesePattern := regexp.MustCompile(`[\p{Han}]`)
-	englishPattern := regexp.MustCompile(`\b[a-zA-Z]+\b`)
-
-	chineseCount := len(chinesePattern.FindAllString(content, -1))
-	englishWords := englishPattern.FindAllString(content, -1)
-	
-	return chineseCount + len(englishWords)
+	return language.CountWords(content)
 }
 
 // containsURLs 检查是否包含URL
@@ -341,15 +384,9 @@ func (te *TextExtractor) containsURLs(content string) bool {
 	return urlPattern.MatchString(content)
 }
 
-// estimateReadTime 估算阅读时间（分钟）
-func (te *TextExtractor) estimateReadTime(content string) int {
-	wordCount := te.countWords(content)
-	// 假设每分钟阅读200个词/字
-	minutes := wordCount / 200
-	if minutes < 1 {
-		return 1
-	}
-	return minutes
+// estimateReadTime 按内容语言的阅读速度估算阅读时间（分钟）
+func (te *TextExtractor) estimateReadTime(content, lang string) int {
+	return language.EstimateReadMinutes(te.countWords(content), lang, te.config.ReadingSpeed)
 }
 
 // Close 关闭文本提取器
@@ -360,9 +397,10 @@ func (te *TextExtractor) Close() error {
 
 // LinkExtractor 链接内容提取器
 type LinkExtractor struct {
-	config     config.ProcessingConfig
-	logger     *logger.Logger
-	httpClient *http.Client
+	config           config.ProcessingConfig
+	languageDetector *language.Detector
+	logger           *logger.Logger
+	fetcher          *linkFetcher
 }
 
 // Extract 提取链接内容
@@ -379,45 +417,31 @@ func (le *LinkExtractor) Extract(ctx context.Context, rawContent string, content
 		"host":   parsedURL.Host,
 	})
 
-	// 创建HTTP请求
-	req, err := http.NewRequestWithContext(ctx, "GET", parsedURL.String(), nil)
+	// 抓取页面（暂时性错误自动重试，相同规范URL在缓存有效期内不重复抓取）
+	page, err := le.fetcher.Fetch(ctx, parsedURL)
 	if err != nil {
-		return nil, errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to create HTTP request").
-			WithCause(err).
-			WithContext(map[string]interface{}{"url": parsedURL.String()})
+		return nil, err
 	}
 
-	// 设置User-Agent
-	req.Header.Set("User-Agent", "Memoro/1.0 (Knowledge Management Bot)")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	htmlContent := string(page.body)
 
-	// 发送请求
-	resp, err := le.httpClient.Do(req)
-	if err != nil {
-		return nil, errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to fetch URL").
-			WithCause(err).
-			WithContext(map[string]interface{}{"url": parsedURL.String()})
-	}
-	defer resp.Body.Close()
-
-	// 检查响应状态
-	if resp.StatusCode >= 400 {
-		return nil, errors.ErrValidationFailed("url", fmt.Sprintf("HTTP error: %d %s", resp.StatusCode, resp.Status))
-	}
+	// 解析最终地址（跟随重定向后）和规范URL
+	finalURL := page.finalURL
+	canonicalURL := CanonicalizeURL(finalURL.String())
 
-	// 读取响应内容
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to read response body").
-			WithCause(err)
+	// 提取网页信息（纯文本响应直接使用正文）
+	var title, description, content string
+	if page.isPlainText() {
+		content = truncateLinkContent(tidyLines(htmlContent))
+	} else {
+		if canonicalLink := extractCanonicalLink(htmlContent, finalURL); canonicalLink != "" {
+			canonicalURL = CanonicalizeURL(canonicalLink)
+		}
+		title = le.extractTitle(htmlContent)
+		description = le.extractDescription(htmlContent)
+		content = le.extractMainContent(htmlContent)
 	}
-
-	htmlContent := string(bodyBytes)
-
-	// 提取网页信息
-	title := le.extractTitle(htmlContent)
-	description := le.extractDescription(htmlContent)
-	content := le.extractMainContent(htmlContent)
+	lang, langConfidence := detectLanguage(le.languageDetector, content)
 
 	result := &ExtractedContent{
 		Content:     content,
@@ -425,22 +449,40 @@ func (le *LinkExtractor) Extract(ctx context.Context, rawContent string, content
 		Description: description,
 		Type:        models.ContentTypeLink,
 		Size:        int64(len(content)),
-		Language:    le.detectLanguage(content),
+		Language:    lang,
 		Metadata: map[string]interface{}{
-			"url":             parsedURL.String(),
-			"domain":          parsedURL.Host,
-			"status_code":     resp.StatusCode,
-			"content_type":    resp.Header.Get("Content-Type"),
-			"content_length":  len(bodyBytes),
-			"response_time":   time.Now(),
+			"url":                 parsedURL.String(),
+			"original_url":        parsedURL.String(),
+			"final_url":           finalURL.String(),
+			"canonical_url":       canonicalURL,
+			"domain":              parsedURL.Host,
+			"status_code":         page.statusCode,
+			"content_type":        page.contentType,
+			"content_length":      len(page.body),
+			"truncated":           page.truncated,
+			"response_time":       page.fetchedAt,
+			"fetched_at":          page.fetchedAt,
+			"fetch_attempts":      page.attempts,
+			"from_cache":          page.fromCache,
+			"language":            lang,
+			"language_confidence": langConfidence,
 		},
 	}
 
+	// 链接预览（富卡片展示用，缺失的字段不写入）
+	if !page.isPlainText() && !le.config.LinkFetch.DisablePreview {
+		for key, value := range extractLinkPreview(htmlContent, finalURL) {
+			result.Metadata[key] = value
+		}
+	}
+
 	le.logger.Debug("Link extraction completed", logger.Fields{
 		"url":            parsedURL.String(),
+		"canonical_url":  canonicalURL,
 		"title":          title,
 		"content_length": len(content),
-		"status_code":    resp.StatusCode,
+		"status_code":    page.statusCode,
+		"from_cache":     page.fromCache,
 	})
 
 	return result, nil
@@ -487,32 +529,18 @@ func (le *LinkExtractor) extractMainContent(html string) string {
 	
 	content = strings.TrimSpace(content)
 	
-	// 限制内容长度
+	return truncateLinkContent(content)
+}
+
+// truncateLinkContent 限制链接正文长度
+func truncateLinkContent(content string) string {
 	maxLen := 5000
 	if len(content) > maxLen {
 		content = content[:maxLen] + "..."
 	}
-	
 	return content
 }
 
-// detectLanguage 检测语言
-func (le *LinkExtractor) detectLanguage(content string) string {
-	// 简单的中英文检测（复用TextExtractor的逻辑）
-	chinesePattern := regexp.MustCompile(`[\p{Han}]`)
-	englishPattern := regexp.MustCompile(`[a-zA-Z]`)
-
-	chineseCount := len(chinesePattern.FindAllString(content, -1))
-	englishCount := len(englishPattern.FindAllString(content, -1))
-
-	if chineseCount > englishCount {
-		return "zh"
-	} else if englishCount > 0 {
-		return "en"
-	}
-	return "unknown"
-}
-
 // CanHandle 检查是否能处理链接类型
 func (le *LinkExtractor) CanHandle(contentType models.ContentType) bool {
 	return contentType == models.ContentTypeLink
@@ -0,0 +1,238 @@
+package content
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/language"
+	"memoro/internal/services/preprocess"
+)
+
+// TestTextExtractor_Language 测试文本提取时记录语言及置信度
+func TestTextExtractor_Language(t *testing.T) {
+	extractor := &TextExtractor{
+		languageDetector: language.NewDetector(config.LanguageDetectionConfig{}),
+		logger:           logger.NewLogger("text-extractor-test"),
+	}
+
+	t.Run("法文不再被识别为英文", func(t *testing.T) {
+		result, err := extractor.Extract(context.Background(),
+			"Je voudrais réserver une table pour deux personnes ce soir, s'il vous plaît.", models.ContentTypeText)
+		require.NoError(t, err)
+
+		assert.Equal(t, "fr", result.Language)
+		assert.Equal(t, "fr", result.Metadata["language"])
+		assert.Greater(t, result.Metadata["language_confidence"], 0.5)
+	})
+
+	t.Run("短文本返回unknown", func(t *testing.T) {
+		result, err := extractor.Extract(context.Background(), "ok", models.ContentTypeText)
+		require.NoError(t, err)
+
+		assert.Equal(t, language.Unknown, result.Language)
+	})
+}
+
+// TestTextExtractor_WordCount 测试中文按词计数及阅读时间估算
+func TestTextExtractor_WordCount(t *testing.T) {
+	<-language.PreloadSegmenter()
+
+	extractor := &TextExtractor{
+		languageDetector: language.NewDetector(config.LanguageDetectionConfig{}),
+		logger:           logger.NewLogger("text-extractor-test"),
+	}
+	paragraph := "我们今天讨论分布式系统的一致性问题，以及如何选择合适的方案。"
+
+	t.Run("中文按词而不是逐字计数", func(t *testing.T) {
+		hanCount := 0
+		for _, r := range paragraph {
+			if unicode.Is(unicode.Han, r) {
+				hanCount++
+			}
+		}
+
+		wordCount := extractor.countWords(paragraph)
+		assert.Equal(t, 14, wordCount)
+		assert.Less(t, wordCount, hanCount)
+	})
+
+	t.Run("长中文文本的阅读时间", func(t *testing.T) {
+		content := strings.Repeat(paragraph, 60)
+
+		result, err := extractor.Extract(context.Background(), content, models.ContentTypeText)
+		require.NoError(t, err)
+
+		assert.Equal(t, "zh", result.Language)
+		assert.Equal(t, 14*60, result.Metadata["word_count"])
+		assert.Equal(t, 3, result.Metadata["estimated_read_time"])
+	})
+
+	t.Run("使用配置的阅读速度", func(t *testing.T) {
+		configured := &TextExtractor{
+			config: config.ProcessingConfig{
+				ReadingSpeed: config.ReadingSpeedConfig{Languages: map[string]int{"zh": 500}},
+			},
+			languageDetector: extractor.languageDetector,
+			logger:           extractor.logger,
+		}
+
+		assert.Equal(t, 2, configured.estimateReadTime(strings.Repeat(paragraph, 60), "zh"))
+	})
+}
+
+// TestExtractorManager_Preprocessing 测试提取结果经过统一预处理
+func TestExtractorManager_Preprocessing(t *testing.T) {
+	preprocessor, err := preprocess.NewPreprocessor(config.PreprocessingConfig{})
+	require.NoError(t, err)
+
+	manager := &ExtractorManager{
+		extractors:   make(map[models.ContentType]Extractor),
+		config:       config.ProcessingConfig{MaxContentSize: 100000},
+		logger:       logger.NewLogger("extractor-manager-test"),
+		preprocessor: preprocessor,
+	}
+	require.NoError(t, manager.registerExtractors())
+
+	composed, err := manager.Extract(context.Background(), "Café au lait est une boisson chaude très populaire en France.", models.ContentTypeText)
+	require.NoError(t, err)
+	decomposed, err := manager.Extract(context.Background(), "  Cafe\u0301 au\u200b lait est une boisson\n\nchaude très populaire en France.\x00", models.ContentTypeText)
+	require.NoError(t, err)
+
+	assert.Equal(t, composed.Content, decomposed.Content)
+	assert.Equal(t, preprocessor.ForContentType(models.ContentTypeText).Apply(decomposed.Content), decomposed.Content)
+	assert.Equal(t, int64(len(decomposed.Content)), decomposed.Size)
+}
+
+// TestExtractorManager_ExtractionQuality 测试乱码提取结果被标记为低质量
+func TestExtractorManager_ExtractionQuality(t *testing.T) {
+	manager := &ExtractorManager{
+		extractors: make(map[models.ContentType]Extractor),
+		config:     config.ProcessingConfig{MaxContentSize: 100000},
+		logger:     logger.NewLogger("extractor-manager-test"),
+	}
+	require.NoError(t, manager.registerExtractors())
+
+	t.Run("乱码被标记为低质量", func(t *testing.T) {
+		gibberish := strings.Repeat("#@!% xj9$k ~~~ ||| 0x3f@@ ^^&* qzxv ;;:: ", 10)
+		result, err := manager.Extract(context.Background(), gibberish, models.ContentTypeText)
+		require.NoError(t, err)
+
+		assert.True(t, result.LowQuality)
+		assert.Less(t, result.Metadata["extraction_quality"], defaultMinExtractionQuality)
+	})
+
+	t.Run("正常文章不被标记", func(t *testing.T) {
+		article := "Distributed systems must balance consistency, availability and partition tolerance. " +
+			"In practice most teams choose eventual consistency for user-facing features, " +
+			"then add compensating workflows where stronger guarantees are required. " +
+			"This article walks through three production incidents and what we learned from each."
+		result, err := manager.Extract(context.Background(), article, models.ContentTypeText)
+		require.NoError(t, err)
+
+		assert.False(t, result.LowQuality)
+		assert.Greater(t, result.Metadata["extraction_quality"], 0.8)
+	})
+
+	t.Run("阈值可配置", func(t *testing.T) {
+		strict := &ExtractorManager{
+			extractors: manager.extractors,
+			config: config.ProcessingConfig{
+				MaxContentSize:    100000,
+				ExtractionQuality: config.ExtractionQualityConfig{MinScore: 0.99},
+			},
+			logger: manager.logger,
+		}
+		result, err := strict.Extract(context.Background(), "Short note with a typo: teh meeting moved to 3pm!!", models.ContentTypeText)
+		require.NoError(t, err)
+
+		assert.True(t, result.LowQuality)
+	})
+}
+
+// TestTextExtractor_Markup 测试Markdown/HTML转换为纯文本并保留原始标记
+func TestTextExtractor_Markup(t *testing.T) {
+	extractor := &TextExtractor{
+		languageDetector: language.NewDetector(config.LanguageDetectionConfig{}),
+		logger:           logger.NewLogger("text-extractor-test"),
+	}
+	markdown := "# Go **concurrency** notes\n\n" +
+		"Read the [official tour](https://go.dev/tour) before *anything* else.\n\n" +
+		"## Key ideas\n\n" +
+		"- Goroutines are `cheap`\n" +
+		"- Channels __synchronize__ work\n\n" +
+		"> Don't communicate by sharing memory.\n"
+
+	t.Run("Markdown转换为干净的纯文本", func(t *testing.T) {
+		result, err := extractor.Extract(context.Background(), markdown, models.ContentTypeText)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Go concurrency notes.\n\n"+
+			"Read the official tour before anything else.\n\n"+
+			"Key ideas.\n\n"+
+			"Goroutines are cheap.\n"+
+			"Channels synchronize work.\n\n"+
+			"Don't communicate by sharing memory.", result.Content)
+		assert.Equal(t, "Go concurrency notes", result.Title)
+		assert.Equal(t, string(TextFormatMarkdown), result.Metadata[TextFormatKey])
+		assert.Equal(t, strings.TrimSpace(markdown), result.OriginalMarkup)
+		assert.NotContains(t, result.Content, "https://go.dev/tour")
+	})
+
+	t.Run("HTML去除标签并解码实体", func(t *testing.T) {
+		result, err := extractor.Extract(context.Background(),
+			`<div><p>Tom &amp; Jerry</p><script>track()</script><p>A <strong>classic</strong> cartoon.</p></div>`, models.ContentTypeText)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Tom & Jerry\n\nA classic cartoon.", result.Content)
+		assert.Equal(t, string(TextFormatHTML), result.Metadata[TextFormatKey])
+		assert.NotEmpty(t, result.OriginalMarkup)
+	})
+
+	t.Run("纯文本保持不变", func(t *testing.T) {
+		text := "snake_case names and 2 * 3 = 6 are not markup."
+		result, err := extractor.Extract(context.Background(), text, models.ContentTypeText)
+		require.NoError(t, err)
+
+		assert.Equal(t, text, result.Content)
+		assert.Equal(t, string(TextFormatPlain), result.Metadata[TextFormatKey])
+		assert.Empty(t, result.OriginalMarkup)
+	})
+
+	t.Run("配置保留标记", func(t *testing.T) {
+		keeping := &TextExtractor{
+			config: config.ProcessingConfig{TextMarkup: config.TextMarkupConfig{KeepMarkup: true}},
+			logger: extractor.logger,
+		}
+		result, err := keeping.Extract(context.Background(), markdown, models.ContentTypeText)
+		require.NoError(t, err)
+
+		assert.Equal(t, strings.TrimSpace(markdown), result.Content)
+		assert.Equal(t, string(TextFormatMarkdown), result.Metadata[TextFormatKey])
+		assert.Empty(t, result.OriginalMarkup)
+	})
+
+	t.Run("处理数据保留原始标记", func(t *testing.T) {
+		processor := newTestProcessor(t)
+
+		result, err := processor.doProcessing(context.Background(), &ProcessingRequest{
+			ID:          "req-markup",
+			Content:     markdown,
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+		})
+		require.NoError(t, err)
+
+		assert.NotContains(t, result.ContentItem.RawContent, "**")
+		processedData := result.ContentItem.GetProcessedData()
+		assert.Equal(t, strings.TrimSpace(markdown), processedData[OriginalMarkupKey])
+		assert.Equal(t, string(TextFormatMarkdown), processedData[TextFormatKey])
+	})
+}
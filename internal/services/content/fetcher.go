@@ -0,0 +1,675 @@
+package content
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/services/llm"
+)
+
+// 未配置时的链接抓取参数
+const (
+	defaultFetchTimeout        = 30 * time.Second
+	defaultFetchRetryDelay     = 500 * time.Millisecond
+	defaultFetchMaxRetryDelay  = 10 * time.Second
+	defaultFetchMaxRedirects   = 10
+	defaultFetchConnectTimeout = 10 * time.Second
+	defaultFetchMaxBodySize    = 5 * 1024 * 1024
+	defaultFetchCacheMaxBytes  = 64 * 1024 * 1024
+	defaultMaxCrawlDelay       = 30 * time.Second
+	robotsCacheTTL             = time.Hour
+	maxRobotsSize              = 512 * 1024
+	maxErrorBodyDrain          = 64 * 1024   // 错误响应最多读取的字节数（读完以便复用连接，超出时直接关闭）
+	hostStateSweepInterval     = time.Minute // 清理过期的robots.txt规则和主机请求间隔记录的最小间隔
+	fetchUserAgent             = "Memoro/1.0 (Knowledge Management Bot)"
+	robotsUserAgentToken       = "memoro"
+)
+
+// fetchedPage 抓取到的页面响应
+type fetchedPage struct {
+	finalURL    *url.URL
+	statusCode  int
+	contentType string
+	body        []byte
+	fetchedAt   time.Time
+	attempts    int  // 实际发出的请求次数（含重试）
+	fromCache   bool // 是否来自响应缓存
+	truncated   bool // 响应体超过读取上限被截断
+}
+
+// isPlainText 响应是否为纯文本（非HTML）
+func (page *fetchedPage) isPlainText() bool {
+	mediaType, _, _ := mime.ParseMediaType(page.contentType)
+	return mediaType == "text/plain"
+}
+
+// cachedPage 响应缓存条目
+type cachedPage struct {
+	key       string
+	page      *fetchedPage
+	expiresAt time.Time
+	element   *list.Element // 在LRU链表中的位置（表头为最近使用）
+}
+
+// robotsRules 主机的robots.txt规则
+type robotsRules struct {
+	allow      []string
+	disallow   []string
+	crawlDelay time.Duration
+	expiresAt  time.Time
+}
+
+// linkFetcher 链接抓取器：暂时性错误自动重试（指数退避），按规范URL缓存响应（总字节数有上限，超出时按LRU淘汰），
+// 遵守robots.txt和每个主机的请求间隔（Crawl-delay有上限），跟随重定向（有跳数上限）
+type linkFetcher struct {
+	config     config.LinkFetchConfig
+	httpClient *http.Client
+	logger     *logger.Logger
+
+	cache      map[string]*cachedPage
+	cacheLRU   *list.List // 元素为*cachedPage
+	cacheBytes int64      // 缓存中响应体的总字节数
+	cacheMutex sync.Mutex
+
+	robots          map[string]*robotsRules
+	robotsLastSweep time.Time
+	robotsMutex     sync.Mutex
+
+	hostNextRequest map[string]time.Time // 主机下一次允许请求的时间
+	hostLastSweep   time.Time
+	hostMutex       sync.Mutex
+}
+
+// newLinkFetcher 创建链接抓取器
+func newLinkFetcher(cfg config.LinkFetchConfig) *linkFetcher {
+	connectTimeout := cfg.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = defaultFetchConnectTimeout
+	}
+	maxRedirects := cfg.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultFetchMaxRedirects
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+
+	return &linkFetcher{
+		config: cfg,
+		// 请求超时按主机在每次请求的上下文上设置
+		httpClient: &http.Client{
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				return nil
+			},
+		},
+		logger:          logger.NewLogger("link-fetcher"),
+		cache:           make(map[string]*cachedPage),
+		cacheLRU:        list.New(),
+		robots:          make(map[string]*robotsRules),
+		hostNextRequest: make(map[string]time.Time),
+	}
+}
+
+// Fetch 抓取页面，缓存有效期内的相同规范URL直接返回缓存的响应
+func (lf *linkFetcher) Fetch(ctx context.Context, target *url.URL) (*fetchedPage, error) {
+	cacheKey := CanonicalizeURL(target.String())
+	if page := lf.cachedPage(cacheKey); page != nil {
+		llm.RecordCacheHit(ctx)
+		lf.logger.Debug("Link served from cache", logger.Fields{
+			"url":           target.String(),
+			"canonical_url": cacheKey,
+		})
+		return page, nil
+	}
+
+	if !lf.config.IgnoreRobots {
+		rules := lf.robotsRules(ctx, target)
+		if !rules.allows(target) {
+			return nil, errors.ErrValidationFailed("url", "fetching is disallowed by robots.txt")
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= lf.config.RetryTimes; attempt++ {
+		if attempt > 0 {
+			delay := lf.retryDelay(attempt, lastErr)
+			lf.logger.Warn("Retrying link fetch after transient error", logger.Fields{
+				"url":     target.String(),
+				"attempt": attempt,
+				"delay":   delay.String(),
+				"error":   lastErr.Error(),
+			})
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, lf.fetchError(err, target)
+			}
+		}
+
+		page, err := lf.fetchOnce(ctx, target)
+		if err == nil {
+			page.attempts = attempt + 1
+			lf.storePage(cacheKey, page)
+			return page, nil
+		}
+		lastErr = err
+		if !isTransientFetchError(ctx, err) {
+			break
+		}
+	}
+
+	return nil, lf.fetchError(lastErr, target)
+}
+
+// hostLimits 获取主机的请求超时和响应体读取上限（主机覆盖优先于全局配置）
+func (lf *linkFetcher) hostLimits(host string) (time.Duration, int64) {
+	timeout := lf.config.Timeout
+	if timeout <= 0 {
+		timeout = defaultFetchTimeout
+	}
+	maxBodySize := lf.config.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultFetchMaxBodySize
+	}
+
+	override, exists := lf.config.HostOverrides[strings.ToLower(host)]
+	if !exists {
+		// 未按host:port配置时按主机名匹配
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			override, exists = lf.config.HostOverrides[strings.ToLower(hostname)]
+		}
+	}
+	if exists {
+		if override.Timeout > 0 {
+			timeout = override.Timeout
+		}
+		if override.MaxBodySize > 0 {
+			maxBodySize = override.MaxBodySize
+		}
+	}
+	return timeout, maxBodySize
+}
+
+// fetchOnce 发出一次请求（等待主机请求间隔）
+func (lf *linkFetcher) fetchOnce(ctx context.Context, target *url.URL) (*fetchedPage, error) {
+	if err := lf.waitForHost(ctx, target.Host); err != nil {
+		return nil, err
+	}
+
+	timeout, maxBodySize := lf.hostLimits(target.Host)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", fetchUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+
+	resp, err := lf.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodyDrain))
+		return nil, &httpStatusError{statusCode: resp.StatusCode, status: resp.Status, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	// 读取响应体前检查类型，不支持的类型不下载
+	contentType := resp.Header.Get("Content-Type")
+	if !isSupportedPageType(contentType) {
+		return nil, &unsupportedContentTypeError{contentType: contentType}
+	}
+
+	// 多读一个字节用于判断是否超过上限，超出部分不读取
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	truncated := int64(len(body)) > maxBodySize
+	if truncated {
+		body = body[:maxBodySize]
+		lf.logger.Warn("Link response body truncated", logger.Fields{
+			"url":           target.String(),
+			"max_body_size": maxBodySize,
+		})
+	}
+
+	finalURL := target
+	if resp.Request != nil && resp.Request.URL != nil {
+		finalURL = resp.Request.URL
+	}
+
+	return &fetchedPage{
+		finalURL:    finalURL,
+		statusCode:  resp.StatusCode,
+		contentType: contentType,
+		body:        body,
+		fetchedAt:   time.Now(),
+		truncated:   truncated,
+	}, nil
+}
+
+// isSupportedPageType 是否为可提取的响应类型（HTML和纯文本，未声明类型时按HTML处理）
+func isSupportedPageType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "text/html", "application/xhtml+xml", "text/plain":
+		return true
+	default:
+		return false
+	}
+}
+
+// fetchError 转换为对外的错误：HTTP错误状态为校验错误，其他为网络错误
+func (lf *linkFetcher) fetchError(err error, target *url.URL) error {
+	var statusErr *httpStatusError
+	if stderrors.As(err, &statusErr) {
+		return errors.ErrValidationFailed("url", fmt.Sprintf("HTTP error: %d %s", statusErr.statusCode, statusErr.status))
+	}
+	var typeErr *unsupportedContentTypeError
+	if stderrors.As(err, &typeErr) {
+		return errors.ErrValidationFailed("url", typeErr.Error())
+	}
+	return errors.NewMemoroError(errors.ErrorTypeNetwork, errors.ErrCodeSystemGeneric, "Failed to fetch URL").
+		WithCause(err).
+		WithContext(map[string]interface{}{"url": target.String()})
+}
+
+// retryDelay 第attempt次重试前的等待时间（指数退避，服务端指定Retry-After时以其为准）
+func (lf *linkFetcher) retryDelay(attempt int, lastErr error) time.Duration {
+	delay := lf.config.RetryDelay
+	if delay <= 0 {
+		delay = defaultFetchRetryDelay
+	}
+	maxDelay := lf.config.MaxRetryDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultFetchMaxRetryDelay
+	}
+
+	delay <<= uint(attempt - 1)
+	var statusErr *httpStatusError
+	if stderrors.As(lastErr, &statusErr) && statusErr.retryAfter > delay {
+		delay = statusErr.retryAfter
+	}
+	if delay > maxDelay || delay <= 0 {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// cachedPage 获取未过期的缓存响应，命中时移到LRU表头
+func (lf *linkFetcher) cachedPage(cacheKey string) *fetchedPage {
+	if lf.config.CacheTTL <= 0 {
+		return nil
+	}
+
+	lf.cacheMutex.Lock()
+	defer lf.cacheMutex.Unlock()
+
+	entry, exists := lf.cache[cacheKey]
+	if !exists {
+		return nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		lf.removeCachedPage(entry)
+		return nil
+	}
+	lf.cacheLRU.MoveToFront(entry.element)
+
+	page := *entry.page
+	page.fromCache = true
+	page.attempts = 0
+	return &page
+}
+
+// storePage 缓存响应，清理过期条目，总字节数超过上限时淘汰最久未使用的响应；单个响应超过上限时不缓存
+func (lf *linkFetcher) storePage(cacheKey string, page *fetchedPage) {
+	if lf.config.CacheTTL <= 0 {
+		return
+	}
+	maxBytes := lf.config.CacheMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultFetchCacheMaxBytes
+	}
+	size := int64(len(page.body))
+	if size > maxBytes {
+		return
+	}
+
+	lf.cacheMutex.Lock()
+	defer lf.cacheMutex.Unlock()
+
+	now := time.Now()
+	for _, entry := range lf.cache {
+		if now.After(entry.expiresAt) {
+			lf.removeCachedPage(entry)
+		}
+	}
+	if existing, exists := lf.cache[cacheKey]; exists {
+		lf.removeCachedPage(existing)
+	}
+	for lf.cacheBytes+size > maxBytes {
+		oldest := lf.cacheLRU.Back()
+		if oldest == nil {
+			break
+		}
+		lf.removeCachedPage(oldest.Value.(*cachedPage))
+	}
+
+	entry := &cachedPage{key: cacheKey, page: page, expiresAt: now.Add(lf.config.CacheTTL)}
+	entry.element = lf.cacheLRU.PushFront(entry)
+	lf.cache[cacheKey] = entry
+	lf.cacheBytes += size
+}
+
+// removeCachedPage 移除缓存条目，调用方需持有cacheMutex
+func (lf *linkFetcher) removeCachedPage(entry *cachedPage) {
+	lf.cacheLRU.Remove(entry.element)
+	delete(lf.cache, entry.key)
+	lf.cacheBytes -= int64(len(entry.page.body))
+}
+
+// waitForHost 等待到主机允许下一次请求的时间，并预约下一次请求的时间
+func (lf *linkFetcher) waitForHost(ctx context.Context, host string) error {
+	interval := lf.config.HostMinInterval
+	if !lf.config.IgnoreRobots {
+		maxCrawlDelay := lf.config.MaxCrawlDelay
+		if maxCrawlDelay <= 0 {
+			maxCrawlDelay = defaultMaxCrawlDelay
+		}
+		lf.robotsMutex.Lock()
+		if rules, exists := lf.robots[host]; exists && rules.crawlDelay > interval {
+			interval = min(rules.crawlDelay, max(maxCrawlDelay, interval))
+		}
+		lf.robotsMutex.Unlock()
+	}
+	if interval <= 0 {
+		return nil
+	}
+
+	lf.hostMutex.Lock()
+	now := time.Now()
+	// 定期清理已过期的预约时间，访问过的主机不会无限累积
+	if now.Sub(lf.hostLastSweep) >= hostStateSweepInterval {
+		for knownHost, next := range lf.hostNextRequest {
+			if next.Before(now) {
+				delete(lf.hostNextRequest, knownHost)
+			}
+		}
+		lf.hostLastSweep = now
+	}
+	next := lf.hostNextRequest[host]
+	if next.Before(now) {
+		next = now
+	}
+	lf.hostNextRequest[host] = next.Add(interval)
+	lf.hostMutex.Unlock()
+
+	return sleepContext(ctx, next.Sub(now))
+}
+
+// robotsRules 获取主机的robots.txt规则（按主机缓存），获取失败时视为不限制
+func (lf *linkFetcher) robotsRules(ctx context.Context, target *url.URL) *robotsRules {
+	lf.robotsMutex.Lock()
+	rules, exists := lf.robots[target.Host]
+	lf.robotsMutex.Unlock()
+	if exists && time.Now().Before(rules.expiresAt) {
+		return rules
+	}
+
+	rules = &robotsRules{}
+	robotsURL := url.URL{Scheme: target.Scheme, Host: target.Host, Path: "/robots.txt"}
+	timeout, _ := lf.hostLimits(target.Host)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err == nil {
+		req.Header.Set("User-Agent", fetchUserAgent)
+		resp, err := lf.httpClient.Do(req)
+		if err != nil {
+			lf.logger.Debug("Failed to fetch robots.txt, assuming allowed", logger.Fields{
+				"host":  target.Host,
+				"error": err.Error(),
+			})
+		} else {
+			if resp.StatusCode == http.StatusOK {
+				rules = parseRobots(io.LimitReader(resp.Body, maxRobotsSize), robotsUserAgentToken)
+			}
+			resp.Body.Close()
+		}
+	}
+	now := time.Now()
+	rules.expiresAt = now.Add(robotsCacheTTL)
+
+	lf.robotsMutex.Lock()
+	// 定期清理过期的规则，访问过的主机不会无限累积
+	if now.Sub(lf.robotsLastSweep) >= hostStateSweepInterval {
+		for host, cached := range lf.robots {
+			if now.After(cached.expiresAt) {
+				delete(lf.robots, host)
+			}
+		}
+		lf.robotsLastSweep = now
+	}
+	lf.robots[target.Host] = rules
+	lf.robotsMutex.Unlock()
+
+	return rules
+}
+
+// allows 路径是否允许抓取（最长匹配规则优先，长度相同时Allow优先）
+func (r *robotsRules) allows(target *url.URL) bool {
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+
+	longestAllow, longestDisallow := -1, -1
+	for _, pattern := range r.allow {
+		if len(pattern) > longestAllow && robotsPatternMatches(pattern, path) {
+			longestAllow = len(pattern)
+		}
+	}
+	for _, pattern := range r.disallow {
+		if len(pattern) > longestDisallow && robotsPatternMatches(pattern, path) {
+			longestDisallow = len(pattern)
+		}
+	}
+	return longestDisallow < 0 || longestAllow >= longestDisallow
+}
+
+// robotsPatternMatches 匹配robots.txt路径规则（支持*通配符和$结尾锚定）
+func robotsPatternMatches(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+	if anchored && len(parts) == 1 {
+		return rest == ""
+	}
+	if anchored {
+		return strings.HasSuffix(path, parts[len(parts)-1])
+	}
+	return true
+}
+
+// parseRobots 解析robots.txt，优先使用匹配userAgent的规则组，没有时使用*规则组
+func parseRobots(reader io.Reader, userAgent string) *robotsRules {
+	specific, wildcard := &robotsRules{}, &robotsRules{}
+	var current []*robotsRules
+	matchedSpecific := false
+	inAgentLines := false
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			// 连续的User-agent行属于同一规则组
+			if !inAgentLines {
+				current = nil
+			}
+			inAgentLines = true
+			agent := strings.ToLower(value)
+			switch {
+			case agent == "*":
+				current = append(current, wildcard)
+			case strings.Contains(userAgent, agent) || strings.Contains(agent, userAgent):
+				current = append(current, specific)
+				matchedSpecific = true
+			}
+			continue
+		}
+		inAgentLines = false
+
+		for _, rules := range current {
+			switch key {
+			case "allow":
+				if value != "" {
+					rules.allow = append(rules.allow, value)
+				}
+			case "disallow":
+				if value != "" {
+					rules.disallow = append(rules.disallow, value)
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					rules.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	if matchedSpecific {
+		return specific
+	}
+	return wildcard
+}
+
+// httpStatusError HTTP错误状态
+type httpStatusError struct {
+	statusCode int
+	status     string
+	retryAfter time.Duration
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP error: %d %s", e.statusCode, e.status)
+}
+
+// unsupportedContentTypeError 响应类型不是HTML或纯文本
+type unsupportedContentTypeError struct {
+	contentType string
+}
+
+func (e *unsupportedContentTypeError) Error() string {
+	return fmt.Sprintf("unsupported content type: %s", e.contentType)
+}
+
+// isTransientFetchError 是否为可重试的暂时性错误（5xx、408、429、超时、DNS解析失败、连接错误）
+func isTransientFetchError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *httpStatusError
+	if stderrors.As(err, &statusErr) {
+		return statusErr.statusCode >= 500 ||
+			statusErr.statusCode == http.StatusRequestTimeout ||
+			statusErr.statusCode == http.StatusTooManyRequests
+	}
+
+	var dnsErr *net.DNSError
+	if stderrors.As(err, &dnsErr) {
+		return true
+	}
+	var netErr net.Error
+	if stderrors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if stderrors.As(err, &opErr) {
+		return true
+	}
+	return stderrors.Is(err, io.ErrUnexpectedEOF) || stderrors.Is(err, io.EOF)
+}
+
+// parseRetryAfter 解析Retry-After头（秒数或HTTP日期）
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// sleepContext 等待指定时间，上下文取消时提前返回
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,307 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/language"
+)
+
+// newTestLinkExtractor 创建使用指定抓取配置的链接提取器
+func newTestLinkExtractor(fetchConfig config.LinkFetchConfig) *LinkExtractor {
+	return &LinkExtractor{
+		languageDetector: language.NewDetector(config.LanguageDetectionConfig{}),
+		logger:           logger.NewLogger("link-extractor-test"),
+		fetcher:          newLinkFetcher(fetchConfig),
+	}
+}
+
+// TestLinkExtractor_Fetch 测试链接抓取的重试、缓存、robots.txt和重定向上限
+func TestLinkExtractor_Fetch(t *testing.T) {
+	ctx := context.Background()
+	const page = `<html><head><title>Go并发编程</title></head><body><p>goroutine和channel的使用方法</p></body></html>`
+
+	t.Run("暂时性错误重试后成功", func(t *testing.T) {
+		var hits int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				http.NotFound(w, r)
+				return
+			}
+			if atomic.AddInt32(&hits, 1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, page)
+		}))
+		defer server.Close()
+
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{RetryTimes: 2, RetryDelay: time.Millisecond})
+		result, err := extractor.Extract(ctx, server.URL+"/article", models.ContentTypeLink)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Go并发编程", result.Title)
+		assert.Equal(t, 3, result.Metadata["fetch_attempts"])
+		assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+	})
+
+	t.Run("重试次数用尽后返回错误", func(t *testing.T) {
+		var hits int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{RetryTimes: 1, RetryDelay: time.Millisecond, IgnoreRobots: true})
+		_, err := extractor.Extract(ctx, server.URL+"/article", models.ContentTypeLink)
+		assert.Error(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+	})
+
+	t.Run("客户端错误不重试", func(t *testing.T) {
+		var hits int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			http.NotFound(w, r)
+		}))
+		defer server.Close()
+
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{RetryTimes: 3, RetryDelay: time.Millisecond, IgnoreRobots: true})
+		_, err := extractor.Extract(ctx, server.URL+"/missing", models.ContentTypeLink)
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	})
+
+	t.Run("缓存有效期内相同规范URL不重复抓取", func(t *testing.T) {
+		var hits int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			fmt.Fprint(w, page)
+		}))
+		defer server.Close()
+
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{CacheTTL: time.Minute, IgnoreRobots: true})
+		first, err := extractor.Extract(ctx, server.URL+"/article?utm_source=wechat", models.ContentTypeLink)
+		require.NoError(t, err)
+		second, err := extractor.Extract(ctx, server.URL+"/article/", models.ContentTypeLink)
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+		assert.Equal(t, false, first.Metadata["from_cache"])
+		assert.Equal(t, true, second.Metadata["from_cache"])
+		assert.Equal(t, server.URL+"/article/", second.Metadata["original_url"])
+		assert.Equal(t, first.Content, second.Content)
+	})
+
+	t.Run("遵守robots.txt", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				fmt.Fprint(w, "User-agent: *\nDisallow: /private\nAllow: /private/public\n")
+				return
+			}
+			fmt.Fprint(w, page)
+		}))
+		defer server.Close()
+
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{})
+		_, err := extractor.Extract(ctx, server.URL+"/private/notes", models.ContentTypeLink)
+		assert.Error(t, err)
+
+		_, err = extractor.Extract(ctx, server.URL+"/private/public/notes", models.ContentTypeLink)
+		assert.NoError(t, err)
+
+		ignoring := newTestLinkExtractor(config.LinkFetchConfig{IgnoreRobots: true})
+		_, err = ignoring.Extract(ctx, server.URL+"/private/notes", models.ContentTypeLink)
+		assert.NoError(t, err)
+	})
+
+	t.Run("重定向超过跳数上限", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var hop int
+			fmt.Sscanf(r.URL.Path, "/hop/%d", &hop)
+			if hop < 3 {
+				http.Redirect(w, r, fmt.Sprintf("/hop/%d", hop+1), http.StatusFound)
+				return
+			}
+			fmt.Fprint(w, page)
+		}))
+		defer server.Close()
+
+		limited := newTestLinkExtractor(config.LinkFetchConfig{MaxRedirects: 2, IgnoreRobots: true})
+		_, err := limited.Extract(ctx, server.URL+"/hop/0", models.ContentTypeLink)
+		assert.Error(t, err)
+
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{MaxRedirects: 3, IgnoreRobots: true})
+		result, err := extractor.Extract(ctx, server.URL+"/hop/0", models.ContentTypeLink)
+		require.NoError(t, err)
+		assert.Equal(t, server.URL+"/hop/3", result.Metadata["final_url"])
+	})
+}
+
+// TestLinkExtractor_Limits 测试响应体上限、响应类型检查和按主机覆盖的超时
+func TestLinkExtractor_Limits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("超过上限的响应体被截断", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, "<html><head><title>大页面</title></head><body><p>")
+			for i := 0; i < 1024; i++ {
+				fmt.Fprint(w, strings.Repeat("并发", 512))
+			}
+			fmt.Fprint(w, "</p></body></html>")
+		}))
+		defer server.Close()
+
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{IgnoreRobots: true, MaxBodySize: 4096})
+		result, err := extractor.Extract(ctx, server.URL+"/huge", models.ContentTypeLink)
+		require.NoError(t, err)
+
+		assert.Equal(t, "大页面", result.Title)
+		assert.Equal(t, true, result.Metadata["truncated"])
+		assert.Equal(t, 4096, result.Metadata["content_length"])
+	})
+
+	t.Run("非HTML响应被拒绝且不重试", func(t *testing.T) {
+		var hits int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.Header().Set("Content-Type", "application/pdf")
+			fmt.Fprint(w, "%PDF-1.7")
+		}))
+		defer server.Close()
+
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{IgnoreRobots: true, RetryTimes: 2, RetryDelay: time.Millisecond})
+		_, err := extractor.Extract(ctx, server.URL+"/paper.pdf", models.ContentTypeLink)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported content type: application/pdf")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	})
+
+	t.Run("纯文本响应直接使用正文", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprint(w, "if a < b && c > d {\n    return\n}")
+		}))
+		defer server.Close()
+
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{IgnoreRobots: true})
+		result, err := extractor.Extract(ctx, server.URL+"/notes.txt", models.ContentTypeLink)
+		require.NoError(t, err)
+		assert.Equal(t, "if a < b && c > d {\nreturn\n}", result.Content)
+		assert.Empty(t, result.Title)
+	})
+
+	t.Run("按主机覆盖超时", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, "<html><body>慢速页面</body></html>")
+		}))
+		defer server.Close()
+
+		serverURL, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		limited := newTestLinkExtractor(config.LinkFetchConfig{
+			IgnoreRobots:  true,
+			HostOverrides: map[string]config.LinkFetchHostConfig{serverURL.Hostname(): {Timeout: 50 * time.Millisecond}},
+		})
+		_, err = limited.Extract(ctx, server.URL+"/slow", models.ContentTypeLink)
+		assert.Error(t, err)
+
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{IgnoreRobots: true, Timeout: time.Second})
+		_, err = extractor.Extract(ctx, server.URL+"/slow", models.ContentTypeLink)
+		assert.NoError(t, err)
+	})
+}
+
+// TestParseRobots 测试robots.txt解析
+func TestParseRobots(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`
+# comments are ignored
+User-agent: *
+Disallow: /
+
+User-agent: Memoro
+Disallow: /admin
+Allow: /admin/help$
+Crawl-delay: 2
+`), robotsUserAgentToken)
+
+	allows := func(rawURL string) bool {
+		parsedURL, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return rules.allows(parsedURL)
+	}
+
+	assert.Equal(t, 2*time.Second, rules.crawlDelay)
+	assert.True(t, allows("https://example.com/articles/1"))
+	assert.False(t, allows("https://example.com/admin/users"))
+	assert.True(t, allows("https://example.com/admin/help"))
+	assert.False(t, allows("https://example.com/admin/help/more"))
+}
+
+// TestLinkFetcher_Bounds 测试响应缓存的字节上限、Crawl-delay上限和主机状态清理
+func TestLinkFetcher_Bounds(t *testing.T) {
+	t.Run("缓存超过字节上限时淘汰最久未使用的响应", func(t *testing.T) {
+		fetcher := newLinkFetcher(config.LinkFetchConfig{CacheTTL: time.Minute, CacheMaxBytes: 10})
+		page := func() *fetchedPage { return &fetchedPage{body: []byte("abcd")} }
+
+		fetcher.storePage("a", page())
+		fetcher.storePage("b", page())
+		require.NotNil(t, fetcher.cachedPage("a")) // a变为最近使用
+		fetcher.storePage("c", page())
+
+		assert.NotNil(t, fetcher.cachedPage("a"))
+		assert.Nil(t, fetcher.cachedPage("b"))
+		assert.NotNil(t, fetcher.cachedPage("c"))
+		assert.Equal(t, int64(8), fetcher.cacheBytes)
+
+		// 单个响应超过上限时不缓存
+		fetcher.storePage("huge", &fetchedPage{body: []byte(strings.Repeat("x", 11))})
+		assert.Nil(t, fetcher.cachedPage("huge"))
+		assert.Equal(t, 2, fetcher.cacheLRU.Len())
+	})
+
+	t.Run("Crawl-delay不超过上限", func(t *testing.T) {
+		fetcher := newLinkFetcher(config.LinkFetchConfig{MaxCrawlDelay: 20 * time.Millisecond})
+		fetcher.robots["slow.example.com"] = &robotsRules{crawlDelay: time.Hour, expiresAt: time.Now().Add(time.Hour)}
+
+		require.NoError(t, fetcher.waitForHost(context.Background(), "slow.example.com"))
+		start := time.Now()
+		require.NoError(t, fetcher.waitForHost(context.Background(), "slow.example.com"))
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("过期的主机状态被清理", func(t *testing.T) {
+		fetcher := newLinkFetcher(config.LinkFetchConfig{HostMinInterval: time.Millisecond})
+		fetcher.hostNextRequest["old.example.com"] = time.Now().Add(-time.Hour)
+		fetcher.robots["old.example.com"] = &robotsRules{expiresAt: time.Now().Add(-time.Hour)}
+
+		require.NoError(t, fetcher.waitForHost(context.Background(), "new.example.com"))
+		assert.NotContains(t, fetcher.hostNextRequest, "old.example.com")
+		assert.Contains(t, fetcher.hostNextRequest, "new.example.com")
+
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+		target, err := url.Parse(server.URL + "/page")
+		require.NoError(t, err)
+		fetcher.robotsRules(context.Background(), target)
+		assert.NotContains(t, fetcher.robots, "old.example.com")
+		assert.Contains(t, fetcher.robots, target.Host)
+	})
+}
@@ -0,0 +1,237 @@
+package content
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+)
+
+// 未配置时的刷屏检测默认值
+const (
+	defaultFloodSimilarityThreshold = 0.9
+	defaultFloodMaxSimilar          = 5
+	defaultFloodWindow              = time.Minute
+)
+
+const (
+	// floodShingleSize 计算文本相似度的字符片段长度
+	floodShingleSize = 3
+	// floodSampleRunes 计算相似度时只取内容开头的字符数，控制长内容的计算和内存开销
+	floodSampleRunes = 2000
+	// maxFloodHistory 每个用户保留的最近提交数量
+	maxFloodHistory = 100
+)
+
+// FloodAction 触发刷屏检测后的处理方式
+type FloodAction string
+
+const (
+	FloodActionThrottle   FloodAction = "throttle"   // 拒绝提交，返回刷屏错误
+	FloodActionQuarantine FloodAction = "quarantine" // 接受提交并标记为隔离，跳过分析和向量化
+)
+
+// QuarantinedKey 隔离内容在处理元数据中的标记键
+const QuarantinedKey = "quarantined"
+
+// floodSubmission 用户最近的一次提交
+type floodSubmission struct {
+	at       time.Time
+	shingles map[uint32]struct{}
+}
+
+// floodDetector 按用户统计时间窗口内的相似提交，超过上限时判定为刷屏；未启用时为nil
+type floodDetector struct {
+	threshold  float64
+	maxSimilar int
+	window     time.Duration
+	action     FloodAction
+
+	mu        sync.Mutex
+	history   map[string][]*floodSubmission
+	lastSweep time.Time // 上次清理空闲用户的时间
+	now       func() time.Time
+}
+
+// newFloodDetector 根据配置创建刷屏检测器，未启用时返回nil
+func newFloodDetector(cfg config.FloodProtectionConfig) *floodDetector {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	detector := &floodDetector{
+		threshold:  cfg.SimilarityThreshold,
+		maxSimilar: cfg.MaxSimilar,
+		window:     cfg.Window,
+		action:     FloodAction(cfg.Action),
+		history:    make(map[string][]*floodSubmission),
+		now:        time.Now,
+	}
+	if detector.threshold <= 0 {
+		detector.threshold = defaultFloodSimilarityThreshold
+	}
+	if detector.maxSimilar <= 0 {
+		detector.maxSimilar = defaultFloodMaxSimilar
+	}
+	if detector.window <= 0 {
+		detector.window = defaultFloodWindow
+	}
+	if detector.action == "" {
+		detector.action = FloodActionThrottle
+	}
+	return detector
+}
+
+// record 记录一次提交并返回时间窗口内之前的相似提交数；被判定为刷屏的提交同样记录，持续刷屏时保持触发
+func (d *floodDetector) record(userID string, contentType models.ContentType, content string) int {
+	shingles := submissionShingles(contentType, content)
+	now := d.now()
+	cutoff := now.Add(-d.window)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.sweep(now, cutoff)
+
+	recent := d.history[userID][:0]
+	similar := 0
+	for _, submission := range d.history[userID] {
+		if submission.at.Before(cutoff) {
+			continue
+		}
+		recent = append(recent, submission)
+		if jaccardSimilarity(shingles, submission.shingles) >= d.threshold {
+			similar++
+		}
+	}
+
+	recent = append(recent, &floodSubmission{at: now, shingles: shingles})
+	if len(recent) > maxFloodHistory {
+		recent = recent[len(recent)-maxFloodHistory:]
+	}
+	d.history[userID] = recent
+	return similar
+}
+
+// sweep 每个时间窗口清理一次窗口内没有提交的用户，调用方持有mu
+func (d *floodDetector) sweep(now, cutoff time.Time) {
+	if now.Sub(d.lastSweep) < d.window {
+		return
+	}
+	d.lastSweep = now
+	for userID, submissions := range d.history {
+		if len(submissions) == 0 || submissions[len(submissions)-1].at.Before(cutoff) {
+			delete(d.history, userID)
+		}
+	}
+}
+
+// checkFlood 检查提交是否构成刷屏：限流时返回刷屏错误，隔离时关闭分析和向量化并标记请求
+func (p *Processor) checkFlood(request *ProcessingRequest) error {
+	if p.flood == nil {
+		return nil
+	}
+
+	similar := p.flood.record(request.UserID, request.ContentType, request.Content)
+	if similar < p.flood.maxSimilar {
+		return nil
+	}
+
+	p.logger.Warn("Submission flood detected", logger.Fields{
+		"request_id": request.ID,
+		"user_id":    request.UserID,
+		"similar":    similar,
+		"window":     p.flood.window.String(),
+		"action":     string(p.flood.action),
+	})
+
+	if p.flood.action == FloodActionQuarantine {
+		request.quarantined = true
+		request.Options.EnableSummary = false
+		request.Options.EnableTags = false
+		request.Options.EnableClassification = false
+		request.Options.EnableImportanceScore = false
+		request.Options.EnableVectorization = false
+		request.Options.EnableNeighborTags = false
+		return nil
+	}
+	return errors.ErrFloodDetected(request.UserID, similar, p.flood.window)
+}
+
+// submissionShingles 计算提交的相似度片段；链接提交时正文尚未抓取，URL的字符片段无法反映内容相似度
+// （同一网站的不同文章URL高度相似），按规范URL整体比较，只有重复提交同一链接才算相似
+func submissionShingles(contentType models.ContentType, content string) map[uint32]struct{} {
+	if contentType != models.ContentTypeLink {
+		return contentShingles(content)
+	}
+	key := CanonicalizeURL(content)
+	if key == "" {
+		key = strings.TrimSpace(content)
+	}
+	return map[uint32]struct{}{hashShingle(key): {}}
+}
+
+// contentShingles 将内容规范化（小写、合并空白）后切分为字符片段的哈希集合
+func contentShingles(content string) map[uint32]struct{} {
+	runes := make([]rune, 0, floodSampleRunes)
+	lastSpace := true
+	for _, r := range content {
+		if len(runes) >= floodSampleRunes {
+			break
+		}
+		if unicode.IsSpace(r) {
+			if !lastSpace {
+				runes = append(runes, ' ')
+			}
+			lastSpace = true
+			continue
+		}
+		runes = append(runes, unicode.ToLower(r))
+		lastSpace = false
+	}
+	text := strings.TrimSpace(string(runes))
+	runes = []rune(text)
+
+	shingles := make(map[uint32]struct{})
+	if len(runes) < floodShingleSize {
+		if len(runes) > 0 {
+			shingles[hashShingle(text)] = struct{}{}
+		}
+		return shingles
+	}
+	for i := 0; i+floodShingleSize <= len(runes); i++ {
+		shingles[hashShingle(string(runes[i:i+floodShingleSize]))] = struct{}{}
+	}
+	return shingles
+}
+
+// hashShingle 计算字符片段的哈希
+func hashShingle(shingle string) uint32 {
+	hash := fnv.New32a()
+	hash.Write([]byte(shingle))
+	return hash.Sum32()
+}
+
+// jaccardSimilarity 计算两个片段集合的Jaccard相似度
+func jaccardSimilarity(a, b map[uint32]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	intersection := 0
+	for shingle := range a {
+		if _, ok := b[shingle]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
@@ -0,0 +1,150 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/models"
+)
+
+// TestProcessor_FloodProtection 测试同一用户短时间内提交大量相似内容时的限流和隔离
+func TestProcessor_FloodProtection(t *testing.T) {
+	newProcessor := func(t *testing.T, action string) *Processor {
+		processor := newTestProcessor(t)
+		processor.flood = newFloodDetector(config.FloodProtectionConfig{
+			Enabled:             true,
+			SimilarityThreshold: 0.8,
+			MaxSimilar:          3,
+			Window:              time.Minute,
+			Action:              action,
+		})
+		return processor
+	}
+	submit := func(processor *Processor, id, userID, content string) (*ProcessingRequest, error) {
+		request := &ProcessingRequest{
+			ID:          id,
+			Content:     content,
+			ContentType: models.ContentTypeText,
+			UserID:      userID,
+		}
+		return request, processor.ProcessContentAsync(request)
+	}
+	spam := func(i int) string {
+		return fmt.Sprintf("限时优惠！点击链接领取免费会员，名额有限，先到先得 #%d", i)
+	}
+
+	t.Run("连续提交10条近似内容在达到上限后被限流", func(t *testing.T) {
+		processor := newProcessor(t, "throttle")
+
+		var throttled int
+		for i := 0; i < 10; i++ {
+			_, err := submit(processor, fmt.Sprintf("spam-%d", i), "user-1", spam(i))
+			if i < 3 {
+				require.NoError(t, err, "submission %d", i)
+				continue
+			}
+			require.Error(t, err, "submission %d", i)
+			memoErr, ok := err.(*errors.MemoroError)
+			require.True(t, ok)
+			assert.Equal(t, errors.ErrCodeFloodDetected, memoErr.Code)
+			throttled++
+		}
+		assert.Equal(t, 7, throttled)
+
+		// 被限流的请求未进入处理队列
+		assert.Len(t, processor.requestChan, 3)
+	})
+
+	t.Run("其他用户和不相似的内容不受影响", func(t *testing.T) {
+		processor := newProcessor(t, "throttle")
+		for i := 0; i < 5; i++ {
+			submit(processor, fmt.Sprintf("spam-%d", i), "user-1", spam(i))
+		}
+
+		_, err := submit(processor, "other-user", "user-2", spam(0))
+		assert.NoError(t, err)
+		_, err = submit(processor, "distinct", "user-1", "Go语言的并发模型基于goroutine和channel")
+		assert.NoError(t, err)
+	})
+
+	t.Run("超出时间窗口后恢复", func(t *testing.T) {
+		processor := newProcessor(t, "throttle")
+		now := time.Now()
+		processor.flood.now = func() time.Time { return now }
+		for i := 0; i < 4; i++ {
+			submit(processor, fmt.Sprintf("spam-%d", i), "user-1", spam(i))
+		}
+
+		now = now.Add(2 * time.Minute)
+		_, err := submit(processor, "later", "user-1", spam(9))
+		assert.NoError(t, err)
+	})
+
+	t.Run("链接按规范URL比较，同一网站的不同文章不算相似", func(t *testing.T) {
+		processor := newProcessor(t, "throttle")
+		submitLink := func(id, url string) error {
+			return processor.ProcessContentAsync(&ProcessingRequest{
+				ID:          id,
+				Content:     url,
+				ContentType: models.ContentTypeLink,
+				UserID:      "user-1",
+			})
+		}
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, submitLink(fmt.Sprintf("post-%d", i), fmt.Sprintf("https://blog.example.com/posts/2024/go-concurrency-part-%d", i)))
+		}
+
+		processor = newProcessor(t, "throttle")
+		var err error
+		for i := 0; i < 4; i++ {
+			err = submitLink(fmt.Sprintf("same-%d", i), "https://blog.example.com/posts/go?utm_source="+fmt.Sprint(i))
+		}
+		memoErr, ok := err.(*errors.MemoroError)
+		require.True(t, ok)
+		assert.Equal(t, errors.ErrCodeFloodDetected, memoErr.Code)
+	})
+
+	t.Run("清理时间窗口内没有提交的用户", func(t *testing.T) {
+		processor := newProcessor(t, "throttle")
+		now := time.Now()
+		processor.flood.now = func() time.Time { return now }
+		for i := 0; i < 5; i++ {
+			submit(processor, fmt.Sprintf("user-%d", i), fmt.Sprintf("user-%d", i), spam(i))
+		}
+		require.Len(t, processor.flood.history, 5)
+
+		now = now.Add(2 * time.Minute)
+		_, err := submit(processor, "active", "user-9", spam(9))
+		require.NoError(t, err)
+		assert.Len(t, processor.flood.history, 1)
+		assert.Contains(t, processor.flood.history, "user-9")
+	})
+
+	t.Run("隔离模式接受提交但不分析和索引", func(t *testing.T) {
+		processor := newProcessor(t, "quarantine")
+		var request *ProcessingRequest
+		for i := 0; i < 4; i++ {
+			var err error
+			request, err = submit(processor, fmt.Sprintf("spam-%d", i), "user-1", spam(i))
+			require.NoError(t, err)
+		}
+
+		assert.True(t, request.quarantined)
+		assert.False(t, request.Options.EnableSummary)
+		assert.False(t, request.Options.EnableVectorization)
+
+		result, err := processor.doProcessing(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.Quarantined)
+		assert.Equal(t, true, result.ContentItem.GetProcessedData()[QuarantinedKey])
+		assert.Nil(t, result.VectorResult)
+	})
+}
@@ -0,0 +1,25 @@
+package content
+
+import "memoro/internal/config"
+
+// fallbackImportanceScore 未配置默认评分时使用的重要性评分
+const fallbackImportanceScore = 0.5
+
+// ImportanceSource 重要性评分来源
+type ImportanceSource string
+
+const (
+	ImportanceSourceComputed ImportanceSource = "computed" // 由分类器计算
+	ImportanceSourceDefault  ImportanceSource = "default"  // 计算失败时使用默认值
+)
+
+// ImportanceSourceKey 重要性评分来源在元数据中的键
+const ImportanceSourceKey = "importance_source"
+
+// defaultImportanceScore 获取配置的默认重要性评分
+func defaultImportanceScore(processingConfig config.ProcessingConfig) float64 {
+	if processingConfig.DefaultImportanceScore > 0 {
+		return processingConfig.DefaultImportanceScore
+	}
+	return fallbackImportanceScore
+}
@@ -0,0 +1,104 @@
+package content
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+)
+
+// inflightEntry 同一内容键的处理锁，持有者数量降为0时移除
+type inflightEntry struct {
+	lock   chan struct{}     // 容量为1的信号量，可在等待时响应ctx取消
+	refs   int               // 正在等待或持有锁的请求数（受inflightLocks.mu保护）
+	result *ProcessingResult // 先完成处理的结果（受lock保护，处理失败时为nil）
+}
+
+// inflightLocks 按内容键（用户、内容类型、内容哈希、处理选项、敏感标记和线程）串行处理相同内容的并发请求，
+// 后获得锁的请求复用先完成的结果，不再重复向量化和索引；未启用时为nil
+type inflightLocks struct {
+	mu      sync.Mutex
+	entries map[string]*inflightEntry
+}
+
+// newInflightLocks 根据去重配置创建并发去重锁，未启用时返回nil
+func newInflightLocks(cfg config.DedupConfig) *inflightLocks {
+	if !cfg.Concurrent {
+		return nil
+	}
+	return &inflightLocks{entries: make(map[string]*inflightEntry)}
+}
+
+// newCanonicalLocks 创建按规范URL串行处理链接的锁（始终启用，不受并发去重配置影响）
+func newCanonicalLocks() *inflightLocks {
+	return &inflightLocks{entries: make(map[string]*inflightEntry)}
+}
+
+// inflightKey 计算请求的内容键；处理选项、敏感标记或线程不同的请求产生不同的结果，不能互相复用
+func inflightKey(request *ProcessingRequest) string {
+	hash := sha256.New()
+	hash.Write([]byte(request.Content))
+	hash.Write([]byte{0})
+	// ProcessingOptions只含基本类型和切片，序列化不会失败且字段顺序固定
+	options, _ := json.Marshal(request.Options)
+	hash.Write(options)
+	hash.Write([]byte{0})
+	hash.Write([]byte(strconv.FormatBool(request.Sensitive) + "|" + request.ThreadID))
+	return request.UserID + "|" + string(request.ContentType) + "|" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// acquire 获取内容键的处理锁（阻塞直到先到的请求处理结束），ctx在等待期间取消时返回错误
+func (l *inflightLocks) acquire(ctx context.Context, key string) (*inflightEntry, error) {
+	l.mu.Lock()
+	entry, exists := l.entries[key]
+	if !exists {
+		entry = &inflightEntry{lock: make(chan struct{}, 1)}
+		l.entries[key] = entry
+	}
+	entry.refs++
+	l.mu.Unlock()
+
+	select {
+	case entry.lock <- struct{}{}:
+		return entry, nil
+	case <-ctx.Done():
+		l.unref(key, entry)
+		return nil, errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeSystemGeneric, "Cancelled while waiting for identical content").
+			WithCause(ctx.Err())
+	}
+}
+
+// release 释放内容键的处理锁，没有其他请求等待时移除该键（之后的相同内容由索引时去重处理）
+func (l *inflightLocks) release(key string, entry *inflightEntry) {
+	<-entry.lock
+	l.unref(key, entry)
+}
+
+// unref 减少内容键的引用数，降为0时移除该键
+func (l *inflightLocks) unref(key string, entry *inflightEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry.refs--
+	if entry.refs == 0 {
+		delete(l.entries, key)
+	}
+}
+
+// inflightDuplicateResult 复用先完成请求的结果，生成后到请求的重复结果
+func inflightDuplicateResult(request *ProcessingRequest, first *ProcessingResult) *ProcessingResult {
+	duplicateOf := first.DuplicateOf
+	if duplicateOf == "" {
+		duplicateOf = first.ContentItem.ID
+	}
+	return &ProcessingResult{
+		RequestID:       request.ID,
+		ContentItem:     first.ContentItem,
+		DuplicateOf:     duplicateOf,
+		ImportanceScore: first.ImportanceScore,
+	}
+}
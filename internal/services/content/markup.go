@@ -0,0 +1,211 @@
+package content
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TextFormat 文本内容的标记格式
+type TextFormat string
+
+const (
+	TextFormatPlain    TextFormat = "plain"    // 纯文本
+	TextFormatMarkdown TextFormat = "markdown" // Markdown（可能内嵌HTML）
+	TextFormatHTML     TextFormat = "html"     // HTML
+)
+
+// 提取元数据和处理数据中记录标记信息的键
+const (
+	TextFormatKey     = "text_format"     // 检测到的文本格式
+	OriginalMarkupKey = "original_markup" // 转换为纯文本前的原始内容
+)
+
+var (
+	// Markdown检测：强信号出现一次即可判定，弱信号需要至少两种
+	markdownHeadingPattern = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s+\S`)
+	markdownLinkPattern    = regexp.MustCompile(`!?\[[^\]\n]+\]\([^)\s]+(?:\s+"[^"]*")?\)`)
+	markdownFencePattern   = regexp.MustCompile("(?m)^\\s*(```|~~~)")
+	markdownEmphasisSignal = regexp.MustCompile(`\*\*[^*\n]+\*\*|__[^_\n]+__|~~[^~\n]+~~|` + "`[^`\n]+`")
+	markdownListSignal     = regexp.MustCompile(`(?m)^\s*(?:[-*+]|\d+[.)])\s+\S`)
+	markdownQuoteSignal    = regexp.MustCompile(`(?m)^\s*>\s?\S`)
+
+	// HTML检测：常见的结构和格式标签
+	htmlTagSignal = regexp.MustCompile(`(?i)</?(?:html|body|head|p|div|span|br|a|strong|em|b|i|u|h[1-6]|ul|ol|li|table|tr|td|th|blockquote|pre|code|img)\b[^>]*>`)
+
+	// Markdown转换
+	markdownFenceLine      = regexp.MustCompile("^\\s*(```|~~~).*$")
+	markdownHeadingLine    = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	markdownSetextLine     = regexp.MustCompile(`^\s{0,3}(?:=+|-+)\s*$`)
+	markdownRuleLine       = regexp.MustCompile(`^\s{0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	markdownQuotePrefix    = regexp.MustCompile(`^\s*(?:>\s?)+`)
+	markdownListPrefix     = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?`)
+	markdownTableSeparator = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(?:\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	markdownReferenceDef   = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s+\S+.*$`)
+	markdownImage          = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownInlineLink     = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	markdownReferenceLink  = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	markdownAutoLink       = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	markdownInlineCode     = regexp.MustCompile("`+([^`]+)`+")
+	markdownStrong         = regexp.MustCompile(`(\*\*|__)([^\s*_](?:.*?[^\s*_])?)(\*\*|__)`)
+	markdownStrike         = regexp.MustCompile(`~~(.+?)~~`)
+	markdownStarEmphasis   = regexp.MustCompile(`\*([^\s*](?:[^*]*[^\s*])?)\*`)
+	markdownUnderEmphasis  = regexp.MustCompile(`(^|[^\w])_([^\s_](?:[^_]*[^\s_])?)_([^\w]|$)`)
+
+	// HTML转换
+	htmlInvisibleBlock = regexp.MustCompile(`(?is)<(script|style|head|noscript)\b[^>]*>.*?</(?:script|style|head|noscript)>`)
+	htmlComment        = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlBlockBoundary  = regexp.MustCompile(`(?i)<(?:br\s*/?|/?(?:p|div|h[1-6]|li|ul|ol|tr|table|blockquote|pre|section|article|header|footer))\b[^>]*>`)
+	htmlAnyTag         = regexp.MustCompile(`<[^>]+>`)
+	horizontalSpace    = regexp.MustCompile(`[ \t\f\v]+`)
+)
+
+// detectTextFormat 检测文本是否为Markdown或HTML。同时含有两者时视为内嵌HTML的Markdown
+func detectTextFormat(content string) TextFormat {
+	strong := markdownHeadingPattern.MatchString(content) ||
+		markdownLinkPattern.MatchString(content) ||
+		markdownFencePattern.MatchString(content)
+
+	weak := 0
+	for _, signal := range []*regexp.Regexp{markdownEmphasisSignal, markdownListSignal, markdownQuoteSignal} {
+		if signal.MatchString(content) {
+			weak++
+		}
+	}
+
+	if strong || weak >= 2 {
+		return TextFormatMarkdown
+	}
+	if htmlTagSignal.MatchString(content) {
+		return TextFormatHTML
+	}
+	return TextFormatPlain
+}
+
+// stripMarkup 将Markdown或HTML转换为纯文本：去除格式标记，链接和图片保留可读文字，
+// 标题和列表项作为独立的句子保留
+func stripMarkup(content string, format TextFormat) string {
+	switch format {
+	case TextFormatMarkdown:
+		content = markdownToText(content)
+		if htmlTagSignal.MatchString(content) {
+			content = htmlToText(content)
+		}
+	case TextFormatHTML:
+		content = htmlToText(content)
+	default:
+		return content
+	}
+	return tidyLines(content)
+}
+
+// markdownToText 逐行去除Markdown块级标记和行内标记
+func markdownToText(content string) string {
+	lines := strings.Split(content, "\n")
+	output := make([]string, 0, len(lines))
+	inFence := false
+
+	for _, line := range lines {
+		if markdownFenceLine.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		// 代码块内容原样保留
+		if inFence {
+			output = append(output, line)
+			continue
+		}
+
+		switch {
+		case markdownRuleLine.MatchString(line),
+			markdownSetextLine.MatchString(line) && strings.TrimSpace(line) != "",
+			markdownTableSeparator.MatchString(line) && strings.Contains(line, "-"),
+			markdownReferenceDef.MatchString(line):
+			continue
+		}
+
+		line = markdownQuotePrefix.ReplaceAllString(line, "")
+		sentence := false
+		if matches := markdownHeadingLine.FindStringSubmatch(line); matches != nil {
+			line = matches[1]
+			sentence = true
+		} else if markdownListPrefix.MatchString(line) {
+			line = markdownListPrefix.ReplaceAllString(line, "")
+			sentence = true
+		}
+		if strings.Count(line, "|") >= 2 {
+			line = strings.Trim(strings.TrimSpace(line), "|")
+			line = strings.ReplaceAll(line, "|", ", ")
+		}
+
+		line = stripInlineMarkdown(line)
+		if sentence {
+			line = endSentence(line)
+		}
+		output = append(output, line)
+	}
+
+	return strings.Join(output, "\n")
+}
+
+// stripInlineMarkdown 去除行内的链接、图片、代码和强调标记
+func stripInlineMarkdown(line string) string {
+	line = markdownImage.ReplaceAllString(line, "$1")
+	line = markdownInlineLink.ReplaceAllString(line, "$1")
+	line = markdownReferenceLink.ReplaceAllString(line, "$1")
+	line = markdownAutoLink.ReplaceAllString(line, "$1")
+	line = markdownInlineCode.ReplaceAllString(line, "$1")
+	line = markdownStrong.ReplaceAllString(line, "$2")
+	line = markdownStrike.ReplaceAllString(line, "$1")
+	line = markdownStarEmphasis.ReplaceAllString(line, "$1")
+	line = markdownUnderEmphasis.ReplaceAllString(line, "$1$2$3")
+	return line
+}
+
+// htmlToText 去除HTML标签和不可见内容，块级标签转换为换行，并解码实体
+func htmlToText(content string) string {
+	content = htmlInvisibleBlock.ReplaceAllString(content, "")
+	content = htmlComment.ReplaceAllString(content, "")
+	content = htmlBlockBoundary.ReplaceAllString(content, "\n")
+	content = htmlAnyTag.ReplaceAllString(content, "")
+	return html.UnescapeString(content)
+}
+
+// endSentence 标题和列表项没有结尾标点时补上句号，使展平后的文本读起来是完整的句子
+func endSentence(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return line
+	}
+
+	last, _ := utf8.DecodeLastRuneInString(line)
+	if unicode.IsPunct(last) {
+		return line
+	}
+	if unicode.Is(unicode.Han, last) {
+		return line + "。"
+	}
+	return line + "."
+}
+
+// tidyLines 合并行内多余空白，连续空行只保留一个
+func tidyLines(content string) string {
+	lines := strings.Split(content, "\n")
+	output := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimSpace(horizontalSpace.ReplaceAllString(line, " "))
+		if line == "" {
+			if !blank && len(output) > 0 {
+				output = append(output, "")
+			}
+			blank = true
+			continue
+		}
+		output = append(output, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(output, "\n"))
+}
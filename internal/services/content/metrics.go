@@ -0,0 +1,71 @@
+package content
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"memoro/internal/services/llm"
+)
+
+// StageMetric 单个处理阶段的指标
+type StageMetric struct {
+	Duration   time.Duration `json:"duration"`    // 阶段耗时（并发执行的分析阶段相互重叠）
+	TokensUsed int64         `json:"tokens_used"` // LLM和embedding调用消耗的token数
+	ModelCalls int64         `json:"model_calls"` // LLM和embedding调用次数
+	CacheHits  int64         `json:"cache_hits"`  // 命中缓存的次数（链接抓取、向量复用、查询向量和搜索结果缓存）
+}
+
+// stageMetricsCollector 收集一次处理中各阶段的指标，未启用时为nil，各方法不做任何统计
+type stageMetricsCollector struct {
+	mu      sync.Mutex
+	metrics map[ProcessingStage]*StageMetric
+}
+
+// newStageMetricsCollector 创建阶段指标收集器，未启用时返回nil
+func newStageMetricsCollector(enabled bool) *stageMetricsCollector {
+	if !enabled {
+		return nil
+	}
+	return &stageMetricsCollector{metrics: make(map[ProcessingStage]*StageMetric)}
+}
+
+// begin 开始计量阶段，返回附加了该阶段用量记录器的上下文和结束计量的函数；同一阶段多次计量时累加
+func (c *stageMetricsCollector) begin(ctx context.Context, stage ProcessingStage) (context.Context, func()) {
+	if c == nil {
+		return ctx, func() {}
+	}
+
+	recorder := &llm.UsageRecorder{}
+	start := time.Now()
+	return llm.WithUsageRecorder(ctx, recorder), func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		metric, exists := c.metrics[stage]
+		if !exists {
+			metric = &StageMetric{}
+			c.metrics[stage] = metric
+		}
+		metric.Duration += time.Since(start)
+		metric.TokensUsed += recorder.Tokens()
+		metric.ModelCalls += recorder.Calls()
+		metric.CacheHits += recorder.CacheHits()
+	}
+}
+
+// snapshot 获取已收集的指标，未启用时返回nil
+func (c *stageMetricsCollector) snapshot() map[ProcessingStage]*StageMetric {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	metrics := make(map[ProcessingStage]*StageMetric, len(c.metrics))
+	for stage, metric := range c.metrics {
+		copied := *metric
+		metrics[stage] = &copied
+	}
+	return metrics
+}
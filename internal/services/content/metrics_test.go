@@ -0,0 +1,132 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/models"
+	"memoro/internal/services/llm"
+	"memoro/internal/services/vector"
+)
+
+// TestProcessor_StageMetrics 测试各阶段的耗时、token消耗和缓存命中统计
+func TestProcessor_StageMetrics(t *testing.T) {
+	// 每次对话调用耗时20ms、消耗20个token，embedding调用消耗3个token
+	const callDelay = 20 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":3,"total_tokens":3}}`)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		time.Sleep(callDelay)
+		answer := "Go语言通过goroutine和channel实现并发"
+		if strings.Contains(string(body), "标签生成专家") {
+			answer = `{"tags":["Go","并发"],"categories":["技术"],"keywords":["goroutine"],"confidence":{"Go":0.9,"并发":0.8}}`
+		}
+		response, _ := json.Marshal(map[string]interface{}{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"model":   "test-model",
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": answer}, "finish_reason": "stop"}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 10, "total_tokens": 20},
+		})
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: server.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+		Processing: config.ProcessingConfig{
+			MaxContentSize: 100000,
+			SummaryLevels:  config.SummaryLevelsConfig{OneLineMaxLength: 200, ParagraphMaxLength: 500, DetailedMaxLength: 1000},
+			TagLimits:      config.TagLimitsConfig{MaxTags: 10, MaxTagLength: 50, DefaultConfidence: 0.5},
+		},
+	}))
+	client, err := llm.NewClient()
+	require.NoError(t, err)
+	summarizer, err := llm.NewSummarizer(client)
+	require.NoError(t, err)
+	tagger, err := llm.NewTagger(client)
+	require.NoError(t, err)
+	engine, err := vector.NewSearchEngineWithStore(vector.NewMemoryStore())
+	require.NoError(t, err)
+	defer engine.Close()
+
+	processor := newTestProcessor(t)
+	processor.summarizer = summarizer
+	processor.tagger = tagger
+	processor.searchEngine = engine
+	// 顺序执行分析阶段，各阶段耗时不重叠
+	processor.config.StageConcurrency = 1
+
+	process := func(id string, enableMetrics bool) (*ProcessingResult, time.Duration) {
+		start := time.Now()
+		result, err := processor.doProcessing(context.Background(), &ProcessingRequest{
+			ID:          id,
+			Content:     "Go语言的并发模型基于goroutine和channel，调度器把大量goroutine复用到少量系统线程上。",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options: ProcessingOptions{
+				EnableSummary:       true,
+				EnableTags:          true,
+				EnableVectorization: true,
+				MaxTags:             5,
+				EnableMetrics:       enableMetrics,
+			},
+		})
+		require.NoError(t, err)
+		return result, time.Since(start)
+	}
+
+	t.Run("阶段耗时之和接近总耗时且记录token消耗", func(t *testing.T) {
+		result, elapsed := process("req-1", true)
+		metrics := result.StageMetrics
+		require.NotNil(t, metrics)
+
+		var total time.Duration
+		for _, metric := range metrics {
+			total += metric.Duration
+		}
+		assert.LessOrEqual(t, total, elapsed)
+		assert.InDelta(t, float64(elapsed), float64(total), float64(10*time.Millisecond))
+
+		require.Contains(t, metrics, StageSummarize)
+		assert.Equal(t, int64(3), metrics[StageSummarize].ModelCalls)
+		assert.Equal(t, int64(60), metrics[StageSummarize].TokensUsed)
+		assert.GreaterOrEqual(t, metrics[StageSummarize].Duration, 3*callDelay)
+
+		require.Contains(t, metrics, StageTag)
+		assert.Equal(t, int64(20), metrics[StageTag].TokensUsed)
+
+		require.Contains(t, metrics, StageVectorize)
+		assert.Equal(t, int64(3), metrics[StageVectorize].TokensUsed)
+		assert.Zero(t, metrics[StageVectorize].CacheHits)
+		assert.Contains(t, metrics, StageExtract)
+	})
+
+	t.Run("相同内容复用向量时记录缓存命中", func(t *testing.T) {
+		result, _ := process("req-2", true)
+		require.Contains(t, result.StageMetrics, StageVectorize)
+		assert.Equal(t, int64(1), result.StageMetrics[StageVectorize].CacheHits)
+		assert.Zero(t, result.StageMetrics[StageVectorize].TokensUsed)
+	})
+
+	t.Run("未启用时不返回指标", func(t *testing.T) {
+		result, _ := process("req-3", false)
+		assert.Nil(t, result.StageMetrics)
+	})
+}
@@ -0,0 +1,89 @@
+package content
+
+import (
+	"context"
+	"strings"
+
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/vector"
+)
+
+// applyNeighborTags 从相似的已打标签文档继承标签，与LLM标签合并后更新内容项、索引和存储（失败不中断处理）
+func (p *Processor) applyNeighborTags(ctx context.Context, request *ProcessingRequest, contentItem *models.ContentItem, result *ProcessingResult) {
+	neighborConfig := p.config.NeighborTags
+	suggestions, err := p.searchEngine.SuggestNeighborTags(ctx, contentItem.ID, neighborConfig.TopK, float32(neighborConfig.MinSimilarity))
+	if err != nil {
+		p.logger.Warn("Failed to suggest neighbor tags", logger.Fields{
+			"request_id": request.ID,
+			"content_id": contentItem.ID,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	merged, inherited := mergeNeighborTags(contentItem.GetTags(), suggestions, neighborConfig.MinScore, request.Options.MaxTags)
+	if len(inherited) == 0 {
+		return
+	}
+
+	contentItem.SetTags(merged)
+	result.NeighborTags = inherited
+
+	if err := p.searchEngine.UpdateDocumentMetadata(ctx, contentItem.ID, map[string]interface{}{
+		"tags":                    merged,
+		vector.MetadataKeyHasTags: true,
+	}); err != nil {
+		p.logger.Warn("Failed to update neighbor tags in index", logger.Fields{
+			"request_id": request.ID,
+			"content_id": contentItem.ID,
+			"error":      err.Error(),
+		})
+	}
+
+	if p.store != nil {
+		if err := p.store.Save(ctx, contentItem); err != nil {
+			p.logger.Warn("Failed to persist neighbor tags", logger.Fields{
+				"request_id": request.ID,
+				"content_id": contentItem.ID,
+				"error":      err.Error(),
+			})
+		}
+	}
+
+	p.logger.Debug("Neighbor tags applied", logger.Fields{
+		"request_id": request.ID,
+		"content_id": contentItem.ID,
+		"inherited":  inherited,
+	})
+}
+
+// mergeNeighborTags 在现有标签后按分数追加邻居建议的标签（忽略大小写去重，不超过maxTags），返回合并结果和新继承的标签
+func mergeNeighborTags(tags []string, suggestions []*vector.TagSuggestion, minScore float64, maxTags int) ([]string, []string) {
+	merged := make([]string, 0, len(tags)+len(suggestions))
+	seen := make(map[string]bool, len(tags)+len(suggestions))
+	for _, tag := range tags {
+		key := strings.ToLower(tag)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, tag)
+	}
+
+	inherited := make([]string, 0)
+	for _, suggestion := range suggestions {
+		if maxTags > 0 && len(merged) >= maxTags {
+			break
+		}
+		key := strings.ToLower(suggestion.Tag)
+		if suggestion.Score < minScore || seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, suggestion.Tag)
+		inherited = append(inherited, suggestion.Tag)
+	}
+
+	return merged, inherited
+}
@@ -0,0 +1,49 @@
+package content
+
+// ProcessingPreset 命名的处理预设，设置后覆盖请求中的各阶段开关
+type ProcessingPreset string
+
+const (
+	// PresetFull 执行全部阶段（未设置预设和任何阶段开关时的默认行为）
+	PresetFull ProcessingPreset = "full"
+	// PresetSummaryOnly 只提取内容并生成摘要，不做分类、评分、标签和向量化，
+	// 不访问向量库和embedding服务，向量库不可用时也能处理
+	PresetSummaryOnly ProcessingPreset = "summary_only"
+)
+
+// processingPresets 各预设对应的阶段开关
+var processingPresets = map[ProcessingPreset]ProcessingOptions{
+	PresetFull: {
+		EnableSummary:         true,
+		EnableTags:            true,
+		EnableClassification:  true,
+		EnableImportanceScore: true,
+		EnableVectorization:   true,
+	},
+	PresetSummaryOnly: {
+		EnableSummary: true,
+	},
+}
+
+// IsValidPreset 检查处理预设是否已定义
+func IsValidPreset(preset ProcessingPreset) bool {
+	_, exists := processingPresets[preset]
+	return exists
+}
+
+// applyPreset 用预设的阶段开关覆盖请求选项，保留现有标签和标签数等其他选项；未设置预设时返回false
+func applyPreset(options *ProcessingOptions) bool {
+	stages, exists := processingPresets[options.Preset]
+	if !exists {
+		return false
+	}
+
+	options.EnableSummary = stages.EnableSummary
+	options.EnableTags = stages.EnableTags
+	options.EnableClassification = stages.EnableClassification
+	options.EnableImportanceScore = stages.EnableImportanceScore
+	options.EnableVectorization = stages.EnableVectorization
+	// 相似文档标签依赖向量化和标签阶段
+	options.EnableNeighborTags = options.EnableNeighborTags && stages.EnableVectorization && stages.EnableTags
+	return true
+}
@@ -0,0 +1,182 @@
+package content
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"memoro/internal/services/vector"
+)
+
+// maxPreviewURLLength 预览图和favicon地址的最大长度，超出时视为无效
+const maxPreviewURLLength = 2048
+
+var (
+	// metaTagPattern 匹配HTML中的meta标签
+	metaTagPattern = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	// linkTagPattern 匹配HTML中的link标签
+	linkTagPattern = regexp.MustCompile(`(?is)<link\s[^>]*>`)
+	// tagAttributePattern 匹配标签属性（双引号、单引号或不带引号的值）
+	tagAttributePattern = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// 按优先级排列的meta标签名（property或name属性）
+var (
+	previewImageMetaNames  = []string{"og:image:secure_url", "og:image:url", "og:image", "twitter:image", "twitter:image:src"}
+	siteNameMetaNames      = []string{"og:site_name", "application-name"}
+	authorMetaNames        = []string{"author", "article:author", "twitter:creator"}
+	publishedTimeMetaNames = []string{"article:published_time", "og:published_time", "pubdate", "publish_date", "date"}
+)
+
+// faviconRels 按优先级排列的favicon链接rel值
+var faviconRels = []string{"icon", "shortcut icon", "apple-touch-icon"}
+
+// publishedTimeLayouts 发布时间支持的格式
+var publishedTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// extractLinkPreview 从HTML的meta和link标签中提取链接预览信息（预览图、站点名、favicon、作者、发布时间），
+// 图片和favicon地址基于页面URL解析为绝对的http(s)地址，缺失或无效的字段不返回
+func extractLinkPreview(htmlContent string, pageURL *url.URL) map[string]string {
+	metas := make(map[string]string)
+	for _, tag := range metaTagPattern.FindAllString(htmlContent, -1) {
+		attributes := parseTagAttributes(tag)
+		content := strings.TrimSpace(attributes["content"])
+		if content == "" {
+			continue
+		}
+		for _, nameAttribute := range []string{"property", "name", "itemprop"} {
+			name := strings.ToLower(strings.TrimSpace(attributes[nameAttribute]))
+			if name != "" {
+				if _, exists := metas[name]; !exists {
+					metas[name] = content
+				}
+			}
+		}
+	}
+
+	preview := make(map[string]string)
+	if image := firstMeta(metas, previewImageMetaNames); image != "" {
+		if imageURL := normalizePreviewURL(image, pageURL); imageURL != "" {
+			preview[vector.MetadataKeyPreviewImage] = imageURL
+		}
+	}
+	if siteName := firstMeta(metas, siteNameMetaNames); siteName != "" {
+		preview[vector.MetadataKeySiteName] = siteName
+	}
+	if author := firstMeta(metas, authorMetaNames); author != "" {
+		preview[vector.MetadataKeyAuthor] = author
+	}
+	if published := normalizePublishedTime(firstMeta(metas, publishedTimeMetaNames)); published != "" {
+		preview[vector.MetadataKeyPublishedTime] = published
+	}
+	if favicon := extractFavicon(htmlContent, pageURL); favicon != "" {
+		preview[vector.MetadataKeyFavicon] = favicon
+	}
+
+	return preview
+}
+
+// parseTagAttributes 解析标签的属性（属性名小写，值解码HTML实体）
+func parseTagAttributes(tag string) map[string]string {
+	attributes := make(map[string]string)
+	for _, match := range tagAttributePattern.FindAllStringSubmatch(tag, -1) {
+		name := strings.ToLower(match[1])
+		if _, exists := attributes[name]; exists {
+			continue
+		}
+		value := match[2]
+		if value == "" {
+			value = match[3]
+		}
+		if value == "" {
+			value = match[4]
+		}
+		attributes[name] = html.UnescapeString(value)
+	}
+	return attributes
+}
+
+// firstMeta 按优先级返回第一个存在的meta值
+func firstMeta(metas map[string]string, names []string) string {
+	for _, name := range names {
+		if value := metas[name]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// extractFavicon 提取favicon链接（按rel优先级），页面未声明时不返回
+func extractFavicon(htmlContent string, pageURL *url.URL) string {
+	icons := make(map[string]string)
+	for _, tag := range linkTagPattern.FindAllString(htmlContent, -1) {
+		attributes := parseTagAttributes(tag)
+		rel := strings.Join(strings.Fields(strings.ToLower(attributes["rel"])), " ")
+		href := strings.TrimSpace(attributes["href"])
+		if rel == "" || href == "" {
+			continue
+		}
+		if _, exists := icons[rel]; !exists {
+			icons[rel] = href
+		}
+	}
+
+	for _, rel := range faviconRels {
+		if href, exists := icons[rel]; exists {
+			if faviconURL := normalizePreviewURL(href, pageURL); faviconURL != "" {
+				return faviconURL
+			}
+		}
+	}
+	return ""
+}
+
+// normalizePreviewURL 将图片地址解析为绝对地址，只接受http(s)且带主机名的地址
+func normalizePreviewURL(rawURL string, pageURL *url.URL) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" || len(rawURL) > maxPreviewURLLength {
+		return ""
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if pageURL != nil {
+		parsedURL = pageURL.ResolveReference(parsedURL)
+	}
+
+	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
+	if (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return ""
+	}
+	parsedURL.Fragment = ""
+
+	normalized := parsedURL.String()
+	if len(normalized) > maxPreviewURLLength {
+		return ""
+	}
+	return normalized
+}
+
+// normalizePublishedTime 将发布时间统一为RFC3339格式（UTC），无法解析时返回空
+func normalizePublishedTime(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	for _, layout := range publishedTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC().Format(time.RFC3339)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/models"
+	"memoro/internal/services/vector"
+)
+
+// previewPage 带Open Graph标签的页面（图片为相对地址，属性顺序和引号不统一）
+const previewPage = `<html><head>
+<title>Go 1.23发布说明</title>
+<meta property="og:title" content="Go 1.23 is released">
+<meta content="/images/go123.png?w=1200&amp;h=630" property="og:image" />
+<meta property='og:site_name' content='The Go Blog'>
+<meta name="author" content="Go Team">
+<meta property="article:published_time" content="2024-08-13T10:00:00+08:00">
+<link rel="shortcut icon" href="//static.example.com/favicon.ico">
+</head><body><p>Go 1.23新增了range-over-func迭代器。</p></body></html>`
+
+// TestLinkExtractor_Preview 测试链接预览字段的提取和规范化
+func TestLinkExtractor_Preview(t *testing.T) {
+	ctx := context.Background()
+	serve := func(page string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, page)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	t.Run("提取OG标签并规范化地址", func(t *testing.T) {
+		server := serve(previewPage)
+		result, err := newTestLinkExtractor(config.LinkFetchConfig{}).Extract(ctx, server.URL+"/blog/go1.23", models.ContentTypeLink)
+		require.NoError(t, err)
+
+		assert.Equal(t, server.URL+"/images/go123.png?w=1200&h=630", result.Metadata[vector.MetadataKeyPreviewImage])
+		assert.Equal(t, "The Go Blog", result.Metadata[vector.MetadataKeySiteName])
+		assert.Equal(t, "http://static.example.com/favicon.ico", result.Metadata[vector.MetadataKeyFavicon])
+		assert.Equal(t, "Go Team", result.Metadata[vector.MetadataKeyAuthor])
+		assert.Equal(t, "2024-08-13T02:00:00Z", result.Metadata[vector.MetadataKeyPublishedTime])
+	})
+
+	t.Run("缺失或无效的标签不写入", func(t *testing.T) {
+		server := serve(`<html><head><title>无预览</title>
+<meta property="og:image" content="javascript:alert(1)">
+<meta property="article:published_time" content="上周">
+</head><body>正文</body></html>`)
+		result, err := newTestLinkExtractor(config.LinkFetchConfig{}).Extract(ctx, server.URL, models.ContentTypeLink)
+		require.NoError(t, err)
+
+		for _, key := range vector.LinkPreviewMetadataKeys {
+			assert.NotContains(t, result.Metadata, key)
+		}
+	})
+
+	t.Run("配置关闭时不提取", func(t *testing.T) {
+		server := serve(previewPage)
+		extractor := newTestLinkExtractor(config.LinkFetchConfig{})
+		extractor.config.LinkFetch.DisablePreview = true
+		result, err := extractor.Extract(ctx, server.URL, models.ContentTypeLink)
+		require.NoError(t, err)
+		assert.NotContains(t, result.Metadata, vector.MetadataKeyPreviewImage)
+	})
+
+	t.Run("预览字段写入向量元数据", func(t *testing.T) {
+		embeddings := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+		}))
+		defer embeddings.Close()
+		require.NoError(t, config.InitializeForTest(&config.Config{
+			LLM: config.LLMConfig{APIBase: embeddings.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+		}))
+
+		store := vector.NewMemoryStore()
+		engine, err := vector.NewSearchEngineWithStore(store)
+		require.NoError(t, err)
+		defer engine.Close()
+
+		processor := newTestProcessor(t)
+		processor.searchEngine = engine
+		server := serve(previewPage)
+		result, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID:          "req-preview",
+			Content:     server.URL + "/blog/go1.23",
+			ContentType: models.ContentTypeLink,
+			UserID:      "user-1",
+			Options:     ProcessingOptions{EnableVectorization: true},
+		})
+		require.NoError(t, err)
+
+		doc, err := store.GetDocument(ctx, result.ContentItem.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "The Go Blog", doc.Metadata[vector.MetadataKeySiteName])
+		assert.Equal(t, server.URL+"/images/go123.png?w=1200&h=630", doc.Metadata[vector.MetadataKeyPreviewImage])
+	})
+}
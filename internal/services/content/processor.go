@@ -3,6 +3,8 @@ package content
 import (
 	"context"
 	"fmt"
+	"math"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,8 +12,10 @@ import (
 	"memoro/internal/errors"
 	"memoro/internal/logger"
 	"memoro/internal/models"
+	"memoro/internal/services/language"
 	"memoro/internal/services/llm"
 	"memoro/internal/services/vector"
+	"memoro/internal/storage"
 )
 
 // ProcessingStatus 处理状态枚举
@@ -25,6 +29,18 @@ const (
 	StatusCancelled  ProcessingStatus = "cancelled"  // 已取消
 )
 
+// TitleContextKey 请求上下文中调用方提供的标题（如邮件主题），优先于从内容中提取的标题
+const TitleContextKey = "title"
+
+// StoredFileMetadataKey 请求元数据中引用文件存储中原始文件的键（值为存储目录下的文件名）
+const StoredFileMetadataKey = "upload_file"
+
+// StoredFileContent 生成保存到文件存储的图片或文件的处理内容：原始文件名、MIME类型和大小，
+// 原始文件通过StoredFileMetadataKey引用，存储文件名不进入内容和向量
+func StoredFileContent(filename, mimeType string, size int) string {
+	return fmt.Sprintf("%s (%s, %d bytes)", filename, mimeType, size)
+}
+
 // ProcessingRequest 内容处理请求
 type ProcessingRequest struct {
 	ID          string                 `json:"id"`
@@ -35,6 +51,15 @@ type ProcessingRequest struct {
 	Context     map[string]interface{} `json:"context"`  // 上下文信息
 	Options     ProcessingOptions      `json:"options"`  // 处理选项
 	CreatedAt   time.Time              `json:"created_at"`
+
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`     // 调用方自定义元数据，写入向量索引并可用于搜索过滤
+	CallbackURL string                 `json:"callback_url,omitempty"` // 处理结束后POST处理结果的地址（http/https）
+
+	ThreadID string `json:"thread_id,omitempty"` // 会话线程ID（如聊天会话），同一线程的消息可折叠搜索并汇总为线程文档
+
+	Sensitive bool `json:"sensitive,omitempty"` // 敏感内容，保存到数据库时始终加密原始内容（需要配置database.encryption）
+
+	quarantined bool // 提交时被判定为刷屏并隔离（只保存，不分析和索引）
 }
 
 // ProcessingOptions 处理选项
@@ -46,6 +71,12 @@ type ProcessingOptions struct {
 	EnableVectorization   bool     `json:"enable_vectorization"`    // 是否启用向量化
 	ExistingTags          []string `json:"existing_tags"`           // 现有标签
 	MaxTags               int      `json:"max_tags"`                // 最大标签数
+
+	EnableNeighborTags bool `json:"enable_neighbor_tags"` // 向量化后从相似的已打标签文档继承标签（需启用向量化）
+
+	EnableMetrics bool `json:"enable_metrics"` // 是否在结果中返回各阶段的耗时、token消耗和缓存命中
+
+	Preset ProcessingPreset `json:"preset,omitempty"` // 命名的处理预设（如summary_only），设置后覆盖上面的阶段开关
 }
 
 // ProcessingResult 处理结果
@@ -54,12 +85,35 @@ type ProcessingResult struct {
 	Status          ProcessingStatus    `json:"status"`
 	ContentItem     *models.ContentItem `json:"content_item"`
 	Summary         *llm.SummaryResult  `json:"summary"`
+	SummarySource   SummarySource       `json:"summary_source,omitempty"` // 摘要来源：generated由LLM生成，derived直接取自内容
 	Tags            *llm.TagResult      `json:"tags"`
 	ImportanceScore float64             `json:"importance_score"`
 	VectorResult    *VectorResult       `json:"vector_result,omitempty"`    // 向量化结果
+	DuplicateOf     string              `json:"duplicate_of,omitempty"`     // 重复内容对应的已有内容ID
+	Dedup           *DedupDecision      `json:"dedup,omitempty"`            // 启用索引时去重时的判断结果
 	ProcessingTime  time.Duration       `json:"processing_time"`
 	Error           string              `json:"error,omitempty"`
 	CompletedAt     time.Time           `json:"completed_at"`
+
+	TimedOutStage ProcessingStage `json:"timed_out_stage,omitempty"` // 超过阶段超时的处理阶段
+
+	StageErrors map[ProcessingStage]string `json:"stage_errors,omitempty"` // 分类、摘要、标签阶段各自的失败原因（并发执行，互不取消）
+
+	TransformErrors map[string]string `json:"transform_errors,omitempty"` // 出错后被跳过的内容转换器及失败原因
+
+	StageMetrics map[ProcessingStage]*StageMetric `json:"stage_metrics,omitempty"` // 各阶段的耗时、token消耗和缓存命中（启用enable_metrics时返回）
+
+	NeighborTags []string `json:"neighbor_tags,omitempty"` // 从相似文档继承的标签
+
+	ReprocessSuggested bool `json:"reprocess_suggested,omitempty"` // 提取质量过低，建议人工复核后重新处理
+
+	Quarantined bool `json:"quarantined,omitempty"` // 提交时被判定为刷屏，已隔离保存（未分析和索引）
+
+	ThreadDocumentID    string `json:"thread_document_id,omitempty"`    // 启用线程汇总时更新的线程文档ID
+	ThreadRollupPending bool   `json:"thread_rollup_pending,omitempty"` // 距上次汇总不足间隔，线程文档将在稍后合并汇总
+
+	Persisted        bool   `json:"persisted"`                   // 内容项是否已保存到数据库
+	PersistenceError string `json:"persistence_error,omitempty"` // 保存到数据库失败的原因（向量索引不受影响）
 }
 
 // VectorResult 向量化结果
@@ -69,17 +123,78 @@ type VectorResult struct {
 	Indexed         bool      `json:"indexed"`          // 是否已索引
 	IndexedAt       time.Time `json:"indexed_at"`       // 索引时间
 	Error           string    `json:"error,omitempty"`  // 向量化错误
+
+	Skipped    bool   `json:"skipped,omitempty"`     // 内容没有可向量化的文本或向量库不可用，已跳过向量化
+	SkipReason string `json:"skip_reason,omitempty"` // 跳过原因
+}
+
+// ContentDetail 内容项详情
+type ContentDetail struct {
+	ID              string                 `json:"id"`               // 内容ID
+	Type            models.ContentType     `json:"type"`             // 内容类型
+	RawContent      string                 `json:"raw_content"`      // 原始内容
+	UserID          string                 `json:"user_id"`          // 用户ID
+	Summary         models.Summary         `json:"summary"`          // 多层次摘要
+	Tags            []string               `json:"tags"`             // 标签
+	ImportanceScore float64                `json:"importance_score"` // 重要性评分
+	ProcessedData   map[string]interface{} `json:"processed_data"`   // 处理元数据
+	CreatedAt       time.Time              `json:"created_at"`       // 创建时间
+	UpdatedAt       time.Time              `json:"updated_at"`       // 更新时间
+	Vector          *VectorStatus          `json:"vector,omitempty"` // 向量索引状态
+}
+
+// 时间线分页大小
+const (
+	defaultTimelineLimit = 20  // 未指定时的每页数量
+	maxTimelineLimit     = 100 // 每页最大数量
+)
+
+// TimelinePage 按时间倒序的内容列表分页
+type TimelinePage struct {
+	Items      []*ContentDetail `json:"items"`                 // 内容项（新的在前）
+	HasMore    bool             `json:"has_more"`              // 是否还有更早的内容
+	NextBefore *time.Time       `json:"next_before,omitempty"` // 获取下一页时使用的before游标
+}
+
+// VectorStatus 内容的向量索引状态
+type VectorStatus struct {
+	Indexed         bool                   `json:"indexed"`                    // 是否已索引
+	VectorDimension int                    `json:"vector_dimension,omitempty"` // 向量维度
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`         // 向量库中的元数据
+	Error           string                 `json:"error,omitempty"`            // 查询向量库的错误
+}
+
+// ContentMetadataPatch 内容元数据补丁（仅包含需要修改的字段，未设置的字段保持不变）
+type ContentMetadataPatch struct {
+	Tags            []string `json:"tags,omitempty"`             // 新的标签列表（空列表表示清除标签）
+	ImportanceScore *float64 `json:"importance_score,omitempty"` // 新的重要性评分
+	Pinned          *bool    `json:"pinned,omitempty"`           // 是否置顶
 }
 
 // SearchRequest 搜索请求
 type SearchRequest struct {
-	Query         string                `json:"query"`                   // 查询文本
-	UserID        string                `json:"user_id"`                 // 用户ID
-	ContentTypes  []models.ContentType  `json:"content_types,omitempty"` // 内容类型过滤
-	TopK          int                   `json:"top_k"`                   // 返回结果数量
-	MinSimilarity float32               `json:"min_similarity"`          // 最小相似度
-	TimeRange     *TimeRange            `json:"time_range,omitempty"`    // 时间范围
-	Tags          []string              `json:"tags,omitempty"`          // 标签过滤
+	Query           string               `json:"query"`                      // 查询文本
+	UserID          string               `json:"user_id"`                    // 用户ID
+	ContentTypes    []models.ContentType `json:"content_types,omitempty"`    // 内容类型过滤
+	TopK            int                  `json:"top_k"`                      // 返回结果数量
+	MinSimilarity   float32              `json:"min_similarity"`             // 最小相似度
+	TimeRange       *TimeRange           `json:"time_range,omitempty"`       // 时间范围
+	Tags            []string             `json:"tags,omitempty"`             // 标签过滤
+	RankingStrategy string               `json:"ranking_strategy,omitempty"` // 排序策略，为空时使用配置默认值
+	Mode            string               `json:"mode,omitempty"`             // 检索模式（vector、hybrid、keyword），为空时使用配置默认值
+	IncludeArchived bool                 `json:"include_archived,omitempty"` // 同时检索已归档到冷存储的内容
+
+	CollapseDuplicates bool `json:"collapse_duplicates,omitempty"` // 折叠近似重复的结果
+	MinPerContentType  int  `json:"min_per_content_type,omitempty"` // 前top_k中每种内容类型至少保留的数量
+
+	Languages              []string `json:"languages,omitempty"`                // 语言过滤
+	IncludeUnknownLanguage bool     `json:"include_unknown_language,omitempty"` // 语言过滤时包含没有语言信息的内容
+
+	EnableLLMRerank bool `json:"enable_llm_rerank,omitempty"` // 由LLM为排名靠前的结果打相关性分数并重排
+
+	Fields []string `json:"fields,omitempty"` // 只返回指定字段（如document_id、similarity、title、summary），为空时返回全部字段
+
+	SnippetSource string `json:"snippet_source,omitempty"` // 结果片段来源（computed、summary），为空时使用配置默认值
 }
 
 // SearchResponse 搜索响应
@@ -93,12 +208,27 @@ type SearchResponse struct {
 type SearchResultItem struct {
 	DocumentID      string                 `json:"document_id"`      // 文档ID
 	Content         string                 `json:"content"`          // 文档内容
-	Similarity      float64                `json:"similarity"`       // 相似度分数
+	Similarity      float64                `json:"similarity"`       // 相似度分数（即归一化分数）
 	Rank            int                    `json:"rank"`             // 排名
 	Metadata        map[string]interface{} `json:"metadata"`         // 文档元数据
 	ContentSummary  string                 `json:"content_summary"`  // 内容摘要
 	MatchedKeywords []string               `json:"matched_keywords"` // 匹配的关键词
 	CreatedAt       time.Time              `json:"created_at"`       // 创建时间
+
+	DuplicateOf []string `json:"duplicate_of,omitempty"` // 被折叠到该结果下的近似重复文档ID
+
+	Distance        float32               `json:"distance"`         // 向量库返回的原始距离
+	RawSimilarity   float64               `json:"raw_similarity"`   // 相似度类型的原始输出
+	NormalizedScore float64               `json:"normalized_score"` // 归一化到0-1的相似度
+	SimilarityType  vector.SimilarityType `json:"similarity_type"`  // 计算相似度使用的类型
+
+	projectedKeys map[string]bool // 指定投影时序列化的JSON键
+}
+
+// MarshalJSON 指定投影时只序列化请求的字段，未请求的字段不以零值出现在响应中
+func (r SearchResultItem) MarshalJSON() ([]byte, error) {
+	type plain SearchResultItem
+	return vector.MarshalProjected(plain(r), r.projectedKeys)
 }
 
 // RecommendationRequest 推荐请求
@@ -111,6 +241,11 @@ type RecommendationRequest struct {
 	ContentTypes        []models.ContentType  `json:"content_types,omitempty"`      // 内容类型过滤
 	ExcludeDocuments    []string              `json:"exclude_documents,omitempty"`  // 排除的文档ID
 	MinSimilarity       float32               `json:"min_similarity"`               // 最小相似度
+	MinConfidence       float64               `json:"min_confidence,omitempty"`     // 最小归一化置信度（0-1），为空时使用配置默认值
+
+	IncludeExplanations bool `json:"include_explanations,omitempty"` // 是否返回推荐解释
+
+	MetadataFilters map[string]interface{} `json:"metadata_filters,omitempty"` // 自定义元数据过滤，将推荐限定在匹配的文档内
 }
 
 // RecommendationResponse 推荐响应
@@ -118,7 +253,9 @@ type RecommendationResponse struct {
 	Recommendations []*RecommendationItem `json:"recommendations"`     // 推荐结果
 	TotalFound      int                   `json:"total_found"`         // 总发现数量
 	ProcessTime     time.Duration         `json:"process_time"`        // 处理时间
-	Type            string                `json:"recommendation_type"` // 推荐类型
+	Type            string                `json:"recommendation_type"` // 推荐类型（个性化降级时为实际使用的类型）
+
+	Metadata map[string]interface{} `json:"metadata,omitempty"` // 推荐元数据（personalized表示是否应用了个性化，降级时包含原因）
 }
 
 // RecommendationItem 推荐项
@@ -132,6 +269,8 @@ type RecommendationItem struct {
 	RecommendationScore float64              `json:"recommendation_score"` // 推荐分数
 	RelatedKeywords   []string               `json:"related_keywords"`   // 相关关键词
 	CreatedAt         time.Time              `json:"created_at"`         // 创建时间
+
+	Explanation *vector.RecommendationExplanation `json:"explanation,omitempty"` // 推荐解释（请求include_explanations时返回）
 }
 
 // TimeRange 时间范围
@@ -147,8 +286,17 @@ type Processor struct {
 	summarizer *llm.Summarizer
 	tagger     *llm.Tagger
 	extractor  *ExtractorManager
-	classifier *ContentClassifier
-	searchEngine *vector.SearchEngine  // 智能搜索引擎
+	classifier Classifier
+	searchEngine *vector.SearchEngine  // 智能搜索引擎（向量库不可用时为nil，向量化、搜索和推荐不可用）
+	searchEngineErr string             // 搜索引擎初始化失败的原因
+	store      *storage.ContentStore   // 内容持久化存储
+	budget     *llm.TokenBudget        // token额度（用于提交前的额度检查）
+	callbacks  *callbackDispatcher     // 处理完成回调发送器
+	transformers []registeredTransformer // 注册的内容转换器（受mu保护）
+	flood      *floodDetector          // 提交时的刷屏检测（未启用时为nil）
+	inflight   *inflightLocks          // 相同内容并发请求的串行处理（未启用时为nil）
+	canonical  *inflightLocks          // 相同规范URL链接的串行处理，保证按存储查找和保存之间不插入相同链接
+	threads    threadRollups           // 会话线程汇总的串行执行和合并
 	logger     *logger.Logger
 
 	// 处理状态管理
@@ -160,10 +308,23 @@ type Processor struct {
 	requestChan chan *ProcessingRequest
 	stopChan    chan struct{}
 	workerWg    sync.WaitGroup
+
+	// 关闭协调：closing后不再接收新请求，超过排空期限时取消processCtx中止进行中的处理
+	closing       bool
+	processCtx    context.Context
+	cancelProcess context.CancelFunc
+
+	eventSubscribers map[string][]chan *ProcessingEvent // 按请求ID的处理事件订阅者（受mu保护）
 }
 
-// NewProcessor 创建新的内容处理器
+// NewProcessor 创建新的内容处理器（自行初始化向量搜索引擎）
 func NewProcessor() (*Processor, error) {
+	return NewProcessorWithSearchEngine(nil)
+}
+
+// NewProcessorWithSearchEngine 创建使用共享搜索引擎的内容处理器，使搜索API、推荐API和内容处理共用同一份交互记录和缓存；
+// sharedEngine为nil时自行初始化
+func NewProcessorWithSearchEngine(sharedEngine *vector.SearchEngine) (*Processor, error) {
 	cfg := config.Get()
 	if cfg == nil {
 		return nil, errors.ErrConfigMissing("processing config")
@@ -206,26 +367,59 @@ func NewProcessor() (*Processor, error) {
 		return nil, err
 	}
 
-	// 初始化向量搜索引擎
-	searchEngine, err := vector.NewSearchEngine()
-	if err != nil {
-		processorLogger.LogMemoroError(err.(*errors.MemoroError), "Failed to create search engine")
-		return nil, err
+	// 初始化向量搜索引擎；向量库不可用时只关闭向量化、搜索和推荐，摘要、标签和分类照常工作
+	var searchEngineErr string
+	searchEngine := sharedEngine
+	if searchEngine == nil {
+		searchEngine, err = vector.NewSearchEngine()
+		if err != nil {
+			searchEngine = nil
+			searchEngineErr = err.Error()
+			processorLogger.Warn("Search engine initialization failed, vectorization, search and recommendations will be unavailable", logger.Fields{
+				"error": err.Error(),
+			})
+		} else {
+			// 记录内容查看，用于个性化推荐和按访问调整重要性
+			searchEngine.SetInteractionStore(vector.NewInteractionStoreWithConfig(cfg))
+		}
+	}
+
+	// 初始化内容存储（未配置数据库时仅在内存中保留处理结果）
+	var store *storage.ContentStore
+	if cfg.Database.Path != "" {
+		store, err = storage.NewContentStore()
+		if err != nil {
+			processorLogger.Error("Failed to create content store", logger.Fields{"error": err.Error()})
+			return nil, err
+		}
+	} else {
+		processorLogger.Warn("Database path not configured, processed content will not be persisted")
 	}
 
+	processCtx, cancelProcess := context.WithCancel(context.Background())
+
 	processor := &Processor{
-		config:         cfg.Processing,
-		llmClient:      llmClient,
-		summarizer:     summarizer,
-		tagger:         tagger,
-		extractor:      extractor,
-		classifier:     classifier,
-		searchEngine:   searchEngine,
-		logger:         processorLogger,
-		activeRequests: make(map[string]*ProcessingRequest),
-		results:        make(map[string]*ProcessingResult),
-		requestChan:    make(chan *ProcessingRequest, cfg.Processing.QueueSize),
-		stopChan:       make(chan struct{}),
+		config:          cfg.Processing,
+		llmClient:       llmClient,
+		summarizer:      summarizer,
+		tagger:          tagger,
+		extractor:       extractor,
+		classifier:      classifier,
+		searchEngine:    searchEngine,
+		searchEngineErr: searchEngineErr,
+		store:           store,
+		budget:          llm.GetTokenBudget(),
+		callbacks:       newCallbackDispatcher(cfg.Processing.Callbacks),
+		flood:           newFloodDetector(cfg.Processing.FloodProtection),
+		inflight:        newInflightLocks(cfg.Processing.Dedup),
+		canonical:       newCanonicalLocks(),
+		logger:          processorLogger,
+		activeRequests:  make(map[string]*ProcessingRequest),
+		results:         make(map[string]*ProcessingResult),
+		requestChan:     make(chan *ProcessingRequest, cfg.Processing.QueueSize),
+		stopChan:        make(chan struct{}),
+		processCtx:      processCtx,
+		cancelProcess:   cancelProcess,
 	}
 
 	// 启动工作协程
@@ -235,6 +429,7 @@ func NewProcessor() (*Processor, error) {
 		"max_workers": cfg.Processing.MaxWorkers,
 		"queue_size":  cfg.Processing.QueueSize,
 		"timeout":     cfg.Processing.Timeout,
+		"search":      searchEngine != nil,
 	})
 
 	return processor, nil
@@ -255,6 +450,11 @@ func (p *Processor) ProcessContent(ctx context.Context, request *ProcessingReque
 	// 设置默认值
 	p.setDefaultOptions(request)
 
+	// 刷屏检测（隔离时关闭分析和向量化）
+	if err := p.checkFlood(request); err != nil {
+		return nil, err
+	}
+
 	p.logger.Debug("Processing content request", logger.Fields{
 		"request_id":   request.ID,
 		"content_type": string(request.ContentType),
@@ -269,27 +469,19 @@ func (p *Processor) ProcessContent(ctx context.Context, request *ProcessingReque
 		Status:    StatusPending,
 	}
 
-	// 保存请求和初始结果
-	p.mu.Lock()
-	p.activeRequests[request.ID] = request
-	p.results[request.ID] = result
-	p.mu.Unlock()
-
-	// 提交到处理队列
-	select {
-	case p.requestChan <- request:
-		p.logger.Debug("Request queued for processing", logger.Fields{
-			"request_id": request.ID,
-		})
-	case <-ctx.Done():
-		p.removeRequest(request.ID)
+	if err := ctx.Err(); err != nil {
 		return nil, errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeSystemGeneric, "Request cancelled").
-			WithCause(ctx.Err())
-	default:
-		p.removeRequest(request.ID)
-		return nil, errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeSystemGeneric, "Processing queue full")
+			WithCause(err)
 	}
 
+	// 保存请求和初始结果并提交到处理队列
+	if err := p.enqueueRequest(request, result); err != nil {
+		return nil, err
+	}
+	p.logger.Debug("Request queued for processing", logger.Fields{
+		"request_id": request.ID,
+	})
+
 	// 等待处理完成
 	return p.waitForResult(ctx, request.ID)
 }
@@ -308,27 +500,43 @@ func (p *Processor) ProcessContentAsync(request *ProcessingRequest) error {
 	// 设置默认值
 	p.setDefaultOptions(request)
 
+	// 刷屏检测（隔离时关闭分析和向量化）
+	if err := p.checkFlood(request); err != nil {
+		return err
+	}
+
 	// 创建处理结果
 	result := &ProcessingResult{
 		RequestID: request.ID,
 		Status:    StatusPending,
 	}
 
-	// 保存请求和初始结果
+	// 保存请求和初始结果并提交到处理队列
+	if err := p.enqueueRequest(request, result); err != nil {
+		return err
+	}
+	p.logger.Debug("Request queued for async processing", logger.Fields{
+		"request_id": request.ID,
+	})
+	return nil
+}
+
+// enqueueRequest 保存请求和初始结果并提交到处理队列，处理器关闭中或队列已满时拒绝
+func (p *Processor) enqueueRequest(request *ProcessingRequest, result *ProcessingResult) error {
+	// 持锁提交，保证关闭开始后不会再有请求进入队列
 	p.mu.Lock()
-	p.activeRequests[request.ID] = request
-	p.results[request.ID] = result
-	p.mu.Unlock()
+	defer p.mu.Unlock()
+
+	if p.closing {
+		return errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeSystemGeneric, "Processor is shutting down")
+	}
 
-	// 提交到处理队列
 	select {
 	case p.requestChan <- request:
-		p.logger.Debug("Request queued for async processing", logger.Fields{
-			"request_id": request.ID,
-		})
+		p.activeRequests[request.ID] = request
+		p.results[request.ID] = result
 		return nil
 	default:
-		p.removeRequest(request.ID)
 		return errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeSystemGeneric, "Processing queue full")
 	}
 }
@@ -377,6 +585,7 @@ func (p *Processor) CancelRequest(requestID string) error {
 
 	result.Status = StatusCancelled
 	result.CompletedAt = time.Now()
+	p.publishStatusLocked(requestID, StatusCancelled, "")
 
 	p.logger.Debug("Processing request cancelled", logger.Fields{
 		"request_id": requestID,
@@ -405,8 +614,16 @@ func (p *Processor) worker(workerID int) {
 		case request := <-p.requestChan:
 			p.processRequest(workerLogger, request)
 		case <-p.stopChan:
-			workerLogger.Debug("Content worker stopping")
-			return
+			// 关闭时处理完队列中剩余的请求再退出（超过排空期限后剩余请求直接取消）
+			for {
+				select {
+				case request := <-p.requestChan:
+					p.processRequest(workerLogger, request)
+				default:
+					workerLogger.Debug("Content worker stopping")
+					return
+				}
+			}
 		}
 	}
 }
@@ -415,6 +632,22 @@ func (p *Processor) worker(workerID int) {
 func (p *Processor) processRequest(workerLogger *logger.Logger, request *ProcessingRequest) {
 	startTime := time.Now()
 
+	parent := p.processCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	// 排空期限已过，不再开始新的处理
+	if parent.Err() != nil {
+		p.finishRequest(request, &ProcessingResult{
+			RequestID:   request.ID,
+			Status:      StatusCancelled,
+			Error:       "processor shut down before request started",
+			CompletedAt: time.Now(),
+		})
+		return
+	}
+
 	// 更新状态为处理中
 	p.updateRequestStatus(request.ID, StatusProcessing)
 
@@ -425,19 +658,32 @@ func (p *Processor) processRequest(workerLogger *logger.Logger, request *Process
 	})
 
 	// 创建处理上下文
-	ctx, cancel := context.WithTimeout(context.Background(), p.config.Timeout)
+	ctx, cancel := context.WithTimeout(parent, p.config.Timeout)
 	defer cancel()
 
 	// 执行实际处理
 	result, err := p.doProcessing(ctx, request)
 	if err != nil {
-		workerLogger.LogMemoroError(err.(*errors.MemoroError), "Processing failed")
-		p.updateRequestResult(request.ID, &ProcessingResult{
+		status := StatusFailed
+		if parent.Err() != nil {
+			// 关闭时超过排空期限被取消
+			status = StatusCancelled
+		}
+		if memoErr, ok := err.(*errors.MemoroError); ok {
+			workerLogger.LogMemoroError(memoErr, "Processing failed")
+		} else {
+			workerLogger.Error("Processing failed", logger.Fields{
+				"request_id": request.ID,
+				"error":      err.Error(),
+			})
+		}
+		p.finishRequest(request, &ProcessingResult{
 			RequestID:      request.ID,
-			Status:         StatusFailed,
+			Status:         status,
 			Error:          err.Error(),
 			ProcessingTime: time.Since(startTime),
 			CompletedAt:    time.Now(),
+			TimedOutStage:  timedOutStage(err),
 		})
 		return
 	}
@@ -447,7 +693,7 @@ func (p *Processor) processRequest(workerLogger *logger.Logger, request *Process
 	result.CompletedAt = time.Now()
 	result.Status = StatusCompleted
 
-	p.updateRequestResult(request.ID, result)
+	p.finishRequest(request, result)
 
 	workerLogger.Debug("Processing completed", logger.Fields{
 		"request_id":       request.ID,
@@ -458,14 +704,54 @@ func (p *Processor) processRequest(workerLogger *logger.Logger, request *Process
 	})
 }
 
-// doProcessing 执行实际的内容处理
+// doProcessing 执行实际的内容处理，启用并发去重时相同内容的并发请求串行处理
 func (p *Processor) doProcessing(ctx context.Context, request *ProcessingRequest) (*ProcessingResult, error) {
+	if p.inflight == nil {
+		return p.processContent(ctx, request)
+	}
+
+	key := inflightKey(request)
+	entry, err := p.inflight.acquire(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer p.inflight.release(key, entry)
+
+	// 先到的相同内容已处理完成时直接复用其结果
+	if first := entry.result; first != nil {
+		p.logger.Info("Concurrent duplicate content detected", logger.Fields{
+			"request_id":   request.ID,
+			"duplicate_of": first.ContentItem.ID,
+		})
+		return inflightDuplicateResult(request, first), nil
+	}
+
+	result, err := p.processContent(ctx, request)
+	if err == nil && result.ContentItem != nil && !result.Quarantined {
+		entry.result = result
+	}
+	return result, err
+}
+
+// processContent 执行一次完整的内容处理流程
+func (p *Processor) processContent(ctx context.Context, request *ProcessingRequest) (*ProcessingResult, error) {
 	result := &ProcessingResult{
 		RequestID: request.ID,
 	}
 
+	// LLM调用的token消耗归属到请求用户
+	ctx = llm.WithBudgetUser(ctx, request.UserID)
+
+	metrics := newStageMetricsCollector(request.Options.EnableMetrics)
+	defer func() { result.StageMetrics = metrics.snapshot() }()
+
 	// 1. 内容提取和清理
-	extractedContent, err := p.extractor.Extract(ctx, request.Content, request.ContentType)
+	extractCtx, done := metrics.begin(ctx, StageExtract)
+	stageCtx, cancel := p.withStageTimeout(extractCtx, StageExtract)
+	extractedContent, err := p.extractor.Extract(stageCtx, request.Content, request.ContentType)
+	err = p.stageError(ctx, stageCtx, StageExtract, err)
+	cancel()
+	done()
 	if err != nil {
 		p.logger.Error("Content extraction failed", logger.Fields{
 			"request_id": request.ID,
@@ -473,40 +759,119 @@ func (p *Processor) doProcessing(ctx context.Context, request *ProcessingRequest
 		})
 		return nil, err
 	}
+	p.publishStage(request.ID, StageExtract)
+
+	// 按规范URL去重：持有该URL的锁直到保存完成，相同链接的并发请求依次在存储中查找
+	canonicalURL := getCanonicalURL(extractedContent)
+	if canonicalURL != "" && p.canonical != nil {
+		key := request.UserID + "|" + canonicalURL
+		entry, err := p.canonical.acquire(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		defer p.canonical.release(key, entry)
+	}
+	if existing := p.findByCanonicalURL(ctx, request.UserID, canonicalURL); existing != nil {
+		p.logger.Info("Duplicate link content detected", logger.Fields{
+			"request_id":    request.ID,
+			"canonical_url": canonicalURL,
+			"duplicate_of":  existing.ID,
+		})
+		result.ContentItem = existing
+		result.DuplicateOf = existing.ID
+		result.ImportanceScore = existing.ImportanceScore
+		return result, nil
+	}
 
 	// 2. 创建内容项
 	contentItem := models.NewContentItem(request.ContentType, extractedContent.Content, request.UserID)
 	if contentItem == nil {
 		return nil, errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeValidationFailed, "Failed to create content item")
 	}
+	contentItem.Sensitive = request.Sensitive
+	// 隔离的内容未经分析，不作为后续相同链接的去重目标
+	if !request.quarantined {
+		contentItem.CanonicalURL = canonicalURL
+	}
 
 	// 设置提取的元数据
 	processedData := contentItem.GetProcessedData()
 	if extractedContent.Title != "" {
 		processedData["title"] = extractedContent.Title
 	}
+	if title, ok := request.Context[TitleContextKey].(string); ok && strings.TrimSpace(title) != "" {
+		processedData["title"] = strings.TrimSpace(title)
+	}
 	if extractedContent.Description != "" {
 		processedData["description"] = extractedContent.Description
 	}
 	if len(extractedContent.Metadata) > 0 {
 		processedData["extraction_metadata"] = extractedContent.Metadata
 	}
+	if provenance := buildProvenance(extractedContent); provenance != nil {
+		processedData["provenance"] = provenance
+	}
+	for _, key := range vector.LinkPreviewMetadataKeys {
+		if value, ok := extractedContent.Metadata[key].(string); ok && value != "" {
+			processedData[key] = value
+		}
+	}
+	if extractedContent.Language != "" && extractedContent.Language != language.Unknown {
+		processedData[vector.MetadataKeyLanguage] = extractedContent.Language
+	}
+	if extractedContent.OriginalMarkup != "" {
+		// 内容已转换为纯文本，保留原始标记
+		processedData[OriginalMarkupKey] = extractedContent.OriginalMarkup
+		processedData[TextFormatKey] = extractedContent.Metadata[TextFormatKey]
+	}
+	if extractedContent.LowQuality {
+		// 低质量提取仍然保存，标记后可人工复核并重新处理
+		processedData["low_quality"] = true
+		processedData["reprocess_suggested"] = true
+		result.ReprocessSuggested = true
+	}
+	if len(request.Metadata) > 0 {
+		processedData[vector.CustomMetadataKey] = request.Metadata
+	}
+	if request.quarantined {
+		processedData[QuarantinedKey] = true
+		result.Quarantined = true
+	}
+	if request.ThreadID != "" {
+		processedData[vector.MetadataKeyThreadID] = request.ThreadID
+	}
 	contentItem.SetProcessedData(processedData)
 
+	// 3-5. 分类、摘要和标签只依赖提取结果，并发执行后按顺序应用
+	outcome := p.runAnalysisStages(ctx, request, extractedContent, metrics)
+	if stageErrors := outcome.stageErrors(); len(stageErrors) > 0 {
+		result.StageErrors = make(map[ProcessingStage]string, len(stageErrors))
+		for stage, err := range stageErrors {
+			result.StageErrors[stage] = err.Error()
+		}
+	}
+
 	// 3. 内容分类和重要性评分
 	if request.Options.EnableClassification || request.Options.EnableImportanceScore {
-		classificationResult, err := p.classifier.Classify(ctx, extractedContent)
+		classificationResult, err := outcome.classification, outcome.classifyErr
 		if err != nil {
 			p.logger.Error("Content classification failed", logger.Fields{
 				"request_id": request.ID,
 				"error":      err.Error(),
 			})
-			// 不中断处理，使用默认值
+			result.TimedOutStage = timedOutStage(err)
+			// 不中断处理，使用默认值并标记来源
 			if request.Options.EnableImportanceScore {
-				contentItem.ImportanceScore = 0.5
-				result.ImportanceScore = 0.5
+				contentItem.ImportanceScore = defaultImportanceScore(p.config)
+				result.ImportanceScore = contentItem.ImportanceScore
+
+				processedData := contentItem.GetProcessedData()
+				processedData[ImportanceSourceKey] = string(ImportanceSourceDefault)
+				contentItem.SetProcessedData(processedData)
 			}
 		} else {
+			p.publishStage(request.ID, StageClassify)
+
 			// 应用分类结果
 			if request.Options.EnableClassification {
 				// 将分类信息存储到ProcessedData中
@@ -521,24 +886,32 @@ func (p *Processor) doProcessing(ctx context.Context, request *ProcessingRequest
 			if request.Options.EnableImportanceScore {
 				result.ImportanceScore = classificationResult.ImportanceScore
 				contentItem.ImportanceScore = classificationResult.ImportanceScore
+
+				source, ok := classificationResult.Metadata[ImportanceSourceKey].(string)
+				if !ok {
+					source = string(ImportanceSourceComputed)
+				}
+				processedData := contentItem.GetProcessedData()
+				processedData[ImportanceSourceKey] = source
+				contentItem.SetProcessedData(processedData)
 			}
 		}
 	}
 
-	// 4. 生成摘要
+	// 4. 生成摘要（内容过短时不调用LLM，直接使用内容作为一句话摘要）
 	if request.Options.EnableSummary {
-		summaryRequest := llm.SummaryRequest{
-			Content:     extractedContent.Content,
-			ContentType: request.ContentType,
-			Context:     request.Context,
-		}
-
-		summary, err := p.summarizer.GenerateSummary(ctx, summaryRequest)
-		if err != nil {
-			return nil, err
+		if outcome.summaryErr != nil {
+			return nil, outcome.summaryErr
 		}
+		summary := outcome.summary
 
 		result.Summary = summary
+		result.SummarySource = outcome.summarySource
+		p.publishStage(request.ID, StageSummarize)
+
+		processedData := contentItem.GetProcessedData()
+		processedData[SummarySourceKey] = string(outcome.summarySource)
+		contentItem.SetProcessedData(processedData)
 
 		// 设置内容项的摘要
 		modelSummary := models.Summary{
@@ -551,33 +924,86 @@ func (p *Processor) doProcessing(ctx context.Context, request *ProcessingRequest
 
 	// 5. 生成标签
 	if request.Options.EnableTags {
-		tagRequest := llm.TagRequest{
-			Content:      extractedContent.Content,
-			ContentType:  request.ContentType,
-			Context:      request.Context,
-			ExistingTags: request.Options.ExistingTags,
-			MaxTags:      request.Options.MaxTags,
+		tags, err := outcome.tags, outcome.tagErr
+		if err != nil && !(request.Options.EnableNeighborTags && request.Options.EnableVectorization) {
+			return nil, err
 		}
 
-		tags, err := p.tagger.GenerateTags(ctx, tagRequest)
 		if err != nil {
-			return nil, err
+			// 标签生成不可用时由相似文档的标签补充
+			p.logger.Warn("Tag generation failed, relying on neighbor tags", logger.Fields{
+				"request_id": request.ID,
+				"error":      err.Error(),
+			})
+			result.TimedOutStage = timedOutStage(err)
+			tags = nil
+		} else {
+			result.Tags = tags
+			p.publishStage(request.ID, StageTag)
 		}
 
-		result.Tags = tags
+		// 合并用户提供和生成的标签，折叠大小写和别名变体
+		mergedTags, tagSources := mergeTags(request.Options.ExistingTags, tags, request.Options.MaxTags, p.config.TagLimits.Aliases)
+		if tags != nil {
+			tags.Tags = mergedTags
+		}
+		contentItem.SetTags(mergedTags)
 
-		// 设置内容项的标签
-		contentItem.SetTags(tags.Tags)
+		processedData := contentItem.GetProcessedData()
+		processedData[TagSourcesKey] = map[string][]string{
+			string(TagSourceUser):  tagSources[TagSourceUser],
+			string(TagSourceModel): tagSources[TagSourceModel],
+		}
+		contentItem.SetProcessedData(processedData)
+	}
+
+	// 内容转换器（内置的实体元数据和注册的自定义转换器）在索引前修改内容项
+	transformCtx, done := metrics.begin(ctx, StageTransform)
+	err = p.applyTransformers(transformCtx, request, extractedContent, contentItem, result)
+	done()
+	if err != nil {
+		return nil, err
 	}
 
 	// 6. 向量化和索引
 	if request.Options.EnableVectorization {
+		ctx, done := metrics.begin(ctx, StageVectorize)
+
 		vectorResult := &VectorResult{
 			DocumentID: contentItem.ID,
 		}
 
-		err := p.searchEngine.IndexDocument(ctx, contentItem)
-		if err != nil {
+		var match *vector.DuplicateMatch
+		var err error
+		if unavailable := p.requireSearchEngine("vectorization"); unavailable != nil {
+			// 向量库不可用时跳过向量化，不视为失败
+			vectorResult.Skipped = true
+			vectorResult.SkipReason = unavailable.Error()
+		} else {
+			stageCtx, cancel := p.withStageTimeout(ctx, StageVectorize)
+			policy := p.dedupPolicy()
+			match, err = p.searchEngine.IndexDocumentDeduplicated(stageCtx, contentItem, policy)
+			err = p.stageError(ctx, stageCtx, StageVectorize, err)
+			cancel()
+			if policy != nil && err == nil {
+				result.Dedup = &DedupDecision{Duplicate: match != nil, Scope: policy.Scope, Match: match}
+			}
+		}
+		if vectorResult.Skipped {
+			p.logger.Debug("Content vectorization skipped, search engine unavailable", logger.Fields{
+				"request_id": request.ID,
+				"content_id": contentItem.ID,
+			})
+		} else if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeInsufficientInput) {
+			// 没有可向量化的文本（如图片OCR结果为空）时跳过向量化，不视为失败
+			p.logger.Info("Content vectorization skipped", logger.Fields{
+				"request_id": request.ID,
+				"content_id": contentItem.ID,
+				"reason":     err.Error(),
+			})
+			vectorResult.Skipped = true
+			vectorResult.SkipReason = err.Error()
+		} else if err != nil {
 			p.logger.Error("Content vectorization failed", logger.Fields{
 				"request_id":  request.ID,
 				"content_id":  contentItem.ID,
@@ -585,10 +1011,28 @@ func (p *Processor) doProcessing(ctx context.Context, request *ProcessingRequest
 			})
 			// 向量化失败不中断处理，记录错误
 			vectorResult.Error = err.Error()
+			result.TimedOutStage = timedOutStage(err)
 			vectorResult.Indexed = false
+		} else if match != nil {
+			// 与已有内容重复时不索引，也不保存内容项
+			p.logger.Info("Duplicate content skipped", logger.Fields{
+				"request_id":   request.ID,
+				"content_id":   contentItem.ID,
+				"duplicate_of": match.DocumentID,
+				"other_user":   match.OtherUser,
+				"similarity":   match.Similarity,
+			})
+			vectorResult.Skipped = true
+			if match.OtherUser {
+				vectorResult.SkipReason = "duplicate of existing content"
+			} else {
+				vectorResult.SkipReason = "duplicate of " + match.DocumentID
+			}
+			result.DuplicateOf = match.DocumentID
 		} else {
 			vectorResult.Indexed = true
 			vectorResult.IndexedAt = time.Now()
+			p.publishStage(request.ID, StageVectorize)
 			
 			p.logger.Debug("Content indexed successfully", logger.Fields{
 				"request_id": request.ID,
@@ -596,40 +1040,149 @@ func (p *Processor) doProcessing(ctx context.Context, request *ProcessingRequest
 			})
 		}
 
-		// 获取向量维度信息（如果索引成功）
+		// 获取向量维度信息（如果索引成功），维度随模型和回退服务变化，取自索引
 		if vectorResult.Indexed {
-			// 这里可以从搜索引擎获取向量维度信息
-			// 暂时设置一个默认值，后续可以从embedding service获取
-			vectorResult.VectorDimension = 1536 // OpenAI embedding维度
+			if dimension, err := p.searchEngine.VectorDimension(ctx); err != nil {
+				p.logger.Warn("Failed to get vector dimension", logger.Fields{
+					"request_id": request.ID,
+					"error":      err.Error(),
+				})
+			} else {
+				vectorResult.VectorDimension = dimension
+			}
 		}
 
 		result.VectorResult = vectorResult
+
+		// 7. 从相似文档继承标签
+		if vectorResult.Indexed && request.Options.EnableNeighborTags {
+			p.applyNeighborTags(ctx, request, contentItem, result)
+		}
+
+		// 8. 超出文档配额时按淘汰策略软删除多出的文档（刚索引的文档不参与淘汰）
+		if vectorResult.Indexed && request.UserID != "" {
+			if _, err := p.searchEngine.EnforceUserQuota(ctx, request.UserID, contentItem.ID); err != nil {
+				p.logger.Warn("User quota enforcement failed", logger.Fields{
+					"request_id": request.ID,
+					"user_id":    request.UserID,
+					"error":      err.Error(),
+				})
+			}
+		}
+		done()
+	}
+
+	// 判定为重复的内容不保存（重复的是其他用户的内容时不返回其ID）
+	if result.DuplicateOf != "" || (result.Dedup != nil && result.Dedup.Duplicate) {
+		result.ContentItem = contentItem
+		return result, nil
+	}
+
+	// 9. 持久化处理完成的内容项（向量索引状态在读取时从向量库获取）
+	// 持久化失败不影响已建立的向量索引，错误记录在结果中
+	if p.store != nil {
+		persistCtx, done := metrics.begin(ctx, StagePersist)
+		err := p.store.Save(persistCtx, contentItem)
+		done()
+		if err != nil {
+			p.logger.Error("Failed to persist content item", logger.Fields{
+				"request_id": request.ID,
+				"content_id": contentItem.ID,
+				"error":      err.Error(),
+			})
+			result.PersistenceError = err.Error()
+		} else {
+			result.Persisted = true
+		}
+	}
+
+	// 10. 汇总会话线程，失败不影响消息本身的处理结果
+	if p.config.Threads.Aggregate && request.ThreadID != "" && result.VectorResult != nil && result.VectorResult.Indexed {
+		threadDocumentID, deferred, err := p.scheduleThreadRollup(ctx, request)
+		if err != nil {
+			p.logger.Warn("Thread rollup failed", logger.Fields{
+				"request_id": request.ID,
+				"thread_id":  request.ThreadID,
+				"error":      err.Error(),
+			})
+		} else {
+			result.ThreadDocumentID = threadDocumentID
+			result.ThreadRollupPending = deferred
+		}
 	}
 
 	result.ContentItem = contentItem
 	return result, nil
 }
 
-// validateRequest 验证处理请求
-func (p *Processor) validateRequest(request *ProcessingRequest) error {
-	if request.ID == "" {
-		return errors.ErrValidationFailed("id", "cannot be empty")
+// getCanonicalURL 获取提取内容的规范URL
+func getCanonicalURL(extractedContent *ExtractedContent) string {
+	if extractedContent == nil || extractedContent.Metadata == nil {
+		return ""
+	}
+	canonicalURL, _ := extractedContent.Metadata["canonical_url"].(string)
+	return canonicalURL
+}
+
+// buildProvenance 构建内容来源信息（原始URL、规范URL、抓取时间、HTTP状态）
+func buildProvenance(extractedContent *ExtractedContent) map[string]interface{} {
+	if getCanonicalURL(extractedContent) == "" {
+		return nil
+	}
+
+	provenance := make(map[string]interface{})
+	for _, key := range []string{"original_url", "final_url", "canonical_url", "fetched_at", "status_code"} {
+		if value, exists := extractedContent.Metadata[key]; exists {
+			provenance[key] = value
+		}
 	}
+	return provenance
+}
 
-	if request.Content == "" {
-		return errors.ErrValidationFailed("content", "cannot be empty")
+// findByCanonicalURL 在存储中按规范URL查找用户已保存的内容，未配置存储或查找失败时不去重
+func (p *Processor) findByCanonicalURL(ctx context.Context, userID, canonicalURL string) *models.ContentItem {
+	if canonicalURL == "" || p.store == nil {
+		return nil
 	}
 
-	if len(request.Content) > 100000 { // 100KB限制
-		return errors.ErrValidationFailed("content", "content too large (max 100KB)")
+	existing, err := p.store.FindByCanonicalURL(ctx, userID, canonicalURL)
+	if err != nil {
+		if memoErr, ok := err.(*errors.MemoroError); !ok || !memoErr.IsCode(errors.ErrCodeResourceNotFound) {
+			p.logger.Warn("Canonical URL lookup failed, processing without dedup", logger.Fields{
+				"canonical_url": canonicalURL,
+				"error":         err.Error(),
+			})
+		}
+		return nil
 	}
+	return existing
+}
+
+// defaultAnonymousUserID 允许匿名且未配置默认用户时使用的用户ID
+const defaultAnonymousUserID = "default"
 
-	if request.UserID == "" {
-		return errors.ErrValidationFailed("user_id", "cannot be empty")
+// resolveUserID 允许匿名时为空用户ID返回配置的默认用户，否则原样返回（由后续校验拒绝）
+func (p *Processor) resolveUserID(userID string) string {
+	if userID != "" || !p.config.AllowAnonymous {
+		return userID
 	}
+	if p.config.DefaultUserID != "" {
+		return p.config.DefaultUserID
+	}
+	return defaultAnonymousUserID
+}
 
-	if !models.IsValidContentType(request.ContentType) {
-		return errors.ErrValidationFailed("content_type", fmt.Sprintf("invalid content type: %s", request.ContentType))
+// validateRequest 验证处理请求（允许匿名时先为未指定用户的请求填入默认用户）
+func (p *Processor) validateRequest(request *ProcessingRequest) error {
+	if request.ID == "" {
+		return errors.ErrValidationFailed("id", "cannot be empty")
+	}
+
+	request.UserID = p.resolveUserID(request.UserID)
+
+	// 与预检共用同一套检查，返回第一个不通过的原因
+	if reasons := p.checkContent(request); len(reasons) > 0 {
+		return reasons[0].toError()
 	}
 
 	return nil
@@ -647,8 +1200,8 @@ func (p *Processor) setDefaultOptions(request *ProcessingRequest) {
 
 	options := &request.Options
 
-	// 如果没有明确设置，默认启用所有功能
-	if !options.EnableSummary && !options.EnableTags && !options.EnableClassification && !options.EnableImportanceScore && !options.EnableVectorization {
+	// 设置了预设时由预设决定各阶段开关；否则如果没有明确设置，默认启用所有功能
+	if !applyPreset(options) && !options.EnableSummary && !options.EnableTags && !options.EnableClassification && !options.EnableImportanceScore && !options.EnableVectorization {
 		options.EnableSummary = true
 		options.EnableTags = true
 		options.EnableClassification = true
@@ -660,6 +1213,10 @@ func (p *Processor) setDefaultOptions(request *ProcessingRequest) {
 		options.MaxTags = p.config.TagLimits.MaxTags
 	}
 
+	if p.config.StageMetrics {
+		options.EnableMetrics = true
+	}
+
 	if options.MaxTags > p.config.TagLimits.MaxTags {
 		options.MaxTags = p.config.TagLimits.MaxTags
 	}
@@ -672,6 +1229,7 @@ func (p *Processor) updateRequestStatus(requestID string, status ProcessingStatu
 
 	if result, exists := p.results[requestID]; exists {
 		result.Status = status
+		p.publishStatusLocked(requestID, status, "")
 	}
 }
 
@@ -681,6 +1239,16 @@ func (p *Processor) updateRequestResult(requestID string, result *ProcessingResu
 	defer p.mu.Unlock()
 
 	p.results[requestID] = result
+	p.publishStatusLocked(requestID, result.Status, result.Error)
+}
+
+// finishRequest 保存请求的最终结果，请求设置了回调地址时提交回调
+func (p *Processor) finishRequest(request *ProcessingRequest, result *ProcessingResult) {
+	p.updateRequestResult(request.ID, result)
+
+	if request.CallbackURL != "" {
+		p.callbacks.enqueue(request.CallbackURL, result)
+	}
 }
 
 // waitForResult 等待处理结果
@@ -735,19 +1303,65 @@ func (p *Processor) GetStats() map[string]interface{} {
 		statusCounts[result.Status]++
 	}
 	stats["status_distribution"] = statusCounts
+	stats["token_budget"] = llm.GetTokenBudget().GetStats()
+	stats["callbacks"] = p.callbacks.stats()
 
 	return stats
 }
 
-// Close 关闭处理器
-func (p *Processor) Close() error {
-	p.logger.Info("Shutting down content processor")
+// Close 关闭处理器：停止接收新请求，等待工作协程处理完进行中和已排队的请求，
+// ctx到期后取消仍在进行的处理；已完成请求的结果在关闭后仍可通过GetResult获取
+func (p *Processor) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closing {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closing = true
+	p.mu.Unlock()
+
+	p.logger.Info("Shutting down content processor", logger.Fields{
+		"queued_requests": len(p.requestChan),
+	})
 
 	// 停止接收新请求
 	close(p.stopChan)
 
-	// 等待所有工作协程完成
-	p.workerWg.Wait()
+	// 等待所有工作协程完成，超过排空期限时取消进行中的处理
+	drained := make(chan struct{})
+	go func() {
+		p.workerWg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		p.logger.Warn("Drain deadline exceeded, cancelling in-flight requests", logger.Fields{
+			"error": ctx.Err().Error(),
+		})
+		if p.cancelProcess != nil {
+			p.cancelProcess()
+		}
+		<-drained
+	}
+	if p.cancelProcess != nil {
+		p.cancelProcess()
+	}
+
+	// 尚未执行的延迟线程汇总在下一条消息处理时重新汇总
+	if cancelled := p.threads.close(); cancelled > 0 {
+		p.logger.Info("Pending thread rollups cancelled on shutdown", logger.Fields{
+			"cancelled": cancelled,
+		})
+	}
+
+	// 已完成请求的回调在同一排空期限内发送
+	if err := p.callbacks.Close(ctx); err != nil {
+		p.logger.Warn("Pending callbacks abandoned on shutdown", logger.Fields{
+			"error": err.Error(),
+		})
+	}
 
 	// 关闭依赖组件
 	if p.llmClient != nil {
@@ -768,11 +1382,35 @@ func (p *Processor) Close() error {
 	if p.searchEngine != nil {
 		p.searchEngine.Close()
 	}
+	if p.store != nil {
+		p.store.Close()
+	}
 
 	p.logger.Info("Content processor shut down completed")
 	return nil
 }
 
+// FlushCache 清空内容搜索使用的缓存，返回各类缓存清除的条目数
+func (p *Processor) FlushCache(cacheType string) (map[string]int, error) {
+	if p.searchEngine == nil {
+		return map[string]int{}, nil
+	}
+	return p.searchEngine.FlushCache(cacheType)
+}
+
+// requireSearchEngine 检查向量搜索引擎是否可用，不可用时返回说明原因的功能不可用错误
+func (p *Processor) requireSearchEngine(feature string) error {
+	if p.searchEngine != nil {
+		return nil
+	}
+
+	reason := p.searchEngineErr
+	if reason == "" {
+		reason = "search engine is not initialized"
+	}
+	return errors.ErrFeatureUnavailable(feature, reason)
+}
+
 // SearchContent 搜索内容
 func (p *Processor) SearchContent(ctx context.Context, request *SearchRequest) (*SearchResponse, error) {
 	if request == nil {
@@ -787,28 +1425,54 @@ func (p *Processor) SearchContent(ctx context.Context, request *SearchRequest) (
 		request.TopK = 10 // 默认返回10个结果
 	}
 
+	if request.RankingStrategy != "" && !vector.IsValidRankingStrategy(vector.RankingStrategy(request.RankingStrategy)) {
+		return nil, errors.ErrValidationFailed("ranking_strategy", fmt.Sprintf("unknown ranking strategy: %s", request.RankingStrategy))
+	}
+
+	if request.Mode != "" && !vector.IsValidSearchMode(vector.SearchMode(request.Mode)) {
+		return nil, errors.ErrValidationFailed("mode", fmt.Sprintf("unknown search mode: %s", request.Mode))
+	}
+
+	if request.SnippetSource != "" && !vector.IsValidSnippetSource(vector.SnippetSource(request.SnippetSource)) {
+		return nil, errors.ErrValidationFailed("snippet_source", fmt.Sprintf("unknown snippet source: %s", request.SnippetSource))
+	}
+
+	if err := p.requireSearchEngine("search"); err != nil {
+		return nil, err
+	}
+
 	p.logger.Debug("Searching content", logger.Fields{
-		"query":          request.Query,
-		"user_id":        request.UserID,
-		"top_k":          request.TopK,
-		"min_similarity": request.MinSimilarity,
+		"query":            request.Query,
+		"user_id":          request.UserID,
+		"top_k":            request.TopK,
+		"min_similarity":   request.MinSimilarity,
+		"ranking_strategy": request.RankingStrategy,
 	})
 
 	startTime := time.Now()
 
 	// 构建搜索选项
 	searchOptions := &vector.SearchOptions{
-		Query:               request.Query,
-		ContentTypes:        request.ContentTypes,
-		UserID:              request.UserID,
-		TopK:                request.TopK,
-		MinSimilarity:       request.MinSimilarity,
-		IncludeContent:      true,
-		SimilarityType:      vector.SimilarityTypeCosine,
-		TimeRange:           (*vector.TimeRange)(request.TimeRange),
-		Tags:                request.Tags,
-		EnableReranking:     true,
-		MaxResults:          request.TopK * 2, // 获取更多结果用于重排序
+		Query:                  request.Query,
+		ContentTypes:           request.ContentTypes,
+		UserID:                 request.UserID,
+		TopK:                   request.TopK,
+		MinSimilarity:          request.MinSimilarity,
+		IncludeContent:         true,
+		TimeRange:              (*vector.TimeRange)(request.TimeRange),
+		Tags:                   request.Tags,
+		EnableReranking:        true,
+		RankingStrategy:        vector.RankingStrategy(request.RankingStrategy),
+		Mode:                   vector.SearchMode(request.Mode),
+		IncludeArchived:        request.IncludeArchived,
+		MaxResults:             request.TopK * 2, // 获取更多结果用于重排序
+		CollapseDuplicates:     request.CollapseDuplicates,
+		MinPerContentType:      request.MinPerContentType,
+		Languages:              request.Languages,
+		IncludeUnknownLanguage: request.IncludeUnknownLanguage,
+		EnableLLMRerank:        request.EnableLLMRerank,
+		Fields:                 request.Fields,
+		SnippetSource:          vector.SnippetSource(request.SnippetSource),
 	}
 
 	// 执行搜索
@@ -834,6 +1498,12 @@ func (p *Processor) SearchContent(ctx context.Context, request *SearchRequest) (
 			ContentSummary:  item.ContentSummary,
 			MatchedKeywords: item.MatchedKeywords,
 			CreatedAt:       item.CreatedAt,
+			DuplicateOf:     item.DuplicateOf,
+			Distance:        item.Distance,
+			RawSimilarity:   item.RawSimilarity,
+			NormalizedScore: item.NormalizedScore,
+			SimilarityType:  item.SimilarityType,
+			projectedKeys:   item.ProjectedKeys(),
 		}
 	}
 
@@ -868,6 +1538,10 @@ func (p *Processor) GetRecommendations(ctx context.Context, request *Recommendat
 		request.MaxRecommendations = 5 // 默认返回5个推荐
 	}
 
+	if err := p.requireSearchEngine("recommendations"); err != nil {
+		return nil, err
+	}
+
 	p.logger.Debug("Getting recommendations", logger.Fields{
 		"type":                request.Type,
 		"user_id":             request.UserID,
@@ -887,8 +1561,10 @@ func (p *Processor) GetRecommendations(ctx context.Context, request *Recommendat
 		ContentTypes:        request.ContentTypes,
 		ExcludeDocuments:    request.ExcludeDocuments,
 		MinSimilarity:       request.MinSimilarity,
+		MinConfidence:       request.MinConfidence,
 		DiversityEnabled:    true,
-		IncludeExplanations: false, // 简化版本不包含解释
+		IncludeExplanations: request.IncludeExplanations,
+		MetadataFilters:     request.MetadataFilters,
 	}
 
 	// 执行推荐
@@ -905,17 +1581,7 @@ func (p *Processor) GetRecommendations(ctx context.Context, request *Recommendat
 	// 转换结果格式
 	recommendations := make([]*RecommendationItem, len(recResponse.Recommendations))
 	for i, item := range recResponse.Recommendations {
-		recommendations[i] = &RecommendationItem{
-			DocumentID:          item.DocumentID,
-			Content:             item.Content,
-			Similarity:          item.Similarity,
-			Confidence:          item.Confidence,
-			Rank:                item.Rank,
-			Metadata:            item.Metadata,
-			RecommendationScore: item.RecommendationScore,
-			RelatedKeywords:     item.RelatedKeywords,
-			CreatedAt:           item.CreatedAt,
-		}
+		recommendations[i] = recommendationItemFrom(item)
 	}
 
 	processTime := time.Since(startTime)
@@ -924,7 +1590,8 @@ func (p *Processor) GetRecommendations(ctx context.Context, request *Recommendat
 		Recommendations: recommendations,
 		TotalFound:      len(recommendations),
 		ProcessTime:     processTime,
-		Type:            request.Type,
+		Type:            string(recResponse.RecommendationType),
+		Metadata:        recResponse.Metadata,
 	}
 
 	p.logger.Debug("Recommendations generated", logger.Fields{
@@ -936,10 +1603,168 @@ func (p *Processor) GetRecommendations(ctx context.Context, request *Recommendat
 	return response, nil
 }
 
-// BatchIndexContent 批量索引内容
-func (p *Processor) BatchIndexContent(ctx context.Context, contentItems []*models.ContentItem) error {
+// recommendationItemFrom 将推荐引擎的结果项转换为处理器的推荐项
+func recommendationItemFrom(item *vector.RecommendationItem) *RecommendationItem {
+	return &RecommendationItem{
+		DocumentID:          item.DocumentID,
+		Content:             item.Content,
+		Similarity:          item.Similarity,
+		Confidence:          item.Confidence,
+		Rank:                item.Rank,
+		Metadata:            item.Metadata,
+		RecommendationScore: item.RecommendationScore,
+		RelatedKeywords:     item.RelatedKeywords,
+		CreatedAt:           item.CreatedAt,
+		Explanation:         item.Explanation,
+	}
+}
+
+// GetContent 获取内容项详情，userID不为空时仅返回该用户的内容
+func (p *Processor) GetContent(ctx context.Context, id string, userID string) (*ContentDetail, error) {
+	if id == "" {
+		return nil, errors.ErrValidationFailed("id", "cannot be empty")
+	}
+
+	if p.store == nil {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+
+	item, err := p.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不属于该用户的内容按不存在处理，避免泄露其他用户的内容ID
+	if userID != "" && item.UserID != userID {
+		return nil, errors.ErrResourceNotFound("content", id)
+	}
+
+	detail := contentDetailOf(item)
+	if p.searchEngine != nil {
+		detail.Vector = p.getVectorStatus(ctx, id)
+		if userID != "" {
+			p.searchEngine.RecordInteraction(userID, id)
+		}
+	}
+
+	return detail, nil
+}
+
+// GetSummary 获取内容项已保存的多级摘要，level为空时返回全部级别，否则只返回指定级别；内容或摘要不存在时返回资源不存在错误
+func (p *Processor) GetSummary(ctx context.Context, id string, userID string, level SummaryLevel) (*models.Summary, error) {
+	if id == "" {
+		return nil, errors.ErrValidationFailed("id", "cannot be empty")
+	}
+
+	if p.store == nil {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+
+	item, err := p.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不属于该用户的内容按不存在处理，避免泄露其他用户的内容ID
+	if userID != "" && item.UserID != userID {
+		return nil, errors.ErrResourceNotFound("content", id)
+	}
+
+	summary, exists, err := selectSummaryLevel(item.GetSummary(), level)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.ErrResourceNotFound("summary", id)
+	}
+
+	return &summary, nil
+}
+
+// ListRecent 按创建时间倒序列出用户的内容（不经过向量搜索），before为上一页返回的游标，零值表示从最新开始
+func (p *Processor) ListRecent(ctx context.Context, userID string, limit int, before time.Time) (*TimelinePage, error) {
+	userID = p.resolveUserID(userID)
+	if userID == "" {
+		return nil, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+	if limit <= 0 {
+		limit = defaultTimelineLimit
+	}
+	if limit > maxTimelineLimit {
+		return nil, errors.ErrValidationFailed("limit", fmt.Sprintf("must not exceed %d", maxTimelineLimit))
+	}
+
+	if p.store == nil {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+
+	// 多取一条判断是否还有下一页
+	items, err := p.store.ListByUser(ctx, userID, limit+1, before)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TimelinePage{Items: make([]*ContentDetail, 0, limit)}
+	if len(items) > limit {
+		page.HasMore = true
+		items = items[:limit]
+	}
+	for _, item := range items {
+		page.Items = append(page.Items, contentDetailOf(item))
+	}
+	if page.HasMore {
+		nextBefore := items[len(items)-1].CreatedAt
+		page.NextBefore = &nextBefore
+	}
+
+	return page, nil
+}
+
+// contentDetailOf 将存储的内容项转换为内容详情（不含向量状态）
+func contentDetailOf(item *models.ContentItem) *ContentDetail {
+	return &ContentDetail{
+		ID:              item.ID,
+		Type:            item.Type,
+		RawContent:      item.RawContent,
+		UserID:          item.UserID,
+		Summary:         item.GetSummary(),
+		Tags:            item.GetTags(),
+		ImportanceScore: item.GetImportanceScore(),
+		ProcessedData:   item.GetProcessedData(),
+		CreatedAt:       item.CreatedAt,
+		UpdatedAt:       item.UpdatedAt,
+	}
+}
+
+// getVectorStatus 从向量库获取内容的索引状态
+func (p *Processor) getVectorStatus(ctx context.Context, id string) *VectorStatus {
+	doc, err := p.searchEngine.GetDocument(ctx, id)
+	if err != nil {
+		if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeResourceNotFound) {
+			return &VectorStatus{Indexed: false}
+		}
+		p.logger.Warn("Failed to get vector status", logger.Fields{
+			"content_id": id,
+			"error":      err.Error(),
+		})
+		return &VectorStatus{Indexed: false, Error: err.Error()}
+	}
+
+	return &VectorStatus{
+		Indexed:         true,
+		VectorDimension: len(doc.Embedding),
+		Metadata:        doc.Metadata,
+	}
+}
+
+// BatchIndexContent 批量索引内容，返回逐文档的索引报告
+func (p *Processor) BatchIndexContent(ctx context.Context, contentItems []*models.ContentItem) (*vector.BatchIndexReport, error) {
 	if len(contentItems) == 0 {
-		return errors.ErrValidationFailed("content_items", "cannot be empty")
+		return nil, errors.ErrValidationFailed("content_items", "cannot be empty")
+	}
+
+	if err := p.requireSearchEngine("indexing"); err != nil {
+		return nil, err
 	}
 
 	p.logger.Info("Batch indexing content", logger.Fields{
@@ -955,6 +1780,10 @@ func (p *Processor) DeleteFromIndex(ctx context.Context, documentID string) erro
 		return errors.ErrValidationFailed("document_id", "cannot be empty")
 	}
 
+	if err := p.requireSearchEngine("indexing"); err != nil {
+		return err
+	}
+
 	p.logger.Debug("Deleting document from index", logger.Fields{
 		"document_id": documentID,
 	})
@@ -962,7 +1791,147 @@ func (p *Processor) DeleteFromIndex(ctx context.Context, documentID string) erro
 	return p.searchEngine.DeleteDocument(ctx, documentID)
 }
 
+// UpdateContentMetadata 更新内容的标签和重要性，只修改索引元数据而不重新向量化
+func (p *Processor) UpdateContentMetadata(ctx context.Context, documentID string, patch *ContentMetadataPatch) error {
+	if documentID == "" {
+		return errors.ErrValidationFailed("document_id", "cannot be empty")
+	}
+
+	metadata, err := p.metadataPatchFields(patch)
+	if err != nil {
+		return err
+	}
+
+	if err := p.requireSearchEngine("indexing"); err != nil {
+		return err
+	}
+
+	p.logger.Debug("Updating content metadata", logger.Fields{
+		"document_id":   documentID,
+		"metadata_keys": len(metadata),
+	})
+
+	return p.searchEngine.UpdateDocumentMetadata(ctx, documentID, metadata)
+}
+
+// metadataPatchFields 校验补丁并转换为索引元数据（标签去空白和重复），补丁中没有任何字段时返回校验错误
+func (p *Processor) metadataPatchFields(patch *ContentMetadataPatch) (map[string]interface{}, error) {
+	if patch == nil || (patch.Tags == nil && patch.ImportanceScore == nil && patch.Pinned == nil) {
+		return nil, errors.ErrValidationFailed("patch", "must contain tags, importance_score or pinned")
+	}
+
+	metadata := make(map[string]interface{})
+
+	if patch.Tags != nil {
+		tags := make([]string, 0, len(patch.Tags))
+		seen := make(map[string]bool)
+		for _, tag := range patch.Tags {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+
+		if maxTags := p.config.TagLimits.MaxTags; maxTags > 0 && len(tags) > maxTags {
+			return nil, errors.ErrValidationFailed("tags", fmt.Sprintf("cannot have more than %d tags", maxTags))
+		}
+		metadata["tags"] = tags
+		metadata[vector.MetadataKeyHasTags] = len(tags) > 0
+	}
+
+	if patch.ImportanceScore != nil {
+		score := *patch.ImportanceScore
+		if math.IsNaN(score) || score < 0.0 || score > 1.0 {
+			return nil, errors.ErrValidationFailed("importance_score", fmt.Sprintf("must be between 0.0 and 1.0, got %f", score))
+		}
+		metadata["importance_score"] = score
+	}
+
+	if patch.Pinned != nil {
+		metadata[vector.MetadataKeyPinned] = *patch.Pinned
+	}
+
+	return metadata, nil
+}
+
+// PatchContent 按补丁修改内容项的标签、重要性和置顶状态，同时更新数据库和索引元数据（不重新向量化），返回修改后的内容详情。
+// 补丁中未设置的字段保持不变；不属于该用户的内容按不存在处理；内容未建立索引时只更新数据库
+func (p *Processor) PatchContent(ctx context.Context, id string, userID string, patch *ContentMetadataPatch) (*ContentDetail, error) {
+	if id == "" {
+		return nil, errors.ErrValidationFailed("id", "cannot be empty")
+	}
+
+	metadata, err := p.metadataPatchFields(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.store == nil {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+
+	item, err := p.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if userID != "" && item.UserID != userID {
+		return nil, errors.ErrResourceNotFound("content", id)
+	}
+
+	if tags, ok := metadata["tags"].([]string); ok {
+		if err := item.SetTags(tags); err != nil {
+			return nil, err
+		}
+	}
+	if patch.ImportanceScore != nil {
+		if err := item.SetImportanceScore(*patch.ImportanceScore); err != nil {
+			return nil, err
+		}
+	}
+	if patch.Pinned != nil {
+		processedData := item.GetProcessedData()
+		processedData[vector.MetadataKeyPinned] = *patch.Pinned
+		if err := item.SetProcessedData(processedData); err != nil {
+			return nil, err
+		}
+	}
+
+	// 先更新索引，索引更新失败时数据库保持原状
+	if p.searchEngine != nil {
+		err := p.searchEngine.UpdateDocumentMetadata(ctx, id, metadata)
+		if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeResourceNotFound) {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.store.Save(ctx, item); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Content patched", logger.Fields{
+		"content_id":    id,
+		"user_id":       item.UserID,
+		"update_tags":   patch.Tags != nil,
+		"update_score":  patch.ImportanceScore != nil,
+		"update_pinned": patch.Pinned != nil,
+	})
+
+	detail := contentDetailOf(item)
+	if p.searchEngine != nil {
+		detail.Vector = p.getVectorStatus(ctx, id)
+	}
+	return detail, nil
+}
+
 // GetVectorStats 获取向量数据库统计信息
 func (p *Processor) GetVectorStats(ctx context.Context) (map[string]interface{}, error) {
+	if err := p.requireSearchEngine("search stats"); err != nil {
+		return nil, err
+	}
 	return p.searchEngine.GetSearchStats(ctx)
 }
@@ -0,0 +1,2005 @@
+package content
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/llm"
+	"memoro/internal/services/vector"
+	"memoro/internal/storage"
+)
+
+// newTestProcessor 创建不依赖LLM和向量库的测试处理器
+func newTestProcessor(t *testing.T) *Processor {
+	processingConfig := config.ProcessingConfig{
+		MaxWorkers:     1,
+		QueueSize:      10,
+		Timeout:        10 * time.Second,
+		MaxContentSize: 100000,
+		TagLimits: config.TagLimitsConfig{
+			MaxTags: 10,
+		},
+	}
+
+	extractor := &ExtractorManager{
+		extractors: make(map[models.ContentType]Extractor),
+		config:     processingConfig,
+		logger:     logger.NewLogger("extractor-manager-test"),
+	}
+	require.NoError(t, extractor.registerExtractors())
+
+	return &Processor{
+		config:         processingConfig,
+		extractor:      extractor,
+		logger:         logger.NewLogger("content-processor-test"),
+		activeRequests: make(map[string]*ProcessingRequest),
+		results:        make(map[string]*ProcessingResult),
+		canonical:      newCanonicalLocks(),
+		requestChan:    make(chan *ProcessingRequest, processingConfig.QueueSize),
+		stopChan:       make(chan struct{}),
+	}
+}
+
+// newTestStore 创建内存数据库的内容存储
+func newTestStore(t *testing.T) *storage.ContentStore {
+	store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// TestProcessor_CanonicalURLDedup 测试按规范URL去重
+func TestProcessor_CanonicalURLDedup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>Go并发编程实践</title></head><body><p>本文介绍Go语言的并发模型与最佳实践。</p></body></html>`)
+	}))
+	defer server.Close()
+
+	t.Run("跟踪参数变体去重为同一文档", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.store = newTestStore(t)
+		ctx := context.Background()
+
+		first, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID:          "req-1",
+			Content:     server.URL + "/article/1?utm_source=wechat&utm_medium=share",
+			ContentType: models.ContentTypeLink,
+			UserID:      "user-1",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, first.ContentItem)
+		assert.Empty(t, first.DuplicateOf)
+
+		second, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID:          "req-2",
+			Content:     server.URL + "/article/1/?fbclid=abc123&utm_source=weibo#comments",
+			ContentType: models.ContentTypeLink,
+			UserID:      "user-1",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, first.ContentItem.ID, second.DuplicateOf)
+		assert.Equal(t, first.ContentItem.ID, second.ContentItem.ID)
+
+		saved, err := processor.store.Get(ctx, first.ContentItem.ID)
+		require.NoError(t, err)
+		assert.Equal(t, CanonicalizeURL(server.URL+"/article/1"), saved.CanonicalURL)
+	})
+
+	t.Run("重启后仍按存储去重", func(t *testing.T) {
+		store := newTestStore(t)
+		ctx := context.Background()
+		// 每次使用新的处理器，模拟服务重启
+		restarted := func() *Processor {
+			processor := newTestProcessor(t)
+			processor.store = store
+			return processor
+		}
+
+		first, err := restarted().doProcessing(ctx, &ProcessingRequest{
+			ID: "req-6", Content: server.URL + "/article/4", ContentType: models.ContentTypeLink, UserID: "user-1",
+		})
+		require.NoError(t, err)
+
+		second, err := restarted().doProcessing(ctx, &ProcessingRequest{
+			ID: "req-7", Content: server.URL + "/article/4?utm_source=rss", ContentType: models.ContentTypeLink, UserID: "user-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, first.ContentItem.ID, second.DuplicateOf)
+	})
+
+	t.Run("保留来源信息", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		originalURL := server.URL + "/article/2?utm_campaign=spring"
+
+		result, err := processor.doProcessing(context.Background(), &ProcessingRequest{
+			ID:          "req-3",
+			Content:     originalURL,
+			ContentType: models.ContentTypeLink,
+			UserID:      "user-1",
+		})
+		require.NoError(t, err)
+
+		provenance, ok := result.ContentItem.GetProcessedData()["provenance"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, originalURL, provenance["original_url"])
+		assert.Equal(t, CanonicalizeURL(server.URL+"/article/2"), provenance["canonical_url"])
+		assert.Equal(t, http.StatusOK, provenance["status_code"])
+		assert.NotNil(t, provenance["fetched_at"])
+	})
+
+	t.Run("不同用户不去重", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.store = newTestStore(t)
+		ctx := context.Background()
+
+		first, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID: "req-4", Content: server.URL + "/article/3", ContentType: models.ContentTypeLink, UserID: "user-1",
+		})
+		require.NoError(t, err)
+
+		second, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID: "req-5", Content: server.URL + "/article/3", ContentType: models.ContentTypeLink, UserID: "user-2",
+		})
+		require.NoError(t, err)
+
+		assert.Empty(t, second.DuplicateOf)
+		assert.NotEqual(t, first.ContentItem.ID, second.ContentItem.ID)
+	})
+}
+
+// TestProcessor_RelCanonical 测试优先使用rel=canonical
+func TestProcessor_RelCanonical(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>文章</title><link rel="canonical" href="/posts/go-concurrency"></head><body>内容正文</body></html>`)
+	}))
+	defer server.Close()
+
+	processor := newTestProcessor(t)
+	processor.store = newTestStore(t)
+	ctx := context.Background()
+
+	first, err := processor.doProcessing(ctx, &ProcessingRequest{
+		ID: "req-1", Content: server.URL + "/m/12345", ContentType: models.ContentTypeLink, UserID: "user-1",
+	})
+	require.NoError(t, err)
+
+	second, err := processor.doProcessing(ctx, &ProcessingRequest{
+		ID: "req-2", Content: server.URL + "/desktop/12345", ContentType: models.ContentTypeLink, UserID: "user-1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, first.ContentItem.ID, second.DuplicateOf)
+}
+
+// TestProcessor_UpdateContentMetadata 测试元数据补丁校验
+func TestProcessor_UpdateContentMetadata(t *testing.T) {
+	processor := newTestProcessor(t)
+	ctx := context.Background()
+
+	t.Run("补丁不能为空", func(t *testing.T) {
+		assert.Error(t, processor.UpdateContentMetadata(ctx, "doc-1", nil))
+		assert.Error(t, processor.UpdateContentMetadata(ctx, "doc-1", &ContentMetadataPatch{}))
+	})
+
+	t.Run("文档ID不能为空", func(t *testing.T) {
+		assert.Error(t, processor.UpdateContentMetadata(ctx, "", &ContentMetadataPatch{Tags: []string{"go"}}))
+	})
+
+	t.Run("重要性超出范围", func(t *testing.T) {
+		score := 1.5
+		assert.Error(t, processor.UpdateContentMetadata(ctx, "doc-1", &ContentMetadataPatch{ImportanceScore: &score}))
+	})
+
+	t.Run("标签数量超出限制", func(t *testing.T) {
+		tags := make([]string, 0, 11)
+		for i := 0; i < 11; i++ {
+			tags = append(tags, fmt.Sprintf("tag-%d", i))
+		}
+		assert.Error(t, processor.UpdateContentMetadata(ctx, "doc-1", &ContentMetadataPatch{Tags: tags}))
+	})
+}
+
+// TestProcessor_GetContent 测试从存储读取内容详情
+func TestProcessor_GetContent(t *testing.T) {
+	store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	processor := newTestProcessor(t)
+	processor.store = store
+	ctx := context.Background()
+
+	item := models.NewContentItem(models.ContentTypeText, "Go语言并发编程实践", "user-1")
+	require.NotNil(t, item)
+	item.SetSummary(models.Summary{OneLine: "Go并发", Paragraph: "介绍goroutine和channel", Detailed: "详细介绍"})
+	item.SetTags([]string{"go", "并发"})
+	item.SetImportanceScore(0.8)
+	require.NoError(t, store.Save(ctx, item))
+
+	t.Run("返回完整详情", func(t *testing.T) {
+		detail, err := processor.GetContent(ctx, item.ID, "user-1")
+		require.NoError(t, err)
+
+		assert.Equal(t, "Go语言并发编程实践", detail.RawContent)
+		assert.Equal(t, "介绍goroutine和channel", detail.Summary.Paragraph)
+		assert.Equal(t, []string{"go", "并发"}, detail.Tags)
+		assert.Equal(t, 0.8, detail.ImportanceScore)
+		assert.Nil(t, detail.Vector)
+	})
+
+	t.Run("不存在返回未找到错误", func(t *testing.T) {
+		_, err := processor.GetContent(ctx, "missing", "")
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+	})
+
+	t.Run("其他用户的内容按不存在处理", func(t *testing.T) {
+		_, err := processor.GetContent(ctx, item.ID, "user-2")
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+	})
+}
+
+// TestProcessor_PatchContent 测试部分更新同时修改数据库和索引元数据且不重新向量化
+func TestProcessor_PatchContent(t *testing.T) {
+	embeddings := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected model call: %s", r.URL.Path)
+		http.Error(w, "unexpected", http.StatusInternalServerError)
+	}))
+	defer embeddings.Close()
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: embeddings.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+	}))
+
+	store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	vectors := vector.NewMemoryStore()
+	engine, err := vector.NewSearchEngineWithStore(vectors)
+	require.NoError(t, err)
+	defer engine.Close()
+
+	processor := newTestProcessor(t)
+	processor.store = store
+	processor.searchEngine = engine
+	ctx := context.Background()
+
+	item := models.NewContentItem(models.ContentTypeText, "Go语言并发编程实践", "user-1")
+	require.NotNil(t, item)
+	item.SetTags([]string{"go"})
+	item.SetImportanceScore(0.5)
+	require.NoError(t, store.Save(ctx, item))
+	require.NoError(t, vectors.AddDocument(ctx, &vector.VectorDocument{
+		ID:        item.ID,
+		Content:   item.RawContent,
+		Embedding: []float32{1, 0, 0},
+		Metadata:  map[string]interface{}{"user_id": "user-1", "tags": []string{"go"}, "importance_score": 0.5},
+	}))
+
+	t.Run("只修改标签", func(t *testing.T) {
+		detail, err := processor.PatchContent(ctx, item.ID, "user-1", &ContentMetadataPatch{Tags: []string{"go", " 并发 ", "go"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go", "并发"}, detail.Tags)
+		assert.Equal(t, 0.5, detail.ImportanceScore)
+
+		stored, err := store.Get(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go", "并发"}, stored.GetTags())
+
+		doc, err := vectors.GetDocument(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go", "并发"}, doc.Metadata["tags"])
+		assert.Equal(t, []float32{1, 0, 0}, doc.Embedding)
+	})
+
+	t.Run("修改重要性和置顶", func(t *testing.T) {
+		score := 0.9
+		pinned := true
+		detail, err := processor.PatchContent(ctx, item.ID, "", &ContentMetadataPatch{ImportanceScore: &score, Pinned: &pinned})
+		require.NoError(t, err)
+		assert.Equal(t, 0.9, detail.ImportanceScore)
+		assert.Equal(t, []string{"go", "并发"}, detail.Tags)
+		assert.Equal(t, true, detail.ProcessedData[vector.MetadataKeyPinned])
+
+		doc, err := vectors.GetDocument(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0.9, doc.Metadata["importance_score"])
+		assert.Equal(t, true, doc.Metadata[vector.MetadataKeyPinned])
+	})
+
+	t.Run("无效的重要性不修改任何数据", func(t *testing.T) {
+		score := 1.5
+		_, err := processor.PatchContent(ctx, item.ID, "", &ContentMetadataPatch{ImportanceScore: &score})
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeValidationFailed))
+
+		stored, err := store.Get(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0.9, stored.ImportanceScore)
+	})
+
+	t.Run("其他用户的内容按不存在处理", func(t *testing.T) {
+		pinned := false
+		_, err := processor.PatchContent(ctx, item.ID, "user-2", &ContentMetadataPatch{Pinned: &pinned})
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+	})
+}
+
+// TestProcessor_GetSummary 测试从存储读取多级摘要
+func TestProcessor_GetSummary(t *testing.T) {
+	store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	processor := newTestProcessor(t)
+	processor.store = store
+	ctx := context.Background()
+
+	item := models.NewContentItem(models.ContentTypeText, "Go语言并发编程实践", "user-1")
+	require.NotNil(t, item)
+	item.SetSummary(models.Summary{OneLine: "Go并发", Paragraph: "介绍goroutine和channel"})
+	require.NoError(t, store.Save(ctx, item))
+
+	unsummarized := models.NewContentItem(models.ContentTypeText, "还没有生成摘要的内容", "user-1")
+	require.NotNil(t, unsummarized)
+	require.NoError(t, store.Save(ctx, unsummarized))
+
+	t.Run("返回全部级别", func(t *testing.T) {
+		summary, err := processor.GetSummary(ctx, item.ID, "user-1", "")
+		require.NoError(t, err)
+		assert.Equal(t, models.Summary{OneLine: "Go并发", Paragraph: "介绍goroutine和channel"}, *summary)
+	})
+
+	t.Run("只返回指定级别", func(t *testing.T) {
+		summary, err := processor.GetSummary(ctx, item.ID, "", SummaryLevelParagraph)
+		require.NoError(t, err)
+		assert.Equal(t, models.Summary{Paragraph: "介绍goroutine和channel"}, *summary)
+	})
+
+	t.Run("摘要或指定级别不存在返回未找到错误", func(t *testing.T) {
+		_, err := processor.GetSummary(ctx, unsummarized.ID, "", "")
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+
+		_, err = processor.GetSummary(ctx, item.ID, "", SummaryLevelDetailed)
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+
+		_, err = processor.GetSummary(ctx, item.ID, "user-2", "")
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+	})
+
+	t.Run("无效级别返回验证错误", func(t *testing.T) {
+		_, err := processor.GetSummary(ctx, item.ID, "", "full")
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeValidationFailed))
+	})
+}
+
+// TestProcessor_ListRecent 测试按时间倒序列出内容
+func TestProcessor_ListRecent(t *testing.T) {
+	store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	processor := newTestProcessor(t)
+	processor.store = store
+	ctx := context.Background()
+
+	// 按乱序写入5条内容，创建时间间隔1分钟；另有一条其他用户的内容
+	baseTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	expected := make([]string, 5)
+	for _, i := range []int{2, 0, 4, 1, 3} {
+		item := models.NewContentItem(models.ContentTypeText, fmt.Sprintf("内容-%d", i), "user-1")
+		require.NotNil(t, item)
+		item.CreatedAt = baseTime.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, store.Save(ctx, item))
+		expected[4-i] = item.ID
+	}
+	other := models.NewContentItem(models.ContentTypeText, "其他用户的内容", "user-2")
+	require.NotNil(t, other)
+	require.NoError(t, store.Save(ctx, other))
+	// 线程汇总文档不出现在时间线中
+	rollup := models.NewContentItem(models.ContentTypeText, "内容-0\n\n内容-1", "user-1")
+	require.NotNil(t, rollup)
+	rollup.ID = ThreadDocumentID("user-1", "chat-42")
+	require.NoError(t, store.Save(ctx, rollup))
+
+	ids := func(page *TimelinePage) []string {
+		result := make([]string, 0, len(page.Items))
+		for _, item := range page.Items {
+			result = append(result, item.ID)
+		}
+		return result
+	}
+
+	t.Run("新的在前", func(t *testing.T) {
+		page, err := processor.ListRecent(ctx, "user-1", 10, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, expected, ids(page))
+		assert.False(t, page.HasMore)
+		assert.Nil(t, page.NextBefore)
+	})
+
+	t.Run("before游标分页不重叠", func(t *testing.T) {
+		var collected []string
+		before := time.Time{}
+		for pages := 0; pages < 10; pages++ {
+			page, err := processor.ListRecent(ctx, "user-1", 2, before)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(page.Items), 2)
+			collected = append(collected, ids(page)...)
+			if !page.HasMore {
+				break
+			}
+			require.NotNil(t, page.NextBefore)
+			before = *page.NextBefore
+		}
+		assert.Equal(t, expected, collected)
+	})
+
+	t.Run("参数校验", func(t *testing.T) {
+		_, err := processor.ListRecent(ctx, "", 10, time.Time{})
+		assert.Error(t, err)
+		_, err = processor.ListRecent(ctx, "user-1", maxTimelineLimit+1, time.Time{})
+		assert.Error(t, err)
+	})
+}
+
+// TestProcessor_ValidateContent 测试内容预检
+func TestProcessor_ValidateContent(t *testing.T) {
+	processor := newTestProcessor(t)
+	processor.config.MaxContentSize = 1000
+	processor.config.MinContentLength = 5
+	ctx := context.Background()
+
+	validate := func(content string) *ValidationVerdict {
+		verdict, err := processor.ValidateContent(ctx, &ProcessingRequest{
+			Content:     content,
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+		})
+		require.NoError(t, err)
+		return verdict
+	}
+
+	t.Run("有效内容通过预检", func(t *testing.T) {
+		verdict := validate("Go语言并发编程实践")
+		assert.True(t, verdict.Accepted)
+		assert.Empty(t, verdict.Reasons)
+		assert.Equal(t, 1000, verdict.MaxContentSize)
+		assert.Greater(t, verdict.EstimatedTokens, 0)
+	})
+
+	t.Run("空内容", func(t *testing.T) {
+		verdict := validate("")
+		assert.False(t, verdict.Accepted)
+		require.Len(t, verdict.Reasons, 1)
+		assert.Equal(t, "content", verdict.Reasons[0].Field)
+		assert.Equal(t, ReasonEmpty, verdict.Reasons[0].Code)
+	})
+
+	t.Run("内容过大", func(t *testing.T) {
+		verdict := validate(strings.Repeat("a", 1001))
+		assert.False(t, verdict.Accepted)
+		require.Len(t, verdict.Reasons, 1)
+		assert.Equal(t, ReasonTooLarge, verdict.Reasons[0].Code)
+		assert.Equal(t, 1001, verdict.ContentSize)
+	})
+
+	t.Run("内容过短", func(t *testing.T) {
+		verdict := validate("Go")
+		assert.False(t, verdict.Accepted)
+		require.Len(t, verdict.Reasons, 1)
+		assert.Equal(t, ReasonTooShort, verdict.Reasons[0].Code)
+	})
+
+	t.Run("返回全部不通过的原因", func(t *testing.T) {
+		verdict, err := processor.ValidateContent(ctx, &ProcessingRequest{ContentType: "unknown"})
+		require.NoError(t, err)
+		assert.False(t, verdict.Accepted)
+
+		fields := make([]string, 0, len(verdict.Reasons))
+		for _, reason := range verdict.Reasons {
+			fields = append(fields, reason.Field)
+		}
+		assert.Equal(t, []string{"content", "user_id", "content_type"}, fields)
+	})
+
+	t.Run("自定义元数据无效", func(t *testing.T) {
+		request := &ProcessingRequest{
+			ID:          "content-1",
+			Content:     "Go语言并发编程实践",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Metadata:    map[string]interface{}{"project": map[string]interface{}{"id": 42}},
+		}
+		verdict, err := processor.ValidateContent(ctx, request)
+		require.NoError(t, err)
+		assert.False(t, verdict.Accepted)
+		require.Len(t, verdict.Reasons, 1)
+		assert.Equal(t, ReasonInvalidMetadata, verdict.Reasons[0].Code)
+
+		err = processor.validateRequest(request)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "metadata.project")
+
+		request.Metadata = map[string]interface{}{"source": "slack", "project_id": 42}
+		assert.NoError(t, processor.validateRequest(request))
+	})
+
+	t.Run("回调地址无效", func(t *testing.T) {
+		request := &ProcessingRequest{
+			ID:          "content-1",
+			Content:     "Go语言并发编程实践",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			CallbackURL: "ftp://example.com/hook",
+		}
+		verdict, err := processor.ValidateContent(ctx, request)
+		require.NoError(t, err)
+		require.Len(t, verdict.Reasons, 1)
+		assert.Equal(t, "callback_url", verdict.Reasons[0].Field)
+		assert.Equal(t, ReasonInvalidURL, verdict.Reasons[0].Code)
+
+		request.CallbackURL = "https://example.com/hook"
+		assert.NoError(t, processor.validateRequest(request))
+	})
+
+	t.Run("额度不足", func(t *testing.T) {
+		budgeted := newTestProcessor(t)
+		budgeted.budget = llm.NewTokenBudget(config.TokenBudgetConfig{UserTotalLimit: 5})
+
+		verdict, err := budgeted.ValidateContent(ctx, &ProcessingRequest{
+			Content:     strings.Repeat("并发编程", 20),
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+		})
+		require.NoError(t, err)
+		assert.False(t, verdict.Accepted)
+		require.Len(t, verdict.Reasons, 1)
+		assert.Equal(t, ReasonQuotaExceeded, verdict.Reasons[0].Code)
+	})
+
+	t.Run("与实际处理使用相同校验", func(t *testing.T) {
+		err := processor.validateRequest(&ProcessingRequest{
+			ID:          "content-1",
+			Content:     strings.Repeat("a", 1001),
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+		})
+		require.Error(t, err)
+		memoErr, ok := err.(*errors.MemoroError)
+		require.True(t, ok)
+		assert.Equal(t, errors.ErrCodeValidationFailed, memoErr.Code)
+		assert.Contains(t, memoErr.Details, "content too large")
+
+		assert.NoError(t, processor.validateRequest(&ProcessingRequest{
+			ID:          "content-2",
+			Content:     "Go语言并发编程实践",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+		}))
+	})
+}
+
+// failingClassifier 总是分类失败的测试分类器
+type failingClassifier struct{}
+
+func (failingClassifier) Classify(ctx context.Context, content *ExtractedContent) (*ClassificationResult, error) {
+	return nil, errors.NewMemoroError(errors.ErrorTypeLLM, errors.ErrCodeLLMAPICall, "LLM unavailable")
+}
+
+func (failingClassifier) CalculateImportance(ctx context.Context, content *ExtractedContent) (float64, error) {
+	return 0, errors.NewMemoroError(errors.ErrorTypeLLM, errors.ErrCodeLLMAPICall, "LLM unavailable")
+}
+
+func (failingClassifier) Close() error {
+	return nil
+}
+
+// TestProcessor_DefaultImportanceScore 测试分类失败时使用配置的默认重要性评分
+func TestProcessor_DefaultImportanceScore(t *testing.T) {
+	process := func(t *testing.T, processor *Processor) *ProcessingResult {
+		processor.classifier = failingClassifier{}
+		result, err := processor.doProcessing(context.Background(), &ProcessingRequest{
+			ID:          "req-1",
+			Content:     "Go语言并发编程实践",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options:     ProcessingOptions{EnableClassification: true, EnableImportanceScore: true},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("使用配置的默认评分并标记来源", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.config.DefaultImportanceScore = 0.3
+
+		result := process(t, processor)
+		assert.Equal(t, 0.3, result.ImportanceScore)
+		assert.Equal(t, 0.3, result.ContentItem.ImportanceScore)
+		assert.Equal(t, string(ImportanceSourceDefault), result.ContentItem.GetProcessedData()[ImportanceSourceKey])
+	})
+
+	t.Run("未配置时使用0.5", func(t *testing.T) {
+		result := process(t, newTestProcessor(t))
+		assert.Equal(t, fallbackImportanceScore, result.ImportanceScore)
+		assert.Equal(t, string(ImportanceSourceDefault), result.ContentItem.GetProcessedData()[ImportanceSourceKey])
+	})
+}
+
+// slowClassifier 等待上下文结束的测试分类器（模拟卡住的LLM调用）
+type slowClassifier struct{}
+
+func (slowClassifier) Classify(ctx context.Context, content *ExtractedContent) (*ClassificationResult, error) {
+	<-ctx.Done()
+	return nil, errors.NewMemoroError(errors.ErrorTypeLLM, errors.ErrCodeLLMAPICall, "LLM call aborted").WithCause(ctx.Err())
+}
+
+func (slowClassifier) CalculateImportance(ctx context.Context, content *ExtractedContent) (float64, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func (slowClassifier) Close() error {
+	return nil
+}
+
+// slowExtractor 等待上下文结束的测试提取器
+type slowExtractor struct{}
+
+func (slowExtractor) Extract(ctx context.Context, rawContent string, contentType models.ContentType) (*ExtractedContent, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (slowExtractor) CanHandle(contentType models.ContentType) bool {
+	return true
+}
+
+func (slowExtractor) GetSupportedTypes() []models.ContentType {
+	return []models.ContentType{models.ContentTypeText}
+}
+
+func (slowExtractor) Close() error {
+	return nil
+}
+
+// TestProcessor_StageTimeouts 测试单个阶段超过阶段超时时快速失败并报告阶段
+func TestProcessor_StageTimeouts(t *testing.T) {
+	request := func() *ProcessingRequest {
+		return &ProcessingRequest{
+			ID:          "req-1",
+			Content:     "Go语言并发编程实践",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options:     ProcessingOptions{EnableClassification: true, EnableImportanceScore: true},
+		}
+	}
+
+	t.Run("提取阶段超时返回阶段超时错误", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.config.StageTimeouts.Extract = 50 * time.Millisecond
+		processor.extractor.extractors[models.ContentTypeText] = slowExtractor{}
+
+		ctx, cancel := context.WithTimeout(context.Background(), processor.config.Timeout)
+		defer cancel()
+
+		start := time.Now()
+		_, err := processor.doProcessing(ctx, request())
+		require.Error(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+
+		memoErr, ok := err.(*errors.MemoroError)
+		require.True(t, ok)
+		assert.True(t, memoErr.IsCode(errors.ErrCodeStageTimeout))
+		assert.Contains(t, memoErr.Details, "stage 'extract' exceeded 50ms")
+		assert.Equal(t, StageExtract, timedOutStage(err))
+	})
+
+	t.Run("失败结果报告超时阶段", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.config.StageTimeouts.Extract = 50 * time.Millisecond
+		processor.extractor.extractors[models.ContentTypeText] = slowExtractor{}
+
+		processor.processRequest(processor.logger, request())
+
+		result, err := processor.GetResult("req-1")
+		require.NoError(t, err)
+		assert.Equal(t, StatusFailed, result.Status)
+		assert.Equal(t, StageExtract, result.TimedOutStage)
+	})
+
+	t.Run("分类阶段超时使用默认评分继续处理", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.config.StageTimeouts.Classify = 50 * time.Millisecond
+		processor.classifier = slowClassifier{}
+
+		result, err := processor.doProcessing(context.Background(), request())
+		require.NoError(t, err)
+		assert.Equal(t, StageClassify, result.TimedOutStage)
+		assert.Equal(t, fallbackImportanceScore, result.ImportanceScore)
+	})
+
+	t.Run("总超时先到时不报告阶段超时", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.config.StageTimeouts.Extract = time.Minute
+		processor.extractor.extractors[models.ContentTypeText] = slowExtractor{}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := processor.doProcessing(ctx, request())
+		require.Error(t, err)
+		assert.Equal(t, ProcessingStage(""), timedOutStage(err))
+	})
+}
+
+// TestMergeNeighborTags 测试邻居标签与LLM标签合并
+func TestMergeNeighborTags(t *testing.T) {
+	suggestions := []*vector.TagSuggestion{
+		{Tag: "devops", Score: 0.9, Sources: 3},
+		{Tag: "Kubernetes", Score: 0.6, Sources: 2},
+		{Tag: "ci", Score: 0.2, Sources: 1},
+	}
+
+	t.Run("继承相似文档的标签", func(t *testing.T) {
+		merged, inherited := mergeNeighborTags([]string{"kubernetes", "容器"}, suggestions, 0.3, 10)
+		assert.Equal(t, []string{"kubernetes", "容器", "devops"}, merged)
+		assert.Equal(t, []string{"devops"}, inherited)
+	})
+
+	t.Run("不超过最大标签数", func(t *testing.T) {
+		merged, inherited := mergeNeighborTags([]string{"kubernetes", "容器"}, suggestions, 0, 3)
+		assert.Equal(t, []string{"kubernetes", "容器", "devops"}, merged)
+		assert.Equal(t, []string{"devops"}, inherited)
+	})
+
+	t.Run("没有LLM标签时全部来自邻居", func(t *testing.T) {
+		merged, inherited := mergeNeighborTags(nil, suggestions, 0.5, 10)
+		assert.Equal(t, []string{"devops", "Kubernetes"}, merged)
+		assert.Equal(t, merged, inherited)
+	})
+}
+
+// TestMergeTags 测试用户提供标签与LLM标签合并
+func TestMergeTags(t *testing.T) {
+	existing := []string{"AI", "machine-learning", "个人笔记", "Go"}
+	generated := &llm.TagResult{
+		Tags: []string{"ai", "Machine Learning", "golang", "并发", "数据库"},
+		Confidence: map[string]float64{
+			"ai": 0.9, "Machine Learning": 0.8, "golang": 0.7, "并发": 0.85, "数据库": 0.4,
+		},
+	}
+	aliases := map[string]string{"Golang": "Go"}
+
+	t.Run("折叠变体并按出现次数和置信度截断", func(t *testing.T) {
+		tags, sources := mergeTags(existing, generated, 5, aliases)
+		assert.Equal(t, []string{"AI", "machine-learning", "个人笔记", "Go", "并发"}, tags)
+		assert.Equal(t, []string{"AI", "machine-learning", "个人笔记", "Go"}, sources[TagSourceUser])
+		assert.Equal(t, []string{"AI", "machine-learning", "Go", "并发"}, sources[TagSourceModel])
+	})
+
+	t.Run("没有生成标签时保留用户标签", func(t *testing.T) {
+		tags, sources := mergeTags([]string{"AI", "ai ", "Go"}, nil, 10, nil)
+		assert.Equal(t, []string{"AI", "Go"}, tags)
+		assert.Empty(t, sources[TagSourceModel])
+	})
+}
+
+// TestRecommendationItemFrom 测试推荐结果转换保留推荐解释
+func TestRecommendationItemFrom(t *testing.T) {
+	explanation := &vector.RecommendationExplanation{Reason: "与源文档内容相似", SimilarityScore: 0.9}
+	item := recommendationItemFrom(&vector.RecommendationItem{
+		DocumentID:  "doc-1",
+		Similarity:  0.9,
+		Rank:        1,
+		Explanation: explanation,
+	})
+
+	assert.Equal(t, "doc-1", item.DocumentID)
+	assert.Same(t, explanation, item.Explanation)
+	assert.Nil(t, recommendationItemFrom(&vector.RecommendationItem{DocumentID: "doc-2"}).Explanation)
+}
+
+// TestProcessor_SearchScopes 测试保存搜索范围并合并到搜索选项
+func TestProcessor_SearchScopes(t *testing.T) {
+	store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	processor := newTestProcessor(t)
+	processor.store = store
+	ctx := context.Background()
+
+	requireSummary := true
+	_, err = processor.SaveSearchScope(ctx, "user-1", "links", &vector.SearchOptions{
+		Query:           "不保存的查询",
+		ContentTypes:    []models.ContentType{models.ContentTypeLink},
+		Tags:            []string{"go", "数据库"},
+		TopK:            5,
+		MinSimilarity:   0.6,
+		RankingStrategy: vector.RankingStrategyTime,
+		RequireSummary:  &requireSummary,
+		MetadataFilters: map[string]interface{}{"project": "memoro", "team": "core"},
+	})
+	require.NoError(t, err)
+
+	t.Run("同名范围覆盖且按用户隔离", func(t *testing.T) {
+		_, err := processor.SaveSearchScope(ctx, "user-1", "notes", &vector.SearchOptions{Tags: []string{"旧"}})
+		require.NoError(t, err)
+		updated, err := processor.SaveSearchScope(ctx, "user-1", "notes", &vector.SearchOptions{Tags: []string{"新"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"新"}, updated.Filters.Tags)
+
+		scopes, err := processor.ListSearchScopes(ctx, "user-1")
+		require.NoError(t, err)
+		require.Len(t, scopes, 2)
+		assert.Equal(t, "links", scopes[0].Name)
+		assert.Equal(t, "notes", scopes[1].Name)
+		assert.Empty(t, scopes[0].Filters.Query)
+
+		_, err = processor.GetSearchScope(ctx, "user-2", "links")
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+	})
+
+	t.Run("合并范围过滤条件，显式字段优先", func(t *testing.T) {
+		scope, err := processor.GetSearchScope(ctx, "user-1", "links")
+		require.NoError(t, err)
+
+		options := &vector.SearchOptions{
+			Query:           "分布式事务",
+			UserID:          "user-1",
+			TopK:            20,
+			MetadataFilters: map[string]interface{}{"team": "search"},
+		}
+		ApplySearchScope(options, scope)
+
+		assert.Equal(t, "分布式事务", options.Query)
+		assert.Equal(t, 20, options.TopK)
+		assert.Equal(t, []models.ContentType{models.ContentTypeLink}, options.ContentTypes)
+		assert.Equal(t, []string{"go", "数据库"}, options.Tags)
+		assert.Equal(t, float32(0.6), options.MinSimilarity)
+		assert.Equal(t, vector.RankingStrategyTime, options.RankingStrategy)
+		require.NotNil(t, options.RequireSummary)
+		assert.True(t, *options.RequireSummary)
+		assert.Equal(t, map[string]interface{}{"project": "memoro", "team": "search"}, options.MetadataFilters)
+	})
+
+	t.Run("拒绝无效参数", func(t *testing.T) {
+		_, err := processor.SaveSearchScope(ctx, "", "links", &vector.SearchOptions{})
+		assert.Error(t, err)
+		_, err = processor.SaveSearchScope(ctx, "user-1", " ", &vector.SearchOptions{})
+		assert.Error(t, err)
+		_, err = processor.SaveSearchScope(ctx, "user-1", "bad", &vector.SearchOptions{RankingStrategy: "random"})
+		assert.Error(t, err)
+	})
+}
+
+// delayedClassifier 固定耗时后返回的测试分类器（模拟较慢但能完成的LLM调用）
+type delayedClassifier struct {
+	delay time.Duration
+}
+
+func (c delayedClassifier) Classify(ctx context.Context, content *ExtractedContent) (*ClassificationResult, error) {
+	time.Sleep(c.delay)
+	return &ClassificationResult{Categories: []string{"技术"}, Confidence: 0.8}, nil
+}
+
+func (c delayedClassifier) CalculateImportance(ctx context.Context, content *ExtractedContent) (float64, error) {
+	return 0.6, nil
+}
+
+func (delayedClassifier) Close() error {
+	return nil
+}
+
+// TestProcessor_CloseDrain 测试关闭时排空进行中的请求
+func TestProcessor_CloseDrain(t *testing.T) {
+	newRunningProcessor := func(t *testing.T) *Processor {
+		processor := newTestProcessor(t)
+		processor.processCtx, processor.cancelProcess = context.WithCancel(context.Background())
+		processor.startWorkers()
+		return processor
+	}
+	request := func(id string) *ProcessingRequest {
+		return &ProcessingRequest{
+			ID:          id,
+			Content:     "Go语言并发编程实践",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options:     ProcessingOptions{EnableClassification: true, EnableImportanceScore: true},
+		}
+	}
+	waitProcessing := func(t *testing.T, processor *Processor, id string) {
+		require.Eventually(t, func() bool {
+			status, err := processor.GetStatus(id)
+			return err == nil && status == StatusProcessing
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	t.Run("期限内完成进行中和排队的请求", func(t *testing.T) {
+		processor := newRunningProcessor(t)
+		processor.classifier = delayedClassifier{delay: 100 * time.Millisecond}
+
+		require.NoError(t, processor.ProcessContentAsync(request("req-1")))
+		require.NoError(t, processor.ProcessContentAsync(request("req-2")))
+		waitProcessing(t, processor, "req-1")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, processor.Close(ctx))
+
+		for _, id := range []string{"req-1", "req-2"} {
+			result, err := processor.GetResult(id)
+			require.NoError(t, err)
+			assert.Equal(t, StatusCompleted, result.Status, id)
+			assert.NotNil(t, result.ContentItem)
+		}
+
+		err := processor.ProcessContentAsync(request("req-3"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "shutting down")
+	})
+
+	t.Run("超过期限时取消进行中的请求", func(t *testing.T) {
+		processor := newRunningProcessor(t)
+		processor.extractor.extractors[models.ContentTypeText] = slowExtractor{}
+
+		require.NoError(t, processor.ProcessContentAsync(request("req-1")))
+		require.NoError(t, processor.ProcessContentAsync(request("req-2")))
+		waitProcessing(t, processor, "req-1")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		require.NoError(t, processor.Close(ctx))
+		assert.Less(t, time.Since(start), time.Second)
+
+		for _, id := range []string{"req-1", "req-2"} {
+			result, err := processor.GetResult(id)
+			require.NoError(t, err)
+			assert.Equal(t, StatusCancelled, result.Status, id)
+		}
+	})
+}
+
+// TestProcessor_ProcessingEvents 测试订阅异步请求的处理事件
+func TestProcessor_ProcessingEvents(t *testing.T) {
+	processor := newTestProcessor(t)
+	processor.processCtx, processor.cancelProcess = context.WithCancel(context.Background())
+	processor.startWorkers()
+	processor.classifier = delayedClassifier{delay: 100 * time.Millisecond}
+	defer processor.Close(context.Background())
+
+	request := &ProcessingRequest{
+		ID:          "req-1",
+		Content:     "Go语言并发编程实践",
+		ContentType: models.ContentTypeText,
+		UserID:      "user-1",
+		Options:     ProcessingOptions{EnableClassification: true, EnableImportanceScore: true},
+	}
+
+	t.Run("事件序列以完成事件结束", func(t *testing.T) {
+		require.NoError(t, processor.ProcessContentAsync(request))
+
+		events, unsubscribe, err := processor.SubscribeEvents("req-1")
+		require.NoError(t, err)
+		defer unsubscribe()
+
+		var received []*ProcessingEvent
+		timeout := time.After(5 * time.Second)
+	collect:
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					break collect
+				}
+				received = append(received, event)
+			case <-timeout:
+				t.Fatal("timed out waiting for processing events")
+			}
+		}
+
+		require.NotEmpty(t, received)
+		assert.Equal(t, EventTypeStatus, received[0].Type)
+
+		last := received[len(received)-1]
+		assert.Equal(t, EventTypeStatus, last.Type)
+		assert.Equal(t, StatusCompleted, last.Status)
+		assert.True(t, last.Terminal())
+
+		var stages []ProcessingStage
+		for _, event := range received {
+			assert.Equal(t, "req-1", event.RequestID)
+			if event.Type == EventTypeStage {
+				stages = append(stages, event.Stage)
+			}
+		}
+		assert.Contains(t, stages, StageClassify)
+	})
+
+	t.Run("已结束的请求只推送当前状态", func(t *testing.T) {
+		events, unsubscribe, err := processor.SubscribeEvents("req-1")
+		require.NoError(t, err)
+		defer unsubscribe()
+
+		var received []*ProcessingEvent
+		for event := range events {
+			received = append(received, event)
+		}
+		require.Len(t, received, 1)
+		assert.Equal(t, StatusCompleted, received[0].Status)
+	})
+
+	t.Run("请求不存在", func(t *testing.T) {
+		_, _, err := processor.SubscribeEvents("missing")
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+	})
+}
+
+// TestProcessor_SearchContentScores 测试搜索结果保留引擎返回的距离、原始相似度和相似度类型
+func TestProcessor_SearchContentScores(t *testing.T) {
+	embeddingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer embeddingServer.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: embeddingServer.URL, APIKey: "test-key", Timeout: 5 * time.Second},
+	}))
+
+	store := vector.NewMemoryStore()
+	require.NoError(t, store.AddDocument(context.Background(), &vector.VectorDocument{
+		ID:        "doc-1",
+		Content:   "Go语言并发编程实践",
+		Embedding: []float32{0.6, 0.8, 0},
+		Metadata:  map[string]interface{}{"user_id": "user-1"},
+	}))
+	engine, err := vector.NewSearchEngineWithStore(store)
+	require.NoError(t, err)
+	defer engine.Close()
+
+	processor := newTestProcessor(t)
+	processor.searchEngine = engine
+
+	response, err := processor.SearchContent(context.Background(), &SearchRequest{Query: "Go并发", UserID: "user-1", TopK: 5})
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+
+	result := response.Results[0]
+	assert.Equal(t, vector.SimilarityTypeCosine, result.SimilarityType)
+	// 内存存储返回平方L2距离：(1-0.6)²+0.8² = 0.8
+	assert.InDelta(t, 0.8, result.Distance, 1e-6)
+	assert.InDelta(t, 0.6, result.RawSimilarity, 1e-6)
+	assert.InDelta(t, 0.8, result.NormalizedScore, 1e-6)
+	assert.Equal(t, result.NormalizedScore, result.Similarity)
+}
+
+// TestProcessor_SearchContentProjection 测试指定投影时序列化的搜索结果不包含未请求的字段
+func TestProcessor_SearchContentProjection(t *testing.T) {
+	embeddingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer embeddingServer.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: embeddingServer.URL, APIKey: "test-key", Timeout: 5 * time.Second},
+	}))
+
+	store := vector.NewMemoryStore()
+	require.NoError(t, store.AddDocument(context.Background(), &vector.VectorDocument{
+		ID:        "doc-1",
+		Content:   "Go语言并发编程实践",
+		Embedding: []float32{0.6, 0.8, 0},
+		Metadata:  map[string]interface{}{"user_id": "user-1", "title": "Go并发"},
+		CreatedAt: time.Now(),
+	}))
+	engine, err := vector.NewSearchEngineWithStore(store)
+	require.NoError(t, err)
+	defer engine.Close()
+
+	processor := newTestProcessor(t)
+	processor.searchEngine = engine
+
+	response, err := processor.SearchContent(context.Background(), &SearchRequest{
+		Query:  "Go并发",
+		UserID: "user-1",
+		TopK:   5,
+		Fields: []string{vector.FieldDocumentID, vector.FieldTitle},
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Results, 1)
+
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+	var serialized struct {
+		Results []map[string]interface{} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(data, &serialized))
+	require.Len(t, serialized.Results, 1)
+
+	result := serialized.Results[0]
+	assert.Equal(t, "doc-1", result["document_id"])
+	assert.Equal(t, map[string]interface{}{"title": "Go并发"}, result["metadata"])
+	for _, key := range []string{"content", "similarity", "distance", "raw_similarity", "normalized_score", "similarity_type", "rank", "matched_keywords", "content_summary", "created_at"} {
+		assert.NotContains(t, result, key)
+	}
+}
+
+// TestProcessor_PersistProcessedContent 测试处理完成的内容项保存到数据库，保存失败不影响向量索引
+func TestProcessor_PersistProcessedContent(t *testing.T) {
+	embeddingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer embeddingServer.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: embeddingServer.URL, APIKey: "test-key", Timeout: 5 * time.Second},
+	}))
+
+	newProcessor := func(t *testing.T) (*Processor, *storage.ContentStore, *vector.MemoryStore) {
+		store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+		require.NoError(t, err)
+		t.Cleanup(func() { store.Close() })
+
+		vectors := vector.NewMemoryStore()
+		engine, err := vector.NewSearchEngineWithStore(vectors)
+		require.NoError(t, err)
+		t.Cleanup(func() { engine.Close() })
+
+		processor := newTestProcessor(t)
+		processor.store = store
+		processor.searchEngine = engine
+		return processor, store, vectors
+	}
+
+	request := &ProcessingRequest{
+		ID:          "req-1",
+		Content:     "Go语言并发编程实践",
+		ContentType: models.ContentTypeText,
+		UserID:      "user-1",
+		Options:     ProcessingOptions{EnableVectorization: true},
+	}
+	ctx := context.Background()
+
+	t.Run("处理后可从数据库读取", func(t *testing.T) {
+		processor, store, _ := newProcessor(t)
+
+		result, err := processor.doProcessing(ctx, request)
+		require.NoError(t, err)
+		assert.True(t, result.Persisted)
+		assert.Empty(t, result.PersistenceError)
+		require.NotNil(t, result.VectorResult)
+		assert.Equal(t, 3, result.VectorResult.VectorDimension)
+
+		item, err := store.Get(ctx, result.ContentItem.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", item.UserID)
+		assert.Equal(t, "Go语言并发编程实践", item.RawContent)
+	})
+
+	t.Run("保存失败时记录错误并保留向量索引", func(t *testing.T) {
+		processor, store, vectors := newProcessor(t)
+		require.NoError(t, store.Close())
+
+		result, err := processor.doProcessing(ctx, request)
+		require.NoError(t, err)
+		assert.False(t, result.Persisted)
+		assert.NotEmpty(t, result.PersistenceError)
+		require.NotNil(t, result.VectorResult)
+		assert.True(t, result.VectorResult.Indexed)
+
+		_, err = vectors.GetDocument(ctx, result.ContentItem.ID)
+		assert.NoError(t, err)
+	})
+}
+
+// TestProcessor_StoredFileContent 测试上传图片向量化的是原始文件名、类型和大小，而不是存储文件名
+func TestProcessor_StoredFileContent(t *testing.T) {
+	var mu sync.Mutex
+	var embedded []string
+	embeddingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input interface{} `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		embedded = append(embedded, fmt.Sprint(body.Input))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer embeddingServer.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: embeddingServer.URL, APIKey: "test-key", Timeout: 5 * time.Second},
+	}))
+
+	vectors := vector.NewMemoryStore()
+	engine, err := vector.NewSearchEngineWithStore(vectors)
+	require.NoError(t, err)
+	defer engine.Close()
+
+	processor := newTestProcessor(t)
+	processor.store = newTestStore(t)
+	processor.searchEngine = engine
+
+	storedName := "3f2c9a4e-photo.png"
+	request := &ProcessingRequest{
+		ID:          "req-image",
+		Content:     StoredFileContent("photo.png", "image/png", 2048),
+		ContentType: models.ContentTypeImage,
+		UserID:      "user-1",
+		Context:     map[string]interface{}{TitleContextKey: "photo.png"},
+		Metadata: map[string]interface{}{
+			"source":              "upload",
+			StoredFileMetadataKey: storedName,
+		},
+		Options: ProcessingOptions{EnableVectorization: true},
+	}
+
+	ctx := context.Background()
+	result, err := processor.doProcessing(ctx, request)
+	require.NoError(t, err)
+	require.NotNil(t, result.VectorResult)
+	require.True(t, result.VectorResult.Indexed)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, embedded)
+	for _, input := range embedded {
+		assert.Contains(t, input, "photo.png (image/png, 2048 bytes)")
+		assert.NotContains(t, input, storedName)
+	}
+
+	doc, err := vectors.GetDocument(ctx, result.ContentItem.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, doc.Content, storedName)
+
+	item, err := processor.store.Get(ctx, result.ContentItem.ID)
+	require.NoError(t, err)
+	assert.Contains(t, item.RawContent, "photo.png (image/png, 2048 bytes)")
+	assert.NotContains(t, item.RawContent, storedName)
+	custom, _ := item.GetProcessedData()[vector.CustomMetadataKey].(map[string]interface{})
+	assert.Equal(t, storedName, custom[StoredFileMetadataKey])
+}
+
+// TestProcessor_DerivedSummary 测试内容过短时直接使用内容作为一句话摘要，不调用LLM
+func TestProcessor_DerivedSummary(t *testing.T) {
+	var llmCalls int32
+	llmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&llmCalls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer llmServer.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: llmServer.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+	}))
+	client, err := llm.NewClient()
+	require.NoError(t, err)
+	summarizer, err := llm.NewSummarizer(client)
+	require.NoError(t, err)
+
+	processor := newTestProcessor(t)
+	processor.summarizer = summarizer
+	processor.config.SummaryLevels = config.SummaryLevelsConfig{OneLineMaxLength: 60, MinContentWords: 30}
+
+	// 15个词
+	content := "Goroutines are cheap,\nso spawn one per request and let the scheduler balance the load."
+	result, err := processor.doProcessing(context.Background(), &ProcessingRequest{
+		ID:          "req-1",
+		Content:     content,
+		ContentType: models.ContentTypeText,
+		UserID:      "user-1",
+		Options:     ProcessingOptions{EnableSummary: true},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&llmCalls))
+	assert.Equal(t, SummarySourceDerived, result.SummarySource)
+	assert.Equal(t, "Goroutines are cheap, so spawn one per request and let...", result.Summary.OneLine)
+	assert.LessOrEqual(t, len(result.Summary.OneLine), 60)
+	assert.Empty(t, result.Summary.Paragraph)
+	assert.Empty(t, result.Summary.Detailed)
+
+	summary := result.ContentItem.GetSummary()
+	assert.Equal(t, result.Summary.OneLine, summary.OneLine)
+	assert.Equal(t, string(SummarySourceDerived), result.ContentItem.GetProcessedData()[SummarySourceKey])
+}
+
+// TestProcessor_ParallelStages 测试分类、摘要和标签阶段并发执行
+func TestProcessor_ParallelStages(t *testing.T) {
+	// 每次LLM调用耗时100ms：摘要3次调用约300ms，标签1次约100ms，分类约300ms
+	const callDelay = 100 * time.Millisecond
+	llmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		time.Sleep(callDelay)
+
+		answer := "Go语言通过goroutine和channel实现并发"
+		if strings.Contains(string(body), "标签生成专家") {
+			answer = `{"tags":["Go","并发"],"categories":["技术"],"keywords":["goroutine"],"confidence":{"Go":0.9,"并发":0.8}}`
+		}
+		response, _ := json.Marshal(map[string]interface{}{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"model":   "test-model",
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": answer}, "finish_reason": "stop"}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 10, "total_tokens": 20},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}))
+	defer llmServer.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: llmServer.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+		Processing: config.ProcessingConfig{
+			MaxContentSize: 100000,
+			SummaryLevels:  config.SummaryLevelsConfig{OneLineMaxLength: 200, ParagraphMaxLength: 500, DetailedMaxLength: 1000},
+			TagLimits:      config.TagLimitsConfig{MaxTags: 10, MaxTagLength: 50, DefaultConfidence: 0.5},
+		},
+	}))
+	client, err := llm.NewClient()
+	require.NoError(t, err)
+	summarizer, err := llm.NewSummarizer(client)
+	require.NoError(t, err)
+	tagger, err := llm.NewTagger(client)
+	require.NoError(t, err)
+
+	process := func(stageConcurrency int) (*ProcessingResult, time.Duration) {
+		processor := newTestProcessor(t)
+		processor.summarizer = summarizer
+		processor.tagger = tagger
+		processor.classifier = delayedClassifier{delay: 3 * callDelay}
+		processor.config.StageConcurrency = stageConcurrency
+
+		start := time.Now()
+		result, err := processor.doProcessing(context.Background(), &ProcessingRequest{
+			ID:          "req-1",
+			Content:     "Go语言的并发模型基于goroutine和channel，调度器把大量goroutine复用到少量系统线程上。",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options: ProcessingOptions{
+				EnableClassification:  true,
+				EnableImportanceScore: true,
+				EnableSummary:         true,
+				EnableTags:            true,
+				MaxTags:               5,
+			},
+		})
+		require.NoError(t, err)
+		return result, time.Since(start)
+	}
+
+	t.Run("总耗时接近最慢的单个阶段", func(t *testing.T) {
+		result, elapsed := process(0)
+
+		assert.Less(t, elapsed, 5*callDelay)
+		assert.Equal(t, []string{"技术"}, result.ContentItem.GetProcessedData()["categories"])
+		assert.Equal(t, "Go语言通过goroutine和channel实现并发", result.Summary.OneLine)
+		assert.Equal(t, []string{"Go", "并发"}, result.ContentItem.GetTags())
+		assert.Empty(t, result.StageErrors)
+	})
+
+	t.Run("并发数为1时顺序执行", func(t *testing.T) {
+		_, elapsed := process(1)
+		assert.GreaterOrEqual(t, elapsed, 7*callDelay)
+	})
+}
+
+// TestProcessor_RetagBulk 测试批量重命名和删除标签同时更新数据库和索引元数据
+func TestProcessor_RetagBulk(t *testing.T) {
+	embeddingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer embeddingServer.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: embeddingServer.URL, APIKey: "test-key", Timeout: 5 * time.Second},
+	}))
+	ctx := context.Background()
+
+	newProcessor := func(t *testing.T) (*Processor, *storage.ContentStore, *models.ContentItem) {
+		store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+		require.NoError(t, err)
+		t.Cleanup(func() { store.Close() })
+
+		engine, err := vector.NewSearchEngineWithStore(vector.NewMemoryStore())
+		require.NoError(t, err)
+		t.Cleanup(func() { engine.Close() })
+
+		processor := newTestProcessor(t)
+		processor.store = store
+		processor.searchEngine = engine
+
+		// 同一用户的三个文档带有ml标签（其中一个已有新标签），另一个用户的文档不受影响
+		documents := []struct {
+			userID string
+			tags   []string
+		}{
+			{"user-1", []string{"ml", "go"}},
+			{"user-1", []string{"python", "ml"}},
+			{"user-1", []string{"ml", "machine-learning"}},
+			{"user-1", []string{"go"}},
+			{"user-2", []string{"ml"}},
+		}
+		for i, doc := range documents {
+			item := models.NewContentItem(models.ContentTypeText, fmt.Sprintf("关于机器学习的笔记%d", i), doc.userID)
+			require.NoError(t, item.SetTags(doc.tags))
+			require.NoError(t, store.Save(ctx, item))
+			require.NoError(t, engine.IndexDocument(ctx, item))
+		}
+
+		// 只保存在数据库中的内容项（未索引），标签来源记录了用户提供和模型生成的标签
+		unindexed := models.NewContentItem(models.ContentTypeText, "索引失败的机器学习笔记", "user-1")
+		require.NoError(t, unindexed.SetTags([]string{"ml", "go"}))
+		require.NoError(t, unindexed.SetProcessedData(map[string]interface{}{
+			TagSourcesKey: map[string][]string{
+				string(TagSourceUser):  {"ml"},
+				string(TagSourceModel): {"go", "ml"},
+			},
+		}))
+		require.NoError(t, store.Save(ctx, unindexed))
+		return processor, store, unindexed
+	}
+
+	search := func(t *testing.T, processor *Processor, userID, tag string) int {
+		response, err := processor.searchEngine.Search(ctx, &vector.SearchOptions{
+			Query: "机器学习", UserID: userID, TopK: 10, Tags: []string{tag},
+		})
+		require.NoError(t, err)
+		return len(response.Results)
+	}
+
+	t.Run("重命名后旧标签不再匹配", func(t *testing.T) {
+		processor, store, _ := newProcessor(t)
+		require.Equal(t, 3, search(t, processor, "user-1", "ml"))
+
+		changed, err := processor.RetagBulk(ctx, "user-1", "ml", "machine-learning")
+		require.NoError(t, err)
+		assert.Equal(t, 4, changed)
+
+		assert.Equal(t, 0, search(t, processor, "user-1", "ml"))
+		assert.Equal(t, 3, search(t, processor, "user-1", "machine-learning"))
+		assert.Equal(t, 1, search(t, processor, "user-2", "ml"))
+
+		items, err := store.ListByUser(ctx, "user-1", 10, time.Time{})
+		require.NoError(t, err)
+		tagSets := make([][]string, 0, len(items))
+		for _, item := range items {
+			assert.NotContains(t, item.GetTags(), "ml")
+			tagSets = append(tagSets, item.GetTags())
+		}
+		// 已有新标签的文档合并为一个标签
+		assert.ElementsMatch(t, [][]string{
+			{"machine-learning", "go"},
+			{"python", "machine-learning"},
+			{"machine-learning"},
+			{"go"},
+			{"machine-learning", "go"},
+		}, tagSets)
+	})
+
+	t.Run("未索引的内容项同样修改并更新标签来源", func(t *testing.T) {
+		processor, store, unindexed := newProcessor(t)
+
+		_, err := processor.RetagBulk(ctx, "user-1", "ml", "machine-learning")
+		require.NoError(t, err)
+
+		stored, err := store.Get(ctx, unindexed.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"machine-learning", "go"}, stored.GetTags())
+		assert.Equal(t, []string{"machine-learning"}, tagsFromSource(stored.GetProcessedData()[TagSourcesKey], TagSourceUser))
+		assert.Equal(t, []string{"go", "machine-learning"}, tagsFromSource(stored.GetProcessedData()[TagSourcesKey], TagSourceModel))
+	})
+
+	t.Run("搜索引擎不可用时只修改数据库", func(t *testing.T) {
+		processor, store, unindexed := newProcessor(t)
+		processor.searchEngine = nil
+
+		changed, err := processor.DeleteTagBulk(ctx, "user-1", "ml")
+		require.NoError(t, err)
+		assert.Equal(t, 4, changed)
+
+		stored, err := store.Get(ctx, unindexed.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go"}, stored.GetTags())
+		assert.Empty(t, tagsFromSource(stored.GetProcessedData()[TagSourcesKey], TagSourceUser))
+	})
+
+	t.Run("删除标签", func(t *testing.T) {
+		processor, store, _ := newProcessor(t)
+
+		changed, err := processor.DeleteTagBulk(ctx, "user-1", "go")
+		require.NoError(t, err)
+		assert.Equal(t, 3, changed)
+		assert.Equal(t, 0, search(t, processor, "user-1", "go"))
+
+		items, err := store.ListByUser(ctx, "user-1", 10, time.Time{})
+		require.NoError(t, err)
+		for _, item := range items {
+			assert.NotContains(t, item.GetTags(), "go")
+		}
+	})
+
+	t.Run("新标签不能与原标签相同", func(t *testing.T) {
+		processor, _, _ := newProcessor(t)
+
+		_, err := processor.RetagBulk(ctx, "user-1", "ml", "ml")
+		assert.Error(t, err)
+	})
+}
+
+// unavailableVectorStore 模拟不可用的向量库，写入和查询都返回连接错误
+type unavailableVectorStore struct {
+	vector.VectorStore
+}
+
+func (s *unavailableVectorStore) AddDocument(ctx context.Context, doc *vector.VectorDocument) error {
+	return fmt.Errorf("connection refused")
+}
+
+func (s *unavailableVectorStore) Search(ctx context.Context, query *vector.SearchQuery) (*vector.SearchResult, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+func (s *unavailableVectorStore) Close() error {
+	return nil
+}
+
+func (s *unavailableVectorStore) GetVectorDimension(ctx context.Context) (int, error) {
+	return 0, fmt.Errorf("connection refused")
+}
+
+func (s *unavailableVectorStore) GetDocumentsByFilter(ctx context.Context, filter map[string]interface{}, limit int) ([]*vector.VectorDocument, error) {
+	return nil, fmt.Errorf("connection refused")
+}
+
+// TestProcessor_SummaryOnlyPreset 测试只生成摘要的预设不调用embedding服务，向量库不可用时也能处理
+func TestProcessor_SummaryOnlyPreset(t *testing.T) {
+	var embeddingCalls int32
+	embeddingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&embeddingCalls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer embeddingServer.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: embeddingServer.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+	}))
+	client, err := llm.NewClient()
+	require.NoError(t, err)
+	summarizer, err := llm.NewSummarizer(client)
+	require.NoError(t, err)
+	engine, err := vector.NewSearchEngineWithStore(&unavailableVectorStore{})
+	require.NoError(t, err)
+	defer engine.Close()
+
+	processor := newTestProcessor(t)
+	processor.summarizer = summarizer
+	processor.searchEngine = engine
+	processor.config.SummaryLevels = config.SummaryLevelsConfig{OneLineMaxLength: 60, MinContentWords: 30}
+
+	newRequest := func(id string, preset ProcessingPreset) *ProcessingRequest {
+		request := &ProcessingRequest{
+			ID:          id,
+			Content:     "Goroutines are cheap, so spawn one per request.",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options:     ProcessingOptions{Preset: preset, EnableNeighborTags: true},
+		}
+		require.NoError(t, processor.validateRequest(request))
+		processor.setDefaultOptions(request)
+		return request
+	}
+
+	t.Run("只生成摘要", func(t *testing.T) {
+		request := newRequest("req-1", PresetSummaryOnly)
+		assert.Equal(t, ProcessingOptions{Preset: PresetSummaryOnly, EnableSummary: true, MaxTags: 10}, request.Options)
+
+		result, err := processor.doProcessing(context.Background(), request)
+		require.NoError(t, err)
+
+		require.NotNil(t, result.Summary)
+		assert.NotEmpty(t, result.Summary.OneLine)
+		assert.Nil(t, result.Tags)
+		assert.Nil(t, result.VectorResult)
+		assert.Empty(t, result.StageErrors)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&embeddingCalls))
+	})
+
+	t.Run("启用向量化时访问不可用的向量库", func(t *testing.T) {
+		request := newRequest("req-2", PresetFull)
+		assert.True(t, request.Options.EnableVectorization)
+		assert.True(t, request.Options.EnableNeighborTags)
+
+		result, err := processor.doProcessing(context.Background(), &ProcessingRequest{
+			ID:          "req-2",
+			Content:     request.Content,
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options:     ProcessingOptions{EnableVectorization: true},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, result.VectorResult)
+		assert.False(t, result.VectorResult.Indexed)
+		assert.NotEmpty(t, result.VectorResult.Error)
+		assert.Greater(t, atomic.LoadInt32(&embeddingCalls), int32(0))
+	})
+
+	t.Run("未定义的预设", func(t *testing.T) {
+		err := processor.validateRequest(&ProcessingRequest{
+			ID:          "req-3",
+			Content:     "Goroutines are cheap, so spawn one per request.",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options:     ProcessingOptions{Preset: "tags_only"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "options.preset")
+	})
+}
+
+// TestProcessor_AnonymousUser 测试允许匿名时未指定用户的请求归属默认用户，未开启时被拒绝
+func TestProcessor_AnonymousUser(t *testing.T) {
+	newRequest := func(id string) *ProcessingRequest {
+		return &ProcessingRequest{
+			ID:          id,
+			Content:     "单用户部署中记录的一条笔记",
+			ContentType: models.ContentTypeText,
+		}
+	}
+
+	t.Run("允许匿名时使用默认用户", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.config.AllowAnonymous = true
+		processor.config.DefaultUserID = "owner"
+
+		request := newRequest("req-1")
+		require.NoError(t, processor.validateRequest(request))
+		assert.Equal(t, "owner", request.UserID)
+
+		result, err := processor.doProcessing(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, "owner", result.ContentItem.UserID)
+	})
+
+	t.Run("未配置默认用户ID时使用default", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.config.AllowAnonymous = true
+
+		verdict, err := processor.ValidateContent(context.Background(), newRequest("req-2"))
+		require.NoError(t, err)
+		assert.True(t, verdict.Accepted)
+
+		request := newRequest("req-2")
+		require.NoError(t, processor.validateRequest(request))
+		assert.Equal(t, defaultAnonymousUserID, request.UserID)
+	})
+
+	t.Run("显式指定的用户不被覆盖", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.config.AllowAnonymous = true
+
+		request := newRequest("req-3")
+		request.UserID = "user-1"
+		require.NoError(t, processor.validateRequest(request))
+		assert.Equal(t, "user-1", request.UserID)
+	})
+
+	t.Run("未允许匿名时拒绝请求", func(t *testing.T) {
+		processor := newTestProcessor(t)
+		processor.config.DefaultUserID = "owner"
+
+		err := processor.validateRequest(newRequest("req-4"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "user_id")
+
+		_, err = processor.ListRecent(context.Background(), "", 10, time.Time{})
+		require.Error(t, err)
+	})
+}
+
+// TestProcessor_PersonalizedRecommendations 测试个性化推荐：有交互历史的用户得到个性化推荐，新用户降级为热门推荐
+func TestProcessor_PersonalizedRecommendations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: server.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+	}))
+	ctx := context.Background()
+
+	engine, err := vector.NewSearchEngineWithStore(vector.NewMemoryStore())
+	require.NoError(t, err)
+	engine.SetInteractionStore(vector.NewInteractionStore())
+	t.Cleanup(func() { engine.Close() })
+
+	processor := newTestProcessor(t)
+	processor.searchEngine = engine
+
+	index := func(userID, text string, tags ...string) string {
+		item := models.NewContentItem(models.ContentTypeText, text, userID)
+		require.NoError(t, item.SetTags(tags))
+		require.NoError(t, engine.IndexDocument(ctx, item))
+		return item.ID
+	}
+	read := index("user-1", "Go并发编程：goroutine与channel", "go", "concurrency")
+	index("user-1", "Go泛型入门", "go")
+	index("user-1", "周末烘焙笔记", "baking")
+	index("user-2", "新用户的第一条笔记", "notes")
+
+	for i := 0; i < 3; i++ {
+		engine.RecordInteraction("user-1", read)
+	}
+
+	t.Run("有交互历史的用户得到个性化推荐", func(t *testing.T) {
+		response, err := processor.GetRecommendations(ctx, &RecommendationRequest{
+			Type:   "personalized",
+			UserID: "user-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "personalized", response.Type)
+		assert.Equal(t, true, response.Metadata["personalized"])
+		assert.NotContains(t, response.Metadata, "personalization_fallback")
+		assert.NotEmpty(t, response.Recommendations)
+	})
+
+	t.Run("新用户降级为热门推荐并说明原因", func(t *testing.T) {
+		response, err := processor.GetRecommendations(ctx, &RecommendationRequest{
+			Type:   "personalized",
+			UserID: "user-2",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "trending", response.Type)
+		assert.Equal(t, false, response.Metadata["personalized"])
+		assert.Equal(t, "trending", response.Metadata["personalization_fallback"])
+		assert.Equal(t, "insufficient interaction history for personalization", response.Metadata["fallback_reason"])
+		require.Len(t, response.Recommendations, 1)
+		assert.Equal(t, "user-2", response.Recommendations[0].Metadata["user_id"])
+	})
+}
+
+// TestProcessor_SearchEngineUnavailable 测试向量库不可用时摘要照常生成，搜索和推荐返回明确的不可用错误
+func TestProcessor_SearchEngineUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: server.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+	}))
+	client, err := llm.NewClient()
+	require.NoError(t, err)
+	summarizer, err := llm.NewSummarizer(client)
+	require.NoError(t, err)
+
+	processor := newTestProcessor(t)
+	processor.summarizer = summarizer
+	processor.searchEngineErr = "chroma connection refused"
+	processor.config.SummaryLevels = config.SummaryLevelsConfig{OneLineMaxLength: 60, MinContentWords: 30}
+
+	newRequest := func(id string, options ProcessingOptions) *ProcessingRequest {
+		return &ProcessingRequest{
+			ID:          id,
+			Content:     "Goroutines are cheap, so spawn one per request.",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options:     options,
+		}
+	}
+
+	t.Run("未启用向量化时正常处理", func(t *testing.T) {
+		result, err := processor.doProcessing(context.Background(), newRequest("req-1", ProcessingOptions{EnableSummary: true}))
+		require.NoError(t, err)
+		require.NotNil(t, result.Summary)
+		assert.NotEmpty(t, result.Summary.OneLine)
+		assert.Nil(t, result.VectorResult)
+	})
+
+	t.Run("启用向量化时跳过", func(t *testing.T) {
+		result, err := processor.doProcessing(context.Background(), newRequest("req-2", ProcessingOptions{EnableSummary: true, EnableVectorization: true}))
+		require.NoError(t, err)
+		require.NotNil(t, result.VectorResult)
+		assert.True(t, result.VectorResult.Skipped)
+		assert.False(t, result.VectorResult.Indexed)
+		assert.Contains(t, result.VectorResult.SkipReason, "chroma connection refused")
+		assert.Empty(t, result.StageErrors)
+	})
+
+	t.Run("搜索和推荐返回不可用错误", func(t *testing.T) {
+		_, err := processor.SearchContent(context.Background(), &SearchRequest{Query: "goroutines", UserID: "user-1"})
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeFeatureUnavailable))
+		assert.Contains(t, err.Error(), "chroma connection refused")
+
+		_, err = processor.GetRecommendations(context.Background(), &RecommendationRequest{UserID: "user-1"})
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeFeatureUnavailable))
+	})
+}
+
+// TestProcessor_SensitiveEncryption 测试敏感内容在数据库中保存为密文，通过GetContent读取时返回明文
+func TestProcessor_SensitiveEncryption(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	t.Setenv("MEMORO_TEST_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(key))
+
+	path := filepath.Join(t.TempDir(), "memoro.db")
+	store, err := storage.OpenContentStore(config.DatabaseConfig{
+		Type:        "sqlite",
+		Path:        path,
+		AutoMigrate: true,
+		Encryption:  config.EncryptionConfig{Mode: "sensitive", KeyEnv: "MEMORO_TEST_ENCRYPTION_KEY"},
+	})
+	require.NoError(t, err)
+	defer store.Close()
+
+	processor := newTestProcessor(t)
+	processor.store = store
+	ctx := context.Background()
+
+	rawColumn := func(t *testing.T, id string) string {
+		db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+		require.NoError(t, err)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		defer sqlDB.Close()
+
+		var raw string
+		require.NoError(t, db.Raw("SELECT raw_content FROM content_items WHERE id = ?", id).Scan(&raw).Error)
+		return raw
+	}
+
+	t.Run("敏感内容加密保存", func(t *testing.T) {
+		request := &ProcessingRequest{
+			ID:          "req-1",
+			Content:     "银行卡密码提示：生日倒序",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Sensitive:   true,
+		}
+		require.NoError(t, processor.validateRequest(request))
+		result, err := processor.doProcessing(ctx, request)
+		require.NoError(t, err)
+		require.True(t, result.Persisted)
+		assert.Equal(t, "银行卡密码提示：生日倒序", result.ContentItem.RawContent)
+
+		raw := rawColumn(t, result.ContentItem.ID)
+		assert.True(t, models.IsEncryptedValue(raw))
+		assert.NotContains(t, raw, "生日倒序")
+
+		detail, err := processor.GetContent(ctx, result.ContentItem.ID, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, "银行卡密码提示：生日倒序", detail.RawContent)
+	})
+
+	t.Run("敏感内容的处理数据加密且不写入向量元数据", func(t *testing.T) {
+		embeddingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+		}))
+		defer embeddingServer.Close()
+		require.NoError(t, config.InitializeForTest(&config.Config{
+			LLM: config.LLMConfig{APIBase: embeddingServer.URL, APIKey: "test-key", Timeout: 5 * time.Second},
+		}))
+
+		vectorStore := vector.NewMemoryStore()
+		engine, err := vector.NewSearchEngineWithStore(vectorStore)
+		require.NoError(t, err)
+		defer engine.Close()
+
+		indexingProcessor := newTestProcessor(t)
+		indexingProcessor.store = store
+		indexingProcessor.searchEngine = engine
+
+		result, err := indexingProcessor.doProcessing(ctx, &ProcessingRequest{
+			ID:          "req-4",
+			Content:     "复查结果：血糖偏高，需要调整饮食",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Context:     map[string]interface{}{TitleContextKey: "体检复查记录"},
+			Options:     ProcessingOptions{EnableVectorization: true},
+			Metadata:    map[string]interface{}{"doctor": "王医生"},
+			Sensitive:   true,
+		})
+		require.NoError(t, err)
+		require.True(t, result.Persisted)
+
+		db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+		require.NoError(t, err)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		defer sqlDB.Close()
+		var processedData string
+		require.NoError(t, db.Raw("SELECT processed_data FROM content_items WHERE id = ?", result.ContentItem.ID).Scan(&processedData).Error)
+		assert.True(t, models.IsEncryptedValue(processedData))
+		assert.NotContains(t, processedData, "体检复查记录")
+
+		detail, err := indexingProcessor.GetContent(ctx, result.ContentItem.ID, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, "体检复查记录", detail.ProcessedData["title"])
+
+		doc, err := vectorStore.GetDocument(ctx, result.ContentItem.ID)
+		require.NoError(t, err)
+		assert.Empty(t, doc.Content)
+		assert.Equal(t, true, doc.Metadata[vector.MetadataKeySensitive])
+		for _, key := range []string{"title", "keywords", "doctor", "summary_oneline", vector.MetadataKeySummaryParagraph} {
+			assert.NotContains(t, doc.Metadata, key)
+		}
+	})
+
+	t.Run("普通内容不加密", func(t *testing.T) {
+		result, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID:          "req-2",
+			Content:     "周末去爬山",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "周末去爬山", rawColumn(t, result.ContentItem.ID))
+	})
+
+	t.Run("未配置加密时拒绝敏感内容", func(t *testing.T) {
+		plainStore, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+		require.NoError(t, err)
+		defer plainStore.Close()
+
+		plainProcessor := newTestProcessor(t)
+		plainProcessor.store = plainStore
+		err = plainProcessor.validateRequest(&ProcessingRequest{
+			ID:          "req-3",
+			Content:     "银行卡密码提示：生日倒序",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Sensitive:   true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "sensitive")
+	})
+}
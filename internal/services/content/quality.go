@@ -0,0 +1,154 @@
+package content
+
+import (
+	"strings"
+	"unicode"
+
+	"memoro/internal/logger"
+	"memoro/internal/services/language"
+)
+
+// defaultMinExtractionQuality 未配置时判定为低质量提取的分数阈值
+const defaultMinExtractionQuality = 0.5
+
+// ExtractionQuality 提取质量评估结果
+type ExtractionQuality struct {
+	Score         float64 `json:"score"`          // 综合质量分数(0-1)
+	WordRatio     float64 `json:"word_ratio"`     // 像正常词语的词元比例
+	LetterRatio   float64 `json:"letter_ratio"`   // 非空白字符中文字的比例
+	Diversity     float64 `json:"diversity"`      // 词汇多样性（与分类器的词汇丰富度判断一致）
+	RepeatedRatio float64 `json:"repeated_ratio"` // 最高频词元占全部词元的比例
+}
+
+// assessExtractionQuality 评估提取文本的质量：正常文章的词元大多是由文字组成的词，乱码则充斥符号、数字混杂或无元音的字母串
+func assessExtractionQuality(content string) *ExtractionQuality {
+	tokens := strings.Fields(content)
+	quality := &ExtractionQuality{}
+	if len(tokens) == 0 {
+		return quality
+	}
+
+	letters, visible := 0, 0
+	for _, r := range content {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		visible++
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if visible > 0 {
+		quality.LetterRatio = float64(letters) / float64(visible)
+	}
+
+	words := 0
+	counts := make(map[string]int, len(tokens))
+	maxCount := 0
+	for _, token := range tokens {
+		if isWordLike(token) {
+			words++
+		}
+		key := strings.ToLower(token)
+		counts[key]++
+		if counts[key] > maxCount {
+			maxCount = counts[key]
+		}
+	}
+	quality.WordRatio = float64(words) / float64(len(tokens))
+	quality.Diversity = vocabularyDiversity(content)
+	quality.RepeatedRatio = float64(maxCount) / float64(len(tokens))
+
+	// 多样性达到分类器的丰富度标准(0.5)即满分；少量词元时重复比例没有意义
+	diversityScore := quality.Diversity / 0.5
+	if diversityScore > 1 {
+		diversityScore = 1
+	}
+	repetitionPenalty := 0.0
+	if len(tokens) >= 10 && quality.RepeatedRatio > 0.3 {
+		repetitionPenalty = quality.RepeatedRatio - 0.3
+	}
+
+	score := quality.WordRatio*0.5 + quality.LetterRatio*0.3 + diversityScore*0.2 - repetitionPenalty
+	if score < 0 {
+		score = 0
+	}
+	quality.Score = score
+	return quality
+}
+
+// isWordLike 判断词元是否像正常词语：由文字、数字和常规标点组成（不含符号），较长的拉丁字母串需包含元音
+func isWordLike(token string) bool {
+	letters, digits, latin, vowels := 0, 0, 0, 0
+	for _, r := range token {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			letters++
+			if r < unicode.MaxASCII {
+				latin++
+				if strings.ContainsRune("aeiouyAEIOUY", r) {
+					vowels++
+				}
+			}
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsPunct(r) && !strings.ContainsRune(`#@%&*\`, r):
+		default:
+			// 符号（如|~^$+=<>）在正常文本的词语中很少出现
+			return false
+		}
+	}
+
+	if letters == 0 && digits == 0 {
+		return false
+	}
+	// 没有元音的较长拉丁字母串通常是乱码（如xkcdqzt）
+	if latin > 3 && vowels == 0 {
+		return false
+	}
+	return true
+}
+
+// vocabularyDiversity 词汇多样性：长度大于2的不同词数量占全部词数的比例（按分词结果计算，中文不再整段视为一个词）
+func vocabularyDiversity(content string) float64 {
+	words := language.Tokenize(content)
+	if len(words) == 0 {
+		return 0
+	}
+
+	uniqueWords := make(map[string]bool)
+	for _, word := range words {
+		if len(word) > 2 {
+			uniqueWords[strings.ToLower(word)] = true
+		}
+	}
+	return float64(len(uniqueWords)) / float64(len(words))
+}
+
+// minExtractionQuality 获取低质量判定阈值
+func (em *ExtractorManager) minExtractionQuality() float64 {
+	if minScore := em.config.ExtractionQuality.MinScore; minScore > 0 {
+		return minScore
+	}
+	return defaultMinExtractionQuality
+}
+
+// applyExtractionQuality 评估提取质量，分数写入元数据，低于阈值时标记为低质量
+func (em *ExtractorManager) applyExtractionQuality(content *ExtractedContent) {
+	quality := assessExtractionQuality(content.Content)
+	if content.Metadata == nil {
+		content.Metadata = make(map[string]interface{})
+	}
+	content.Metadata["extraction_quality"] = quality.Score
+	content.LowQuality = quality.Score < em.minExtractionQuality()
+
+	if content.LowQuality {
+		em.logger.Warn("Low quality extraction detected", logger.Fields{
+			"content_type":   string(content.Type),
+			"quality_score":  quality.Score,
+			"word_ratio":     quality.WordRatio,
+			"letter_ratio":   quality.LetterRatio,
+			"repeated_ratio": quality.RepeatedRatio,
+		})
+	}
+}
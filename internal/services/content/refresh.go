@@ -0,0 +1,179 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/llm"
+	"memoro/internal/services/vector"
+)
+
+// RefreshOptions 重新生成摘要和标签的选项
+type RefreshOptions struct {
+	Style llm.SummaryStyle `json:"style,omitempty"` // 摘要风格，为空时使用默认风格
+	Model string           `json:"model,omitempty"` // 使用的对话模型，为空时使用配置的默认模型，须在llm.allowed_models中
+}
+
+// RefreshContent 对已保存的原始内容重新生成摘要和标签，同时更新数据库和索引元数据（内容未变化，不重新向量化），返回更新后的内容详情。
+// 用户提供的标签保留，模型生成的标签被替换；不属于该用户的内容按不存在处理
+func (p *Processor) RefreshContent(ctx context.Context, id string, userID string, options RefreshOptions) (*ContentDetail, error) {
+	if id == "" {
+		return nil, errors.ErrValidationFailed("id", "cannot be empty")
+	}
+	if !llm.ValidSummaryStyle(options.Style) {
+		return nil, errors.ErrValidationFailed("style", "must be one of: "+strings.Join(llm.SummaryStyleNames(), ", "))
+	}
+	model := strings.TrimSpace(options.Model)
+	if model != "" && !allowedModel(model) {
+		return nil, errors.ErrValidationFailed("model", fmt.Sprintf("model %q is not allowed", model))
+	}
+
+	if p.store == nil {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+	if p.summarizer == nil || p.tagger == nil {
+		return nil, errors.ErrConfigMissing("llm")
+	}
+
+	item, err := p.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if userID != "" && item.UserID != userID {
+		return nil, errors.ErrResourceNotFound("content", id)
+	}
+	if strings.TrimSpace(item.RawContent) == "" {
+		return nil, errors.ErrValidationFailed("content", "has no stored text to summarize")
+	}
+
+	// LLM调用的token消耗归属到内容所属用户
+	ctx = llm.WithBudgetUser(ctx, item.UserID)
+	if model != "" {
+		ctx = llm.WithModel(ctx, model)
+	}
+
+	summary, err := p.summarizer.GenerateSummary(ctx, llm.SummaryRequest{
+		Content:     item.RawContent,
+		ContentType: item.Type,
+		Style:       options.Style,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userTags := userTagsOf(item)
+	generated, err := p.tagger.GenerateTags(ctx, llm.TagRequest{
+		Content:      item.RawContent,
+		ContentType:  item.Type,
+		ExistingTags: userTags,
+		MaxTags:      p.config.TagLimits.MaxTags,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags, tagSources := mergeTags(userTags, generated, p.config.TagLimits.MaxTags, p.config.TagLimits.Aliases)
+
+	if err := item.SetSummary(models.Summary{
+		OneLine:   summary.OneLine,
+		Paragraph: summary.Paragraph,
+		Detailed:  summary.Detailed,
+	}); err != nil {
+		return nil, err
+	}
+	if err := item.SetTags(tags); err != nil {
+		return nil, err
+	}
+	processedData := item.GetProcessedData()
+	processedData[SummarySourceKey] = string(SummarySourceGenerated)
+	processedData[TagSourcesKey] = map[string][]string{
+		string(TagSourceUser):  tagSources[TagSourceUser],
+		string(TagSourceModel): tagSources[TagSourceModel],
+	}
+	if err := item.SetProcessedData(processedData); err != nil {
+		return nil, err
+	}
+
+	// 先更新索引元数据，索引更新失败时数据库保持原状
+	if p.searchEngine != nil {
+		patch := map[string]interface{}{
+			vector.MetadataKeyHasSummary: summary.OneLine != "",
+			"tags":                       tags,
+			vector.MetadataKeyHasTags:    len(tags) > 0,
+		}
+		// 敏感内容的摘要只加密保存在数据库中
+		if !item.Sensitive {
+			patch["summary_oneline"] = summary.OneLine
+			patch[vector.MetadataKeySummaryParagraph] = summary.Paragraph
+		}
+		err := p.searchEngine.UpdateDocumentMetadata(ctx, id, patch)
+		if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeResourceNotFound) {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.store.Save(ctx, item); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Content summary and tags refreshed", logger.Fields{
+		"content_id": id,
+		"user_id":    item.UserID,
+		"style":      string(options.Style),
+		"model":      model,
+		"tags":       len(tags),
+	})
+
+	detail := contentDetailOf(item)
+	if p.searchEngine != nil {
+		detail.Vector = p.getVectorStatus(ctx, id)
+	}
+	return detail, nil
+}
+
+// allowedModel 判断请求指定的模型是否可用：配置的默认模型或llm.allowed_models中的模型
+func allowedModel(model string) bool {
+	cfg := config.Get()
+	if cfg == nil {
+		return false
+	}
+	if model == cfg.LLM.Model {
+		return true
+	}
+	for _, allowed := range cfg.LLM.AllowedModels {
+		if model == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// userTagsOf 获取内容项中用户提供的标签（处理时记录在标签来源中）
+func userTagsOf(item *models.ContentItem) []string {
+	return tagsFromSource(item.GetProcessedData()[TagSourcesKey], TagSourceUser)
+}
+
+// tagsFromSource 从标签来源记录中读取指定来源的标签（刚处理时为map[string][]string，从数据库读取后为JSON解码的类型）
+func tagsFromSource(sources interface{}, source TagSource) []string {
+	switch sources := sources.(type) {
+	case map[string][]string:
+		return sources[string(source)]
+	case map[string]interface{}:
+		values, _ := sources[string(source)].([]interface{})
+		tags := make([]string, 0, len(values))
+		for _, value := range values {
+			if tag, ok := value.(string); ok && tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		return tags
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/models"
+	"memoro/internal/services/llm"
+	"memoro/internal/services/vector"
+	"memoro/internal/storage"
+)
+
+// TestProcessor_RefreshContent 测试重新生成摘要和标签：更新数据库和索引元数据，不重新向量化
+func TestProcessor_RefreshContent(t *testing.T) {
+	var embeddingCalls int32
+	var requestedModels []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			atomic.AddInt32(&embeddingCalls, 1)
+			fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":3,"total_tokens":3}}`)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var request llm.ChatCompletionRequest
+		_ = json.Unmarshal(body, &request)
+		requestedModels = append(requestedModels, request.Model)
+
+		answer := "初始摘要"
+		if strings.Contains(string(body), "摘要风格：要点") {
+			answer = "- goroutine轻量\n- channel通信"
+		}
+		if strings.Contains(string(body), "标签生成专家") {
+			answer = `{"tags":["Go","并发"],"categories":["技术"],"keywords":["goroutine"],"confidence":{"Go":0.9,"并发":0.8}}`
+		}
+		response, _ := json.Marshal(map[string]interface{}{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"model":   request.Model,
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": answer}, "finish_reason": "stop"}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 10, "total_tokens": 20},
+		})
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: server.URL, APIKey: "test-key", Model: "test-model", AllowedModels: []string{"better-model"}, Timeout: 5 * time.Second},
+		Processing: config.ProcessingConfig{
+			MaxContentSize: 100000,
+			SummaryLevels:  config.SummaryLevelsConfig{OneLineMaxLength: 200, ParagraphMaxLength: 500, DetailedMaxLength: 1000},
+			TagLimits:      config.TagLimitsConfig{MaxTags: 10, MaxTagLength: 50, DefaultConfidence: 0.5},
+		},
+	}))
+	client, err := llm.NewClient()
+	require.NoError(t, err)
+	summarizer, err := llm.NewSummarizer(client)
+	require.NoError(t, err)
+	tagger, err := llm.NewTagger(client)
+	require.NoError(t, err)
+	engine, err := vector.NewSearchEngineWithStore(vector.NewMemoryStore())
+	require.NoError(t, err)
+	defer engine.Close()
+	store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+	require.NoError(t, err)
+	defer store.Close()
+
+	processor := newTestProcessor(t)
+	processor.summarizer = summarizer
+	processor.tagger = tagger
+	processor.searchEngine = engine
+	processor.store = store
+
+	ctx := context.Background()
+	result, err := processor.doProcessing(ctx, &ProcessingRequest{
+		ID:          "req-1",
+		Content:     "Go语言的并发模型基于goroutine和channel，调度器把大量goroutine复用到少量系统线程上。",
+		ContentType: models.ContentTypeText,
+		UserID:      "user-1",
+		Options: ProcessingOptions{
+			EnableSummary:       true,
+			EnableTags:          true,
+			EnableVectorization: true,
+			ExistingTags:        []string{"学习笔记"},
+			MaxTags:             5,
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, result.Persisted)
+	id := result.ContentItem.ID
+	require.Equal(t, "初始摘要", result.ContentItem.Summary.OneLine)
+	embeddingsBefore := atomic.LoadInt32(&embeddingCalls)
+
+	t.Run("按指定风格和模型重新生成摘要", func(t *testing.T) {
+		requestedModels = nil
+		detail, err := processor.RefreshContent(ctx, id, "user-1", RefreshOptions{Style: llm.SummaryStyleBullet, Model: "better-model"})
+		require.NoError(t, err)
+		assert.Contains(t, detail.Summary.OneLine, "goroutine轻量")
+		assert.ElementsMatch(t, []string{"学习笔记", "Go", "并发"}, detail.Tags)
+		require.NotEmpty(t, requestedModels)
+		for _, model := range requestedModels {
+			assert.Equal(t, "better-model", model)
+		}
+
+		// 数据库和索引元数据均已更新，没有重新向量化
+		stored, err := store.Get(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, detail.Summary.OneLine, stored.Summary.OneLine)
+		doc, err := engine.GetDocument(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, detail.Summary.OneLine, doc.Metadata["summary_oneline"])
+		assert.Equal(t, embeddingsBefore, atomic.LoadInt32(&embeddingCalls))
+	})
+
+	t.Run("不支持的风格", func(t *testing.T) {
+		_, err := processor.RefreshContent(ctx, id, "user-1", RefreshOptions{Style: "poetic"})
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeValidationFailed))
+	})
+
+	t.Run("未允许的模型", func(t *testing.T) {
+		_, err := processor.RefreshContent(ctx, id, "user-1", RefreshOptions{Model: "unknown-model"})
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeValidationFailed))
+	})
+
+	t.Run("其他用户的内容按不存在处理", func(t *testing.T) {
+		_, err := processor.RefreshContent(ctx, id, "user-2", RefreshOptions{})
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+	})
+}
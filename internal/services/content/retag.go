@@ -0,0 +1,149 @@
+package content
+
+import (
+	"context"
+	"strings"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/vector"
+)
+
+// RetagBulk 将用户所有带有from标签的文档改为to标签（已有to标签时合并），同时更新数据库和索引元数据（不重新向量化），返回修改的文档数
+func (p *Processor) RetagBulk(ctx context.Context, userID, from, to string) (int, error) {
+	from = strings.TrimSpace(from)
+	to = strings.TrimSpace(to)
+	if to == "" {
+		return 0, errors.ErrValidationFailed("to", "cannot be empty")
+	}
+	if from == to {
+		return 0, errors.ErrValidationFailed("to", "must differ from the tag being renamed")
+	}
+
+	return p.editTagBulk(ctx, userID, from, to)
+}
+
+// DeleteTagBulk 从用户所有文档中删除指定标签，返回修改的文档数
+func (p *Processor) DeleteTagBulk(ctx context.Context, userID, tag string) (int, error) {
+	return p.editTagBulk(ctx, userID, strings.TrimSpace(tag), "")
+}
+
+// editTagBulk 按数据库（内容数据的权威来源）查找带有from标签的内容项，逐个替换为to标签（to为空时删除）并更新标签来源，
+// 再尽力同步索引元数据：未索引的内容跳过，索引更新失败只记录警告。
+// 出错时返回已修改的内容项数和错误，已修改的内容项不回滚
+func (p *Processor) editTagBulk(ctx context.Context, userID, from, to string) (int, error) {
+	if userID == "" {
+		return 0, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+	if from == "" {
+		return 0, errors.ErrValidationFailed("from", "cannot be empty")
+	}
+	if p.store == nil {
+		return 0, errors.ErrConfigMissing("database.path")
+	}
+
+	items, err := p.store.ListByTag(ctx, userID, from)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	indexFailed := 0
+	for _, item := range items {
+		tags, modified := replaceTag(item.GetTags(), from, to)
+		if !modified {
+			continue
+		}
+		if err := item.SetTags(tags); err != nil {
+			return changed, err
+		}
+		if err := retagSources(item, from, to); err != nil {
+			return changed, err
+		}
+		if err := p.store.Save(ctx, item); err != nil {
+			return changed, err
+		}
+		changed++
+
+		if !p.retagIndexedItem(ctx, item.ID, tags) {
+			indexFailed++
+		}
+	}
+
+	p.logger.Info("Bulk tag edit completed", logger.Fields{
+		"user_id":      userID,
+		"from":         from,
+		"to":           to,
+		"matched":      len(items),
+		"changed":      changed,
+		"index_failed": indexFailed,
+	})
+
+	return changed, nil
+}
+
+// retagIndexedItem 尽力更新索引中文档的标签元数据，搜索引擎不可用或更新失败时返回false（内容未索引时视为成功）
+func (p *Processor) retagIndexedItem(ctx context.Context, id string, tags []string) bool {
+	if p.searchEngine == nil {
+		return false
+	}
+
+	err := p.searchEngine.UpdateDocumentMetadata(ctx, id, map[string]interface{}{
+		"tags":                    tags,
+		vector.MetadataKeyHasTags: len(tags) > 0,
+	})
+	if err == nil {
+		return true
+	}
+	if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeResourceNotFound) {
+		return true
+	}
+
+	p.logger.Warn("Failed to update tags in index, index is out of sync until the item is reprocessed", logger.Fields{
+		"content_id": id,
+		"error":      err.Error(),
+	})
+	return false
+}
+
+// retagSources 在处理数据记录的标签来源中同样替换from标签（to为空时删除），没有标签来源记录时不修改
+func retagSources(item *models.ContentItem, from, to string) error {
+	processedData := item.GetProcessedData()
+	if _, exists := processedData[TagSourcesKey]; !exists {
+		return nil
+	}
+
+	sources := make(map[string][]string, 2)
+	for _, source := range []TagSource{TagSourceUser, TagSourceModel} {
+		tags, _ := replaceTag(tagsFromSource(processedData[TagSourcesKey], source), from, to)
+		sources[string(source)] = tags
+	}
+	processedData[TagSourcesKey] = sources
+	return item.SetProcessedData(processedData)
+}
+
+// replaceTag 将标签列表中的from替换为to（to为空时删除，to已存在时不重复添加），返回新列表和是否有变化
+func replaceTag(tags []string, from, to string) ([]string, bool) {
+	hasTarget := false
+	for _, tag := range tags {
+		if to != "" && tag == to {
+			hasTarget = true
+		}
+	}
+
+	result := make([]string, 0, len(tags))
+	modified := false
+	for _, tag := range tags {
+		if tag != from {
+			result = append(result, tag)
+			continue
+		}
+		modified = true
+		if to != "" && !hasTarget {
+			result = append(result, to)
+			hasTarget = true
+		}
+	}
+	return result, modified
+}
@@ -0,0 +1,200 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/vector"
+)
+
+// SearchScope 用户保存的搜索范围
+type SearchScope struct {
+	Name      string                `json:"name"`       // 范围名称
+	UserID    string                `json:"user_id"`    // 用户ID
+	Filters   *vector.SearchOptions `json:"filters"`    // 保存的过滤条件（不含查询文本）
+	CreatedAt time.Time             `json:"created_at"` // 创建时间
+	UpdatedAt time.Time             `json:"updated_at"` // 更新时间
+}
+
+// SaveSearchScope 保存用户的搜索范围，同名范围会被覆盖
+func (p *Processor) SaveSearchScope(ctx context.Context, userID, name string, options *vector.SearchOptions) (*SearchScope, error) {
+	name = strings.TrimSpace(name)
+	if userID == "" {
+		return nil, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+	if name == "" {
+		return nil, errors.ErrValidationFailed("name", "cannot be empty")
+	}
+	if options == nil {
+		return nil, errors.ErrValidationFailed("filters", "cannot be nil")
+	}
+	if options.RankingStrategy != "" && !vector.IsValidRankingStrategy(options.RankingStrategy) {
+		return nil, errors.ErrValidationFailed("filters.ranking_strategy", "unknown ranking strategy: "+string(options.RankingStrategy))
+	}
+
+	if p.store == nil {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+
+	// 范围只保存过滤条件，查询文本和用户由每次搜索提供
+	filters := *options
+	filters.Query = ""
+	filters.UserID = ""
+
+	data, err := json.Marshal(&filters)
+	if err != nil {
+		return nil, errors.ErrValidationFailed("filters", err.Error())
+	}
+
+	record := models.NewSearchScope(userID, name, string(data))
+	if err := p.store.SaveSearchScope(ctx, record); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Search scope saved", logger.Fields{
+		"user_id": userID,
+		"name":    name,
+	})
+
+	return searchScopeOf(record)
+}
+
+// GetSearchScope 获取用户的指定搜索范围
+func (p *Processor) GetSearchScope(ctx context.Context, userID, name string) (*SearchScope, error) {
+	if userID == "" {
+		return nil, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+
+	if p.store == nil {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+
+	record, err := p.store.GetSearchScope(ctx, userID, strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return searchScopeOf(record)
+}
+
+// ListSearchScopes 列出用户保存的搜索范围
+func (p *Processor) ListSearchScopes(ctx context.Context, userID string) ([]*SearchScope, error) {
+	if userID == "" {
+		return nil, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+
+	if p.store == nil {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+
+	records, err := p.store.ListSearchScopes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]*SearchScope, 0, len(records))
+	for _, record := range records {
+		scope, err := searchScopeOf(record)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, scope)
+	}
+
+	return scopes, nil
+}
+
+// searchScopeOf 将存储的搜索范围转换为带过滤条件的范围
+func searchScopeOf(record *models.SearchScope) (*SearchScope, error) {
+	filters := &vector.SearchOptions{}
+	if record.Filters != "" {
+		if err := json.Unmarshal([]byte(record.Filters), filters); err != nil {
+			return nil, errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to decode search scope filters").
+				WithCause(err).
+				WithContext(map[string]interface{}{
+					"user_id": record.UserID,
+					"name":    record.Name,
+				})
+		}
+	}
+
+	return &SearchScope{
+		Name:      record.Name,
+		UserID:    record.UserID,
+		Filters:   filters,
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+	}, nil
+}
+
+// ApplySearchScope 将范围的过滤条件合并到搜索选项，搜索选项中已显式设置的字段优先
+func ApplySearchScope(options *vector.SearchOptions, scope *SearchScope) {
+	if options == nil || scope == nil || scope.Filters == nil {
+		return
+	}
+	filters := scope.Filters
+
+	if len(options.ContentTypes) == 0 {
+		options.ContentTypes = filters.ContentTypes
+	}
+	if options.TopK <= 0 {
+		options.TopK = filters.TopK
+	}
+	if options.MinSimilarity <= 0 {
+		options.MinSimilarity = filters.MinSimilarity
+	}
+	if options.TimeRange == nil {
+		options.TimeRange = filters.TimeRange
+	}
+	if len(options.Tags) == 0 {
+		options.Tags = filters.Tags
+	}
+	if options.ImportanceThreshold <= 0 {
+		options.ImportanceThreshold = filters.ImportanceThreshold
+	}
+	if options.RankingStrategy == "" {
+		options.RankingStrategy = filters.RankingStrategy
+	}
+	if options.MaxResults <= 0 {
+		options.MaxResults = filters.MaxResults
+	}
+	if options.DuplicateThreshold <= 0 {
+		options.DuplicateThreshold = filters.DuplicateThreshold
+	}
+	options.CollapseDuplicates = options.CollapseDuplicates || filters.CollapseDuplicates
+	if options.MinPerContentType <= 0 {
+		options.MinPerContentType = filters.MinPerContentType
+	}
+	options.IncludeLowQuality = options.IncludeLowQuality || filters.IncludeLowQuality
+	if len(options.Languages) == 0 {
+		options.Languages = filters.Languages
+	}
+	options.IncludeUnknownLanguage = options.IncludeUnknownLanguage || filters.IncludeUnknownLanguage
+
+	if options.RequireSummary == nil {
+		options.RequireSummary = filters.RequireSummary
+	}
+	if options.RequireTags == nil {
+		options.RequireTags = filters.RequireTags
+	}
+	if options.RequireIndexed == nil {
+		options.RequireIndexed = filters.RequireIndexed
+	}
+
+	// 元数据过滤按键合并，同名键以请求为准
+	if len(filters.MetadataFilters) > 0 {
+		merged := make(map[string]interface{}, len(filters.MetadataFilters)+len(options.MetadataFilters))
+		for key, value := range filters.MetadataFilters {
+			merged[key] = value
+		}
+		for key, value := range options.MetadataFilters {
+			merged[key] = value
+		}
+		options.MetadataFilters = merged
+	}
+}
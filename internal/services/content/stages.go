@@ -0,0 +1,76 @@
+package content
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"memoro/internal/errors"
+)
+
+// ProcessingStage 内容处理阶段
+type ProcessingStage string
+
+const (
+	StageExtract   ProcessingStage = "extract"   // 内容提取
+	StageClassify  ProcessingStage = "classify"  // 分类和重要性评分
+	StageSummarize ProcessingStage = "summarize" // 摘要生成
+	StageTag       ProcessingStage = "tag"       // 标签生成
+	StageVectorize ProcessingStage = "vectorize" // 向量化和索引
+
+	// 以下阶段只出现在阶段指标中
+	StageTransform ProcessingStage = "transform" // 内容转换器
+	StagePersist   ProcessingStage = "persist"   // 保存内容项
+)
+
+// stageTimeout 获取阶段超时，0表示只受总超时限制
+func (p *Processor) stageTimeout(stage ProcessingStage) time.Duration {
+	timeouts := p.config.StageTimeouts
+	switch stage {
+	case StageExtract:
+		return timeouts.Extract
+	case StageClassify:
+		return timeouts.Classify
+	case StageSummarize:
+		return timeouts.Summarize
+	case StageTag:
+		return timeouts.Tag
+	case StageVectorize:
+		return timeouts.Vectorize
+	default:
+		return 0
+	}
+}
+
+// withStageTimeout 从总处理上下文派生阶段上下文（总超时仍为上限）
+func (p *Processor) withStageTimeout(ctx context.Context, stage ProcessingStage) (context.Context, context.CancelFunc) {
+	if timeout := p.stageTimeout(stage); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// stageError 阶段上下文超时（而非总上下文结束）时转换为阶段超时错误，其他错误原样返回
+func (p *Processor) stageError(ctx, stageCtx context.Context, stage ProcessingStage, err error) error {
+	if err == nil {
+		return nil
+	}
+	if stderrors.Is(stageCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return errors.ErrStageTimeout(string(stage), p.stageTimeout(stage)).WithCause(err)
+	}
+	return err
+}
+
+// timedOutStage 获取阶段超时错误对应的阶段，其他错误返回空
+func timedOutStage(err error) ProcessingStage {
+	var memoErr *errors.MemoroError
+	if !stderrors.As(err, &memoErr) || !memoErr.IsCode(errors.ErrCodeStageTimeout) {
+		return ""
+	}
+	if errContext, ok := memoErr.Context.(map[string]interface{}); ok {
+		if stage, ok := errContext["stage"].(string); ok {
+			return ProcessingStage(stage)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package content
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/models"
+	"memoro/internal/services/language"
+	"memoro/internal/services/llm"
+)
+
+// SummarySource 摘要来源
+type SummarySource string
+
+const (
+	SummarySourceGenerated SummarySource = "generated" // 由LLM生成
+	SummarySourceDerived   SummarySource = "derived"   // 内容过短，直接使用内容作为一句话摘要
+)
+
+// SummarySourceKey 摘要来源在元数据中的键
+const SummarySourceKey = "summary_source"
+
+// SummaryLevel 摘要级别
+type SummaryLevel string
+
+const (
+	SummaryLevelOneLine   SummaryLevel = "one_line"  // 一句话摘要
+	SummaryLevelParagraph SummaryLevel = "paragraph" // 段落摘要
+	SummaryLevelDetailed  SummaryLevel = "detailed"  // 详细摘要
+)
+
+// selectSummaryLevel 返回只包含指定级别的摘要，级别为空时返回全部级别；对应级别没有内容时返回false
+func selectSummaryLevel(summary models.Summary, level SummaryLevel) (models.Summary, bool, error) {
+	switch level {
+	case "":
+		return summary, summary.OneLine != "" || summary.Paragraph != "" || summary.Detailed != "", nil
+	case SummaryLevelOneLine:
+		return models.Summary{OneLine: summary.OneLine}, summary.OneLine != "", nil
+	case SummaryLevelParagraph:
+		return models.Summary{Paragraph: summary.Paragraph}, summary.Paragraph != "", nil
+	case SummaryLevelDetailed:
+		return models.Summary{Detailed: summary.Detailed}, summary.Detailed != "", nil
+	default:
+		return models.Summary{}, false, errors.ErrValidationFailed("level", "must be one of one_line, paragraph, detailed")
+	}
+}
+
+// shouldDeriveSummary 内容词数低于配置的下限时不调用LLM生成摘要
+func shouldDeriveSummary(summaryConfig config.SummaryLevelsConfig, content string) bool {
+	return summaryConfig.MinContentWords > 0 && language.CountWords(content) < summaryConfig.MinContentWords
+}
+
+// deriveSummary 使用内容本身作为一句话摘要（合并为单行并截断到一句话摘要的长度上限），段落和详细摘要留空
+func deriveSummary(summaryConfig config.SummaryLevelsConfig, content string) *llm.SummaryResult {
+	oneLine := strings.Join(strings.Fields(content), " ")
+
+	if maxLength := summaryConfig.OneLineMaxLength; maxLength > 0 && len(oneLine) > maxLength {
+		const ellipsis = "..."
+		cut := maxLength - len(ellipsis)
+		if cut < 0 {
+			cut = 0
+		}
+		// 不截断多字节字符，以空格分词的文本尽量在词边界截断
+		for cut > 0 && !utf8.RuneStart(oneLine[cut]) {
+			cut--
+		}
+		if space := strings.LastIndex(oneLine[:cut], " "); space > cut/2 {
+			cut = space
+		}
+		oneLine = strings.TrimSpace(oneLine[:cut]) + ellipsis
+	}
+
+	return &llm.SummaryResult{OneLine: oneLine}
+}
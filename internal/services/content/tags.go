@@ -0,0 +1,126 @@
+package content
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"memoro/internal/services/llm"
+)
+
+// TagSource 标签来源
+type TagSource string
+
+const (
+	TagSourceUser  TagSource = "user"  // 用户提供（ProcessingOptions.ExistingTags）
+	TagSourceModel TagSource = "model" // LLM生成
+)
+
+// TagSourcesKey 处理数据中记录各来源标签的键
+const TagSourcesKey = "tag_sources"
+
+// userTagConfidence 用户提供标签的置信度（用户标签视为确定）
+const userTagConfidence = 1.0
+
+// canonicalTagKey 规范化标签用于去重：忽略大小写，空白、连字符和下划线视为同一分隔符，再按别名表映射
+func canonicalTagKey(tag string, aliases map[string]string) string {
+	key := normalizeTagText(tag)
+	if canonical, exists := aliases[key]; exists {
+		return normalizeTagText(canonical)
+	}
+	return key
+}
+
+// normalizeTagText 统一标签文本的大小写和分隔符
+func normalizeTagText(tag string) string {
+	fields := strings.FieldsFunc(strings.ToLower(strings.TrimSpace(tag)), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-' || r == '_'
+	})
+	return strings.Join(fields, " ")
+}
+
+// mergedTag 合并中的标签
+type mergedTag struct {
+	display    string
+	count      int
+	confidence float64
+	order      int
+	sources    map[TagSource]bool
+}
+
+// mergeTags 合并用户提供和LLM生成的标签：按规范形式折叠大小写和别名变体，
+// 超出maxTags时保留出现次数多、置信度高的标签（相同时保持原有顺序）。
+// 返回合并后的标签和按来源划分的标签（两个来源都有的标签同时出现在两边）
+func mergeTags(existing []string, generated *llm.TagResult, maxTags int, aliases map[string]string) ([]string, map[TagSource][]string) {
+	normalizedAliases := make(map[string]string, len(aliases))
+	for alias, canonical := range aliases {
+		normalizedAliases[normalizeTagText(alias)] = canonical
+	}
+
+	merged := make(map[string]*mergedTag)
+	ordered := make([]*mergedTag, 0)
+	add := func(tag string, source TagSource, confidence float64) {
+		tag = strings.TrimSpace(tag)
+		key := canonicalTagKey(tag, normalizedAliases)
+		if key == "" {
+			return
+		}
+
+		entry, exists := merged[key]
+		if !exists {
+			display := tag
+			if canonical, aliased := normalizedAliases[normalizeTagText(tag)]; aliased {
+				display = canonical
+			}
+			entry = &mergedTag{display: display, order: len(ordered), sources: make(map[TagSource]bool)}
+			merged[key] = entry
+			ordered = append(ordered, entry)
+		}
+		entry.count++
+		entry.sources[source] = true
+		if confidence > entry.confidence {
+			entry.confidence = confidence
+		}
+	}
+
+	for _, tag := range existing {
+		add(tag, TagSourceUser, userTagConfidence)
+	}
+	if generated != nil {
+		for _, tag := range generated.Tags {
+			add(tag, TagSourceModel, generated.Confidence[tag])
+		}
+	}
+
+	ranked := make([]*mergedTag, len(ordered))
+	copy(ranked, ordered)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].count != ranked[j].count {
+			return ranked[i].count > ranked[j].count
+		}
+		return ranked[i].confidence > ranked[j].confidence
+	})
+	if maxTags > 0 && len(ranked) > maxTags {
+		ranked = ranked[:maxTags]
+	}
+	// 截断后恢复原有顺序（用户标签在前）
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].order < ranked[j].order
+	})
+
+	tags := make([]string, 0, len(ranked))
+	sources := map[TagSource][]string{
+		TagSourceUser:  {},
+		TagSourceModel: {},
+	}
+	for _, entry := range ranked {
+		tags = append(tags, entry.display)
+		for _, source := range []TagSource{TagSourceUser, TagSourceModel} {
+			if entry.sources[source] {
+				sources[source] = append(sources[source], entry.display)
+			}
+		}
+	}
+
+	return tags, sources
+}
@@ -0,0 +1,308 @@
+package content
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/llm"
+	"memoro/internal/services/vector"
+)
+
+// defaultThreadMaxMessages 参与线程汇总的最近消息数默认上限
+const defaultThreadMaxMessages = 50
+
+// defaultThreadRollupInterval 同一线程两次汇总之间的默认最小间隔
+const defaultThreadRollupInterval = 30 * time.Second
+
+// ThreadMessageCountKey 线程汇总文档处理数据中的消息数量字段
+const ThreadMessageCountKey = "thread_message_count"
+
+// threadRollups 线程汇总的调度：同一线程的汇总串行执行，距上次汇总不足间隔时合并为一次延迟汇总，
+// 避免每条消息都重新汇总整个线程（零值可用）
+type threadRollups struct {
+	mu      sync.Mutex
+	threads map[string]*threadRollupState // 按 user_id|thread_id
+	wg      sync.WaitGroup                // 进行中的延迟汇总
+	closed  bool
+}
+
+// threadRollupState 单个线程的汇总状态
+type threadRollupState struct {
+	running sync.Mutex         // 同一线程的汇总串行执行
+	active  int                // 正在汇总或等待汇总的调用数（受threadRollups.mu保护）
+	last    time.Time          // 最近一次汇总开始的时间
+	pending *time.Timer        // 已安排的延迟汇总
+	request *ProcessingRequest // 延迟汇总使用的请求（间隔内任一消息敏感时按敏感处理）
+}
+
+// threadRollupKey 线程汇总状态的键
+func threadRollupKey(userID, threadID string) string {
+	return userID + "|" + threadID
+}
+
+// acquire 获取线程状态并登记一次汇总调用；距上次汇总不足间隔时返回需要等待的时长（此时不登记）
+func (tr *threadRollups) acquire(key string, interval time.Duration, now time.Time) (*threadRollupState, time.Duration) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if tr.threads == nil {
+		tr.threads = make(map[string]*threadRollupState)
+	}
+	tr.prune(key, interval, now)
+
+	state, exists := tr.threads[key]
+	if !exists {
+		state = &threadRollupState{}
+		tr.threads[key] = state
+	}
+	if !state.last.IsZero() {
+		if wait := state.last.Add(interval).Sub(now); wait > 0 {
+			return state, wait
+		}
+	}
+	state.active++
+	state.last = now
+	return state, 0
+}
+
+// release 结束一次汇总调用
+func (tr *threadRollups) release(state *threadRollupState) {
+	tr.mu.Lock()
+	state.active--
+	tr.mu.Unlock()
+}
+
+// prune 清理空闲超过汇总间隔的线程状态（不含当前线程），调用方持有mu
+func (tr *threadRollups) prune(current string, interval time.Duration, now time.Time) {
+	for key, state := range tr.threads {
+		if key != current && state.active == 0 && state.pending == nil && now.Sub(state.last) >= interval {
+			delete(tr.threads, key)
+		}
+	}
+}
+
+// close 取消尚未执行的延迟汇总并等待进行中的汇总结束，返回取消的数量
+func (tr *threadRollups) close() int {
+	tr.mu.Lock()
+	tr.closed = true
+	cancelled := 0
+	for _, state := range tr.threads {
+		if state.pending != nil && state.pending.Stop() {
+			state.pending = nil
+			state.request = nil
+			cancelled++
+			tr.wg.Done()
+		}
+	}
+	tr.mu.Unlock()
+
+	tr.wg.Wait()
+	return cancelled
+}
+
+// scheduleThreadRollup 汇总请求所属的线程：距上次汇总已超过间隔时立即汇总，否则安排一次延迟汇总
+// （间隔内的后续消息共用这一次），返回线程文档ID以及是否延迟执行
+func (p *Processor) scheduleThreadRollup(ctx context.Context, request *ProcessingRequest) (string, bool, error) {
+	interval := p.config.Threads.RollupInterval
+	if interval <= 0 {
+		interval = defaultThreadRollupInterval
+	}
+	key := threadRollupKey(request.UserID, request.ThreadID)
+
+	state, wait := p.threads.acquire(key, interval, time.Now())
+	if wait > 0 {
+		p.deferThreadRollup(key, state, request, wait)
+		return ThreadDocumentID(request.UserID, request.ThreadID), true, nil
+	}
+
+	defer p.threads.release(state)
+	state.running.Lock()
+	defer state.running.Unlock()
+
+	documentID, err := p.rollupThread(ctx, request)
+	return documentID, false, err
+}
+
+// deferThreadRollup 安排线程的延迟汇总，已有待执行的汇总时不重复安排
+func (p *Processor) deferThreadRollup(key string, state *threadRollupState, request *ProcessingRequest, wait time.Duration) {
+	p.threads.mu.Lock()
+	defer p.threads.mu.Unlock()
+	if p.threads.closed {
+		return
+	}
+	if state.pending != nil {
+		if request.Sensitive {
+			state.request.Sensitive = true
+		}
+		return
+	}
+
+	// 延迟汇总只使用线程标识和汇总相关的选项，不持有原请求
+	state.request = &ProcessingRequest{
+		ID:        request.ID,
+		UserID:    request.UserID,
+		ThreadID:  request.ThreadID,
+		Context:   request.Context,
+		Sensitive: request.Sensitive,
+		Options:   request.Options,
+	}
+
+	p.threads.wg.Add(1)
+	state.pending = time.AfterFunc(wait, func() {
+		defer p.threads.wg.Done()
+
+		p.threads.mu.Lock()
+		deferred := state.request
+		state.pending = nil
+		state.request = nil
+		if p.threads.closed {
+			p.threads.mu.Unlock()
+			return
+		}
+		state.active++
+		state.last = time.Now()
+		p.threads.mu.Unlock()
+		defer p.threads.release(state)
+
+		state.running.Lock()
+		defer state.running.Unlock()
+
+		ctx := p.processCtx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if _, err := p.rollupThread(ctx, deferred); err != nil {
+			p.logger.Warn("Deferred thread rollup failed", logger.Fields{
+				"thread_key": key,
+				"thread_id":  deferred.ThreadID,
+				"error":      err.Error(),
+			})
+		}
+	})
+}
+
+// ThreadDocumentID 生成用户会话线程汇总文档的稳定ID，同一线程的每次汇总更新同一个文档
+func ThreadDocumentID(userID, threadID string) string {
+	sum := sha256.Sum256([]byte(userID + "|" + threadID))
+	return models.ThreadDocumentIDPrefix + hex.EncodeToString(sum[:])[:16]
+}
+
+// rollupThread 将线程内的消息按时间顺序合并为一个线程文档，生成摘要后写入向量索引（已存在时更新），返回线程文档ID
+func (p *Processor) rollupThread(ctx context.Context, request *ProcessingRequest) (string, error) {
+	maxMessages := p.config.Threads.MaxMessages
+	if maxMessages <= 0 {
+		maxMessages = defaultThreadMaxMessages
+	}
+
+	messages, err := p.searchEngine.ThreadMessages(ctx, request.UserID, request.ThreadID, maxMessages)
+	if err != nil {
+		return "", err
+	}
+	if len(messages) == 0 {
+		return "", errors.ErrValidationFailed("thread_id", "thread has no indexed messages")
+	}
+	// 只有一条消息的线程（如新的邮件会话）不生成汇总文档
+	if len(messages) < 2 {
+		return "", nil
+	}
+
+	contents := make([]string, 0, len(messages))
+	for _, message := range messages {
+		if text := strings.TrimSpace(message.Content); text != "" {
+			contents = append(contents, text)
+		}
+	}
+	threadContent := strings.Join(contents, "\n\n")
+
+	threadItem := models.NewContentItem(models.ContentTypeText, threadContent, request.UserID)
+	if threadItem == nil {
+		return "", errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeValidationFailed, "Failed to create thread content item")
+	}
+	threadItem.ID = ThreadDocumentID(request.UserID, request.ThreadID)
+
+	// 线程中有敏感消息时线程文档也按敏感内容加密保存
+	threadItem.Sensitive = request.Sensitive
+	if !threadItem.Sensitive && p.store != nil {
+		if existing, err := p.store.Get(ctx, threadItem.ID); err == nil {
+			threadItem.Sensitive = existing.Sensitive
+		}
+	}
+
+	processedData := threadItem.GetProcessedData()
+	processedData[vector.MetadataKeyThreadID] = request.ThreadID
+	processedData[vector.MetadataKeyThreadRollup] = true
+	processedData["title"] = fmt.Sprintf("Thread %s", request.ThreadID)
+	processedData[ThreadMessageCountKey] = len(messages)
+
+	if request.Options.EnableSummary {
+		summary, source, err := p.summarizeThread(ctx, request, threadContent)
+		if err != nil {
+			// 摘要失败时仍索引线程文档，下一条消息处理时重新汇总
+			p.logger.Warn("Thread summary failed", logger.Fields{
+				"request_id": request.ID,
+				"thread_id":  request.ThreadID,
+				"error":      err.Error(),
+			})
+		} else {
+			processedData[SummarySourceKey] = string(source)
+			threadItem.SetSummary(models.Summary{
+				OneLine:   summary.OneLine,
+				Paragraph: summary.Paragraph,
+				Detailed:  summary.Detailed,
+			})
+		}
+	}
+	threadItem.SetProcessedData(processedData)
+
+	if existing, err := p.searchEngine.GetDocument(ctx, threadItem.ID); err == nil && existing != nil {
+		err = p.searchEngine.UpdateDocument(ctx, threadItem)
+		if err != nil {
+			return "", err
+		}
+	} else if err := p.searchEngine.IndexDocument(ctx, threadItem); err != nil {
+		return "", err
+	}
+
+	if p.store != nil {
+		if err := p.store.Save(ctx, threadItem); err != nil {
+			p.logger.Warn("Failed to persist thread document", logger.Fields{
+				"request_id": request.ID,
+				"thread_id":  request.ThreadID,
+				"error":      err.Error(),
+			})
+		}
+	}
+
+	p.logger.Debug("Thread rolled up", logger.Fields{
+		"request_id":    request.ID,
+		"thread_id":     request.ThreadID,
+		"document_id":   threadItem.ID,
+		"message_count": len(messages),
+	})
+
+	return threadItem.ID, nil
+}
+
+// summarizeThread 生成线程内容的摘要，内容过短时直接使用内容作为一句话摘要
+func (p *Processor) summarizeThread(ctx context.Context, request *ProcessingRequest, threadContent string) (*llm.SummaryResult, SummarySource, error) {
+	if shouldDeriveSummary(p.config.SummaryLevels, threadContent) {
+		return deriveSummary(p.config.SummaryLevels, threadContent), SummarySourceDerived, nil
+	}
+
+	stageCtx, cancel := p.withStageTimeout(ctx, StageSummarize)
+	defer cancel()
+	summary, err := p.summarizer.GenerateSummary(stageCtx, llm.SummaryRequest{
+		Content:     threadContent,
+		ContentType: models.ContentTypeText,
+		Context:     request.Context,
+	})
+	return summary, SummarySourceGenerated, p.stageError(ctx, stageCtx, StageSummarize, err)
+}
@@ -0,0 +1,235 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/models"
+	"memoro/internal/services/llm"
+	"memoro/internal/services/vector"
+)
+
+// TestProcessor_ThreadRollup 测试同一线程的消息汇总为线程文档，并在搜索结果中折叠
+func TestProcessor_ThreadRollup(t *testing.T) {
+	messages := []string{
+		"周一讨论发布计划：版本定在下周五。",
+		"周二确认发布前需要完成性能测试。",
+		"周三决定发布说明由产品团队撰写。",
+	}
+
+	// 包含全部三条消息的摘要请求返回线程摘要，其余返回单条消息摘要
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":3,"total_tokens":3}}`)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		answer := "单条消息摘要"
+		if strings.Contains(string(body), "下周五") && strings.Contains(string(body), "性能测试") && strings.Contains(string(body), "产品团队") {
+			answer = "线程摘要：下周五发布，发布前完成性能测试，产品团队撰写发布说明"
+		}
+		response, _ := json.Marshal(map[string]interface{}{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"model":   "test-model",
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": answer}, "finish_reason": "stop"}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 10, "total_tokens": 20},
+		})
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: server.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+		Processing: config.ProcessingConfig{
+			MaxContentSize: 100000,
+			SummaryLevels:  config.SummaryLevelsConfig{OneLineMaxLength: 200, ParagraphMaxLength: 500, DetailedMaxLength: 1000},
+		},
+	}))
+	client, err := llm.NewClient()
+	require.NoError(t, err)
+	summarizer, err := llm.NewSummarizer(client)
+	require.NoError(t, err)
+	engine, err := vector.NewSearchEngineWithStore(vector.NewMemoryStore())
+	require.NoError(t, err)
+	defer engine.Close()
+
+	processor := newTestProcessor(t)
+	processor.summarizer = summarizer
+	processor.searchEngine = engine
+	processor.config.Threads.Aggregate = true
+	processor.config.Threads.RollupInterval = time.Nanosecond
+
+	ctx := context.Background()
+	messageIDs := make([]string, 0, len(messages))
+	var threadDocumentID string
+	for i, message := range messages {
+		result, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID:          fmt.Sprintf("req-%d", i),
+			Content:     message,
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			ThreadID:    "chat-42",
+			Options:     ProcessingOptions{EnableSummary: true, EnableVectorization: true},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, result.VectorResult)
+		require.True(t, result.VectorResult.Indexed)
+		messageIDs = append(messageIDs, result.ContentItem.ID)
+		threadDocumentID = result.ThreadDocumentID
+		// 消息之间留出间隔，保证线程内容按时间排序
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	t.Run("生成线程级摘要文档", func(t *testing.T) {
+		assert.Equal(t, ThreadDocumentID("user-1", "chat-42"), threadDocumentID)
+
+		doc, err := engine.GetDocument(ctx, threadDocumentID)
+		require.NoError(t, err)
+		assert.Equal(t, strings.Join(messages, "\n\n"), doc.Content)
+		assert.Equal(t, true, doc.Metadata[vector.MetadataKeyThreadRollup])
+		assert.Equal(t, "chat-42", doc.Metadata[vector.MetadataKeyThreadID])
+		assert.Contains(t, doc.Metadata["summary_oneline"], "线程摘要")
+	})
+
+	t.Run("单条消息仍可查询", func(t *testing.T) {
+		threadMessages, err := engine.ThreadMessages(ctx, "user-1", "chat-42", 0)
+		require.NoError(t, err)
+		require.Len(t, threadMessages, 3)
+		for i, doc := range threadMessages {
+			assert.Equal(t, messageIDs[i], doc.ID)
+		}
+
+		response, err := engine.Search(ctx, &vector.SearchOptions{Query: "发布计划", UserID: "user-1", TopK: 10})
+		require.NoError(t, err)
+		assert.Len(t, response.Results, 4)
+	})
+
+	t.Run("搜索结果按线程折叠", func(t *testing.T) {
+		response, err := engine.Search(ctx, &vector.SearchOptions{Query: "发布计划", UserID: "user-1", TopK: 10, CollapseThreads: true})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 1)
+
+		result := response.Results[0]
+		assert.Equal(t, threadDocumentID, result.DocumentID)
+		assert.ElementsMatch(t, messageIDs, result.ThreadMessages)
+		assert.Equal(t, 3, response.Metadata["collapsed_thread_messages"])
+	})
+}
+
+// TestProcessor_ThreadRollupInterval 测试汇总间隔内的后续消息合并为一次延迟汇总
+func TestProcessor_ThreadRollupInterval(t *testing.T) {
+	messages := []string{
+		"周一讨论发布计划：版本定在下周五。",
+		"周二确认发布前需要完成性能测试。",
+		"周三决定发布说明由产品团队撰写。",
+	}
+
+	// 分别统计只包含前两条消息（中间汇总）和包含全部消息的线程摘要请求
+	var partialRollups, fullRollups int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":3,"total_tokens":3}}`)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "下周五") && strings.Contains(string(body), "性能测试") {
+			if strings.Contains(string(body), "产品团队") {
+				atomic.AddInt32(&fullRollups, 1)
+			} else {
+				atomic.AddInt32(&partialRollups, 1)
+			}
+		}
+		response, _ := json.Marshal(map[string]interface{}{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"model":   "test-model",
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": "摘要"}, "finish_reason": "stop"}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 10, "total_tokens": 20},
+		})
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: server.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+		Processing: config.ProcessingConfig{
+			MaxContentSize: 100000,
+			SummaryLevels:  config.SummaryLevelsConfig{OneLineMaxLength: 200, ParagraphMaxLength: 500, DetailedMaxLength: 1000},
+		},
+	}))
+	client, err := llm.NewClient()
+	require.NoError(t, err)
+	summarizer, err := llm.NewSummarizer(client)
+	require.NoError(t, err)
+	engine, err := vector.NewSearchEngineWithStore(vector.NewMemoryStore())
+	require.NoError(t, err)
+	defer engine.Close()
+
+	processor := newTestProcessor(t)
+	processor.summarizer = summarizer
+	processor.searchEngine = engine
+	processor.config.Threads.Aggregate = true
+	processor.config.Threads.RollupInterval = 200 * time.Millisecond
+
+	ctx := context.Background()
+	threadDocumentID := ThreadDocumentID("user-1", "chat-42")
+	var pending []bool
+	for i, message := range messages {
+		result, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID:          fmt.Sprintf("req-%d", i),
+			Content:     message,
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			ThreadID:    "chat-42",
+			Options:     ProcessingOptions{EnableSummary: true, EnableVectorization: true},
+		})
+		require.NoError(t, err)
+		pending = append(pending, result.ThreadRollupPending)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	t.Run("单条消息不生成汇总，间隔内的消息延迟汇总", func(t *testing.T) {
+		assert.Equal(t, []bool{false, true, true}, pending)
+		_, err := engine.GetDocument(ctx, threadDocumentID)
+		assert.Error(t, err)
+	})
+
+	t.Run("延迟汇总包含间隔内的全部消息且只汇总一次", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			doc, err := engine.GetDocument(ctx, threadDocumentID)
+			return err == nil && doc.Content == strings.Join(messages, "\n\n")
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Zero(t, atomic.LoadInt32(&partialRollups))
+		assert.NotZero(t, atomic.LoadInt32(&fullRollups))
+	})
+
+	t.Run("关闭时取消尚未执行的延迟汇总", func(t *testing.T) {
+		_, err := processor.doProcessing(ctx, &ProcessingRequest{
+			ID:          "req-late",
+			Content:     "周四补充：发布会改到下午。",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			ThreadID:    "chat-42",
+			Options:     ProcessingOptions{EnableSummary: true, EnableVectorization: true},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, processor.threads.close())
+	})
+}
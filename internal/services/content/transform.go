@@ -0,0 +1,142 @@
+package content
+
+import (
+	"context"
+	"fmt"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/vector"
+)
+
+// ContentTransformer 内容转换器：在提取和分析之后、向量化索引之前修改内容项（如补充元数据、脱敏、自定义分类），
+// 对内容项的修改会写入向量文档和持久化的内容项
+type ContentTransformer interface {
+	Transform(ctx context.Context, extracted *ExtractedContent, item *models.ContentItem) error
+}
+
+// ContentTransformerFunc 函数形式的内容转换器
+type ContentTransformerFunc func(ctx context.Context, extracted *ExtractedContent, item *models.ContentItem) error
+
+// Transform 调用函数本身
+func (f ContentTransformerFunc) Transform(ctx context.Context, extracted *ExtractedContent, item *models.ContentItem) error {
+	return f(ctx, extracted, item)
+}
+
+// TransformErrorPolicy 转换器出错时的处理方式
+type TransformErrorPolicy string
+
+const (
+	TransformErrorSkip  TransformErrorPolicy = "skip"  // 记录错误并继续执行后续转换器（已做的修改保留）
+	TransformErrorFatal TransformErrorPolicy = "fatal" // 中止处理并返回错误
+)
+
+// registeredTransformer 已注册的转换器
+type registeredTransformer struct {
+	name        string
+	transformer ContentTransformer
+	onError     TransformErrorPolicy
+}
+
+// builtinTransformers 内置转换器，先于注册的转换器执行
+var builtinTransformers = []registeredTransformer{
+	{name: "entities", transformer: ContentTransformerFunc(applyEntityMetadata), onError: TransformErrorSkip},
+}
+
+// RegisterTransformer 注册内容转换器，按注册顺序在内置转换器之后执行，应在开始处理前调用
+func (p *Processor) RegisterTransformer(name string, transformer ContentTransformer, onError TransformErrorPolicy) error {
+	if name == "" {
+		return errors.ErrValidationFailed("name", "cannot be empty")
+	}
+	if transformer == nil {
+		return errors.ErrValidationFailed("transformer", "cannot be nil")
+	}
+	if onError != TransformErrorSkip && onError != TransformErrorFatal {
+		return errors.ErrValidationFailed("on_error", "must be skip or fatal")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, chain := range [][]registeredTransformer{builtinTransformers, p.transformers} {
+		for _, registered := range chain {
+			if registered.name == name {
+				return errors.ErrValidationFailed("name", "transformer already registered: "+name)
+			}
+		}
+	}
+	p.transformers = append(p.transformers, registeredTransformer{name: name, transformer: transformer, onError: onError})
+	return nil
+}
+
+// applyTransformers 按顺序执行内置和注册的转换器，可跳过的错误按转换器名记录在结果中，致命错误中止处理
+func (p *Processor) applyTransformers(ctx context.Context, request *ProcessingRequest, extracted *ExtractedContent, item *models.ContentItem, result *ProcessingResult) error {
+	p.mu.RLock()
+	chain := append(append([]registeredTransformer(nil), builtinTransformers...), p.transformers...)
+	p.mu.RUnlock()
+
+	for _, registered := range chain {
+		err := registered.transformer.Transform(ctx, extracted, item)
+		if err == nil {
+			continue
+		}
+
+		if registered.onError == TransformErrorFatal {
+			p.logger.Error("Content transformer failed", logger.Fields{
+				"request_id":  request.ID,
+				"transformer": registered.name,
+				"error":       err.Error(),
+			})
+			return errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Content transformer failed").
+				WithDetails(fmt.Sprintf("%s: %v", registered.name, err)).
+				WithCause(err)
+		}
+
+		p.logger.Warn("Content transformer failed, skipped", logger.Fields{
+			"request_id":  request.ID,
+			"transformer": registered.name,
+			"error":       err.Error(),
+		})
+		if result.TransformErrors == nil {
+			result.TransformErrors = make(map[string]string)
+		}
+		result.TransformErrors[registered.name] = err.Error()
+	}
+	return nil
+}
+
+// SetCustomMetadata 在内容项的自定义元数据中设置字段（随向量文档索引，可用于过滤），键不能与系统字段冲突
+func SetCustomMetadata(item *models.ContentItem, key string, value interface{}) error {
+	if err := vector.ValidateCustomMetadata(map[string]interface{}{key: value}); err != nil {
+		return err
+	}
+
+	processedData := item.GetProcessedData()
+	custom := make(map[string]interface{})
+	if existing, ok := processedData[vector.CustomMetadataKey].(map[string]interface{}); ok {
+		for k, v := range existing {
+			custom[k] = v
+		}
+	}
+	custom[key] = value
+	processedData[vector.CustomMetadataKey] = custom
+	return item.SetProcessedData(processedData)
+}
+
+// applyEntityMetadata 将分类阶段抽取的实体写入内容项元数据（未执行分类时不做修改）
+func applyEntityMetadata(_ context.Context, _ *ExtractedContent, item *models.ContentItem) error {
+	processedData := item.GetProcessedData()
+	classificationMetadata, ok := processedData["classification_metadata"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	fields := entityMetadataFrom(classificationMetadata)
+	if len(fields) == 0 {
+		return nil
+	}
+	for key, entities := range fields {
+		processedData[key] = entities
+	}
+	return item.SetProcessedData(processedData)
+}
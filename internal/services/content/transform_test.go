@@ -0,0 +1,107 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/models"
+	"memoro/internal/services/vector"
+)
+
+// TestProcessor_Transformers 测试注册的内容转换器在索引前执行及其错误处理方式
+func TestProcessor_Transformers(t *testing.T) {
+	ctx := context.Background()
+	embeddings := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer embeddings.Close()
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{APIBase: embeddings.URL, APIKey: "test-key", Model: "test-model", Timeout: 5 * time.Second},
+	}))
+
+	newProcessor := func(t *testing.T) (*Processor, *vector.MemoryStore) {
+		store := vector.NewMemoryStore()
+		engine, err := vector.NewSearchEngineWithStore(store)
+		require.NoError(t, err)
+		t.Cleanup(func() { engine.Close() })
+
+		processor := newTestProcessor(t)
+		processor.searchEngine = engine
+		return processor, store
+	}
+	process := func(processor *Processor) (*ProcessingResult, error) {
+		return processor.doProcessing(ctx, &ProcessingRequest{
+			ID:          "req-transform",
+			Content:     "季度复盘会议纪要",
+			ContentType: models.ContentTypeText,
+			UserID:      "user-1",
+			Options:     ProcessingOptions{EnableVectorization: true},
+		})
+	}
+	failing := ContentTransformerFunc(func(context.Context, *ExtractedContent, *models.ContentItem) error {
+		return fmt.Errorf("classifier unavailable")
+	})
+
+	t.Run("转换器添加的元数据写入向量文档", func(t *testing.T) {
+		processor, store := newProcessor(t)
+		var order []string
+		require.NoError(t, processor.RegisterTransformer("reviewer", ContentTransformerFunc(func(_ context.Context, extracted *ExtractedContent, item *models.ContentItem) error {
+			order = append(order, "reviewer")
+			assert.Equal(t, "季度复盘会议纪要", extracted.Content)
+			return SetCustomMetadata(item, "reviewed_by", "compliance-bot")
+		}), TransformErrorFatal))
+		require.NoError(t, processor.RegisterTransformer("team", ContentTransformerFunc(func(_ context.Context, _ *ExtractedContent, item *models.ContentItem) error {
+			order = append(order, "team")
+			return SetCustomMetadata(item, "team", "finance")
+		}), TransformErrorSkip))
+
+		result, err := process(processor)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"reviewer", "team"}, order)
+
+		doc, err := store.GetDocument(ctx, result.ContentItem.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "compliance-bot", doc.Metadata["reviewed_by"])
+		assert.Equal(t, "finance", doc.Metadata["team"])
+	})
+
+	t.Run("可跳过的错误记录在结果中并继续处理", func(t *testing.T) {
+		processor, store := newProcessor(t)
+		require.NoError(t, processor.RegisterTransformer("classifier", failing, TransformErrorSkip))
+
+		result, err := process(processor)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"classifier": "classifier unavailable"}, result.TransformErrors)
+		_, err = store.GetDocument(ctx, result.ContentItem.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("致命错误中止处理且不索引", func(t *testing.T) {
+		processor, store := newProcessor(t)
+		require.NoError(t, processor.RegisterTransformer("classifier", failing, TransformErrorFatal))
+
+		_, err := process(processor)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "classifier unavailable")
+		docs, err := store.GetDocumentsByFilter(ctx, map[string]interface{}{"user_id": "user-1"}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, docs)
+	})
+
+	t.Run("拒绝重名、保留字段和无效的错误处理方式", func(t *testing.T) {
+		processor, _ := newProcessor(t)
+		assert.Error(t, processor.RegisterTransformer("entities", failing, TransformErrorSkip))
+		assert.Error(t, processor.RegisterTransformer("custom", failing, "retry"))
+		assert.Error(t, SetCustomMetadata(models.NewContentItem(models.ContentTypeText, "内容", "user-1"), vector.MetadataKeyDeleted, true))
+	})
+}
@@ -0,0 +1,160 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+
+	"memoro/internal/errors"
+	"memoro/internal/models"
+	"memoro/internal/services/vector"
+)
+
+// defaultMaxContentSize 未配置时的最大内容大小（100KB）
+const defaultMaxContentSize = 100000
+
+// ValidationReasonCode 预检不通过的原因代码
+type ValidationReasonCode string
+
+const (
+	ReasonEmpty         ValidationReasonCode = "empty"          // 字段为空
+	ReasonTooLarge      ValidationReasonCode = "too_large"      // 内容超过大小限制
+	ReasonTooShort      ValidationReasonCode = "too_short"      // 内容短于最小长度
+	ReasonInvalidType   ValidationReasonCode = "invalid_type"   // 内容类型无效
+	ReasonQuotaExceeded ValidationReasonCode = "quota_exceeded" // token额度不足
+
+	ReasonInvalidMetadata ValidationReasonCode = "invalid_metadata" // 自定义元数据无效
+	ReasonInvalidURL      ValidationReasonCode = "invalid_url"      // 回调地址无效
+	ReasonInvalidPreset   ValidationReasonCode = "invalid_preset"   // 处理预设未定义
+
+	ReasonEncryptionUnavailable ValidationReasonCode = "encryption_unavailable" // 敏感内容需要静态加密，但内容存储未配置加密
+)
+
+// ValidationReason 预检不通过的具体原因
+type ValidationReason struct {
+	Field   string               `json:"field"`   // 相关字段
+	Code    ValidationReasonCode `json:"code"`    // 原因代码
+	Message string               `json:"message"` // 说明
+
+	err *errors.MemoroError // 原始错误（实际处理路径直接返回）
+}
+
+// ValidationVerdict 内容预检结果
+type ValidationVerdict struct {
+	Accepted        bool                `json:"accepted"`          // 是否会被接受处理
+	Reasons         []*ValidationReason `json:"reasons,omitempty"` // 不通过的原因
+	ContentSize     int                 `json:"content_size"`      // 内容大小（字节）
+	MaxContentSize  int                 `json:"max_content_size"`  // 最大内容大小（字节）
+	EstimatedTokens int                 `json:"estimated_tokens"`  // 预计消耗的token数
+}
+
+// ValidateContent 预检内容是否会被接受处理，不进入处理队列也不消耗LLM额度
+func (p *Processor) ValidateContent(ctx context.Context, request *ProcessingRequest) (*ValidationVerdict, error) {
+	if request == nil {
+		return nil, errors.ErrValidationFailed("request", "cannot be nil")
+	}
+
+	request.UserID = p.resolveUserID(request.UserID)
+	reasons := p.checkContent(request)
+	return &ValidationVerdict{
+		Accepted:        len(reasons) == 0,
+		Reasons:         reasons,
+		ContentSize:     len(request.Content),
+		MaxContentSize:  p.maxContentSize(),
+		EstimatedTokens: vector.EstimateTokens(request.Content),
+	}, nil
+}
+
+// checkContent 检查内容、用户、类型、大小和额度，返回全部不通过的原因（预检和实际处理共用）
+func (p *Processor) checkContent(request *ProcessingRequest) []*ValidationReason {
+	reasons := make([]*ValidationReason, 0)
+
+	maxSize := p.maxContentSize()
+	switch {
+	case request.Content == "":
+		reasons = append(reasons, &ValidationReason{Field: "content", Code: ReasonEmpty, Message: "cannot be empty"})
+	case len(request.Content) > maxSize:
+		reasons = append(reasons, &ValidationReason{Field: "content", Code: ReasonTooLarge,
+			Message: fmt.Sprintf("content too large (max %dKB)", maxSize/1000)})
+	case p.config.MinContentLength > 0 && utf8.RuneCountInString(request.Content) < p.config.MinContentLength:
+		reasons = append(reasons, &ValidationReason{Field: "content", Code: ReasonTooShort,
+			Message: fmt.Sprintf("content too short (min %d characters)", p.config.MinContentLength)})
+	}
+
+	if request.UserID == "" {
+		reasons = append(reasons, &ValidationReason{Field: "user_id", Code: ReasonEmpty, Message: "cannot be empty"})
+	}
+
+	if !models.IsValidContentType(request.ContentType) {
+		reasons = append(reasons, &ValidationReason{Field: "content_type", Code: ReasonInvalidType,
+			Message: fmt.Sprintf("invalid content type: %s", request.ContentType)})
+	}
+
+	if err := vector.ValidateCustomMetadata(request.Metadata); err != nil {
+		reason := &ValidationReason{Field: "metadata", Code: ReasonInvalidMetadata, Message: err.Error()}
+		if memoErr, ok := err.(*errors.MemoroError); ok {
+			reason.Message = memoErr.Details
+			reason.err = memoErr
+		}
+		reasons = append(reasons, reason)
+	}
+
+	if request.Options.Preset != "" && !IsValidPreset(request.Options.Preset) {
+		reasons = append(reasons, &ValidationReason{Field: "options.preset", Code: ReasonInvalidPreset,
+			Message: fmt.Sprintf("unknown processing preset: %s", request.Options.Preset)})
+	}
+
+	if request.CallbackURL != "" && !isValidCallbackURL(request.CallbackURL) {
+		reasons = append(reasons, &ValidationReason{Field: "callback_url", Code: ReasonInvalidURL,
+			Message: "must be an absolute http or https URL"})
+	}
+
+	// 敏感内容只能保存到启用加密的存储（未配置数据库时内容不落盘）
+	if request.Sensitive && p.store != nil && !p.store.EncryptionEnabled() {
+		reasons = append(reasons, &ValidationReason{Field: "sensitive", Code: ReasonEncryptionUnavailable,
+			Message: "encryption at rest is not configured (database.encryption.mode)"})
+	}
+
+	// 额度检查只在内容本身有效时进行
+	if len(reasons) == 0 && p.budget != nil {
+		if err := p.budget.Check(request.UserID, vector.EstimateTokens(request.Content)); err != nil {
+			message := err.Error()
+			if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.Details != "" {
+				message = memoErr.Details
+			}
+			reasons = append(reasons, &ValidationReason{Field: "user_id", Code: ReasonQuotaExceeded, Message: message})
+		}
+	}
+
+	return reasons
+}
+
+// isValidCallbackURL 检查回调地址是否为绝对的http/https地址
+func isValidCallbackURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// toError 转换为实际处理路径返回的错误
+func (r *ValidationReason) toError() *errors.MemoroError {
+	if r.err != nil {
+		return r.err
+	}
+	if r.Code == ReasonQuotaExceeded {
+		return errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeBudgetExceeded, "Token budget exceeded").
+			WithDetails(r.Message)
+	}
+	return errors.ErrValidationFailed(r.Field, r.Message)
+}
+
+// maxContentSize 获取最大内容大小
+func (p *Processor) maxContentSize() int {
+	if p.config.MaxContentSize > 0 {
+		return p.config.MaxContentSize
+	}
+	return defaultMaxContentSize
+}
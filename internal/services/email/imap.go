@@ -0,0 +1,245 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"memoro/internal/errors"
+)
+
+// maxMessageSize 单封邮件的最大字节数（IMAP字面量上限）
+const maxMessageSize = 25 << 20
+
+// imapResponse 一条非标记响应，字面量内容替换为占位符{n}并按顺序保存在literals中
+type imapResponse struct {
+	text     string
+	literals [][]byte
+}
+
+// imapClient 只实现邮件采集所需命令的最小IMAP4rev1客户端
+type imapClient struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+	tagSeq  int
+}
+
+// dialIMAP 连接IMAP服务器并读取问候语
+func dialIMAP(ctx context.Context, address string, useTLS bool, timeout time.Duration) (*imapClient, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if useTLS {
+		host, _, _ := net.SplitHostPort(address)
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, errors.NewMemoroError(errors.ErrorTypeNetwork, errors.ErrCodeSystemGeneric, "Failed to connect to IMAP server").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"address": address,
+			})
+	}
+
+	client := &imapClient{conn: conn, reader: bufio.NewReader(conn), timeout: timeout}
+	client.extendDeadline()
+	greeting, err := client.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		conn.Close()
+		return nil, imapError("Unexpected IMAP greeting", greeting)
+	}
+	return client, nil
+}
+
+// Login 使用账号密码登录
+func (c *imapClient) Login(username, password string) error {
+	_, err := c.command("LOGIN %s %s", quoteIMAP(username), quoteIMAP(password))
+	return err
+}
+
+// Select 选择邮件夹
+func (c *imapClient) Select(mailbox string) error {
+	_, err := c.command("SELECT %s", quoteIMAP(mailbox))
+	return err
+}
+
+// SearchUnseen 获取未读邮件的UID（按UID升序）
+func (c *imapClient) SearchUnseen() ([]uint32, error) {
+	responses, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []uint32
+	for _, response := range responses {
+		fields := strings.Fields(response.text)
+		if len(fields) < 2 || !strings.EqualFold(fields[1], "SEARCH") {
+			continue
+		}
+		for _, field := range fields[2:] {
+			uid, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, imapError("Invalid IMAP search response", response.text)
+			}
+			uids = append(uids, uint32(uid))
+		}
+	}
+	return uids, nil
+}
+
+// FetchMessage 获取邮件原文（使用BODY.PEEK，不改变已读状态）
+func (c *imapClient) FetchMessage(uid uint32) ([]byte, error) {
+	responses, err := c.command("UID FETCH %d (UID BODY.PEEK[])", uid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, response := range responses {
+		if strings.Contains(strings.ToUpper(response.text), "FETCH") && len(response.literals) > 0 {
+			return response.literals[0], nil
+		}
+	}
+	return nil, errors.ErrResourceNotFound("email_message", strconv.FormatUint(uint64(uid), 10))
+}
+
+// MarkSeen 将邮件标记为已读
+func (c *imapClient) MarkSeen(uid uint32) error {
+	_, err := c.command("UID STORE %d +FLAGS.SILENT (\\Seen)", uid)
+	return err
+}
+
+// Move 将邮件移动到指定邮件夹（需要服务器支持MOVE扩展）
+func (c *imapClient) Move(uid uint32, mailbox string) error {
+	_, err := c.command("UID MOVE %d %s", uid, quoteIMAP(mailbox))
+	return err
+}
+
+// Close 退出登录并关闭连接
+func (c *imapClient) Close() error {
+	_, _ = c.command("LOGOUT")
+	return c.conn.Close()
+}
+
+// command 发送命令并读取响应直到对应的标记响应，标记响应不是OK时返回错误
+func (c *imapClient) command(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tagSeq++
+	tag := fmt.Sprintf("m%d", c.tagSeq)
+	line := fmt.Sprintf(format, args...)
+
+	c.extendDeadline()
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, line); err != nil {
+		return nil, errors.NewMemoroError(errors.ErrorTypeNetwork, errors.ErrCodeSystemGeneric, "Failed to send IMAP command").
+			WithCause(err)
+	}
+
+	var responses []imapResponse
+	for {
+		response, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(response.text, tag+" ") {
+			// 非标记响应和继续请求（本客户端不发送需要继续的命令）
+			responses = append(responses, response)
+			continue
+		}
+
+		status := strings.TrimPrefix(response.text, tag+" ")
+		if !strings.HasPrefix(strings.ToUpper(status), "OK") {
+			// 不在错误中回显LOGIN命令的参数
+			command := strings.Fields(line)[0]
+			return nil, imapError(fmt.Sprintf("IMAP %s failed", command), status)
+		}
+		return responses, nil
+	}
+}
+
+// readResponse 读取一条完整响应，行尾的字面量{n}读取后继续拼接剩余部分
+func (c *imapClient) readResponse() (imapResponse, error) {
+	var response imapResponse
+	var builder strings.Builder
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return response, err
+		}
+		builder.WriteString(line)
+
+		size, ok := literalSize(line)
+		if !ok {
+			response.text = builder.String()
+			return response, nil
+		}
+		if size > maxMessageSize {
+			return response, imapError("IMAP literal too large", strconv.Itoa(size))
+		}
+
+		literal := make([]byte, size)
+		c.extendDeadline()
+		if _, err := io.ReadFull(c.reader, literal); err != nil {
+			return response, errors.NewMemoroError(errors.ErrorTypeNetwork, errors.ErrCodeSystemGeneric, "Failed to read IMAP literal").
+				WithCause(err)
+		}
+		response.literals = append(response.literals, literal)
+	}
+}
+
+// readLine 读取一行并去掉行尾的CRLF
+func (c *imapClient) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", errors.NewMemoroError(errors.ErrorTypeNetwork, errors.ErrCodeSystemGeneric, "Failed to read IMAP response").
+			WithCause(err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// extendDeadline 每次读写前延长连接的超时时间
+func (c *imapClient) extendDeadline() {
+	if c.timeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+}
+
+// literalSize 解析行尾的字面量长度标记{n}
+func literalSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	start := strings.LastIndex(line, "{")
+	if start < 0 {
+		return 0, false
+	}
+	size, err := strconv.Atoi(strings.TrimSuffix(line[start+1:len(line)-1], "+"))
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// quoteIMAP 将参数转换为IMAP带引号字符串
+func quoteIMAP(value string) string {
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	return `"` + value + `"`
+}
+
+// imapError 服务器返回意外响应时的错误
+func imapError(message, response string) error {
+	return errors.NewMemoroError(errors.ErrorTypeNetwork, errors.ErrCodeSystemGeneric, message).
+		WithDetails(response)
+}
@@ -0,0 +1,280 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+
+	"memoro/internal/errors"
+)
+
+// maxMimeDepth multipart嵌套的最大深度
+const maxMimeDepth = 10
+
+// linkRegex 正文中的http(s)链接（HTML中的href同样匹配），只匹配URL允许的ASCII字符，避免吞掉紧随的中文标点
+var linkRegex = regexp.MustCompile(`https?://[A-Za-z0-9\-._~:/?#@!$&*+,;=%]+`)
+
+// charsetDecoder 解码非UTF-8字符集的编码词（如=?gb2312?B?...?=）
+var charsetDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Message 解析后的邮件
+type Message struct {
+	MessageID   string        `json:"message_id"`  // Message-ID头（去掉尖括号）
+	ThreadID    string        `json:"thread_id"`   // 会话线程ID：References中的首个ID，没有时为In-Reply-To，新会话为自身的Message-ID
+	From        string        `json:"from"`        // 发件人地址（小写）
+	Subject     string        `json:"subject"`     // 解码后的主题
+	Date        time.Time     `json:"date"`        // 发送时间（缺失时为零值）
+	Body        string        `json:"body"`        // 正文，优先纯文本，没有时为HTML
+	HTMLBody    bool          `json:"html_body"`   // 正文是否为HTML
+	Links       []string      `json:"links"`       // 正文中的链接（去重，保持出现顺序）
+	Attachments []*Attachment `json:"attachments"` // 附件（超过大小上限的附件不包含在内）
+}
+
+// Attachment 邮件附件
+type Attachment struct {
+	Filename    string `json:"filename"`     // 文件名
+	ContentType string `json:"content_type"` // MIME类型
+	Data        []byte `json:"-"`            // 解码后的内容
+}
+
+// IsImage 检查附件是否为图片
+func (a *Attachment) IsImage() bool {
+	return strings.HasPrefix(a.ContentType, "image/")
+}
+
+// IsText 检查附件是否可以直接作为文本处理
+func (a *Attachment) IsText() bool {
+	switch a.ContentType {
+	case "application/json", "application/xml", "application/x-yaml":
+		return true
+	}
+	return strings.HasPrefix(a.ContentType, "text/")
+}
+
+// ParseMessage 解析RFC 5322邮件：解码主题和正文，提取链接和附件（超过maxAttachmentSize的附件被忽略）
+func ParseMessage(raw []byte, maxAttachmentSize int64) (*Message, error) {
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, errors.ErrValidationFailed("email", err.Error())
+	}
+
+	message := &Message{
+		MessageID: strings.Trim(strings.TrimSpace(parsed.Header.Get("Message-ID")), "<>"),
+		Subject:   decodeHeader(parsed.Header.Get("Subject")),
+	}
+	message.ThreadID = threadRootID(parsed.Header, message.MessageID)
+	if from, err := parseAddress(parsed.Header.Get("From")); err == nil {
+		message.From = from
+	}
+	if date, err := parsed.Header.Date(); err == nil {
+		message.Date = date
+	}
+
+	parser := &messageParser{maxAttachmentSize: maxAttachmentSize}
+	if err := parser.walk(textproto.MIMEHeader(parsed.Header), parsed.Body, 0); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case parser.plainText != "":
+		message.Body = parser.plainText
+	case parser.htmlText != "":
+		message.Body = parser.htmlText
+		message.HTMLBody = true
+	}
+	message.Body = strings.TrimSpace(message.Body)
+	message.Links = extractLinks(message.Body)
+	message.Attachments = parser.attachments
+
+	return message, nil
+}
+
+// threadRootID 从References（首个ID为会话的第一封邮件）或In-Reply-To确定会话线程ID，都没有时以自身Message-ID开始新会话
+func threadRootID(header mail.Header, messageID string) string {
+	for _, name := range []string{"References", "In-Reply-To"} {
+		if id := firstMessageID(header.Get(name)); id != "" {
+			return id
+		}
+	}
+	return messageID
+}
+
+// firstMessageID 返回消息ID列表头中的第一个ID（去掉尖括号）
+func firstMessageID(value string) string {
+	value = strings.TrimSpace(value)
+	if start := strings.Index(value, "<"); start >= 0 {
+		if end := strings.Index(value[start:], ">"); end > 0 {
+			return strings.TrimSpace(value[start+1 : start+end])
+		}
+	}
+	if fields := strings.Fields(value); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// messageParser 遍历MIME结构，收集第一个纯文本和HTML正文以及附件
+type messageParser struct {
+	maxAttachmentSize int64
+	plainText         string
+	htmlText          string
+	attachments       []*Attachment
+}
+
+// walk 递归处理MIME部分
+func (p *messageParser) walk(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	if depth > maxMimeDepth {
+		return errors.ErrValidationFailed("email", "MIME structure is too deeply nested")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return errors.ErrValidationFailed("email", err.Error())
+			}
+			if err := p.walk(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 附件：声明为attachment、带文件名或非文本的内联部分
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := decodeHeader(dispositionParams["filename"])
+	if filename == "" {
+		filename = decodeHeader(params["name"])
+	}
+	isBody := (mediaType == "text/plain" || mediaType == "text/html") && disposition != "attachment" && filename == ""
+	if !isBody {
+		return p.addAttachment(header, body, mediaType, filename)
+	}
+
+	data, err := io.ReadAll(decodeTransfer(header, body))
+	if err != nil {
+		return errors.ErrValidationFailed("email", err.Error())
+	}
+	text := decodeCharset(data, params["charset"])
+	if mediaType == "text/plain" && p.plainText == "" {
+		p.plainText = text
+	} else if mediaType == "text/html" && p.htmlText == "" {
+		p.htmlText = text
+	}
+	return nil
+}
+
+// addAttachment 读取附件内容，超过大小上限时忽略
+func (p *messageParser) addAttachment(header textproto.MIMEHeader, body io.Reader, mediaType, filename string) error {
+	limit := p.maxAttachmentSize
+	data, err := io.ReadAll(io.LimitReader(decodeTransfer(header, body), limit+1))
+	if err != nil {
+		return errors.ErrValidationFailed("email", err.Error())
+	}
+	if int64(len(data)) > limit || len(data) == 0 {
+		return nil
+	}
+
+	if filename == "" {
+		filename = fmt.Sprintf("attachment-%d", len(p.attachments)+1)
+		if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+			filename += extensions[0]
+		}
+	}
+	p.attachments = append(p.attachments, &Attachment{
+		Filename:    filename,
+		ContentType: mediaType,
+		Data:        data,
+	})
+	return nil
+}
+
+// decodeTransfer 按Content-Transfer-Encoding解码（multipart.Reader已自动解码quoted-printable）
+func decodeTransfer(header textproto.MIMEHeader, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// decodeCharset 将正文转换为UTF-8，未知字符集按原样返回
+func decodeCharset(data []byte, charset string) string {
+	if charset == "" || strings.EqualFold(charset, "utf-8") || strings.EqualFold(charset, "us-ascii") {
+		return string(data)
+	}
+	reader, err := charsetReader(charset, bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// charsetReader 按字符集名称创建UTF-8转换读取器
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// decodeHeader 解码RFC 2047编码词，解码失败时返回原值
+func decodeHeader(value string) string {
+	decoded, err := charsetDecoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+// parseAddress 解析发件人地址并转换为小写
+func parseAddress(value string) (string, error) {
+	parser := &mail.AddressParser{WordDecoder: charsetDecoder}
+	address, err := parser.Parse(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(address.Address), nil
+}
+
+// extractLinks 提取正文中的链接，解码HTML实体、去掉末尾标点并去重
+func extractLinks(body string) []string {
+	var links []string
+	seen := make(map[string]bool)
+	for _, link := range linkRegex.FindAllString(body, -1) {
+		link = strings.TrimRight(html.UnescapeString(link), ".,;:!?")
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
@@ -0,0 +1,422 @@
+package email
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/content"
+)
+
+// 未配置时的邮件采集默认值
+const (
+	defaultMailbox            = "INBOX"
+	defaultPollInterval       = 5 * time.Minute
+	defaultTimeout            = 30 * time.Second
+	defaultMaxMessagesPerPoll = 20
+	defaultMaxAttachmentSize  = 10 << 20
+	defaultTLSPort            = 993
+	defaultPlainPort          = 143
+)
+
+// ContentSubmitter 接收邮件内容的内容处理服务
+type ContentSubmitter interface {
+	ProcessContentAsync(request *content.ProcessingRequest) error
+}
+
+// FileStore 保存图片和二进制附件的文件存储（与上传文件共用storage.file_path）
+type FileStore interface {
+	IsAllowed(mimeType, filename string) bool
+	Save(filename string, data []byte) (string, error)
+	Remove(path string) error
+}
+
+// PollReport 一次轮询的结果
+type PollReport struct {
+	Messages  int `json:"messages"`  // 未读邮件数
+	Submitted int `json:"submitted"` // 提交的处理请求数（正文和附件分别计数）
+	Skipped   int `json:"skipped"`   // 发件人未映射或没有内容而忽略的邮件数（同样标记为已处理）
+	Failed    int `json:"failed"`    // 解析或提交失败的邮件数（未标记，下次轮询重试）
+}
+
+// Poller 定期轮询IMAP邮箱，将转发的邮件和附件提交为内容处理请求的后台任务
+type Poller struct {
+	config    config.EmailConfig
+	senders   map[string]config.EmailSenderConfig
+	submitter ContentSubmitter
+	files     FileStore // 未设置时跳过图片和二进制附件
+	logger    *logger.Logger
+
+	runMutex sync.Mutex // 串行化轮询，避免同一封邮件被并发提交
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewPoller 根据全局配置创建邮件采集任务
+func NewPoller(submitter ContentSubmitter) (*Poller, error) {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil, errors.ErrConfigMissing("email config")
+	}
+	if submitter == nil {
+		return nil, errors.ErrValidationFailed("submitter", "cannot be nil")
+	}
+
+	return NewPollerWithConfig(cfg.Email, submitter), nil
+}
+
+// NewPollerWithConfig 按指定配置创建邮件采集任务（未设置的项使用默认值）
+func NewPollerWithConfig(emailConfig config.EmailConfig, submitter ContentSubmitter) *Poller {
+	if emailConfig.Mailbox == "" {
+		emailConfig.Mailbox = defaultMailbox
+	}
+	if emailConfig.Port == 0 {
+		emailConfig.Port = defaultPlainPort
+		if emailConfig.UseTLS {
+			emailConfig.Port = defaultTLSPort
+		}
+	}
+	if emailConfig.PollInterval <= 0 {
+		emailConfig.PollInterval = defaultPollInterval
+	}
+	if emailConfig.Timeout <= 0 {
+		emailConfig.Timeout = defaultTimeout
+	}
+	if emailConfig.MaxMessagesPerPoll <= 0 {
+		emailConfig.MaxMessagesPerPoll = defaultMaxMessagesPerPoll
+	}
+	if emailConfig.MaxAttachmentSize <= 0 {
+		emailConfig.MaxAttachmentSize = defaultMaxAttachmentSize
+	}
+
+	senders := make(map[string]config.EmailSenderConfig, len(emailConfig.Senders))
+	for _, sender := range emailConfig.Senders {
+		senders[strings.ToLower(strings.TrimSpace(sender.Address))] = sender
+	}
+
+	return &Poller{
+		config:    emailConfig,
+		senders:   senders,
+		submitter: submitter,
+		logger:    logger.NewLogger("email-poller"),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// SetFileStore 设置保存图片和二进制附件的文件存储，应在Start前调用
+func (p *Poller) SetFileStore(files FileStore) {
+	p.files = files
+}
+
+// Start 启动定期轮询协程（启动时立即轮询一次）
+func (p *Poller) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			p.poll()
+
+			select {
+			case <-ticker.C:
+			case <-p.stopChan:
+				return
+			}
+		}
+	}()
+
+	p.logger.Info("Email poller started", logger.Fields{
+		"host":          p.config.Host,
+		"mailbox":       p.config.Mailbox,
+		"poll_interval": p.config.PollInterval,
+	})
+}
+
+// Stop 停止轮询协程
+func (p *Poller) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+	})
+	p.wg.Wait()
+}
+
+// poll 执行一次轮询，失败只记录警告
+func (p *Poller) poll() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.PollInterval)
+	defer cancel()
+
+	if _, err := p.PollNow(ctx); err != nil {
+		p.logger.Warn("Email poll failed", logger.Fields{
+			"host":  p.config.Host,
+			"error": err.Error(),
+		})
+	}
+}
+
+// PollNow 立即轮询邮箱：提交未读邮件并将其标记为已读（或移动到已处理邮件夹）
+func (p *Poller) PollNow(ctx context.Context) (*PollReport, error) {
+	p.runMutex.Lock()
+	defer p.runMutex.Unlock()
+
+	address := net.JoinHostPort(p.config.Host, strconv.Itoa(p.config.Port))
+	client, err := dialIMAP(ctx, address, p.config.UseTLS, p.config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	if err := client.Login(p.config.Username, p.config.Password); err != nil {
+		return nil, err
+	}
+	if err := client.Select(p.config.Mailbox); err != nil {
+		return nil, err
+	}
+	uids, err := client.SearchUnseen()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PollReport{Messages: len(uids)}
+	for i, uid := range uids {
+		if i >= p.config.MaxMessagesPerPoll || ctx.Err() != nil {
+			// 剩余邮件留到下次轮询
+			break
+		}
+
+		submitted, err := p.processMessage(client, uid)
+		if err != nil {
+			report.Failed++
+			p.logger.Warn("Failed to process email", logger.Fields{
+				"uid":   uid,
+				"error": err.Error(),
+			})
+			continue
+		}
+		if submitted == 0 {
+			report.Skipped++
+		}
+		report.Submitted += submitted
+
+		if err := p.markProcessed(client, uid); err != nil {
+			return report, err
+		}
+	}
+
+	p.logger.Info("Mailbox polled", logger.Fields{
+		"mailbox":   p.config.Mailbox,
+		"messages":  report.Messages,
+		"submitted": report.Submitted,
+		"skipped":   report.Skipped,
+		"failed":    report.Failed,
+	})
+
+	return report, nil
+}
+
+// processMessage 获取并解析邮件，提交正文和附件，返回提交的请求数（0表示忽略该邮件）。
+// 返回错误时邮件不会被标记，下次轮询重试
+func (p *Poller) processMessage(client *imapClient, uid uint32) (int, error) {
+	raw, err := client.FetchMessage(uid)
+	if err != nil {
+		return 0, err
+	}
+	message, err := ParseMessage(raw, p.config.MaxAttachmentSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sender, ok := p.senderFor(message.From)
+	if !ok {
+		p.logger.Warn("Ignoring email from unmapped sender", logger.Fields{
+			"uid":  uid,
+			"from": message.From,
+		})
+		return 0, nil
+	}
+
+	requests, stored, err := p.requestsFor(message, sender)
+	if err != nil {
+		return 0, err
+	}
+	for i, request := range requests {
+		if err := p.submitter.ProcessContentAsync(request); err != nil {
+			// 未提交的附件不保留已保存的文件（整封邮件重试时重新保存）
+			p.removeStored(requests[i:], stored)
+			if i == 0 {
+				return 0, err
+			}
+			// 已有部分内容提交，不再重试整封邮件，避免重复
+			p.logger.Warn("Failed to submit email attachment", logger.Fields{
+				"uid":   uid,
+				"error": err.Error(),
+			})
+			return i, nil
+		}
+	}
+	return len(requests), nil
+}
+
+// removeStored 删除未提交的请求已保存的附件文件
+func (p *Poller) removeStored(requests []*content.ProcessingRequest, stored map[string]string) {
+	for _, request := range requests {
+		path, exists := stored[request.ID]
+		if !exists {
+			continue
+		}
+		if err := p.files.Remove(path); err != nil {
+			p.logger.Warn("Failed to remove unsubmitted email attachment", logger.Fields{
+				"path":  path,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// markProcessed 将已处理的邮件移动到已处理邮件夹，未配置时标记为已读
+func (p *Poller) markProcessed(client *imapClient, uid uint32) error {
+	if p.config.ProcessedMailbox != "" {
+		return client.Move(uid, p.config.ProcessedMailbox)
+	}
+	return client.MarkSeen(uid)
+}
+
+// senderFor 查找发件人对应的用户，未映射时使用默认用户
+func (p *Poller) senderFor(from string) (config.EmailSenderConfig, bool) {
+	if sender, exists := p.senders[from]; exists {
+		return sender, true
+	}
+	if p.config.DefaultUserID != "" {
+		return config.EmailSenderConfig{Address: from, UserID: p.config.DefaultUserID}, true
+	}
+	return config.EmailSenderConfig{}, false
+}
+
+// requestsFor 将邮件转换为处理请求：正文为文本（只含一个链接时为链接），文本类附件按文本处理，
+// 图片和其他附件保存到文件存储后按图片或文件处理。同时返回请求ID到已保存附件文件路径的映射
+func (p *Poller) requestsFor(message *Message, sender config.EmailSenderConfig) ([]*content.ProcessingRequest, map[string]string, error) {
+	var requests []*content.ProcessingRequest
+	stored := make(map[string]string)
+
+	if message.Body != "" {
+		contentType := models.ContentTypeText
+		body := message.Body
+		if len(message.Links) == 1 && !message.HTMLBody && strings.TrimSpace(body) == message.Links[0] {
+			contentType = models.ContentTypeLink
+			body = message.Links[0]
+		}
+
+		request := p.newRequest(message, sender, contentType, body, message.Subject)
+		if len(message.Links) > 0 {
+			request.Metadata["email_links"] = message.Links
+		}
+		requests = append(requests, request)
+	}
+
+	for _, attachment := range message.Attachments {
+		if attachment.IsText() {
+			requests = append(requests, p.attachmentRequest(message, sender, attachment, models.ContentTypeText, string(attachment.Data)))
+			continue
+		}
+
+		path, ok := p.storeAttachment(message, attachment)
+		if !ok {
+			continue
+		}
+		contentType := models.ContentTypeFile
+		if attachment.IsImage() {
+			contentType = models.ContentTypeImage
+		}
+		// 内容为附件的文件名、类型和大小；只记录存储目录下的文件名，不暴露服务器上的存储路径
+		body := content.StoredFileContent(attachment.Filename, attachment.ContentType, len(attachment.Data))
+		request := p.attachmentRequest(message, sender, attachment, contentType, body)
+		request.Metadata[content.StoredFileMetadataKey] = filepath.Base(path)
+		requests = append(requests, request)
+		stored[request.ID] = path
+	}
+
+	return requests, stored, nil
+}
+
+// storeAttachment 将图片或二进制附件保存到文件存储，未配置存储、类型不允许或保存失败时跳过该附件
+func (p *Poller) storeAttachment(message *Message, attachment *Attachment) (string, bool) {
+	fields := logger.Fields{
+		"message_id":   message.MessageID,
+		"filename":     attachment.Filename,
+		"content_type": attachment.ContentType,
+	}
+	if p.files == nil {
+		p.logger.Warn("Skipping email attachment, file store is not configured", fields)
+		return "", false
+	}
+	if !p.files.IsAllowed(attachment.ContentType, attachment.Filename) {
+		p.logger.Warn("Skipping email attachment with disallowed type", fields)
+		return "", false
+	}
+
+	path, err := p.files.Save(attachment.Filename, attachment.Data)
+	if err != nil {
+		fields["error"] = err.Error()
+		p.logger.Warn("Failed to store email attachment", fields)
+		return "", false
+	}
+	return path, true
+}
+
+// attachmentRequest 将附件转换为处理请求：文本类附件直接提交内容，其他附件提交文件描述
+func (p *Poller) attachmentRequest(message *Message, sender config.EmailSenderConfig, attachment *Attachment, contentType models.ContentType, body string) *content.ProcessingRequest {
+	request := p.newRequest(message, sender, contentType, body, attachment.Filename)
+	request.Metadata["email_attachment"] = attachment.Filename
+	request.Metadata["email_attachment_type"] = attachment.ContentType
+	return request
+}
+
+// newRequest 创建邮件内容的处理请求
+func (p *Poller) newRequest(message *Message, sender config.EmailSenderConfig, contentType models.ContentType, body, title string) *content.ProcessingRequest {
+	metadata := map[string]interface{}{
+		"source":     "email",
+		"email_from": message.From,
+	}
+	if message.MessageID != "" {
+		metadata["email_message_id"] = message.MessageID
+	}
+	if message.Subject != "" {
+		metadata["email_subject"] = message.Subject
+	}
+
+	requestContext := map[string]interface{}{
+		"email_from": message.From,
+	}
+	if title != "" {
+		requestContext[content.TitleContextKey] = title
+	}
+	if !message.Date.IsZero() {
+		requestContext["email_date"] = message.Date.Format(time.RFC3339)
+	}
+
+	request := &content.ProcessingRequest{
+		ID:          uuid.New().String(),
+		Content:     body,
+		ContentType: contentType,
+		UserID:      sender.UserID,
+		Context:     requestContext,
+		Metadata:    metadata,
+		ThreadID:    message.ThreadID,
+	}
+	request.Options.ExistingTags = sender.Tags
+
+	return request
+}
@@ -0,0 +1,334 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/models"
+	"memoro/internal/services/content"
+	"memoro/internal/storage"
+)
+
+// recordingSubmitter 记录提交的处理请求
+type recordingSubmitter struct {
+	mu       sync.Mutex
+	requests []*content.ProcessingRequest
+}
+
+func (s *recordingSubmitter) ProcessContentAsync(request *content.ProcessingRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, request)
+	return nil
+}
+
+// fakeIMAPServer 只支持邮件采集所用命令的IMAP服务器
+type fakeIMAPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages map[uint32]string
+	seen     map[uint32]bool
+	moved    map[uint32]string
+}
+
+func newFakeIMAPServer(t *testing.T, messages map[uint32]string) *fakeIMAPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeIMAPServer{
+		listener: listener,
+		messages: messages,
+		seen:     make(map[uint32]bool),
+		moved:    make(map[uint32]string),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+// config 指向该服务器的邮件采集配置
+func (s *fakeIMAPServer) config() config.EmailConfig {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return config.EmailConfig{
+		Host:     addr.IP.String(),
+		Port:     addr.Port,
+		Username: "inbox@example.com",
+		Password: "secret",
+		Timeout:  5 * time.Second,
+		Senders:  []config.EmailSenderConfig{{Address: "Alice@Example.com", UserID: "user-1", Tags: []string{"newsletter"}}},
+	}
+}
+
+// flags 获取邮件是否已读和移动到的邮件夹
+func (s *fakeIMAPServer) flags(uid uint32) (bool, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seen[uid], s.moved[uid]
+}
+
+func (s *fakeIMAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK fake IMAP ready\r\n")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(strings.TrimSpace(line))
+		if len(fields) < 2 {
+			continue
+		}
+		tag, command := fields[0], strings.ToUpper(fields[1])
+		if command == "UID" && len(fields) > 2 {
+			command += " " + strings.ToUpper(fields[2])
+		}
+
+		s.mu.Lock()
+		switch command {
+		case "LOGIN":
+			if fields[2] != `"inbox@example.com"` || fields[3] != `"secret"` {
+				fmt.Fprintf(conn, "%s NO authentication failed\r\n", tag)
+				s.mu.Unlock()
+				continue
+			}
+		case "SELECT":
+			fmt.Fprintf(conn, "* %d EXISTS\r\n", len(s.messages))
+		case "UID SEARCH":
+			var uids []string
+			for uid := range s.messages {
+				if !s.seen[uid] && s.moved[uid] == "" {
+					uids = append(uids, strconv.Itoa(int(uid)))
+				}
+			}
+			fmt.Fprintf(conn, "* SEARCH %s\r\n", strings.Join(uids, " "))
+		case "UID FETCH":
+			uid, _ := strconv.Atoi(fields[3])
+			raw := s.messages[uint32(uid)]
+			fmt.Fprintf(conn, "* 1 FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", uid, len(raw), raw)
+		case "UID STORE":
+			uid, _ := strconv.Atoi(fields[3])
+			s.seen[uint32(uid)] = true
+		case "UID MOVE":
+			uid, _ := strconv.Atoi(fields[3])
+			s.moved[uint32(uid)] = strings.Trim(fields[4], `"`)
+		case "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK LOGOUT completed\r\n", tag)
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+		fmt.Fprintf(conn, "%s OK %s completed\r\n", tag, command)
+	}
+}
+
+// testEmail 带文本和图片附件的转发邮件（主题为UTF-8编码词，正文为quoted-printable）
+const testEmail = "From: Alice <alice@example.com>\r\n" +
+	"To: inbox@example.com\r\n" +
+	"Subject: =?UTF-8?B?5ZGo5oql77yaR2/liqjmgIE=?=\r\n" +
+	"Message-ID: <weekly-1@example.com>\r\n" +
+	"Date: Mon, 02 Sep 2024 08:00:00 +0000\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"=E6=9C=AC=E5=91=A8Go=E5=8A=A8=E6=80=81=EF=BC=9Ahttps://go.dev/blog/go1.23=E3=80=82\r\n" +
+	"More: https://example.com/range-functions.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>HTML version</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Disposition: attachment; filename=\"notes.txt\"\r\n" +
+	"\r\n" +
+	"range-over-func笔记\r\n" +
+	"--outer\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-Disposition: attachment; filename=\"chart.png\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"iVBORw0KGgo=\r\n" +
+	"--outer--\r\n"
+
+// TestPoller_PollNow 测试邮件转换为处理请求并在处理后标记
+func TestPoller_PollNow(t *testing.T) {
+	server := newFakeIMAPServer(t, map[uint32]string{
+		7: testEmail,
+		8: "From: stranger@example.com\r\nSubject: spam\r\n\r\nBuy now\r\n",
+	})
+	submitter := &recordingSubmitter{}
+	poller := NewPollerWithConfig(server.config(), submitter)
+	dir := t.TempDir()
+	files, err := storage.OpenFileStore(config.StorageConfig{FilePath: dir})
+	require.NoError(t, err)
+	poller.SetFileStore(files)
+	ctx := context.Background()
+
+	t.Run("提交正文和附件并标记已读", func(t *testing.T) {
+		report, err := poller.PollNow(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &PollReport{Messages: 2, Submitted: 3, Skipped: 1}, report)
+		require.Len(t, submitter.requests, 3)
+
+		body := submitter.requests[0]
+		assert.Equal(t, models.ContentTypeText, body.ContentType)
+		assert.Equal(t, "user-1", body.UserID)
+		assert.Equal(t, "本周Go动态：https://go.dev/blog/go1.23。\r\nMore: https://example.com/range-functions.", body.Content)
+		assert.Equal(t, "周报：Go动态", body.Context[content.TitleContextKey])
+		assert.Equal(t, []string{"newsletter"}, body.Options.ExistingTags)
+		assert.Equal(t, "email", body.Metadata["source"])
+		assert.Equal(t, "weekly-1@example.com", body.Metadata["email_message_id"])
+		assert.Equal(t, "alice@example.com", body.Metadata["email_from"])
+		assert.Equal(t, []string{"https://go.dev/blog/go1.23", "https://example.com/range-functions"}, body.Metadata["email_links"])
+
+		notes := submitter.requests[1]
+		assert.Equal(t, models.ContentTypeText, notes.ContentType)
+		assert.Equal(t, "range-over-func笔记", notes.Content)
+		assert.Equal(t, "notes.txt", notes.Context[content.TitleContextKey])
+		assert.Equal(t, "notes.txt", notes.Metadata["email_attachment"])
+		assert.Equal(t, "text/plain", notes.Metadata["email_attachment_type"])
+		assert.NotContains(t, notes.Metadata, "email_attachment_path")
+		assert.NotContains(t, notes.Metadata, "upload_file")
+
+		// 图片附件保存到文件存储，请求引用保存的文件
+		chart := submitter.requests[2]
+		assert.Equal(t, models.ContentTypeImage, chart.ContentType)
+		assert.Equal(t, "user-1", chart.UserID)
+		assert.Equal(t, "chart.png", chart.Context[content.TitleContextKey])
+		assert.Equal(t, "image/png", chart.Metadata["email_attachment_type"])
+		assert.Equal(t, content.StoredFileContent("chart.png", "image/png", len("\x89PNG\r\n\x1a\n")), chart.Content)
+		storedName, _ := chart.Metadata[content.StoredFileMetadataKey].(string)
+		require.NotEmpty(t, storedName)
+		assert.NotContains(t, storedName, string(filepath.Separator))
+		stored, err := os.ReadFile(filepath.Join(dir, "uploads", storedName))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("\x89PNG\r\n\x1a\n"), stored)
+
+		// 未映射发件人的邮件同样标记，避免重复处理
+		for _, uid := range []uint32{7, 8} {
+			seen, _ := server.flags(uid)
+			assert.True(t, seen)
+		}
+	})
+
+	t.Run("已处理的邮件不重复提交", func(t *testing.T) {
+		report, err := poller.PollNow(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.Messages)
+		assert.Len(t, submitter.requests, 3)
+	})
+
+	t.Run("配置已处理邮件夹时移动邮件", func(t *testing.T) {
+		server := newFakeIMAPServer(t, map[uint32]string{3: testEmail})
+		cfg := server.config()
+		cfg.ProcessedMailbox = "Memoro/Processed"
+
+		report, err := NewPollerWithConfig(cfg, &recordingSubmitter{}).PollNow(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Submitted)
+		seen, moved := server.flags(3)
+		assert.False(t, seen)
+		assert.Equal(t, "Memoro/Processed", moved)
+	})
+
+	t.Run("登录失败返回错误", func(t *testing.T) {
+		cfg := server.config()
+		cfg.Password = "wrong"
+		_, err := NewPollerWithConfig(cfg, &recordingSubmitter{}).PollNow(ctx)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "wrong")
+	})
+}
+
+// TestParseMessage 测试只有HTML正文和只含链接的邮件
+func TestParseMessage(t *testing.T) {
+	t.Run("只有HTML正文时使用HTML并提取链接", func(t *testing.T) {
+		message, err := ParseMessage([]byte("From: bob@example.com\r\n"+
+			"Subject: =?gb2312?B?1tC5+g==?=\r\n"+
+			"Content-Type: text/html; charset=utf-8\r\n\r\n"+
+			`<p>Read <a href="https://example.com/a?x=1&amp;y=2">this</a></p>`), defaultMaxAttachmentSize)
+		require.NoError(t, err)
+		assert.Equal(t, "中国", message.Subject)
+		assert.True(t, message.HTMLBody)
+		assert.Equal(t, []string{"https://example.com/a?x=1&y=2"}, message.Links)
+	})
+
+	t.Run("正文只有一个链接时作为链接提交", func(t *testing.T) {
+		message, err := ParseMessage([]byte("From: Alice@example.com\r\nSubject: link\r\n\r\nhttps://go.dev/blog/go1.23\r\n"), defaultMaxAttachmentSize)
+		require.NoError(t, err)
+
+		poller := NewPollerWithConfig(config.EmailConfig{DefaultUserID: "owner"}, &recordingSubmitter{})
+		sender, ok := poller.senderFor(message.From)
+		require.True(t, ok)
+		requests, _, err := poller.requestsFor(message, sender)
+		require.NoError(t, err)
+		require.Len(t, requests, 1)
+		assert.Equal(t, models.ContentTypeLink, requests[0].ContentType)
+		assert.Equal(t, "https://go.dev/blog/go1.23", requests[0].Content)
+		assert.Equal(t, "owner", requests[0].UserID)
+	})
+
+	t.Run("回复邮件归入会话第一封邮件的线程", func(t *testing.T) {
+		message, err := ParseMessage([]byte("From: alice@example.com\r\n"+
+			"Message-ID: <c@example.com>\r\n"+
+			"In-Reply-To: <b@example.com>\r\n"+
+			"References: <a@example.com> <b@example.com>\r\n"+
+			"Subject: Re: plan\r\n\r\nsounds good\r\n"), defaultMaxAttachmentSize)
+		require.NoError(t, err)
+		assert.Equal(t, "a@example.com", message.ThreadID)
+
+		poller := NewPollerWithConfig(config.EmailConfig{DefaultUserID: "owner"}, &recordingSubmitter{})
+		sender, ok := poller.senderFor(message.From)
+		require.True(t, ok)
+		requests, _, err := poller.requestsFor(message, sender)
+		require.NoError(t, err)
+		require.Len(t, requests, 1)
+		assert.Equal(t, "a@example.com", requests[0].ThreadID)
+	})
+
+	t.Run("只有In-Reply-To或新会话时的线程ID", func(t *testing.T) {
+		reply, err := ParseMessage([]byte("From: alice@example.com\r\nMessage-ID: <b@example.com>\r\nIn-Reply-To: <a@example.com>\r\n\r\nok\r\n"), defaultMaxAttachmentSize)
+		require.NoError(t, err)
+		assert.Equal(t, "a@example.com", reply.ThreadID)
+
+		first, err := ParseMessage([]byte("From: alice@example.com\r\nMessage-ID: <a@example.com>\r\n\r\nhello\r\n"), defaultMaxAttachmentSize)
+		require.NoError(t, err)
+		assert.Equal(t, "a@example.com", first.ThreadID)
+	})
+
+	t.Run("超过大小上限的附件被忽略", func(t *testing.T) {
+		message, err := ParseMessage([]byte(testEmail), 4)
+		require.NoError(t, err)
+		assert.Empty(t, message.Attachments)
+	})
+}
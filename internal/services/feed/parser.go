@@ -0,0 +1,166 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+
+	"memoro/internal/errors"
+)
+
+// Entry 订阅源中的一个条目
+type Entry struct {
+	GUID      string    `json:"guid"`      // 条目唯一标识（RSS guid或Atom id）
+	URL       string    `json:"url"`       // 条目链接
+	Title     string    `json:"title"`     // 标题
+	Published time.Time `json:"published"` // 发布时间（未提供时为零值）
+	ThreadID  string    `json:"thread_id"` // 所属会话线程：Atom评论条目回复的条目ID（RFC 4685 thr:in-reply-to），其他条目为空
+}
+
+// rssDocument RSS 2.0文档
+type rssDocument struct {
+	Items []struct {
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		GUID    string `xml:"guid"`
+		PubDate string `xml:"pubDate"`
+	} `xml:"channel>item"`
+}
+
+// atomDocument Atom文档
+type atomDocument struct {
+	Entries []struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		// Atom线程扩展（RFC 4685）的回复关系
+		InReplyTo []struct {
+			Ref string `xml:"ref,attr"`
+		} `xml:"http://purl.org/syndication/thread/1.0 in-reply-to"`
+	} `xml:"entry"`
+}
+
+// feedTimeLayouts RSS和Atom常见的时间格式
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2006-01-02",
+}
+
+// ParseFeed 解析RSS 2.0或Atom文档，按文档中的顺序返回条目（没有链接的条目会被跳过）
+func ParseFeed(data []byte) ([]*Entry, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, errors.ErrValidationFailed("feed", "invalid XML: "+err.Error())
+	}
+
+	switch strings.ToLower(root.XMLName.Local) {
+	case "rss":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	default:
+		return nil, errors.ErrValidationFailed("feed", "unsupported feed format: "+root.XMLName.Local)
+	}
+}
+
+// parseRSS 解析RSS 2.0条目
+func parseRSS(data []byte) ([]*Entry, error) {
+	var doc rssDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.ErrValidationFailed("feed", "invalid RSS: "+err.Error())
+	}
+
+	entries := make([]*Entry, 0, len(doc.Items))
+	for _, item := range doc.Items {
+		link := strings.TrimSpace(item.Link)
+		guid := strings.TrimSpace(item.GUID)
+		// guid可以是永久链接，没有link时使用它
+		if link == "" && isHTTPURL(guid) {
+			link = guid
+		}
+		if link == "" {
+			continue
+		}
+
+		entries = append(entries, &Entry{
+			GUID:      guid,
+			URL:       link,
+			Title:     strings.TrimSpace(item.Title),
+			Published: parseFeedTime(item.PubDate),
+		})
+	}
+
+	return entries, nil
+}
+
+// parseAtom 解析Atom条目（优先使用rel为alternate或未指定rel的链接）
+func parseAtom(data []byte) ([]*Entry, error) {
+	var doc atomDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.ErrValidationFailed("feed", "invalid Atom: "+err.Error())
+	}
+
+	entries := make([]*Entry, 0, len(doc.Entries))
+	for _, entry := range doc.Entries {
+		link := ""
+		for _, l := range entry.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = strings.TrimSpace(l.Href)
+				break
+			}
+		}
+		if link == "" {
+			continue
+		}
+
+		published := parseFeedTime(entry.Published)
+		if published.IsZero() {
+			published = parseFeedTime(entry.Updated)
+		}
+
+		threadID := ""
+		if len(entry.InReplyTo) > 0 {
+			threadID = strings.TrimSpace(entry.InReplyTo[0].Ref)
+		}
+
+		entries = append(entries, &Entry{
+			GUID:      strings.TrimSpace(entry.ID),
+			URL:       link,
+			Title:     strings.TrimSpace(entry.Title),
+			Published: published,
+			ThreadID:  threadID,
+		})
+	}
+
+	return entries, nil
+}
+
+// parseFeedTime 按常见格式解析时间，无法解析时返回零值
+func parseFeedTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// isHTTPURL 检查字符串是否为http(s)链接
+func isHTTPURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
@@ -0,0 +1,368 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/content"
+)
+
+// 未配置时的订阅采集默认值
+const (
+	defaultPollInterval      = 30 * time.Minute
+	defaultFetchTimeout      = 30 * time.Second
+	defaultMaxEntriesPerPoll = 50
+)
+
+// maxSeenKeys 每个订阅源保留的已提交条目标识数量（超过后丢弃最早的，远大于订阅源通常的条目数）
+const maxSeenKeys = 1000
+
+// maxFeedSize 订阅源文档的最大字节数
+const maxFeedSize = 10 << 20
+
+// ContentSubmitter 接收订阅条目的内容处理服务
+type ContentSubmitter interface {
+	ProcessContentAsync(request *content.ProcessingRequest) error
+}
+
+// StateStore 订阅源采集进度存储
+type StateStore interface {
+	// GetFeedState 获取采集进度，从未采集过时返回资源不存在错误
+	GetFeedState(ctx context.Context, feedURL string) (*models.FeedState, error)
+	// SaveFeedState 保存采集进度
+	SaveFeedState(ctx context.Context, state *models.FeedState) error
+}
+
+// PollReport 一次轮询的结果
+type PollReport struct {
+	FeedURL   string `json:"feed_url"`  // 订阅源地址
+	Entries   int    `json:"entries"`   // 订阅源中的条目数
+	Submitted int    `json:"submitted"` // 提交处理的新条目数
+	Skipped   int    `json:"skipped"`   // 已处理过或重复的条目数
+	Failed    int    `json:"failed"`    // 提交失败的条目数（下次轮询重试）
+}
+
+// Poller 定期抓取订阅源并将新条目提交为链接处理请求的后台任务
+type Poller struct {
+	config     config.FeedsConfig
+	submitter  ContentSubmitter
+	states     StateStore
+	httpClient *http.Client
+	logger     *logger.Logger
+
+	runMutex sync.Mutex // 串行化轮询，避免同一条目被并发提交
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewPoller 根据全局配置创建订阅采集任务
+func NewPoller(submitter ContentSubmitter, states StateStore) (*Poller, error) {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil, errors.ErrConfigMissing("feeds config")
+	}
+	if submitter == nil {
+		return nil, errors.ErrValidationFailed("submitter", "cannot be nil")
+	}
+	if states == nil {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+
+	return NewPollerWithConfig(cfg.Feeds, submitter, states), nil
+}
+
+// NewPollerWithConfig 按指定配置创建订阅采集任务（未设置的项使用默认值）
+func NewPollerWithConfig(feedsConfig config.FeedsConfig, submitter ContentSubmitter, states StateStore) *Poller {
+	if feedsConfig.PollInterval <= 0 {
+		feedsConfig.PollInterval = defaultPollInterval
+	}
+	if feedsConfig.FetchTimeout <= 0 {
+		feedsConfig.FetchTimeout = defaultFetchTimeout
+	}
+	if feedsConfig.MaxEntriesPerPoll <= 0 {
+		feedsConfig.MaxEntriesPerPoll = defaultMaxEntriesPerPoll
+	}
+
+	return &Poller{
+		config:     feedsConfig,
+		submitter:  submitter,
+		states:     states,
+		httpClient: &http.Client{Timeout: feedsConfig.FetchTimeout},
+		logger:     logger.NewLogger("feed-poller"),
+		stopChan:   make(chan struct{}),
+	}
+}
+
+// Start 启动定期轮询协程（启动时立即轮询一次）
+func (p *Poller) Start() {
+	if len(p.config.Sources) == 0 {
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			p.pollAll()
+
+			select {
+			case <-ticker.C:
+			case <-p.stopChan:
+				return
+			}
+		}
+	}()
+
+	p.logger.Info("Feed poller started", logger.Fields{
+		"feeds":         len(p.config.Sources),
+		"poll_interval": p.config.PollInterval,
+	})
+}
+
+// Stop 停止轮询协程
+func (p *Poller) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+	})
+	p.wg.Wait()
+}
+
+// pollAll 轮询所有订阅源，单个订阅源失败只记录警告
+func (p *Poller) pollAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.PollInterval)
+	defer cancel()
+
+	if _, err := p.PollNow(ctx); err != nil {
+		p.logger.Warn("Feed poll failed", logger.Fields{
+			"error": err.Error(),
+		})
+	}
+}
+
+// PollNow 立即轮询所有订阅源，返回各订阅源的结果和遇到的第一个错误
+func (p *Poller) PollNow(ctx context.Context) ([]*PollReport, error) {
+	p.runMutex.Lock()
+	defer p.runMutex.Unlock()
+
+	reports := make([]*PollReport, 0, len(p.config.Sources))
+	var firstErr error
+	for _, source := range p.config.Sources {
+		report, err := p.pollFeed(ctx, source)
+		if err != nil {
+			p.logger.Warn("Failed to poll feed", logger.Fields{
+				"feed_url": source.URL,
+				"error":    err.Error(),
+			})
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, firstErr
+}
+
+// pollFeed 抓取单个订阅源，提交未处理过的条目并保存采集进度
+func (p *Poller) pollFeed(ctx context.Context, source config.FeedSourceConfig) (*PollReport, error) {
+	entries, err := p.fetchEntries(ctx, source.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	state, seenKeys, err := p.loadState(ctx, source.URL)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(seenKeys))
+	for _, key := range seenKeys {
+		seen[key] = true
+	}
+
+	report := &PollReport{FeedURL: source.URL, Entries: len(entries)}
+
+	// 订阅源通常按时间倒序排列，从最早的条目开始提交
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		keys := entryKeys(entry)
+		if anySeen(seen, keys) {
+			report.Skipped++
+			continue
+		}
+		if report.Submitted >= p.config.MaxEntriesPerPoll {
+			// 剩余条目留到下次轮询
+			break
+		}
+
+		if err := p.submitter.ProcessContentAsync(p.requestFor(source, entry)); err != nil {
+			report.Failed++
+			p.logger.Warn("Failed to submit feed entry", logger.Fields{
+				"feed_url":  source.URL,
+				"entry_url": entry.URL,
+				"error":     err.Error(),
+			})
+			continue
+		}
+
+		report.Submitted++
+		for _, key := range keys {
+			seen[key] = true
+			seenKeys = append(seenKeys, key)
+		}
+	}
+
+	if len(seenKeys) > maxSeenKeys {
+		seenKeys = seenKeys[len(seenKeys)-maxSeenKeys:]
+	}
+	data, err := json.Marshal(seenKeys)
+	if err != nil {
+		return nil, errors.ErrValidationFailed("feed_state", err.Error())
+	}
+	state.SeenKeys = string(data)
+	state.LastPolledAt = time.Now()
+	if err := p.states.SaveFeedState(ctx, state); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("Feed polled", logger.Fields{
+		"feed_url":  source.URL,
+		"entries":   report.Entries,
+		"submitted": report.Submitted,
+		"skipped":   report.Skipped,
+		"failed":    report.Failed,
+	})
+
+	return report, nil
+}
+
+// fetchEntries 抓取并解析订阅源
+func (p *Poller) fetchEntries(ctx context.Context, feedURL string) ([]*Entry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, errors.ErrValidationFailed("feed_url", err.Error())
+	}
+	req.Header.Set("User-Agent", "Memoro/1.0 (Knowledge Management Bot)")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml, text/xml")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.NewMemoroError(errors.ErrorTypeNetwork, errors.ErrCodeSystemGeneric, "Failed to fetch feed").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"feed_url": feedURL,
+			})
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.NewMemoroError(errors.ErrorTypeNetwork, errors.ErrCodeSystemGeneric, fmt.Sprintf("Feed returned status %d", resp.StatusCode)).
+			WithContext(map[string]interface{}{
+				"feed_url":    feedURL,
+				"status_code": resp.StatusCode,
+			})
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, errors.NewMemoroError(errors.ErrorTypeNetwork, errors.ErrCodeSystemGeneric, "Failed to read feed").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"feed_url": feedURL,
+			})
+	}
+
+	return ParseFeed(data)
+}
+
+// loadState 读取订阅源的采集进度，从未采集过时返回空进度
+func (p *Poller) loadState(ctx context.Context, feedURL string) (*models.FeedState, []string, error) {
+	state, err := p.states.GetFeedState(ctx, feedURL)
+	if err != nil {
+		if memoErr, ok := err.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeResourceNotFound) {
+			return &models.FeedState{FeedURL: feedURL}, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	var seenKeys []string
+	if state.SeenKeys != "" {
+		if err := json.Unmarshal([]byte(state.SeenKeys), &seenKeys); err != nil {
+			return nil, nil, errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to decode feed state").
+				WithCause(err).
+				WithContext(map[string]interface{}{
+					"feed_url": feedURL,
+				})
+		}
+	}
+
+	return state, seenKeys, nil
+}
+
+// requestFor 将条目转换为链接处理请求
+func (p *Poller) requestFor(source config.FeedSourceConfig, entry *Entry) *content.ProcessingRequest {
+	metadata := map[string]interface{}{
+		"source":   "feed",
+		"feed_url": source.URL,
+	}
+	if entry.GUID != "" {
+		metadata["feed_entry_id"] = entry.GUID
+	}
+	if len(source.Tags) > 0 {
+		metadata["feed_tags"] = source.Tags
+	}
+
+	request := &content.ProcessingRequest{
+		ID:          uuid.New().String(),
+		Content:     entry.URL,
+		ContentType: models.ContentTypeLink,
+		UserID:      source.UserID,
+		Context: map[string]interface{}{
+			"feed_url":    source.URL,
+			"entry_title": entry.Title,
+		},
+		Metadata: metadata,
+		ThreadID: entry.ThreadID,
+	}
+	request.Options.ExistingTags = source.Tags
+
+	return request
+}
+
+// entryKeys 条目的去重标识：GUID和规范化后的链接
+func entryKeys(entry *Entry) []string {
+	keys := make([]string, 0, 2)
+	if entry.GUID != "" {
+		keys = append(keys, "guid:"+entry.GUID)
+	}
+	if canonical := content.CanonicalizeURL(entry.URL); canonical != "" {
+		keys = append(keys, "url:"+canonical)
+	}
+	return keys
+}
+
+// anySeen 任一标识已出现过即视为已处理
+func anySeen(seen map[string]bool, keys []string) bool {
+	for _, key := range keys {
+		if seen[key] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/models"
+	"memoro/internal/services/content"
+	"memoro/internal/storage"
+)
+
+// recordingSubmitter 记录提交的处理请求
+type recordingSubmitter struct {
+	mu       sync.Mutex
+	requests []*content.ProcessingRequest
+	fail     bool
+}
+
+func (s *recordingSubmitter) ProcessContentAsync(request *content.ProcessingRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeSystemGeneric, "Processing queue is full")
+	}
+	s.requests = append(s.requests, request)
+	return nil
+}
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Go Blog</title>
+    <item>
+      <title>Go 1.23 is released</title>
+      <link>https://go.dev/blog/go1.23?utm_source=rss</link>
+      <guid>https://go.dev/blog/go1.23</guid>
+      <pubDate>Tue, 13 Aug 2024 00:00:00 +0000</pubDate>
+    </item>
+    <item>
+      <title>Range over function types</title>
+      <link>https://go.dev/blog/range-functions</link>
+      <guid>range-functions</guid>
+      <pubDate>Tue, 20 Aug 2024 00:00:00 +0000</pubDate>
+    </item>
+  </channel>
+</rss>`
+
+// newTestStateStore 创建内存数据库中的采集进度存储
+func newTestStateStore(t *testing.T) *storage.ContentStore {
+	store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// TestPoller_PollNow 测试订阅条目的提交、去重和进度持久化
+func TestPoller_PollNow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer server.Close()
+
+	feedsConfig := config.FeedsConfig{
+		Sources: []config.FeedSourceConfig{{URL: server.URL, UserID: "user-1", Tags: []string{"golang"}}},
+	}
+	store := newTestStateStore(t)
+	submitter := &recordingSubmitter{}
+	poller := NewPollerWithConfig(feedsConfig, submitter, store)
+	ctx := context.Background()
+
+	t.Run("首次轮询提交所有条目", func(t *testing.T) {
+		reports, err := poller.PollNow(ctx)
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, 2, reports[0].Submitted)
+
+		require.Len(t, submitter.requests, 2)
+		// 从最早的条目开始提交
+		first := submitter.requests[0]
+		assert.Equal(t, "https://go.dev/blog/range-functions", first.Content)
+		assert.Equal(t, models.ContentTypeLink, first.ContentType)
+		assert.Equal(t, "user-1", first.UserID)
+		assert.Equal(t, []string{"golang"}, first.Options.ExistingTags)
+		assert.Equal(t, "feed", first.Metadata["source"])
+		assert.Equal(t, []string{"golang"}, first.Metadata["feed_tags"])
+		assert.NotEqual(t, first.ID, submitter.requests[1].ID)
+	})
+
+	t.Run("没有新条目时不重复提交", func(t *testing.T) {
+		reports, err := poller.PollNow(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, reports[0].Submitted)
+		assert.Equal(t, 2, reports[0].Skipped)
+		assert.Len(t, submitter.requests, 2)
+	})
+
+	t.Run("进度持久化后新的采集任务也不重复提交", func(t *testing.T) {
+		other := &recordingSubmitter{}
+		reports, err := NewPollerWithConfig(feedsConfig, other, store).PollNow(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, reports[0].Submitted)
+		assert.Empty(t, other.requests)
+	})
+
+	t.Run("提交失败的条目下次重试", func(t *testing.T) {
+		failing := &recordingSubmitter{fail: true}
+		fresh := newTestStateStore(t)
+		reports, err := NewPollerWithConfig(feedsConfig, failing, fresh).PollNow(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, reports[0].Failed)
+
+		failing.fail = false
+		reports, err = NewPollerWithConfig(feedsConfig, failing, fresh).PollNow(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, reports[0].Submitted)
+	})
+}
+
+// TestParseFeed 测试RSS和Atom解析
+func TestParseFeed(t *testing.T) {
+	t.Run("RSS", func(t *testing.T) {
+		entries, err := ParseFeed([]byte(testRSS))
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "https://go.dev/blog/go1.23", entries[0].GUID)
+		assert.Equal(t, 2024, entries[0].Published.Year())
+	})
+
+	t.Run("Atom", func(t *testing.T) {
+		entries, err := ParseFeed([]byte(`<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <title>Hello</title>
+    <id>urn:uuid:1</id>
+    <link rel="self" href="https://example.com/self"/>
+    <link href="https://example.com/hello"/>
+    <updated>2024-05-01T08:00:00Z</updated>
+  </entry>
+</feed>`))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "https://example.com/hello", entries[0].URL)
+		assert.Equal(t, "urn:uuid:1", entries[0].GUID)
+		assert.False(t, entries[0].Published.IsZero())
+	})
+
+	t.Run("Atom评论条目按回复的条目归入线程", func(t *testing.T) {
+		entries, err := ParseFeed([]byte(`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:thr="http://purl.org/syndication/thread/1.0">
+  <entry>
+    <title>Re: Hello</title>
+    <id>urn:uuid:2</id>
+    <link href="https://example.com/hello#comment-2"/>
+    <thr:in-reply-to ref="urn:uuid:1" href="https://example.com/hello"/>
+  </entry>
+  <entry>
+    <title>Hello</title>
+    <id>urn:uuid:1</id>
+    <link href="https://example.com/hello"/>
+  </entry>
+</feed>`))
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "urn:uuid:1", entries[0].ThreadID)
+		assert.Empty(t, entries[1].ThreadID)
+
+		poller := NewPollerWithConfig(config.FeedsConfig{}, &recordingSubmitter{}, newTestStateStore(t))
+		request := poller.requestFor(config.FeedSourceConfig{URL: "https://example.com/feed", UserID: "user-1"}, entries[0])
+		assert.Equal(t, "urn:uuid:1", request.ThreadID)
+	})
+
+	t.Run("不支持的格式", func(t *testing.T) {
+		_, err := ParseFeed([]byte(`<html></html>`))
+		assert.Error(t, err)
+	})
+}
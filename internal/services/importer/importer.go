@@ -0,0 +1,491 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/content"
+	"memoro/internal/services/jobs"
+)
+
+// 未配置时的导入默认值
+const (
+	defaultThrottle     = 200 * time.Millisecond
+	defaultMaxEntries   = 5000
+	defaultMaxFileSize  = 10 << 20
+	defaultRetryBackoff = time.Second
+	defaultMaxRetries   = 5
+)
+
+// maxRetryBackoff 暂时性错误重试等待时间的上限
+const maxRetryBackoff = time.Minute
+
+// JobType 导入任务在后台任务管理器中的类型
+const JobType = "import"
+
+// progressSaveInterval 每处理多少个条目保存一次进度（中断后最多重复提交这么多条目，由处理器按规范URL去重）
+const progressSaveInterval = 20
+
+// ContentSubmitter 接收导入条目的内容处理服务
+type ContentSubmitter interface {
+	ProcessContentAsync(request *content.ProcessingRequest) error
+}
+
+// JobStore 导入任务存储
+type JobStore interface {
+	// GetImportJob 获取导入任务，不存在时返回资源不存在错误
+	GetImportJob(ctx context.Context, id string) (*models.ImportJob, error)
+	// SaveImportJob 保存导入任务及其进度
+	SaveImportJob(ctx context.Context, job *models.ImportJob) error
+	// LinkURLs 列出用户已保存链接的原始URL和规范URL，导入时跳过这些链接
+	LinkURLs(ctx context.Context, userID string) ([]string, error)
+}
+
+// JobRunner 执行导入任务的后台任务管理器
+type JobRunner interface {
+	// Run 在后台执行一次性任务
+	Run(jobType string, fn jobs.JobFunc) (*jobs.Job, error)
+	// Cancel 取消任务并等待其退出
+	Cancel(ctx context.Context, id string) (*jobs.Job, error)
+}
+
+// Importer 将书签和稍后读导出文件中的条目按规范URL去重（包括用户已保存的链接）后，在后台任务管理器中限速提交为链接处理请求的导入服务。
+// 进度随任务保存，提交遇到暂时性错误时按指数退避重试，重试用尽、任务被取消或服务关闭时任务暂停，可从进度处继续
+type Importer struct {
+	config    config.ImportConfig
+	submitter ContentSubmitter
+	store     JobStore
+	runner    JobRunner
+	logger    *logger.Logger
+
+	mu      sync.Mutex
+	running map[string]string // 执行中的导入任务ID -> 后台任务ID
+	closed  bool
+}
+
+// NewImporter 根据全局配置创建导入服务
+func NewImporter(submitter ContentSubmitter, store JobStore, runner JobRunner) (*Importer, error) {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil, errors.ErrConfigMissing("import config")
+	}
+	if submitter == nil {
+		return nil, errors.ErrValidationFailed("submitter", "cannot be nil")
+	}
+	if store == nil {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+	if runner == nil {
+		return nil, errors.ErrValidationFailed("runner", "cannot be nil")
+	}
+
+	return NewImporterWithConfig(cfg.Import, submitter, store, runner), nil
+}
+
+// NewImporterWithConfig 按指定配置创建导入服务（未设置的项使用默认值，限速间隔为0时使用默认值）
+func NewImporterWithConfig(importConfig config.ImportConfig, submitter ContentSubmitter, store JobStore, runner JobRunner) *Importer {
+	if importConfig.Throttle <= 0 {
+		importConfig.Throttle = defaultThrottle
+	}
+	if importConfig.MaxEntries <= 0 {
+		importConfig.MaxEntries = defaultMaxEntries
+	}
+	if importConfig.MaxFileSize <= 0 {
+		importConfig.MaxFileSize = defaultMaxFileSize
+	}
+	if importConfig.RetryBackoff <= 0 {
+		importConfig.RetryBackoff = defaultRetryBackoff
+	}
+	if importConfig.MaxRetries <= 0 {
+		importConfig.MaxRetries = defaultMaxRetries
+	}
+
+	return &Importer{
+		config:    importConfig,
+		submitter: submitter,
+		store:     store,
+		runner:    runner,
+		logger:    logger.NewLogger("importer"),
+		running:   make(map[string]string),
+	}
+}
+
+// MaxFileSize 导出文件大小上限（字节）
+func (im *Importer) MaxFileSize() int64 {
+	return im.config.MaxFileSize
+}
+
+// Start 解析导出文件并按规范URL去重，跳过用户已保存的链接，创建导入任务后在后台限速提交，立即返回任务
+func (im *Importer) Start(ctx context.Context, userID string, format Format, data []byte) (*models.ImportJob, error) {
+	if !ValidFormat(format) {
+		return nil, errors.ErrValidationFailed("format", "must be one of: "+strings.Join(FormatNames(), ", "))
+	}
+	if int64(len(data)) > im.config.MaxFileSize {
+		return nil, errors.ErrValidationFailed("file", fmt.Sprintf("exceeds %d bytes", im.config.MaxFileSize))
+	}
+
+	parsed, err := Parse(format, data)
+	if err != nil {
+		return nil, err
+	}
+	entries, duplicates := dedupEntries(parsed)
+	entries, saved := im.skipSaved(ctx, userID, entries)
+	duplicates += saved
+	if len(entries) > im.config.MaxEntries {
+		return nil, errors.ErrValidationFailed("file", fmt.Sprintf("contains %d links, at most %d can be imported at once", len(entries), im.config.MaxEntries))
+	}
+
+	encoded, err := json.Marshal(entries)
+	if err != nil {
+		return nil, errors.ErrValidationFailed("entries", err.Error())
+	}
+	job := &models.ImportJob{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		Format:     string(format),
+		Status:     models.ImportStatusRunning,
+		Entries:    string(encoded),
+		Total:      len(entries),
+		Duplicates: duplicates,
+	}
+	if err := im.store.SaveImportJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	im.logger.Info("Import started", logger.Fields{
+		"job_id":     job.ID,
+		"user_id":    userID,
+		"format":     job.Format,
+		"entries":    job.Total,
+		"duplicates": duplicates,
+	})
+
+	if err := im.launch(job, entries); err != nil {
+		return nil, err
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// Resume 从保存的进度处继续暂停的导入任务；任务执行中时返回重复资源错误，已完成时返回验证错误
+func (im *Importer) Resume(ctx context.Context, id string, userID string) (*models.ImportJob, error) {
+	job, err := im.Get(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == models.ImportStatusCompleted {
+		return nil, errors.ErrValidationFailed("status", "import job is already completed")
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal([]byte(job.Entries), &entries); err != nil {
+		return nil, errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to decode import entries").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"job_id": id,
+			})
+	}
+
+	job.Status = models.ImportStatusRunning
+	job.Error = ""
+	if err := im.launch(job, entries); err != nil {
+		return nil, err
+	}
+
+	im.logger.Info("Import resumed", logger.Fields{
+		"job_id": job.ID,
+		"cursor": job.Cursor,
+		"total":  job.Total,
+	})
+
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// Get 获取导入任务；指定用户时，不属于该用户的任务按不存在处理
+func (im *Importer) Get(ctx context.Context, id string, userID string) (*models.ImportJob, error) {
+	job, err := im.store.GetImportJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if userID != "" && job.UserID != userID {
+		return nil, errors.ErrResourceNotFound("import_job", id)
+	}
+
+	// 服务重启前执行中的任务已不在运行，按暂停返回以便继续
+	im.mu.Lock()
+	_, running := im.running[id]
+	im.mu.Unlock()
+	if job.Status == models.ImportStatusRunning && !running {
+		job.Status = models.ImportStatusPaused
+	}
+	return job, nil
+}
+
+// Close 取消所有导入任务并等待其保存进度，未完成的任务变为暂停状态
+func (im *Importer) Close(ctx context.Context) error {
+	im.mu.Lock()
+	im.closed = true
+	runnerIDs := make([]string, 0, len(im.running))
+	for _, runnerID := range im.running {
+		runnerIDs = append(runnerIDs, runnerID)
+	}
+	im.mu.Unlock()
+
+	for _, runnerID := range runnerIDs {
+		if _, err := im.runner.Cancel(ctx, runnerID); err != nil && ctx.Err() != nil {
+			return errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Timed out waiting for imports to stop").
+				WithCause(ctx.Err())
+		}
+	}
+	return nil
+}
+
+// launch 登记任务并交给后台任务管理器执行
+func (im *Importer) launch(job *models.ImportJob, entries []*Entry) error {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	if im.closed {
+		return errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Importer is closed")
+	}
+	if _, running := im.running[job.ID]; running {
+		return errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeDuplicateResource, "Import already running").
+			WithContext(map[string]interface{}{"job_id": job.ID})
+	}
+
+	// 任务使用副本，避免与返回给调用方的快照共享
+	worker := *job
+	managed, err := im.runner.Run(JobType, func(ctx context.Context, progress jobs.ProgressFunc) (interface{}, error) {
+		defer func() {
+			im.mu.Lock()
+			delete(im.running, worker.ID)
+			im.mu.Unlock()
+		}()
+		return im.run(ctx, &worker, entries, progress)
+	})
+	if err != nil {
+		return err
+	}
+	im.running[job.ID] = managed.ID
+	return nil
+}
+
+// run 从任务进度处依次限速提交条目，定期保存进度并上报百分比；暂时性错误重试用尽或ctx取消时暂停任务并返回错误
+func (im *Importer) run(ctx context.Context, job *models.ImportJob, entries []*Entry, progress jobs.ProgressFunc) (interface{}, error) {
+	throttle := time.NewTicker(im.config.Throttle)
+	defer throttle.Stop()
+
+	for job.Cursor < len(entries) {
+		progress(float64(job.Cursor) * 100 / float64(len(entries)))
+
+		select {
+		case <-ctx.Done():
+			im.pause(job, "import interrupted")
+			return nil, ctx.Err()
+		case <-throttle.C:
+		}
+
+		entry := entries[job.Cursor]
+		if err := im.submit(ctx, im.requestFor(job, entry)); err != nil {
+			if ctx.Err() != nil {
+				im.pause(job, "import interrupted")
+				return nil, ctx.Err()
+			}
+			// 只有条目本身无效时计为失败，暂时性错误重试用尽后暂停任务，稍后继续不会丢失条目
+			if !isInvalidEntry(err) {
+				im.pause(job, err.Error())
+				return nil, err
+			}
+			job.Failed++
+			im.logger.Warn("Failed to submit imported entry", logger.Fields{
+				"job_id":    job.ID,
+				"entry_url": entry.URL,
+				"error":     err.Error(),
+			})
+		} else {
+			job.Submitted++
+		}
+		job.Cursor++
+		if job.Cursor%progressSaveInterval == 0 && job.Cursor < len(entries) {
+			im.saveProgress(job)
+		}
+	}
+
+	job.Status = models.ImportStatusCompleted
+	im.saveProgress(job)
+
+	im.logger.Info("Import completed", logger.Fields{
+		"job_id":     job.ID,
+		"total":      job.Total,
+		"submitted":  job.Submitted,
+		"failed":     job.Failed,
+		"duplicates": job.Duplicates,
+	})
+
+	return map[string]interface{}{
+		"import_job_id": job.ID,
+		"total":         job.Total,
+		"submitted":     job.Submitted,
+		"failed":        job.Failed,
+		"duplicates":    job.Duplicates,
+	}, nil
+}
+
+// submit 提交处理请求，队列已满、刷屏或额度用尽等暂时性错误按指数退避重试，重试用尽或ctx取消时返回错误
+func (im *Importer) submit(ctx context.Context, request *content.ProcessingRequest) error {
+	backoff := im.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := im.submitter.ProcessContentAsync(request)
+		if err == nil || isInvalidEntry(err) || attempt >= im.config.MaxRetries {
+			return err
+		}
+
+		im.logger.Debug("Import submission throttled, retrying", logger.Fields{
+			"attempt": attempt + 1,
+			"backoff": backoff.String(),
+			"error":   err.Error(),
+		})
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// isInvalidEntry 提交错误是否由条目本身无效导致（重试不会成功）
+func isInvalidEntry(err error) bool {
+	memoErr, ok := err.(*errors.MemoroError)
+	return ok && memoErr.IsCode(errors.ErrCodeValidationFailed)
+}
+
+// pause 暂停任务并保存进度
+func (im *Importer) pause(job *models.ImportJob, reason string) {
+	job.Status = models.ImportStatusPaused
+	job.Error = reason
+	im.saveProgress(job)
+
+	im.logger.Warn("Import paused", logger.Fields{
+		"job_id": job.ID,
+		"cursor": job.Cursor,
+		"total":  job.Total,
+		"reason": reason,
+	})
+}
+
+// saveProgress 保存任务进度（不受任务ctx取消影响），失败只记录警告
+func (im *Importer) saveProgress(job *models.ImportJob) {
+	if err := im.store.SaveImportJob(context.Background(), job); err != nil {
+		im.logger.Warn("Failed to save import progress", logger.Fields{
+			"job_id": job.ID,
+			"cursor": job.Cursor,
+			"error":  err.Error(),
+		})
+	}
+}
+
+// requestFor 将条目转换为链接处理请求：书签标题优先于页面标题，导入来源和导出文件中的标签作为用户标签
+func (im *Importer) requestFor(job *models.ImportJob, entry *Entry) *content.ProcessingRequest {
+	metadata := map[string]interface{}{
+		"source":        "import",
+		"import_format": job.Format,
+		"import_job_id": job.ID,
+	}
+	if !entry.AddedAt.IsZero() {
+		metadata["import_added_at"] = entry.AddedAt.UTC().Format(time.RFC3339)
+	}
+	requestContext := map[string]interface{}{}
+	if entry.Title != "" {
+		requestContext[content.TitleContextKey] = entry.Title
+	}
+
+	request := &content.ProcessingRequest{
+		ID:          uuid.New().String(),
+		Content:     entry.URL,
+		ContentType: models.ContentTypeLink,
+		UserID:      job.UserID,
+		Context:     requestContext,
+		Metadata:    metadata,
+	}
+	request.Options.ExistingTags = append([]string{SourceTag(Format(job.Format))}, entry.Tags...)
+
+	return request
+}
+
+// SourceTag 标记导入来源的标签
+func SourceTag(format Format) string {
+	switch format {
+	case FormatPocketCSV, FormatPocketJSON:
+		return "import:pocket"
+	default:
+		return "import:bookmarks"
+	}
+}
+
+// skipSaved 跳过用户已保存的链接（按规范URL比较），返回剩余条目和跳过的条目数；查询失败时只记录警告，由处理器去重
+func (im *Importer) skipSaved(ctx context.Context, userID string, entries []*Entry) ([]*Entry, int) {
+	urls, err := im.store.LinkURLs(ctx, userID)
+	if err != nil {
+		im.logger.Warn("Failed to look up saved links, importing without skipping them", logger.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+		return entries, 0
+	}
+	if len(urls) == 0 {
+		return entries, 0
+	}
+
+	saved := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		saved[entryKey(url)] = true
+	}
+	kept := make([]*Entry, 0, len(entries))
+	for _, entry := range entries {
+		if !saved[entryKey(entry.URL)] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, len(entries) - len(kept)
+}
+
+// entryKey 条目去重使用的键：规范URL，无法规范化时使用原始URL
+func entryKey(url string) string {
+	if key := content.CanonicalizeURL(url); key != "" {
+		return key
+	}
+	return url
+}
+
+// dedupEntries 按规范URL去重（保留首次出现的条目，合并重复条目的标签），返回去重后的条目和重复条目数
+func dedupEntries(entries []*Entry) ([]*Entry, int) {
+	kept := make([]*Entry, 0, len(entries))
+	byKey := make(map[string]*Entry, len(entries))
+	for _, entry := range entries {
+		key := entryKey(entry.URL)
+		if first, exists := byKey[key]; exists {
+			first.Tags = uniqueTags(append(first.Tags, entry.Tags...))
+			continue
+		}
+		byKey[key] = entry
+		kept = append(kept, entry)
+	}
+	return kept, len(entries) - len(kept)
+}
@@ -0,0 +1,231 @@
+package importer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/models"
+	"memoro/internal/services/content"
+	"memoro/internal/services/jobs"
+	"memoro/internal/storage"
+)
+
+// recordingSubmitter 记录提交的处理请求，达到上限后返回处理队列已满
+type recordingSubmitter struct {
+	mu       sync.Mutex
+	requests []*content.ProcessingRequest
+	limit    int
+}
+
+func (s *recordingSubmitter) ProcessContentAsync(request *content.ProcessingRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.limit > 0 && len(s.requests) >= s.limit {
+		return errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeSystemGeneric, "Processing queue full")
+	}
+	s.requests = append(s.requests, request)
+	return nil
+}
+
+func (s *recordingSubmitter) submitted() []*content.ProcessingRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*content.ProcessingRequest(nil), s.requests...)
+}
+
+func (s *recordingSubmitter) setLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+}
+
+// testBookmarks 书签文件：包含跟踪参数不同的重复链接、完全重复的链接和无法抓取的书签脚本
+const testBookmarks = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000">Reading</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/blog/go1.23" ADD_DATE="1723507200" TAGS="golang,release">Go 1.23 is released</A>
+        <DT><A HREF="https://go.dev/blog/range-functions" ADD_DATE="1724112000">Range over function types</A>
+        <DT><A HREF="https://go.dev/blog/go1.23?utm_source=twitter" TAGS="news">Go 1.23 &amp; more</A>
+    </DL><p>
+    <DT><A HREF="https://example.com/post">Example post</A>
+    <DT><A HREF="https://example.com/post">Example post again</A>
+    <DT><A HREF="javascript:alert(1)">Bookmarklet</A>
+</DL><p>`
+
+// waitForStatus 轮询导入任务直到进入指定状态
+func waitForStatus(t *testing.T, importer *Importer, id string, status models.ImportStatus) *models.ImportJob {
+	t.Helper()
+	var job *models.ImportJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = importer.store.GetImportJob(context.Background(), id)
+		require.NoError(t, err)
+		return job.Status == status
+	}, 2*time.Second, 5*time.Millisecond)
+	return job
+}
+
+// newTestImporter 创建使用内存数据库的导入服务（限速间隔和首次重试等待1毫秒，最多重试2次）
+func newTestImporter(t *testing.T, submitter ContentSubmitter) (*Importer, *storage.ContentStore) {
+	store, err := storage.OpenContentStore(config.DatabaseConfig{Type: "sqlite", Path: ":memory:", AutoMigrate: true})
+	require.NoError(t, err)
+	manager := jobs.NewJobManager()
+	importConfig := config.ImportConfig{Throttle: time.Millisecond, RetryBackoff: time.Millisecond, MaxRetries: 2}
+	importer := NewImporterWithConfig(importConfig, submitter, store, manager)
+	t.Cleanup(func() {
+		importer.Close(context.Background())
+		manager.Close(context.Background())
+		store.Close()
+	})
+	return importer, store
+}
+
+// TestImporter_Bookmarks 测试书签文件按规范URL去重后提交链接处理请求
+func TestImporter_Bookmarks(t *testing.T) {
+	submitter := &recordingSubmitter{}
+	importer, _ := newTestImporter(t, submitter)
+	ctx := context.Background()
+
+	job, err := importer.Start(ctx, "user-1", FormatBookmarks, []byte(testBookmarks))
+	require.NoError(t, err)
+	assert.Equal(t, 3, job.Total)
+	assert.Equal(t, 2, job.Duplicates)
+
+	job = waitForStatus(t, importer, job.ID, models.ImportStatusCompleted)
+	assert.Equal(t, 3, job.Submitted)
+	assert.Equal(t, float64(100), job.Progress())
+
+	requests := submitter.submitted()
+	require.Len(t, requests, 3)
+	first := requests[0]
+	assert.Equal(t, "https://go.dev/blog/go1.23", first.Content)
+	assert.Equal(t, models.ContentTypeLink, first.ContentType)
+	assert.Equal(t, "user-1", first.UserID)
+	assert.Equal(t, "Go 1.23 is released", first.Context[content.TitleContextKey])
+	assert.Equal(t, "import", first.Metadata["source"])
+	assert.Equal(t, job.ID, first.Metadata["import_job_id"])
+	// 重复条目的标签合并到首次出现的条目
+	assert.Equal(t, []string{"import:bookmarks", "golang", "release", "news"}, first.Options.ExistingTags)
+	assert.Equal(t, "https://go.dev/blog/range-functions", requests[1].Content)
+	assert.Equal(t, "https://example.com/post", requests[2].Content)
+}
+
+// TestImporter_SkipsSavedLinks 测试导入时按规范URL跳过用户已保存的链接
+func TestImporter_SkipsSavedLinks(t *testing.T) {
+	submitter := &recordingSubmitter{}
+	importer, store := newTestImporter(t, submitter)
+	ctx := context.Background()
+
+	saved := models.NewContentItem(models.ContentTypeLink, "https://example.com/post?utm_source=feed", "user-1")
+	require.NotNil(t, saved)
+	require.NoError(t, saved.SetProcessedData(map[string]interface{}{
+		"provenance": map[string]interface{}{
+			"original_url":  "https://example.com/post?utm_source=feed",
+			"canonical_url": "https://example.com/post",
+		},
+	}))
+	require.NoError(t, store.Save(ctx, saved))
+
+	job, err := importer.Start(ctx, "user-1", FormatBookmarks, []byte(testBookmarks))
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.Total)
+	assert.Equal(t, 3, job.Duplicates)
+
+	waitForStatus(t, importer, job.ID, models.ImportStatusCompleted)
+	requests := submitter.submitted()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "https://go.dev/blog/range-functions", requests[1].Content)
+
+	t.Run("其他用户保存的链接不跳过", func(t *testing.T) {
+		job, err := importer.Start(ctx, "user-2", FormatBookmarks, []byte(testBookmarks))
+		require.NoError(t, err)
+		assert.Equal(t, 3, job.Total)
+	})
+}
+
+// TestImporter_Resume 测试处理队列持续已满时重试用尽后暂停导入，继续后从进度处提交剩余条目
+func TestImporter_Resume(t *testing.T) {
+	submitter := &recordingSubmitter{limit: 2}
+	importer, _ := newTestImporter(t, submitter)
+	ctx := context.Background()
+
+	job, err := importer.Start(ctx, "user-1", FormatBookmarks, []byte(testBookmarks))
+	require.NoError(t, err)
+
+	job = waitForStatus(t, importer, job.ID, models.ImportStatusPaused)
+	assert.Equal(t, 2, job.Cursor)
+	assert.Equal(t, 2, job.Submitted)
+	assert.Contains(t, job.Error, "queue full")
+
+	t.Run("其他用户无法继续", func(t *testing.T) {
+		_, err := importer.Resume(ctx, job.ID, "user-2")
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+	})
+
+	t.Run("继续后只提交剩余条目", func(t *testing.T) {
+		submitter.setLimit(0)
+		resumed, err := importer.Resume(ctx, job.ID, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, models.ImportStatusRunning, resumed.Status)
+
+		job = waitForStatus(t, importer, job.ID, models.ImportStatusCompleted)
+		assert.Equal(t, 3, job.Submitted)
+		assert.Empty(t, job.Error)
+
+		requests := submitter.submitted()
+		require.Len(t, requests, 3)
+		assert.Equal(t, "https://example.com/post", requests[2].Content)
+	})
+
+	t.Run("已完成的任务不能继续", func(t *testing.T) {
+		_, err := importer.Resume(ctx, job.ID, "user-1")
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeValidationFailed))
+	})
+}
+
+// TestParse_Pocket 测试解析Pocket导出的CSV和JSON
+func TestParse_Pocket(t *testing.T) {
+	t.Run("CSV", func(t *testing.T) {
+		data := "title,url,time_added,tags,status\n" +
+			"Effective Go,https://go.dev/doc/effective_go,1700000000,golang|docs,unread\n" +
+			"Not a link,mailto:someone@example.com,1700000001,,unread\n"
+		entries, err := Parse(FormatPocketCSV, []byte(data))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "Effective Go", entries[0].Title)
+		assert.Equal(t, []string{"golang", "docs"}, entries[0].Tags)
+		assert.Equal(t, int64(1700000000), entries[0].AddedAt.Unix())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		data := `{"status":1,"list":{
+			"2":{"given_url":"https://example.com/b","resolved_title":"B","time_added":"1700000002","sort_id":1},
+			"1":{"given_url":"https://example.com/a","given_title":"A","time_added":"1700000001","sort_id":0,"tags":{"reading":{"tag":"reading"}}}
+		}}`
+		entries, err := Parse(FormatPocketJSON, []byte(data))
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "https://example.com/a", entries[0].URL)
+		assert.Equal(t, []string{"reading"}, entries[0].Tags)
+		assert.Equal(t, "B", entries[1].Title)
+	})
+
+	t.Run("没有链接的文件", func(t *testing.T) {
+		_, err := Parse(FormatPocketJSON, []byte(`[]`))
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeValidationFailed))
+	})
+}
@@ -0,0 +1,294 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"html"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"memoro/internal/errors"
+)
+
+// Format 导出文件格式
+type Format string
+
+const (
+	FormatBookmarks  Format = "bookmarks"   // 浏览器导出的Netscape书签HTML（Chrome、Firefox、Safari及Pocket的HTML导出）
+	FormatPocketCSV  Format = "pocket_csv"  // Pocket导出的CSV（title,url,time_added,tags,status）
+	FormatPocketJSON Format = "pocket_json" // Pocket API的JSON（list对象）或条目数组
+)
+
+// Entry 导出文件中的一个条目
+type Entry struct {
+	URL     string    `json:"url"`                // 条目链接
+	Title   string    `json:"title,omitempty"`    // 标题
+	Tags    []string  `json:"tags,omitempty"`     // 导出文件中的标签
+	AddedAt time.Time `json:"added_at,omitempty"` // 加入书签或稍后读的时间（未提供时为零值）
+}
+
+// ValidFormat 判断导出文件格式是否受支持
+func ValidFormat(format Format) bool {
+	switch format {
+	case FormatBookmarks, FormatPocketCSV, FormatPocketJSON:
+		return true
+	}
+	return false
+}
+
+// FormatNames 受支持的导出文件格式名称（用于错误提示）
+func FormatNames() []string {
+	return []string{string(FormatBookmarks), string(FormatPocketCSV), string(FormatPocketJSON)}
+}
+
+// Parse 按指定格式解析导出文件，按文件中的顺序返回条目（非http(s)链接的条目会被跳过）
+func Parse(format Format, data []byte) ([]*Entry, error) {
+	var entries []*Entry
+	var err error
+	switch format {
+	case FormatBookmarks:
+		entries = parseBookmarks(data)
+	case FormatPocketCSV:
+		entries, err = parsePocketCSV(data)
+	case FormatPocketJSON:
+		entries, err = parsePocketJSON(data)
+	default:
+		return nil, errors.ErrValidationFailed("format", "must be one of: "+strings.Join(FormatNames(), ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.ErrValidationFailed("file", "no links found for format "+string(format))
+	}
+
+	return entries, nil
+}
+
+// bookmarkAnchorPattern 匹配书签HTML中的链接元素
+var bookmarkAnchorPattern = regexp.MustCompile(`(?is)<a\s([^>]*)>(.*?)</a>`)
+
+// bookmarkAttrPattern 匹配链接元素的属性（属性名不区分大小写）
+var bookmarkAttrPattern = regexp.MustCompile(`(?is)([a-z_]+)\s*=\s*"([^"]*)"`)
+
+// htmlTagPattern 匹配标题中残留的HTML标签
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// parseBookmarks 解析Netscape书签HTML，读取HREF、ADD_DATE和TAGS属性
+func parseBookmarks(data []byte) []*Entry {
+	matches := bookmarkAnchorPattern.FindAllSubmatch(data, -1)
+	entries := make([]*Entry, 0, len(matches))
+	for _, match := range matches {
+		attrs := make(map[string]string)
+		for _, attr := range bookmarkAttrPattern.FindAllSubmatch(match[1], -1) {
+			attrs[strings.ToLower(string(attr[1]))] = html.UnescapeString(string(attr[2]))
+		}
+
+		link := strings.TrimSpace(attrs["href"])
+		if !isWebURL(link) {
+			continue
+		}
+		title := strings.TrimSpace(html.UnescapeString(htmlTagPattern.ReplaceAllString(string(match[2]), "")))
+		entries = append(entries, &Entry{
+			URL:     link,
+			Title:   title,
+			Tags:    splitTags(attrs["tags"], ","),
+			AddedAt: parseUnixTime(attrs["add_date"]),
+		})
+	}
+	return entries
+}
+
+// parsePocketCSV 解析Pocket导出的CSV，按表头定位列（标签以|分隔）
+func parsePocketCSV(data []byte) ([]*Entry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.ErrValidationFailed("file", "invalid CSV: "+err.Error())
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, exists := columns["url"]; !exists {
+		return nil, errors.ErrValidationFailed("file", "CSV header must contain a url column")
+	}
+	field := func(record []string, name string) string {
+		if i, exists := columns[name]; exists && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var entries []*Entry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.ErrValidationFailed("file", "invalid CSV: "+err.Error())
+		}
+
+		link := field(record, "url")
+		if !isWebURL(link) {
+			continue
+		}
+		entries = append(entries, &Entry{
+			URL:     link,
+			Title:   field(record, "title"),
+			Tags:    splitTags(field(record, "tags"), "|"),
+			AddedAt: parseUnixTime(field(record, "time_added")),
+		})
+	}
+	return entries, nil
+}
+
+// pocketItem Pocket API条目（list对象的值）
+type pocketItem struct {
+	GivenURL      string                     `json:"given_url"`
+	ResolvedURL   string                     `json:"resolved_url"`
+	GivenTitle    string                     `json:"given_title"`
+	ResolvedTitle string                     `json:"resolved_title"`
+	TimeAdded     string                     `json:"time_added"`
+	SortID        int                        `json:"sort_id"`
+	Tags          map[string]json.RawMessage `json:"tags"`
+}
+
+// pocketArrayItem 条目数组形式的JSON条目
+type pocketArrayItem struct {
+	URL       string          `json:"url"`
+	Title     string          `json:"title"`
+	Tags      []string        `json:"tags"`
+	TimeAdded json.RawMessage `json:"time_added"`
+}
+
+// parsePocketJSON 解析Pocket API的JSON（{"list": {...}}，按sort_id排序）或{url,title,tags,time_added}条目数组
+func parsePocketJSON(data []byte) ([]*Entry, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var items []pocketArrayItem
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, errors.ErrValidationFailed("file", "invalid JSON: "+err.Error())
+		}
+
+		entries := make([]*Entry, 0, len(items))
+		for _, item := range items {
+			link := strings.TrimSpace(item.URL)
+			if !isWebURL(link) {
+				continue
+			}
+			entries = append(entries, &Entry{
+				URL:     link,
+				Title:   strings.TrimSpace(item.Title),
+				Tags:    uniqueTags(item.Tags),
+				AddedAt: parseUnixTime(strings.Trim(string(item.TimeAdded), `"`)),
+			})
+		}
+		return entries, nil
+	}
+
+	var doc struct {
+		List map[string]pocketItem `json:"list"`
+	}
+	if err := json.Unmarshal(trimmed, &doc); err != nil {
+		return nil, errors.ErrValidationFailed("file", "invalid JSON: "+err.Error())
+	}
+
+	items := make([]pocketItem, 0, len(doc.List))
+	for _, item := range doc.List {
+		items = append(items, item)
+	}
+	// list对象没有顺序，按sort_id排序保证导入顺序稳定
+	sort.Slice(items, func(i, j int) bool {
+		return pocketItemLess(items[i], items[j])
+	})
+
+	entries := make([]*Entry, 0, len(items))
+	for _, item := range items {
+		link := strings.TrimSpace(item.GivenURL)
+		if link == "" {
+			link = strings.TrimSpace(item.ResolvedURL)
+		}
+		if !isWebURL(link) {
+			continue
+		}
+		title := strings.TrimSpace(item.ResolvedTitle)
+		if title == "" {
+			title = strings.TrimSpace(item.GivenTitle)
+		}
+		tags := make([]string, 0, len(item.Tags))
+		for tag := range item.Tags {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		entries = append(entries, &Entry{
+			URL:     link,
+			Title:   title,
+			Tags:    uniqueTags(tags),
+			AddedAt: parseUnixTime(item.TimeAdded),
+		})
+	}
+	return entries, nil
+}
+
+// pocketItemLess 比较两个Pocket条目的顺序：按sort_id，相同时按加入时间和链接
+func pocketItemLess(a, b pocketItem) bool {
+	if a.SortID != b.SortID {
+		return a.SortID < b.SortID
+	}
+	if a.TimeAdded != b.TimeAdded {
+		return a.TimeAdded < b.TimeAdded
+	}
+	return a.GivenURL < b.GivenURL
+}
+
+// isWebURL 判断是否为http(s)链接（书签中的javascript:、place:等链接无法抓取）
+func isWebURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	return parsed.Scheme == "http" || parsed.Scheme == "https"
+}
+
+// splitTags 按分隔符拆分标签，去掉空白和重复
+func splitTags(value string, separator string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return uniqueTags(strings.Split(value, separator))
+}
+
+// uniqueTags 去掉标签的首尾空白、空标签和重复标签（忽略大小写），保持原有顺序
+func uniqueTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var unique []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, tag)
+	}
+	return unique
+}
+
+// parseUnixTime 解析秒级Unix时间戳，无效时返回零值
+func parseUnixTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
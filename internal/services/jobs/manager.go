@@ -0,0 +1,329 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+)
+
+// maxFinishedJobs 保留的已结束任务数量（超过后丢弃最早结束的）
+const maxFinishedJobs = 100
+
+// JobStatus 任务状态
+type JobStatus string
+
+const (
+	JobStatusRunning   JobStatus = "running"   // 执行中
+	JobStatusCompleted JobStatus = "completed" // 执行成功
+	JobStatusFailed    JobStatus = "failed"    // 执行失败
+	JobStatusCancelled JobStatus = "cancelled" // 已取消
+)
+
+// ProgressFunc 任务上报进度的函数，percent为0到100之间的百分比
+type ProgressFunc func(percent float64)
+
+// JobFunc 任务执行函数，应在ctx取消时尽快返回，返回的结果在任务成功后随状态一起返回
+type JobFunc func(ctx context.Context, progress ProgressFunc) (interface{}, error)
+
+// Job 任务状态快照
+type Job struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Status     JobStatus   `json:"status"`
+	Progress   float64     `json:"progress"`              // 进度百分比（0-100）
+	Error      string      `json:"error,omitempty"`       // 失败原因
+	Result     interface{} `json:"result,omitempty"`      // 任务成功时的结果
+	StartedAt  time.Time   `json:"started_at"`            // 开始时间
+	FinishedAt *time.Time  `json:"finished_at,omitempty"` // 结束时间（执行中为空）
+}
+
+// runningJob 任务的内部状态
+type runningJob struct {
+	job    Job
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// JobManager 后台任务管理器：按类型注册运维任务（同一类型同时只允许一个任务执行）或直接执行一次性任务，
+// 每个任务在可取消的goroutine中执行并记录状态、进度和错误
+type JobManager struct {
+	mu      sync.RWMutex
+	types   map[string]JobFunc
+	jobs    map[string]*runningJob
+	running map[string]string // 任务类型 -> 执行中的任务ID
+	closed  bool
+
+	wg     sync.WaitGroup
+	logger *logger.Logger
+}
+
+// NewJobManager 创建任务管理器
+func NewJobManager() *JobManager {
+	return &JobManager{
+		types:   make(map[string]JobFunc),
+		jobs:    make(map[string]*runningJob),
+		running: make(map[string]string),
+		logger:  logger.NewLogger("job-manager"),
+	}
+}
+
+// Register 注册任务类型，类型名不能重复
+func (m *JobManager) Register(jobType string, fn JobFunc) error {
+	if jobType == "" {
+		return errors.ErrValidationFailed("type", "cannot be empty")
+	}
+	if fn == nil {
+		return errors.ErrValidationFailed("job", "cannot be nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.types[jobType]; exists {
+		return errors.ErrValidationFailed("type", "job type already registered: "+jobType)
+	}
+	m.types[jobType] = fn
+	return nil
+}
+
+// Types 获取已注册的任务类型
+func (m *JobManager) Types() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	types := make([]string, 0, len(m.types))
+	for jobType := range m.types {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Start 启动指定类型的任务，类型未注册时返回资源不存在错误，同类型任务执行中时返回重复资源错误
+func (m *JobManager) Start(jobType string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Job manager is closed")
+	}
+	fn, exists := m.types[jobType]
+	if !exists {
+		return nil, errors.ErrResourceNotFound("job type", jobType)
+	}
+	if runningID, exists := m.running[jobType]; exists {
+		return nil, errors.NewMemoroError(errors.ErrorTypeBusiness, errors.ErrCodeDuplicateResource, "Job already running").
+			WithDetails(fmt.Sprintf("%s job is already running as %s", jobType, runningID)).
+			WithContext(map[string]interface{}{"type": jobType, "job_id": runningID})
+	}
+
+	job := m.launch(jobType, fn)
+	m.running[jobType] = job.job.ID
+
+	snapshot := job.job
+	return &snapshot, nil
+}
+
+// Run 启动一次性任务（如导入），无需注册类型，同类型任务可以同时执行；状态、进度、取消和关闭与注册的任务相同
+func (m *JobManager) Run(jobType string, fn JobFunc) (*Job, error) {
+	if jobType == "" {
+		return nil, errors.ErrValidationFailed("type", "cannot be empty")
+	}
+	if fn == nil {
+		return nil, errors.ErrValidationFailed("job", "cannot be nil")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Job manager is closed")
+	}
+
+	job := m.launch(jobType, fn)
+	snapshot := job.job
+	return &snapshot, nil
+}
+
+// launch 登记任务并在后台执行，调用方需持有写锁
+func (m *JobManager) launch(jobType string, fn JobFunc) *runningJob {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &runningJob{
+		job: Job{
+			ID:        uuid.New().String(),
+			Type:      jobType,
+			Status:    JobStatusRunning,
+			StartedAt: time.Now(),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.jobs[job.job.ID] = job
+
+	m.logger.Info("Job started", logger.Fields{
+		"job_id": job.job.ID,
+		"type":   jobType,
+	})
+
+	m.wg.Add(1)
+	go m.run(ctx, job, fn)
+	return job
+}
+
+// Get 获取任务状态，任务不存在时返回资源不存在错误
+func (m *JobManager) Get(id string) (*Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, exists := m.jobs[id]
+	if !exists {
+		return nil, errors.ErrResourceNotFound("job", id)
+	}
+	snapshot := job.job
+	return &snapshot, nil
+}
+
+// Cancel 取消执行中的任务并等待其退出，返回取消后的状态；任务已结束时直接返回其状态
+func (m *JobManager) Cancel(ctx context.Context, id string) (*Job, error) {
+	m.mu.RLock()
+	job, exists := m.jobs[id]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, errors.ErrResourceNotFound("job", id)
+	}
+
+	job.cancel()
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return m.Get(id)
+}
+
+// Close 取消所有执行中的任务并等待退出，之后不再接受新任务
+func (m *JobManager) Close(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	for _, job := range m.jobs {
+		if job.job.FinishedAt == nil {
+			job.cancel()
+		}
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 执行任务并记录结束状态，任务panic时记为失败
+func (m *JobManager) run(ctx context.Context, job *runningJob, fn JobFunc) {
+	defer m.wg.Done()
+	defer close(job.done)
+
+	var result interface{}
+	var err error
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = fmt.Errorf("job panicked: %v", recovered)
+			}
+		}()
+		result, err = fn(ctx, func(percent float64) {
+			m.setProgress(job, percent)
+		})
+	}()
+
+	m.finish(job, ctx.Err() != nil, result, err)
+}
+
+// setProgress 更新任务进度，超出范围的值截断到0-100
+func (m *JobManager) setProgress(job *runningJob, percent float64) {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if job.job.Status == JobStatusRunning {
+		job.job.Progress = percent
+	}
+}
+
+// finish 记录任务结束状态并清理超出保留数量的已结束任务
+func (m *JobManager) finish(job *runningJob, cancelled bool, result interface{}, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	finishedAt := time.Now()
+	job.job.FinishedAt = &finishedAt
+	switch {
+	case cancelled:
+		job.job.Status = JobStatusCancelled
+	case err != nil:
+		job.job.Status = JobStatusFailed
+		job.job.Error = err.Error()
+	default:
+		job.job.Status = JobStatusCompleted
+		job.job.Progress = 100
+		job.job.Result = result
+	}
+	job.cancel()
+	if m.running[job.job.Type] == job.job.ID {
+		delete(m.running, job.job.Type)
+	}
+
+	fields := logger.Fields{
+		"job_id":   job.job.ID,
+		"type":     job.job.Type,
+		"status":   string(job.job.Status),
+		"duration": finishedAt.Sub(job.job.StartedAt).String(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		m.logger.Warn("Job finished with error", fields)
+	} else {
+		m.logger.Info("Job finished", fields)
+	}
+
+	m.pruneFinished()
+}
+
+// pruneFinished 丢弃最早结束的任务，使已结束任务数不超过保留数量
+func (m *JobManager) pruneFinished() {
+	finished := make([]*runningJob, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		if job.job.FinishedAt != nil {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].job.FinishedAt.Before(*finished[j].job.FinishedAt)
+	})
+	for _, job := range finished[:len(finished)-maxFinishedJobs] {
+		delete(m.jobs, job.job.ID)
+	}
+}
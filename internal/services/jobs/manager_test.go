@@ -0,0 +1,165 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/errors"
+)
+
+// longJob 分步执行的模拟长任务，每步上报进度，步骤间等待下一步信号或取消
+func longJob(steps int, next <-chan struct{}) JobFunc {
+	return func(ctx context.Context, progress ProgressFunc) (interface{}, error) {
+		for i := 1; i <= steps; i++ {
+			select {
+			case <-next:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			progress(float64(i) * 100 / float64(steps))
+		}
+		return map[string]int{"steps": steps}, nil
+	}
+}
+
+// waitForJob 轮询任务直到满足条件
+func waitForJob(t *testing.T, manager *JobManager, id string, condition func(*Job) bool) *Job {
+	t.Helper()
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = manager.Get(id)
+		require.NoError(t, err)
+		return condition(job)
+	}, 2*time.Second, 5*time.Millisecond)
+	return job
+}
+
+// TestJobManager 测试任务启动、进度查询、取消和同类型互斥
+func TestJobManager(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("进度随任务执行更新并在完成后返回结果", func(t *testing.T) {
+		manager := NewJobManager()
+		defer manager.Close(ctx)
+		next := make(chan struct{})
+		require.NoError(t, manager.Register("reindex", longJob(4, next)))
+
+		job, err := manager.Start("reindex")
+		require.NoError(t, err)
+		assert.Equal(t, JobStatusRunning, job.Status)
+		assert.Zero(t, job.Progress)
+
+		next <- struct{}{}
+		next <- struct{}{}
+		job = waitForJob(t, manager, job.ID, func(job *Job) bool { return job.Progress == 50 })
+		assert.Equal(t, JobStatusRunning, job.Status)
+
+		next <- struct{}{}
+		next <- struct{}{}
+		job = waitForJob(t, manager, job.ID, func(job *Job) bool { return job.Status != JobStatusRunning })
+		assert.Equal(t, JobStatusCompleted, job.Status)
+		assert.Equal(t, float64(100), job.Progress)
+		assert.Equal(t, map[string]int{"steps": 4}, job.Result)
+		assert.NotNil(t, job.FinishedAt)
+	})
+
+	t.Run("同类型任务执行中时拒绝启动，取消后可再次启动", func(t *testing.T) {
+		manager := NewJobManager()
+		defer manager.Close(ctx)
+		require.NoError(t, manager.Register("reindex", longJob(10, make(chan struct{}))))
+
+		first, err := manager.Start("reindex")
+		require.NoError(t, err)
+
+		_, err = manager.Start("reindex")
+		require.Error(t, err)
+		memoErr, ok := err.(*errors.MemoroError)
+		require.True(t, ok)
+		assert.Equal(t, errors.ErrCodeDuplicateResource, memoErr.Code)
+
+		cancelled, err := manager.Cancel(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobStatusCancelled, cancelled.Status)
+
+		second, err := manager.Start("reindex")
+		require.NoError(t, err)
+		assert.NotEqual(t, first.ID, second.ID)
+	})
+
+	t.Run("失败和panic记录错误信息", func(t *testing.T) {
+		manager := NewJobManager()
+		defer manager.Close(ctx)
+		require.NoError(t, manager.Register("reconcile", func(context.Context, ProgressFunc) (interface{}, error) {
+			return nil, fmt.Errorf("vector store unavailable")
+		}))
+		require.NoError(t, manager.Register("trending", func(context.Context, ProgressFunc) (interface{}, error) {
+			panic("boom")
+		}))
+
+		failed, err := manager.Start("reconcile")
+		require.NoError(t, err)
+		job := waitForJob(t, manager, failed.ID, func(job *Job) bool { return job.Status != JobStatusRunning })
+		assert.Equal(t, JobStatusFailed, job.Status)
+		assert.Equal(t, "vector store unavailable", job.Error)
+
+		panicked, err := manager.Start("trending")
+		require.NoError(t, err)
+		job = waitForJob(t, manager, panicked.ID, func(job *Job) bool { return job.Status != JobStatusRunning })
+		assert.Equal(t, JobStatusFailed, job.Status)
+		assert.Contains(t, job.Error, "boom")
+	})
+
+	t.Run("未注册的类型和不存在的任务", func(t *testing.T) {
+		manager := NewJobManager()
+		defer manager.Close(ctx)
+
+		_, err := manager.Start("unknown")
+		assert.Error(t, err)
+		_, err = manager.Get("missing")
+		assert.Error(t, err)
+		_, err = manager.Cancel(ctx, "missing")
+		assert.Error(t, err)
+		assert.Error(t, manager.Register("", longJob(1, nil)))
+	})
+
+	t.Run("一次性任务无需注册且同类型可并行，关闭时一并取消", func(t *testing.T) {
+		manager := NewJobManager()
+		first, err := manager.Run("import", longJob(10, make(chan struct{})))
+		require.NoError(t, err)
+		second, err := manager.Run("import", longJob(10, make(chan struct{})))
+		require.NoError(t, err)
+		assert.NotEqual(t, first.ID, second.ID)
+		assert.Empty(t, manager.Types())
+
+		require.NoError(t, manager.Close(ctx))
+		for _, id := range []string{first.ID, second.ID} {
+			job, err := manager.Get(id)
+			require.NoError(t, err)
+			assert.Equal(t, JobStatusCancelled, job.Status)
+		}
+
+		_, err = manager.Run("import", longJob(1, nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("关闭时取消执行中的任务并拒绝新任务", func(t *testing.T) {
+		manager := NewJobManager()
+		require.NoError(t, manager.Register("archive", longJob(10, make(chan struct{}))))
+		job, err := manager.Start("archive")
+		require.NoError(t, err)
+
+		require.NoError(t, manager.Close(ctx))
+		job, err = manager.Get(job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobStatusCancelled, job.Status)
+
+		_, err = manager.Start("archive")
+		assert.Error(t, err)
+	})
+}
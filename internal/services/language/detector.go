@@ -0,0 +1,143 @@
+package language
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/abadojack/whatlanggo"
+
+	"memoro/internal/config"
+)
+
+// Unknown 无法可靠判断语言时返回的语言代码
+const Unknown = "unknown"
+
+const (
+	// defaultMinTextLength 字母文字参与三元组识别的最少字母数
+	defaultMinTextLength = 12
+	// defaultMinConfidence 低于该置信度时返回unknown
+	defaultMinConfidence = 0.5
+	// minScriptChars 中日韩等文字可直接确定语言，所需的最少字符数
+	minScriptChars = 2
+	// saturationLetters 字母文字达到该字母数后不再因长度降低置信度
+	saturationLetters = 40
+	// saturationScriptChars 中日韩文本达到该字符数后不再因长度降低置信度
+	saturationScriptChars = 4
+	// scriptCharWeight 中日韩单字符相对拉丁字母的信息量权重（用于混合文本判断主体语言）
+	scriptCharWeight = 3
+	// kanaRatioThreshold 假名占比达到该值时判定为日文
+	kanaRatioThreshold = 0.1
+)
+
+// Detector 语言检测器（中日韩按文字判断，拉丁、西里尔等字母文字使用whatlanggo的三元组模型）
+type Detector struct {
+	config config.LanguageDetectionConfig
+}
+
+var defaultDetector = NewDetector(config.LanguageDetectionConfig{})
+
+// NewDetector 创建语言检测器
+func NewDetector(detectionConfig config.LanguageDetectionConfig) *Detector {
+	if detectionConfig.MinTextLength <= 0 {
+		detectionConfig.MinTextLength = defaultMinTextLength
+	}
+	if detectionConfig.MinConfidence <= 0 {
+		detectionConfig.MinConfidence = defaultMinConfidence
+	}
+
+	return &Detector{config: detectionConfig}
+}
+
+// Detect 使用默认配置检测文本语言，返回ISO 639-1语言代码和置信度
+func Detect(text string) (string, float64) {
+	return defaultDetector.Detect(text)
+}
+
+// Detect 检测文本语言，返回ISO 639-1语言代码和置信度；文本过短或置信度不足时返回unknown
+func (d *Detector) Detect(text string) (string, float64) {
+	var han, kana, hangul, latin, other int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.IsLetter(r):
+			other++
+		}
+	}
+
+	cjk := han + kana
+	totalWeight := float64((cjk+hangul)*scriptCharWeight + latin + other)
+	if totalWeight == 0 {
+		return Unknown, 0
+	}
+
+	var code string
+	var confidence float64
+	switch {
+	case cjk >= hangul && float64(cjk*scriptCharWeight) >= float64(latin+other):
+		if cjk < minScriptChars {
+			return Unknown, 0
+		}
+		code = "zh"
+		if float64(kana) >= float64(cjk)*kanaRatioThreshold {
+			code = "ja"
+		}
+		share := float64(cjk*scriptCharWeight) / totalWeight
+		confidence = share * lengthFactor(cjk, saturationScriptChars)
+	case hangul*scriptCharWeight >= latin+other:
+		if hangul < minScriptChars {
+			return Unknown, 0
+		}
+		code = "ko"
+		share := float64(hangul*scriptCharWeight) / totalWeight
+		confidence = share * lengthFactor(hangul, saturationScriptChars)
+	default:
+		letters := latin + other
+		if letters < d.config.MinTextLength {
+			return Unknown, 0
+		}
+		var modelConfidence float64
+		code, modelConfidence = detectAlphabetic(text)
+		if code == Unknown {
+			return Unknown, 0
+		}
+		share := float64(letters) / totalWeight
+		confidence = modelConfidence * share * lengthFactor(letters, saturationLetters)
+	}
+
+	confidence = math.Round(confidence*1000) / 1000
+	if confidence < d.config.MinConfidence {
+		return Unknown, confidence
+	}
+	return code, confidence
+}
+
+// lengthFactor 根据文本长度计算置信度系数
+func lengthFactor(count, saturation int) float64 {
+	if count >= saturation {
+		return 1
+	}
+	return float64(count) / float64(saturation)
+}
+
+// detectAlphabetic 使用whatlanggo识别字母文字部分的语言，返回ISO 639-1语言代码及模型置信度
+func detectAlphabetic(text string) (string, float64) {
+	// 去掉中日韩文字，避免混合文本中的少量汉字左右文字判断
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return IsCJKRune(r) || !unicode.IsLetter(r) && r != '\'' && r != '’'
+	})
+
+	info := whatlanggo.Detect(strings.Join(words, " "))
+	code := info.Lang.Iso6391()
+	if code == "" {
+		return Unknown, 0
+	}
+	return code, info.Confidence
+}
@@ -0,0 +1,87 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"memoro/internal/config"
+)
+
+// TestDetect 测试语言检测
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "英文",
+			text:     "Go is an open source programming language that makes it simple to build reliable software.",
+			expected: "en",
+		},
+		{
+			name:     "中文",
+			text:     "今天学习了Go语言的并发编程，goroutine和channel的设计非常优雅。",
+			expected: "zh",
+		},
+		{
+			name:     "日文",
+			text:     "今日はGo言語の並行処理について勉強しました。とても面白かったです。",
+			expected: "ja",
+		},
+		{
+			name:     "法文",
+			text:     "Je voudrais réserver une table pour deux personnes ce soir, s'il vous plaît.",
+			expected: "fr",
+		},
+		{
+			name:     "西班牙文",
+			text:     "El desarrollo rápido de la tecnología moderna ha cambiado la forma en que las personas trabajan y viven.",
+			expected: "es",
+		},
+		{
+			name:     "俄文",
+			text:     "Быстрое развитие современных технологий изменило то, как люди работают и живут.",
+			expected: "ru",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, confidence := Detect(tt.text)
+			assert.Equal(t, tt.expected, code)
+			assert.GreaterOrEqual(t, confidence, defaultMinConfidence)
+			assert.LessOrEqual(t, confidence, 1.0)
+		})
+	}
+
+	t.Run("短文本返回unknown", func(t *testing.T) {
+		code, confidence := Detect("ok thx")
+		assert.Equal(t, Unknown, code)
+		assert.Less(t, confidence, defaultMinConfidence)
+	})
+
+	t.Run("无文字内容返回unknown", func(t *testing.T) {
+		code, confidence := Detect("12345 !!! 😀")
+		assert.Equal(t, Unknown, code)
+		assert.Zero(t, confidence)
+	})
+
+	t.Run("中英混合以主体语言为准", func(t *testing.T) {
+		code, _ := Detect("这篇关于Kubernetes的文章介绍了如何部署服务和配置负载均衡")
+		assert.Equal(t, "zh", code)
+	})
+}
+
+// TestDetector_MinConfidence 测试置信度阈值配置
+func TestDetector_MinConfidence(t *testing.T) {
+	text := "Bonjour à tous, merci beaucoup"
+
+	code, confidence := NewDetector(config.LanguageDetectionConfig{MinConfidence: 0.1}).Detect(text)
+	assert.Equal(t, "fr", code)
+
+	strictCode, strictConfidence := NewDetector(config.LanguageDetectionConfig{MinConfidence: 0.99}).Detect(text)
+	assert.Equal(t, Unknown, strictCode)
+	assert.Equal(t, confidence, strictConfidence)
+}
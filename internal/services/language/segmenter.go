@@ -0,0 +1,97 @@
+package language
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/go-ego/gse"
+
+	"memoro/internal/logger"
+)
+
+// segmenterDictionaries 中日文分词使用的gse内置词频词典（简体中文和日文）
+var segmenterDictionaries = []string{"zh_s", "ja"}
+
+// defaultSegmenter 包级分词器，词典在后台加载
+var defaultSegmenter = newLazySegmenter(loadEmbeddedSegmenter)
+
+// lazySegmenter 后台加载词典的中日文分词器：词典加载耗时数秒，加载完成前按字切分，避免阻塞处理请求
+type lazySegmenter struct {
+	load  func() (*gse.Segmenter, error)
+	once  sync.Once
+	ready chan struct{}
+	seg   *gse.Segmenter
+}
+
+// newLazySegmenter 创建后台加载词典的分词器
+func newLazySegmenter(load func() (*gse.Segmenter, error)) *lazySegmenter {
+	return &lazySegmenter{
+		load:  load,
+		ready: make(chan struct{}),
+	}
+}
+
+// PreloadSegmenter 在后台开始加载分词词典（可重复调用），返回加载结束时关闭的通道
+func PreloadSegmenter() <-chan struct{} {
+	return defaultSegmenter.preload()
+}
+
+// preload 启动后台加载，返回加载结束时关闭的通道
+func (l *lazySegmenter) preload() <-chan struct{} {
+	l.once.Do(func() {
+		go func() {
+			defer close(l.ready)
+
+			seg, err := l.load()
+			if err != nil {
+				logger.Error("Failed to load segmenter dictionaries, falling back to per-character segmentation", logger.Fields{
+					"error": err.Error(),
+				})
+				return
+			}
+			l.seg = seg
+		}()
+	})
+	return l.ready
+}
+
+// segment 切分中日文字片段：词典就绪时使用gse（词典路径加HMM识别未登录词），否则逐字切分
+func (l *lazySegmenter) segment(runes []rune) []string {
+	select {
+	case <-l.preload():
+	default:
+		return splitRunes(runes)
+	}
+	if l.seg == nil {
+		return splitRunes(runes)
+	}
+
+	words := l.seg.Cut(string(runes), true)
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// splitRunes 逐字切分
+func splitRunes(runes []rune) []string {
+	tokens := make([]string, len(runes))
+	for i, r := range runes {
+		tokens[i] = string(r)
+	}
+	return tokens
+}
+
+// loadEmbeddedSegmenter 加载gse内置的词频词典
+func loadEmbeddedSegmenter() (*gse.Segmenter, error) {
+	seg := &gse.Segmenter{}
+	for _, dict := range segmenterDictionaries {
+		if err := seg.LoadDictEmbed(dict); err != nil {
+			return nil, err
+		}
+	}
+	return seg, nil
+}
@@ -0,0 +1,52 @@
+package language
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-ego/gse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLazySegmenter 测试分词词典在后台加载
+func TestLazySegmenter(t *testing.T) {
+	text := []rune("分布式系统")
+
+	t.Run("词典加载完成前不阻塞且逐字切分", func(t *testing.T) {
+		release := make(chan struct{})
+		segmenter := newLazySegmenter(func() (*gse.Segmenter, error) {
+			<-release
+			seg := &gse.Segmenter{}
+			if err := seg.LoadDictStr("分布式 1000 n\n系统 1000 n"); err != nil {
+				return nil, err
+			}
+			return seg, nil
+		})
+
+		done := make(chan []string, 1)
+		go func() { done <- segmenter.segment(text) }()
+
+		select {
+		case tokens := <-done:
+			assert.Equal(t, []string{"分", "布", "式", "系", "统"}, tokens)
+		case <-time.After(time.Second):
+			t.Fatal("segment blocked on dictionary loading")
+		}
+
+		close(release)
+		<-segmenter.preload()
+		assert.Equal(t, []string{"分布式", "系统"}, segmenter.segment(text))
+	})
+
+	t.Run("词典加载失败时逐字切分", func(t *testing.T) {
+		segmenter := newLazySegmenter(func() (*gse.Segmenter, error) {
+			return nil, errors.New("dictionary missing")
+		})
+
+		<-segmenter.preload()
+		require.Nil(t, segmenter.seg)
+		assert.Equal(t, []string{"分", "布", "式", "系", "统"}, segmenter.segment(text))
+	})
+}
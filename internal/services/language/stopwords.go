@@ -0,0 +1,32 @@
+package language
+
+import "strings"
+
+// stopwords 中英文停用词表（内容分类的关键词提取和搜索的查询词加权共用）
+var stopwords = map[string]bool{
+	// 中文
+	"的": true, "了": true, "在": true, "是": true, "我": true, "有": true, "和": true,
+	"就": true, "不": true, "人": true, "都": true, "一": true, "个": true, "上": true,
+	"也": true, "很": true, "到": true, "说": true, "要": true, "去": true, "你": true,
+	"会": true, "着": true, "没": true, "看": true, "好": true, "自": true, "己": true,
+	"可以": true, "这个": true, "那个": true, "什么": true, "怎么": true, "为什么": true,
+	"哪个": true, "如何": true, "是否": true, "一个": true, "我们": true, "他们": true,
+
+	// 英文
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true,
+	"you": true, "all": true, "can": true, "had": true, "her": true, "was": true,
+	"one": true, "our": true, "out": true, "day": true, "get": true, "has": true,
+	"what": true, "which": true, "who": true, "whom": true, "whose": true, "when": true,
+	"where": true, "why": true, "how": true, "this": true, "that": true, "these": true,
+	"those": true, "with": true, "from": true, "into": true, "about": true, "than": true,
+	"then": true, "there": true, "their": true, "they": true, "them": true, "been": true,
+	"being": true, "have": true, "does": true, "did": true, "doing": true, "will": true,
+	"would": true, "should": true, "could": true, "its": true, "his": true, "she": true,
+	"any": true, "some": true, "such": true, "only": true, "own": true, "same": true,
+	"very": true, "just": true, "also": true, "more": true, "most": true, "other": true,
+}
+
+// IsStopword 判断词是否为停用词（不区分大小写）
+func IsStopword(word string) bool {
+	return stopwords[strings.ToLower(word)]
+}
@@ -0,0 +1,104 @@
+package language
+
+import (
+	"math"
+	"unicode"
+
+	"memoro/internal/config"
+)
+
+// defaultReadingSpeed 未配置语言阅读速度时使用的每分钟词数
+const defaultReadingSpeed = 200
+
+// defaultReadingSpeeds 各语言默认的每分钟阅读词数（按本分词器的切分口径）
+var defaultReadingSpeeds = map[string]int{
+	"en": 230,
+	"zh": 250,
+	"ja": 250,
+	"ko": 220,
+}
+
+// IsCJKRune 是否为中日韩文字（汉字、假名、韩文）
+func IsCJKRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// isUnspacedRune 是否为不以空格分词的文字（汉字和假名，韩文以空格分词）
+func isUnspacedRune(r rune) bool {
+	return IsCJKRune(r) && !unicode.Is(unicode.Hangul, r)
+}
+
+// isWordRune 是否为以空格分词的语言中词的组成字符（字母、数字、组合符号）
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// Tokenize 将文本切分为词：汉字和假名的连续片段交给gse按词频词典切分（词典加载完成前逐字切分），
+// 拉丁字母、韩文等以空格分词的文字按空白和标点切分（词内的撇号保留，如don't）
+func Tokenize(text string) []string {
+	runes := []rune(text)
+	tokens := make([]string, 0, len(runes)/2)
+
+	for i := 0; i < len(runes); {
+		switch {
+		case isUnspacedRune(runes[i]):
+			end := i
+			for end < len(runes) && isUnspacedRune(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, defaultSegmenter.segment(runes[i:end])...)
+			i = end
+		case isWordRune(runes[i]):
+			end := i
+			for end < len(runes) {
+				if isWordRune(runes[end]) && !isUnspacedRune(runes[end]) {
+					end++
+					continue
+				}
+				// 词内撇号（don't、it's）
+				if (runes[end] == '\'' || runes[end] == '’') && end+1 < len(runes) && isWordRune(runes[end+1]) && !isUnspacedRune(runes[end+1]) {
+					end += 2
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		default:
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// CountWords 统计文本的词数（切分规则同Tokenize）
+func CountWords(text string) int {
+	return len(Tokenize(text))
+}
+
+// ReadingSpeed 获取语言的每分钟阅读词数：优先使用配置，其次使用内置的语言默认值
+func ReadingSpeed(lang string, speeds config.ReadingSpeedConfig) int {
+	if speed := speeds.Languages[lang]; speed > 0 {
+		return speed
+	}
+	if speed, exists := defaultReadingSpeeds[lang]; exists {
+		return speed
+	}
+	if speeds.WordsPerMinute > 0 {
+		return speeds.WordsPerMinute
+	}
+	return defaultReadingSpeed
+}
+
+// EstimateReadMinutes 按语言的阅读速度估算阅读时间（分钟，四舍五入，至少1分钟）
+func EstimateReadMinutes(wordCount int, lang string, speeds config.ReadingSpeedConfig) int {
+	minutes := int(math.Round(float64(wordCount) / float64(ReadingSpeed(lang, speeds))))
+	if minutes < 1 {
+		return 1
+	}
+	return minutes
+}
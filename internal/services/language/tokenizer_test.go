@@ -0,0 +1,95 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"memoro/internal/config"
+)
+
+// TestTokenize 测试分词
+func TestTokenize(t *testing.T) {
+	<-PreloadSegmenter()
+
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{
+			name:     "英文按空白和标点切分",
+			text:     "Go makes it simple, don't you think?",
+			expected: []string{"Go", "makes", "it", "simple", "don't", "you", "think"},
+		},
+		{
+			name: "中文按词切分",
+			text: "我们今天讨论分布式系统的一致性问题，以及如何选择合适的方案。",
+			expected: []string{
+				"我们", "今天", "讨论", "分布式", "系统", "的", "一致性", "问题",
+				"以及", "如何", "选择", "合适", "的", "方案",
+			},
+		},
+		{
+			name:     "中英混合",
+			text:     "Go语言的goroutine调度器很高效, don't worry.",
+			expected: []string{"Go", "语言", "的", "goroutine", "调度器", "很", "高效", "don't", "worry"},
+		},
+		{
+			name:     "日文助词单独成词",
+			text:     "東京は日本の首都",
+			expected: []string{"東京", "は", "日本", "の", "首都"},
+		},
+		{
+			name:     "韩文按空格切分",
+			text:     "안녕하세요 세계",
+			expected: []string{"안녕하세요", "세계"},
+		},
+		{
+			name:     "无文字内容",
+			text:     "!!! ... 😀",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Tokenize(tt.text))
+		})
+	}
+}
+
+// TestIsCJKRune 测试中日韩文字判断
+func TestIsCJKRune(t *testing.T) {
+	for _, r := range "中あア한" {
+		assert.True(t, IsCJKRune(r), string(r))
+	}
+	for _, r := range "a1é!" {
+		assert.False(t, IsCJKRune(r), string(r))
+	}
+}
+
+// TestEstimateReadMinutes 测试按语言估算阅读时间
+func TestEstimateReadMinutes(t *testing.T) {
+	t.Run("使用语言的默认阅读速度", func(t *testing.T) {
+		assert.Equal(t, 250, ReadingSpeed("zh", config.ReadingSpeedConfig{}))
+		assert.Equal(t, 230, ReadingSpeed("en", config.ReadingSpeedConfig{}))
+		assert.Equal(t, defaultReadingSpeed, ReadingSpeed(Unknown, config.ReadingSpeedConfig{}))
+	})
+
+	t.Run("配置优先于默认值", func(t *testing.T) {
+		speeds := config.ReadingSpeedConfig{
+			WordsPerMinute: 180,
+			Languages:      map[string]int{"zh": 300},
+		}
+		assert.Equal(t, 300, ReadingSpeed("zh", speeds))
+		assert.Equal(t, 230, ReadingSpeed("en", speeds))
+		assert.Equal(t, 180, ReadingSpeed("fr", speeds))
+	})
+
+	t.Run("四舍五入且至少1分钟", func(t *testing.T) {
+		assert.Equal(t, 1, EstimateReadMinutes(0, "zh", config.ReadingSpeedConfig{}))
+		assert.Equal(t, 2, EstimateReadMinutes(400, "zh", config.ReadingSpeedConfig{}))
+		assert.Equal(t, 3, EstimateReadMinutes(640, "zh", config.ReadingSpeedConfig{}))
+	})
+}
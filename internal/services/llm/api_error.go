@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+
+	"memoro/internal/errors"
+)
+
+// maxErrorBodyLength 错误详情中保留的响应体长度上限
+const maxErrorBodyLength = 500
+
+// providerErrorBody OpenAI兼容接口的错误响应体
+type providerErrorBody struct {
+	Error struct {
+		Message string      `json:"message"`
+		Type    string      `json:"type"`
+		Code    interface{} `json:"code"` // 部分服务返回字符串，部分返回数字
+	} `json:"error"`
+}
+
+// ClassifyAPIError 将LLM/embedding接口的错误响应按状态码和提供方错误类型转换为对应错误码的错误：
+// 401/403为认证失败，429为限流，5xx为服务端错误，其他4xx为无效请求
+func ClassifyAPIError(service string, statusCode int, body []byte) *errors.MemoroError {
+	message, errorType := parseProviderError(body)
+
+	code := errors.ErrCodeLLMAPICall
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		code = errors.ErrCodeLLMAuth
+	case statusCode == http.StatusTooManyRequests:
+		code = errors.ErrCodeLLMRateLimited
+	case statusCode == http.StatusRequestTimeout || statusCode >= 500:
+		code = errors.ErrCodeLLMServerError
+	case statusCode >= 400:
+		code = errors.ErrCodeLLMInvalidRequest
+	}
+
+	// 部分服务用400等状态返回限流或密钥错误，以错误类型为准
+	switch {
+	case strings.Contains(errorType, "rate_limit"):
+		code = errors.ErrCodeLLMRateLimited
+	case strings.Contains(errorType, "invalid_api_key") || strings.Contains(errorType, "authentication"):
+		code = errors.ErrCodeLLMAuth
+	}
+
+	return errors.ErrLLMAPIStatus(code, service, statusCode, message)
+}
+
+// parseProviderError 从响应体解析错误信息和错误类型（小写），无法解析时返回截断的响应体
+func parseProviderError(body []byte) (string, string) {
+	var parsed providerErrorBody
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Error.Message != "" {
+		errorType := parsed.Error.Type
+		if code, ok := parsed.Error.Code.(string); ok && code != "" {
+			errorType += " " + code
+		}
+		return parsed.Error.Message, strings.ToLower(errorType)
+	}
+
+	text := strings.TrimSpace(string(body))
+	if len(text) > maxErrorBodyLength {
+		text = text[:maxErrorBodyLength] + "..."
+	}
+	return text, ""
+}
+
+// RetryCondition resty重试条件：网络错误、408、429和5xx重试，其他状态不重试
+func RetryCondition(resp *resty.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	if resp == nil {
+		return false
+	}
+	statusCode := resp.StatusCode()
+	return statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
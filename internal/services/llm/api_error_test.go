@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"memoro/internal/errors"
+)
+
+// TestClassifyAPIError 测试按状态码和提供方错误类型分类接口错误
+func TestClassifyAPIError(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		expectedCode    errors.ErrorCode
+		expectedMessage string
+	}{
+		{"401认证失败", http.StatusUnauthorized, `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error"}}`, errors.ErrCodeLLMAuth, "Incorrect API key provided"},
+		{"403无权限", http.StatusForbidden, `forbidden`, errors.ErrCodeLLMAuth, "forbidden"},
+		{"429限流", http.StatusTooManyRequests, `{"error":{"message":"Rate limit reached","type":"requests"}}`, errors.ErrCodeLLMRateLimited, "Rate limit reached"},
+		{"400无效请求", http.StatusBadRequest, `{"error":{"message":"Invalid model","type":"invalid_request_error"}}`, errors.ErrCodeLLMInvalidRequest, "Invalid model"},
+		{"400但错误类型为限流", http.StatusBadRequest, `{"error":{"message":"Too many requests","type":"rate_limit_error"}}`, errors.ErrCodeLLMRateLimited, "Too many requests"},
+		{"400但错误码为密钥无效", http.StatusBadRequest, `{"error":{"message":"Bad key","type":"invalid_request_error","code":"invalid_api_key"}}`, errors.ErrCodeLLMAuth, "Bad key"},
+		{"500服务端错误", http.StatusInternalServerError, `upstream error`, errors.ErrCodeLLMServerError, "upstream error"},
+		{"503服务不可用", http.StatusServiceUnavailable, ``, errors.ErrCodeLLMServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memoErr := ClassifyAPIError("Embedding", tt.status, []byte(tt.body))
+			assert.Equal(t, tt.expectedCode, memoErr.Code)
+			assert.Equal(t, errors.ErrorTypeLLM, memoErr.Type)
+			assert.Contains(t, memoErr.Details, tt.expectedMessage)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+)
+
+// defaultBudgetWindow 默认的周期额度统计窗口
+const defaultBudgetWindow = 24 * time.Hour
+
+// TokenBudget token预算记账器，统计单用户和全局的token消耗并执行额度限制
+type TokenBudget struct {
+	config config.TokenBudgetConfig
+	mu     sync.Mutex
+	global *tokenUsage
+	users  map[string]*tokenUsage
+	now    func() time.Time
+	logger *logger.Logger
+}
+
+// tokenUsage 单个统计对象的token消耗
+type tokenUsage struct {
+	windowStart  time.Time
+	windowTokens int64
+	totalTokens  int64
+	requests     int64
+}
+
+// TokenUsageStats token消耗统计
+type TokenUsageStats struct {
+	WindowTokens int64     `json:"window_tokens"` // 当前窗口消耗
+	TotalTokens  int64     `json:"total_tokens"`  // 累计消耗
+	Requests     int64     `json:"requests"`      // 请求次数
+	WindowStart  time.Time `json:"window_start"`  // 当前窗口开始时间
+}
+
+// budgetUserKey 上下文中的预算用户键
+type budgetUserKey struct{}
+
+var (
+	sharedBudget     *TokenBudget
+	sharedBudgetOnce sync.Once
+)
+
+// NewTokenBudget 创建token预算记账器
+func NewTokenBudget(budgetConfig config.TokenBudgetConfig) *TokenBudget {
+	if budgetConfig.Window <= 0 {
+		budgetConfig.Window = defaultBudgetWindow
+	}
+
+	return &TokenBudget{
+		config: budgetConfig,
+		global: &tokenUsage{},
+		users:  make(map[string]*tokenUsage),
+		now:    time.Now,
+		logger: logger.NewLogger("token-budget"),
+	}
+}
+
+// GetTokenBudget 获取进程内共享的token预算记账器
+func GetTokenBudget() *TokenBudget {
+	sharedBudgetOnce.Do(func() {
+		var budgetConfig config.TokenBudgetConfig
+		if cfg := config.Get(); cfg != nil {
+			budgetConfig = cfg.LLM.TokenBudget
+		}
+		sharedBudget = NewTokenBudget(budgetConfig)
+	})
+	return sharedBudget
+}
+
+// WithBudgetUser 在上下文中标记token消耗归属的用户
+func WithBudgetUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, budgetUserKey{}, userID)
+}
+
+// BudgetUserFromContext 从上下文获取token消耗归属的用户
+func BudgetUserFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	userID, _ := ctx.Value(budgetUserKey{}).(string)
+	return userID
+}
+
+// Check 检查预计消耗是否会超出额度，超出时返回预算超限错误
+func (b *TokenBudget) Check(userID string, estimatedTokens int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	estimated := int64(estimatedTokens)
+
+	b.global.resetIfExpired(now, b.config.Window)
+	if err := checkLimit("global window", b.global.windowTokens, estimated, b.config.GlobalWindowLimit); err != nil {
+		return err
+	}
+	if err := checkLimit("global total", b.global.totalTokens, estimated, b.config.GlobalTotalLimit); err != nil {
+		return err
+	}
+
+	if userID == "" {
+		return nil
+	}
+
+	usage, exists := b.users[userID]
+	if !exists {
+		usage = &tokenUsage{}
+	}
+
+	usage.resetIfExpired(now, b.config.Window)
+	if err := checkLimit("user window", usage.windowTokens, estimated, b.config.UserWindowLimit); err != nil {
+		return err.WithContext(map[string]interface{}{"user_id": userID})
+	}
+	if err := checkLimit("user total", usage.totalTokens, estimated, b.config.UserTotalLimit); err != nil {
+		return err.WithContext(map[string]interface{}{"user_id": userID})
+	}
+
+	return nil
+}
+
+// Record 记录一次调用实际消耗的token
+func (b *TokenBudget) Record(userID string, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.global.add(now, b.config.Window, int64(tokens))
+
+	if userID != "" {
+		usage, exists := b.users[userID]
+		if !exists {
+			usage = &tokenUsage{}
+			b.users[userID] = usage
+		}
+		usage.add(now, b.config.Window, int64(tokens))
+	}
+}
+
+// Usage 获取用户的token消耗，userID为空时返回全局消耗
+func (b *TokenBudget) Usage(userID string) TokenUsageStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	usage := b.global
+	if userID != "" {
+		var exists bool
+		if usage, exists = b.users[userID]; !exists {
+			return TokenUsageStats{}
+		}
+	}
+
+	usage.resetIfExpired(b.now(), b.config.Window)
+	return TokenUsageStats{
+		WindowTokens: usage.windowTokens,
+		TotalTokens:  usage.totalTokens,
+		Requests:     usage.requests,
+		WindowStart:  usage.windowStart,
+	}
+}
+
+// GetStats 获取预算统计信息
+func (b *TokenBudget) GetStats() map[string]interface{} {
+	global := b.Usage("")
+
+	b.mu.Lock()
+	userCount := len(b.users)
+	b.mu.Unlock()
+
+	return map[string]interface{}{
+		"window":              b.config.Window.String(),
+		"global_window_usage": global.WindowTokens,
+		"global_total_usage":  global.TotalTokens,
+		"global_requests":     global.Requests,
+		"global_window_limit": b.config.GlobalWindowLimit,
+		"global_total_limit":  b.config.GlobalTotalLimit,
+		"user_window_limit":   b.config.UserWindowLimit,
+		"user_total_limit":    b.config.UserTotalLimit,
+		"tracked_users":       userCount,
+	}
+}
+
+// checkLimit 检查单项额度，limit为0表示不限制
+func checkLimit(scope string, used, estimated, limit int64) *errors.MemoroError {
+	if limit <= 0 {
+		return nil
+	}
+	if used >= limit || used+estimated > limit {
+		return errors.ErrBudgetExceeded(scope, used, limit)
+	}
+	return nil
+}
+
+// resetIfExpired 窗口到期时重置周期消耗
+func (u *tokenUsage) resetIfExpired(now time.Time, window time.Duration) {
+	if u.windowStart.IsZero() || now.Sub(u.windowStart) >= window {
+		u.windowStart = now
+		u.windowTokens = 0
+	}
+}
+
+// add 累加token消耗
+func (u *tokenUsage) add(now time.Time, window time.Duration, tokens int64) {
+	u.resetIfExpired(now, window)
+	u.windowTokens += tokens
+	u.totalTokens += tokens
+	u.requests++
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+)
+
+// newTestTokenBudget 创建使用可控时钟的预算记账器
+func newTestTokenBudget(budgetConfig config.TokenBudgetConfig) (*TokenBudget, *time.Time) {
+	now := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	budget := NewTokenBudget(budgetConfig)
+	budget.now = func() time.Time { return now }
+	return budget, &now
+}
+
+// assertBudgetExceeded 断言返回预算超限错误
+func assertBudgetExceeded(t *testing.T, err error) {
+	require.Error(t, err)
+	memoErr, ok := err.(*errors.MemoroError)
+	require.True(t, ok)
+	assert.True(t, memoErr.IsCode(errors.ErrCodeBudgetExceeded))
+}
+
+func TestTokenBudget(t *testing.T) {
+	t.Run("超出用户窗口额度后拒绝调用", func(t *testing.T) {
+		budget, _ := newTestTokenBudget(config.TokenBudgetConfig{UserWindowLimit: 100})
+
+		require.NoError(t, budget.Check("user-1", 10))
+		budget.Record("user-1", 60)
+		require.NoError(t, budget.Check("user-1", 10))
+		budget.Record("user-1", 50)
+
+		assertBudgetExceeded(t, budget.Check("user-1", 0))
+		// 其他用户不受影响
+		assert.NoError(t, budget.Check("user-2", 10))
+	})
+
+	t.Run("预计消耗超出额度时拒绝", func(t *testing.T) {
+		budget, _ := newTestTokenBudget(config.TokenBudgetConfig{UserWindowLimit: 100})
+		budget.Record("user-1", 90)
+
+		assertBudgetExceeded(t, budget.Check("user-1", 20))
+		assert.NoError(t, budget.Check("user-1", 10))
+	})
+
+	t.Run("窗口到期后重置用量", func(t *testing.T) {
+		budget, now := newTestTokenBudget(config.TokenBudgetConfig{
+			Window:          time.Hour,
+			UserWindowLimit: 100,
+		})
+		budget.Record("user-1", 120)
+		assertBudgetExceeded(t, budget.Check("user-1", 0))
+
+		*now = now.Add(time.Hour)
+
+		assert.NoError(t, budget.Check("user-1", 10))
+		usage := budget.Usage("user-1")
+		assert.Equal(t, int64(0), usage.WindowTokens)
+		assert.Equal(t, int64(120), usage.TotalTokens)
+	})
+
+	t.Run("累计额度不随窗口重置", func(t *testing.T) {
+		budget, now := newTestTokenBudget(config.TokenBudgetConfig{
+			Window:           time.Hour,
+			GlobalTotalLimit: 100,
+		})
+		budget.Record("user-1", 70)
+		*now = now.Add(2 * time.Hour)
+		budget.Record("user-2", 40)
+
+		assertBudgetExceeded(t, budget.Check("user-3", 0))
+		assert.Equal(t, int64(110), budget.Usage("").TotalTokens)
+	})
+
+	t.Run("未配置额度时不限制", func(t *testing.T) {
+		budget, _ := newTestTokenBudget(config.TokenBudgetConfig{})
+		budget.Record("user-1", 1000000)
+
+		assert.NoError(t, budget.Check("user-1", 1000000))
+	})
+
+	t.Run("统计信息", func(t *testing.T) {
+		budget, _ := newTestTokenBudget(config.TokenBudgetConfig{GlobalWindowLimit: 500})
+		budget.Record("user-1", 30)
+		budget.Record("user-2", 20)
+
+		stats := budget.GetStats()
+		assert.Equal(t, int64(50), stats["global_window_usage"])
+		assert.Equal(t, int64(2), stats["global_requests"])
+		assert.Equal(t, int64(500), stats["global_window_limit"])
+		assert.Equal(t, 2, stats["tracked_users"])
+	})
+
+	t.Run("上下文携带用户", func(t *testing.T) {
+		ctx := WithBudgetUser(context.Background(), "user-1")
+		assert.Equal(t, "user-1", BudgetUserFromContext(ctx))
+		assert.Empty(t, BudgetUserFromContext(context.Background()))
+	})
+}
@@ -15,6 +15,7 @@ import (
 type Client struct {
 	httpClient *resty.Client
 	config     config.LLMConfig
+	budget     *TokenBudget
 	logger     *logger.Logger
 }
 
@@ -87,6 +88,8 @@ func NewClient() (*Client, error) {
 	httpClient.SetRetryCount(cfg.RetryTimes)
 	httpClient.SetRetryWaitTime(cfg.RetryDelay)
 	httpClient.SetRetryMaxWaitTime(cfg.RetryDelay * 3)
+	// 只重试网络错误、限流和服务端错误，认证失败和无效请求不重试
+	httpClient.AddRetryCondition(RetryCondition)
 
 	// 添加请求日志
 	httpClient.OnBeforeRequest(func(c *resty.Client, req *resty.Request) error {
@@ -117,6 +120,7 @@ func NewClient() (*Client, error) {
 	client := &Client{
 		httpClient: httpClient,
 		config:     cfg,
+		budget:     GetTokenBudget(),
 		logger:     clientLogger,
 	}
 
@@ -151,17 +155,37 @@ func (c *Client) ChatCompletion(ctx context.Context, messages []ChatMessage) (*C
 		}
 	}
 
-	// 构建请求
+	// 检查token预算
+	budgetUser := BudgetUserFromContext(ctx)
+	if c.budget != nil {
+		if err := c.budget.Check(budgetUser, 0); err != nil {
+			c.logger.Warn("Chat completion blocked by token budget", logger.Fields{
+				"user_id": budgetUser,
+				"error":   err.Error(),
+			})
+			return nil, err
+		}
+	}
+
+	// 构建请求（上下文指定模型时覆盖默认模型）
+	model := c.config.Model
+	if override := ModelFromContext(ctx); override != "" {
+		model = override
+	}
+	// 按上下文所属阶段覆盖max_tokens和temperature
+	stage := StageFromContext(ctx)
+	maxTokens, temperature := stageParameters(c.config, stage)
 	request := ChatCompletionRequest{
-		Model:       c.config.Model,
+		Model:       model,
 		Messages:    messages,
-		MaxTokens:   c.config.MaxTokens,
-		Temperature: c.config.Temperature,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
 		Stream:      false, // 目前只支持非流式
 	}
 
 	c.logger.Debug("Sending chat completion request", logger.Fields{
 		"model":         request.Model,
+		"stage":         string(stage),
 		"message_count": len(messages),
 		"max_tokens":    request.MaxTokens,
 		"temperature":   request.Temperature,
@@ -187,12 +211,7 @@ func (c *Client) ChatCompletion(ctx context.Context, messages []ChatMessage) (*C
 
 	// 检查HTTP状态
 	if resp.StatusCode() != 200 {
-		memoErr := errors.NewMemoroError(errors.ErrorTypeLLM, errors.ErrCodeLLMAPICall, "LLM API returned error status").
-			WithDetails(fmt.Sprintf("Status: %d, Body: %s", resp.StatusCode(), string(resp.Body()))).
-			WithContext(map[string]interface{}{
-				"status_code": resp.StatusCode(),
-				"model":       request.Model,
-			})
+		memoErr := ClassifyAPIError("LLM", resp.StatusCode(), resp.Body())
 		c.logger.LogMemoroError(memoErr, "LLM API error response")
 		return nil, memoErr
 	}
@@ -217,6 +236,12 @@ func (c *Client) ChatCompletion(ctx context.Context, messages []ChatMessage) (*C
 		return nil, memoErr
 	}
 
+	// 记录token消耗
+	if c.budget != nil {
+		c.budget.Record(budgetUser, result.Usage.TotalTokens)
+	}
+	RecordTokenUsage(ctx, result.Usage.TotalTokens)
+
 	c.logger.Debug("Chat completion successful", logger.Fields{
 		"response_id":       result.ID,
 		"model":             result.Model,
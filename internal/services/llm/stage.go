@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"context"
+
+	"memoro/internal/config"
+)
+
+// Stage LLM调用所属的处理阶段，用于按阶段覆盖temperature和max_tokens
+type Stage string
+
+const (
+	StageSummary        Stage = "summary"        // 摘要生成
+	StageTag            Stage = "tag"            // 标签生成
+	StageClassification Stage = "classification" // 内容分类
+)
+
+// stageKey 上下文中处理阶段的键
+type stageKey struct{}
+
+// WithStage 在上下文中指定本次调用所属的处理阶段
+func WithStage(ctx context.Context, stage Stage) context.Context {
+	return context.WithValue(ctx, stageKey{}, stage)
+}
+
+// StageFromContext 从上下文获取处理阶段，未指定时返回空字符串
+func StageFromContext(ctx context.Context) Stage {
+	if ctx == nil {
+		return ""
+	}
+	stage, _ := ctx.Value(stageKey{}).(Stage)
+	return stage
+}
+
+// withDefaultStage 上下文未指定处理阶段时设置默认阶段（如分类调用标签生成时保留分类阶段）
+func withDefaultStage(ctx context.Context, stage Stage) context.Context {
+	if StageFromContext(ctx) != "" {
+		return ctx
+	}
+	return WithStage(ctx, stage)
+}
+
+// stageParameters 返回指定阶段的max_tokens和temperature，阶段未覆盖的值使用全局配置
+func stageParameters(cfg config.LLMConfig, stage Stage) (int, float64) {
+	maxTokens, temperature := cfg.MaxTokens, cfg.Temperature
+
+	var override config.LLMStageConfig
+	switch stage {
+	case StageSummary:
+		override = cfg.Stages.Summary
+	case StageTag:
+		override = cfg.Stages.Tag
+	case StageClassification:
+		override = cfg.Stages.Classification
+	default:
+		return maxTokens, temperature
+	}
+
+	if override.MaxTokens > 0 {
+		maxTokens = override.MaxTokens
+	}
+	if override.Temperature != nil {
+		temperature = *override.Temperature
+	}
+	return maxTokens, temperature
+}
@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/models"
+)
+
+// TestStageParameters 测试摘要、标签和分类阶段按配置覆盖temperature和max_tokens，未覆盖时使用全局值
+func TestStageParameters(t *testing.T) {
+	var mu sync.Mutex
+	var requests []ChatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		mu.Lock()
+		requests = append(requests, request)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"chat-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"golang, release"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`)
+	}))
+	defer server.Close()
+
+	lastRequest := func(t *testing.T) ChatCompletionRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		require.NotEmpty(t, requests)
+		return requests[len(requests)-1]
+	}
+
+	summaryTemperature := 0.1
+	tagTemperature := 0.9
+	require.NoError(t, config.InitializeForTest(&config.Config{
+		LLM: config.LLMConfig{
+			APIBase:     server.URL,
+			APIKey:      "test-key",
+			Model:       "test-model",
+			MaxTokens:   1000,
+			Temperature: 0.7,
+			Timeout:     5 * time.Second,
+			Stages: config.LLMStagesConfig{
+				Summary: config.LLMStageConfig{Temperature: &summaryTemperature},
+				Tag:     config.LLMStageConfig{Temperature: &tagTemperature, MaxTokens: 200},
+			},
+		},
+		Processing: config.ProcessingConfig{
+			SummaryLevels: config.SummaryLevelsConfig{OneLineMaxLength: 100},
+		},
+	}))
+
+	client, err := NewClient()
+	require.NoError(t, err)
+	summarizer, err := NewSummarizer(client)
+	require.NoError(t, err)
+	tagger, err := NewTagger(client)
+	require.NoError(t, err)
+	ctx := context.Background()
+	const text = "Go 1.23 adds range-over-func iterators."
+
+	t.Run("摘要阶段使用配置的低temperature", func(t *testing.T) {
+		_, err := summarizer.GenerateQuickSummary(ctx, text, models.ContentTypeText)
+		require.NoError(t, err)
+		request := lastRequest(t)
+		assert.Equal(t, 0.1, request.Temperature)
+		assert.Equal(t, 1000, request.MaxTokens)
+	})
+
+	t.Run("标签阶段使用自己的参数", func(t *testing.T) {
+		_, err := tagger.GenerateSimpleTags(ctx, text, models.ContentTypeText, 5)
+		require.NoError(t, err)
+		request := lastRequest(t)
+		assert.Equal(t, 0.9, request.Temperature)
+		assert.Equal(t, 200, request.MaxTokens)
+	})
+
+	t.Run("指定的阶段不被默认阶段覆盖", func(t *testing.T) {
+		_, err := tagger.GenerateSimpleTags(WithStage(ctx, StageClassification), text, models.ContentTypeText, 5)
+		require.NoError(t, err)
+		request := lastRequest(t)
+		// 分类阶段未配置覆盖，使用全局值
+		assert.Equal(t, 0.7, request.Temperature)
+		assert.Equal(t, 1000, request.MaxTokens)
+	})
+
+	t.Run("未指定阶段时使用全局值", func(t *testing.T) {
+		_, err := client.SimpleCompletion(ctx, "", text)
+		require.NoError(t, err)
+		request := lastRequest(t)
+		assert.Equal(t, 0.7, request.Temperature)
+		assert.Equal(t, 1000, request.MaxTokens)
+	})
+}
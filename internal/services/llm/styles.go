@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// SummaryStyle 摘要风格，为空时使用默认风格
+type SummaryStyle string
+
+const (
+	SummaryStyleConcise   SummaryStyle = "concise"   // 简洁：只保留最核心的结论
+	SummaryStyleDetailed  SummaryStyle = "detailed"  // 详尽：尽量保留细节和数据
+	SummaryStyleBullet    SummaryStyle = "bullet"    // 要点：以要点列表组织段落和详细摘要
+	SummaryStyleTechnical SummaryStyle = "technical" // 技术：保留术语、参数和实现细节
+)
+
+// summaryStyleInstructions 各摘要风格追加到系统提示的要求
+var summaryStyleInstructions = map[SummaryStyle]string{
+	SummaryStyleConcise: `摘要风格：简洁
+- 只保留最核心的结论，省略背景和举例
+- 在长度上限内尽量简短`,
+	SummaryStyleDetailed: `摘要风格：详尽
+- 尽量保留重要细节、数据和例子
+- 在长度上限内尽量完整`,
+	SummaryStyleBullet: `摘要风格：要点
+- 段落摘要和详细摘要以"- "开头的要点列表组织，每个要点一行
+- 一句话摘要仍为一句话`,
+	SummaryStyleTechnical: `摘要风格：技术
+- 保留专业术语、参数、版本号和实现细节
+- 面向具备相关背景的读者，不解释基础概念`,
+}
+
+// ValidSummaryStyle 判断摘要风格是否受支持（空字符串表示默认风格）
+func ValidSummaryStyle(style SummaryStyle) bool {
+	if style == "" {
+		return true
+	}
+	_, exists := summaryStyleInstructions[style]
+	return exists
+}
+
+// SummaryStyleNames 受支持的摘要风格名称（用于错误提示）
+func SummaryStyleNames() []string {
+	return []string{string(SummaryStyleConcise), string(SummaryStyleDetailed), string(SummaryStyleBullet), string(SummaryStyleTechnical)}
+}
+
+// applySummaryStyle 将摘要风格要求追加到系统提示
+func applySummaryStyle(systemPrompt string, style SummaryStyle) string {
+	instruction, exists := summaryStyleInstructions[style]
+	if !exists {
+		return systemPrompt
+	}
+	return systemPrompt + "\n\n" + instruction
+}
+
+// modelOverrideKey 上下文中覆盖模型的键
+type modelOverrideKey struct{}
+
+// WithModel 在上下文中指定本次调用使用的模型，覆盖配置的默认模型（为空时不覆盖）
+func WithModel(ctx context.Context, model string) context.Context {
+	return context.WithValue(ctx, modelOverrideKey{}, strings.TrimSpace(model))
+}
+
+// ModelFromContext 从上下文获取覆盖的模型，未指定时返回空字符串
+func ModelFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	model, _ := ctx.Value(modelOverrideKey{}).(string)
+	return model
+}
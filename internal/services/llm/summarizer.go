@@ -23,6 +23,7 @@ type SummaryRequest struct {
 	Content     string                 `json:"content"`
 	ContentType models.ContentType     `json:"content_type"`
 	Context     map[string]interface{} `json:"context,omitempty"` // 可选的上下文信息
+	Style       SummaryStyle           `json:"style,omitempty"`   // 摘要风格，为空时使用默认风格
 }
 
 // SummaryResult 摘要结果
@@ -64,6 +65,13 @@ func (s *Summarizer) GenerateSummary(ctx context.Context, request SummaryRequest
 		return nil, errors.ErrValidationFailed("content", "cannot be empty")
 	}
 
+	// 未指定阶段时按摘要阶段调用LLM
+	ctx = withDefaultStage(ctx, StageSummary)
+
+	if !ValidSummaryStyle(request.Style) {
+		return nil, errors.ErrValidationFailed("style", "must be one of: "+strings.Join(SummaryStyleNames(), ", "))
+	}
+
 	if len(request.Content) > s.config.MaxContentSize {
 		return nil, errors.ErrValidationFailed("content", fmt.Sprintf("content too large (max %d bytes)", s.config.MaxContentSize))
 	}
@@ -75,7 +83,7 @@ func (s *Summarizer) GenerateSummary(ctx context.Context, request SummaryRequest
 	})
 
 	// 构建系统提示
-	systemPrompt := s.buildSystemPrompt(request.ContentType)
+	systemPrompt := applySummaryStyle(s.buildSystemPrompt(request.ContentType), request.Style)
 
 	// 生成一句话摘要
 	oneLine, err := s.generateOneLineSummary(ctx, systemPrompt, request.Content)
@@ -388,7 +396,7 @@ func (s *Summarizer) GenerateQuickSummary(ctx context.Context, content string, c
 	}
 
 	systemPrompt := s.buildSystemPrompt(contentType)
-	return s.generateOneLineSummary(ctx, systemPrompt, content)
+	return s.generateOneLineSummary(withDefaultStage(ctx, StageSummary), systemPrompt, content)
 }
 
 // Close 关闭摘要生成器
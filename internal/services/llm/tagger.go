@@ -95,7 +95,8 @@ func (t *Tagger) GenerateTags(ctx context.Context, request TagRequest) (*TagResu
 		"has_context":    request.Context != nil,
 	})
 
-	// 构建系统提示和用户请求
+	// 构建系统提示和用户请求（未指定阶段时按标签生成阶段调用）
+	ctx = withDefaultStage(ctx, StageTag)
 	systemPrompt := t.buildSystemPrompt(request.ContentType)
 	userPrompt := t.buildUserPrompt(request)
 
@@ -166,7 +167,7 @@ func (t *Tagger) GenerateSimpleTags(ctx context.Context, content string, content
 
 标签（用逗号分隔）：`, t.getContentTypeDisplay(contentType), maxTags, content)
 
-	response, err := t.client.SimpleCompletion(ctx, systemPrompt, userPrompt)
+	response, err := t.client.SimpleCompletion(withDefaultStage(ctx, StageTag), systemPrompt, userPrompt)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// usageRecorderKey 上下文中的用量记录器键
+type usageRecorderKey struct{}
+
+// UsageRecorder 统计一段处理中的模型调用：token消耗、调用次数和缓存命中次数。
+// 通过上下文传给LLM和向量化调用，上下文中没有记录器时不做统计
+type UsageRecorder struct {
+	tokens    atomic.Int64
+	calls     atomic.Int64
+	cacheHits atomic.Int64
+}
+
+// WithUsageRecorder 在上下文中附加用量记录器，后续的模型调用和缓存命中计入该记录器
+func WithUsageRecorder(ctx context.Context, recorder *UsageRecorder) context.Context {
+	return context.WithValue(ctx, usageRecorderKey{}, recorder)
+}
+
+// usageRecorderFrom 获取上下文中的用量记录器
+func usageRecorderFrom(ctx context.Context) *UsageRecorder {
+	if ctx == nil {
+		return nil
+	}
+	recorder, _ := ctx.Value(usageRecorderKey{}).(*UsageRecorder)
+	return recorder
+}
+
+// RecordTokenUsage 记录一次模型调用及其token消耗
+func RecordTokenUsage(ctx context.Context, tokens int) {
+	if recorder := usageRecorderFrom(ctx); recorder != nil {
+		recorder.calls.Add(1)
+		recorder.tokens.Add(int64(tokens))
+	}
+}
+
+// RecordCacheHit 记录一次缓存命中（省去了一次模型调用或外部请求）
+func RecordCacheHit(ctx context.Context) {
+	if recorder := usageRecorderFrom(ctx); recorder != nil {
+		recorder.cacheHits.Add(1)
+	}
+}
+
+// Tokens 累计的token消耗
+func (r *UsageRecorder) Tokens() int64 {
+	return r.tokens.Load()
+}
+
+// Calls 累计的模型调用次数
+func (r *UsageRecorder) Calls() int64 {
+	return r.calls.Load()
+}
+
+// CacheHits 累计的缓存命中次数
+func (r *UsageRecorder) CacheHits() int64 {
+	return r.cacheHits.Load()
+}
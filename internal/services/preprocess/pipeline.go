@@ -0,0 +1,166 @@
+package preprocess
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/models"
+)
+
+// Step 预处理步骤名称
+type Step string
+
+const (
+	StepTrim               Step = "trim"                // 去除首尾空白
+	StepCollapseWhitespace Step = "collapse_whitespace" // 连续空白（含换行、制表符）合并为单个空格
+	StepNormalizeUnicode   Step = "normalize_unicode"   // Unicode NFC规范化
+	StepStripControlChars  Step = "strip_control_chars" // 移除控制字符和零宽字符（保留换行和制表符）
+	StepLowercase          Step = "lowercase"           // 转为小写
+)
+
+// DefaultSteps 未配置时使用的预处理步骤
+var DefaultSteps = []Step{StepNormalizeUnicode, StepStripControlChars, StepCollapseWhitespace, StepTrim}
+
+// stepFuncs 各步骤的实现
+var stepFuncs = map[Step]func(string) string{
+	StepTrim:               strings.TrimSpace,
+	StepCollapseWhitespace: collapseWhitespace,
+	StepNormalizeUnicode:   norm.NFC.String,
+	StepStripControlChars:  stripControlChars,
+	StepLowercase:          strings.ToLower,
+}
+
+// IsValidStep 检查预处理步骤名称是否有效
+func IsValidStep(step Step) bool {
+	_, exists := stepFuncs[step]
+	return exists
+}
+
+// Pipeline 由有序步骤组成的预处理流水线
+type Pipeline struct {
+	steps []Step
+}
+
+// NewPipeline 按步骤名称创建流水线，为空时使用默认步骤
+func NewPipeline(names []string) (*Pipeline, error) {
+	if len(names) == 0 {
+		return &Pipeline{steps: DefaultSteps}, nil
+	}
+
+	steps := make([]Step, 0, len(names))
+	for _, name := range names {
+		step := Step(name)
+		if !IsValidStep(step) {
+			return nil, errors.ErrValidationFailed("preprocessing.steps", fmt.Sprintf("unknown step: %s", name))
+		}
+		steps = append(steps, step)
+	}
+	return &Pipeline{steps: steps}, nil
+}
+
+// Steps 获取流水线步骤
+func (p *Pipeline) Steps() []Step {
+	return p.steps
+}
+
+// Apply 依次执行各步骤
+func (p *Pipeline) Apply(text string) string {
+	for _, step := range p.steps {
+		text = stepFuncs[step](text)
+	}
+	return text
+}
+
+// Preprocessor 按内容类型选择预处理流水线（nil时所有类型使用默认步骤）
+type Preprocessor struct {
+	defaultPipeline *Pipeline
+	byContentType   map[models.ContentType]*Pipeline
+}
+
+// defaultPipeline 默认步骤流水线
+var defaultPipeline = &Pipeline{steps: DefaultSteps}
+
+// NewPreprocessor 根据预处理配置创建预处理器
+func NewPreprocessor(preprocessingConfig config.PreprocessingConfig) (*Preprocessor, error) {
+	pipeline, err := NewPipeline(preprocessingConfig.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	preprocessor := &Preprocessor{
+		defaultPipeline: pipeline,
+		byContentType:   make(map[models.ContentType]*Pipeline),
+	}
+
+	for contentType, names := range preprocessingConfig.ContentTypes {
+		if !models.IsValidContentType(models.ContentType(contentType)) {
+			return nil, errors.ErrValidationFailed("preprocessing.content_types", fmt.Sprintf("invalid content type: %s", contentType))
+		}
+		typePipeline, err := NewPipeline(names)
+		if err != nil {
+			return nil, err
+		}
+		preprocessor.byContentType[models.ContentType(contentType)] = typePipeline
+	}
+
+	return preprocessor, nil
+}
+
+// Default 获取默认流水线（用于查询文本和未单独配置的内容类型）
+func (p *Preprocessor) Default() *Pipeline {
+	if p == nil || p.defaultPipeline == nil {
+		return defaultPipeline
+	}
+	return p.defaultPipeline
+}
+
+// ForContentType 获取指定内容类型的流水线
+func (p *Preprocessor) ForContentType(contentType models.ContentType) *Pipeline {
+	if p != nil {
+		if pipeline, exists := p.byContentType[contentType]; exists {
+			return pipeline
+		}
+	}
+	return p.Default()
+}
+
+// collapseWhitespace 将连续空白合并为单个空格
+func collapseWhitespace(text string) string {
+	var builder strings.Builder
+	builder.Grow(len(text))
+
+	inSpace := false
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			if !inSpace {
+				builder.WriteRune(' ')
+				inSpace = true
+			}
+			continue
+		}
+		builder.WriteRune(r)
+		inSpace = false
+	}
+	return builder.String()
+}
+
+// stripControlChars 移除控制字符和零宽字符，保留换行、回车和制表符
+func stripControlChars(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '\n', '\r', '\t':
+			return r
+		case '\u200b', '\u200c', '\u200d', '\ufeff':
+			return -1
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
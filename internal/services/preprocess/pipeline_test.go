@@ -0,0 +1,84 @@
+package preprocess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/models"
+)
+
+// TestPipeline_Steps 测试各预处理步骤
+func TestPipeline_Steps(t *testing.T) {
+	apply := func(t *testing.T, step Step, input string) string {
+		pipeline, err := NewPipeline([]string{string(step)})
+		require.NoError(t, err)
+		return pipeline.Apply(input)
+	}
+
+	t.Run("trim", func(t *testing.T) {
+		assert.Equal(t, "Go 并发", apply(t, StepTrim, "  Go 并发\n\t"))
+	})
+
+	t.Run("collapse_whitespace", func(t *testing.T) {
+		assert.Equal(t, " Go 并发 编程 ", apply(t, StepCollapseWhitespace, "\tGo  并发\n\n编程\u3000"))
+	})
+
+	t.Run("normalize_unicode", func(t *testing.T) {
+		assert.Equal(t, "Caf\u00e9", apply(t, StepNormalizeUnicode, "Cafe\u0301"))
+	})
+
+	t.Run("strip_control_chars", func(t *testing.T) {
+		assert.Equal(t, "Go\n并发\t编程", apply(t, StepStripControlChars, "G\u200bo\x00\n并\u0007发\t编\ufeff程"))
+	})
+
+	t.Run("lowercase", func(t *testing.T) {
+		assert.Equal(t, "golang 并发", apply(t, StepLowercase, "GoLang 并发"))
+	})
+}
+
+// TestNewPipeline 测试流水线创建
+func TestNewPipeline(t *testing.T) {
+	t.Run("为空时使用默认步骤", func(t *testing.T) {
+		pipeline, err := NewPipeline(nil)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultSteps, pipeline.Steps())
+		assert.Equal(t, "Café Go", pipeline.Apply("  Café\u200b \n Go\x00 "))
+	})
+
+	t.Run("按配置顺序执行", func(t *testing.T) {
+		pipeline, err := NewPipeline([]string{"collapse_whitespace", "trim", "lowercase"})
+		require.NoError(t, err)
+		assert.Equal(t, "go 并发", pipeline.Apply("  GO\n\n并发 "))
+	})
+
+	t.Run("未知步骤", func(t *testing.T) {
+		_, err := NewPipeline([]string{"trim", "stem"})
+		assert.Error(t, err)
+	})
+}
+
+// TestPreprocessor_ForContentType 测试按内容类型选择流水线
+func TestPreprocessor_ForContentType(t *testing.T) {
+	preprocessor, err := NewPreprocessor(config.PreprocessingConfig{
+		Steps:        []string{"trim"},
+		ContentTypes: map[string][]string{"link": {"trim", "lowercase"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "HTTPS://Example.com", preprocessor.ForContentType(models.ContentTypeText).Apply(" HTTPS://Example.com "))
+	assert.Equal(t, "https://example.com", preprocessor.ForContentType(models.ContentTypeLink).Apply(" HTTPS://Example.com "))
+	assert.Equal(t, []Step{StepTrim}, preprocessor.Default().Steps())
+
+	t.Run("nil预处理器使用默认步骤", func(t *testing.T) {
+		var empty *Preprocessor
+		assert.Equal(t, DefaultSteps, empty.ForContentType(models.ContentTypeText).Steps())
+	})
+
+	t.Run("无效的内容类型", func(t *testing.T) {
+		_, err := NewPreprocessor(config.PreprocessingConfig{ContentTypes: map[string][]string{"video_clip": {"trim"}}})
+		assert.Error(t, err)
+	})
+}
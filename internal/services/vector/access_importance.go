@@ -0,0 +1,73 @@
+package vector
+
+import (
+	"math"
+	"time"
+
+	"memoro/internal/config"
+)
+
+// 未配置时的访问信号参数
+const (
+	defaultAccessImportanceWindow   = 30 * 24 * time.Hour
+	defaultAccessImportanceHalfLife = 7 * 24 * time.Hour
+)
+
+// accessImportance 查询时计算的访问信号：经常且最近被访问的文档对用户更重要。
+// 只在排序时与存储的重要性分数混合，不修改存储的重要性
+type accessImportance struct {
+	weight  float64
+	signals map[string]float64 // 文档ID -> 访问信号（0-1），没有访问记录的文档为0
+}
+
+// newAccessImportance 根据交互记录计算访问信号，userID为空时使用全部用户的访问。
+// 配置未启用（权重为0）或没有交互记录时返回nil
+func newAccessImportance(cfg *config.AccessImportanceConfig, interactions *InteractionStore, userID string, now time.Time) *accessImportance {
+	if cfg == nil || cfg.Weight <= 0 || interactions == nil {
+		return nil
+	}
+
+	window := cfg.Window
+	if window <= 0 {
+		window = defaultAccessImportanceWindow
+	}
+	halfLife := cfg.HalfLife
+	if halfLife <= 0 {
+		halfLife = defaultAccessImportanceHalfLife
+	}
+
+	stats := interactions.AccessStats(userID, now.Add(-window))
+	maxCount := 0
+	for _, stat := range stats {
+		if stat.Count > maxCount {
+			maxCount = stat.Count
+		}
+	}
+
+	// 访问频率（按最大访问次数归一化）和最近访问时间的指数衰减各占一半
+	signals := make(map[string]float64, len(stats))
+	for documentID, stat := range stats {
+		frequency := float64(stat.Count) / float64(maxCount)
+		age := now.Sub(stat.LastAccess)
+		if age < 0 {
+			age = 0
+		}
+		recency := math.Pow(0.5, age.Hours()/halfLife.Hours())
+		signals[documentID] = frequency*0.5 + recency*0.5
+	}
+
+	return &accessImportance{
+		weight:  math.Min(cfg.Weight, 1.0),
+		signals: signals,
+	}
+}
+
+// blend 将基础重要性（0-1）与文档的访问信号按权重混合，返回混合后的重要性和访问信号；未启用时返回基础重要性
+func (a *accessImportance) blend(documentID string, base float64) (float64, float64) {
+	if a == nil {
+		return base, 0
+	}
+
+	access := a.signals[documentID]
+	return base*(1-a.weight) + access*a.weight, access
+}
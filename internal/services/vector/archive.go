@@ -0,0 +1,277 @@
+package vector
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+)
+
+const (
+	defaultArchiveMaxAge    = 180 * 24 * time.Hour
+	defaultArchiveBatchSize = 500
+
+	// archiveCollectionSuffix 未配置冷存储集合名时追加到活跃集合名的后缀
+	archiveCollectionSuffix = "_archive"
+)
+
+// ArchiveConfig 归档策略配置
+type ArchiveConfig struct {
+	Enabled         bool          // 是否启用归档
+	MaxAge          time.Duration // 创建时间超过该时长的文档才归档
+	ImportanceFloor float64       // 只归档重要性低于该值的文档，0表示不按重要性限制
+	Collection      string        // 冷存储集合名
+	Interval        time.Duration // 自动归档的执行间隔，0表示不自动执行
+	BatchSize       int           // 单次归档扫描的文档数上限
+}
+
+// ArchiveReport 归档结果
+type ArchiveReport struct {
+	Cutoff      time.Time `json:"cutoff"`       // 早于该时间创建的文档参与归档
+	Scanned     int       `json:"scanned"`      // 扫描的文档数
+	ArchivedIDs []string  `json:"archived_ids"` // 移入冷存储的文档ID
+}
+
+// DefaultArchiveConfig 默认归档配置（默认关闭）
+func DefaultArchiveConfig() ArchiveConfig {
+	return ArchiveConfig{
+		MaxAge:    defaultArchiveMaxAge,
+		BatchSize: defaultArchiveBatchSize,
+	}
+}
+
+// archiveConfigFrom 从全局配置读取归档配置，未配置的项使用默认值
+func archiveConfigFrom(cfg *config.Config) ArchiveConfig {
+	archiveConfig := DefaultArchiveConfig()
+	if cfg == nil {
+		return archiveConfig
+	}
+	archiveConfig.Collection = cfg.VectorDB.Collection + archiveCollectionSuffix
+
+	archive := cfg.VectorDB.Archive
+	if archive == nil {
+		return archiveConfig
+	}
+	archiveConfig.Enabled = archive.Enabled
+	archiveConfig.ImportanceFloor = archive.ImportanceFloor
+	archiveConfig.Interval = archive.Interval
+	if archive.MaxAge > 0 {
+		archiveConfig.MaxAge = archive.MaxAge
+	}
+	if archive.Collection != "" {
+		archiveConfig.Collection = archive.Collection
+	}
+	if archive.BatchSize > 0 {
+		archiveConfig.BatchSize = archive.BatchSize
+	}
+	return archiveConfig
+}
+
+// SetArchiveStore 设置归档使用的冷存储，应在开始处理前调用
+func (se *SearchEngine) SetArchiveStore(store VectorStore) {
+	se.archiveStore = store
+}
+
+// ArchiveStale 将超过配置时长、重要性低于下限且未置顶的文档移入冷存储：先写入冷存储再从活跃集合删除，单个文档失败时跳过。
+// progress非nil时按已处理的候选文档上报进度百分比
+func (se *SearchEngine) ArchiveStale(ctx context.Context, progress func(percent float64)) (*ArchiveReport, error) {
+	if se.archiveStore == nil {
+		return nil, errors.ErrConfigMissing("vector_db.archive")
+	}
+
+	archiveConfig := se.archiveConfig
+	report := &ArchiveReport{
+		Cutoff:      time.Now().Add(-archiveConfig.MaxAge),
+		ArchivedIDs: make([]string, 0),
+	}
+
+	// 重要性下限和置顶排除都放在过滤条件中，一批候选全部不符合时也不会卡住后续文档
+	filter := map[string]interface{}{
+		"created_at":      map[string]interface{}{"$lt": report.Cutoff.Unix()},
+		MetadataKeyPinned: map[string]interface{}{"$ne": true},
+	}
+	if archiveConfig.ImportanceFloor > 0 {
+		filter["importance_score"] = map[string]interface{}{"$lt": archiveConfig.ImportanceFloor}
+	}
+	candidates, err := se.store.GetDocumentsByFilter(ctx, filter, archiveConfig.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	report.Scanned = len(candidates)
+
+	ids := make([]string, 0, len(candidates))
+	for _, doc := range candidates {
+		ids = append(ids, doc.ID)
+	}
+	if len(ids) == 0 {
+		return report, nil
+	}
+	sort.Strings(ids)
+
+	// 按ID读取完整文档（含向量），冷存储中的文档无需重新向量化即可检索和恢复
+	documents, err := se.store.GetDocuments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	archivedAt := time.Now().Unix()
+	for i, doc := range documents {
+		reportProgress(progress, i, len(documents))
+		archived := copyVectorDocument(doc)
+		archived.Metadata[MetadataKeyArchived] = true
+		archived.Metadata[MetadataKeyArchivedAt] = archivedAt
+
+		if err := se.archiveStore.AddDocument(ctx, archived); err != nil {
+			se.logger.Warn("Failed to write document to archive", logger.Fields{
+				"document_id": doc.ID,
+				"error":       err.Error(),
+			})
+			continue
+		}
+		if err := se.store.DeleteDocument(ctx, doc.ID); err != nil {
+			// 活跃集合删除失败时撤销冷存储写入，避免同一文档出现在两处
+			se.logger.Warn("Failed to remove archived document from active collection", logger.Fields{
+				"document_id": doc.ID,
+				"error":       err.Error(),
+			})
+			if rollbackErr := se.archiveStore.DeleteDocument(ctx, doc.ID); rollbackErr != nil {
+				se.logger.Error("Failed to roll back archive write", logger.Fields{
+					"document_id": doc.ID,
+					"error":       rollbackErr.Error(),
+				})
+			}
+			continue
+		}
+
+		report.ArchivedIDs = append(report.ArchivedIDs, doc.ID)
+		se.recordArchiveAudit(ctx, logger.AuditActionArchive, archived)
+	}
+
+	if len(report.ArchivedIDs) > 0 {
+		se.bumpCollectionVersion()
+	}
+
+	se.logger.Info("Stale documents archived", logger.Fields{
+		"cutoff":           report.Cutoff,
+		"scanned":          report.Scanned,
+		"archived":         len(report.ArchivedIDs),
+		"importance_floor": archiveConfig.ImportanceFloor,
+	})
+
+	return report, nil
+}
+
+// RestoreDocument 将冷存储中的文档恢复到活跃集合（移除归档标记），文档不在冷存储中时返回资源不存在错误
+func (se *SearchEngine) RestoreDocument(ctx context.Context, documentID string) error {
+	if documentID == "" {
+		return errors.ErrValidationFailed("document_id", "cannot be empty")
+	}
+	if se.archiveStore == nil {
+		return errors.ErrConfigMissing("vector_db.archive")
+	}
+
+	archived, err := se.archiveStore.GetDocument(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
+	restored := copyVectorDocument(archived)
+	delete(restored.Metadata, MetadataKeyArchived)
+	delete(restored.Metadata, MetadataKeyArchivedAt)
+
+	if err := se.store.AddDocument(ctx, restored); err != nil {
+		return err
+	}
+	if err := se.archiveStore.DeleteDocument(ctx, documentID); err != nil {
+		// 已恢复到活跃集合，冷存储中的副本留待下次清理，不影响检索
+		se.logger.Warn("Failed to remove restored document from archive", logger.Fields{
+			"document_id": documentID,
+			"error":       err.Error(),
+		})
+	}
+	se.bumpCollectionVersion()
+	se.recordArchiveAudit(ctx, logger.AuditActionRestore, restored)
+
+	se.logger.Info("Archived document restored", logger.Fields{
+		"document_id": documentID,
+	})
+	return nil
+}
+
+// recordArchiveAudit 记录归档或恢复的审计日志
+func (se *SearchEngine) recordArchiveAudit(ctx context.Context, action logger.AuditAction, doc *VectorDocument) {
+	if !se.auditLogger.Enabled() {
+		return
+	}
+	userID, _ := doc.Metadata["user_id"].(string)
+	se.auditLogger.Record(ctx, logger.AuditRecord{
+		Action:     action,
+		DocumentID: doc.ID,
+		UserID:     userID,
+	})
+}
+
+// vectorSearch 在活跃集合中执行向量检索，请求包含归档内容时按需查询冷存储并按距离合并
+func (se *SearchEngine) vectorSearch(ctx context.Context, searchQuery *SearchQuery, options *SearchOptions) (*SearchResult, error) {
+	results, err := se.store.Search(ctx, searchQuery)
+	if err != nil || !options.IncludeArchived || se.archiveStore == nil {
+		return results, err
+	}
+
+	archived, err := se.archiveStore.Search(ctx, searchQuery)
+	if err != nil {
+		return nil, err
+	}
+	return mergeArchivedResults(results, archived.Documents, searchQuery.TopK), nil
+}
+
+// mergeArchivedResults 将冷存储的检索结果按距离合并到活跃集合的结果中（两者使用相同的距离函数）
+func mergeArchivedResults(active *SearchResult, archived []*VectorDocument, limit int) *SearchResult {
+	if len(archived) == 0 {
+		return active
+	}
+
+	documents := make([]*VectorDocument, 0, len(active.Documents)+len(archived))
+	documents = append(documents, active.Documents...)
+	documents = append(documents, archived...)
+	sort.SliceStable(documents, func(i, j int) bool {
+		return documents[i].Distance < documents[j].Distance
+	})
+	if limit > 0 && len(documents) > limit {
+		documents = documents[:limit]
+	}
+
+	return &SearchResult{
+		Documents:    documents,
+		QueryTime:    active.QueryTime,
+		TotalResults: len(documents),
+	}
+}
+
+// startArchival 按配置的间隔在后台执行归档
+func (se *SearchEngine) startArchival() {
+	if !se.archiveConfig.Enabled || se.archiveConfig.Interval <= 0 || se.archiveStore == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	se.archiveCancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(se.archiveConfig.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := se.ArchiveStale(ctx, nil); err != nil {
+					se.logger.Warn("Scheduled archival failed", logger.Fields{"error": err.Error()})
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,174 @@
+package vector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newArchiveTestEngine 创建带冷存储的测试搜索引擎，活跃集合中包含新旧、重要性不同的文档
+func newArchiveTestEngine(t *testing.T) (*SearchEngine, *MemoryStore, *MemoryStore) {
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{1, 0}, Dimension: 2}, nil)
+
+	now := time.Now()
+	day := 24 * time.Hour
+	active := NewMemoryStore()
+	for _, doc := range []struct {
+		id         string
+		importance float64
+		age        time.Duration
+		embedding  []float32
+	}{
+		{id: "old-trivial", importance: 0.1, age: 400 * day, embedding: []float32{1, 0.05}},
+		{id: "old-important", importance: 0.9, age: 400 * day, embedding: []float32{1, 0.3}},
+		{id: "fresh-trivial", importance: 0.1, age: 10 * day, embedding: []float32{1, 0.4}},
+	} {
+		require.NoError(t, active.AddDocument(context.Background(), &VectorDocument{
+			ID:        doc.id,
+			Content:   "旅行计划笔记",
+			Embedding: doc.embedding,
+			Metadata:  map[string]interface{}{"user_id": "user-1", "importance_score": doc.importance},
+			CreatedAt: now.Add(-doc.age),
+		}))
+	}
+
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = active
+	engine.archiveConfig = ArchiveConfig{Enabled: true, MaxAge: 365 * day, ImportanceFloor: 0.5, BatchSize: 100}
+	cold := NewMemoryStore()
+	engine.SetArchiveStore(cold)
+	return engine, active, cold
+}
+
+// searchIDs 搜索并返回结果文档ID
+func searchIDs(t *testing.T, engine *SearchEngine, options *SearchOptions) []string {
+	response, err := engine.Search(context.Background(), options)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(response.Results))
+	for _, result := range response.Results {
+		ids = append(ids, result.DocumentID)
+	}
+	return ids
+}
+
+// TestSearchEngine_ArchiveStale 测试归档、默认搜索排除归档内容和按需检索
+func TestSearchEngine_ArchiveStale(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("只归档超龄且低重要性的文档", func(t *testing.T) {
+		engine, active, cold := newArchiveTestEngine(t)
+
+		report, err := engine.ArchiveStale(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Scanned)
+		assert.Equal(t, []string{"old-trivial"}, report.ArchivedIDs)
+
+		_, err = active.GetDocument(ctx, "old-trivial")
+		assert.Error(t, err)
+
+		archived, err := cold.GetDocument(ctx, "old-trivial")
+		require.NoError(t, err)
+		assert.Equal(t, true, archived.Metadata[MetadataKeyArchived])
+		assert.Contains(t, archived.Metadata, MetadataKeyArchivedAt)
+		assert.NotEmpty(t, archived.Embedding)
+		assert.Equal(t, "旅行计划笔记", archived.Content)
+	})
+
+	t.Run("按已处理的候选文档上报进度", func(t *testing.T) {
+		engine, active, _ := newArchiveTestEngine(t)
+		require.NoError(t, active.AddDocument(ctx, &VectorDocument{
+			ID:        "old-trivial-2",
+			Content:   "旅行计划笔记",
+			Embedding: []float32{1, 0.1},
+			Metadata:  map[string]interface{}{"importance_score": 0.2},
+			CreatedAt: time.Now().Add(-400 * 24 * time.Hour),
+		}))
+
+		var progress []float64
+		report, err := engine.ArchiveStale(ctx, func(percent float64) {
+			progress = append(progress, percent)
+		})
+		require.NoError(t, err)
+		assert.Len(t, report.ArchivedIDs, 2)
+		assert.Equal(t, []float64{0, 50}, progress)
+	})
+
+	t.Run("高重要性和置顶文档不占用批次", func(t *testing.T) {
+		engine, active, _ := newArchiveTestEngine(t)
+		engine.archiveConfig.BatchSize = 1
+		old := time.Now().Add(-400 * 24 * time.Hour)
+		for _, doc := range []*VectorDocument{
+			{ID: "old-important-2", Metadata: map[string]interface{}{"importance_score": 0.8}},
+			{ID: "old-important-3", Metadata: map[string]interface{}{"importance_score": 0.7}},
+			{ID: "old-pinned", Metadata: map[string]interface{}{"importance_score": 0.1, MetadataKeyPinned: true}},
+		} {
+			doc.Content = "旅行计划笔记"
+			doc.Embedding = []float32{1, 0.2}
+			doc.CreatedAt = old
+			require.NoError(t, active.AddDocument(ctx, doc))
+		}
+
+		report, err := engine.ArchiveStale(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"old-trivial"}, report.ArchivedIDs)
+
+		report, err = engine.ArchiveStale(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, report.ArchivedIDs)
+		_, err = active.GetDocument(ctx, "old-pinned")
+		assert.NoError(t, err)
+	})
+
+	t.Run("默认搜索不包含归档文档", func(t *testing.T) {
+		engine, _, _ := newArchiveTestEngine(t)
+		_, err := engine.ArchiveStale(ctx, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"old-important", "fresh-trivial"}, searchIDs(t, engine, &SearchOptions{Query: "旅行计划"}))
+	})
+
+	t.Run("按需检索冷存储并按距离合并", func(t *testing.T) {
+		engine, _, _ := newArchiveTestEngine(t)
+		_, err := engine.ArchiveStale(ctx, nil)
+		require.NoError(t, err)
+
+		response, err := engine.Search(ctx, &SearchOptions{Query: "旅行计划", IncludeArchived: true})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 3)
+		assert.Equal(t, "old-trivial", response.Results[0].DocumentID)
+		assert.Equal(t, true, response.Results[0].Metadata[MetadataKeyArchived])
+	})
+
+	t.Run("恢复后重新出现在默认搜索中", func(t *testing.T) {
+		engine, active, cold := newArchiveTestEngine(t)
+		_, err := engine.ArchiveStale(ctx, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, engine.RestoreDocument(ctx, "old-trivial"))
+
+		restored, err := active.GetDocument(ctx, "old-trivial")
+		require.NoError(t, err)
+		assert.NotContains(t, restored.Metadata, MetadataKeyArchived)
+		assert.NotContains(t, restored.Metadata, MetadataKeyArchivedAt)
+		_, err = cold.GetDocument(ctx, "old-trivial")
+		assert.Error(t, err)
+
+		assert.Equal(t, "old-trivial", searchIDs(t, engine, &SearchOptions{Query: "旅行计划"})[0])
+		assert.Error(t, engine.RestoreDocument(ctx, "old-trivial"))
+	})
+
+	t.Run("未配置冷存储时返回错误", func(t *testing.T) {
+		engine, _, _ := newArchiveTestEngine(t)
+		engine.SetArchiveStore(nil)
+
+		_, err := engine.ArchiveStale(ctx, nil)
+		assert.Error(t, err)
+		assert.Error(t, engine.RestoreDocument(ctx, "old-trivial"))
+	})
+}
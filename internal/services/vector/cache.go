@@ -3,13 +3,24 @@ package vector
 import (
 	"crypto/md5"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"memoro/internal/config"
+	"memoro/internal/errors"
 	"memoro/internal/logger"
 )
 
+// 可按类型清空的缓存
+const (
+	CacheTypeQueryVector    = "query_vector"
+	CacheTypeRecommendation = "recommendation"
+	CacheTypeUserPreference = "user_preference"
+	CacheTypeSearchResult   = "search_result"
+	CacheTypeAll            = "all" // 清空以上全部缓存
+)
+
 // CacheConfig 缓存配置
 type CacheConfig struct {
 	QueryVectorTTL        time.Duration `yaml:"query_vector_ttl"`
@@ -19,6 +30,9 @@ type CacheConfig struct {
 	UserPreferenceTTL     time.Duration `yaml:"user_preference_ttl"`
 	UserPreferenceMaxSize int           `yaml:"user_preference_max_size"`
 	CleanupInterval       time.Duration `yaml:"cleanup_interval"`
+
+	SearchResultTTL     time.Duration `yaml:"search_result_ttl"`      // 完整搜索结果缓存时间，0表示不缓存
+	SearchResultMaxSize int           `yaml:"search_result_max_size"` // 最多缓存的搜索结果数量
 }
 
 // DefaultCacheConfig 默认缓存配置
@@ -31,6 +45,7 @@ func DefaultCacheConfig() *CacheConfig {
 		UserPreferenceTTL:     24 * time.Hour,   // 24小时
 		UserPreferenceMaxSize: 1000,             // 最多缓存1k个用户偏好
 		CleanupInterval:       10 * time.Minute, // 10分钟清理一次
+		SearchResultMaxSize:   1000,             // 最多缓存1k个搜索结果（默认不启用）
 	}
 }
 
@@ -53,6 +68,15 @@ type CachedRecommendation struct {
 	LastAccess      time.Time             `json:"last_access"`
 }
 
+// CachedSearchResult 缓存的完整搜索结果
+type CachedSearchResult struct {
+	Response    *SearchResponse `json:"response"`
+	Version     uint64          `json:"version"` // 缓存时的集合版本，集合变更后失效
+	CachedAt    time.Time       `json:"cached_at"`
+	AccessCount int64           `json:"access_count"`
+	LastAccess  time.Time       `json:"last_access"`
+}
+
 // CachedUserPreference 缓存的用户偏好
 type CachedUserPreference struct {
 	UserID          string                 `json:"user_id"`
@@ -79,10 +103,18 @@ type VectorCacheManager struct {
 	userPreferenceCache map[string]*CachedUserPreference
 	userPreferenceMutex sync.RWMutex
 
+	// 完整搜索结果缓存
+	searchResultCache map[string]*CachedSearchResult
+	searchResultMutex sync.RWMutex
+
 	// 统计信息
 	stats      *CacheStats
 	statsMutex sync.RWMutex
 
+	// 各类缓存最近一次被手动清空的时间
+	lastFlush      map[string]time.Time
+	lastFlushMutex sync.RWMutex
+
 	// 清理控制
 	stopCleanup chan struct{}
 	cleanupWg   sync.WaitGroup
@@ -102,6 +134,10 @@ type CacheStats struct {
 	UserPreferenceHits      int64 `json:"user_preference_hits"`
 	UserPreferenceMisses    int64 `json:"user_preference_misses"`
 	UserPreferenceEvictions int64 `json:"user_preference_evictions"`
+
+	SearchResultHits      int64 `json:"search_result_hits"`
+	SearchResultMisses    int64 `json:"search_result_misses"`
+	SearchResultEvictions int64 `json:"search_result_evictions"`
 }
 
 // NewVectorCacheManager 创建向量缓存管理器
@@ -130,6 +166,12 @@ func NewVectorCacheManager(cfg *config.Config) *VectorCacheManager {
 		if cfg.VectorDB.CacheConfig.CleanupInterval > 0 {
 			cacheConfig.CleanupInterval = cfg.VectorDB.CacheConfig.CleanupInterval
 		}
+		if cfg.VectorDB.CacheConfig.SearchResultTTL > 0 {
+			cacheConfig.SearchResultTTL = cfg.VectorDB.CacheConfig.SearchResultTTL
+		}
+		if cfg.VectorDB.CacheConfig.SearchResultMaxSize > 0 {
+			cacheConfig.SearchResultMaxSize = cfg.VectorDB.CacheConfig.SearchResultMaxSize
+		}
 	}
 
 	manager := &VectorCacheManager{
@@ -138,7 +180,9 @@ func NewVectorCacheManager(cfg *config.Config) *VectorCacheManager {
 		queryVectorCache:    make(map[string]*CachedQueryVector),
 		recommendationCache: make(map[string]*CachedRecommendation),
 		userPreferenceCache: make(map[string]*CachedUserPreference),
+		searchResultCache:   make(map[string]*CachedSearchResult),
 		stats:               &CacheStats{},
+		lastFlush:           make(map[string]time.Time),
 		stopCleanup:         make(chan struct{}),
 	}
 
@@ -150,6 +194,7 @@ func NewVectorCacheManager(cfg *config.Config) *VectorCacheManager {
 		"query_vector_max_size":   cacheConfig.QueryVectorMaxSize,
 		"recommendation_ttl":      cacheConfig.RecommendationTTL,
 		"recommendation_max_size": cacheConfig.RecommendationMaxSize,
+		"search_result_ttl":       cacheConfig.SearchResultTTL,
 		"cleanup_interval":        cacheConfig.CleanupInterval,
 	})
 
@@ -191,6 +236,17 @@ func (cm *VectorCacheManager) GetQueryVector(query string, options *SearchOption
 	return cached.Vector, true
 }
 
+// HasQueryVector 检查查询向量是否已缓存且未过期（不计入命中统计）
+func (cm *VectorCacheManager) HasQueryVector(query string, options *SearchOptions) bool {
+	key := cm.generateQueryVectorKey(query, options)
+
+	cm.queryVectorMutex.RLock()
+	defer cm.queryVectorMutex.RUnlock()
+
+	cached, exists := cm.queryVectorCache[key]
+	return exists && time.Since(cached.CachedAt) <= cm.config.QueryVectorTTL
+}
+
 // SetQueryVector 设置查询向量
 func (cm *VectorCacheManager) SetQueryVector(query string, options *SearchOptions, vector []float32) {
 	key := cm.generateQueryVectorKey(query, options)
@@ -253,7 +309,8 @@ func (cm *VectorCacheManager) GetRecommendation(request *RecommendationRequest)
 		"access_count":    cached.AccessCount,
 	})
 
-	return cached.Recommendations, true
+	// 返回副本：调用方会过滤和重新设置排名，不能修改其他读取方共享的缓存内容
+	return copyRecommendationItems(cached.Recommendations), true
 }
 
 // SetRecommendation 设置推荐结果
@@ -261,13 +318,12 @@ func (cm *VectorCacheManager) SetRecommendation(request *RecommendationRequest,
 	key := cm.generateRecommendationKey(request)
 
 	cached := &CachedRecommendation{
-		Recommendations: make([]*RecommendationItem, len(recommendations)),
+		Recommendations: copyRecommendationItems(recommendations),
 		RequestHash:     key,
 		CachedAt:        time.Now(),
 		AccessCount:     1,
 		LastAccess:      time.Now(),
 	}
-	copy(cached.Recommendations, recommendations)
 
 	cm.recommendationMutex.Lock()
 	defer cm.recommendationMutex.Unlock()
@@ -284,6 +340,101 @@ func (cm *VectorCacheManager) SetRecommendation(request *RecommendationRequest,
 	})
 }
 
+// copyRecommendationItems 深拷贝推荐项列表
+func copyRecommendationItems(items []*RecommendationItem) []*RecommendationItem {
+	copied := make([]*RecommendationItem, len(items))
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		itemCopy := *item
+		itemCopy.Metadata = copyMetadata(item.Metadata)
+		itemCopy.RelatedKeywords = append([]string(nil), item.RelatedKeywords...)
+		if item.Explanation != nil {
+			explanation := *item.Explanation
+			explanation.FactorBreakdown = copyFloatMap(item.Explanation.FactorBreakdown)
+			explanation.MatchedFeatures = append([]string(nil), item.Explanation.MatchedFeatures...)
+			explanation.UserPreferences = append([]string(nil), item.Explanation.UserPreferences...)
+			itemCopy.Explanation = &explanation
+		}
+		copied[i] = &itemCopy
+	}
+	return copied
+}
+
+// SearchResultCacheEnabled 是否启用完整搜索结果缓存
+func (cm *VectorCacheManager) SearchResultCacheEnabled() bool {
+	return cm.config.SearchResultTTL > 0 && cm.config.SearchResultMaxSize > 0
+}
+
+// GetSearchResult 获取缓存的搜索结果，缓存时的集合版本与当前版本不同或已过期时视为未命中
+func (cm *VectorCacheManager) GetSearchResult(key string, version uint64) (*SearchResponse, bool) {
+	if !cm.SearchResultCacheEnabled() {
+		return nil, false
+	}
+
+	cm.searchResultMutex.RLock()
+	cached, exists := cm.searchResultCache[key]
+	cm.searchResultMutex.RUnlock()
+
+	if !exists {
+		cm.incrementStat("search_result_misses")
+		return nil, false
+	}
+
+	// 检查是否过期或集合已变更
+	if cached.Version != version || time.Since(cached.CachedAt) > cm.config.SearchResultTTL {
+		cm.evictSearchResult(key)
+		cm.incrementStat("search_result_misses")
+		return nil, false
+	}
+
+	// 更新访问统计
+	cm.searchResultMutex.Lock()
+	cached.AccessCount++
+	cached.LastAccess = time.Now()
+	cm.searchResultMutex.Unlock()
+
+	cm.incrementStat("search_result_hits")
+	cm.logger.Debug("Search result cache hit", logger.Fields{
+		"result_key":   key,
+		"version":      version,
+		"access_count": cached.AccessCount,
+	})
+
+	return cached.Response, true
+}
+
+// SetSearchResult 缓存搜索结果，version为执行搜索前读取的集合版本
+func (cm *VectorCacheManager) SetSearchResult(key string, version uint64, response *SearchResponse) {
+	if !cm.SearchResultCacheEnabled() || response == nil {
+		return
+	}
+
+	cached := &CachedSearchResult{
+		Response:    response,
+		Version:     version,
+		CachedAt:    time.Now(),
+		AccessCount: 1,
+		LastAccess:  time.Now(),
+	}
+
+	cm.searchResultMutex.Lock()
+	defer cm.searchResultMutex.Unlock()
+
+	// 检查缓存大小限制
+	if _, exists := cm.searchResultCache[key]; !exists && len(cm.searchResultCache) >= cm.config.SearchResultMaxSize {
+		cm.evictLRUSearchResult()
+	}
+
+	cm.searchResultCache[key] = cached
+	cm.logger.Debug("Search result cached", logger.Fields{
+		"result_key": key,
+		"version":    version,
+		"results":    len(response.Results),
+	})
+}
+
 // generateQueryVectorKey 生成查询向量缓存键
 func (cm *VectorCacheManager) generateQueryVectorKey(query string, options *SearchOptions) string {
 	data := fmt.Sprintf("%s|%v", query, options)
@@ -293,12 +444,22 @@ func (cm *VectorCacheManager) generateQueryVectorKey(query string, options *Sear
 
 // generateRecommendationKey 生成推荐结果缓存键
 func (cm *VectorCacheManager) generateRecommendationKey(request *RecommendationRequest) string {
-	data := fmt.Sprintf("%s|%s|%s|%d|%f",
+	includeSeen := ""
+	if request.IncludeSeen != nil {
+		includeSeen = strconv.FormatBool(*request.IncludeSeen)
+	}
+	// fmt按键排序输出map，相同的元数据过滤条件生成相同的键
+	data := fmt.Sprintf("%s|%s|%s|%d|%f|%s|%f|%s|%f|%v",
 		request.Type,
 		request.UserID,
 		request.SourceDocumentID,
 		request.MaxRecommendations,
-		request.MinSimilarity)
+		request.MinSimilarity,
+		request.TrendingWindow,
+		request.MinConfidence,
+		includeSeen,
+		request.MinScore,
+		request.MetadataFilters)
 	hash := md5.Sum([]byte(data))
 	return fmt.Sprintf("rec:%x", hash)
 }
@@ -371,6 +532,32 @@ func (cm *VectorCacheManager) evictLRURecommendation() {
 	}
 }
 
+// evictSearchResult 驱逐搜索结果
+func (cm *VectorCacheManager) evictSearchResult(key string) {
+	cm.searchResultMutex.Lock()
+	delete(cm.searchResultCache, key)
+	cm.searchResultMutex.Unlock()
+	cm.incrementStat("search_result_evictions")
+}
+
+// evictLRUSearchResult 驱逐最少使用的搜索结果
+func (cm *VectorCacheManager) evictLRUSearchResult() {
+	var oldestKey string
+	var oldestTime time.Time
+
+	for key, cached := range cm.searchResultCache {
+		if oldestKey == "" || cached.LastAccess.Before(oldestTime) {
+			oldestKey = key
+			oldestTime = cached.LastAccess
+		}
+	}
+
+	if oldestKey != "" {
+		delete(cm.searchResultCache, oldestKey)
+		cm.incrementStat("search_result_evictions")
+	}
+}
+
 // incrementStat 增加统计计数
 func (cm *VectorCacheManager) incrementStat(statName string) {
 	cm.statsMutex.Lock()
@@ -395,6 +582,12 @@ func (cm *VectorCacheManager) incrementStat(statName string) {
 		cm.stats.UserPreferenceMisses++
 	case "user_preference_evictions":
 		cm.stats.UserPreferenceEvictions++
+	case "search_result_hits":
+		cm.stats.SearchResultHits++
+	case "search_result_misses":
+		cm.stats.SearchResultMisses++
+	case "search_result_evictions":
+		cm.stats.SearchResultEvictions++
 	}
 }
 
@@ -414,6 +607,9 @@ func (cm *VectorCacheManager) GetStats() *CacheStats {
 		UserPreferenceHits:      cm.stats.UserPreferenceHits,
 		UserPreferenceMisses:    cm.stats.UserPreferenceMisses,
 		UserPreferenceEvictions: cm.stats.UserPreferenceEvictions,
+		SearchResultHits:        cm.stats.SearchResultHits,
+		SearchResultMisses:      cm.stats.SearchResultMisses,
+		SearchResultEvictions:   cm.stats.SearchResultEvictions,
 	}
 }
 
@@ -431,39 +627,123 @@ func (cm *VectorCacheManager) GetCacheInfo() map[string]interface{} {
 	userPreferenceSize := len(cm.userPreferenceCache)
 	cm.userPreferenceMutex.RUnlock()
 
+	cm.searchResultMutex.RLock()
+	searchResultSize := len(cm.searchResultCache)
+	cm.searchResultMutex.RUnlock()
+
 	stats := cm.GetStats()
 
 	return map[string]interface{}{
 		"query_vector_cache": map[string]interface{}{
-			"size":      queryVectorSize,
-			"max_size":  cm.config.QueryVectorMaxSize,
-			"ttl":       cm.config.QueryVectorTTL,
-			"hits":      stats.QueryVectorHits,
-			"misses":    stats.QueryVectorMisses,
-			"evictions": stats.QueryVectorEvictions,
-			"hit_ratio": cm.calculateHitRatio(stats.QueryVectorHits, stats.QueryVectorMisses),
+			"size":          queryVectorSize,
+			"max_size":      cm.config.QueryVectorMaxSize,
+			"ttl":           cm.config.QueryVectorTTL,
+			"hits":          stats.QueryVectorHits,
+			"misses":        stats.QueryVectorMisses,
+			"evictions":     stats.QueryVectorEvictions,
+			"hit_ratio":     cm.calculateHitRatio(stats.QueryVectorHits, stats.QueryVectorMisses),
+			"last_flush_at": cm.lastFlushAt(CacheTypeQueryVector),
 		},
 		"recommendation_cache": map[string]interface{}{
-			"size":      recommendationSize,
-			"max_size":  cm.config.RecommendationMaxSize,
-			"ttl":       cm.config.RecommendationTTL,
-			"hits":      stats.RecommendationHits,
-			"misses":    stats.RecommendationMisses,
-			"evictions": stats.RecommendationEvictions,
-			"hit_ratio": cm.calculateHitRatio(stats.RecommendationHits, stats.RecommendationMisses),
+			"size":          recommendationSize,
+			"max_size":      cm.config.RecommendationMaxSize,
+			"ttl":           cm.config.RecommendationTTL,
+			"hits":          stats.RecommendationHits,
+			"misses":        stats.RecommendationMisses,
+			"evictions":     stats.RecommendationEvictions,
+			"hit_ratio":     cm.calculateHitRatio(stats.RecommendationHits, stats.RecommendationMisses),
+			"last_flush_at": cm.lastFlushAt(CacheTypeRecommendation),
 		},
 		"user_preference_cache": map[string]interface{}{
-			"size":      userPreferenceSize,
-			"max_size":  cm.config.UserPreferenceMaxSize,
-			"ttl":       cm.config.UserPreferenceTTL,
-			"hits":      stats.UserPreferenceHits,
-			"misses":    stats.UserPreferenceMisses,
-			"evictions": stats.UserPreferenceEvictions,
-			"hit_ratio": cm.calculateHitRatio(stats.UserPreferenceHits, stats.UserPreferenceMisses),
+			"size":          userPreferenceSize,
+			"max_size":      cm.config.UserPreferenceMaxSize,
+			"ttl":           cm.config.UserPreferenceTTL,
+			"hits":          stats.UserPreferenceHits,
+			"misses":        stats.UserPreferenceMisses,
+			"evictions":     stats.UserPreferenceEvictions,
+			"hit_ratio":     cm.calculateHitRatio(stats.UserPreferenceHits, stats.UserPreferenceMisses),
+			"last_flush_at": cm.lastFlushAt(CacheTypeUserPreference),
+		},
+		"search_result_cache": map[string]interface{}{
+			"enabled":       cm.SearchResultCacheEnabled(),
+			"size":          searchResultSize,
+			"max_size":      cm.config.SearchResultMaxSize,
+			"ttl":           cm.config.SearchResultTTL,
+			"hits":          stats.SearchResultHits,
+			"misses":        stats.SearchResultMisses,
+			"evictions":     stats.SearchResultEvictions,
+			"hit_ratio":     cm.calculateHitRatio(stats.SearchResultHits, stats.SearchResultMisses),
+			"last_flush_at": cm.lastFlushAt(CacheTypeSearchResult),
 		},
 	}
 }
 
+// Flush 清空指定类型的缓存（query_vector、recommendation、user_preference、search_result或all），
+// 返回各类缓存清除的条目数。清空时持有对应缓存的写锁，与并发读写互不干扰
+func (cm *VectorCacheManager) Flush(cacheType string) (map[string]int, error) {
+	var cacheTypes []string
+	switch cacheType {
+	case CacheTypeAll:
+		cacheTypes = []string{CacheTypeQueryVector, CacheTypeRecommendation, CacheTypeUserPreference, CacheTypeSearchResult}
+	case CacheTypeQueryVector, CacheTypeRecommendation, CacheTypeUserPreference, CacheTypeSearchResult:
+		cacheTypes = []string{cacheType}
+	default:
+		return nil, errors.ErrValidationFailed("cache_type", "must be one of: query_vector, recommendation, user_preference, search_result, all")
+	}
+
+	cleared := make(map[string]int, len(cacheTypes))
+	for _, flushType := range cacheTypes {
+		switch flushType {
+		case CacheTypeQueryVector:
+			cm.queryVectorMutex.Lock()
+			cleared[flushType] = len(cm.queryVectorCache)
+			cm.queryVectorCache = make(map[string]*CachedQueryVector)
+			cm.queryVectorMutex.Unlock()
+		case CacheTypeRecommendation:
+			cm.recommendationMutex.Lock()
+			cleared[flushType] = len(cm.recommendationCache)
+			cm.recommendationCache = make(map[string]*CachedRecommendation)
+			cm.recommendationMutex.Unlock()
+		case CacheTypeUserPreference:
+			cm.userPreferenceMutex.Lock()
+			cleared[flushType] = len(cm.userPreferenceCache)
+			cm.userPreferenceCache = make(map[string]*CachedUserPreference)
+			cm.userPreferenceMutex.Unlock()
+		case CacheTypeSearchResult:
+			cm.searchResultMutex.Lock()
+			cleared[flushType] = len(cm.searchResultCache)
+			cm.searchResultCache = make(map[string]*CachedSearchResult)
+			cm.searchResultMutex.Unlock()
+		}
+	}
+
+	now := time.Now()
+	cm.lastFlushMutex.Lock()
+	for _, flushType := range cacheTypes {
+		cm.lastFlush[flushType] = now
+	}
+	cm.lastFlushMutex.Unlock()
+
+	cm.logger.Info("Cache flushed", logger.Fields{
+		"cache_type": cacheType,
+		"cleared":    cleared,
+	})
+
+	return cleared, nil
+}
+
+// lastFlushAt 获取缓存最近一次被清空的时间，从未清空时返回nil
+func (cm *VectorCacheManager) lastFlushAt(cacheType string) *time.Time {
+	cm.lastFlushMutex.RLock()
+	defer cm.lastFlushMutex.RUnlock()
+
+	flushedAt, exists := cm.lastFlush[cacheType]
+	if !exists {
+		return nil
+	}
+	return &flushedAt
+}
+
 // calculateHitRatio 计算命中率
 func (cm *VectorCacheManager) calculateHitRatio(hits, misses int64) float64 {
 	total := hits + misses
@@ -498,6 +778,7 @@ func (cm *VectorCacheManager) cleanupExpiredEntries() {
 	cleanedQuery := 0
 	cleanedRec := 0
 	cleanedUser := 0
+	cleanedSearch := 0
 
 	// 清理过期的查询向量
 	cm.queryVectorMutex.Lock()
@@ -529,11 +810,22 @@ func (cm *VectorCacheManager) cleanupExpiredEntries() {
 	}
 	cm.userPreferenceMutex.Unlock()
 
-	if cleanedQuery > 0 || cleanedRec > 0 || cleanedUser > 0 {
+	// 清理过期的搜索结果
+	cm.searchResultMutex.Lock()
+	for key, cached := range cm.searchResultCache {
+		if now.Sub(cached.CachedAt) > cm.config.SearchResultTTL {
+			delete(cm.searchResultCache, key)
+			cleanedSearch++
+		}
+	}
+	cm.searchResultMutex.Unlock()
+
+	if cleanedQuery > 0 || cleanedRec > 0 || cleanedUser > 0 || cleanedSearch > 0 {
 		cm.logger.Debug("Cache cleanup completed", logger.Fields{
 			"cleaned_query_vectors":    cleanedQuery,
 			"cleaned_recommendations":  cleanedRec,
 			"cleaned_user_preferences": cleanedUser,
+			"cleaned_search_results":   cleanedSearch,
 		})
 	}
 }
@@ -560,6 +852,10 @@ func (cm *VectorCacheManager) Close() error {
 		cm.userPreferenceCache = make(map[string]*CachedUserPreference)
 		cm.userPreferenceMutex.Unlock()
 
+		cm.searchResultMutex.Lock()
+		cm.searchResultCache = make(map[string]*CachedSearchResult)
+		cm.searchResultMutex.Unlock()
+
 		cm.logger.Info("Vector cache manager shut down completed")
 	})
 
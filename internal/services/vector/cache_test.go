@@ -632,3 +632,116 @@ func TestCacheConfiguration(t *testing.T) {
 		assert.Equal(t, 45*time.Minute, recInfo["ttl"].(time.Duration))
 	})
 }
+
+// TestVectorCacheManager_Flush 测试按类型清空缓存
+func TestVectorCacheManager_Flush(t *testing.T) {
+	newPopulatedManager := func(t *testing.T) *VectorCacheManager {
+		cacheManager := NewVectorCacheManager(&config.Config{
+			VectorDB: config.VectorDBConfig{
+				CacheConfig: &config.VectorCacheConfig{
+					SearchResultTTL: time.Minute,
+					CleanupInterval: time.Hour,
+				},
+			},
+		})
+		t.Cleanup(func() { cacheManager.Close() })
+
+		options := &SearchOptions{TopK: 10}
+		cacheManager.SetQueryVector("go并发", options, []float32{0.1, 0.2})
+		cacheManager.SetQueryVector("rust所有权", options, []float32{0.3, 0.4})
+		cacheManager.SetRecommendation(&RecommendationRequest{Type: RecommendationTypeSimilar, UserID: "user-1"}, []*RecommendationItem{{DocumentID: "doc-1"}})
+		cacheManager.SetSearchResult("search-1", 1, &SearchResponse{TotalResults: 1})
+		cacheManager.userPreferenceMutex.Lock()
+		cacheManager.userPreferenceCache["user-1"] = &CachedUserPreference{UserID: "user-1", CachedAt: time.Now()}
+		cacheManager.userPreferenceMutex.Unlock()
+		return cacheManager
+	}
+
+	sizeOf := func(cacheManager *VectorCacheManager, section string) int {
+		return cacheManager.GetCacheInfo()[section].(map[string]interface{})["size"].(int)
+	}
+
+	t.Run("只清空指定类型的缓存", func(t *testing.T) {
+		cacheManager := newPopulatedManager(t)
+		assert.Nil(t, cacheManager.GetCacheInfo()["query_vector_cache"].(map[string]interface{})["last_flush_at"])
+
+		cleared, err := cacheManager.Flush(CacheTypeQueryVector)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{CacheTypeQueryVector: 2}, cleared)
+
+		assert.Equal(t, 0, sizeOf(cacheManager, "query_vector_cache"))
+		assert.Equal(t, 1, sizeOf(cacheManager, "recommendation_cache"))
+		assert.Equal(t, 1, sizeOf(cacheManager, "user_preference_cache"))
+		assert.Equal(t, 1, sizeOf(cacheManager, "search_result_cache"))
+
+		info := cacheManager.GetCacheInfo()
+		assert.NotNil(t, info["query_vector_cache"].(map[string]interface{})["last_flush_at"])
+		assert.Nil(t, info["recommendation_cache"].(map[string]interface{})["last_flush_at"])
+
+		cleared, err = cacheManager.Flush(CacheTypeRecommendation)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{CacheTypeRecommendation: 1}, cleared)
+		assert.Equal(t, 0, sizeOf(cacheManager, "recommendation_cache"))
+		assert.Equal(t, 1, sizeOf(cacheManager, "user_preference_cache"))
+
+		cleared, err = cacheManager.Flush(CacheTypeUserPreference)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{CacheTypeUserPreference: 1}, cleared)
+		assert.Equal(t, 0, sizeOf(cacheManager, "user_preference_cache"))
+		assert.Equal(t, 1, sizeOf(cacheManager, "search_result_cache"))
+
+		// 清空后的缓存可以继续写入
+		_, found := cacheManager.GetQueryVector("go并发", &SearchOptions{TopK: 10})
+		assert.False(t, found)
+		cacheManager.SetQueryVector("go并发", &SearchOptions{TopK: 10}, []float32{0.1, 0.2})
+		assert.Equal(t, 1, sizeOf(cacheManager, "query_vector_cache"))
+	})
+
+	t.Run("all清空全部缓存", func(t *testing.T) {
+		cacheManager := newPopulatedManager(t)
+
+		cleared, err := cacheManager.Flush(CacheTypeAll)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{
+			CacheTypeQueryVector:    2,
+			CacheTypeRecommendation: 1,
+			CacheTypeUserPreference: 1,
+			CacheTypeSearchResult:   1,
+		}, cleared)
+		for _, section := range []string{"query_vector_cache", "recommendation_cache", "user_preference_cache", "search_result_cache"} {
+			assert.Equal(t, 0, sizeOf(cacheManager, section))
+			assert.NotNil(t, cacheManager.GetCacheInfo()[section].(map[string]interface{})["last_flush_at"])
+		}
+	})
+
+	t.Run("无效的缓存类型", func(t *testing.T) {
+		cacheManager := newPopulatedManager(t)
+
+		_, err := cacheManager.Flush("embeddings")
+		assert.Error(t, err)
+		assert.Equal(t, 2, sizeOf(cacheManager, "query_vector_cache"))
+	})
+
+	t.Run("与并发读写同时进行", func(t *testing.T) {
+		cacheManager := newPopulatedManager(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					query := fmt.Sprintf("query-%d-%d", worker, j)
+					cacheManager.SetQueryVector(query, nil, []float32{0.1})
+					cacheManager.GetQueryVector(query, nil)
+					cacheManager.GetRecommendation(&RecommendationRequest{UserID: query})
+				}
+			}(i)
+		}
+		for i := 0; i < 20; i++ {
+			_, err := cacheManager.Flush(CacheTypeAll)
+			require.NoError(t, err)
+		}
+		wg.Wait()
+	})
+}
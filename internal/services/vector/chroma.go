@@ -6,6 +6,7 @@ import (
 	"time"
 
 	chroma "github.com/amikos-tech/chroma-go"
+	openapiclient "github.com/amikos-tech/chroma-go/swagger"
 	"github.com/amikos-tech/chroma-go/types"
 	"memoro/internal/config"
 	"memoro/internal/errors"
@@ -18,8 +19,16 @@ type ChromaClient struct {
 	collection *chroma.Collection
 	config     config.VectorDBConfig
 	logger     *logger.Logger
+
+	serverVersion string // 检测到的Chroma服务版本，用于校验where过滤条件（为空表示未知）
+
+	distance          DistanceFunction        // 集合实际使用的距离函数（新建时来自配置，已有集合从集合元数据读取）
+	embeddingFunction types.EmbeddingFunction // 集合的embedding函数（向量都由embedding服务生成，为nil时使用Chroma默认函数）
 }
 
+// ChromaClient 实现 DistanceReporter
+var _ DistanceReporter = (*ChromaClient)(nil)
+
 // VectorDocument 向量文档结构
 type VectorDocument struct {
 	ID        string                 `json:"id"`         // 文档ID
@@ -40,6 +49,14 @@ type SearchQuery struct {
 	MinSimilarity float32                `json:"min_similarity"`         // 最小相似度阈值
 }
 
+// KeywordQuery 关键词检索查询
+type KeywordQuery struct {
+	Terms       []string               `json:"terms"`            // 关键词（内容包含任一关键词即匹配）
+	Filter      map[string]interface{} `json:"filter,omitempty"` // 过滤条件
+	Limit       int                    `json:"limit"`            // 返回文档数量上限
+	IncludeText bool                   `json:"include_text"`     // 是否包含原文
+}
+
 // SearchResult 搜索结果
 type SearchResult struct {
 	Documents    []*VectorDocument `json:"documents"`     // 匹配的文档
@@ -54,10 +71,30 @@ func NewChromaClient() (*ChromaClient, error) {
 		return nil, errors.ErrConfigMissing("vector database config")
 	}
 
+	return newChromaClient(cfg.VectorDB)
+}
+
+// NewChromaClientForCollection 创建访问指定集合的Chroma客户端（如归档使用的冷存储集合），其余连接配置与活跃集合相同
+func NewChromaClientForCollection(collection string) (*ChromaClient, error) {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil, errors.ErrConfigMissing("vector database config")
+	}
+	if collection == "" {
+		return nil, errors.ErrValidationFailed("collection", "cannot be empty")
+	}
+
+	vectorConfig := cfg.VectorDB
+	vectorConfig.Collection = collection
+	return newChromaClient(vectorConfig)
+}
+
+// newChromaClient 按向量数据库配置创建Chroma客户端并初始化集合
+func newChromaClient(vectorConfig config.VectorDBConfig) (*ChromaClient, error) {
 	chromaLogger := logger.NewLogger("chroma-client")
 
 	// 构建Chroma服务器URL
-	serverURL := fmt.Sprintf("http://%s:%d", cfg.VectorDB.Host, cfg.VectorDB.Port)
+	serverURL := fmt.Sprintf("http://%s:%d", vectorConfig.Host, vectorConfig.Port)
 
 	// 创建Chroma客户端
 	client, err := chroma.NewClient(chroma.WithBasePath(serverURL))
@@ -73,7 +110,7 @@ func NewChromaClient() (*ChromaClient, error) {
 
 	chromaClient := &ChromaClient{
 		client: client,
-		config: cfg.VectorDB,
+		config: vectorConfig,
 		logger: chromaLogger,
 	}
 
@@ -82,12 +119,15 @@ func NewChromaClient() (*ChromaClient, error) {
 		return nil, err
 	}
 
+	chromaClient.detectServerVersion()
+
 	chromaLogger.Info("Chroma client initialized", logger.Fields{
-		"server_url":  serverURL,
-		"collection":  cfg.VectorDB.Collection,
-		"batch_size":  cfg.VectorDB.BatchSize,
-		"retry_times": cfg.VectorDB.RetryTimes,
-		"timeout":     cfg.VectorDB.Timeout,
+		"server_url":     serverURL,
+		"server_version": chromaClient.serverVersion,
+		"collection":  vectorConfig.Collection,
+		"batch_size":  vectorConfig.BatchSize,
+		"retry_times": vectorConfig.RetryTimes,
+		"timeout":     vectorConfig.Timeout,
 	})
 
 	return chromaClient, nil
@@ -98,8 +138,13 @@ func (cc *ChromaClient) initializeCollection() error {
 	ctx, cancel := context.WithTimeout(context.Background(), cc.config.Timeout)
 	defer cancel()
 
+	configured, err := distanceFunctionFrom(cc.config.DistanceFunction)
+	if err != nil {
+		return err
+	}
+
 	// 尝试获取现有集合
-	collection, err := cc.client.GetCollection(ctx, cc.config.Collection, nil)
+	collection, err := cc.client.GetCollection(ctx, cc.config.Collection, cc.embeddingFunction)
 	if err != nil {
 		// 如果集合不存在，创建新集合
 		cc.logger.Info("Collection not found, creating new collection", logger.Fields{
@@ -111,7 +156,7 @@ func (cc *ChromaClient) initializeCollection() error {
 			"created_at":  time.Now().Unix(),
 		}
 
-		collection, err = cc.client.CreateCollection(ctx, cc.config.Collection, metadata, true, nil, types.L2)
+		collection, err = cc.client.CreateCollection(ctx, cc.config.Collection, metadata, true, cc.embeddingFunction, configured.chromaDistanceFunction())
 		if err != nil {
 			memoErr := errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to create Chroma collection").
 				WithCause(err).
@@ -122,12 +167,24 @@ func (cc *ChromaClient) initializeCollection() error {
 			return memoErr
 		}
 
+		cc.distance = configured
 		cc.logger.Info("Created new Chroma collection", logger.Fields{
-			"collection": cc.config.Collection,
+			"collection":        cc.config.Collection,
+			"distance_function": string(cc.distance),
 		})
 	} else {
+		// 距离函数在创建集合时确定，已有集合以集合记录的为准
+		cc.distance = collectionDistanceFunction(collection.Metadata)
+		if cc.config.DistanceFunction != "" && cc.distance != configured {
+			cc.logger.Warn("Configured distance function differs from existing collection, using collection's", logger.Fields{
+				"collection":          cc.config.Collection,
+				"configured":          string(configured),
+				"collection_distance": string(cc.distance),
+			})
+		}
 		cc.logger.Info("Using existing Chroma collection", logger.Fields{
-			"collection": cc.config.Collection,
+			"collection":        cc.config.Collection,
+			"distance_function": string(cc.distance),
 		})
 	}
 
@@ -135,6 +192,39 @@ func (cc *ChromaClient) initializeCollection() error {
 	return nil
 }
 
+// DistanceFunction 获取集合使用的距离函数
+func (cc *ChromaClient) DistanceFunction() DistanceFunction {
+	return cc.distance
+}
+
+// detectServerVersion 检测Chroma服务版本，失败时只记录警告（按最新版本的where语法处理）
+func (cc *ChromaClient) detectServerVersion() {
+	ctx, cancel := context.WithTimeout(context.Background(), cc.config.Timeout)
+	defer cancel()
+
+	version, err := cc.client.Version(ctx)
+	if err != nil {
+		cc.logger.Warn("Failed to detect Chroma server version", logger.Fields{
+			"error": err.Error(),
+		})
+		return
+	}
+	cc.serverVersion = version
+}
+
+// whereFor 规范化过滤条件并按服务版本校验
+func (cc *ChromaClient) whereFor(filter map[string]interface{}) (map[string]interface{}, error) {
+	where := normalizeWhere(filter)
+	if err := validateWhereForVersion(where, cc.serverVersion); err != nil {
+		cc.logger.Warn("Rejected where filter", logger.Fields{
+			"server_version": cc.serverVersion,
+			"error":          err.Error(),
+		})
+		return nil, err
+	}
+	return where, nil
+}
+
 // AddDocument 添加文档到向量数据库
 func (cc *ChromaClient) AddDocument(ctx context.Context, doc *VectorDocument) error {
 	if doc == nil {
@@ -330,7 +420,11 @@ func (cc *ChromaClient) Search(ctx context.Context, query *SearchQuery) (*Search
 
 	// 添加过滤条件
 	if len(query.Filter) > 0 {
-		queryOptions = append(queryOptions, types.WithWhereMap(query.Filter))
+		where, err := cc.whereFor(query.Filter)
+		if err != nil {
+			return nil, err
+		}
+		queryOptions = append(queryOptions, types.WithWhereMap(where))
 	}
 
 	// 执行查询
@@ -349,6 +443,7 @@ func (cc *ChromaClient) Search(ctx context.Context, query *SearchQuery) (*Search
 
 	// 处理查询结果
 	documents := make([]*VectorDocument, 0)
+	negativeSimilarities := 0
 
 	if queryResult != nil && len(queryResult.Ids) > 0 {
 		for i := 0; i < len(queryResult.Ids[0]); i++ {
@@ -358,10 +453,14 @@ func (cc *ChromaClient) Search(ctx context.Context, query *SearchQuery) (*Search
 				distance = float32(queryResult.Distances[0][i])
 			}
 
-			similarity := 1.0 - distance // L2距离转换为相似度
+			// 按集合的距离函数转换为相似度
+			similarity, negative := distanceToSimilarity(cc.distance, distance)
+			if negative {
+				negativeSimilarities++
+			}
 
-			// 应用最小相似度过滤
-			if similarity < query.MinSimilarity {
+			// 应用最小相似度过滤（按换算的实际值，相似度下限只作用于展示的分数）
+			if !meetsMinSimilarity(similarity, query.MinSimilarity) {
 				continue
 			}
 
@@ -400,6 +499,14 @@ func (cc *ChromaClient) Search(ctx context.Context, query *SearchQuery) (*Search
 		}
 	}
 
+	if negativeSimilarities > 0 {
+		cc.logger.Warn("Distances converted to negative similarity", logger.Fields{
+			"negative":          negativeSimilarities,
+			"distance_function": cc.distance,
+			"collection":        cc.config.Collection,
+		})
+	}
+
 	queryTime := time.Since(startTime)
 	result := &SearchResult{
 		Documents:    documents,
@@ -446,26 +553,200 @@ func (cc *ChromaClient) GetDocument(ctx context.Context, id string) (*VectorDocu
 		return nil, errors.ErrResourceNotFound("document", id)
 	}
 
-	// 构建文档对象
+	doc := documentFromGetResult(getResult, 0)
+
+	cc.logger.Debug("Document retrieved successfully", logger.Fields{
+		"document_id":    id,
+		"content_length": len(doc.Content),
+		"has_embedding":  len(doc.Embedding) > 0,
+	})
+
+	return doc, nil
+}
+
+// GetDocuments 根据ID批量获取文档（不存在的ID会被忽略）
+func (cc *ChromaClient) GetDocuments(ctx context.Context, ids []string) ([]*VectorDocument, error) {
+	if len(ids) == 0 {
+		return nil, errors.ErrValidationFailed("ids", "cannot be empty")
+	}
+
+	cc.logger.Debug("Getting documents by IDs", logger.Fields{
+		"document_count": len(ids),
+	})
+
+	getResult, err := cc.collection.GetWithOptions(ctx,
+		types.WithIds(ids),
+		types.WithInclude(types.IDocuments, types.IEmbeddings, types.IMetadatas),
+	)
+	if err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to get documents from Chroma").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"document_count": len(ids),
+				"collection":     cc.config.Collection,
+			})
+		cc.logger.LogMemoroError(memoErr, "Batch document retrieval failed")
+		return nil, memoErr
+	}
+
+	documents := make([]*VectorDocument, 0, len(ids))
+	if getResult != nil {
+		for i := range getResult.Ids {
+			documents = append(documents, documentFromGetResult(getResult, i))
+		}
+	}
+
+	return documents, nil
+}
+
+// GetDocumentsByFilter 按元数据过滤条件获取文档（不返回向量，不需要查询向量）
+func (cc *ChromaClient) GetDocumentsByFilter(ctx context.Context, filter map[string]interface{}, limit int) ([]*VectorDocument, error) {
+	if len(filter) == 0 {
+		return nil, errors.ErrValidationFailed("filter", "cannot be empty")
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+
+	cc.logger.Debug("Getting documents by filter", logger.Fields{
+		"filter_keys": len(filter),
+		"limit":       limit,
+	})
+
+	where, err := cc.whereFor(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	getResult, err := cc.collection.GetWithOptions(ctx,
+		types.WithWhereMap(where),
+		types.WithLimit(int32(limit)),
+		types.WithInclude(types.IDocuments, types.IMetadatas),
+	)
+	if err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to get documents from Chroma").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"limit":      limit,
+				"collection": cc.config.Collection,
+			})
+		cc.logger.LogMemoroError(memoErr, "Filtered document retrieval failed")
+		return nil, memoErr
+	}
+
+	documents := make([]*VectorDocument, 0)
+	if getResult != nil {
+		for i := range getResult.Ids {
+			documents = append(documents, documentFromGetResult(getResult, i))
+		}
+	}
+
+	return documents, nil
+}
+
+// KeywordSearch 使用where_document的$contains获取包含任一关键词的文档（Chroma按大小写敏感匹配，调用方应同时提供原始大小写和小写形式）
+func (cc *ChromaClient) KeywordSearch(ctx context.Context, query *KeywordQuery) ([]*VectorDocument, error) {
+	if query == nil || len(query.Terms) == 0 {
+		return nil, errors.ErrValidationFailed("terms", "cannot be empty")
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	clauses := make([]interface{}, 0, len(query.Terms))
+	for _, term := range query.Terms {
+		clauses = append(clauses, map[string]interface{}{"$contains": term})
+	}
+	whereDocument := clauses[0].(map[string]interface{})
+	if len(clauses) > 1 {
+		whereDocument = map[string]interface{}{"$or": clauses}
+	}
+
+	options := []types.CollectionQueryOption{
+		types.WithWhereDocumentMap(whereDocument),
+		types.WithLimit(int32(limit)),
+		types.WithInclude(types.IDocuments, types.IMetadatas, types.IEmbeddings),
+	}
+	if len(query.Filter) > 0 {
+		where, err := cc.whereFor(query.Filter)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, types.WithWhereMap(where))
+	}
+
+	getResult, err := cc.collection.GetWithOptions(ctx, options...)
+	if err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to search documents by keyword in Chroma").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"terms":      len(query.Terms),
+				"collection": cc.config.Collection,
+			})
+		cc.logger.LogMemoroError(memoErr, "Keyword search failed")
+		return nil, memoErr
+	}
+
+	documents := make([]*VectorDocument, 0)
+	if getResult != nil {
+		for i := range getResult.Ids {
+			doc := documentFromGetResult(getResult, i)
+			if !query.IncludeText {
+				doc.Content = ""
+			}
+			documents = append(documents, doc)
+		}
+	}
+	return documents, nil
+}
+
+// GetVectorDimension 获取集合中已存储向量的维度（集合为空时返回0）
+func (cc *ChromaClient) GetVectorDimension(ctx context.Context) (int, error) {
+	getResult, err := cc.collection.GetWithOptions(ctx,
+		types.WithLimit(1),
+		types.WithInclude(types.IEmbeddings),
+	)
+	if err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to sample document from Chroma").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"collection": cc.config.Collection,
+			})
+		cc.logger.LogMemoroError(memoErr, "Vector dimension lookup failed")
+		return 0, memoErr
+	}
+
+	if getResult == nil || len(getResult.Ids) == 0 {
+		return 0, nil
+	}
+
+	return len(documentFromGetResult(getResult, 0).Embedding), nil
+}
+
+// documentFromGetResult 从Chroma get结果构建第i个文档
+func documentFromGetResult(getResult *chroma.GetResults, i int) *VectorDocument {
 	doc := &VectorDocument{
-		ID: id,
+		ID: getResult.Ids[i],
 	}
 
 	// 设置内容
-	if len(getResult.Documents) > 0 && len(getResult.Documents) > 0 {
-		doc.Content = getResult.Documents[0]
+	if len(getResult.Documents) > i {
+		doc.Content = getResult.Documents[i]
 	}
 
 	// 设置向量
-	if len(getResult.Embeddings) > 0 && getResult.Embeddings[0] != nil {
-		if getResult.Embeddings[0].ArrayOfFloat32 != nil {
-			doc.Embedding = *getResult.Embeddings[0].ArrayOfFloat32
+	if len(getResult.Embeddings) > i && getResult.Embeddings[i] != nil {
+		if getResult.Embeddings[i].ArrayOfFloat32 != nil {
+			doc.Embedding = *getResult.Embeddings[i].ArrayOfFloat32
 		}
 	}
 
 	// 设置元数据
-	if len(getResult.Metadatas) > 0 {
-		doc.Metadata = getResult.Metadatas[0]
+	if len(getResult.Metadatas) > i {
+		doc.Metadata = getResult.Metadatas[i]
 
 		// 从元数据中恢复创建时间
 		if createdAtVal, exists := doc.Metadata["created_at"]; exists {
@@ -477,13 +758,7 @@ func (cc *ChromaClient) GetDocument(ctx context.Context, id string) (*VectorDocu
 		}
 	}
 
-	cc.logger.Debug("Document retrieved successfully", logger.Fields{
-		"document_id":    id,
-		"content_length": len(doc.Content),
-		"has_embedding":  len(doc.Embedding) > 0,
-	})
-
-	return doc, nil
+	return doc
 }
 
 // DeleteDocument 删除文档
@@ -586,6 +861,55 @@ func (cc *ChromaClient) UpdateDocument(ctx context.Context, doc *VectorDocument)
 	return nil
 }
 
+// UpdateDocumentMetadata 仅更新文档元数据，不修改向量和内容
+func (cc *ChromaClient) UpdateDocumentMetadata(ctx context.Context, id string, metadata map[string]interface{}) error {
+	if id == "" {
+		return errors.ErrValidationFailed("id", "cannot be empty")
+	}
+
+	if len(metadata) == 0 {
+		return errors.ErrValidationFailed("metadata", "cannot be empty")
+	}
+
+	cc.logger.Debug("Updating document metadata", logger.Fields{
+		"document_id":   id,
+		"metadata_keys": getMetadataKeys(metadata),
+	})
+
+	if cc.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cc.config.Timeout)
+		defer cancel()
+	}
+
+	// 直接调用update接口，不携带embeddings和documents，避免集合的embedding函数重新计算向量
+	// Chroma按键合并元数据，未包含的键保持不变
+	_, rawResp, err := cc.collection.ApiClient.DefaultApi.Update(ctx, cc.collection.ID).
+		UpdateEmbedding(openapiclient.UpdateEmbedding{
+			Ids:       []string{id},
+			Metadatas: []map[string]interface{}{metadata},
+		}).Execute()
+	if err == nil && rawResp != nil && rawResp.StatusCode >= 400 {
+		err = fmt.Errorf("chroma returned status %d", rawResp.StatusCode)
+	}
+	if err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to update document metadata in Chroma").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"document_id": id,
+				"collection":  cc.config.Collection,
+			})
+		cc.logger.LogMemoroError(memoErr, "Document metadata update failed")
+		return memoErr
+	}
+
+	cc.logger.Debug("Document metadata updated successfully", logger.Fields{
+		"document_id": id,
+	})
+
+	return nil
+}
+
 // GetCollectionInfo 获取集合信息
 func (cc *ChromaClient) GetCollectionInfo(ctx context.Context) (map[string]interface{}, error) {
 	cc.logger.Debug("Getting collection information")
@@ -605,6 +929,7 @@ func (cc *ChromaClient) GetCollectionInfo(ctx context.Context) (map[string]inter
 		"server_url":      fmt.Sprintf("http://%s:%d", cc.config.Host, cc.config.Port),
 		"batch_size":      cc.config.BatchSize,
 		"timeout":         cc.config.Timeout,
+		"distance":        string(cc.distance),
 	}
 
 	cc.logger.Debug("Collection information retrieved", logger.Fields{
@@ -0,0 +1,488 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	chroma "github.com/amikos-tech/chroma-go"
+	"github.com/amikos-tech/chroma-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+)
+
+// fakeChromaRecord 模拟Chroma中存储的记录
+type fakeChromaRecord struct {
+	document  string
+	embedding []float32
+	metadata  map[string]interface{}
+}
+
+// fakeChromaServer 模拟Chroma REST API（add/get/update/delete/query）
+type fakeChromaServer struct {
+	mu          sync.Mutex
+	records     map[string]*fakeChromaRecord
+	updateCalls []map[string]interface{}
+	addCalls    int
+	rejectIDs   map[string]bool                   // 写入时拒绝的文档ID（模拟Chroma写入失败）
+	distances   map[string]float32                // 查询时返回的预设距离（未设置时按向量计算）
+	collections map[string]map[string]interface{} // 已创建集合的元数据（按集合名）
+	server      *httptest.Server
+}
+
+// newFakeChromaServer 创建模拟Chroma服务
+func newFakeChromaServer(t *testing.T) *fakeChromaServer {
+	fake := &fakeChromaServer{records: make(map[string]*fakeChromaRecord), rejectIDs: make(map[string]bool), distances: make(map[string]float32),
+		collections: make(map[string]map[string]interface{})}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+// put 写入一条记录
+func (f *fakeChromaServer) put(id, document string, embedding []float32, metadata map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records[id] = &fakeChromaRecord{document: document, embedding: embedding, metadata: metadata}
+}
+
+// handle 处理Chroma API请求
+func (f *fakeChromaServer) handle(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/api/v1/version":
+		_, _ = w.Write([]byte(`"0.4.14"`))
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/collections":
+		name, _ := body["name"].(string)
+		if _, exists := f.collections[name]; !exists {
+			metadata, _ := body["metadata"].(map[string]interface{})
+			f.collections[name] = metadata
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": name + "-id", "name": name, "metadata": f.collections[name]})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/collections/"):
+		name := strings.TrimPrefix(r.URL.Path, "/api/v1/collections/")
+		metadata, exists := f.collections[name]
+		if !exists {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "ValueError('Collection " + name + " does not exist.')"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": name + "-id", "name": name, "metadata": metadata})
+	case strings.HasSuffix(r.URL.Path, "/add"):
+		f.addCalls++
+		ids, _ := body["ids"].([]interface{})
+		for _, id := range ids {
+			if f.rejectIDs[id.(string)] {
+				w.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "rejected document " + id.(string)})
+				return
+			}
+		}
+		documents, _ := body["documents"].([]interface{})
+		metadatas, _ := body["metadatas"].([]interface{})
+		embeddings, _ := body["embeddings"].([]interface{})
+		for i, id := range ids {
+			record := &fakeChromaRecord{metadata: map[string]interface{}{}}
+			if i < len(documents) {
+				record.document, _ = documents[i].(string)
+			}
+			if i < len(metadatas) {
+				if metadata, ok := metadatas[i].(map[string]interface{}); ok {
+					record.metadata = metadata
+				}
+			}
+			if i < len(embeddings) {
+				values, _ := embeddings[i].([]interface{})
+				for _, v := range values {
+					record.embedding = append(record.embedding, float32(v.(float64)))
+				}
+			}
+			f.records[id.(string)] = record
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(true)
+	case strings.HasSuffix(r.URL.Path, "/update"):
+		f.updateCalls = append(f.updateCalls, body)
+		ids, _ := body["ids"].([]interface{})
+		metadatas, _ := body["metadatas"].([]interface{})
+		for i, id := range ids {
+			record, exists := f.records[id.(string)]
+			if !exists || i >= len(metadatas) {
+				continue
+			}
+			// 与Chroma一致：按键合并元数据
+			for key, value := range metadatas[i].(map[string]interface{}) {
+				record.metadata[key] = value
+			}
+		}
+		_ = json.NewEncoder(w).Encode(true)
+	case strings.HasSuffix(r.URL.Path, "/get"):
+		result := map[string]interface{}{"ids": []string{}, "documents": []string{}, "metadatas": []interface{}{}, "embeddings": []interface{}{}}
+		ids, _ := body["ids"].([]interface{})
+		if len(ids) == 0 {
+			// 未指定ID时按排序返回，支持where和limit
+			where, _ := body["where"].(map[string]interface{})
+			for _, id := range f.sortedIDs() {
+				if matchesWhere(f.records[id].metadata, where) {
+					ids = append(ids, id)
+				}
+			}
+			if limit, ok := body["limit"].(float64); ok && int(limit) < len(ids) {
+				ids = ids[:int(limit)]
+			}
+		}
+		for _, id := range ids {
+			if record, exists := f.records[id.(string)]; exists {
+				result["ids"] = append(result["ids"].([]string), id.(string))
+				result["documents"] = append(result["documents"].([]string), record.document)
+				result["metadatas"] = append(result["metadatas"].([]interface{}), record.metadata)
+				result["embeddings"] = append(result["embeddings"].([]interface{}), record.embedding)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	case strings.HasSuffix(r.URL.Path, "/delete"):
+		deleted := []string{}
+		ids, _ := body["ids"].([]interface{})
+		for _, id := range ids {
+			if _, exists := f.records[id.(string)]; exists {
+				delete(f.records, id.(string))
+				deleted = append(deleted, id.(string))
+			}
+		}
+		_ = json.NewEncoder(w).Encode(deleted)
+	case strings.HasSuffix(r.URL.Path, "/query"):
+		where, _ := body["where"].(map[string]interface{})
+		var queryEmbedding []float32
+		if queryEmbeddings, ok := body["query_embeddings"].([]interface{}); ok && len(queryEmbeddings) > 0 {
+			values, _ := queryEmbeddings[0].([]interface{})
+			for _, v := range values {
+				queryEmbedding = append(queryEmbedding, float32(v.(float64)))
+			}
+		}
+
+		type match struct {
+			id       string
+			distance float32
+		}
+		matches := make([]match, 0, len(f.records))
+		for _, id := range f.sortedIDs() {
+			if !matchesWhere(f.records[id].metadata, where) {
+				continue
+			}
+			matches = append(matches, match{id: id, distance: f.distance(id, queryEmbedding)})
+		}
+		// 与Chroma一致：按距离升序返回（距离相同时按ID排序）
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+
+		ids, documents, metadatas, distances := []string{}, []string{}, []interface{}{}, []float32{}
+		for _, m := range matches {
+			record := f.records[m.id]
+			ids = append(ids, m.id)
+			documents = append(documents, record.document)
+			metadatas = append(metadatas, record.metadata)
+			distances = append(distances, m.distance)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"ids":       [][]string{ids},
+			"documents": [][]string{documents},
+			"metadatas": [][]interface{}{metadatas},
+			"distances": [][]float32{distances},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// distance 计算记录与查询向量的距离：优先使用预设距离，其次按集合的距离函数计算（余弦或平方L2），无法计算时为0.1
+func (f *fakeChromaServer) distance(id string, queryEmbedding []float32) float32 {
+	if distance, exists := f.distances[id]; exists {
+		return distance
+	}
+
+	embedding := f.records[id].embedding
+	if len(queryEmbedding) == 0 || len(embedding) != len(queryEmbedding) {
+		return 0.1
+	}
+
+	for _, metadata := range f.collections {
+		if metadata["hnsw:space"] == "cosine" {
+			var dot, normA, normB float64
+			for i := range embedding {
+				dot += float64(embedding[i]) * float64(queryEmbedding[i])
+				normA += float64(embedding[i]) * float64(embedding[i])
+				normB += float64(queryEmbedding[i]) * float64(queryEmbedding[i])
+			}
+			return float32(1 - dot/math.Sqrt(normA*normB))
+		}
+	}
+
+	var sum float32
+	for i := range embedding {
+		diff := embedding[i] - queryEmbedding[i]
+		sum += diff * diff
+	}
+	return sum
+}
+
+// sortedIDs 按ID排序返回记录，保证查询结果顺序稳定
+func (f *fakeChromaServer) sortedIDs() []string {
+	ids := make([]string, 0, len(f.records))
+	for id := range f.records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// matchesWhere 简化的where过滤（支持$and、等值、$in和数值$gte/$lte，$in对列表值按任一元素匹配）
+func matchesWhere(metadata map[string]interface{}, where map[string]interface{}) bool {
+	for key, condition := range where {
+		if key == "$and" {
+			for _, clause := range whereList(condition) {
+				if !matchesWhere(metadata, clause) {
+					return false
+				}
+			}
+			continue
+		}
+
+		value, exists := metadata[key]
+		if cond, ok := condition.(map[string]interface{}); ok {
+			if ne, ok := cond["$ne"]; ok && exists && value == ne {
+				return false
+			}
+			if in, ok := cond["$in"].([]interface{}); ok && !containsAny(value, in) {
+				return false
+			}
+			number, _ := value.(float64)
+			if gte, ok := cond["$gte"].(float64); ok && number < gte {
+				return false
+			}
+			if lte, ok := cond["$lte"].(float64); ok && number > lte {
+				return false
+			}
+			continue
+		}
+		if value != condition {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAny 检查值（或列表值中的任一元素）是否在候选集合中
+func containsAny(value interface{}, candidates []interface{}) bool {
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	for _, v := range values {
+		for _, c := range candidates {
+			if v == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newTestChromaClient 创建连接到模拟服务的Chroma客户端
+func newTestChromaClient(t *testing.T, fake *fakeChromaServer) *ChromaClient {
+	client, err := chroma.NewClient(chroma.WithBasePath(fake.server.URL))
+	require.NoError(t, err)
+
+	collection := chroma.NewCollection(client, "test-collection-id", "test", nil,
+		types.NewConsistentHashEmbeddingFunction(), types.DefaultTenant, types.DefaultDatabase)
+
+	return &ChromaClient{
+		client:     client,
+		collection: collection,
+		config:     config.VectorDBConfig{Collection: "test"},
+		logger:     logger.NewLogger("chroma-client-test"),
+	}
+}
+
+// TestChromaClient_UpdateDocumentMetadata 测试仅更新元数据
+func TestChromaClient_UpdateDocumentMetadata(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	fake.put("doc-1", "Go并发编程", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id":    "user-1",
+		"created_at": float64(1700000000),
+	})
+	client := newTestChromaClient(t, fake)
+
+	t.Run("请求不携带向量和内容", func(t *testing.T) {
+		err := client.UpdateDocumentMetadata(context.Background(), "doc-1", map[string]interface{}{"importance_score": 0.9})
+		require.NoError(t, err)
+
+		require.Len(t, fake.updateCalls, 1)
+		assert.NotContains(t, fake.updateCalls[0], "embeddings")
+		assert.NotContains(t, fake.updateCalls[0], "documents")
+	})
+
+	t.Run("参数校验", func(t *testing.T) {
+		assert.Error(t, client.UpdateDocumentMetadata(context.Background(), "", map[string]interface{}{"a": 1}))
+		assert.Error(t, client.UpdateDocumentMetadata(context.Background(), "doc-1", nil))
+	})
+}
+
+// TestChromaClient_GetDocuments 测试批量获取文档
+func TestChromaClient_GetDocuments(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	fake.put("doc-1", "Go并发编程", []float32{0.1, 0.2, 0.3}, map[string]interface{}{"user_id": "user-1"})
+	fake.put("doc-2", "Rust所有权", []float32{0.3, 0.2, 0.1}, map[string]interface{}{"user_id": "user-1"})
+	client := newTestChromaClient(t, fake)
+
+	documents, err := client.GetDocuments(context.Background(), []string{"doc-1", "doc-2", "missing"})
+	require.NoError(t, err)
+	require.Len(t, documents, 2)
+	assert.Equal(t, "doc-1", documents[0].ID)
+	assert.Equal(t, []float32{0.1, 0.2, 0.3}, documents[0].Embedding)
+	assert.Equal(t, "Rust所有权", documents[1].Content)
+
+	_, err = client.GetDocuments(context.Background(), nil)
+	assert.Error(t, err)
+}
+
+// TestChromaClient_SearchSimilarityFloor 测试距离换算的相似度按实际值参与最小相似度过滤，不被下限抬高
+func TestChromaClient_SearchSimilarityFloor(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	fake.put("near", "Go并发编程", []float32{0.1, 0.2, 0.3}, map[string]interface{}{"user_id": "user-1"})
+	fake.put("far", "红烧肉做法", []float32{0.3, 0.2, 0.1}, map[string]interface{}{"user_id": "user-1"})
+	fake.distances["near"] = 0.2
+	fake.distances["far"] = 3.5
+	client := newTestChromaClient(t, fake)
+	client.config.SimilarityFloor = 0.3
+
+	t.Run("大距离换算为负数并标记", func(t *testing.T) {
+		similarity, negative := distanceToSimilarity(DistanceCosine, 3.5)
+		assert.InDelta(t, -2.5, similarity, 1e-6)
+		assert.True(t, negative)
+
+		similarity, negative = distanceToSimilarity(DistanceCosine, 0.2)
+		assert.InDelta(t, 0.8, similarity, 1e-6)
+		assert.False(t, negative)
+	})
+
+	t.Run("按集合的距离函数换算", func(t *testing.T) {
+		// 单位向量的平方L2距离0.4对应余弦值0.8
+		similarity, negative := distanceToSimilarity(DistanceL2, 0.4)
+		assert.InDelta(t, 0.8, similarity, 1e-6)
+		assert.False(t, negative)
+
+		similarity, _ = distanceToSimilarity(DistanceIP, 0.4)
+		assert.InDelta(t, 0.6, similarity, 1e-6)
+
+		// 平方L2距离最大为4，不会像1-距离那样把中等距离截断为0
+		similarity, negative = distanceToSimilarity(DistanceL2, 1.5)
+		assert.InDelta(t, 0.25, similarity, 1e-6)
+		assert.False(t, negative)
+	})
+
+	t.Run("下限不影响最小相似度过滤", func(t *testing.T) {
+		result, err := client.Search(context.Background(), &SearchQuery{QueryVector: []float32{0.1, 0.2, 0.3}, TopK: 10})
+		require.NoError(t, err)
+		assert.Len(t, result.Documents, 2)
+
+		// 下限0.3高于最小相似度0.1，但负相似度的结果仍被过滤
+		result, err = client.Search(context.Background(), &SearchQuery{QueryVector: []float32{0.1, 0.2, 0.3}, TopK: 10, MinSimilarity: 0.1})
+		require.NoError(t, err)
+		require.Len(t, result.Documents, 1)
+		assert.Equal(t, "near", result.Documents[0].ID)
+	})
+}
+
+// TestChromaClient_CollectionDistance 测试按配置的距离函数创建集合、读取已有集合的距离函数，以及余弦集合端到端使用余弦相似度
+func TestChromaClient_CollectionDistance(t *testing.T) {
+	embeddingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	}))
+	defer embeddingServer.Close()
+
+	newClient := func(t *testing.T, fake *fakeChromaServer, distance string) *ChromaClient {
+		client, err := chroma.NewClient(chroma.WithBasePath(fake.server.URL))
+		require.NoError(t, err)
+
+		cc := &ChromaClient{
+			client:            client,
+			config:            config.VectorDBConfig{Collection: "memoro", DistanceFunction: distance, Timeout: 5 * time.Second},
+			logger:            logger.NewLogger("chroma-client-test"),
+			embeddingFunction: types.NewConsistentHashEmbeddingFunction(),
+		}
+		require.NoError(t, cc.initializeCollection())
+		return cc
+	}
+
+	t.Run("余弦集合端到端使用余弦相似度", func(t *testing.T) {
+		fake := newFakeChromaServer(t)
+		cc := newClient(t, fake, "cosine")
+		assert.Equal(t, DistanceCosine, cc.DistanceFunction())
+		assert.Equal(t, "cosine", fake.collections["memoro"]["hnsw:space"])
+
+		require.NoError(t, config.InitializeForTest(&config.Config{
+			LLM: config.LLMConfig{APIBase: embeddingServer.URL, APIKey: "test-key", Timeout: 5 * time.Second},
+		}))
+		engine, err := NewSearchEngineWithStore(cc)
+		require.NoError(t, err)
+		defer engine.Close()
+
+		fake.put("doc-1", "Go并发编程", []float32{0.6, 0.8, 0}, map[string]interface{}{"user_id": "user-1"})
+		response, err := engine.Search(context.Background(), &SearchOptions{Query: "并发", UserID: "user-1", TopK: 5})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 1)
+
+		result := response.Results[0]
+		assert.Equal(t, SimilarityTypeCosine, result.SimilarityType)
+		// 余弦距离 = 1 - 0.6
+		assert.InDelta(t, 0.4, result.Distance, 1e-6)
+		assert.InDelta(t, 0.6, result.RawSimilarity, 1e-6)
+	})
+
+	t.Run("已有集合沿用创建时的距离函数", func(t *testing.T) {
+		fake := newFakeChromaServer(t)
+		fake.collections["memoro"] = map[string]interface{}{"hnsw:space": "l2"}
+
+		cc := newClient(t, fake, "cosine")
+		assert.Equal(t, DistanceL2, cc.DistanceFunction())
+		assert.Equal(t, "l2", fake.collections["memoro"]["hnsw:space"])
+
+		similarityType, err := defaultSimilarityTypeFor("", cc)
+		require.NoError(t, err)
+		assert.Equal(t, SimilarityTypeEuclidean, similarityType)
+	})
+
+	t.Run("相似度类型与距离函数不兼容时启动失败", func(t *testing.T) {
+		fake := newFakeChromaServer(t)
+		cc := newClient(t, fake, "cosine")
+
+		_, err := defaultSimilarityTypeFor("dot", cc)
+		require.Error(t, err)
+
+		similarityType, err := defaultSimilarityTypeFor("cosine", cc)
+		require.NoError(t, err)
+		assert.Equal(t, SimilarityTypeCosine, similarityType)
+
+		// 无法报告距离函数的存储不做兼容性校验
+		similarityType, err = defaultSimilarityTypeFor("", NewMemoryStore())
+		require.NoError(t, err)
+		assert.Equal(t, SimilarityTypeCosine, similarityType)
+	})
+}
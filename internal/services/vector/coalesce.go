@@ -0,0 +1,116 @@
+package vector
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// searchCall 进行中的搜索调用
+type searchCall struct {
+	done     chan struct{} // 检索完成后关闭
+	response *SearchResponse
+	err      error
+	cancel   context.CancelFunc // 取消检索（所有调用方都放弃等待时）
+	waiting  int                // 仍在等待结果的调用方数（受searchFlightGroup.mu保护）
+	joined   int                // 加入过该调用的调用方总数（受searchFlightGroup.mu保护）
+}
+
+// searchFlightGroup 相同搜索请求合并器
+// 并发的相同搜索只执行一次向量化和向量检索，所有调用方共享同一结果。
+// 检索在与调用方分离的上下文中执行，某个调用方取消或超时不影响其他调用方；所有调用方都放弃时才取消检索
+type searchFlightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*searchCall
+}
+
+// newSearchFlightGroup 创建搜索请求合并器
+func newSearchFlightGroup() *searchFlightGroup {
+	return &searchFlightGroup{
+		calls: make(map[string]*searchCall),
+	}
+}
+
+// Do 执行搜索，若存在相同键的进行中调用则等待其结果；ctx只控制本调用方的等待，取消时返回ctx的错误。
+// fn收到的上下文保留首个调用方的值但不随其取消。返回值shared表示结果是否由多个调用方共享
+func (g *searchFlightGroup) Do(ctx context.Context, key string, fn func(ctx context.Context) (*SearchResponse, error)) (response *SearchResponse, err error, shared bool) {
+	g.mu.Lock()
+	call, exists := g.calls[key]
+	if !exists {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &searchCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go g.run(flightCtx, key, call, fn)
+	}
+	call.waiting++
+	call.joined++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		g.mu.Lock()
+		shared = call.joined > 1
+		g.mu.Unlock()
+		return call.response, call.err, shared
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiting--
+		if call.waiting == 0 {
+			// 没有调用方在等待，取消检索；之后的相同请求重新发起
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			call.cancel()
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err(), false
+	}
+}
+
+// run 执行检索并通知所有等待的调用方
+func (g *searchFlightGroup) run(ctx context.Context, key string, call *searchCall, fn func(ctx context.Context) (*SearchResponse, error)) {
+	call.response, call.err = fn(ctx)
+
+	g.mu.Lock()
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+
+	call.cancel()
+	close(call.done)
+}
+
+// InFlight 获取当前进行中的搜索数量
+func (g *searchFlightGroup) InFlight() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.calls)
+}
+
+// waiting 获取等待进行中搜索结果的调用方总数
+func (g *searchFlightGroup) waiting() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	total := 0
+	for _, call := range g.calls {
+		total += call.waiting
+	}
+	return total
+}
+
+// generateSearchFlightKey 生成搜索合并键（规范化查询+全部过滤条件）
+func generateSearchFlightKey(processedQuery string, options *SearchOptions) string {
+	normalized := *options
+	normalized.Query = processedQuery
+
+	data, err := json.Marshal(&normalized)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%s|%v", processedQuery, normalized))
+	}
+
+	hash := md5.Sum(data)
+	return fmt.Sprintf("sf:%x", hash)
+}
@@ -0,0 +1,93 @@
+package vector
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+)
+
+// searchLimiter 搜索并发限制：最多max_concurrent个搜索同时执行，其余最多max_queue个排队等待，队列已满或等待超时时返回服务繁忙
+type searchLimiter struct {
+	slots        chan struct{}
+	maxQueue     int
+	queueTimeout time.Duration
+
+	queued        int64 // 当前排队数
+	rejected      int64 // 因队列已满被拒绝的累计次数
+	queueTimeouts int64 // 排队超时的累计次数
+}
+
+// newSearchLimiter 按配置创建搜索并发限制，未配置并发上限时返回nil（不限制）
+func newSearchLimiter(limits *config.SearchLimitsConfig) *searchLimiter {
+	if limits == nil || limits.MaxConcurrent <= 0 {
+		return nil
+	}
+	return &searchLimiter{
+		slots:        make(chan struct{}, limits.MaxConcurrent),
+		maxQueue:     limits.MaxQueue,
+		queueTimeout: limits.QueueTimeout,
+	}
+}
+
+// acquire 获取执行名额，返回的release在搜索结束后调用；nil限制器直接放行
+func (l *searchLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	release := func() { <-l.slots }
+
+	// 有空闲名额时直接执行，不进入队列
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if atomic.AddInt64(&l.queued, 1) > int64(l.maxQueue) {
+		atomic.AddInt64(&l.queued, -1)
+		atomic.AddInt64(&l.rejected, 1)
+		return nil, l.busyError()
+	}
+	defer atomic.AddInt64(&l.queued, -1)
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		atomic.AddInt64(&l.queueTimeouts, 1)
+		return nil, l.busyError()
+	}
+}
+
+// busyError 服务繁忙错误
+func (l *searchLimiter) busyError() error {
+	return errors.ErrServerBusy("search", cap(l.slots), l.maxQueue)
+}
+
+// stats 并发限制的统计信息
+func (l *searchLimiter) stats() map[string]interface{} {
+	if l == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+	return map[string]interface{}{
+		"enabled":        true,
+		"max_concurrent": cap(l.slots),
+		"max_queue":      l.maxQueue,
+		"active":         len(l.slots),
+		"queue_depth":    atomic.LoadInt64(&l.queued),
+		"rejected":       atomic.LoadInt64(&l.rejected),
+		"queue_timeouts": atomic.LoadInt64(&l.queueTimeouts),
+	}
+}
@@ -0,0 +1,69 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+)
+
+// TestSearchEngine_ConcurrencyLimit 测试超出并发上限的搜索排队，队列已满后被拒绝
+func TestSearchEngine_ConcurrencyLimit(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan time.Time)
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		WaitUntil(release).
+		Return(&EmbeddingResult{Vector: []float32{1, 0}, Dimension: 2}, nil)
+
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = NewMemoryStore()
+	engine.searchLimiter = newSearchLimiter(&config.SearchLimitsConfig{MaxConcurrent: 2, MaxQueue: 1})
+
+	limiterStat := func(key string) int64 {
+		value := engine.searchLimiter.stats()[key]
+		if active, ok := value.(int); ok {
+			return int64(active)
+		}
+		return value.(int64)
+	}
+
+	// 不同的查询不会被合并，各自占用名额
+	errs := make(chan error, 3)
+	search := func(i int) {
+		_, err := engine.Search(ctx, &SearchOptions{Query: fmt.Sprintf("查询 %d", i)})
+		errs <- err
+	}
+
+	go search(0)
+	go search(1)
+	require.Eventually(t, func() bool { return limiterStat("active") == 2 }, time.Second, 5*time.Millisecond)
+
+	go search(2)
+	require.Eventually(t, func() bool { return limiterStat("queue_depth") == 1 }, time.Second, 5*time.Millisecond)
+
+	_, err := engine.Search(ctx, &SearchOptions{Query: "查询 3"})
+	require.Error(t, err)
+	memoErr, ok := err.(*errors.MemoroError)
+	require.True(t, ok)
+	assert.Equal(t, errors.ErrCodeServerBusy, memoErr.Code)
+	assert.Equal(t, int64(1), limiterStat("rejected"))
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, <-errs)
+	}
+	assert.Equal(t, int64(0), limiterStat("active"))
+	assert.Equal(t, int64(0), limiterStat("queue_depth"))
+
+	stats, err := engine.GetSearchStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats["search_concurrency"].(map[string]interface{})["rejected"])
+}
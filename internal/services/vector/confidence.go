@@ -0,0 +1,80 @@
+package vector
+
+import (
+	"math"
+
+	"memoro/internal/config"
+)
+
+// 推荐置信度归一化：各推荐策略的原始分数尺度不同，统一映射到0-1后混合推荐中的置信度才可比较，并可按同一阈值过滤。
+//
+//   - similar：由相似度(cos+1)/2还原余弦值后截断到[0,1]，正交（无关）的文档置信度为0而不是0.5，负相关同样为0
+//   - related：0.7×按similar方式换算的向量置信度 + 0.3×关键词相似度（关键词相似度本身在0-1）
+//   - personalized：相关性分数加偏好加成（已上限为1），截断到[0,1]
+//   - trending：新鲜度、重要性和互动度的加权和（各分量在0-1），截断到[0,1]
+//   - tag_based、collaborative：重叠度或协同分数，截断到[0,1]
+//
+// 归一化后的置信度写入推荐解释的因子分解（confidence），便于与其他因子对照。
+
+// ExplanationFactorConfidence 因子分解中归一化置信度的键
+const ExplanationFactorConfidence = "confidence"
+
+// cosineConfidence 归一化余弦相似度（(cos+1)/2）对应的置信度，即截断到0-1的余弦值
+func cosineConfidence(similarity float64) float64 {
+	return clampConfidence(2*similarity - 1)
+}
+
+// clampConfidence 将置信度限制在0-1（NaN视为0）
+func clampConfidence(confidence float64) float64 {
+	if math.IsNaN(confidence) || confidence < 0 {
+		return 0
+	}
+	if confidence > 1 {
+		return 1
+	}
+	return confidence
+}
+
+// minConfidenceFrom 从配置读取推荐的最小置信度，未配置时为0（不过滤）
+func minConfidenceFrom(cfg *config.Config) float64 {
+	if cfg == nil || cfg.VectorDB.Recommendation == nil {
+		return 0
+	}
+	return cfg.VectorDB.Recommendation.MinConfidence
+}
+
+// minConfidenceFor 请求实际使用的最小置信度，请求未指定时使用配置值
+func (r *Recommender) minConfidenceFor(req *RecommendationRequest) float64 {
+	if req.MinConfidence > 0 {
+		return req.MinConfidence
+	}
+	return r.minConfidence
+}
+
+// finalizeConfidence 将推荐项的置信度限制在0-1并写入解释的因子分解
+func finalizeConfidence(recommendations []*RecommendationItem) {
+	for _, rec := range recommendations {
+		rec.Confidence = clampConfidence(rec.Confidence)
+		if rec.Explanation != nil {
+			if rec.Explanation.FactorBreakdown == nil {
+				rec.Explanation.FactorBreakdown = make(map[string]float64)
+			}
+			rec.Explanation.FactorBreakdown[ExplanationFactorConfidence] = rec.Confidence
+		}
+	}
+}
+
+// filterByConfidence 去掉置信度低于阈值的推荐，返回保留的推荐和去掉的数量
+func filterByConfidence(recommendations []*RecommendationItem, minConfidence float64) ([]*RecommendationItem, int) {
+	if minConfidence <= 0 {
+		return recommendations, 0
+	}
+
+	kept := make([]*RecommendationItem, 0, len(recommendations))
+	for _, rec := range recommendations {
+		if rec.Confidence >= minConfidence {
+			kept = append(kept, rec)
+		}
+	}
+	return kept, len(recommendations) - len(kept)
+}
@@ -0,0 +1,134 @@
+package vector
+
+import (
+	"context"
+	"time"
+
+	"memoro/internal/logger"
+	"memoro/internal/models"
+)
+
+// defaultDedupThreshold 未配置时判定为重复的相似度阈值
+const defaultDedupThreshold = 0.95
+
+// dedupCandidates 去重时检查的最相似文档数量（跳过自身和已软删除的文档）
+const dedupCandidates = 5
+
+// DedupScope 索引时去重的范围
+type DedupScope string
+
+const (
+	DedupScopeUser   DedupScope = "user"   // 只与同一用户的内容去重
+	DedupScopeGlobal DedupScope = "global" // 与所有用户的内容去重
+)
+
+// DedupPolicy 索引时去重策略
+type DedupPolicy struct {
+	Scope     DedupScope    `json:"scope"`     // 去重范围，为空时为user
+	Window    time.Duration `json:"window"`    // 只与该时间窗口内创建的内容去重，0表示不限
+	Threshold float64       `json:"threshold"` // 判定为重复的相似度阈值，0表示使用默认值
+}
+
+// DuplicateMatch 判定为重复时匹配到的已有文档
+type DuplicateMatch struct {
+	DocumentID string     `json:"document_id,omitempty"` // 已有文档ID（属于其他用户时不返回）
+	OtherUser  bool       `json:"other_user,omitempty"`  // 已有文档属于其他用户（全局去重时）
+	Similarity float64    `json:"similarity"`            // 与新内容的相似度
+	Scope      DedupScope `json:"scope"`                 // 生效的去重范围
+}
+
+// IndexDocumentDeduplicated 按去重策略索引文档：与范围和时间窗口内的已有文档相似度达到阈值时不写入向量库，
+// 返回匹配到的文档；不重复时正常索引并返回nil。policy为nil时不去重。
+// 同一去重范围内的重复检查和写入串行执行，避免并发提交的相同内容都通过检查；全局去重时匹配到其他用户的文档不返回其ID
+func (se *SearchEngine) IndexDocumentDeduplicated(ctx context.Context, contentItem *models.ContentItem, policy *DedupPolicy) (*DuplicateMatch, error) {
+	if policy == nil {
+		return nil, se.IndexDocument(ctx, contentItem)
+	}
+	if err := se.validateIndexItem(contentItem); err != nil {
+		return nil, err
+	}
+
+	vectorDoc, err := se.embeddingService.CreateContentVector(ctx, contentItem)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := se.dedupLocks.lock(dedupLockKey(policy, contentItem.UserID))
+	defer unlock()
+
+	match, duplicateOf, err := se.findDuplicate(ctx, vectorDoc, contentItem.UserID, policy)
+	if err != nil {
+		return nil, err
+	}
+	if match != nil {
+		se.logger.Info("Duplicate content skipped at index time", logger.Fields{
+			"content_id":   contentItem.ID,
+			"duplicate_of": duplicateOf,
+			"other_user":   match.OtherUser,
+			"similarity":   match.Similarity,
+			"scope":        string(match.Scope),
+		})
+		return match, nil
+	}
+
+	return nil, se.addVectorDocument(ctx, contentItem, vectorDoc)
+}
+
+// dedupLockKey 获取去重串行化的键：按用户去重时为用户ID，全局去重时所有写入共用一个键
+func dedupLockKey(policy *DedupPolicy, userID string) string {
+	if policy.Scope == DedupScopeGlobal {
+		return string(DedupScopeGlobal)
+	}
+	return string(DedupScopeUser) + ":" + userID
+}
+
+// findDuplicate 在去重范围和时间窗口内查找与新文档相似度达到阈值的已有文档，同时返回已有文档的ID（仅用于日志）
+func (se *SearchEngine) findDuplicate(ctx context.Context, vectorDoc *VectorDocument, userID string, policy *DedupPolicy) (*DuplicateMatch, string, error) {
+	scope := policy.Scope
+	if scope == "" {
+		scope = DedupScopeUser
+	}
+	threshold := policy.Threshold
+	if threshold <= 0 {
+		threshold = defaultDedupThreshold
+	}
+
+	options := &SearchOptions{}
+	if scope == DedupScopeUser {
+		options.UserID = userID
+	}
+	if policy.Window > 0 {
+		options.TimeRange = &TimeRange{StartTime: time.Now().Add(-policy.Window)}
+	}
+
+	searchResult, err := se.store.Search(ctx, &SearchQuery{
+		QueryVector:   vectorDoc.Embedding,
+		TopK:          dedupCandidates,
+		Filter:        se.buildFilter(options),
+		MinSimilarity: float32(threshold),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, doc := range searchResult.Documents {
+		if doc.ID == vectorDoc.ID {
+			continue
+		}
+		if deleted, _ := doc.Metadata[MetadataKeyDeleted].(bool); deleted {
+			continue
+		}
+		similarity, _ := distanceToSimilarity(storeDistanceFunction(se.store), doc.Distance)
+		if float64(similarity) < threshold {
+			continue
+		}
+		match := &DuplicateMatch{DocumentID: doc.ID, Similarity: float64(similarity), Scope: scope}
+		if owner, _ := doc.Metadata["user_id"].(string); owner != userID {
+			// 不向提交者暴露其他用户的文档ID
+			match.DocumentID = ""
+			match.OtherUser = true
+		}
+		return match, doc.ID, nil
+	}
+	return nil, "", nil
+}
@@ -0,0 +1,134 @@
+package vector
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/amikos-tech/chroma-go/types"
+
+	"memoro/internal/errors"
+)
+
+// DistanceFunction 向量集合的距离函数（Chroma在创建集合时确定，之后不能修改）
+type DistanceFunction string
+
+const (
+	DistanceL2     DistanceFunction = "l2"     // 平方L2距离（Chroma默认）
+	DistanceCosine DistanceFunction = "cosine" // 余弦距离（1-余弦相似度）
+	DistanceIP     DistanceFunction = "ip"     // 内积距离（1-点积）
+)
+
+// defaultDistanceFunction 未配置时新建集合使用的距离函数，与引擎默认的余弦相似度一致
+const defaultDistanceFunction = DistanceCosine
+
+// distanceSimilarityTypes 各距离函数兼容的相似度类型，第一个为由距离函数推导的默认相似度类型。
+// embedding向量已归一化，L2和内积排序与余弦一致，因此两者都兼容余弦相似度
+var distanceSimilarityTypes = map[DistanceFunction][]SimilarityType{
+	DistanceL2:     {SimilarityTypeEuclidean, SimilarityTypeCosine},
+	DistanceCosine: {SimilarityTypeCosine},
+	DistanceIP:     {SimilarityTypeDotProduct, SimilarityTypeCosine},
+}
+
+// DistanceReporter 能报告集合距离函数的向量存储（返回空值表示未知）
+type DistanceReporter interface {
+	DistanceFunction() DistanceFunction
+}
+
+// distanceFunctionFrom 解析配置的距离函数，为空时使用默认值
+func distanceFunctionFrom(value string) (DistanceFunction, error) {
+	if value == "" {
+		return defaultDistanceFunction, nil
+	}
+	distance := DistanceFunction(strings.ToLower(value))
+	if _, exists := distanceSimilarityTypes[distance]; !exists {
+		return "", errors.ErrConfigInvalid("vector_db.distance_function", fmt.Sprintf("unknown distance function: %s", value))
+	}
+	return distance, nil
+}
+
+// collectionDistanceFunction 从集合元数据中读取距离函数，未记录时为Chroma默认的L2
+func collectionDistanceFunction(metadata map[string]interface{}) DistanceFunction {
+	space, _ := metadata[types.HNSWSpace].(string)
+	distance := DistanceFunction(strings.ToLower(space))
+	if _, exists := distanceSimilarityTypes[distance]; !exists {
+		return DistanceL2
+	}
+	return distance
+}
+
+// chromaDistanceFunction 转换为Chroma客户端的距离函数
+func (d DistanceFunction) chromaDistanceFunction() types.DistanceFunction {
+	switch d {
+	case DistanceCosine:
+		return types.COSINE
+	case DistanceIP:
+		return types.IP
+	default:
+		return types.L2
+	}
+}
+
+// SupportsSimilarityType 检查相似度类型与距离函数是否兼容
+func (d DistanceFunction) SupportsSimilarityType(similarityType SimilarityType) bool {
+	for _, supported := range distanceSimilarityTypes[d] {
+		if supported == similarityType {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultSimilarityTypeFor 确定引擎的默认相似度类型：优先使用配置值（需与存储的距离函数兼容），
+// 否则由存储报告的距离函数推导，存储无法报告时使用余弦相似度
+func defaultSimilarityTypeFor(configured string, store VectorStore) (SimilarityType, error) {
+	distance := storeDistanceFunction(store)
+	if configured != "" {
+		similarityType := SimilarityType(strings.ToLower(configured))
+		if distance != "" && !distance.SupportsSimilarityType(similarityType) {
+			return "", errors.ErrConfigInvalid("vector_db.similarity_type",
+				fmt.Sprintf("similarity type %s is not compatible with collection distance function %s", similarityType, distance))
+		}
+		return similarityType, nil
+	}
+
+	if supported := distanceSimilarityTypes[distance]; len(supported) > 0 {
+		return supported[0], nil
+	}
+	return SimilarityTypeCosine, nil
+}
+
+// rawSimilarityFromDistance 由存储返回的距离换算相似度原始值（存储不返回向量时使用），
+// 仅在相似度类型与距离函数兼容时可换算：余弦距离和内积距离为1-原始值，平方L2距离开方为欧氏距离；
+// embedding向量已归一化，平方L2距离d对应的余弦值为1-d/2，内积即余弦值
+func rawSimilarityFromDistance(distance DistanceFunction, value float32, similarityType SimilarityType) (float64, bool) {
+	switch {
+	case distance == DistanceCosine && similarityType == SimilarityTypeCosine,
+		distance == DistanceIP && similarityType == SimilarityTypeDotProduct,
+		distance == DistanceIP && similarityType == SimilarityTypeCosine:
+		return 1 - float64(value), true
+	case distance == DistanceL2 && similarityType == SimilarityTypeEuclidean:
+		return math.Sqrt(math.Max(float64(value), 0)), true
+	case distance == DistanceL2 && similarityType == SimilarityTypeCosine:
+		return 1 - float64(value)/2, true
+	default:
+		return 0, false
+	}
+}
+
+// cosineFromDistance 按集合的距离函数将存储返回的距离换算为余弦相似度，距离函数未知时按默认的余弦距离处理
+func cosineFromDistance(distance DistanceFunction, value float32) float64 {
+	if distance == "" {
+		distance = defaultDistanceFunction
+	}
+	similarity, _ := rawSimilarityFromDistance(distance, value, SimilarityTypeCosine)
+	return similarity
+}
+
+// storeDistanceFunction 获取向量存储报告的距离函数（无法报告时为空）
+func storeDistanceFunction(store VectorStore) DistanceFunction {
+	if reporter, ok := store.(DistanceReporter); ok {
+		return reporter.DistanceFunction()
+	}
+	return ""
+}
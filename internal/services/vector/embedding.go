@@ -5,20 +5,91 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/go-resty/resty/v2"
 	"memoro/internal/config"
 	"memoro/internal/errors"
 	"memoro/internal/logger"
 	"memoro/internal/models"
+	"memoro/internal/services/llm"
+	"memoro/internal/services/preprocess"
 )
 
+// Embedder 向量化服务接口
+type Embedder interface {
+	// GenerateEmbedding 生成文本向量
+	GenerateEmbedding(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResult, error)
+
+	// CreateContentVector 为内容项创建向量文档
+	CreateContentVector(ctx context.Context, contentItem *models.ContentItem) (*VectorDocument, error)
+
+	// Close 关闭服务
+	Close() error
+}
+
 // EmbeddingService 向量化服务
 type EmbeddingService struct {
-	httpClient *resty.Client
-	config     config.LLMConfig
-	logger     *logger.Logger
+	httpClient         *resty.Client
+	config             config.LLMConfig
+	truncationStrategy TruncationStrategy // 默认截断策略
+	budget             *llm.TokenBudget   // token预算记账器
+	logger             *logger.Logger
+
+	preprocessor *preprocess.Preprocessor // 文本预处理（nil时使用默认步骤）
+
+	dimensions modelDimensions // 各模型向量维度一致性检查
+
+	recent *embeddingReuseCache // 近期相同文本的向量复用（nil时不复用）
+
+	fallbacks []*embeddingProvider // 主服务失败时按顺序尝试的备用服务
+
+	normalizeVectors bool // 文档向量在构建向量文档前L2归一化
+}
+
+// defaultEmbeddingModel 未配置时使用的向量化模型
+const defaultEmbeddingModel = "text-embedding-ada-002"
+
+// modelDimensions 记录集合的向量维度，保证按内容类型选择的模型和备用服务写入同一集合的向量维度一致。
+// 启动时从已有集合加载，集合为空时取主服务返回的首个向量
+type modelDimensions struct {
+	mu        sync.Mutex
+	model     string
+	dimension int
+}
+
+// seed 使用已有集合中向量的维度作为参考维度
+func (md *modelDimensions) seed(source string, dimension int) {
+	if dimension <= 0 {
+		return
+	}
+
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.model = source
+	md.dimension = dimension
+}
+
+// check 检查模型返回的向量维度与已有向量一致，尚无参考维度时只有主服务的向量作为参考
+func (md *modelDimensions) check(model string, dimension int, primary bool) error {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	if md.dimension == 0 {
+		if primary {
+			md.model = model
+			md.dimension = dimension
+		}
+		return nil
+	}
+	if dimension != md.dimension {
+		return errors.ErrConfigInvalid("llm.embedding_models",
+			fmt.Sprintf("model %s returns %d-dimensional vectors but %s returns %d, all embedding models must share one dimension",
+				model, dimension, md.model, md.dimension))
+	}
+	return nil
 }
 
 // EmbeddingRequest 向量化请求
@@ -27,6 +98,8 @@ type EmbeddingRequest struct {
 	ContentType models.ContentType     `json:"content_type"`         // 内容类型
 	MaxTokens   int                    `json:"max_tokens,omitempty"` // 最大token数量
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`   // 额外元数据
+
+	TruncationStrategy TruncationStrategy `json:"truncation_strategy,omitempty"` // 截断策略，为空时使用服务默认值
 }
 
 // EmbeddingResponse LLM API的embedding响应
@@ -52,6 +125,7 @@ type EmbeddingResult struct {
 	ProcessTime time.Duration `json:"process_time"` // 处理时间
 	Model       string        `json:"model"`        // 使用的模型
 	TextLength  int           `json:"text_length"`  // 原文长度
+	Provider    string        `json:"provider"`     // 生成向量的服务（primary或备用服务名称）
 }
 
 // BatchEmbeddingRequest 批量向量化请求
@@ -96,17 +170,42 @@ func NewEmbeddingService() (*EmbeddingService, error) {
 	// 设置重试策略
 	httpClient.SetRetryCount(cfg.LLM.RetryTimes)
 	httpClient.SetRetryWaitTime(cfg.LLM.RetryDelay)
+	httpClient.AddRetryCondition(llm.RetryCondition)
+
+	truncationStrategy := TruncationStrategy(cfg.LLM.EmbeddingTruncation)
+	if truncationStrategy == "" {
+		truncationStrategy = TruncationHead
+	}
+	if !IsValidTruncationStrategy(truncationStrategy) {
+		return nil, errors.ErrConfigInvalid("llm.embedding_truncation",
+			fmt.Sprintf("unknown truncation strategy: %s", truncationStrategy))
+	}
+
+	preprocessor, err := preprocess.NewPreprocessor(cfg.Processing.Preprocessing)
+	if err != nil {
+		return nil, err
+	}
 
 	service := &EmbeddingService{
-		httpClient: httpClient,
-		config:     cfg.LLM,
-		logger:     embeddingLogger,
+		httpClient:         httpClient,
+		config:             cfg.LLM,
+		truncationStrategy: truncationStrategy,
+		budget:             llm.GetTokenBudget(),
+		logger:             embeddingLogger,
+		preprocessor:       preprocessor,
+		recent:             newEmbeddingReuseCache(cfg.LLM.EmbeddingReuse),
+		fallbacks:          newEmbeddingProviders(cfg.LLM),
+		normalizeVectors:   cfg.VectorDB.NormalizeVectors,
 	}
 
 	embeddingLogger.Info("Embedding service initialized", logger.Fields{
-		"model":      cfg.LLM.Model,
-		"api_base":   cfg.LLM.APIBase,
-		"max_tokens": cfg.LLM.MaxTokens,
+		"model":       service.embeddingModelFor(""),
+		"type_models": cfg.LLM.EmbeddingModels,
+		"api_base":    cfg.LLM.APIBase,
+		"max_tokens":  cfg.LLM.MaxTokens,
+		"truncation":  string(truncationStrategy),
+		"fallbacks":   len(service.fallbacks),
+		"normalize":   service.normalizeVectors,
 	})
 
 	return service, nil
@@ -122,6 +221,10 @@ func (es *EmbeddingService) GenerateEmbedding(ctx context.Context, req *Embeddin
 		return nil, errors.ErrValidationFailed("text", "cannot be empty")
 	}
 
+	if req.TruncationStrategy != "" && !IsValidTruncationStrategy(req.TruncationStrategy) {
+		return nil, errors.ErrValidationFailed("truncation_strategy", "must be 'head', 'tail', 'head_tail' or 'sample'")
+	}
+
 	startTime := time.Now()
 
 	es.logger.Debug("Generating embedding", logger.Fields{
@@ -135,14 +238,57 @@ func (es *EmbeddingService) GenerateEmbedding(ctx context.Context, req *Embeddin
 
 	// 限制文本长度
 	if req.MaxTokens > 0 {
-		processedText = es.truncateText(processedText, req.MaxTokens)
+		processedText = es.truncateText(processedText, req.MaxTokens, req.TruncationStrategy)
 	}
 
-	// 调用LLM API生成embedding
-	embedding, tokensUsed, err := es.callEmbeddingAPI(ctx, processedText)
+	// 近期已为相同文本生成过向量时直接复用，不调用API也不消耗token预算
+	model := es.embeddingModelFor(req.ContentType)
+	reuseKey := embeddingReuseKey(model, processedText)
+	if vector, ok := es.recent.get(reuseKey); ok {
+		llm.RecordCacheHit(ctx)
+		es.logger.Debug("Embedding reused for identical text", logger.Fields{
+			"dimension":   len(vector),
+			"text_length": len(req.Text),
+		})
+		return &EmbeddingResult{
+			Vector:      vector,
+			Dimension:   len(vector),
+			ProcessTime: time.Since(startTime),
+			Model:       model,
+			TextLength:  len(req.Text),
+			Provider:    PrimaryEmbeddingProvider,
+		}, nil
+	}
+
+	// 检查token预算
+	budgetUser := embeddingBudgetUser(ctx, req)
+	if es.budget != nil {
+		if err := es.budget.Check(budgetUser, EstimateTokens(processedText)); err != nil {
+			es.logger.Warn("Embedding blocked by token budget", logger.Fields{
+				"user_id": budgetUser,
+				"error":   err.Error(),
+			})
+			return nil, err
+		}
+	}
+
+	// 按内容类型选择的模型调用LLM API生成embedding，失败时依次尝试备用服务
+	outcome, err := es.embedWithFallback(ctx, processedText, model)
 	if err != nil {
 		return nil, err
 	}
+	embedding, tokensUsed := outcome.embedding, outcome.tokensUsed
+
+	// 记录token消耗
+	if es.budget != nil {
+		es.budget.Record(budgetUser, tokensUsed)
+	}
+	llm.RecordTokenUsage(ctx, tokensUsed)
+
+	// 只复用主服务生成的向量，主服务恢复后不再返回备用服务的结果
+	if outcome.provider == PrimaryEmbeddingProvider {
+		es.recent.set(reuseKey, embedding)
+	}
 
 	processTime := time.Since(startTime)
 
@@ -151,11 +297,13 @@ func (es *EmbeddingService) GenerateEmbedding(ctx context.Context, req *Embeddin
 		Dimension:   len(embedding),
 		TokensUsed:  tokensUsed,
 		ProcessTime: processTime,
-		Model:       es.config.Model,
+		Model:       outcome.model,
 		TextLength:  len(req.Text),
+		Provider:    outcome.provider,
 	}
 
 	es.logger.Debug("Embedding generated successfully", logger.Fields{
+		"provider":     result.Provider,
 		"dimension":    result.Dimension,
 		"tokens_used":  result.TokensUsed,
 		"process_time": result.ProcessTime,
@@ -244,17 +392,8 @@ func (es *EmbeddingService) GenerateBatchEmbeddings(ctx context.Context, req *Ba
 
 // preprocessText 预处理文本
 func (es *EmbeddingService) preprocessText(text string, contentType models.ContentType) string {
-	// 移除多余的空白字符
-	processed := strings.TrimSpace(text)
-
-	// 替换多个连续空格为单个空格
-	processed = strings.ReplaceAll(processed, "\n", " ")
-	processed = strings.ReplaceAll(processed, "\t", " ")
-
-	// 移除重复空格
-	for strings.Contains(processed, "  ") {
-		processed = strings.ReplaceAll(processed, "  ", " ")
-	}
+	// 使用与内容提取相同的预处理流水线
+	processed := es.preprocessor.ForContentType(contentType).Apply(text)
 
 	// 根据内容类型进行特殊处理
 	switch contentType {
@@ -272,38 +411,61 @@ func (es *EmbeddingService) preprocessText(text string, contentType models.Conte
 	return processed
 }
 
+// embeddingBudgetUser 获取token消耗归属的用户（优先使用请求元数据中的user_id）
+func embeddingBudgetUser(ctx context.Context, req *EmbeddingRequest) string {
+	if userID, ok := req.Metadata["user_id"].(string); ok && userID != "" {
+		return userID
+	}
+	return llm.BudgetUserFromContext(ctx)
+}
+
 // truncateText 截断文本到指定token数量
-func (es *EmbeddingService) truncateText(text string, maxTokens int) string {
+func (es *EmbeddingService) truncateText(text string, maxTokens int, strategy TruncationStrategy) string {
 	if maxTokens <= 0 {
 		return text
 	}
 
-	// 简单的token估算：平均每个token约4个字符
-	estimatedTokens := len(text) / 4
-	if estimatedTokens <= maxTokens {
-		return text
+	// 请求未指定策略时使用服务默认策略（无效策略已在请求校验时拒绝）
+	if strategy == "" {
+		strategy = es.truncationStrategy
 	}
 
-	// 截断文本
-	maxChars := maxTokens * 4
-	if len(text) > maxChars {
-		return text[:maxChars] + "..."
+	truncated := TruncateText(text, maxTokens, strategy)
+	if len(truncated) != len(text) {
+		es.logger.Debug("Embedding input truncated", logger.Fields{
+			"strategy":         string(strategy),
+			"max_tokens":       maxTokens,
+			"estimated_tokens": EstimateTokens(text),
+			"original_length":  len(text),
+			"truncated_length": len(truncated),
+		})
 	}
 
-	return text
+	return truncated
 }
 
-// callEmbeddingAPI 调用LLM API生成embedding
-func (es *EmbeddingService) callEmbeddingAPI(ctx context.Context, text string) ([]float32, int, error) {
+// embeddingModelFor 获取内容类型对应的向量化模型，未单独配置时使用默认模型
+func (es *EmbeddingService) embeddingModelFor(contentType models.ContentType) string {
+	if model := es.config.EmbeddingModels[string(contentType)]; model != "" {
+		return model
+	}
+	if es.config.EmbeddingModel != "" {
+		return es.config.EmbeddingModel
+	}
+	return defaultEmbeddingModel
+}
+
+// callEmbeddingAPI 调用向量化服务的API生成embedding
+func (es *EmbeddingService) callEmbeddingAPI(ctx context.Context, httpClient *resty.Client, text string, model string) ([]float32, int, error) {
 	es.logger.Debug("Calling LLM API for embedding", logger.Fields{
 		"text_length": len(text),
-		"api_base":    es.config.APIBase,
-		"model":       es.config.Model,
+		"api_base":    httpClient.BaseURL,
+		"model":       model,
 	})
 
 	// 构建embedding请求
 	requestBody := map[string]interface{}{
-		"model": "text-embedding-ada-002", // 使用固定的embedding模型
+		"model": model,
 		"input": text,
 	}
 
@@ -316,7 +478,7 @@ func (es *EmbeddingService) callEmbeddingAPI(ctx context.Context, text string) (
 	}
 
 	// 发送HTTP请求
-	resp, err := es.httpClient.R().
+	resp, err := httpClient.R().
 		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(requestJSON).
@@ -335,11 +497,7 @@ func (es *EmbeddingService) callEmbeddingAPI(ctx context.Context, text string) (
 
 	// 检查HTTP状态
 	if resp.StatusCode() != 200 {
-		memoErr := errors.NewMemoroError(errors.ErrorTypeLLM, errors.ErrCodeLLMAPICall, "Embedding API returned error status").
-			WithDetails(fmt.Sprintf("Status: %d, Body: %s", resp.StatusCode(), string(resp.Body()))).
-			WithContext(map[string]interface{}{
-				"status_code": resp.StatusCode(),
-			})
+		memoErr := llm.ClassifyAPIError("Embedding", resp.StatusCode(), resp.Body())
 		es.logger.LogMemoroError(memoErr, "Embedding API error response")
 		return nil, 0, memoErr
 	}
@@ -372,6 +530,37 @@ func (es *EmbeddingService) callEmbeddingAPI(ctx context.Context, text string) (
 	return embedding, tokensUsed, nil
 }
 
+// checkEmbeddingInput 检查预处理后的文本（不含内容类型前缀）满足该内容类型的最低字符数和token数
+func (es *EmbeddingService) checkEmbeddingInput(text string, contentType models.ContentType) error {
+	minChars := es.config.EmbeddingMinInput.MinChars
+	minTokens := es.config.EmbeddingMinInput.MinTokens
+	if threshold, exists := es.config.EmbeddingMinInput.ContentTypes[string(contentType)]; exists {
+		if threshold.MinChars > 0 {
+			minChars = threshold.MinChars
+		}
+		if threshold.MinTokens > 0 {
+			minTokens = threshold.MinTokens
+		}
+	}
+	if minChars <= 0 {
+		minChars = 1
+	}
+
+	processed := es.preprocessor.ForContentType(contentType).Apply(text)
+	chars := 0
+	for _, r := range processed {
+		if !unicode.IsSpace(r) {
+			chars++
+		}
+	}
+	tokens := EstimateTokens(processed)
+
+	if chars < minChars || tokens < minTokens {
+		return errors.ErrInsufficientEmbeddingInput(string(contentType), chars, tokens, minChars, minTokens)
+	}
+	return nil
+}
+
 // GetHTTPClient 获取内部HTTP客户端
 func (es *EmbeddingService) GetHTTPClient() *resty.Client {
 	return es.httpClient
@@ -389,6 +578,16 @@ func (es *EmbeddingService) CreateContentVector(ctx context.Context, contentItem
 		"content_size": len(contentItem.RawContent),
 	})
 
+	// 内容实际上没有可向量化的文本时（如OCR未识别出文字）提前返回，避免无效的API调用
+	if err := es.checkEmbeddingInput(contentItem.RawContent, contentItem.Type); err != nil {
+		es.logger.Info("Skipping embedding for insufficient content", logger.Fields{
+			"content_id":   contentItem.ID,
+			"content_type": string(contentItem.Type),
+			"reason":       err.Error(),
+		})
+		return nil, err
+	}
+
 	// 准备embedding请求
 	embeddingReq := &EmbeddingRequest{
 		Text:        contentItem.RawContent,
@@ -406,6 +605,13 @@ func (es *EmbeddingService) CreateContentVector(ctx context.Context, contentItem
 		return nil, err
 	}
 
+	// 按配置归一化为单位长度（与查询向量使用相同的处理）
+	vector := embeddingResult.Vector
+	normalized := false
+	if es.normalizeVectors {
+		vector, normalized = normalizeL2(vector)
+	}
+
 	// 构建元数据
 	metadata := map[string]interface{}{
 		"content_id":       contentItem.ID,
@@ -416,16 +622,26 @@ func (es *EmbeddingService) CreateContentVector(ctx context.Context, contentItem
 		"vector_dimension": embeddingResult.Dimension,
 		"model":            embeddingResult.Model,
 	}
+	if embeddingResult.Provider != "" {
+		metadata[MetadataKeyEmbeddingProvider] = embeddingResult.Provider
+	}
 
 	// 添加标签信息
 	if tags := contentItem.GetTags(); len(tags) > 0 {
 		metadata["tags"] = tags
 	}
 
-	// 添加摘要信息
+	// 添加摘要信息（敏感内容在数据库中加密保存，向量库不保存摘要明文）
 	summary := contentItem.GetSummary()
-	if summary.OneLine != "" {
-		metadata["summary_oneline"] = summary.OneLine
+	if contentItem.Sensitive {
+		metadata[MetadataKeySensitive] = true
+	} else {
+		if summary.OneLine != "" {
+			metadata["summary_oneline"] = summary.OneLine
+		}
+		if summary.Paragraph != "" {
+			metadata[MetadataKeySummaryParagraph] = summary.Paragraph
+		}
 	}
 
 	// 添加处理后的数据
@@ -434,19 +650,79 @@ func (es *EmbeddingService) CreateContentVector(ctx context.Context, contentItem
 		if categories, exists := processedData["categories"]; exists {
 			metadata["categories"] = categories
 		}
-		if keywords, exists := processedData["keywords"]; exists {
-			metadata["keywords"] = keywords
+		// 标题、关键词、实体和链接预览取自正文，敏感内容不写入向量库
+		if !contentItem.Sensitive {
+			if title, ok := processedData["title"].(string); ok && title != "" {
+				metadata["title"] = title
+			}
+			if keywords, exists := processedData["keywords"]; exists {
+				metadata["keywords"] = keywords
+			}
+			// 分类阶段抽取的实体（用于按实体过滤搜索）
+			for _, key := range entityMetadataKeys {
+				if entities, exists := processedData[key]; exists {
+					metadata[key] = entities
+				}
+			}
+			// 链接预览字段（用于搜索和推荐结果渲染富卡片）
+			for _, key := range LinkPreviewMetadataKeys {
+				if value, ok := processedData[key].(string); ok && value != "" {
+					metadata[key] = value
+				}
+			}
+		}
+		// 重要性评分来源（default表示计算失败时的默认值，下游排序可据此降低权重）
+		if source, exists := processedData["importance_source"]; exists {
+			metadata["importance_source"] = source
+		}
+		// 内容语言（用于按语言过滤搜索）
+		if lang, ok := processedData[MetadataKeyLanguage].(string); ok && lang != "" {
+			metadata[MetadataKeyLanguage] = lang
+		}
+		// 用户置顶标记（重新索引时保留）
+		if pinned, ok := processedData[MetadataKeyPinned].(bool); ok {
+			metadata[MetadataKeyPinned] = pinned
+		}
+		// 会话线程（用于按线程折叠搜索结果）
+		if threadID, ok := processedData[MetadataKeyThreadID].(string); ok && threadID != "" {
+			metadata[MetadataKeyThreadID] = threadID
+			metadata[MetadataKeyThreadTimestamp] = contentItem.CreatedAt.UnixMilli()
+		}
+		if rollup, ok := processedData[MetadataKeyThreadRollup].(bool); ok && rollup {
+			metadata[MetadataKeyThreadRollup] = true
+		}
+		// 低质量提取标记（可配置为默认不参与搜索）
+		if lowQuality, ok := processedData[MetadataKeyLowQuality].(bool); ok && lowQuality {
+			metadata[MetadataKeyLowQuality] = true
 		}
 	}
 
-	// 创建向量文档
+	// 处理完整性标记
+	metadata[MetadataKeyHasSummary] = summary.OneLine != ""
+	metadata[MetadataKeyHasTags] = len(contentItem.GetTags()) > 0
+	metadata[MetadataKeyIndexed] = len(embeddingResult.Vector) > 0
+	metadata[MetadataKeyVectorNormalized] = normalized
+
+	// 合并调用方自定义元数据（不覆盖系统字段；敏感内容的自定义元数据可能包含明文，不写入向量库）
+	if !contentItem.Sensitive {
+		for key, value := range customMetadataOf(contentItem) {
+			if _, exists := metadata[key]; !exists && !reservedMetadataKeys[key] {
+				metadata[key] = value
+			}
+		}
+	}
+
+	// 创建向量文档（敏感内容不保存原文，搜索只依赖向量和元数据）
 	vectorDoc := &VectorDocument{
 		ID:        contentItem.ID,
 		Content:   contentItem.RawContent,
-		Embedding: embeddingResult.Vector,
+		Embedding: vector,
 		Metadata:  metadata,
 		CreatedAt: contentItem.CreatedAt,
 	}
+	if contentItem.Sensitive {
+		vectorDoc.Content = ""
+	}
 
 	es.logger.Debug("Vector document created", logger.Fields{
 		"content_id":       contentItem.ID,
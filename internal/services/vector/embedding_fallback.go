@@ -0,0 +1,149 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-resty/resty/v2"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/services/llm"
+)
+
+// PrimaryEmbeddingProvider 主向量化服务（llm.api_base）的名称
+const PrimaryEmbeddingProvider = "primary"
+
+// embeddingProvider 备用向量化服务
+type embeddingProvider struct {
+	name       string
+	httpClient *resty.Client
+	model      string // 为空时使用按内容类型选择的模型
+}
+
+// embeddingOutcome 一次向量化调用的结果及提供服务的provider
+type embeddingOutcome struct {
+	embedding  []float32
+	tokensUsed int
+	provider   string
+	model      string
+}
+
+// newEmbeddingProviders 根据配置创建备用向量化服务，超时和重试未配置时沿用LLM配置
+func newEmbeddingProviders(cfg config.LLMConfig) []*embeddingProvider {
+	providers := make([]*embeddingProvider, 0, len(cfg.EmbeddingProviders))
+	for _, providerConfig := range cfg.EmbeddingProviders {
+		timeout := providerConfig.Timeout
+		if timeout <= 0 {
+			timeout = cfg.Timeout
+		}
+
+		httpClient := resty.New()
+		httpClient.SetBaseURL(providerConfig.APIBase)
+		httpClient.SetTimeout(timeout)
+		httpClient.SetHeader("Content-Type", "application/json")
+		if providerConfig.APIKey != "" {
+			httpClient.SetHeader("Authorization", fmt.Sprintf("Bearer %s", providerConfig.APIKey))
+		}
+		httpClient.SetRetryCount(cfg.RetryTimes)
+		httpClient.SetRetryWaitTime(cfg.RetryDelay)
+		httpClient.AddRetryCondition(llm.RetryCondition)
+
+		providers = append(providers, &embeddingProvider{
+			name:       providerConfig.Name,
+			httpClient: httpClient,
+			model:      providerConfig.Model,
+		})
+	}
+	return providers
+}
+
+// embeddingProviderNames 备用向量化服务的名称（按配置顺序）
+func embeddingProviderNames(cfg config.LLMConfig) []string {
+	names := make([]string, 0, len(cfg.EmbeddingProviders))
+	for _, providerConfig := range cfg.EmbeddingProviders {
+		names = append(names, providerConfig.Name)
+	}
+	return names
+}
+
+// documentEmbeddingProvider 生成文档向量的服务，未记录时（配置备用服务前写入的文档）视为主服务
+func documentEmbeddingProvider(doc *VectorDocument) string {
+	if provider, _ := doc.Metadata[MetadataKeyEmbeddingProvider].(string); provider != "" {
+		return provider
+	}
+	return PrimaryEmbeddingProvider
+}
+
+// restrictToProvider 把检索限制在与查询向量由同一服务生成的文档上：不同模型的向量即使维度相同也不可比较。
+// 未配置备用服务时不修改过滤条件
+func (se *SearchEngine) restrictToProvider(filter map[string]interface{}, provider string) map[string]interface{} {
+	if len(se.embeddingFallbacks) == 0 {
+		return filter
+	}
+
+	combined := make(map[string]interface{}, len(filter)+1)
+	for key, value := range filter {
+		combined[key] = value
+	}
+	if provider == "" || provider == PrimaryEmbeddingProvider {
+		// 未记录服务的文档视为主服务生成
+		combined[MetadataKeyEmbeddingProvider] = map[string]interface{}{"$nin": se.embeddingFallbacks}
+	} else {
+		combined[MetadataKeyEmbeddingProvider] = provider
+	}
+	return normalizeWhere(combined)
+}
+
+// embedWithFallback 依次尝试主服务和备用服务生成向量：调用失败或返回的向量维度与已有向量不一致时尝试下一个，
+// 全部失败时返回最后一个错误，请求被取消时不再尝试
+func (es *EmbeddingService) embedWithFallback(ctx context.Context, text, model string) (*embeddingOutcome, error) {
+	attempts := append([]*embeddingProvider{{name: PrimaryEmbeddingProvider, httpClient: es.httpClient}}, es.fallbacks...)
+
+	var lastErr error
+	for i, provider := range attempts {
+		providerModel := model
+		if provider.model != "" {
+			providerModel = provider.model
+		}
+
+		embedding, tokensUsed, err := es.callEmbeddingAPI(ctx, provider.httpClient, text, providerModel)
+		if err == nil {
+			// 不同维度的向量不能写入同一集合
+			err = es.dimensions.check(providerModel, len(embedding), i == 0)
+		}
+		if err == nil {
+			if i > 0 {
+				es.logger.Info("Embedding served by fallback provider", logger.Fields{
+					"provider": provider.name,
+					"model":    providerModel,
+				})
+			}
+			return &embeddingOutcome{
+				embedding:  embedding,
+				tokensUsed: tokensUsed,
+				provider:   provider.name,
+				model:      providerModel,
+			}, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+		if i < len(attempts)-1 {
+			es.logger.Warn("Embedding provider failed, trying next provider", logger.Fields{
+				"provider": provider.name,
+				"model":    providerModel,
+				"next":     attempts[i+1].name,
+				"error":    err.Error(),
+			})
+		}
+	}
+
+	if memoErr, ok := lastErr.(*errors.MemoroError); ok && memoErr.IsCode(errors.ErrCodeConfigInvalid) {
+		es.logger.LogMemoroError(memoErr, "Embedding dimension mismatch")
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,232 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+)
+
+// newEmbeddingServer 创建返回固定向量的embedding服务，status非200时返回错误
+func newEmbeddingServer(t *testing.T, status int, vector []float32, calls *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if status != http.StatusOK {
+			http.Error(w, `{"error":"rate limited"}`, status)
+			return
+		}
+		var body struct {
+			Model string `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":  []map[string]interface{}{{"embedding": vector}},
+			"model": body.Model,
+			"usage": map[string]int{"total_tokens": 4},
+		}))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestEmbeddingService_ProviderFallback 测试主服务失败时使用备用服务生成向量
+func TestEmbeddingService_ProviderFallback(t *testing.T) {
+	ctx := context.Background()
+	newService := func(primaryURL string, providers ...config.EmbeddingProviderConfig) *EmbeddingService {
+		llmConfig := config.LLMConfig{
+			EmbeddingModel:     "text-embedding",
+			EmbeddingProviders: providers,
+		}
+		return &EmbeddingService{
+			httpClient:         resty.New().SetBaseURL(primaryURL),
+			config:             llmConfig,
+			truncationStrategy: TruncationHead,
+			logger:             logger.NewLogger("embedding-service-test"),
+			fallbacks:          newEmbeddingProviders(llmConfig),
+		}
+	}
+
+	t.Run("主服务出错时使用备用服务并记录来源", func(t *testing.T) {
+		var primaryCalls, localCalls int32
+		primary := newEmbeddingServer(t, http.StatusTooManyRequests, nil, &primaryCalls)
+		local := newEmbeddingServer(t, http.StatusOK, []float32{0.1, 0.2, 0.3}, &localCalls)
+		service := newService(primary.URL, config.EmbeddingProviderConfig{Name: "local", APIBase: local.URL, Model: "local-embedding"})
+
+		result, err := service.GenerateEmbedding(ctx, &EmbeddingRequest{Text: "向量检索入门", ContentType: models.ContentTypeText})
+		require.NoError(t, err)
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, result.Vector)
+		assert.Equal(t, "local", result.Provider)
+		assert.Equal(t, "local-embedding", result.Model)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&primaryCalls))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&localCalls))
+
+		doc, err := service.CreateContentVector(ctx, &models.ContentItem{ID: "doc-1", Type: models.ContentTypeText, RawContent: "向量检索入门", UserID: "user-1"})
+		require.NoError(t, err)
+		assert.Equal(t, "local", doc.Metadata[MetadataKeyEmbeddingProvider])
+	})
+
+	t.Run("主服务正常时不调用备用服务", func(t *testing.T) {
+		var primaryCalls, localCalls int32
+		primary := newEmbeddingServer(t, http.StatusOK, []float32{1, 0, 0}, &primaryCalls)
+		local := newEmbeddingServer(t, http.StatusOK, []float32{0, 1, 0}, &localCalls)
+		service := newService(primary.URL, config.EmbeddingProviderConfig{Name: "local", APIBase: local.URL})
+
+		result, err := service.GenerateEmbedding(ctx, &EmbeddingRequest{Text: "向量检索入门", ContentType: models.ContentTypeText})
+		require.NoError(t, err)
+		assert.Equal(t, PrimaryEmbeddingProvider, result.Provider)
+		assert.Equal(t, "text-embedding", result.Model)
+		assert.Zero(t, atomic.LoadInt32(&localCalls))
+	})
+
+	t.Run("跳过维度不一致的备用服务", func(t *testing.T) {
+		var primaryCalls, wideCalls, localCalls int32
+		primary := newEmbeddingServer(t, http.StatusServiceUnavailable, nil, &primaryCalls)
+		wide := newEmbeddingServer(t, http.StatusOK, []float32{0.1, 0.2, 0.3, 0.4, 0.5}, &wideCalls)
+		local := newEmbeddingServer(t, http.StatusOK, []float32{0.1, 0.2, 0.3}, &localCalls)
+		service := newService(primary.URL,
+			config.EmbeddingProviderConfig{Name: "wide", APIBase: wide.URL, Model: "wide-embedding"},
+			config.EmbeddingProviderConfig{Name: "local", APIBase: local.URL})
+		// 集合中已有3维向量
+		service.dimensions.seed("existing collection", 3)
+
+		result, err := service.GenerateEmbedding(ctx, &EmbeddingRequest{Text: "向量检索入门", ContentType: models.ContentTypeText})
+		require.NoError(t, err)
+		assert.Equal(t, "local", result.Provider)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&wideCalls))
+	})
+
+	t.Run("全部失败时返回错误", func(t *testing.T) {
+		var primaryCalls, localCalls int32
+		primary := newEmbeddingServer(t, http.StatusInternalServerError, nil, &primaryCalls)
+		local := newEmbeddingServer(t, http.StatusInternalServerError, nil, &localCalls)
+		service := newService(primary.URL, config.EmbeddingProviderConfig{Name: "local", APIBase: local.URL})
+
+		_, err := service.GenerateEmbedding(ctx, &EmbeddingRequest{Text: "向量检索入门", ContentType: models.ContentTypeText})
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&localCalls))
+	})
+}
+
+// TestEmbeddingService_DimensionReference 测试参考维度来自已有集合或主服务，不由备用服务决定
+func TestEmbeddingService_DimensionReference(t *testing.T) {
+	ctx := context.Background()
+	newService := func(primaryURL string, providers ...config.EmbeddingProviderConfig) *EmbeddingService {
+		llmConfig := config.LLMConfig{EmbeddingModel: "text-embedding", EmbeddingProviders: providers}
+		return &EmbeddingService{
+			httpClient:         resty.New().SetBaseURL(primaryURL),
+			config:             llmConfig,
+			truncationStrategy: TruncationHead,
+			logger:             logger.NewLogger("embedding-service-test"),
+			fallbacks:          newEmbeddingProviders(llmConfig),
+		}
+	}
+
+	t.Run("集合为空时备用服务的向量不作为参考维度", func(t *testing.T) {
+		var primaryCalls, wideCalls int32
+		primaryStatus := http.StatusServiceUnavailable
+		primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&primaryCalls, 1)
+			if primaryStatus != http.StatusOK {
+				http.Error(w, `{"error":"unavailable"}`, primaryStatus)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"data":  []map[string]interface{}{{"embedding": []float32{1, 0, 0}}},
+				"usage": map[string]int{"total_tokens": 4},
+			}))
+		}))
+		t.Cleanup(primary.Close)
+		wide := newEmbeddingServer(t, http.StatusOK, []float32{0.1, 0.2, 0.3, 0.4, 0.5}, &wideCalls)
+		service := newService(primary.URL, config.EmbeddingProviderConfig{Name: "wide", APIBase: wide.URL})
+
+		result, err := service.GenerateEmbedding(ctx, &EmbeddingRequest{Text: "第一次请求", ContentType: models.ContentTypeText})
+		require.NoError(t, err)
+		assert.Equal(t, "wide", result.Provider)
+		assert.Zero(t, service.dimensions.dimension)
+
+		primaryStatus = http.StatusOK
+		result, err = service.GenerateEmbedding(ctx, &EmbeddingRequest{Text: "主服务恢复", ContentType: models.ContentTypeText})
+		require.NoError(t, err)
+		assert.Equal(t, PrimaryEmbeddingProvider, result.Provider)
+		assert.Equal(t, 3, service.dimensions.dimension)
+	})
+
+	t.Run("从已有集合加载参考维度", func(t *testing.T) {
+		require.NoError(t, config.InitializeForTest(&config.Config{
+			LLM: config.LLMConfig{APIBase: "http://127.0.0.1:1", EmbeddingModel: "text-embedding"},
+		}))
+		store := NewMemoryStore()
+		require.NoError(t, store.AddDocument(ctx, &VectorDocument{
+			ID: "existing", Content: "已有文档", Embedding: []float32{0.1, 0.2, 0.3, 0.4},
+			Metadata: map[string]interface{}{"user_id": "user-1"},
+		}))
+
+		engine, err := NewSearchEngineWithStore(store)
+		require.NoError(t, err)
+		t.Cleanup(func() { engine.Close() })
+
+		service := engine.embeddingService.(*EmbeddingService)
+		assert.Equal(t, 4, service.dimensions.dimension)
+		assert.Error(t, service.dimensions.check("text-embedding", 3, true))
+	})
+}
+
+// TestSearchEngine_EmbeddingProviderConsistency 测试检索只比较与查询向量由同一服务生成的文档向量
+func TestSearchEngine_EmbeddingProviderConsistency(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	doc := func(id, provider string) *VectorDocument {
+		metadata := map[string]interface{}{"user_id": "user-1"}
+		if provider != "" {
+			metadata[MetadataKeyEmbeddingProvider] = provider
+		}
+		return &VectorDocument{ID: id, Content: "向量检索 " + id, Embedding: []float32{1, 0, 0}, Metadata: metadata}
+	}
+	require.NoError(t, store.AddDocuments(ctx, []*VectorDocument{
+		doc("legacy", ""),
+		doc("primary", PrimaryEmbeddingProvider),
+		doc("local", "local"),
+	}))
+
+	search := func(t *testing.T, provider string, fallbacks []string) []string {
+		embedder := new(MockEmbeddingService)
+		embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+			Return(&EmbeddingResult{Vector: []float32{1, 0, 0}, Dimension: 3, Provider: provider}, nil)
+		engine := newTestSearchEngine(t, embedder)
+		engine.store = store
+		engine.embeddingFallbacks = fallbacks
+
+		response, err := engine.Search(ctx, &SearchOptions{Query: "向量检索", UserID: "user-1", TopK: 10})
+		require.NoError(t, err)
+		ids := make([]string, 0, len(response.Results))
+		for _, result := range response.Results {
+			ids = append(ids, result.DocumentID)
+		}
+		return ids
+	}
+
+	t.Run("主服务的查询向量不匹配备用服务的文档", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"legacy", "primary"}, search(t, PrimaryEmbeddingProvider, []string{"local"}))
+	})
+
+	t.Run("备用服务的查询向量只匹配该服务的文档", func(t *testing.T) {
+		assert.Equal(t, []string{"local"}, search(t, "local", []string{"local"}))
+	})
+
+	t.Run("未配置备用服务时不限制", func(t *testing.T) {
+		assert.Len(t, search(t, PrimaryEmbeddingProvider, nil), 3)
+	})
+}
@@ -0,0 +1,116 @@
+package vector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"memoro/internal/config"
+)
+
+// 未配置时的近期向量复用参数
+const (
+	defaultEmbeddingReuseTTL        = 5 * time.Minute
+	defaultEmbeddingReuseMaxEntries = 1000
+)
+
+// embeddingReuseEntry 近期生成的向量
+type embeddingReuseEntry struct {
+	vector    []float32
+	expiresAt time.Time
+}
+
+// embeddingReuseCache 按内容哈希复用近期生成的向量：同一批次和短时间内的相同文本（如多个用户粘贴的同一段话）只调用一次向量化API。
+// 只在内存中短期保存，与持久化的文档向量无关
+type embeddingReuseCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*embeddingReuseEntry
+}
+
+// newEmbeddingReuseCache 创建近期向量复用缓存，配置为禁用时返回nil
+func newEmbeddingReuseCache(cfg config.EmbeddingReuseConfig) *embeddingReuseCache {
+	if cfg.Disabled {
+		return nil
+	}
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultEmbeddingReuseTTL
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultEmbeddingReuseMaxEntries
+	}
+
+	return &embeddingReuseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*embeddingReuseEntry),
+	}
+}
+
+// embeddingReuseKey 计算复用键（模型和预处理后的文本共同决定向量）
+func embeddingReuseKey(model, processedText string) string {
+	hash := sha256.Sum256([]byte(model + "\x00" + processedText))
+	return hex.EncodeToString(hash[:])
+}
+
+// get 获取未过期的向量副本
+func (c *embeddingReuseCache) get(key string) ([]float32, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	vector := make([]float32, len(entry.vector))
+	copy(vector, entry.vector)
+	return vector, true
+}
+
+// set 保存向量，超出容量时先清理过期条目，仍然超出时淘汰最早过期的条目
+func (c *embeddingReuseCache) set(key string, vector []float32) {
+	if c == nil || len(vector) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		var oldestKey string
+		var oldestExpiry time.Time
+		for entryKey, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, entryKey)
+				continue
+			}
+			if oldestKey == "" || entry.expiresAt.Before(oldestExpiry) {
+				oldestKey = entryKey
+				oldestExpiry = entry.expiresAt
+			}
+		}
+		if len(c.entries) >= c.maxEntries && oldestKey != "" {
+			delete(c.entries, oldestKey)
+		}
+	}
+
+	stored := make([]float32, len(vector))
+	copy(stored, vector)
+	c.entries[key] = &embeddingReuseEntry{vector: stored, expiresAt: now.Add(c.ttl)}
+}
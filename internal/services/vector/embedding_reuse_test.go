@@ -0,0 +1,108 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+)
+
+// TestEmbeddingService_Reuse 测试相同文本在批量和单个索引中复用近期生成的向量
+func TestEmbeddingService_Reuse(t *testing.T) {
+	var apiCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2,0.3]}],"model":"test-embedding","usage":{"prompt_tokens":5,"total_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	newService := func(reuseConfig config.EmbeddingReuseConfig) *EmbeddingService {
+		return &EmbeddingService{
+			httpClient:         resty.New().SetBaseURL(server.URL),
+			config:             config.LLMConfig{Model: "test-embedding"},
+			truncationStrategy: TruncationHead,
+			logger:             logger.NewLogger("embedding-service-test"),
+			recent:             newEmbeddingReuseCache(reuseConfig),
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("批量索引中的重复内容只向量化一次", func(t *testing.T) {
+		atomic.StoreInt32(&apiCalls, 0)
+		engine := newTestSearchEngine(t, newService(config.EmbeddingReuseConfig{}))
+		engine.store = NewMemoryStore()
+
+		// 100个内容项，其中40个与前面的内容重复
+		items := make([]*models.ContentItem, 0, 100)
+		for i := 0; i < 100; i++ {
+			text := fmt.Sprintf("第%d段引用的内容", i)
+			if i >= 60 {
+				text = fmt.Sprintf("第%d段引用的内容", i%20)
+			}
+			items = append(items, &models.ContentItem{
+				ID:         fmt.Sprintf("doc-%d", i),
+				Type:       models.ContentTypeText,
+				RawContent: text,
+				UserID:     fmt.Sprintf("user-%d", i%7),
+			})
+		}
+
+		report, err := engine.BatchIndexDocuments(ctx, items)
+		require.NoError(t, err)
+
+		assert.Len(t, report.SucceededIDs, 100)
+		assert.Equal(t, int32(60), atomic.LoadInt32(&apiCalls))
+
+		// 单个索引路径同样复用
+		require.NoError(t, engine.IndexDocument(ctx, &models.ContentItem{
+			ID: "doc-single", Type: models.ContentTypeText, RawContent: "第3段引用的内容", UserID: "user-1",
+		}))
+		assert.Equal(t, int32(60), atomic.LoadInt32(&apiCalls))
+
+		doc, err := engine.GetDocument(ctx, "doc-single")
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", doc.Metadata["user_id"])
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, doc.Embedding)
+	})
+
+	t.Run("超过复用时间窗口后重新向量化", func(t *testing.T) {
+		atomic.StoreInt32(&apiCalls, 0)
+		service := newService(config.EmbeddingReuseConfig{TTL: 20 * time.Millisecond})
+		request := &EmbeddingRequest{Text: "同一段话", ContentType: models.ContentTypeText}
+
+		_, err := service.GenerateEmbedding(ctx, request)
+		require.NoError(t, err)
+		_, err = service.GenerateEmbedding(ctx, request)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&apiCalls))
+
+		time.Sleep(30 * time.Millisecond)
+		_, err = service.GenerateEmbedding(ctx, request)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&apiCalls))
+	})
+
+	t.Run("关闭复用时每次都调用API", func(t *testing.T) {
+		atomic.StoreInt32(&apiCalls, 0)
+		service := newService(config.EmbeddingReuseConfig{Disabled: true})
+		request := &EmbeddingRequest{Text: "同一段话", ContentType: models.ContentTypeText}
+
+		for i := 0; i < 3; i++ {
+			_, err := service.GenerateEmbedding(ctx, request)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&apiCalls))
+	})
+}
@@ -0,0 +1,409 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+	"memoro/internal/services/llm"
+	"memoro/internal/services/preprocess"
+)
+
+// TestEmbeddingService_TokenBudget 测试token预算限制向量化调用
+func TestEmbeddingService_TokenBudget(t *testing.T) {
+	var apiCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2,0.3]}],"model":"test-embedding","usage":{"prompt_tokens":60,"total_tokens":60}}`)
+	}))
+	defer server.Close()
+
+	budget := llm.NewTokenBudget(config.TokenBudgetConfig{UserWindowLimit: 100})
+	service := &EmbeddingService{
+		httpClient:         resty.New().SetBaseURL(server.URL),
+		config:             config.LLMConfig{Model: "test-embedding"},
+		truncationStrategy: TruncationHead,
+		budget:             budget,
+		logger:             logger.NewLogger("embedding-service-test"),
+	}
+
+	newRequest := func(userID string) *EmbeddingRequest {
+		return &EmbeddingRequest{
+			Text:        "向量化测试文本",
+			ContentType: models.ContentTypeText,
+			Metadata:    map[string]interface{}{"user_id": userID},
+		}
+	}
+	ctx := context.Background()
+
+	_, err := service.GenerateEmbedding(ctx, newRequest("user-1"))
+	require.NoError(t, err)
+	_, err = service.GenerateEmbedding(ctx, newRequest("user-1"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(120), budget.Usage("user-1").WindowTokens)
+
+	// 超出额度后不再调用API
+	_, err = service.GenerateEmbedding(ctx, newRequest("user-1"))
+	require.Error(t, err)
+	memoErr, ok := err.(*errors.MemoroError)
+	require.True(t, ok)
+	assert.True(t, memoErr.IsCode(errors.ErrCodeBudgetExceeded))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&apiCalls))
+
+	// 其他用户仍可调用
+	_, err = service.GenerateEmbedding(ctx, newRequest("user-2"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(180), budget.Usage("").TotalTokens)
+}
+
+// TestPreprocessing_QueryAndDocumentPaths 测试查询与文档使用相同的预处理，等价输入得到相同文本
+func TestPreprocessing_QueryAndDocumentPaths(t *testing.T) {
+	preprocessor, err := preprocess.NewPreprocessor(config.PreprocessingConfig{
+		Steps: []string{"normalize_unicode", "strip_control_chars", "collapse_whitespace", "trim", "lowercase"},
+	})
+	require.NoError(t, err)
+
+	embeddingService := &EmbeddingService{preprocessor: preprocessor}
+	engine := &SearchEngine{preprocessor: preprocessor}
+
+	document := "Cafe\u0301  Go\n\n并发\u200b编程\x00 "
+	query := " CAFÉ go 并发编程"
+
+	assert.Equal(t, "café go 并发编程", embeddingService.preprocessText(document, models.ContentTypeText))
+	assert.Equal(t, embeddingService.preprocessText(document, models.ContentTypeText), engine.preprocessQuery(query))
+
+	t.Run("未配置时使用默认步骤", func(t *testing.T) {
+		embeddingService := &EmbeddingService{}
+		engine := &SearchEngine{}
+		assert.Equal(t, "Café Go 并发编程", embeddingService.preprocessText(document, models.ContentTypeText))
+		assert.Equal(t, "Café Go 并发编程", engine.preprocessQuery("Café Go\t并发编程"))
+	})
+}
+
+// TestEmbeddingService_ContentTypeModels 测试按内容类型选择向量化模型
+func TestEmbeddingService_ContentTypeModels(t *testing.T) {
+	var mu sync.Mutex
+	var requestedModels []string
+	dimensions := map[string]int{"code-embedding": 3, "text-embedding": 3, "wide-embedding": 5}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		requestedModels = append(requestedModels, body.Model)
+		mu.Unlock()
+
+		vector := make([]float32, dimensions[body.Model])
+		for i := range vector {
+			vector[i] = 0.1
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":  []map[string]interface{}{{"embedding": vector}},
+			"model": body.Model,
+			"usage": map[string]int{"total_tokens": 5},
+		}))
+	}))
+	t.Cleanup(server.Close)
+
+	newService := func(typeModels map[string]string) *EmbeddingService {
+		return &EmbeddingService{
+			httpClient: resty.New().SetBaseURL(server.URL),
+			config: config.LLMConfig{
+				Model:           "chat-model",
+				EmbeddingModel:  "text-embedding",
+				EmbeddingModels: typeModels,
+			},
+			truncationStrategy: TruncationHead,
+			logger:             logger.NewLogger("embedding-service-test"),
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("code类型使用代码模型，文本使用默认模型", func(t *testing.T) {
+		requestedModels = nil
+		service := newService(map[string]string{"code": "code-embedding"})
+
+		codeResult, err := service.GenerateEmbedding(ctx, &EmbeddingRequest{Text: "func main() {}", ContentType: "code"})
+		require.NoError(t, err)
+		textResult, err := service.GenerateEmbedding(ctx, &EmbeddingRequest{Text: "向量检索入门", ContentType: models.ContentTypeText})
+		require.NoError(t, err)
+
+		assert.Equal(t, "code-embedding", codeResult.Model)
+		assert.Equal(t, "text-embedding", textResult.Model)
+		assert.Equal(t, []string{"code-embedding", "text-embedding"}, requestedModels)
+	})
+
+	t.Run("未配置默认模型时使用text-embedding-ada-002", func(t *testing.T) {
+		service := newService(nil)
+		service.config.EmbeddingModel = ""
+		assert.Equal(t, defaultEmbeddingModel, service.embeddingModelFor(models.ContentTypeText))
+	})
+
+	t.Run("拒绝与已有向量维度不同的模型", func(t *testing.T) {
+		service := newService(map[string]string{"image": "wide-embedding"})
+
+		_, err := service.GenerateEmbedding(ctx, &EmbeddingRequest{Text: "向量检索入门", ContentType: models.ContentTypeText})
+		require.NoError(t, err)
+		_, err = service.GenerateEmbedding(ctx, &EmbeddingRequest{Text: "图片转写文本", ContentType: models.ContentTypeImage})
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeConfigInvalid))
+	})
+}
+
+// TestEmbeddingService_MinInput 测试向量化输入不足时提前返回，不调用API
+func TestEmbeddingService_MinInput(t *testing.T) {
+	var apiCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2,0.3]}],"model":"test-embedding","usage":{"prompt_tokens":5,"total_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	service := &EmbeddingService{
+		httpClient: resty.New().SetBaseURL(server.URL),
+		config: config.LLMConfig{
+			Model: "test-embedding",
+			EmbeddingMinInput: config.EmbeddingMinInputConfig{
+				ContentTypes: map[string]config.EmbeddingInputThreshold{
+					string(models.ContentTypeImage): {MinChars: 4},
+				},
+			},
+		},
+		truncationStrategy: TruncationHead,
+		logger:             logger.NewLogger("embedding-service-test"),
+	}
+	ctx := context.Background()
+
+	newItem := func(contentType models.ContentType, content string) *models.ContentItem {
+		return &models.ContentItem{ID: "doc-1", Type: contentType, RawContent: content, UserID: "user-1"}
+	}
+
+	t.Run("OCR结果为空时跳过向量化", func(t *testing.T) {
+		atomic.StoreInt32(&apiCalls, 0)
+
+		_, err := service.CreateContentVector(ctx, newItem(models.ContentTypeImage, " \n\t "))
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeInsufficientInput))
+
+		_, err = service.CreateContentVector(ctx, newItem(models.ContentTypeImage, "a b"))
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeInsufficientInput))
+
+		assert.Equal(t, int32(0), atomic.LoadInt32(&apiCalls))
+	})
+
+	t.Run("正常文本继续向量化", func(t *testing.T) {
+		atomic.StoreInt32(&apiCalls, 0)
+
+		doc, err := service.CreateContentVector(ctx, newItem(models.ContentTypeText, "向量检索入门"))
+		require.NoError(t, err)
+		assert.Len(t, doc.Embedding, 3)
+
+		// 内容类型阈值只作用于对应类型
+		_, err = service.CreateContentVector(ctx, newItem(models.ContentTypeText, "ok"))
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&apiCalls))
+	})
+}
+
+// TestEmbeddingService_SensitiveContent 测试敏感内容的向量文档不包含原文和摘要明文
+func TestEmbeddingService_SensitiveContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2,0.3]}],"model":"test-embedding","usage":{"prompt_tokens":5,"total_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	service := &EmbeddingService{
+		httpClient:         resty.New().SetBaseURL(server.URL),
+		config:             config.LLMConfig{Model: "test-embedding"},
+		truncationStrategy: TruncationHead,
+		logger:             logger.NewLogger("embedding-service-test"),
+	}
+	newItem := func(sensitive bool) *models.ContentItem {
+		item := &models.ContentItem{ID: "doc-1", Type: models.ContentTypeText, RawContent: "体检报告：血压偏高", UserID: "user-1", Sensitive: sensitive}
+		item.Summary.OneLine = "血压偏高"
+		item.Summary.Paragraph = "体检显示血压偏高，需要复查"
+		return item
+	}
+
+	t.Run("敏感内容只保留向量和结构化元数据", func(t *testing.T) {
+		doc, err := service.CreateContentVector(context.Background(), newItem(true))
+		require.NoError(t, err)
+		assert.Len(t, doc.Embedding, 3)
+		assert.Empty(t, doc.Content)
+		assert.NotContains(t, doc.Metadata, "summary_oneline")
+		assert.NotContains(t, doc.Metadata, MetadataKeySummaryParagraph)
+		assert.Equal(t, true, doc.Metadata[MetadataKeySensitive])
+		assert.Equal(t, true, doc.Metadata[MetadataKeyHasSummary])
+	})
+
+	t.Run("普通内容保存原文和摘要", func(t *testing.T) {
+		doc, err := service.CreateContentVector(context.Background(), newItem(false))
+		require.NoError(t, err)
+		assert.Equal(t, "体检报告：血压偏高", doc.Content)
+		assert.Equal(t, "血压偏高", doc.Metadata["summary_oneline"])
+		assert.NotContains(t, doc.Metadata, MetadataKeySensitive)
+	})
+}
+
+// TestEmbeddingService_APIErrorClassification 测试embedding接口错误状态映射为具体错误码，只重试限流和服务端错误
+func TestEmbeddingService_APIErrorClassification(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		expectedCode  errors.ErrorCode
+		expectedCalls int32
+	}{
+		{
+			name:          "401认证失败不重试",
+			status:        http.StatusUnauthorized,
+			body:          `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`,
+			expectedCode:  errors.ErrCodeLLMAuth,
+			expectedCalls: 1,
+		},
+		{
+			name:          "429限流重试",
+			status:        http.StatusTooManyRequests,
+			body:          `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`,
+			expectedCode:  errors.ErrCodeLLMRateLimited,
+			expectedCalls: 3,
+		},
+		{
+			name:          "400无效请求不重试",
+			status:        http.StatusBadRequest,
+			body:          `{"error":{"message":"This model's maximum context length is 8192 tokens","type":"invalid_request_error"}}`,
+			expectedCode:  errors.ErrCodeLLMInvalidRequest,
+			expectedCalls: 1,
+		},
+		{
+			name:          "500服务端错误重试",
+			status:        http.StatusInternalServerError,
+			body:          `upstream error`,
+			expectedCode:  errors.ErrCodeLLMServerError,
+			expectedCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			httpClient := resty.New().
+				SetBaseURL(server.URL).
+				SetRetryCount(2).
+				SetRetryWaitTime(time.Millisecond).
+				SetRetryMaxWaitTime(time.Millisecond).
+				AddRetryCondition(llm.RetryCondition)
+			service := &EmbeddingService{
+				httpClient:         httpClient,
+				config:             config.LLMConfig{Model: "test-embedding"},
+				truncationStrategy: TruncationHead,
+				logger:             logger.NewLogger("embedding-service-test"),
+			}
+
+			_, err := service.GenerateEmbedding(context.Background(), &EmbeddingRequest{Text: "向量化测试文本", ContentType: models.ContentTypeText})
+			require.Error(t, err)
+			memoErr, ok := err.(*errors.MemoroError)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCode, memoErr.Code)
+			assert.Equal(t, tt.status, memoErr.Context.(map[string]interface{})["status_code"])
+			assert.Equal(t, tt.expectedCalls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+// TestEmbeddingService_NormalizeVectors 测试开启归一化后文档和查询向量为单位长度，文档与自身的余弦相似度为1
+func TestEmbeddingService_NormalizeVectors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[3,4,0]}],"model":"test-embedding","usage":{"prompt_tokens":3,"total_tokens":3}}`)
+	}))
+	defer server.Close()
+
+	newEngine := func(t *testing.T, normalize bool) *SearchEngine {
+		require.NoError(t, config.InitializeForTest(&config.Config{
+			LLM:      config.LLMConfig{APIBase: server.URL, APIKey: "test-key", Timeout: 5 * time.Second},
+			VectorDB: config.VectorDBConfig{NormalizeVectors: normalize},
+		}))
+		engine, err := NewSearchEngineWithStore(NewMemoryStore())
+		require.NoError(t, err)
+		t.Cleanup(func() { engine.Close() })
+		return engine
+	}
+	unitLength := func(vector []float32) float64 {
+		sum := 0.0
+		for _, value := range vector {
+			sum += float64(value) * float64(value)
+		}
+		return math.Sqrt(sum)
+	}
+	item := &models.ContentItem{ID: "doc-1", Type: models.ContentTypeText, RawContent: "向量归一化测试文本", UserID: "user-1"}
+	ctx := context.Background()
+
+	t.Run("文档和查询向量归一化为单位长度", func(t *testing.T) {
+		engine := newEngine(t, true)
+		require.NoError(t, engine.IndexDocument(ctx, item))
+
+		doc, err := engine.GetDocument(ctx, item.ID)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, unitLength(doc.Embedding), 1e-6)
+		assert.InDeltaSlice(t, []float32{0.6, 0.8, 0}, doc.Embedding, 1e-6)
+		assert.Equal(t, true, doc.Metadata[MetadataKeyVectorNormalized])
+
+		queryVector, _, err := engine.generateQueryVector(ctx, item.RawContent, &SearchOptions{UserID: "user-1"})
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, unitLength(queryVector), 1e-6)
+
+		cosine, err := engine.similarityCalc.CalculateCosine(doc.Embedding, doc.Embedding)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, cosine, 1e-6)
+		// 单位向量的点积与余弦一致
+		cosine, err = engine.similarityCalc.CalculateCosine(queryVector, doc.Embedding)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, cosine, 1e-6)
+	})
+
+	t.Run("未开启时保留原始向量", func(t *testing.T) {
+		engine := newEngine(t, false)
+		require.NoError(t, engine.IndexDocument(ctx, item))
+
+		doc, err := engine.GetDocument(ctx, item.ID)
+		require.NoError(t, err)
+		assert.InDeltaSlice(t, []float32{3, 4, 0}, doc.Embedding, 1e-6)
+		assert.Equal(t, false, doc.Metadata[MetadataKeyVectorNormalized])
+	})
+
+	t.Run("零向量无法归一化时原样返回", func(t *testing.T) {
+		vector, normalized := normalizeL2([]float32{0, 0, 0})
+		assert.False(t, normalized)
+		assert.Equal(t, []float32{0, 0, 0}, vector)
+	})
+}
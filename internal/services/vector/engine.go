@@ -3,24 +3,69 @@ package vector
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"memoro/internal/config"
 	"memoro/internal/errors"
 	"memoro/internal/logger"
 	"memoro/internal/models"
+	"memoro/internal/services/language"
+	"memoro/internal/services/llm"
+	"memoro/internal/services/preprocess"
+)
+
+// defaultDuplicateThreshold 未配置时折叠重复结果的默认相似度阈值
+const defaultDuplicateThreshold = 0.95
+
+// 未配置时查询词命中各元数据字段的默认加分
+const (
+	defaultTitleBoost   = 0.1
+	defaultSummaryBoost = 0.05
+	defaultTagsBoost    = 0.05
 )
 
 // SearchEngine 智能搜索引擎
 type SearchEngine struct {
-	chromaClient     *ChromaClient
-	embeddingService *EmbeddingService
+	store            VectorStore
+	embeddingService Embedder
 	similarityCalc   *SimilarityCalculator
 	cacheManager     *VectorCacheManager
+	ranker           *Ranker
+	searchFlights    *searchFlightGroup
+	searchLimiter    *searchLimiter // 搜索并发限制（nil时不限制）
 	config           config.VectorDBConfig
 	logger           *logger.Logger
+	auditLogger      *logger.AuditLogger
+
+	preprocessor *preprocess.Preprocessor // 查询文本预处理（nil时使用默认步骤）
+
+	embeddingFallbacks []string // 备用向量化服务名称，配置后检索只比较同一服务生成的向量
+
+	warmupCancel context.CancelFunc // 取消后台预热
+
+	evictionPolicy *EvictionPolicy // 超出配额时的淘汰策略
+	evictionLocks  keyedLocks      // 按用户串行化配额检查
+	dedupLocks     keyedLocks      // 按去重范围串行化重复检查和写入
+
+	archiveStore  VectorStore        // 归档文档的冷存储（nil时不支持归档）
+	archiveConfig ArchiveConfig      // 归档策略
+	archiveCancel context.CancelFunc // 停止后台归档
+
+	interactions *InteractionStore // 交互记录（淘汰策略和按访问调整重要性使用）
+
+	recommender     *Recommender // 共享的推荐系统（首次获取推荐时创建）
+	recommenderOnce sync.Once
+
+	relevanceCompleter RelevanceCompleter // LLM重排序的补全客户端（nil时LLM重排序不可用）
+
+	excludeLowQuality bool // 默认搜索跳过低质量提取的文档
+
+	defaultSimilarity SimilarityType // 未指定相似度类型时使用的类型（由集合距离函数推导或配置）
 }
 
 // SearchOptions 搜索选项
@@ -36,7 +81,36 @@ type SearchOptions struct {
 	Tags                []string             `json:"tags,omitempty"`                 // 标签过滤
 	ImportanceThreshold float64              `json:"importance_threshold,omitempty"` // 重要性阈值
 	EnableReranking     bool                 `json:"enable_reranking"`               // 启用重排序
+	RankingStrategy     RankingStrategy      `json:"ranking_strategy,omitempty"`     // 排序策略，为空时使用配置默认值
 	MaxResults          int                  `json:"max_results"`                    // 最大结果数量限制
+	CollapseDuplicates  bool                 `json:"collapse_duplicates,omitempty"`  // 折叠近似重复的结果
+	DuplicateThreshold  float64              `json:"duplicate_threshold,omitempty"`  // 重复判定的相似度阈值，为空时使用配置默认值
+	MinPerContentType   int                  `json:"min_per_content_type,omitempty"` // 前top_k中每种内容类型（指定content_types时为这些类型）至少保留的数量，为空时使用配置默认值
+	CollapseThreads     bool                 `json:"collapse_threads,omitempty"`     // 同一会话线程的消息折叠为一个结果（有线程汇总文档时以其为代表）
+
+	MetadataFilters map[string]interface{} `json:"metadata_filters,omitempty"` // 自定义元数据过滤（标量等值匹配，数组匹配任一元素）
+
+	// 处理完整性过滤：true只返回具备该项的文档，false只返回缺少该项的文档，为空时不过滤
+	RequireSummary *bool `json:"require_summary,omitempty"` // 是否有摘要
+	RequireTags    *bool `json:"require_tags,omitempty"`    // 是否有标签
+	RequireIndexed *bool `json:"require_indexed,omitempty"` // 是否已生成向量
+
+	IncludeLowQuality bool `json:"include_low_quality,omitempty"` // 包含低质量提取的文档（配置为默认排除时生效）
+
+	Languages              []string `json:"languages,omitempty"`                // 语言过滤（语言代码，如zh、en）
+	IncludeUnknownLanguage bool     `json:"include_unknown_language,omitempty"` // 指定语言过滤时也包含没有语言信息的文档（如语言字段加入前索引的文档）
+
+	EnableLLMRerank bool `json:"enable_llm_rerank,omitempty"` // 启发式排序后由LLM为前N个结果打相关性分数并重排（N受配置上限约束），失败时保持启发式排序
+
+	Fields []string `json:"fields,omitempty"` // 只返回指定字段（如document_id、similarity、title、summary），未请求content时不从向量库获取原文，为空时返回全部字段
+
+	Mode SearchMode `json:"mode,omitempty"` // 检索模式（vector、hybrid、keyword），为空时使用配置默认值
+
+	SnippetSource SnippetSource `json:"snippet_source,omitempty"` // 结果片段来源（computed、summary），为空时使用配置默认值
+
+	IncludeArchived bool `json:"include_archived,omitempty"` // 同时检索冷存储中的归档文档（按需查询，默认不包含）
+
+	limitWarnings []string // 超出服务端上限被截断的提示
 }
 
 // TimeRange 时间范围
@@ -58,20 +132,89 @@ type SearchResponse struct {
 
 // SearchResultItem 搜索结果项
 type SearchResultItem struct {
-	DocumentID      string                 `json:"document_id"`       // 文档ID
-	Content         string                 `json:"content,omitempty"` // 文档内容
-	Similarity      float64                `json:"similarity"`        // 相似度分数
-	Distance        float32                `json:"distance"`          // 向量距离
-	Rank            int                    `json:"rank"`              // 排名
-	Metadata        map[string]interface{} `json:"metadata"`          // 文档元数据
-	RelevanceScore  float64                `json:"relevance_score"`   // 综合相关性分数
-	MatchedKeywords []string               `json:"matched_keywords"`  // 匹配的关键词
-	ContentSummary  string                 `json:"content_summary"`   // 内容摘要
-	CreatedAt       time.Time              `json:"created_at"`        // 创建时间
-}
-
-// NewSearchEngine 创建搜索引擎
+	DocumentID      string                 `json:"document_id"`            // 文档ID
+	Content         string                 `json:"content,omitempty"`      // 文档内容
+	Similarity      float64                `json:"similarity"`             // 相似度分数（即归一化分数，保留以兼容旧客户端）
+	Distance        float32                `json:"distance"`               // 向量库返回的原始距离
+	RawSimilarity   float64                `json:"raw_similarity"`         // 相似度类型的原始输出（余弦值、点积或欧氏/曼哈顿距离）
+	NormalizedScore float64                `json:"normalized_score"`       // 归一化到0-1的相似度，各相似度类型可用同一阈值比较
+	SimilarityType  SimilarityType         `json:"similarity_type"`        // 计算相似度使用的类型
+	Rank            int                    `json:"rank"`                   // 排名
+	Metadata        map[string]interface{} `json:"metadata"`               // 文档元数据
+	RelevanceScore  float64                `json:"relevance_score"`        // 综合相关性分数
+	MatchedKeywords []string               `json:"matched_keywords"`       // 匹配的关键词
+	ContentSummary  string                 `json:"content_summary"`        // 内容摘要
+	CreatedAt       time.Time              `json:"created_at"`             // 创建时间
+	DuplicateOf     []string               `json:"duplicate_of,omitempty"` // 被折叠到该结果的近似重复文档ID
+	Embedding       []float32              `json:"-"`                      // 文档向量（仅用于折叠重复结果）
+
+	ThreadMessages []string `json:"thread_messages,omitempty"` // 被折叠到该线程结果的消息文档ID
+
+	ScoreBreakdown *ScoreBreakdown `json:"score_breakdown,omitempty"` // 指定排序策略时的分数分解
+
+	LLMRelevanceScore *float64 `json:"llm_relevance_score,omitempty"` // LLM重排序给出的相关性分数(0-1)
+
+	KeywordScore float64 `json:"keyword_score,omitempty"` // 关键词检索的BM25分数（混合或关键词模式下命中时）
+	FusionScore  float64 `json:"fusion_score,omitempty"`  // 倒数排名融合分数（混合或关键词模式）
+
+	projectedKeys map[string]bool // 指定投影时序列化的JSON键（只读，可在结果副本间共享）
+}
+
+// BatchIndexStage 批量索引的失败阶段
+type BatchIndexStage string
+
+const (
+	BatchIndexStageEmbedding BatchIndexStage = "embedding" // 向量化失败
+	BatchIndexStageChroma    BatchIndexStage = "chroma"    // 写入向量数据库失败
+)
+
+// BatchIndexFailure 单个文档的索引失败信息
+type BatchIndexFailure struct {
+	DocumentID string          `json:"document_id"` // 文档ID
+	Stage      BatchIndexStage `json:"stage"`       // 失败阶段
+	Reason     string          `json:"reason"`      // 失败原因
+}
+
+// BatchIndexReport 批量索引报告
+type BatchIndexReport struct {
+	Total         int                  `json:"total"`          // 提交的文档数
+	SucceededIDs  []string             `json:"succeeded_ids"`  // 索引成功的文档ID
+	Failures      []*BatchIndexFailure `json:"failures"`       // 索引失败的文档
+	EmbeddingTime time.Duration        `json:"embedding_time"` // 向量化耗时
+	IndexTime     time.Duration        `json:"index_time"`     // 写入向量数据库耗时
+	TotalTime     time.Duration        `json:"total_time"`     // 总耗时
+}
+
+// HasFailures 是否存在失败的文档
+func (r *BatchIndexReport) HasFailures() bool {
+	return len(r.Failures) > 0
+}
+
+// FailedIDs 获取失败的文档ID，便于调用方重试
+func (r *BatchIndexReport) FailedIDs() []string {
+	ids := make([]string, 0, len(r.Failures))
+	for _, failure := range r.Failures {
+		ids = append(ids, failure.DocumentID)
+	}
+	return ids
+}
+
+// addFailure 记录失败的文档
+func (r *BatchIndexReport) addFailure(documentID string, stage BatchIndexStage, err error) {
+	r.Failures = append(r.Failures, &BatchIndexFailure{
+		DocumentID: documentID,
+		Stage:      stage,
+		Reason:     err.Error(),
+	})
+}
+
+// NewSearchEngine 创建使用Chroma存储的搜索引擎
 func NewSearchEngine() (*SearchEngine, error) {
+	return NewSearchEngineWithStore(nil)
+}
+
+// NewSearchEngineWithStore 创建使用指定向量存储的搜索引擎，store为nil时连接配置的Chroma
+func NewSearchEngineWithStore(store VectorStore) (*SearchEngine, error) {
 	cfg := config.Get()
 	if cfg == nil {
 		return nil, errors.ErrConfigMissing("search engine config")
@@ -79,8 +222,33 @@ func NewSearchEngine() (*SearchEngine, error) {
 
 	searchLogger := logger.NewLogger("search-engine")
 
-	// 初始化Chroma客户端
-	chromaClient, err := NewChromaClient()
+	// 验证默认排序策略
+	if cfg.VectorDB.DefaultRankingStrategy != "" && !IsValidRankingStrategy(RankingStrategy(cfg.VectorDB.DefaultRankingStrategy)) {
+		return nil, errors.ErrConfigInvalid("vector_db.default_ranking_strategy",
+			fmt.Sprintf("unknown ranking strategy: %s", cfg.VectorDB.DefaultRankingStrategy))
+	}
+
+	// 未注入存储时初始化Chroma客户端，启用归档时冷存储使用独立的集合
+	archiveConfig := archiveConfigFrom(cfg)
+	var archiveStore VectorStore
+	if store == nil {
+		chromaClient, err := NewChromaClient()
+		if err != nil {
+			return nil, err
+		}
+		store = chromaClient
+
+		if archiveConfig.Enabled {
+			archiveClient, err := NewChromaClientForCollection(archiveConfig.Collection)
+			if err != nil {
+				return nil, err
+			}
+			archiveStore = archiveClient
+		}
+	}
+
+	// 默认相似度类型与集合距离函数保持一致
+	defaultSimilarity, err := defaultSimilarityTypeFor(cfg.VectorDB.SimilarityType, store)
 	if err != nil {
 		return nil, err
 	}
@@ -91,6 +259,19 @@ func NewSearchEngine() (*SearchEngine, error) {
 		return nil, err
 	}
 
+	// 维度参考取自已有集合，重启后不由首个响应的服务决定
+	if dimension, err := store.GetVectorDimension(context.Background()); err != nil {
+		searchLogger.Warn("Failed to load vector dimension from collection", logger.Fields{"error": err.Error()})
+	} else {
+		embeddingService.dimensions.seed("existing collection", dimension)
+	}
+
+	// 初始化查询预处理器
+	preprocessor, err := preprocess.NewPreprocessor(cfg.Processing.Preprocessing)
+	if err != nil {
+		return nil, err
+	}
+
 	// 初始化相似度计算器
 	similarityCalc := NewSimilarityCalculator()
 
@@ -98,12 +279,33 @@ func NewSearchEngine() (*SearchEngine, error) {
 	cacheManager := NewVectorCacheManager(cfg)
 
 	engine := &SearchEngine{
-		chromaClient:     chromaClient,
+		store:            store,
 		embeddingService: embeddingService,
 		similarityCalc:   similarityCalc,
 		cacheManager:     cacheManager,
+		ranker:           NewRanker(),
+		searchFlights:    newSearchFlightGroup(),
+		searchLimiter:    newSearchLimiter(cfg.VectorDB.SearchLimits),
 		config:           cfg.VectorDB,
 		logger:           searchLogger,
+		auditLogger:      logger.GetAuditLogger(),
+		preprocessor:     preprocessor,
+		evictionPolicy:   NewEvictionPolicy(evictionConfigFrom(cfg), nil),
+		archiveStore:     archiveStore,
+		archiveConfig:    archiveConfig,
+
+		excludeLowQuality:  cfg.Processing.ExtractionQuality.ExcludeFromSearch,
+		defaultSimilarity:  defaultSimilarity,
+		embeddingFallbacks: embeddingProviderNames(cfg.LLM),
+	}
+
+	// LLM重排序客户端（未配置LLM时不可用，启用LLM重排序的搜索退回启发式排序）
+	if cfg.LLM.APIBase != "" && cfg.LLM.Model != "" {
+		if llmClient, err := llm.NewClient(); err == nil {
+			engine.relevanceCompleter = llmClient
+		} else {
+			searchLogger.Warn("LLM client unavailable, LLM rerank disabled", logger.Fields{"error": err.Error()})
+		}
 	}
 
 	searchLogger.Info("Search engine initialized", logger.Fields{
@@ -112,6 +314,12 @@ func NewSearchEngine() (*SearchEngine, error) {
 		"collection":     cfg.VectorDB.Collection,
 	})
 
+	// 后台预热查询向量缓存
+	engine.startWarmup(warmupConfigFrom(cfg))
+
+	// 按间隔自动归档
+	engine.startArchival()
+
 	return engine, nil
 }
 
@@ -136,37 +344,187 @@ func (se *SearchEngine) Search(ctx context.Context, options *SearchOptions) (*Se
 		"enable_reranking": options.EnableReranking,
 	})
 
-	// 设置默认值
+	if err := se.applySearchDefaults(options); err != nil {
+		return nil, err
+	}
+
+	// 1. 预处理查询文本
+	processedQuery := se.preprocessQuery(options.Query)
+
+	// 合并并发的相同搜索请求，共享一次向量化和向量检索
+	flightKey := generateSearchFlightKey(processedQuery, options)
+
+	// 版本在检索前读取，检索期间发生的索引变更会使本次结果在写入缓存后立即失效
+	version := se.collectionVersion()
+	if cached, ok := se.cachedSearchResult(flightKey, version); ok {
+		llm.RecordCacheHit(ctx)
+		se.logger.Debug("Search result served from cache", logger.Fields{
+			"query":      processedQuery,
+			"flight_key": flightKey,
+		})
+		return cached, nil
+	}
+
+	response, err, shared := se.searchFlights.Do(ctx, flightKey, func(ctx context.Context) (*SearchResponse, error) {
+		// 只有实际执行检索的请求占用并发名额，合并的请求等待同一结果
+		release, err := se.searchLimiter.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		response, err := se.executeSearch(ctx, processedQuery, nil, options, startTime)
+		if err == nil {
+			se.cacheSearchResult(flightKey, version, response)
+		}
+		return response, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if shared {
+		se.logger.Debug("Search request coalesced", logger.Fields{
+			"query":      processedQuery,
+			"flight_key": flightKey,
+		})
+		return se.copySharedResponse(response), nil
+	}
+
+	return response, nil
+}
+
+// SearchByVector 使用调用方提供的向量直接检索，跳过查询文本预处理和向量化
+func (se *SearchEngine) SearchByVector(ctx context.Context, queryVector []float32, options *SearchOptions) (*SearchResponse, error) {
+	if len(queryVector) == 0 {
+		return nil, errors.ErrValidationFailed("query_vector", "cannot be empty")
+	}
+
+	for _, v := range queryVector {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return nil, errors.ErrValidationFailed("query_vector", "must contain only finite values")
+		}
+	}
+
+	if options == nil {
+		options = &SearchOptions{}
+	}
+
+	startTime := time.Now()
+
+	se.logger.Info("Executing vector search", logger.Fields{
+		"vector_length":  len(queryVector),
+		"top_k":          options.TopK,
+		"min_similarity": options.MinSimilarity,
+		"content_types":  options.ContentTypes,
+		"user_id":        options.UserID,
+	})
+
+	// 校验向量维度与索引一致
+	dimension, err := se.store.GetVectorDimension(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dimension > 0 && len(queryVector) != dimension {
+		return nil, errors.ErrValidationFailed("query_vector",
+			fmt.Sprintf("dimension mismatch: expected %d, got %d", dimension, len(queryVector)))
+	}
+
+	if err := se.applySearchDefaults(options); err != nil {
+		return nil, err
+	}
+
+	release, err := se.searchLimiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return se.executeSearch(ctx, strings.TrimSpace(options.Query), queryVector, options, startTime)
+}
+
+// applySearchDefaults 设置搜索选项默认值并校验排序策略
+func (se *SearchEngine) applySearchDefaults(options *SearchOptions) error {
+	// 先截断调用方显式请求的规模，默认值不产生提示
+	se.clampSearchOptions(options)
 	if options.TopK <= 0 {
 		options.TopK = 10
 	}
 	if options.MaxResults <= 0 {
 		options.MaxResults = 100
+		if maxResults := searchLimitsFrom(se.config.SearchLimits).MaxResults; options.MaxResults > maxResults {
+			options.MaxResults = maxResults
+		}
 	}
 	if options.SimilarityType == "" {
-		options.SimilarityType = SimilarityTypeCosine
+		options.SimilarityType = se.defaultSimilarity
+		if options.SimilarityType == "" {
+			options.SimilarityType = SimilarityTypeCosine
+		}
+	}
+	if options.MinPerContentType <= 0 {
+		options.MinPerContentType = se.config.MinPerContentType
+	}
+	if options.RankingStrategy == "" {
+		options.RankingStrategy = RankingStrategy(se.config.DefaultRankingStrategy)
+	}
+	if options.RankingStrategy != "" && !IsValidRankingStrategy(options.RankingStrategy) {
+		return errors.ErrValidationFailed("ranking_strategy",
+			fmt.Sprintf("unknown ranking strategy: %s", options.RankingStrategy))
+	}
+	if options.Mode == "" {
+		options.Mode = hybridSearchSettingsFrom(se.config.HybridSearch).defaultMode
+	}
+	if !IsValidSearchMode(options.Mode) {
+		return errors.ErrValidationFailed("mode", fmt.Sprintf("unknown search mode: %s", options.Mode))
+	}
+	if options.SnippetSource == "" {
+		options.SnippetSource = SnippetSource(se.config.SnippetSource)
+		if options.SnippetSource == "" {
+			options.SnippetSource = SnippetSourceComputed
+		}
+	}
+	if !IsValidSnippetSource(options.SnippetSource) {
+		return errors.ErrValidationFailed("snippet_source", fmt.Sprintf("unknown snippet source: %s", options.SnippetSource))
+	}
+	if err := ValidateMetadataFilters(options.MetadataFilters); err != nil {
+		return err
+	}
+	if err := validateProjectionFields(options.Fields); err != nil {
+		return err
 	}
 
-	// 1. 预处理查询文本
-	processedQuery := se.preprocessQuery(options.Query)
+	return nil
+}
 
-	// 2. 生成查询向量
-	queryVector, err := se.generateQueryVector(ctx, processedQuery, options)
-	if err != nil {
-		se.logger.Error("Failed to generate query vector", logger.Fields{
-			"error": err.Error(),
+// executeSearch 执行搜索流程（向量化、检索、重排序和过滤），queryVector非空时直接使用
+func (se *SearchEngine) executeSearch(ctx context.Context, processedQuery string, queryVector []float32, options *SearchOptions, startTime time.Time) (*SearchResponse, error) {
+	// 2. 生成查询向量（调用方直接提供向量时无法确定生成服务，不限制文档的向量服务；
+	// 关键词模式不使用向量，不调用向量服务，向量服务不可用时仍可检索）
+	provider := ""
+	restrictProvider := len(queryVector) == 0 && options.Mode != SearchModeKeyword
+	if restrictProvider {
+		var err error
+		queryVector, provider, err = se.generateQueryVector(ctx, processedQuery, options)
+		if err != nil {
+			se.logger.Error("Failed to generate query vector", logger.Fields{
+				"error": err.Error(),
+				"query": processedQuery,
+			})
+			return nil, err
+		}
+		
+		se.logger.Debug("Query vector generated", logger.Fields{
+			"vector_length": len(queryVector),
 			"query": processedQuery,
 		})
-		return nil, err
 	}
-	
-	se.logger.Debug("Query vector generated", logger.Fields{
-		"vector_length": len(queryVector),
-		"query": processedQuery,
-	})
 
 	// 3. 构建过滤条件
 	filter := se.buildFilter(options)
+	if restrictProvider {
+		filter = se.restrictToProvider(filter, provider)
+	}
 
 	// 4. 执行向量搜索
 	searchQuery := &SearchQuery{
@@ -174,32 +532,75 @@ func (se *SearchEngine) Search(ctx context.Context, options *SearchOptions) (*Se
 		QueryVector:   queryVector,
 		TopK:          options.MaxResults, // 先获取更多结果用于重排序
 		Filter:        filter,
-		IncludeText:   options.IncludeContent,
+		IncludeText:   options.wantsContent(),
 		MinSimilarity: options.MinSimilarity,
 	}
 
-	vectorResults, err := se.chromaClient.Search(ctx, searchQuery)
+	// 混合或关键词模式下融合关键词检索结果
+	vectorResults, keywordScores, fusionScores, err := se.retrieveCandidates(ctx, searchQuery, options)
 	if err != nil {
 		return nil, err
 	}
 
 	// 5. 转换为搜索结果项
-	resultItems, err := se.convertToSearchResults(ctx, vectorResults, options, queryVector)
+	resultItems, err := se.convertToSearchResults(ctx, vectorResults, options, queryVector, fusionScores)
 	if err != nil {
 		return nil, err
 	}
+	if fusionScores != nil {
+		for _, item := range resultItems {
+			item.KeywordScore = keywordScores[item.DocumentID]
+			item.FusionScore = fusionScores[item.DocumentID]
+		}
+	}
+
+	// 6. 执行重排序（如果启用或指定了排序策略），已有足够高置信结果时只重排序这些结果
+	earlyTerminated := false
+	if (options.EnableReranking || options.RankingStrategy != "") && len(resultItems) > 1 {
+		var candidates []*SearchResultItem
+		candidates, earlyTerminated = se.highConfidenceCandidates(resultItems, options)
+		resultItems = se.rerankResults(ctx, candidates, options)
+	}
+
+	// LLM重排序前N个结果，失败时保持启发式排序
+	llmReranked := 0
+	var llmRerankErr error
+	if options.EnableLLMRerank && len(resultItems) > 1 {
+		llmReranked, llmRerankErr = se.llmRerank(ctx, processedQuery, resultItems)
+		if llmRerankErr != nil {
+			se.logger.Warn("LLM rerank failed, keeping heuristic ranking", logger.Fields{
+				"query": processedQuery,
+				"error": llmRerankErr.Error(),
+			})
+		}
+	}
+
+	// 保证前top_k中每种内容类型的最少数量，把排名靠后的少数类型结果提前
+	promoted := 0
+	if options.MinPerContentType > 0 {
+		resultItems, promoted = guaranteeContentTypes(resultItems, options)
+	}
 
-	// 6. 执行重排序（如果启用）
-	if options.EnableReranking && len(resultItems) > 1 {
-		resultItems = se.rerankResults(ctx, resultItems, options)
+	// 同一会话线程的消息折叠为一个结果
+	threadCollapsed := 0
+	if options.CollapseThreads && len(resultItems) > 1 {
+		resultItems, threadCollapsed = collapseThreads(resultItems)
 	}
 
-	// 7. 应用最终过滤和限制
+	// 7. 折叠近似重复结果前加载结果向量（Chroma查询结果不包含向量）
+	if options.CollapseDuplicates && len(resultItems) > 1 {
+		se.loadResultEmbeddings(ctx, resultItems)
+	}
+
+	// 8. 应用最终过滤和限制
 	finalResults := se.applyFinalFiltering(resultItems, options)
 
-	// 8. 设置排名
+	// 9. 设置排名
+	collapsedCount := 0
 	for i, result := range finalResults {
 		result.Rank = i + 1
+		collapsedCount += len(result.DuplicateOf)
+		projectSearchResult(result, options)
 	}
 
 	queryTime := time.Since(startTime)
@@ -216,8 +617,36 @@ func (se *SearchEngine) Search(ctx context.Context, options *SearchOptions) (*Se
 			"after_filtering":   len(resultItems),
 			"final_count":       len(finalResults),
 			"reranking_enabled": options.EnableReranking,
+			"ranking_strategy":  string(options.RankingStrategy),
+			"search_mode":       string(options.Mode),
 		},
 	}
+	if fusionScores != nil {
+		response.Metadata["keyword_matches"] = len(keywordScores)
+	}
+	if options.CollapseDuplicates {
+		response.Metadata["collapsed_duplicates"] = collapsedCount
+	}
+	if options.CollapseThreads {
+		response.Metadata["collapsed_thread_messages"] = threadCollapsed
+	}
+	if promoted > 0 {
+		response.Metadata["diversity_promoted"] = promoted
+	}
+	if llmReranked > 0 {
+		response.Metadata["llm_reranked"] = llmReranked
+	}
+	if llmRerankErr != nil {
+		response.Metadata["llm_rerank_fallback"] = true
+	}
+	if earlyTerminated {
+		response.Metadata["early_terminated"] = true
+		response.Metadata["reranked_candidates"] = len(resultItems)
+	}
+	if len(options.limitWarnings) > 0 {
+		response.Metadata["limits_clamped"] = true
+		response.Metadata["warnings"] = options.limitWarnings
+	}
 
 	se.logger.Info("Search completed", logger.Fields{
 		"query_time":      queryTime,
@@ -229,42 +658,33 @@ func (se *SearchEngine) Search(ctx context.Context, options *SearchOptions) (*Se
 	return response, nil
 }
 
+// copySharedResponse 深拷贝共享的搜索响应，避免调用方之间相互修改结果和元数据
+func (se *SearchEngine) copySharedResponse(response *SearchResponse) *SearchResponse {
+	copied := snapshotSearchResponse(response)
+	copied.Metadata["coalesced"] = true
+	return copied
+}
+
 // preprocessQuery 预处理查询文本
 func (se *SearchEngine) preprocessQuery(query string) string {
-	// 清理查询文本
-	processed := strings.TrimSpace(query)
-
-	// 移除多余的空白字符
-	processed = strings.ReplaceAll(processed, "\n", " ")
-	processed = strings.ReplaceAll(processed, "\t", " ")
-
-	// 移除重复空格
-	for strings.Contains(processed, "  ") {
-		processed = strings.ReplaceAll(processed, "  ", " ")
-	}
-
-	// 基本的查询扩展（可以根据需要添加更复杂的逻辑）
-	if len(processed) < 10 && !strings.Contains(processed, " ") {
-		// 对于短查询，添加通配符支持
-		processed = strings.ToLower(processed)
-	}
-
-	return processed
+	// 与文档使用相同的默认预处理流水线，保证等价输入得到相同文本
+	return se.preprocessor.Default().Apply(query)
 }
 
-// generateQueryVector 生成查询向量
-func (se *SearchEngine) generateQueryVector(ctx context.Context, query string, options *SearchOptions) ([]float32, error) {
+// generateQueryVector 生成查询向量，同时返回生成向量的服务（缓存的向量都由主服务生成）
+func (se *SearchEngine) generateQueryVector(ctx context.Context, query string, options *SearchOptions) ([]float32, string, error) {
 	se.logger.Debug("Generating query vector", logger.Fields{
 		"query_length": len(query),
 	})
 
 	// 尝试从缓存获取
 	if cachedVector, found := se.cacheManager.GetQueryVector(query, options); found {
+		llm.RecordCacheHit(ctx)
 		se.logger.Debug("Query vector cache hit", logger.Fields{
 			"query_length":  len(query),
 			"vector_length": len(cachedVector),
 		})
-		return cachedVector, nil
+		return cachedVector, PrimaryEmbeddingProvider, nil
 	}
 
 	// 缓存未命中，生成新的向量
@@ -284,21 +704,34 @@ func (se *SearchEngine) generateQueryVector(ctx context.Context, query string, o
 	// 生成embedding
 	result, err := se.embeddingService.GenerateEmbedding(ctx, embeddingReq)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	provider := result.Provider
+	if provider == "" {
+		provider = PrimaryEmbeddingProvider
 	}
 
-	// 缓存结果
-	se.cacheManager.SetQueryVector(query, options, result.Vector)
+	// 与文档向量使用相同的归一化，缓存归一化后的向量
+	vector := result.Vector
+	if se.config.NormalizeVectors {
+		vector, _ = normalizeL2(vector)
+	}
+
+	// 只缓存主服务生成的向量，主服务恢复后不再使用备用服务的查询向量
+	if provider == PrimaryEmbeddingProvider {
+		se.cacheManager.SetQueryVector(query, options, vector)
+	}
 
 	se.logger.Debug("Query vector generated and cached", logger.Fields{
 		"dimension":   result.Dimension,
 		"tokens_used": result.TokensUsed,
+		"provider":    provider,
 	})
 
-	return result.Vector, nil
+	return vector, provider, nil
 }
 
-// buildFilter 构建过滤条件
+// buildFilter 构建过滤条件（多个条件显式包裹在$and中，时间范围拆成$gte和$lte两个子句）
 func (se *SearchEngine) buildFilter(options *SearchOptions) map[string]interface{} {
 	filter := make(map[string]interface{})
 
@@ -346,18 +779,80 @@ func (se *SearchEngine) buildFilter(options *SearchOptions) map[string]interface
 		}
 	}
 
-	return filter
+	// 语言过滤（包含无语言信息的文档时无法在向量库中表达，改为在结果转换时过滤）
+	if len(options.Languages) > 0 && !options.IncludeUnknownLanguage {
+		filter[MetadataKeyLanguage] = map[string]interface{}{
+			"$in": options.Languages,
+		}
+	}
+
+	// 处理完整性过滤（按索引时写入的标记字段）
+	if options.RequireSummary != nil {
+		filter[MetadataKeyHasSummary] = *options.RequireSummary
+	}
+	if options.RequireTags != nil {
+		filter[MetadataKeyHasTags] = *options.RequireTags
+	}
+	if options.RequireIndexed != nil {
+		filter[MetadataKeyIndexed] = *options.RequireIndexed
+	}
+
+	// 自定义元数据过滤（不覆盖上面的内置过滤条件）
+	applyMetadataFilters(filter, options.MetadataFilters)
+
+	return normalizeWhere(filter)
 }
 
-// convertToSearchResults 转换为搜索结果项
-func (se *SearchEngine) convertToSearchResults(ctx context.Context, vectorResults *SearchResult, options *SearchOptions, queryVector []float32) ([]*SearchResultItem, error) {
+// matchesLanguages 检查文档语言是否满足语言过滤，没有语言信息的文档只在IncludeUnknownLanguage时保留
+func matchesLanguages(metadata map[string]interface{}, options *SearchOptions) bool {
+	if len(options.Languages) == 0 {
+		return true
+	}
+	lang, _ := metadata[MetadataKeyLanguage].(string)
+	if lang == "" {
+		return options.IncludeUnknownLanguage
+	}
+	for _, wanted := range options.Languages {
+		if strings.EqualFold(lang, wanted) {
+			return true
+		}
+	}
+	return false
+}
+
+// convertToSearchResults 转换为搜索结果项（没有查询向量时以相对最高分归一化的融合分数作为相似度）
+func (se *SearchEngine) convertToSearchResults(ctx context.Context, vectorResults *SearchResult, options *SearchOptions, queryVector []float32, fusionScores map[string]float64) ([]*SearchResultItem, error) {
+	termWeights := se.queryTermWeights(se.queryTerms(options.Query), vectorResults.Documents)
 	results := make([]*SearchResultItem, 0, len(vectorResults.Documents))
+	distance := storeDistanceFunction(se.store)
+	maxFusion := 0.0
+	for _, fusion := range fusionScores {
+		maxFusion = math.Max(maxFusion, fusion)
+	}
 
 	for _, doc := range vectorResults.Documents {
+		// 跳过因超出配额被软删除的文档
+		if deleted, _ := doc.Metadata[MetadataKeyDeleted].(bool); deleted {
+			continue
+		}
+		// 按配置跳过低质量提取的文档
+		if lowQuality, _ := doc.Metadata[MetadataKeyLowQuality].(bool); lowQuality && se.excludeLowQuality && !options.IncludeLowQuality {
+			continue
+		}
+		// 包含无语言信息的文档时在这里按语言过滤
+		if !matchesLanguages(doc.Metadata, options) {
+			continue
+		}
+
 		// 计算相似度分数
-		similarity := float64(0)
-		if len(doc.Embedding) > 0 {
-			sim, err := se.similarityCalc.CalculateSimilarity(queryVector, doc.Embedding, options.SimilarityType)
+		score := &SimilarityScore{}
+		if len(queryVector) == 0 {
+			if maxFusion > 0 {
+				normalized := fusionScores[doc.ID] / maxFusion
+				score = &SimilarityScore{Raw: normalized, Normalized: normalized}
+			}
+		} else if len(doc.Embedding) > 0 {
+			calculated, err := se.similarityCalc.CalculateScore(queryVector, doc.Embedding, options.SimilarityType)
 			if err != nil {
 				se.logger.Warn("Failed to calculate similarity", logger.Fields{
 					"document_id": doc.ID,
@@ -365,23 +860,30 @@ func (se *SearchEngine) convertToSearchResults(ctx context.Context, vectorResult
 				})
 				continue
 			}
-			similarity = sim
+			score = calculated
+		} else if raw, ok := rawSimilarityFromDistance(distance, doc.Distance, options.SimilarityType); ok {
+			// 存储未返回向量时由距离换算
+			score = &SimilarityScore{Raw: raw, Normalized: se.similarityCalc.Normalize(options.SimilarityType, raw)}
 		}
+		similarity := score.Normalized
 
 		// 提取关键词匹配
 		matchedKeywords := se.extractMatchedKeywords(options.Query, doc.Content, doc.Metadata)
 
-		// 生成内容摘要
-		contentSummary := se.generateContentSummary(doc.Content, options.Query)
+		// 生成结果片段（按片段来源使用已保存的摘要或从原文截取）
+		contentSummary := se.resultSnippet(doc.Content, doc.Metadata, options)
 
 		// 计算综合相关性分数
-		relevanceScore := se.calculateRelevanceScore(similarity, matchedKeywords, doc.Metadata, options)
+		relevanceScore := se.calculateRelevanceScore(similarity, matchedKeywords, termWeights, doc.Metadata, options)
 
 		resultItem := &SearchResultItem{
 			DocumentID:      doc.ID,
 			Content:         doc.Content,
 			Similarity:      similarity,
 			Distance:        doc.Distance,
+			RawSimilarity:   score.Raw,
+			NormalizedScore: similarity,
+			SimilarityType:  options.SimilarityType,
 			Metadata:        doc.Metadata,
 			RelevanceScore:  relevanceScore,
 			MatchedKeywords: matchedKeywords,
@@ -402,12 +904,12 @@ func (se *SearchEngine) convertToSearchResults(ctx context.Context, vectorResult
 
 // extractMatchedKeywords 提取匹配的关键词
 func (se *SearchEngine) extractMatchedKeywords(query string, content string, metadata map[string]interface{}) []string {
-	queryWords := strings.Fields(strings.ToLower(query))
+	queryWords := se.queryTerms(query)
 	contentLower := strings.ToLower(content)
 
 	matched := make([]string, 0)
 	for _, word := range queryWords {
-		if len(word) > 2 && strings.Contains(contentLower, word) {
+		if strings.Contains(contentLower, word) {
 			matched = append(matched, word)
 		}
 	}
@@ -499,21 +1001,21 @@ func (se *SearchEngine) generateContentSummary(content string, query string) str
 }
 
 // calculateRelevanceScore 计算综合相关性分数
-func (se *SearchEngine) calculateRelevanceScore(similarity float64, matchedKeywords []string, metadata map[string]interface{}, options *SearchOptions) float64 {
+func (se *SearchEngine) calculateRelevanceScore(similarity float64, matchedKeywords []string, termWeights map[string]float64, metadata map[string]interface{}, options *SearchOptions) float64 {
+	// 各分项先限制在[0,1]内再加权，避免异常值（如负相似度）产生负贡献
 	// 基础相似度分数 (权重: 0.6)
-	relevanceScore := similarity * 0.6
+	relevanceScore := clampUnit(similarity) * 0.6
 
-	// 关键词匹配分数 (权重: 0.2)
-	keywordScore := float64(len(matchedKeywords)) / float64(len(strings.Fields(options.Query)))
-	if keywordScore > 1.0 {
-		keywordScore = 1.0
-	}
-	relevanceScore += keywordScore * 0.2
+	// 关键词匹配分数 (权重: 0.2)，只统计有实际含义的查询词
+	relevanceScore += clampUnit(keywordMatchScore(matchedKeywords, termWeights)) * 0.2
+
+	// 字段命中加分（标题/摘要/标签，叠加在正文关键词分数之上）
+	relevanceScore += math.Max(0, se.fieldMatchScore(options.Query, metadata))
 
 	// 重要性分数 (权重: 0.1)
 	if importanceVal, exists := metadata["importance_score"]; exists {
 		if importance, ok := importanceVal.(float64); ok {
-			relevanceScore += (importance / 10.0) * 0.1 // 假设重要性分数0-10
+			relevanceScore += clampUnit(importance/10.0) * 0.1 // 假设重要性分数0-10
 		}
 	}
 
@@ -523,27 +1025,199 @@ func (se *SearchEngine) calculateRelevanceScore(similarity float64, matchedKeywo
 			createdAt := time.Unix(createdAtInt, 0)
 			daysSinceCreation := time.Since(createdAt).Hours() / 24
 			freshnessScore := 1.0 / (1.0 + daysSinceCreation/365.0) // 一年后降到50%
-			relevanceScore += freshnessScore * 0.1
+			relevanceScore += clampUnit(freshnessScore) * 0.1
 		}
 	}
 
+	// 内容类型乘数（如更看重链接而非笔记），默认1.0不调整
+	relevanceScore *= contentTypeWeight(se.config.ContentTypeWeights, metadata)
+
 	// 确保分数在[0,1]范围内
-	if relevanceScore > 1.0 {
-		relevanceScore = 1.0
+	return clampUnit(relevanceScore)
+}
+
+// fieldMatchScore 计算查询词命中标题、一句话摘要和标签的加分，按各字段命中的查询词比例乘以字段权重
+func (se *SearchEngine) fieldMatchScore(query string, metadata map[string]interface{}) float64 {
+	queryWords := se.queryTerms(query)
+	if len(queryWords) == 0 {
+		return 0
 	}
-	if relevanceScore < 0.0 {
-		relevanceScore = 0.0
+
+	boosts := fieldBoostsFrom(se.config.FieldBoosts)
+	title, _ := metadata["title"].(string)
+	summary, _ := metadata["summary_oneline"].(string)
+	tags := metadataStrings(metadata["tags"])
+
+	score := 0.0
+	score += textMatchRatio(queryWords, title) * boosts.Title
+	score += textMatchRatio(queryWords, summary) * boosts.Summary
+	score += textMatchRatio(queryWords, strings.Join(tags, " ")) * boosts.Tags
+	return score
+}
+
+// queryTerms 提取参与关键词匹配的查询词：小写、去除首尾标点、去重，并按配置排除停用词
+func (se *SearchEngine) queryTerms(query string) []string {
+	settings := se.config.QueryTerms
+	if settings == nil {
+		settings = &config.QueryTermsConfig{}
 	}
 
-	return relevanceScore
+	extra := make(map[string]bool, len(settings.ExtraStopwords))
+	for _, word := range settings.ExtraStopwords {
+		extra[strings.ToLower(strings.TrimSpace(word))] = true
+	}
+
+	terms := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		word = strings.TrimFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(word) <= 2 || seen[word] {
+			continue
+		}
+		if !settings.KeepStopwords && (language.IsStopword(word) || extra[word]) {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+
+	return terms
+}
+
+// queryTermWeights 计算查询词权重：默认等权，启用IDF加权时按候选文档中的文档频率计算，少见的词权重更高
+func (se *SearchEngine) queryTermWeights(terms []string, docs []*VectorDocument) map[string]float64 {
+	weights := make(map[string]float64, len(terms))
+	idf := se.config.QueryTerms != nil && se.config.QueryTerms.IDFWeighting
+
+	for _, term := range terms {
+		if !idf {
+			weights[term] = 1
+			continue
+		}
+
+		docFreq := 0
+		for _, doc := range docs {
+			if strings.Contains(strings.ToLower(doc.Content), term) {
+				docFreq++
+			}
+		}
+		// 平滑的IDF，下限为1，保证每个词都有权重
+		weights[term] = 1 + math.Log(float64(len(docs)+1)/float64(docFreq+1))
+	}
+
+	return weights
+}
+
+// keywordMatchScore 命中查询词的权重占全部查询词权重的比例，元数据关键词与查询词互相包含也算命中
+func keywordMatchScore(matchedKeywords []string, termWeights map[string]float64) float64 {
+	total := 0.0
+	for _, weight := range termWeights {
+		total += weight
+	}
+	if total == 0 {
+		return 0
+	}
+
+	matched := 0.0
+	for term, weight := range termWeights {
+		for _, keyword := range matchedKeywords {
+			keyword = strings.ToLower(keyword)
+			if keyword == "" {
+				continue
+			}
+			if strings.Contains(keyword, term) || strings.Contains(term, keyword) {
+				matched += weight
+				break
+			}
+		}
+	}
+
+	return matched / total
+}
+
+// fieldBoostsFrom 获取字段加分配置：0使用默认值，负数表示关闭该字段加分
+func fieldBoostsFrom(boosts *config.FieldBoostsConfig) config.FieldBoostsConfig {
+	result := config.FieldBoostsConfig{
+		Title:   defaultTitleBoost,
+		Summary: defaultSummaryBoost,
+		Tags:    defaultTagsBoost,
+	}
+	if boosts == nil {
+		return result
+	}
+
+	apply := func(value float64, target *float64) {
+		switch {
+		case value < 0:
+			*target = 0
+		case value > 0:
+			*target = value
+		}
+	}
+	apply(boosts.Title, &result.Title)
+	apply(boosts.Summary, &result.Summary)
+	apply(boosts.Tags, &result.Tags)
+	return result
+}
+
+// textMatchRatio 计算出现在文本中的查询词比例
+func textMatchRatio(queryWords []string, text string) float64 {
+	if text == "" || len(queryWords) == 0 {
+		return 0
+	}
+
+	textLower := strings.ToLower(text)
+	matched := 0
+	for _, word := range queryWords {
+		if strings.Contains(textLower, word) {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(queryWords))
 }
 
 // rerankResults 重排序结果
 func (se *SearchEngine) rerankResults(ctx context.Context, results []*SearchResultItem, options *SearchOptions) []*SearchResultItem {
 	se.logger.Debug("Reranking search results", logger.Fields{
-		"result_count": len(results),
+		"result_count":     len(results),
+		"ranking_strategy": string(options.RankingStrategy),
 	})
 
+	// 指定了排序策略时使用排序器
+	if options.RankingStrategy != "" && se.ranker != nil {
+		rankingOptions := se.ranker.GetDefaultRankingOptions()
+		rankingOptions.Strategy = options.RankingStrategy
+		// 显式指定的排序策略需要保持严格顺序，不做多样性调整
+		rankingOptions.DiversitySettings = nil
+		// 重要性在查询时按该用户的访问频率和最近访问调整
+		rankingOptions.accessImportance = newAccessImportance(se.config.AccessImportance, se.interactions, options.UserID, time.Now())
+		// 配置的标签增强
+		rankingOptions.BoostFactors = tagBoostFactorsFrom(se.config.TagBoosts)
+		// 配置的内容类型相关性乘数
+		rankingOptions.contentTypeWeights = se.config.ContentTypeWeights
+
+		rankingResult, err := se.ranker.Rank(results, rankingOptions)
+		if err == nil {
+			breakdowns := make(map[string]ScoreBreakdown, len(rankingResult.ScoreBreakdown))
+			for _, breakdown := range rankingResult.ScoreBreakdown {
+				breakdowns[breakdown.DocumentID] = breakdown
+			}
+			for _, result := range rankingResult.RankedResults {
+				if breakdown, exists := breakdowns[result.DocumentID]; exists {
+					result.ScoreBreakdown = &breakdown
+				}
+			}
+			return rankingResult.RankedResults
+		}
+
+		se.logger.Warn("Ranking strategy failed, falling back to relevance sort", logger.Fields{
+			"ranking_strategy": string(options.RankingStrategy),
+			"error":            err.Error(),
+		})
+	}
+
 	// 按照综合相关性分数排序
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].RelevanceScore > results[j].RelevanceScore
@@ -552,34 +1226,155 @@ func (se *SearchEngine) rerankResults(ctx context.Context, results []*SearchResu
 	return results
 }
 
+// tagBoostFactorsFrom 由配置的标签增强构建增强因子（标签统一为小写），未配置时返回nil
+func tagBoostFactorsFrom(tagBoosts map[string]float64) *BoostFactors {
+	if len(tagBoosts) == 0 {
+		return nil
+	}
+
+	boosts := make(map[string]float64, len(tagBoosts))
+	for tag, boost := range tagBoosts {
+		boosts[strings.ToLower(strings.TrimSpace(tag))] = boost
+	}
+	return &BoostFactors{TagBoosts: boosts}
+}
+
+// contentTypeWeight 获取结果内容类型的相关性乘数，未配置的类型为1.0
+func contentTypeWeight(weights map[string]float64, metadata map[string]interface{}) float64 {
+	contentType, _ := metadata["content_type"].(string)
+	if weight, exists := weights[contentType]; exists && weight > 0 {
+		return weight
+	}
+	return 1.0
+}
+
 // applyFinalFiltering 应用最终过滤
 func (se *SearchEngine) applyFinalFiltering(results []*SearchResultItem, options *SearchOptions) []*SearchResultItem {
 	filtered := make([]*SearchResultItem, 0)
+	threshold := se.duplicateThreshold(options)
 
 	for _, result := range results {
-		// 应用最小相似度过滤
-		if result.Similarity < float64(options.MinSimilarity) {
+		// 应用最小相似度过滤（关键词命中的结果不受相似度阈值限制）
+		if result.Similarity < float64(options.MinSimilarity) && result.KeywordScore == 0 {
 			continue
 		}
+		// 相似度下限只作用于展示的分数，在最小相似度过滤之后应用，避免低于阈值的结果被抬高后通过过滤
+		if floor := se.config.SimilarityFloor; result.Similarity < floor {
+			result.Similarity = floor
+			result.NormalizedScore = floor
+		}
+
+		// 折叠近似重复结果，保留排名靠前的结果作为代表
+		if options.CollapseDuplicates {
+			if representative := se.findDuplicateRepresentative(result, filtered, threshold); representative != nil {
+				representative.DuplicateOf = append(representative.DuplicateOf, result.DocumentID)
+				continue
+			}
+		}
 
 		// 如果不需要内容，清空内容字段
-		if !options.IncludeContent {
+		if !options.wantsContent() {
 			result.Content = ""
 		}
 
 		filtered = append(filtered, result)
 
-		// 限制结果数量
-		if len(filtered) >= options.TopK {
+		// 限制结果数量（折叠时需要继续扫描以归并排在后面的重复项）
+		if len(filtered) >= options.TopK && !options.CollapseDuplicates {
 			break
 		}
 	}
 
+	if len(filtered) > options.TopK {
+		filtered = filtered[:options.TopK]
+	}
+
 	return filtered
 }
 
+// duplicateThreshold 获取重复判定的相似度阈值
+func (se *SearchEngine) duplicateThreshold(options *SearchOptions) float64 {
+	if options.DuplicateThreshold > 0 {
+		return options.DuplicateThreshold
+	}
+	if se.config.DuplicateSimilarityThreshold > 0 {
+		return se.config.DuplicateSimilarityThreshold
+	}
+	return defaultDuplicateThreshold
+}
+
+// findDuplicateRepresentative 在已保留的结果中查找与当前结果近似重复的代表结果
+func (se *SearchEngine) findDuplicateRepresentative(result *SearchResultItem, kept []*SearchResultItem, threshold float64) *SearchResultItem {
+	if len(result.Embedding) == 0 {
+		return nil
+	}
+
+	for _, candidate := range kept {
+		if len(candidate.Embedding) != len(result.Embedding) {
+			continue
+		}
+
+		similarity, err := se.similarityCalc.CalculateSimilarity(candidate.Embedding, result.Embedding, SimilarityTypeCosine)
+		if err != nil {
+			continue
+		}
+		if similarity >= threshold {
+			return candidate
+		}
+	}
+
+	return nil
+}
+
+// loadResultEmbeddings 批量加载搜索结果的向量，失败时不折叠重复结果
+func (se *SearchEngine) loadResultEmbeddings(ctx context.Context, results []*SearchResultItem) {
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		if len(result.Embedding) == 0 {
+			ids = append(ids, result.DocumentID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	documents, err := se.store.GetDocuments(ctx, ids)
+	if err != nil {
+		se.logger.Warn("Failed to load result embeddings, skipping duplicate collapse", logger.Fields{
+			"result_count": len(ids),
+			"error":        err.Error(),
+		})
+		return
+	}
+
+	embeddings := make(map[string][]float32, len(documents))
+	for _, doc := range documents {
+		embeddings[doc.ID] = doc.Embedding
+	}
+	for _, result := range results {
+		if embedding, exists := embeddings[result.DocumentID]; exists && len(result.Embedding) == 0 {
+			result.Embedding = embedding
+		}
+	}
+}
+
 // IndexDocument 索引文档到向量数据库
 func (se *SearchEngine) IndexDocument(ctx context.Context, contentItem *models.ContentItem) error {
+	if err := se.validateIndexItem(contentItem); err != nil {
+		return err
+	}
+
+	// 创建向量文档
+	vectorDoc, err := se.embeddingService.CreateContentVector(ctx, contentItem)
+	if err != nil {
+		return err
+	}
+
+	return se.addVectorDocument(ctx, contentItem, vectorDoc)
+}
+
+// validateIndexItem 检查待索引的内容项并记录日志
+func (se *SearchEngine) validateIndexItem(contentItem *models.ContentItem) error {
 	if contentItem == nil {
 		return errors.ErrValidationFailed("content_item", "cannot be nil")
 	}
@@ -589,65 +1384,159 @@ func (se *SearchEngine) IndexDocument(ctx context.Context, contentItem *models.C
 		"content_type": string(contentItem.Type),
 		"user_id":      contentItem.UserID,
 	})
+	return nil
+}
 
-	// 创建向量文档
-	vectorDoc, err := se.embeddingService.CreateContentVector(ctx, contentItem)
-	if err != nil {
-		return err
-	}
-
-	// 添加到向量数据库
-	if err := se.chromaClient.AddDocument(ctx, vectorDoc); err != nil {
+// addVectorDocument 将已生成的向量文档写入向量数据库并记录审计日志
+func (se *SearchEngine) addVectorDocument(ctx context.Context, contentItem *models.ContentItem, vectorDoc *VectorDocument) error {
+	if err := se.store.AddDocument(ctx, vectorDoc); err != nil {
 		return err
 	}
+	se.bumpCollectionVersion()
 
 	se.logger.Info("Document indexed successfully", logger.Fields{
 		"content_id": contentItem.ID,
 		"vector_dim": len(vectorDoc.Embedding),
 	})
 
+	se.auditLogger.Record(ctx, logger.AuditRecord{
+		Action:     logger.AuditActionIndex,
+		DocumentID: contentItem.ID,
+		UserID:     contentItem.UserID,
+		After:      auditItemSummary(contentItem),
+	})
+
 	return nil
 }
 
-// BatchIndexDocuments 批量索引文档
-func (se *SearchEngine) BatchIndexDocuments(ctx context.Context, contentItems []*models.ContentItem) error {
+// BatchIndexDocuments 批量索引文档，返回逐文档的成功/失败报告以便调用方仅重试失败项
+func (se *SearchEngine) BatchIndexDocuments(ctx context.Context, contentItems []*models.ContentItem) (*BatchIndexReport, error) {
 	if len(contentItems) == 0 {
-		return errors.ErrValidationFailed("content_items", "cannot be empty")
+		return nil, errors.ErrValidationFailed("content_items", "cannot be empty")
 	}
 
 	se.logger.Info("Batch indexing documents", logger.Fields{
 		"batch_size": len(contentItems),
 	})
 
+	startTime := time.Now()
+	report := &BatchIndexReport{
+		Total:        len(contentItems),
+		SucceededIDs: make([]string, 0, len(contentItems)),
+		Failures:     make([]*BatchIndexFailure, 0),
+	}
+
 	vectorDocs := make([]*VectorDocument, 0, len(contentItems))
 
 	// 生成向量文档
 	for _, item := range contentItems {
+		if item == nil {
+			report.addFailure("", BatchIndexStageEmbedding, errors.ErrValidationFailed("content_item", "cannot be nil"))
+			continue
+		}
+
 		vectorDoc, err := se.embeddingService.CreateContentVector(ctx, item)
 		if err != nil {
 			se.logger.Error("Failed to create vector for content item", logger.Fields{
 				"content_id": item.ID,
 				"error":      err.Error(),
 			})
+			report.addFailure(item.ID, BatchIndexStageEmbedding, err)
 			continue
 		}
 		vectorDocs = append(vectorDocs, vectorDoc)
 	}
+	report.EmbeddingTime = time.Since(startTime)
 
 	// 批量添加到向量数据库
+	indexStart := time.Now()
 	if len(vectorDocs) > 0 {
-		if err := se.chromaClient.AddDocuments(ctx, vectorDocs); err != nil {
-			return err
+		se.addDocumentsWithAttribution(ctx, vectorDocs, report)
+	}
+	report.IndexTime = time.Since(indexStart)
+	report.TotalTime = time.Since(startTime)
+
+	if se.auditLogger.Enabled() {
+		succeeded := make(map[string]bool, len(report.SucceededIDs))
+		for _, id := range report.SucceededIDs {
+			succeeded[id] = true
+		}
+		for _, item := range contentItems {
+			if item != nil && succeeded[item.ID] {
+				se.auditLogger.Record(ctx, logger.AuditRecord{
+					Action:     logger.AuditActionIndex,
+					DocumentID: item.ID,
+					UserID:     item.UserID,
+					After:      auditItemSummary(item),
+				})
+			}
 		}
 	}
 
 	se.logger.Info("Batch indexing completed", logger.Fields{
-		"total_items":   len(contentItems),
-		"indexed_items": len(vectorDocs),
-		"failed_items":  len(contentItems) - len(vectorDocs),
+		"total_items":    report.Total,
+		"indexed_items":  len(report.SucceededIDs),
+		"failed_items":   len(report.Failures),
+		"embedding_time": report.EmbeddingTime,
+		"index_time":     report.IndexTime,
 	})
 
-	return nil
+	return report, nil
+}
+
+// addDocumentsWithAttribution 批量写入向量数据库，整批失败时逐个重试以定位失败的文档
+func (se *SearchEngine) addDocumentsWithAttribution(ctx context.Context, vectorDocs []*VectorDocument, report *BatchIndexReport) {
+	// 批次失败时也可能有部分文档已写入
+	defer se.bumpCollectionVersion()
+
+	err := se.store.AddDocuments(ctx, vectorDocs)
+	if err == nil {
+		for _, doc := range vectorDocs {
+			report.SucceededIDs = append(report.SucceededIDs, doc.ID)
+		}
+		return
+	}
+
+	// 单个文档的批次无需重试
+	if len(vectorDocs) == 1 {
+		report.addFailure(vectorDocs[0].ID, BatchIndexStageChroma, err)
+		return
+	}
+
+	se.logger.Warn("Batch add failed, retrying documents individually", logger.Fields{
+		"batch_size": len(vectorDocs),
+		"error":      err.Error(),
+	})
+
+	for _, doc := range vectorDocs {
+		if err := se.store.AddDocument(ctx, doc); err != nil {
+			report.addFailure(doc.ID, BatchIndexStageChroma, err)
+			continue
+		}
+		report.SucceededIDs = append(report.SucceededIDs, doc.ID)
+	}
+}
+
+// GetDocument 获取索引中的文档
+func (se *SearchEngine) GetDocument(ctx context.Context, documentID string) (*VectorDocument, error) {
+	if documentID == "" {
+		return nil, errors.ErrValidationFailed("document_id", "cannot be empty")
+	}
+
+	return se.store.GetDocument(ctx, documentID)
+}
+
+// VectorDimension 获取索引中向量的维度（索引为空时返回0）
+func (se *SearchEngine) VectorDimension(ctx context.Context) (int, error) {
+	return se.store.GetVectorDimension(ctx)
+}
+
+// reportProgress 按done/total上报进度百分比，progress为nil或total为0时忽略
+func reportProgress(progress func(percent float64), done, total int) {
+	if progress == nil || total <= 0 {
+		return
+	}
+	progress(float64(done) * 100 / float64(total))
 }
 
 // DeleteDocument 从索引中删除文档
@@ -660,7 +1549,21 @@ func (se *SearchEngine) DeleteDocument(ctx context.Context, documentID string) e
 		"document_id": documentID,
 	})
 
-	return se.chromaClient.DeleteDocument(ctx, documentID)
+	before := se.auditSnapshot(ctx, documentID)
+
+	if err := se.store.DeleteDocument(ctx, documentID); err != nil {
+		return err
+	}
+	se.bumpCollectionVersion()
+
+	se.auditLogger.Record(ctx, logger.AuditRecord{
+		Action:     logger.AuditActionDelete,
+		DocumentID: documentID,
+		UserID:     auditUserID(before),
+		Before:     before,
+	})
+
+	return nil
 }
 
 // UpdateDocument 更新索引中的文档
@@ -679,14 +1582,145 @@ func (se *SearchEngine) UpdateDocument(ctx context.Context, contentItem *models.
 		return err
 	}
 
+	before := se.auditSnapshot(ctx, contentItem.ID)
+
 	// 更新向量数据库
-	return se.chromaClient.UpdateDocument(ctx, vectorDoc)
+	if err := se.store.UpdateDocument(ctx, vectorDoc); err != nil {
+		return err
+	}
+	se.bumpCollectionVersion()
+
+	se.auditLogger.Record(ctx, logger.AuditRecord{
+		Action:     logger.AuditActionUpdate,
+		DocumentID: contentItem.ID,
+		UserID:     contentItem.UserID,
+		Before:     before,
+		After:      auditItemSummary(contentItem),
+	})
+
+	return nil
+}
+
+// UpdateDocumentMetadata 仅更新索引文档的元数据（如标签、重要性），不重新生成向量
+func (se *SearchEngine) UpdateDocumentMetadata(ctx context.Context, documentID string, metadata map[string]interface{}) error {
+	if documentID == "" {
+		return errors.ErrValidationFailed("document_id", "cannot be empty")
+	}
+
+	if len(metadata) == 0 {
+		return errors.ErrValidationFailed("metadata", "cannot be empty")
+	}
+
+	// 向量和内容相关的字段不允许通过元数据更新修改
+	patch := make(map[string]interface{}, len(metadata)+1)
+	for key, value := range metadata {
+		switch key {
+		case "content_id", "created_at", "content_length", "vector_dimension", "model", "tokens_used":
+			return errors.ErrValidationFailed("metadata."+key, "is managed by the index and cannot be updated")
+		}
+		patch[key] = value
+	}
+	patch["updated_at"] = time.Now().Unix()
+
+	se.logger.Info("Updating document metadata in index", logger.Fields{
+		"document_id":   documentID,
+		"metadata_keys": getMetadataKeys(metadata),
+	})
+
+	before := se.auditSnapshot(ctx, documentID)
+
+	if err := se.store.UpdateDocumentMetadata(ctx, documentID, patch); err != nil {
+		return err
+	}
+	se.bumpCollectionVersion()
+
+	if se.auditLogger.Enabled() {
+		after := make(map[string]interface{}, len(before)+len(patch))
+		for key, value := range before {
+			after[key] = value
+		}
+		for key, value := range patch {
+			if auditSummaryKeys[key] {
+				after[key] = value
+			}
+		}
+
+		se.auditLogger.Record(ctx, logger.AuditRecord{
+			Action:     logger.AuditActionUpdate,
+			DocumentID: documentID,
+			UserID:     auditUserID(before),
+			Before:     before,
+			After:      after,
+		})
+	}
+
+	return nil
+}
+
+// auditSummaryKeys 审计记录中摘要的关键元数据字段
+var auditSummaryKeys = map[string]bool{
+	"user_id":          true,
+	"content_type":     true,
+	"content_length":   true,
+	"importance_score": true,
+	"tags":             true,
+	"summary_oneline":  true,
+	"updated_at":       true,
+}
+
+// auditItemSummary 生成内容项关键字段摘要
+func auditItemSummary(contentItem *models.ContentItem) map[string]interface{} {
+	summary := map[string]interface{}{
+		"user_id":          contentItem.UserID,
+		"content_type":     string(contentItem.Type),
+		"content_length":   len(contentItem.RawContent),
+		"importance_score": contentItem.ImportanceScore,
+		"tags":             contentItem.GetTags(),
+	}
+	if contentItem.Summary.OneLine != "" && !contentItem.Sensitive {
+		summary["summary_oneline"] = contentItem.Summary.OneLine
+	}
+	return summary
+}
+
+// auditSnapshot 获取变更前文档关键字段摘要（仅在启用审计时查询，获取失败时返回nil）
+func (se *SearchEngine) auditSnapshot(ctx context.Context, documentID string) map[string]interface{} {
+	if !se.auditLogger.Enabled() {
+		return nil
+	}
+
+	doc, err := se.store.GetDocument(ctx, documentID)
+	if err != nil || doc == nil {
+		return nil
+	}
+
+	summary := make(map[string]interface{})
+	for key, value := range doc.Metadata {
+		if auditSummaryKeys[key] {
+			summary[key] = value
+		}
+	}
+	if _, exists := summary["content_length"]; !exists && doc.Content != "" {
+		summary["content_length"] = len(doc.Content)
+	}
+	return summary
+}
+
+// auditUserID 从文档摘要中提取用户ID
+func auditUserID(summary map[string]interface{}) string {
+	userID, _ := summary["user_id"].(string)
+	return userID
+}
+
+// FlushCache 清空搜索引擎指定类型的缓存，返回各类缓存清除的条目数
+func (se *SearchEngine) FlushCache(cacheType string) (map[string]int, error) {
+	return se.cacheManager.Flush(cacheType)
 }
 
 // GetSearchStats 获取搜索统计信息
 func (se *SearchEngine) GetSearchStats(ctx context.Context) (map[string]interface{}, error) {
 	// 获取Chroma集合信息
-	collectionInfo, err := se.chromaClient.GetCollectionInfo(ctx)
+	collectionInfo, err := se.store.GetCollectionInfo(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -697,9 +1731,12 @@ func (se *SearchEngine) GetSearchStats(ctx context.Context) (map[string]interfac
 	stats := map[string]interface{}{
 		"collection_info":    collectionInfo,
 		"cache_info":         cacheInfo,
+		"inflight_searches":  se.searchFlights.InFlight(),
+		"search_concurrency": se.searchLimiter.stats(),
+		"token_budget":       llm.GetTokenBudget().GetStats(),
 		"engine_type":        "semantic_search",
 		"similarity_types":   []string{"cosine", "euclidean", "dot", "manhattan"},
-		"supported_features": []string{"vector_search", "metadata_filtering", "reranking", "batch_operations", "caching"},
+		"supported_features": []string{"vector_search", "metadata_filtering", "reranking", "batch_operations", "caching", "request_coalescing"},
 	}
 
 	return stats, nil
@@ -709,9 +1746,9 @@ func (se *SearchEngine) GetSearchStats(ctx context.Context) (map[string]interfac
 func (se *SearchEngine) HealthCheck(ctx context.Context) error {
 	se.logger.Debug("Performing search engine health check")
 
-	// 检查Chroma客户端
-	if err := se.chromaClient.HealthCheck(ctx); err != nil {
-		return fmt.Errorf("chroma client health check failed: %w", err)
+	// 检查向量存储
+	if err := se.store.HealthCheck(ctx); err != nil {
+		return fmt.Errorf("vector store health check failed: %w", err)
 	}
 
 	// 检查embedding服务（通过生成一个简单的测试向量）
@@ -729,23 +1766,47 @@ func (se *SearchEngine) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
-// GetRecommendations 获取推荐内容
+// Recommender 获取使用本引擎向量存储和交互记录的共享推荐系统（首次调用时创建），应在设置交互记录之后调用
+func (se *SearchEngine) Recommender() *Recommender {
+	se.recommenderOnce.Do(func() {
+		se.recommender = newRecommender(se)
+	})
+	return se.recommender
+}
+
+// GetRecommendations 获取推荐内容（使用本引擎的向量存储和交互记录）
 func (se *SearchEngine) GetRecommendations(ctx context.Context, request *RecommendationRequest) (*RecommendationResponse, error) {
-	// 创建推荐系统实例
-	recommender, err := NewRecommender()
-	if err != nil {
-		return nil, err
-	}
-	defer recommender.Close()
+	return se.Recommender().GetRecommendations(ctx, request)
+}
 
-	// 调用推荐系统
-	return recommender.GetRecommendations(ctx, request)
+// RecordInteraction 记录用户与文档的交互（未设置交互记录时忽略）
+func (se *SearchEngine) RecordInteraction(userID, documentID string) {
+	if se.interactions == nil {
+		return
+	}
+	se.interactions.RecordInteraction(userID, documentID, time.Now())
+	se.interactionRecorded()
 }
 
 // Close 关闭搜索引擎
 func (se *SearchEngine) Close() error {
 	se.logger.Info("Closing search engine")
 
+	// 停止未完成的预热
+	if se.warmupCancel != nil {
+		se.warmupCancel()
+	}
+
+	// 停止后台归档
+	if se.archiveCancel != nil {
+		se.archiveCancel()
+	}
+
+	// 停止共享推荐系统的热门分数任务
+	if se.recommender != nil && se.recommender.trendingJob != nil {
+		se.recommender.trendingJob.Stop()
+	}
+
 	var err error
 
 	// 关闭缓存管理器
@@ -754,18 +1815,31 @@ func (se *SearchEngine) Close() error {
 		err = closeErr
 	}
 
-	// 关闭Chroma客户端
-	if closeErr := se.chromaClient.Close(); closeErr != nil {
-		se.logger.Error("Failed to close Chroma client", logger.Fields{"error": closeErr.Error()})
+	// 关闭向量存储
+	if closeErr := se.store.Close(); closeErr != nil {
+		se.logger.Error("Failed to close vector store", logger.Fields{"error": closeErr.Error()})
 		err = closeErr
 	}
 
+	// 关闭归档冷存储
+	if se.archiveStore != nil {
+		if closeErr := se.archiveStore.Close(); closeErr != nil {
+			se.logger.Error("Failed to close archive store", logger.Fields{"error": closeErr.Error()})
+			err = closeErr
+		}
+	}
+
 	// 关闭embedding服务
 	if closeErr := se.embeddingService.Close(); closeErr != nil {
 		se.logger.Error("Failed to close embedding service", logger.Fields{"error": closeErr.Error()})
 		err = closeErr
 	}
 
+	// 关闭LLM重排序客户端
+	if closer, ok := se.relevanceCompleter.(interface{ Close() error }); ok {
+		closer.Close()
+	}
+
 	se.logger.Info("Search engine closed")
 	return err
 }
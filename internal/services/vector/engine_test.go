@@ -0,0 +1,1791 @@
+package vector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+)
+
+// newTestSearchEngine 创建不依赖外部服务的测试搜索引擎
+func newTestSearchEngine(t *testing.T, embedder Embedder) *SearchEngine {
+	cfg := &config.Config{
+		VectorDB: config.VectorDBConfig{
+			CacheConfig: &config.VectorCacheConfig{
+				QueryVectorTTL:     time.Hour,
+				QueryVectorMaxSize: 100,
+				CleanupInterval:    time.Minute,
+			},
+		},
+	}
+	require.NoError(t, config.InitializeForTest(cfg))
+
+	cacheManager := NewVectorCacheManager(cfg)
+	t.Cleanup(func() { cacheManager.Close() })
+
+	return &SearchEngine{
+		embeddingService: embedder,
+		similarityCalc:   NewSimilarityCalculator(),
+		cacheManager:     cacheManager,
+		ranker:           NewRanker(),
+		searchFlights:    newSearchFlightGroup(),
+		config:           cfg.VectorDB,
+		logger:           logger.NewLogger("search-engine-test"),
+	}
+}
+
+// TestSearchEngine_RequestCoalescing 测试相同搜索请求合并
+func TestSearchEngine_RequestCoalescing(t *testing.T) {
+	t.Run("并发相同查询只调用一次向量化", func(t *testing.T) {
+		embedder := new(MockEmbeddingService)
+		embedErr := errors.NewMemoroError(errors.ErrorTypeLLM, errors.ErrCodeLLMAPICall, "embedding unavailable")
+		release := make(chan struct{})
+		embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { <-release }).
+			Return((*EmbeddingResult)(nil), embedErr)
+
+		engine := newTestSearchEngine(t, embedder)
+
+		const concurrency = 20
+		errs := make([]error, concurrency)
+		var wg sync.WaitGroup
+
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				_, errs[idx] = engine.Search(context.Background(), &SearchOptions{
+					Query:  "  人工智能   技术  ",
+					TopK:   5,
+					UserID: "user-1",
+				})
+			}(i)
+		}
+
+		// 所有请求都加入同一检索后再放行向量化
+		require.Eventually(t, func() bool { return engine.searchFlights.waiting() == concurrency }, 5*time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		embedder.AssertNumberOfCalls(t, "GenerateEmbedding", 1)
+		for _, err := range errs {
+			require.Error(t, err)
+			assert.Same(t, embedErr, err)
+		}
+		assert.Equal(t, 0, engine.searchFlights.InFlight())
+	})
+
+	t.Run("不同过滤条件不合并", func(t *testing.T) {
+		key1 := generateSearchFlightKey("golang", &SearchOptions{Query: "golang", TopK: 5, UserID: "user-1"})
+		key2 := generateSearchFlightKey("golang", &SearchOptions{Query: "golang", TopK: 5, UserID: "user-2"})
+		key3 := generateSearchFlightKey("golang", &SearchOptions{Query: "golang ", TopK: 5, UserID: "user-1"})
+
+		assert.NotEqual(t, key1, key2)
+		assert.Equal(t, key1, key3, "规范化后的相同查询应使用相同的合并键")
+	})
+}
+
+// TestSearchFlightGroup 测试搜索合并器
+func TestSearchFlightGroup(t *testing.T) {
+	t.Run("共享结果并标记", func(t *testing.T) {
+		group := newSearchFlightGroup()
+		release := make(chan struct{})
+		var calls int32
+		expected := &SearchResponse{TotalResults: 3}
+
+		const concurrency = 5
+		var wg sync.WaitGroup
+		sharedFlags := make([]bool, concurrency)
+		responses := make([]*SearchResponse, concurrency)
+
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				responses[idx], _, sharedFlags[idx] = group.Do(context.Background(), "key", func(ctx context.Context) (*SearchResponse, error) {
+					atomic.AddInt32(&calls, 1)
+					<-release
+					return expected, nil
+				})
+			}(i)
+		}
+
+		require.Eventually(t, func() bool { return group.waiting() == concurrency }, 5*time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		for i := 0; i < concurrency; i++ {
+			assert.Same(t, expected, responses[i])
+			assert.True(t, sharedFlags[i])
+		}
+	})
+
+	t.Run("发起方取消不影响其他等待方", func(t *testing.T) {
+		group := newSearchFlightGroup()
+		release := make(chan struct{})
+		expected := &SearchResponse{TotalResults: 1}
+		var flightErr error
+
+		leaderCtx, cancelLeader := context.WithCancel(context.Background())
+		leaderDone := make(chan error, 1)
+		go func() {
+			_, err, _ := group.Do(leaderCtx, "key", func(ctx context.Context) (*SearchResponse, error) {
+				<-release
+				flightErr = ctx.Err()
+				return expected, nil
+			})
+			leaderDone <- err
+		}()
+		require.Eventually(t, func() bool { return group.waiting() == 1 }, 5*time.Second, time.Millisecond)
+
+		waiterDone := make(chan *SearchResponse, 1)
+		go func() {
+			response, err, _ := group.Do(context.Background(), "key", func(ctx context.Context) (*SearchResponse, error) {
+				return nil, nil
+			})
+			assert.NoError(t, err)
+			waiterDone <- response
+		}()
+		require.Eventually(t, func() bool { return group.waiting() == 2 }, 5*time.Second, time.Millisecond)
+
+		cancelLeader()
+		assert.ErrorIs(t, <-leaderDone, context.Canceled)
+
+		close(release)
+		assert.Same(t, expected, <-waiterDone)
+		assert.NoError(t, flightErr, "仍有调用方等待时检索上下文不应被取消")
+	})
+
+	t.Run("所有调用方放弃后取消检索", func(t *testing.T) {
+		group := newSearchFlightGroup()
+		flightCancelled := make(chan struct{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			_, err, _ := group.Do(ctx, "key", func(ctx context.Context) (*SearchResponse, error) {
+				<-ctx.Done()
+				close(flightCancelled)
+				return nil, ctx.Err()
+			})
+			done <- err
+		}()
+		require.Eventually(t, func() bool { return group.waiting() == 1 }, 5*time.Second, time.Millisecond)
+
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+		<-flightCancelled
+		assert.Equal(t, 0, group.InFlight())
+	})
+
+	t.Run("共享响应深拷贝", func(t *testing.T) {
+		engine := newTestSearchEngine(t, new(MockEmbeddingService))
+		original := &SearchResponse{
+			Results: []*SearchResultItem{
+				{DocumentID: "doc-1", Metadata: map[string]interface{}{"title": "原标题"}, MatchedKeywords: []string{"go"}},
+			},
+			Metadata: map[string]interface{}{"final_count": 1},
+		}
+
+		copied := engine.copySharedResponse(original)
+		copied.Results[0].Metadata["title"] = "修改后"
+		copied.Results[0].MatchedKeywords[0] = "rust"
+		copied.Results[0].DocumentID = "doc-2"
+
+		assert.Equal(t, true, copied.Metadata["coalesced"])
+		assert.Equal(t, 1, copied.Metadata["final_count"])
+		_, exists := original.Metadata["coalesced"]
+		assert.False(t, exists)
+		assert.Equal(t, "原标题", original.Results[0].Metadata["title"])
+		assert.Equal(t, "go", original.Results[0].MatchedKeywords[0])
+		assert.Equal(t, "doc-1", original.Results[0].DocumentID)
+	})
+}
+
+// TestSearchEngine_RankingStrategy 测试排序策略
+func TestSearchEngine_RankingStrategy(t *testing.T) {
+	now := time.Now()
+	newResults := func() []*SearchResultItem {
+		return []*SearchResultItem{
+			{DocumentID: "old-important", Similarity: 0.9, RelevanceScore: 0.9, CreatedAt: now.Add(-72 * time.Hour),
+				Metadata: map[string]interface{}{"importance_score": 9.0}},
+			{DocumentID: "newest", Similarity: 0.7, RelevanceScore: 0.7, CreatedAt: now,
+				Metadata: map[string]interface{}{"importance_score": 3.0}},
+			{DocumentID: "middle", Similarity: 0.8, RelevanceScore: 0.8, CreatedAt: now.Add(-24 * time.Hour),
+				Metadata: map[string]interface{}{"importance_score": 6.0}},
+		}
+	}
+
+	engine := newTestSearchEngine(t, new(MockEmbeddingService))
+
+	t.Run("按时间排序最新在前", func(t *testing.T) {
+		ranked := engine.rerankResults(context.Background(), newResults(), &SearchOptions{RankingStrategy: RankingStrategyTime})
+
+		require.Len(t, ranked, 3)
+		assert.Equal(t, "newest", ranked[0].DocumentID)
+		assert.Equal(t, "middle", ranked[1].DocumentID)
+		assert.Equal(t, "old-important", ranked[2].DocumentID)
+	})
+
+	t.Run("按重要性排序最重要在前", func(t *testing.T) {
+		ranked := engine.rerankResults(context.Background(), newResults(), &SearchOptions{RankingStrategy: RankingStrategyImportance})
+
+		require.Len(t, ranked, 3)
+		assert.Equal(t, "old-important", ranked[0].DocumentID)
+		assert.Equal(t, "middle", ranked[1].DocumentID)
+		assert.Equal(t, "newest", ranked[2].DocumentID)
+	})
+
+	t.Run("拒绝未知排序策略", func(t *testing.T) {
+		embedder := new(MockEmbeddingService)
+		engine := newTestSearchEngine(t, embedder)
+
+		_, err := engine.Search(context.Background(), &SearchOptions{Query: "golang", RankingStrategy: "random"})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ranking_strategy")
+		embedder.AssertNotCalled(t, "GenerateEmbedding", mock.Anything, mock.Anything)
+	})
+
+	t.Run("使用配置默认排序策略", func(t *testing.T) {
+		embedder := new(MockEmbeddingService)
+		embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+			Return((*EmbeddingResult)(nil), errors.ErrValidationFailed("text", "test"))
+		engine := newTestSearchEngine(t, embedder)
+		engine.config.DefaultRankingStrategy = string(RankingStrategyImportance)
+
+		options := &SearchOptions{Query: "golang"}
+		_, _ = engine.Search(context.Background(), options)
+
+		assert.Equal(t, RankingStrategyImportance, options.RankingStrategy)
+	})
+}
+
+// TestSearchEngine_AccessImportance 测试查询时按访问频率和最近访问调整重要性
+func TestSearchEngine_AccessImportance(t *testing.T) {
+	now := time.Now()
+	newResults := func() []*SearchResultItem {
+		return []*SearchResultItem{
+			{DocumentID: "rarely-read", Similarity: 0.8, RelevanceScore: 0.8, CreatedAt: now.Add(-48 * time.Hour),
+				Metadata: map[string]interface{}{"importance_score": 9.0}},
+			{DocumentID: "often-read", Similarity: 0.8, RelevanceScore: 0.8, CreatedAt: now.Add(-48 * time.Hour),
+				Metadata: map[string]interface{}{"importance_score": 2.0}},
+		}
+	}
+	newInteractions := func() *InteractionStore {
+		interactions := NewInteractionStore()
+		for i := 0; i < 10; i++ {
+			interactions.RecordInteraction("user-1", "often-read", now.Add(-time.Duration(i)*time.Hour))
+		}
+		interactions.RecordInteraction("user-1", "rarely-read", now.Add(-20*24*time.Hour))
+		return interactions
+	}
+	accessConfig := &config.AccessImportanceConfig{Weight: 0.5}
+
+	t.Run("经常访问的低重要性文档排在很少访问的高重要性文档之前", func(t *testing.T) {
+		engine := newTestSearchEngine(t, new(MockEmbeddingService))
+		engine.config.AccessImportance = accessConfig
+		engine.SetInteractionStore(newInteractions())
+
+		ranked := engine.rerankResults(context.Background(), newResults(), &SearchOptions{
+			UserID:          "user-1",
+			RankingStrategy: RankingStrategyImportance,
+		})
+
+		require.Len(t, ranked, 2)
+		assert.Equal(t, "often-read", ranked[0].DocumentID)
+		assert.Equal(t, "rarely-read", ranked[1].DocumentID)
+
+		// 分数分解说明混合前后的重要性，存储的重要性不变
+		require.NotNil(t, ranked[0].ScoreBreakdown)
+		assert.InDelta(t, 0.2, ranked[0].ScoreBreakdown.BaseImportanceScore, 1e-9)
+		assert.Greater(t, ranked[0].ScoreBreakdown.AccessScore, ranked[1].ScoreBreakdown.AccessScore)
+		assert.Greater(t, ranked[0].ScoreBreakdown.ImportanceScore, ranked[1].ScoreBreakdown.ImportanceScore)
+		assert.Equal(t, 2.0, ranked[0].Metadata["importance_score"])
+	})
+
+	t.Run("只计入当前用户的访问", func(t *testing.T) {
+		engine := newTestSearchEngine(t, new(MockEmbeddingService))
+		engine.config.AccessImportance = accessConfig
+		engine.SetInteractionStore(newInteractions())
+
+		ranked := engine.rerankResults(context.Background(), newResults(), &SearchOptions{
+			UserID:          "user-2",
+			RankingStrategy: RankingStrategyImportance,
+		})
+
+		require.Len(t, ranked, 2)
+		assert.Equal(t, "rarely-read", ranked[0].DocumentID)
+	})
+
+	t.Run("权重为0时只使用存储的重要性", func(t *testing.T) {
+		engine := newTestSearchEngine(t, new(MockEmbeddingService))
+		engine.SetInteractionStore(newInteractions())
+
+		ranked := engine.rerankResults(context.Background(), newResults(), &SearchOptions{
+			UserID:          "user-1",
+			RankingStrategy: RankingStrategyImportance,
+		})
+
+		require.Len(t, ranked, 2)
+		assert.Equal(t, "rarely-read", ranked[0].DocumentID)
+		assert.Equal(t, 0.0, ranked[0].ScoreBreakdown.AccessScore)
+	})
+
+	t.Run("热门分数使用混合后的重要性", func(t *testing.T) {
+		documents := []*VectorDocument{
+			{ID: "rarely-read", Metadata: map[string]interface{}{"importance_score": 9.0}, CreatedAt: now.Add(-48 * time.Hour)},
+			{ID: "often-read", Metadata: map[string]interface{}{"importance_score": 2.0}, CreatedAt: now.Add(-48 * time.Hour)},
+		}
+		timeRange := &TimeRange{StartTime: now.Add(-30 * 24 * time.Hour), EndTime: now}
+		window := TrendingWindow{TimeWindow: 30 * 24 * time.Hour, DecayCurve: TrendingDecayLinear}
+
+		plain := analyzeTrending(documents, timeRange, nil, nil, window, nil)
+		assert.Greater(t, plain.DocumentScores["rarely-read"], plain.DocumentScores["often-read"])
+
+		access := newAccessImportance(accessConfig, newInteractions(), "", now)
+		blended := analyzeTrending(documents, timeRange, nil, nil, window, access)
+		assert.Greater(t, blended.DocumentScores["often-read"], blended.DocumentScores["rarely-read"])
+	})
+}
+
+// TestSearchEngine_UpdateDocumentMetadata 测试仅更新元数据不重新向量化
+func TestSearchEngine_UpdateDocumentMetadata(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	originalEmbedding := []float32{0.1, 0.2, 0.3}
+	fake.put("doc-1", "Go语言并发编程实践", originalEmbedding, map[string]interface{}{
+		"user_id":          "user-1",
+		"tags":             []interface{}{"go"},
+		"importance_score": 0.5,
+		"created_at":       float64(1700000000),
+	})
+
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{0.1, 0.2, 0.3}, Dimension: 3}, nil)
+
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = newTestChromaClient(t, fake)
+	ctx := context.Background()
+
+	searchByTag := func(tag string) *SearchResponse {
+		response, err := engine.Search(ctx, &SearchOptions{
+			Query: "并发编程 " + tag,
+			TopK:  10,
+			Tags:  []string{tag},
+		})
+		require.NoError(t, err)
+		return response
+	}
+
+	require.Empty(t, searchByTag("concurrency").Results)
+
+	err := engine.UpdateDocumentMetadata(ctx, "doc-1", map[string]interface{}{
+		"tags": []string{"go", "concurrency"},
+	})
+	require.NoError(t, err)
+
+	t.Run("向量保持不变", func(t *testing.T) {
+		doc, err := engine.store.GetDocument(ctx, "doc-1")
+		require.NoError(t, err)
+
+		assert.Equal(t, originalEmbedding, doc.Embedding)
+		assert.Equal(t, []interface{}{"go", "concurrency"}, doc.Metadata["tags"])
+		assert.Equal(t, 0.5, doc.Metadata["importance_score"])
+		embedder.AssertNotCalled(t, "CreateContentVector", mock.Anything, mock.Anything)
+	})
+
+	t.Run("标签过滤可检索到文档", func(t *testing.T) {
+		response := searchByTag("concurrency")
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, "doc-1", response.Results[0].DocumentID)
+	})
+
+	t.Run("拒绝修改索引管理的字段", func(t *testing.T) {
+		err := engine.UpdateDocumentMetadata(ctx, "doc-1", map[string]interface{}{"created_at": 0})
+		assert.Error(t, err)
+	})
+}
+
+// TestSearchEngine_CollapseDuplicates 测试折叠近似重复的搜索结果
+func TestSearchEngine_CollapseDuplicates(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	fake.put("doc-a", "Go语言并发编程实践", []float32{0.8, 0.6, 0.0}, map[string]interface{}{"user_id": "user-1"})
+	fake.put("doc-b", "Go语言并发编程实践（转载）", []float32{0.79, 0.61, 0.01}, map[string]interface{}{"user_id": "user-1"})
+	fake.put("doc-c", "Rust所有权机制", []float32{0.3, 0.6, 0.74}, map[string]interface{}{"user_id": "user-1"})
+
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{0.8, 0.59, 0.0}, Dimension: 3}, nil)
+
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = newTestChromaClient(t, fake)
+	ctx := context.Background()
+
+	t.Run("近似重复结果被折叠到排名靠前的结果", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{
+			Query:              "并发编程",
+			TopK:               10,
+			CollapseDuplicates: true,
+		})
+		require.NoError(t, err)
+
+		require.Len(t, response.Results, 2)
+		assert.Equal(t, "doc-a", response.Results[0].DocumentID)
+		assert.Equal(t, []string{"doc-b"}, response.Results[0].DuplicateOf)
+		assert.Equal(t, "doc-c", response.Results[1].DocumentID)
+		assert.Empty(t, response.Results[1].DuplicateOf)
+		assert.Equal(t, 1, response.Metadata["collapsed_duplicates"])
+	})
+
+	t.Run("阈值过高时不折叠", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{
+			Query:              "并发编程",
+			TopK:               10,
+			CollapseDuplicates: true,
+			DuplicateThreshold: 0.99999,
+		})
+		require.NoError(t, err)
+		assert.Len(t, response.Results, 3)
+	})
+
+	t.Run("未开启时返回全部结果", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "并发编程", TopK: 10})
+		require.NoError(t, err)
+
+		require.Len(t, response.Results, 3)
+		for _, result := range response.Results {
+			assert.Empty(t, result.DuplicateOf)
+		}
+		assert.NotContains(t, response.Metadata, "collapsed_duplicates")
+	})
+}
+
+// TestSearchEngine_BatchIndexDocuments 测试批量索引返回逐文档报告
+func TestSearchEngine_BatchIndexDocuments(t *testing.T) {
+	newItem := func(id, content string) *models.ContentItem {
+		return &models.ContentItem{ID: id, Type: models.ContentTypeText, RawContent: content, UserID: "user-1"}
+	}
+
+	items := []*models.ContentItem{
+		newItem("doc-1", "Go语言并发编程"),
+		newItem("doc-2", ""),
+		newItem("doc-3", "Rust所有权机制"),
+		newItem("doc-4", ""),
+	}
+
+	newEmbedder := func() *MockEmbeddingService {
+		embedder := new(MockEmbeddingService)
+		for _, item := range items {
+			if item.RawContent == "" {
+				embedder.On("CreateContentVector", mock.Anything, item).
+					Return((*VectorDocument)(nil), errors.ErrValidationFailed("text", "cannot be empty"))
+				continue
+			}
+			embedder.On("CreateContentVector", mock.Anything, item).Return(&VectorDocument{
+				ID:        item.ID,
+				Content:   item.RawContent,
+				Embedding: []float32{0.1, 0.2, 0.3},
+				Metadata:  map[string]interface{}{"user_id": item.UserID},
+				CreatedAt: time.Now(),
+			}, nil)
+		}
+		return embedder
+	}
+
+	t.Run("向量化失败的文档单独列出", func(t *testing.T) {
+		fake := newFakeChromaServer(t)
+		engine := newTestSearchEngine(t, newEmbedder())
+		engine.store = newTestChromaClient(t, fake)
+
+		report, err := engine.BatchIndexDocuments(context.Background(), items)
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, report.Total)
+		assert.Equal(t, []string{"doc-1", "doc-3"}, report.SucceededIDs)
+		assert.True(t, report.HasFailures())
+		assert.Equal(t, []string{"doc-2", "doc-4"}, report.FailedIDs())
+		for _, failure := range report.Failures {
+			assert.Equal(t, BatchIndexStageEmbedding, failure.Stage)
+			assert.NotEmpty(t, failure.Reason)
+		}
+		assert.Equal(t, 1, fake.addCalls)
+		assert.Contains(t, fake.records, "doc-1")
+		assert.Contains(t, fake.records, "doc-3")
+	})
+
+	t.Run("写入失败归因到具体文档", func(t *testing.T) {
+		fake := newFakeChromaServer(t)
+		fake.rejectIDs["doc-3"] = true
+		engine := newTestSearchEngine(t, newEmbedder())
+		engine.store = newTestChromaClient(t, fake)
+
+		report, err := engine.BatchIndexDocuments(context.Background(), items)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"doc-1"}, report.SucceededIDs)
+		require.Len(t, report.Failures, 3)
+		chromaFailure := report.Failures[2]
+		assert.Equal(t, "doc-3", chromaFailure.DocumentID)
+		assert.Equal(t, BatchIndexStageChroma, chromaFailure.Stage)
+		assert.Contains(t, chromaFailure.Reason, "Chroma")
+		assert.NotContains(t, fake.records, "doc-3")
+	})
+
+	t.Run("空列表返回校验错误", func(t *testing.T) {
+		engine := newTestSearchEngine(t, newEmbedder())
+		_, err := engine.BatchIndexDocuments(context.Background(), nil)
+		assert.Error(t, err)
+	})
+}
+
+// TestSearchEngine_AuditLog 测试变更操作写入审计日志
+func TestSearchEngine_AuditLog(t *testing.T) {
+	item := &models.ContentItem{
+		ID:              "doc-1",
+		Type:            models.ContentTypeText,
+		RawContent:      "Go语言并发编程",
+		UserID:          "user-1",
+		ImportanceScore: 0.6,
+	}
+
+	newAuditedEngine := func() (*SearchEngine, *bytes.Buffer) {
+		embedder := new(MockEmbeddingService)
+		embedder.On("CreateContentVector", mock.Anything, item).Return(&VectorDocument{
+			ID:        item.ID,
+			Content:   item.RawContent,
+			Embedding: []float32{0.1, 0.2, 0.3},
+			Metadata: map[string]interface{}{
+				"user_id":          item.UserID,
+				"content_type":     string(item.Type),
+				"importance_score": item.ImportanceScore,
+			},
+			CreatedAt: time.Now(),
+		}, nil)
+
+		var buf bytes.Buffer
+		engine := newTestSearchEngine(t, embedder)
+		engine.store = newTestChromaClient(t, newFakeChromaServer(t))
+		engine.auditLogger = logger.NewAuditLogger(&buf)
+		return engine, &buf
+	}
+
+	readRecords := func(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+		records := make([]map[string]interface{}, 0)
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			records = append(records, record)
+		}
+		return records
+	}
+
+	t.Run("索引写入一条审计记录", func(t *testing.T) {
+		engine, buf := newAuditedEngine()
+		ctx := context.WithValue(context.Background(), "actor", "api:user-1")
+
+		require.NoError(t, engine.IndexDocument(ctx, item))
+
+		records := readRecords(t, buf)
+		require.Len(t, records, 1)
+		record := records[0]
+		assert.Equal(t, float64(logger.AuditSchemaVersion), record["schema_version"])
+		assert.Equal(t, "index", record["action"])
+		assert.Equal(t, "doc-1", record["document_id"])
+		assert.Equal(t, "user-1", record["user_id"])
+		assert.Equal(t, "api:user-1", record["actor"])
+		assert.NotEmpty(t, record["timestamp"])
+		assert.Nil(t, record["before"])
+
+		after, ok := record["after"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "text", after["content_type"])
+		assert.Equal(t, 0.6, after["importance_score"])
+	})
+
+	t.Run("删除写入一条审计记录", func(t *testing.T) {
+		engine, buf := newAuditedEngine()
+		require.NoError(t, engine.IndexDocument(context.Background(), item))
+		buf.Reset()
+
+		require.NoError(t, engine.DeleteDocument(context.Background(), "doc-1"))
+
+		records := readRecords(t, buf)
+		require.Len(t, records, 1)
+		record := records[0]
+		assert.Equal(t, "delete", record["action"])
+		assert.Equal(t, "doc-1", record["document_id"])
+		assert.Equal(t, "user-1", record["user_id"])
+		assert.Equal(t, logger.DefaultAuditActor, record["actor"])
+		assert.Nil(t, record["after"])
+
+		before, ok := record["before"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "text", before["content_type"])
+	})
+
+	t.Run("未启用时不写入", func(t *testing.T) {
+		engine, buf := newAuditedEngine()
+		engine.auditLogger = nil
+
+		require.NoError(t, engine.IndexDocument(context.Background(), item))
+		assert.Empty(t, buf.String())
+	})
+}
+
+// TestSearchEngine_SearchByVector 测试直接使用向量检索
+func TestSearchEngine_SearchByVector(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	fake.put("doc-a", "Go语言并发编程", []float32{0.9, 0.1, 0.1}, map[string]interface{}{"user_id": "user-1"})
+	fake.put("doc-b", "Rust所有权机制", []float32{0.1, 0.9, 0.2}, map[string]interface{}{"user_id": "user-1"})
+	fake.put("doc-c", "分布式一致性", []float32{0.2, 0.3, 0.9}, map[string]interface{}{"user_id": "user-1"})
+
+	embedder := new(MockEmbeddingService)
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = newTestChromaClient(t, fake)
+	ctx := context.Background()
+
+	t.Run("文档自身向量排名第一", func(t *testing.T) {
+		response, err := engine.SearchByVector(ctx, []float32{0.1, 0.9, 0.2}, &SearchOptions{TopK: 3})
+		require.NoError(t, err)
+
+		require.NotEmpty(t, response.Results)
+		assert.Equal(t, "doc-b", response.Results[0].DocumentID)
+		assert.InDelta(t, 0, response.Results[0].Distance, 0.0001)
+		assert.Equal(t, 3, response.VectorDimension)
+		embedder.AssertNotCalled(t, "GenerateEmbedding", mock.Anything, mock.Anything)
+	})
+
+	t.Run("维度不匹配", func(t *testing.T) {
+		_, err := engine.SearchByVector(ctx, []float32{0.1, 0.9}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "dimension mismatch")
+	})
+
+	t.Run("参数校验", func(t *testing.T) {
+		_, err := engine.SearchByVector(ctx, nil, nil)
+		assert.Error(t, err)
+
+		_, err = engine.SearchByVector(ctx, []float32{float32(math.NaN()), 0.9, 0.2}, nil)
+		assert.Error(t, err)
+	})
+}
+
+// newTestEmbeddingService 创建连接到模拟embedding API的向量化服务（固定返回三维向量）
+func newTestEmbeddingService(t *testing.T) *EmbeddingService {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2,0.3]}],"model":"test-embedding","usage":{"prompt_tokens":10,"total_tokens":10}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	return &EmbeddingService{
+		httpClient:         resty.New().SetBaseURL(server.URL),
+		config:             config.LLMConfig{Model: "test-embedding"},
+		truncationStrategy: TruncationHead,
+		logger:             logger.NewLogger("embedding-service-test"),
+	}
+}
+
+// TestSearchEngine_MetadataFilters 测试按自定义元数据过滤搜索
+func TestSearchEngine_MetadataFilters(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	engine := newTestSearchEngine(t, newTestEmbeddingService(t))
+	engine.store = newTestChromaClient(t, fake)
+	ctx := context.Background()
+
+	index := func(content string, projectID int) string {
+		item := models.NewContentItem(models.ContentTypeText, content, "user-1")
+		require.NotNil(t, item)
+		require.NoError(t, item.SetProcessedData(map[string]interface{}{
+			CustomMetadataKey: map[string]interface{}{"source": "slack", "project_id": projectID, "user_id": "spoofed"},
+		}))
+		require.NoError(t, engine.IndexDocument(ctx, item))
+		return item.ID
+	}
+	goID := index("Go语言并发编程", 42)
+	index("Rust所有权机制", 7)
+
+	t.Run("自定义元数据写入索引且不覆盖系统字段", func(t *testing.T) {
+		for _, record := range fake.records {
+			assert.Equal(t, "slack", record.metadata["source"])
+			assert.Equal(t, "user-1", record.metadata["user_id"])
+		}
+	})
+
+	t.Run("按project_id过滤", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{
+			Query:           "编程",
+			TopK:            10,
+			MetadataFilters: map[string]interface{}{"project_id": 42},
+		})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, goID, response.Results[0].DocumentID)
+	})
+
+	t.Run("数组按任一元素匹配", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{
+			Query:           "编程",
+			TopK:            10,
+			MetadataFilters: map[string]interface{}{"project_id": []int{7, 42}},
+		})
+		require.NoError(t, err)
+		assert.Len(t, response.Results, 2)
+	})
+
+	t.Run("无效的过滤值", func(t *testing.T) {
+		_, err := engine.Search(ctx, &SearchOptions{
+			Query:           "编程",
+			MetadataFilters: map[string]interface{}{"project_id": map[string]interface{}{"$gt": 1}},
+		})
+		assert.Error(t, err)
+	})
+}
+
+// TestSearchEngine_CompletenessFilters 测试按摘要、标签和向量化状态过滤
+func TestSearchEngine_CompletenessFilters(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	engine := newTestSearchEngine(t, newTestEmbeddingService(t))
+	engine.store = newTestChromaClient(t, fake)
+	ctx := context.Background()
+
+	index := func(content, summary string, tags []string) string {
+		item := models.NewContentItem(models.ContentTypeText, content, "user-1")
+		require.NotNil(t, item)
+		require.NoError(t, item.SetSummary(models.Summary{OneLine: summary}))
+		require.NoError(t, item.SetTags(tags))
+		require.NoError(t, engine.IndexDocument(ctx, item))
+		return item.ID
+	}
+	complete := index("Go语言并发编程", "Go并发", []string{"go"})
+	noSummary := index("Rust所有权机制", "", []string{"rust"})
+	noTags := index("分布式一致性", "Raft协议", nil)
+	// 未生成向量的占位记录
+	fake.put("pending", "待向量化内容", nil, map[string]interface{}{
+		"user_id": "user-1", MetadataKeyHasSummary: false, MetadataKeyHasTags: false, MetadataKeyIndexed: false,
+	})
+
+	search := func(options *SearchOptions) []string {
+		options.Query = "内容"
+		options.TopK = 10
+		response, err := engine.Search(ctx, options)
+		require.NoError(t, err)
+
+		ids := make([]string, 0, len(response.Results))
+		for _, result := range response.Results {
+			ids = append(ids, result.DocumentID)
+		}
+		return ids
+	}
+	yes, no := true, false
+
+	t.Run("索引时写入完整性标记", func(t *testing.T) {
+		metadata := fake.records[complete].metadata
+		assert.Equal(t, true, metadata[MetadataKeyHasSummary])
+		assert.Equal(t, true, metadata[MetadataKeyHasTags])
+		assert.Equal(t, true, metadata[MetadataKeyIndexed])
+	})
+
+	t.Run("有摘要", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{complete, noTags}, search(&SearchOptions{RequireSummary: &yes}))
+	})
+
+	t.Run("缺少摘要", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{noSummary, "pending"}, search(&SearchOptions{RequireSummary: &no}))
+	})
+
+	t.Run("缺少标签", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{noTags, "pending"}, search(&SearchOptions{RequireTags: &no}))
+	})
+
+	t.Run("未向量化", func(t *testing.T) {
+		assert.Equal(t, []string{"pending"}, search(&SearchOptions{RequireIndexed: &no}))
+	})
+
+	t.Run("组合过滤", func(t *testing.T) {
+		assert.Equal(t, []string{complete}, search(&SearchOptions{RequireSummary: &yes, RequireTags: &yes, RequireIndexed: &yes}))
+	})
+
+	t.Run("未设置时不过滤", func(t *testing.T) {
+		assert.Len(t, search(&SearchOptions{}), 4)
+	})
+}
+
+// TestSearchEngine_LanguageFilter 测试按内容语言过滤搜索
+func TestSearchEngine_LanguageFilter(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	engine := newTestSearchEngine(t, newTestEmbeddingService(t))
+	engine.store = newTestChromaClient(t, fake)
+	ctx := context.Background()
+
+	index := func(content, lang string, tags []string) string {
+		item := models.NewContentItem(models.ContentTypeText, content, "user-1")
+		require.NotNil(t, item)
+		if lang != "" {
+			require.NoError(t, item.SetProcessedData(map[string]interface{}{MetadataKeyLanguage: lang}))
+		}
+		require.NoError(t, item.SetTags(tags))
+		require.NoError(t, engine.IndexDocument(ctx, item))
+		return item.ID
+	}
+	zhID := index("Go语言并发编程实践", "zh", []string{"go"})
+	enID := index("Concurrency patterns in Go", "en", []string{"go"})
+	enRustID := index("Ownership and borrowing in Rust", "en", []string{"rust"})
+	// 语言字段加入前索引的文档
+	legacyID := index("Go concurrency notes", "", []string{"go"})
+
+	search := func(options *SearchOptions) []string {
+		options.Query = "concurrency"
+		options.TopK = 10
+		response, err := engine.Search(ctx, options)
+		require.NoError(t, err)
+
+		ids := make([]string, 0, len(response.Results))
+		for _, result := range response.Results {
+			ids = append(ids, result.DocumentID)
+		}
+		return ids
+	}
+
+	t.Run("索引时写入语言", func(t *testing.T) {
+		assert.Equal(t, "zh", fake.records[zhID].metadata[MetadataKeyLanguage])
+		assert.NotContains(t, fake.records[legacyID].metadata, MetadataKeyLanguage)
+	})
+
+	t.Run("只返回指定语言", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{enID, enRustID}, search(&SearchOptions{Languages: []string{"en"}}))
+		assert.ElementsMatch(t, []string{zhID}, search(&SearchOptions{Languages: []string{"zh"}}))
+	})
+
+	t.Run("与标签过滤组合", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{enID}, search(&SearchOptions{Languages: []string{"en"}, Tags: []string{"go"}}))
+	})
+
+	t.Run("可选包含没有语言信息的文档", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{enID, legacyID}, search(&SearchOptions{
+			Languages:              []string{"en"},
+			Tags:                   []string{"go"},
+			IncludeUnknownLanguage: true,
+		}))
+	})
+}
+
+// TestSearchEngine_FieldBoosts 测试查询词命中标题等字段时的加分
+func TestSearchEngine_FieldBoosts(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	engine := newTestSearchEngine(t, newTestEmbeddingService(t))
+	engine.store = newTestChromaClient(t, fake)
+	ctx := context.Background()
+
+	// 两个文档正文都包含查询词且向量距离相同，只有一个在标题中命中
+	fake.put("doc-body", "本文介绍kubernetes集群的日常运维经验", nil, map[string]interface{}{
+		"user_id": "user-1",
+		"title":   "运维笔记",
+	})
+	fake.put("doc-title", "本文介绍kubernetes集群的日常运维经验", nil, map[string]interface{}{
+		"user_id": "user-1",
+		"title":   "Kubernetes运维笔记",
+	})
+	fake.distances["doc-body"] = 0.2
+	fake.distances["doc-title"] = 0.2
+
+	search := func() []*SearchResultItem {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "kubernetes", TopK: 10, IncludeContent: true, EnableReranking: true})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 2)
+		return response.Results
+	}
+
+	t.Run("标题命中排在仅正文命中之前", func(t *testing.T) {
+		results := search()
+		assert.Equal(t, "doc-title", results[0].DocumentID)
+		assert.Greater(t, results[0].RelevanceScore, results[1].RelevanceScore)
+	})
+
+	t.Run("负数关闭字段加分", func(t *testing.T) {
+		engine.config.FieldBoosts = &config.FieldBoostsConfig{Title: -1}
+		defer func() { engine.config.FieldBoosts = nil }()
+
+		results := search()
+		assert.Equal(t, results[0].RelevanceScore, results[1].RelevanceScore)
+	})
+}
+
+// TestSearchEngine_QueryTermWeighting 测试关键词分数只统计有实际含义的查询词
+func TestSearchEngine_QueryTermWeighting(t *testing.T) {
+	engine := newTestSearchEngine(t, newTestEmbeddingService(t))
+	query := "What is the best database?"
+	options := &SearchOptions{Query: query}
+
+	score := func(content string, docs []*VectorDocument) float64 {
+		matched := engine.extractMatchedKeywords(query, content, nil)
+		weights := engine.queryTermWeights(engine.queryTerms(query), docs)
+		return engine.calculateRelevanceScore(0, matched, weights, nil, options)
+	}
+
+	t.Run("排除停用词", func(t *testing.T) {
+		assert.Equal(t, []string{"best", "database"}, engine.queryTerms(query))
+		assert.Equal(t, []string{"best", "database"}, engine.extractMatchedKeywords(query, "The best database for analytics is a column store", nil))
+	})
+
+	t.Run("按内容词计算关键词分数", func(t *testing.T) {
+		assert.InDelta(t, 0.2, score("The best database for analytics is a column store", nil), 1e-9)
+		assert.InDelta(t, 0.1, score("Which database should I pick?", nil), 1e-9)
+		assert.Zero(t, score("What is the answer to this question?", nil))
+	})
+
+	t.Run("保留停用词时沿用旧行为", func(t *testing.T) {
+		engine.config.QueryTerms = &config.QueryTermsConfig{KeepStopwords: true}
+		defer func() { engine.config.QueryTerms = nil }()
+
+		assert.Equal(t, []string{"what", "the", "best", "database"}, engine.queryTerms(query))
+		assert.InDelta(t, 0.2*3/4, score("The best database for analytics", nil), 1e-9)
+	})
+
+	t.Run("额外停用词", func(t *testing.T) {
+		engine.config.QueryTerms = &config.QueryTermsConfig{ExtraStopwords: []string{"Best"}}
+		defer func() { engine.config.QueryTerms = nil }()
+
+		assert.Equal(t, []string{"database"}, engine.queryTerms(query))
+	})
+
+	t.Run("IDF加权时少见的词权重更高", func(t *testing.T) {
+		engine.config.QueryTerms = &config.QueryTermsConfig{IDFWeighting: true}
+		defer func() { engine.config.QueryTerms = nil }()
+
+		docs := []*VectorDocument{
+			{Content: "The best database for analytics"},
+			{Content: "Database indexing basics"},
+			{Content: "Database replication notes"},
+		}
+		weights := engine.queryTermWeights(engine.queryTerms(query), docs)
+		assert.Greater(t, weights["best"], weights["database"])
+
+		// 只命中少见词的文档得分高于只命中常见词的文档
+		assert.Greater(t, score("the best choice", docs), score("database basics", docs))
+	})
+}
+
+// TestSearchEngine_SearchResultCache 测试完整搜索结果缓存
+func TestSearchEngine_SearchResultCache(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	fake.put("doc-a", "Go语言并发编程", []float32{0.9, 0.1, 0.1}, map[string]interface{}{"user_id": "user-1"})
+
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{0.9, 0.1, 0.1}, Dimension: 3}, nil)
+
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = newTestChromaClient(t, fake)
+	engine.cacheManager.config.SearchResultTTL = time.Hour
+	ctx := context.Background()
+
+	search := func() *SearchResponse {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "并发编程", TopK: 10, UserID: "user-1"})
+		require.NoError(t, err)
+		return response
+	}
+
+	first := search()
+	require.Len(t, first.Results, 1)
+	assert.NotContains(t, first.Metadata, "cached")
+
+	// 绕过引擎直接写入，集合版本不变
+	fake.put("doc-b", "Go语言并发模式", []float32{0.85, 0.15, 0.1}, map[string]interface{}{"user_id": "user-1"})
+
+	t.Run("相同搜索命中缓存", func(t *testing.T) {
+		response := search()
+		assert.Len(t, response.Results, 1)
+		assert.Equal(t, true, response.Metadata["cached"])
+		assert.Equal(t, int64(1), engine.cacheManager.GetStats().SearchResultHits)
+
+		// 修改返回的元数据不影响缓存内容
+		response.Metadata["cached"] = false
+		response.Results[0].Metadata["user_id"] = "changed"
+		cached := search()
+		assert.Equal(t, true, cached.Metadata["cached"])
+		assert.Equal(t, "user-1", cached.Results[0].Metadata["user_id"])
+	})
+
+	t.Run("不同过滤条件不共享缓存", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "并发编程", TopK: 5, UserID: "user-1"})
+		require.NoError(t, err)
+		assert.NotContains(t, response.Metadata, "cached")
+	})
+
+	t.Run("索引变更递增版本后未命中", func(t *testing.T) {
+		versionBefore := engine.collectionVersion()
+		require.NoError(t, engine.UpdateDocumentMetadata(ctx, "doc-a", map[string]interface{}{"tags": []string{"go"}}))
+		assert.Greater(t, engine.collectionVersion(), versionBefore)
+
+		response := search()
+		assert.Len(t, response.Results, 2)
+		assert.NotContains(t, response.Metadata, "cached")
+		assert.Equal(t, true, search().Metadata["cached"])
+	})
+
+	t.Run("其他引擎实例的写入同样使缓存失效", func(t *testing.T) {
+		assert.Equal(t, true, search().Metadata["cached"])
+
+		writer := newTestSearchEngine(t, embedder)
+		writer.store = engine.store
+		require.NoError(t, writer.UpdateDocumentMetadata(ctx, "doc-b", map[string]interface{}{"tags": []string{"go"}}))
+
+		assert.NotContains(t, search().Metadata, "cached")
+	})
+
+	t.Run("启用访问重要性时记录交互使缓存失效", func(t *testing.T) {
+		engine.SetInteractionStore(NewInteractionStore())
+
+		assert.Equal(t, true, search().Metadata["cached"])
+		engine.RecordInteraction("user-1", "doc-a")
+		assert.Equal(t, true, search().Metadata["cached"])
+
+		engine.config.AccessImportance = &config.AccessImportanceConfig{Weight: 0.5}
+		defer func() { engine.config.AccessImportance = nil }()
+		engine.RecordInteraction("user-1", "doc-a")
+		assert.NotContains(t, search().Metadata, "cached")
+		assert.Equal(t, true, search().Metadata["cached"])
+
+		engine.Recommender().RecordInteraction("user-1", "doc-b")
+		assert.NotContains(t, search().Metadata, "cached")
+	})
+
+	t.Run("过期后重新检索", func(t *testing.T) {
+		engine.cacheManager.searchResultMutex.Lock()
+		for _, cached := range engine.cacheManager.searchResultCache {
+			cached.CachedAt = time.Now().Add(-2 * time.Hour)
+		}
+		engine.cacheManager.searchResultMutex.Unlock()
+
+		response := search()
+		assert.NotContains(t, response.Metadata, "cached")
+		assert.Equal(t, true, search().Metadata["cached"])
+	})
+
+	t.Run("未配置TTL时不缓存", func(t *testing.T) {
+		engine.cacheManager.config.SearchResultTTL = 0
+		defer func() { engine.cacheManager.config.SearchResultTTL = time.Hour }()
+
+		search()
+		assert.NotContains(t, search().Metadata, "cached")
+	})
+}
+
+// TestSearchEngine_MemoryStore 测试使用内存向量存储的完整索引和搜索流程（不依赖外部服务）
+func TestSearchEngine_MemoryStore(t *testing.T) {
+	items := map[string]*models.ContentItem{
+		"doc-go":   {ID: "doc-go", Type: models.ContentTypeText, RawContent: "Go语言并发编程", UserID: "user-1"},
+		"doc-rust": {ID: "doc-rust", Type: models.ContentTypeText, RawContent: "Rust所有权机制", UserID: "user-1"},
+		"doc-db":   {ID: "doc-db", Type: models.ContentTypeLink, RawContent: "分布式数据库一致性", UserID: "user-2"},
+	}
+	embeddings := map[string][]float32{
+		"doc-go":   {0.3, 0.8, 0.2},
+		"doc-rust": {0.1, 0.9, 0.2},
+		"doc-db":   {0.2, 0.3, 0.9},
+	}
+
+	embedder := new(MockEmbeddingService)
+	for id, item := range items {
+		embedder.On("CreateContentVector", mock.Anything, item).Return(&VectorDocument{
+			ID:        id,
+			Content:   item.RawContent,
+			Embedding: embeddings[id],
+			Metadata: map[string]interface{}{
+				"user_id":      item.UserID,
+				"content_type": string(item.Type),
+			},
+			CreatedAt: time.Now(),
+		}, nil)
+	}
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{0.1, 0.9, 0.2}, Dimension: 3}, nil)
+
+	store := NewMemoryStore()
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = store
+	ctx := context.Background()
+
+	for _, id := range []string{"doc-go", "doc-rust", "doc-db"} {
+		require.NoError(t, engine.IndexDocument(ctx, items[id]))
+	}
+
+	t.Run("按距离排序并应用过滤条件", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "所有权", TopK: 10, UserID: "user-1"})
+		require.NoError(t, err)
+
+		require.Len(t, response.Results, 2)
+		assert.Equal(t, "doc-rust", response.Results[0].DocumentID)
+		assert.Equal(t, "doc-go", response.Results[1].DocumentID)
+
+		response, err = engine.Search(ctx, &SearchOptions{
+			Query:        "所有权",
+			TopK:         10,
+			ContentTypes: []models.ContentType{models.ContentTypeLink},
+		})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, "doc-db", response.Results[0].DocumentID)
+	})
+
+	t.Run("按向量检索", func(t *testing.T) {
+		response, err := engine.SearchByVector(ctx, []float32{0.3, 0.8, 0.2}, &SearchOptions{TopK: 1})
+		require.NoError(t, err)
+
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, "doc-go", response.Results[0].DocumentID)
+		assert.InDelta(t, 0, response.Results[0].Distance, 0.0001)
+	})
+
+	t.Run("元数据更新和删除", func(t *testing.T) {
+		require.NoError(t, engine.UpdateDocumentMetadata(ctx, "doc-go", map[string]interface{}{"tags": []string{"go"}}))
+		doc, err := engine.GetDocument(ctx, "doc-go")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go"}, doc.Metadata["tags"])
+		assert.Equal(t, embeddings["doc-go"], doc.Embedding)
+
+		response, err := engine.Search(ctx, &SearchOptions{Query: "并发", TopK: 10, Tags: []string{"go"}})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, "doc-go", response.Results[0].DocumentID)
+
+		require.NoError(t, engine.DeleteDocument(ctx, "doc-go"))
+		_, err = engine.GetDocument(ctx, "doc-go")
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeResourceNotFound))
+	})
+
+	t.Run("集合信息和健康检查", func(t *testing.T) {
+		info, err := store.GetCollectionInfo(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, info["document_count"])
+		assert.NoError(t, engine.HealthCheck(ctx))
+	})
+}
+
+// TestSearchEngine_RelevanceClampsComponents 测试相关性各分项加权前限制在[0,1]内
+func TestSearchEngine_RelevanceClampsComponents(t *testing.T) {
+	engine := newTestSearchEngine(t, new(MockEmbeddingService))
+	options := &SearchOptions{}
+
+	// 负相似度和超出范围的重要性分数不会产生负贡献或超额贡献
+	score := engine.calculateRelevanceScore(-2.5, nil, nil, map[string]interface{}{"importance_score": 25.0}, options)
+	assert.InDelta(t, 0.1, score, 1e-9)
+
+	score = engine.calculateRelevanceScore(-2.5, nil, nil, map[string]interface{}{"importance_score": -5.0}, options)
+	assert.Equal(t, 0.0, score)
+}
+
+// TestSearchEngine_SimilarityFloor 测试相似度下限只抬高展示的分数，在最小相似度过滤之后应用
+func TestSearchEngine_SimilarityFloor(t *testing.T) {
+	engine := newTestSearchEngine(t, new(MockEmbeddingService))
+	engine.config.SimilarityFloor = 0.3
+	results := []*SearchResultItem{
+		{DocumentID: "strong", Similarity: 0.8, NormalizedScore: 0.8},
+		{DocumentID: "weak", Similarity: 0.2, NormalizedScore: 0.2},
+		{DocumentID: "below", Similarity: 0.05, NormalizedScore: 0.05},
+	}
+
+	filtered := engine.applyFinalFiltering(results, &SearchOptions{TopK: 10, MinSimilarity: 0.1})
+	require.Len(t, filtered, 2)
+	assert.Equal(t, "strong", filtered[0].DocumentID)
+	assert.Equal(t, 0.8, filtered[0].Similarity)
+	assert.Equal(t, "weak", filtered[1].DocumentID)
+	assert.Equal(t, 0.3, filtered[1].Similarity)
+	assert.Equal(t, 0.3, filtered[1].NormalizedScore)
+}
+
+// TestSearchEngine_MinPerContentType 测试保证前top_k中每种内容类型的最少数量
+func TestSearchEngine_MinPerContentType(t *testing.T) {
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{1, 0}, Dimension: 2}, nil)
+
+	// 6个与查询高度相似的链接，1个相关但相似度较低的笔记
+	var documents []*VectorDocument
+	for i := 0; i < 6; i++ {
+		documents = append(documents, &VectorDocument{
+			ID:        fmt.Sprintf("link-%d", i),
+			Content:   "Go并发编程文章",
+			Embedding: []float32{1, 0.05 * float32(i)},
+			Metadata:  map[string]interface{}{"content_type": string(models.ContentTypeLink)},
+			CreatedAt: time.Now(),
+		})
+	}
+	documents = append(documents, &VectorDocument{
+		ID:        "note-1",
+		Content:   "Go并发编程笔记",
+		Embedding: []float32{1, 0.6},
+		Metadata:  map[string]interface{}{"content_type": string(models.ContentTypeText)},
+		CreatedAt: time.Now(),
+	})
+
+	store := NewMemoryStore()
+	require.NoError(t, store.AddDocuments(context.Background(), documents))
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = store
+	ctx := context.Background()
+
+	documentIDs := func(response *SearchResponse) []string {
+		ids := make([]string, len(response.Results))
+		for i, result := range response.Results {
+			ids[i] = result.DocumentID
+		}
+		return ids
+	}
+
+	t.Run("纯相关性排序时笔记被链接挤出", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "Go并发", TopK: 4})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"link-0", "link-1", "link-2", "link-3"}, documentIDs(response))
+	})
+
+	t.Run("保证每种类型后笔记进入前top_k", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "Go并发", TopK: 4, MinPerContentType: 1})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"link-0", "link-1", "link-2", "note-1"}, documentIDs(response))
+		assert.Equal(t, 4, response.Results[3].Rank)
+		assert.Equal(t, 1, response.Metadata["diversity_promoted"])
+	})
+
+	t.Run("没有匹配的类型不占用名额", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{
+			Query:             "Go并发",
+			TopK:              4,
+			MinPerContentType: 2,
+			ContentTypes:      []models.ContentType{models.ContentTypeLink, models.ContentTypeText, models.ContentTypeImage},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"link-0", "link-1", "link-2", "note-1"}, documentIDs(response))
+	})
+}
+
+// relevanceCompleterFunc 用函数模拟LLM补全
+type relevanceCompleterFunc func(ctx context.Context, systemPrompt, userMessage string) (string, error)
+
+func (f relevanceCompleterFunc) SimpleCompletion(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	return f(ctx, systemPrompt, userMessage)
+}
+
+// TestSearchEngine_LLMRerank 测试LLM重排序按LLM相关性分数重排前N个结果，失败时保持启发式排序
+func TestSearchEngine_LLMRerank(t *testing.T) {
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{1, 0}, Dimension: 2}, nil)
+
+	// 向量相似度依次降低的5个文档，模拟LLM认为相关性与向量顺序不同
+	relevance := map[string]float64{
+		"goroutine泄漏排查": 0.2,
+		"channel关闭时机":   0.9,
+		"select多路复用":    0.6,
+		"sync.Pool用法":   0.95,
+		"context取消传播":   0.1,
+	}
+	contents := []string{"goroutine泄漏排查", "channel关闭时机", "select多路复用", "sync.Pool用法", "context取消传播"}
+	store := NewMemoryStore()
+	for i, text := range contents {
+		require.NoError(t, store.AddDocument(context.Background(), &VectorDocument{
+			ID:        fmt.Sprintf("doc-%d", i),
+			Content:   text,
+			Embedding: []float32{1, 0.1 * float32(i)},
+			Metadata:  map[string]interface{}{"content_type": string(models.ContentTypeText)},
+			CreatedAt: time.Now(),
+		}))
+	}
+
+	// 按提示中文档的编号顺序返回分数
+	scoreByPrompt := func(calls *int32) relevanceCompleterFunc {
+		return func(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+			atomic.AddInt32(calls, 1)
+			scores := make([]string, 0)
+			for _, line := range strings.Split(userMessage, "\n") {
+				if !strings.HasPrefix(line, "[") {
+					continue
+				}
+				_, text, _ := strings.Cut(line, "] ")
+				scores = append(scores, fmt.Sprintf("%g", relevance[text]))
+			}
+			return "```json\n{\"scores\":[" + strings.Join(scores, ",") + "]}\n```", nil
+		}
+	}
+
+	newEngine := func(completer RelevanceCompleter) *SearchEngine {
+		engine := newTestSearchEngine(t, embedder)
+		engine.store = store
+		engine.config.LLMRerank = &config.LLMRerankConfig{MaxCandidates: 3}
+		engine.SetRelevanceCompleter(completer)
+		return engine
+	}
+	search := func(t *testing.T, engine *SearchEngine, enableLLMRerank bool) *SearchResponse {
+		response, err := engine.Search(context.Background(), &SearchOptions{
+			Query: "Go并发", TopK: 5, IncludeContent: true, EnableReranking: true, EnableLLMRerank: enableLLMRerank,
+		})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 5)
+		return response
+	}
+	documentIDs := func(response *SearchResponse) []string {
+		ids := make([]string, 0, len(response.Results))
+		for _, item := range response.Results {
+			ids = append(ids, item.DocumentID)
+		}
+		return ids
+	}
+
+	var calls int32
+	engine := newEngine(scoreByPrompt(&calls))
+	baseline := documentIDs(search(t, engine, false))
+	require.Equal(t, []string{"doc-0", "doc-1", "doc-2", "doc-3", "doc-4"}, baseline)
+	require.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	t.Run("前N个结果按LLM分数重排", func(t *testing.T) {
+		response := search(t, engine, true)
+
+		// 只有启发式排序的前3个交给LLM，sync.Pool虽然分数最高但不在候选内
+		assert.Equal(t, []string{"doc-1", "doc-2", "doc-0", "doc-3", "doc-4"}, documentIDs(response))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		assert.Equal(t, 3, response.Metadata["llm_reranked"])
+
+		require.NotNil(t, response.Results[0].LLMRelevanceScore)
+		assert.Equal(t, 0.9, *response.Results[0].LLMRelevanceScore)
+		assert.Nil(t, response.Results[3].LLMRelevanceScore)
+		assert.Equal(t, 1, response.Results[0].Rank)
+	})
+
+	t.Run("LLM调用失败时保持启发式排序", func(t *testing.T) {
+		failing := newEngine(relevanceCompleterFunc(func(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+			return "", errors.NewMemoroError(errors.ErrorTypeLLM, errors.ErrCodeLLMAPICall, "rate limited")
+		}))
+		response := search(t, failing, true)
+
+		assert.Equal(t, baseline, documentIDs(response))
+		assert.Equal(t, true, response.Metadata["llm_rerank_fallback"])
+		assert.Nil(t, response.Results[0].LLMRelevanceScore)
+	})
+
+	t.Run("分数数量不符时保持启发式排序", func(t *testing.T) {
+		malformed := newEngine(relevanceCompleterFunc(func(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+			return `{"scores":[0.9]}`, nil
+		}))
+		response := search(t, malformed, true)
+
+		assert.Equal(t, baseline, documentIDs(response))
+		assert.Equal(t, true, response.Metadata["llm_rerank_fallback"])
+	})
+
+	t.Run("未配置LLM时保持启发式排序", func(t *testing.T) {
+		response := search(t, newEngine(nil), true)
+		assert.Equal(t, baseline, documentIDs(response))
+		assert.Equal(t, true, response.Metadata["llm_rerank_fallback"])
+	})
+}
+
+// includeTextRecorder 记录向量检索是否请求原文
+type includeTextRecorder struct {
+	*MemoryStore
+	includeText []bool
+}
+
+func (r *includeTextRecorder) Search(ctx context.Context, query *SearchQuery) (*SearchResult, error) {
+	r.includeText = append(r.includeText, query.IncludeText)
+	return r.MemoryStore.Search(ctx, query)
+}
+
+// mapKeys 获取JSON对象的键
+func mapKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// TestSearchEngine_FieldProjection 测试只返回请求的字段，未请求原文时不从向量库获取
+func TestSearchEngine_FieldProjection(t *testing.T) {
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{1, 0}, Dimension: 2}, nil)
+
+	store := &includeTextRecorder{MemoryStore: NewMemoryStore()}
+	require.NoError(t, store.AddDocument(context.Background(), &VectorDocument{
+		ID:        "doc-1",
+		Content:   "Go语言并发编程实践",
+		Embedding: []float32{1, 0.1},
+		Metadata: map[string]interface{}{
+			"title":           "Go并发",
+			"summary_oneline": "介绍goroutine和channel",
+			"tags":            []string{"go"},
+			"project_id":      42,
+		},
+		CreatedAt: time.Now(),
+	}))
+
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = store
+
+	t.Run("投影结果只包含请求的字段", func(t *testing.T) {
+		response, err := engine.Search(context.Background(), &SearchOptions{
+			Query:          "并发",
+			TopK:           5,
+			IncludeContent: true,
+			Fields:         []string{FieldDocumentID, FieldSimilarity, FieldTitle, FieldSummary},
+		})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 1)
+		assert.False(t, store.includeText[len(store.includeText)-1])
+
+		result := response.Results[0]
+		assert.Equal(t, "doc-1", result.DocumentID)
+		assert.Greater(t, result.Similarity, 0.0)
+		assert.Empty(t, result.Content)
+		assert.Empty(t, result.ContentSummary)
+		assert.Equal(t, map[string]interface{}{"title": "Go并发", "summary_oneline": "介绍goroutine和channel"}, result.Metadata)
+
+		data, err := json.Marshal(result)
+		require.NoError(t, err)
+		var serialized map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &serialized))
+		assert.ElementsMatch(t, []string{"document_id", "similarity", "distance", "raw_similarity", "normalized_score", "similarity_type", "metadata"},
+			mapKeys(serialized))
+	})
+
+	t.Run("未指定投影时序列化全部字段", func(t *testing.T) {
+		response, err := engine.Search(context.Background(), &SearchOptions{Query: "并发", TopK: 5})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 1)
+
+		data, err := json.Marshal(response.Results[0])
+		require.NoError(t, err)
+		var serialized map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &serialized))
+		assert.Contains(t, serialized, "created_at")
+		assert.Contains(t, serialized, "rank")
+		assert.Contains(t, serialized, "matched_keywords")
+	})
+
+	t.Run("未指定投影时返回全部字段", func(t *testing.T) {
+		response, err := engine.Search(context.Background(), &SearchOptions{Query: "并发编程", TopK: 5, IncludeContent: true})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 1)
+		assert.True(t, store.includeText[len(store.includeText)-1])
+
+		result := response.Results[0]
+		assert.Equal(t, "Go语言并发编程实践", result.Content)
+		assert.Equal(t, 42, result.Metadata["project_id"])
+	})
+
+	t.Run("未知字段返回错误", func(t *testing.T) {
+		_, err := engine.Search(context.Background(), &SearchOptions{Query: "并发", Fields: []string{"password"}})
+		require.Error(t, err)
+	})
+}
+
+// TestSearchEngine_SnippetSource 测试片段来源为summary时使用已保存的摘要且不获取原文，没有摘要时回退到从原文截取
+func TestSearchEngine_SnippetSource(t *testing.T) {
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{1, 0}, Dimension: 2}, nil)
+
+	store := &includeTextRecorder{MemoryStore: NewMemoryStore()}
+	for _, doc := range []*VectorDocument{
+		{
+			ID:        "summarized",
+			Content:   "Go语言通过goroutine和channel实现并发，调度器负责把goroutine分配到系统线程上运行",
+			Embedding: []float32{1, 0.1},
+			Metadata:  map[string]interface{}{"summary_oneline": "介绍goroutine和channel", MetadataKeySummaryParagraph: "Go的并发模型基于goroutine和channel。"},
+		},
+		{
+			ID:        "paragraph-only",
+			Content:   "并发安全的map可以使用sync.Map",
+			Embedding: []float32{1, 0.2},
+			Metadata:  map[string]interface{}{MetadataKeySummaryParagraph: "sync.Map适合读多写少的并发场景。"},
+		},
+		{
+			ID:        "unsummarized",
+			Content:   "并发编程笔记",
+			Embedding: []float32{1, 0.3},
+			Metadata:  map[string]interface{}{},
+		},
+	} {
+		doc.CreatedAt = time.Now()
+		require.NoError(t, store.AddDocument(context.Background(), doc))
+	}
+
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = store
+
+	snippets := func(response *SearchResponse) map[string]string {
+		result := make(map[string]string)
+		for _, item := range response.Results {
+			result[item.DocumentID] = item.ContentSummary
+		}
+		return result
+	}
+
+	t.Run("使用已保存的摘要且不获取原文", func(t *testing.T) {
+		response, err := engine.Search(context.Background(), &SearchOptions{Query: "并发", TopK: 5, SnippetSource: SnippetSourceSummary})
+		require.NoError(t, err)
+		assert.False(t, store.includeText[len(store.includeText)-1])
+
+		got := snippets(response)
+		assert.Equal(t, "介绍goroutine和channel", got["summarized"])
+		assert.Equal(t, "sync.Map适合读多写少的并发场景。", got["paragraph-only"])
+		// 没有摘要且未获取原文时没有片段
+		assert.Empty(t, got["unsummarized"])
+	})
+
+	t.Run("没有摘要时回退到从原文截取", func(t *testing.T) {
+		response, err := engine.Search(context.Background(), &SearchOptions{Query: "并发", TopK: 5, IncludeContent: true, SnippetSource: SnippetSourceSummary})
+		require.NoError(t, err)
+		got := snippets(response)
+		assert.Equal(t, "介绍goroutine和channel", got["summarized"])
+		assert.Equal(t, "并发编程笔记", got["unsummarized"])
+	})
+
+	t.Run("默认从原文截取", func(t *testing.T) {
+		response, err := engine.Search(context.Background(), &SearchOptions{Query: "并发", TopK: 5, IncludeContent: true})
+		require.NoError(t, err)
+		got := snippets(response)
+		assert.Equal(t, "并发安全的map可以使用sync.Map", got["paragraph-only"])
+	})
+
+	t.Run("未知片段来源返回错误", func(t *testing.T) {
+		_, err := engine.Search(context.Background(), &SearchOptions{Query: "并发", SnippetSource: "highlight"})
+		require.Error(t, err)
+	})
+}
+
+// TestSearchEngine_TagBoosts 测试配置的标签增强参与排序并出现在分数分解中
+func TestSearchEngine_TagBoosts(t *testing.T) {
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{1, 0}, Dimension: 2}, nil)
+
+	createdAt := time.Now().Add(-48 * time.Hour)
+	store := NewMemoryStore()
+	for _, doc := range []struct {
+		id        string
+		embedding []float32
+		tags      []string
+	}{
+		{id: "plain", embedding: []float32{1, 0.2}},
+		{id: "important", embedding: []float32{1, 0.2}, tags: []string{"Important"}},
+		{id: "archived", embedding: []float32{1, 0.1}, tags: []string{"archived"}},
+	} {
+		metadata := map[string]interface{}{"content_type": "text"}
+		if doc.tags != nil {
+			metadata["tags"] = doc.tags
+		}
+		require.NoError(t, store.AddDocument(context.Background(), &VectorDocument{
+			ID:        doc.id,
+			Content:   "季度计划回顾",
+			Embedding: doc.embedding,
+			Metadata:  metadata,
+			CreatedAt: createdAt,
+		}))
+	}
+
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = store
+	engine.config.TagBoosts = map[string]float64{"important": 0.1, "archived": -0.1}
+
+	response, err := engine.Search(context.Background(), &SearchOptions{
+		Query:           "计划",
+		TopK:            5,
+		RankingStrategy: RankingStrategyHybrid,
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Results, 3)
+
+	ids := make([]string, 0, len(response.Results))
+	for _, result := range response.Results {
+		ids = append(ids, result.DocumentID)
+	}
+	assert.Equal(t, []string{"important", "plain", "archived"}, ids)
+
+	important := response.Results[0]
+	require.NotNil(t, important.ScoreBreakdown)
+	assert.Equal(t, map[string]float64{"important": 0.1}, important.ScoreBreakdown.TagBoosts)
+	assert.InDelta(t, 0.1, important.ScoreBreakdown.BoostScore, 1e-9)
+
+	plain := response.Results[1]
+	require.NotNil(t, plain.ScoreBreakdown)
+	assert.Empty(t, plain.ScoreBreakdown.TagBoosts)
+	assert.InDelta(t, 0.1, important.RelevanceScore-plain.RelevanceScore, 1e-9)
+
+	archived := response.Results[2]
+	require.NotNil(t, archived.ScoreBreakdown)
+	assert.Equal(t, map[string]float64{"archived": -0.1}, archived.ScoreBreakdown.TagBoosts)
+}
+
+// TestSearchEngine_ContentTypeWeights 测试内容类型相关性乘数：相似度相同时提高link权重使链接排在笔记之前
+func TestSearchEngine_ContentTypeWeights(t *testing.T) {
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{1, 0}, Dimension: 2}, nil)
+
+	createdAt := time.Now().Add(-48 * time.Hour)
+	store := NewMemoryStore()
+	for _, doc := range []struct {
+		id          string
+		contentType string
+	}{
+		{id: "note", contentType: "text"},
+		{id: "link", contentType: "link"},
+	} {
+		require.NoError(t, store.AddDocument(context.Background(), &VectorDocument{
+			ID:        doc.id,
+			Content:   "季度计划回顾",
+			Embedding: []float32{1, 0.5},
+			Metadata:  map[string]interface{}{"content_type": doc.contentType},
+			CreatedAt: createdAt,
+		}))
+	}
+
+	search := func(t *testing.T, weights map[string]float64, strategy RankingStrategy) []*SearchResultItem {
+		engine := newTestSearchEngine(t, embedder)
+		engine.store = store
+		engine.config.ContentTypeWeights = weights
+
+		response, err := engine.Search(context.Background(), &SearchOptions{
+			Query:           "计划",
+			TopK:            5,
+			RankingStrategy: strategy,
+		})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 2)
+		return response.Results
+	}
+
+	t.Run("默认不调整", func(t *testing.T) {
+		results := search(t, nil, "")
+		assert.InDelta(t, results[0].RelevanceScore, results[1].RelevanceScore, 1e-9)
+	})
+
+	t.Run("提高link权重后链接排在前面", func(t *testing.T) {
+		results := search(t, map[string]float64{"link": 1.2}, "")
+		assert.Equal(t, "link", results[0].DocumentID)
+		assert.InDelta(t, 1.2, results[0].RelevanceScore/results[1].RelevanceScore, 1e-6)
+	})
+
+	t.Run("分数分解包含内容类型乘数", func(t *testing.T) {
+		results := search(t, map[string]float64{"link": 1.2}, RankingStrategyHybrid)
+		link, note := results[0], results[1]
+		assert.Equal(t, "link", link.DocumentID)
+		require.NotNil(t, link.ScoreBreakdown)
+		require.NotNil(t, note.ScoreBreakdown)
+		assert.Equal(t, 1.2, link.ScoreBreakdown.ContentTypeWeight)
+		assert.Equal(t, 1.0, note.ScoreBreakdown.ContentTypeWeight)
+		assert.InDelta(t, 1.2, link.RelevanceScore/note.RelevanceScore, 1e-6)
+	})
+}
+
+// TestSearchEngine_HybridMode 测试混合检索：只在少数文档中出现的精确词在向量检索中排名靠后，融合关键词检索后进入前列
+func TestSearchEngine_HybridMode(t *testing.T) {
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{1, 0}, Dimension: 2}, nil)
+
+	store := NewMemoryStore()
+	ctx := context.Background()
+	for i, y := range []float32{0.1, 0.2, 0.3, 0.4, 0.5} {
+		require.NoError(t, store.AddDocument(ctx, &VectorDocument{
+			ID:        fmt.Sprintf("deploy-%d", i),
+			Content:   "部署流水线失败排查记录",
+			Embedding: []float32{1, y},
+			Metadata:  map[string]interface{}{"content_type": "text"},
+			CreatedAt: time.Now(),
+		}))
+	}
+	require.NoError(t, store.AddDocument(ctx, &VectorDocument{
+		ID:        "rare",
+		Content:   "网关返回错误码 ERR-4242 时需要刷新令牌",
+		Embedding: []float32{0.1, 1},
+		Metadata:  map[string]interface{}{"content_type": "text"},
+		CreatedAt: time.Now(),
+	}))
+
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = store
+
+	search := func(mode SearchMode) []string {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "部署失败 ERR-4242", TopK: 3, Mode: mode})
+		require.NoError(t, err)
+		assert.Equal(t, string(mode), response.Metadata["search_mode"])
+		ids := make([]string, 0, len(response.Results))
+		for _, result := range response.Results {
+			ids = append(ids, result.DocumentID)
+		}
+		return ids
+	}
+
+	t.Run("向量模式排名靠后", func(t *testing.T) {
+		assert.NotContains(t, search(SearchModeVector), "rare")
+	})
+
+	t.Run("混合模式排在首位", func(t *testing.T) {
+		ids := search(SearchModeHybrid)
+		require.Len(t, ids, 3)
+		assert.Equal(t, "rare", ids[0])
+	})
+
+	t.Run("关键词模式只返回命中的文档", func(t *testing.T) {
+		assert.Equal(t, []string{"rare"}, search(SearchModeKeyword))
+	})
+
+	t.Run("融合权重可配置", func(t *testing.T) {
+		engine.config.HybridSearch = &config.HybridSearchConfig{KeywordWeight: 0.1, RRFK: 1}
+		defer func() { engine.config.HybridSearch = nil }()
+		assert.NotEqual(t, "rare", search(SearchModeHybrid)[0])
+	})
+
+	t.Run("无效模式返回错误", func(t *testing.T) {
+		_, err := engine.Search(ctx, &SearchOptions{Query: "部署", Mode: "sparse"})
+		assert.Error(t, err)
+	})
+
+	t.Run("关键词模式不调用向量服务", func(t *testing.T) {
+		failing := new(MockEmbeddingService)
+		failing.On("GenerateEmbedding", mock.Anything, mock.Anything).
+			Return((*EmbeddingResult)(nil), fmt.Errorf("embedding provider unavailable"))
+		keywordEngine := newTestSearchEngine(t, failing)
+		keywordEngine.store = store
+
+		response, err := keywordEngine.Search(ctx, &SearchOptions{Query: "ERR-4242", TopK: 3, Mode: SearchModeKeyword})
+		require.NoError(t, err)
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, "rare", response.Results[0].DocumentID)
+		assert.Equal(t, 1.0, response.Results[0].Similarity)
+		assert.Positive(t, response.Results[0].KeywordScore)
+		failing.AssertNotCalled(t, "GenerateEmbedding", mock.Anything, mock.Anything)
+
+		_, err = keywordEngine.Search(ctx, &SearchOptions{Query: "ERR-4242", TopK: 3, Mode: SearchModeVector})
+		assert.Error(t, err)
+	})
+}
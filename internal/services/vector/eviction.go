@@ -0,0 +1,369 @@
+package vector
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+)
+
+// EvictionStrategy 超出配额时选择淘汰文档的策略
+type EvictionStrategy string
+
+const (
+	EvictionLowestImportance EvictionStrategy = "lowest_importance" // 重要性最低的优先
+	EvictionOldest           EvictionStrategy = "oldest"            // 创建最早的优先
+	EvictionLeastInteracted  EvictionStrategy = "least_interacted"  // 最久未交互的优先（从未交互按创建时间）
+	EvictionWeighted         EvictionStrategy = "weighted"          // 重要性、新鲜度和最近交互的加权保留分数最低的优先
+)
+
+// evictionRecencyScale 新鲜度和交互衰减的时间尺度（该时长后分数降到50%）
+const evictionRecencyScale = 30 * 24 * time.Hour
+
+// evictionScanLimit 单次配额检查或清理读取的文档上限
+const evictionScanLimit = 10000
+
+// defaultEvictionPurgeAfter 软删除的文档保留在索引中的默认时长，之后彻底删除
+const defaultEvictionPurgeAfter = 7 * 24 * time.Hour
+
+// EvictionConfig 淘汰策略配置
+type EvictionConfig struct {
+	MaxDocumentsPerUser int              // 每个用户保留的最大文档数，0表示不限制
+	Strategy            EvictionStrategy // 淘汰策略
+	ImportanceWeight    float64          // weighted策略中重要性的权重
+	RecencyWeight       float64          // weighted策略中新鲜度的权重
+	InteractionWeight   float64          // weighted策略中最近交互的权重
+	PurgeAfter          time.Duration    // 软删除超过该时长的文档由清理任务从索引中彻底删除
+}
+
+// EvictionReport 配额检查结果
+type EvictionReport struct {
+	UserID     string   `json:"user_id"`     // 用户ID
+	Documents  int      `json:"documents"`   // 检查时的有效文档数
+	Limit      int      `json:"limit"`       // 配额
+	EvictedIDs []string `json:"evicted_ids"` // 被软删除的文档ID
+}
+
+// PurgeReport 软删除文档清理结果
+type PurgeReport struct {
+	Cutoff    time.Time `json:"cutoff"`     // 软删除早于该时间的文档被清理
+	Scanned   int       `json:"scanned"`    // 符合条件的文档数
+	PurgedIDs []string  `json:"purged_ids"` // 从索引中删除的文档ID
+}
+
+// DefaultEvictionConfig 默认淘汰配置（不限制文档数）
+func DefaultEvictionConfig() EvictionConfig {
+	return EvictionConfig{
+		Strategy:          EvictionLowestImportance,
+		ImportanceWeight:  0.5,
+		RecencyWeight:     0.3,
+		InteractionWeight: 0.2,
+		PurgeAfter:        defaultEvictionPurgeAfter,
+	}
+}
+
+// evictionConfigFrom 从全局配置读取淘汰配置
+func evictionConfigFrom(cfg *config.Config) EvictionConfig {
+	evictionConfig := DefaultEvictionConfig()
+	if cfg == nil || cfg.VectorDB.Eviction == nil {
+		return evictionConfig
+	}
+
+	eviction := cfg.VectorDB.Eviction
+	evictionConfig.MaxDocumentsPerUser = eviction.MaxDocumentsPerUser
+	if eviction.Strategy != "" {
+		evictionConfig.Strategy = EvictionStrategy(eviction.Strategy)
+	}
+	if eviction.ImportanceWeight > 0 || eviction.RecencyWeight > 0 || eviction.InteractionWeight > 0 {
+		evictionConfig.ImportanceWeight = eviction.ImportanceWeight
+		evictionConfig.RecencyWeight = eviction.RecencyWeight
+		evictionConfig.InteractionWeight = eviction.InteractionWeight
+	}
+	if eviction.PurgeAfter > 0 {
+		evictionConfig.PurgeAfter = eviction.PurgeAfter
+	}
+	return evictionConfig
+}
+
+// EvictionPolicy 淘汰策略（无内部可变状态，可并发使用）
+type EvictionPolicy struct {
+	config       EvictionConfig
+	interactions *InteractionStore // 交互记录（nil时least_interacted按创建时间）
+	now          func() time.Time
+}
+
+// NewEvictionPolicy 创建淘汰策略
+func NewEvictionPolicy(evictionConfig EvictionConfig, interactions *InteractionStore) *EvictionPolicy {
+	return &EvictionPolicy{
+		config:       evictionConfig,
+		interactions: interactions,
+		now:          time.Now,
+	}
+}
+
+// SelectVictims 按策略从文档中选出count个淘汰文档，顺序为最先淘汰的在前
+func (p *EvictionPolicy) SelectVictims(documents []*VectorDocument, count int) []*VectorDocument {
+	if count <= 0 || len(documents) == 0 {
+		return nil
+	}
+
+	var lastInteractions map[string]time.Time
+	if p.interactions != nil {
+		lastInteractions = p.interactions.LastInteractions()
+	}
+
+	now := p.now()
+	scores := make(map[string]float64, len(documents))
+	for _, doc := range documents {
+		scores[doc.ID] = p.retentionScore(doc, lastInteractions, now)
+	}
+
+	candidates := make([]*VectorDocument, len(documents))
+	copy(candidates, documents)
+
+	// 保留分数低的先淘汰；分数相同时创建早的先淘汰，再按ID保证稳定
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if scores[a.ID] != scores[b.ID] {
+			return scores[a.ID] < scores[b.ID]
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+	return candidates[:count]
+}
+
+// retentionScore 计算文档的保留分数，分数越低越先淘汰
+func (p *EvictionPolicy) retentionScore(doc *VectorDocument, lastInteractions map[string]time.Time, now time.Time) float64 {
+	lastInteraction, interacted := lastInteractions[doc.ID]
+
+	switch p.config.Strategy {
+	case EvictionOldest:
+		return float64(doc.CreatedAt.Unix())
+	case EvictionLeastInteracted:
+		if !interacted {
+			return float64(doc.CreatedAt.Unix())
+		}
+		return float64(lastInteraction.Unix())
+	case EvictionWeighted:
+		score := documentImportance(doc) * p.config.ImportanceWeight
+		score += recencyScore(now.Sub(doc.CreatedAt)) * p.config.RecencyWeight
+		if interacted {
+			score += recencyScore(now.Sub(lastInteraction)) * p.config.InteractionWeight
+		}
+		return score
+	default:
+		return documentImportance(doc)
+	}
+}
+
+// documentImportance 读取文档元数据中的重要性评分（限制在0-1）
+func documentImportance(doc *VectorDocument) float64 {
+	importance := 0.0
+	switch value := doc.Metadata["importance_score"].(type) {
+	case float64:
+		importance = value
+	case float32:
+		importance = float64(value)
+	case int64:
+		importance = float64(value)
+	}
+
+	if importance < 0 {
+		return 0
+	}
+	if importance > 1 {
+		return 1
+	}
+	return importance
+}
+
+// recencyScore 按时间衰减的分数，刚发生为1，经过evictionRecencyScale后为0.5
+func recencyScore(age time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	return 1.0 / (1.0 + float64(age)/float64(evictionRecencyScale))
+}
+
+// keyedLocks 按键（如用户ID）串行化的互斥锁，用于配额检查和索引时去重
+type keyedLocks struct {
+	mu    sync.Mutex
+	users map[string]*sync.Mutex
+}
+
+// lock 锁定指定的键，返回解锁函数
+func (l *keyedLocks) lock(userID string) func() {
+	l.mu.Lock()
+	if l.users == nil {
+		l.users = make(map[string]*sync.Mutex)
+	}
+	userLock, exists := l.users[userID]
+	if !exists {
+		userLock = &sync.Mutex{}
+		l.users[userID] = userLock
+	}
+	l.mu.Unlock()
+
+	userLock.Lock()
+	return userLock.Unlock
+}
+
+// SetInteractionStore 设置淘汰策略（least_interacted和weighted策略）和按访问调整重要性使用的交互记录，应在开始处理前调用
+func (se *SearchEngine) SetInteractionStore(interactions *InteractionStore) {
+	se.interactions = interactions
+	evictionConfig := DefaultEvictionConfig()
+	if se.evictionPolicy != nil {
+		evictionConfig = se.evictionPolicy.config
+	}
+	se.evictionPolicy = NewEvictionPolicy(evictionConfig, interactions)
+}
+
+// EnforceUserQuota 用户有效文档数超出配额时，按淘汰策略软删除多出的文档；keepID（如刚索引的文档）不参与淘汰
+func (se *SearchEngine) EnforceUserQuota(ctx context.Context, userID, keepID string) (*EvictionReport, error) {
+	if userID == "" {
+		return nil, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+
+	policy := se.evictionPolicy
+	report := &EvictionReport{UserID: userID, EvictedIDs: make([]string, 0)}
+	if policy == nil || policy.config.MaxDocumentsPerUser <= 0 {
+		return report, nil
+	}
+	report.Limit = policy.config.MaxDocumentsPerUser
+
+	unlock := se.evictionLocks.lock(userID)
+	defer unlock()
+
+	// 先清理该用户软删除超过保留时长的文档
+	if _, err := se.PurgeEvicted(ctx, userID, nil); err != nil {
+		se.logger.Warn("Failed to purge evicted documents", logger.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+	}
+
+	// 已软删除的文档在过滤条件中排除，不占用扫描上限
+	active, err := se.store.GetDocumentsByFilter(ctx, excludeDeleted(map[string]interface{}{"user_id": userID}), evictionScanLimit)
+	if err != nil {
+		return nil, err
+	}
+	report.Documents = len(active)
+
+	excess := len(active) - report.Limit
+	if excess <= 0 {
+		return report, nil
+	}
+
+	candidates := make([]*VectorDocument, 0, len(active))
+	for _, doc := range active {
+		if doc.ID != keepID {
+			candidates = append(candidates, doc)
+		}
+	}
+
+	deletedAt := time.Now().Unix()
+	for _, victim := range policy.SelectVictims(candidates, excess) {
+		patch := map[string]interface{}{
+			MetadataKeyDeleted:   true,
+			MetadataKeyDeletedAt: deletedAt,
+		}
+		before := se.auditSnapshot(ctx, victim.ID)
+		if err := se.store.UpdateDocumentMetadata(ctx, victim.ID, patch); err != nil {
+			se.logger.Warn("Failed to soft delete document", logger.Fields{
+				"document_id": victim.ID,
+				"user_id":     userID,
+				"error":       err.Error(),
+			})
+			continue
+		}
+		report.EvictedIDs = append(report.EvictedIDs, victim.ID)
+		se.bumpCollectionVersion()
+
+		if se.auditLogger.Enabled() {
+			after := make(map[string]interface{}, len(before)+len(patch))
+			for key, value := range before {
+				after[key] = value
+			}
+			for key, value := range patch {
+				after[key] = value
+			}
+			se.auditLogger.Record(ctx, logger.AuditRecord{
+				Action:     logger.AuditActionSoftDelete,
+				DocumentID: victim.ID,
+				UserID:     userID,
+				Before:     before,
+				After:      after,
+			})
+		}
+	}
+
+	se.logger.Info("User quota enforced", logger.Fields{
+		"user_id":   userID,
+		"documents": report.Documents,
+		"limit":     report.Limit,
+		"evicted":   len(report.EvictedIDs),
+		"strategy":  string(policy.config.Strategy),
+	})
+
+	return report, nil
+}
+
+// PurgeEvicted 从索引中彻底删除软删除超过PurgeAfter的文档，userID为空时清理所有用户（单个文档失败时跳过，下次清理重试）。
+// progress非nil时按已处理的文档上报进度百分比
+func (se *SearchEngine) PurgeEvicted(ctx context.Context, userID string, progress func(percent float64)) (*PurgeReport, error) {
+	purgeAfter := defaultEvictionPurgeAfter
+	if se.evictionPolicy != nil && se.evictionPolicy.config.PurgeAfter > 0 {
+		purgeAfter = se.evictionPolicy.config.PurgeAfter
+	}
+
+	report := &PurgeReport{
+		Cutoff:    time.Now().Add(-purgeAfter),
+		PurgedIDs: make([]string, 0),
+	}
+	filter := map[string]interface{}{
+		MetadataKeyDeleted:   true,
+		MetadataKeyDeletedAt: map[string]interface{}{"$lte": report.Cutoff.Unix()},
+	}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+	documents, err := se.store.GetDocumentsByFilter(ctx, filter, evictionScanLimit)
+	if err != nil {
+		return nil, err
+	}
+	report.Scanned = len(documents)
+
+	for i, doc := range documents {
+		reportProgress(progress, i, len(documents))
+		if err := se.DeleteDocument(ctx, doc.ID); err != nil {
+			se.logger.Warn("Failed to purge evicted document", logger.Fields{
+				"document_id": doc.ID,
+				"error":       err.Error(),
+			})
+			continue
+		}
+		report.PurgedIDs = append(report.PurgedIDs, doc.ID)
+	}
+
+	if len(report.PurgedIDs) > 0 {
+		se.logger.Info("Evicted documents purged", logger.Fields{
+			"user_id": userID,
+			"cutoff":  report.Cutoff,
+			"scanned": report.Scanned,
+			"purged":  len(report.PurgedIDs),
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,205 @@
+package vector
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evictionCorpus 淘汰策略测试语料：重要性、创建时间和交互时间各不相同
+func evictionCorpus(now time.Time) ([]*VectorDocument, *InteractionStore) {
+	day := 24 * time.Hour
+	doc := func(id string, importance float64, age time.Duration) *VectorDocument {
+		return &VectorDocument{
+			ID:        id,
+			Metadata:  map[string]interface{}{"user_id": "user-1", "importance_score": importance},
+			CreatedAt: now.Add(-age),
+		}
+	}
+
+	documents := []*VectorDocument{
+		doc("fresh-trivial", 0.1, 1*day),   // 新但不重要
+		doc("old-important", 0.9, 200*day), // 旧但重要
+		doc("old-trivial", 0.2, 180*day),   // 旧且不重要
+		doc("mid-read", 0.5, 90*day),       // 中等，最近被阅读
+		doc("mid-unread", 0.6, 60*day),     // 中等，从未交互
+	}
+
+	interactions := NewInteractionStore()
+	interactions.RecordInteraction("user-1", "mid-read", now.Add(-1*time.Hour))
+	interactions.RecordInteraction("user-1", "old-important", now.Add(-2*day))
+	interactions.RecordInteraction("user-1", "fresh-trivial", now.Add(-20*day))
+	interactions.RecordInteraction("user-1", "old-trivial", now.Add(-150*day))
+
+	return documents, interactions
+}
+
+// victimIDs 提取淘汰文档ID
+func victimIDs(victims []*VectorDocument) []string {
+	ids := make([]string, 0, len(victims))
+	for _, victim := range victims {
+		ids = append(ids, victim.ID)
+	}
+	return ids
+}
+
+// TestEvictionPolicy_SelectVictims 测试各淘汰策略选出的文档
+func TestEvictionPolicy_SelectVictims(t *testing.T) {
+	now := time.Now()
+	documents, interactions := evictionCorpus(now)
+
+	tests := []struct {
+		name     string
+		strategy EvictionStrategy
+		expected []string
+	}{
+		{"重要性最低优先", EvictionLowestImportance, []string{"fresh-trivial", "old-trivial"}},
+		{"最旧优先", EvictionOldest, []string{"old-important", "old-trivial"}},
+		{"最久未交互优先", EvictionLeastInteracted, []string{"old-trivial", "mid-unread"}},
+		{"加权混合", EvictionWeighted, []string{"old-trivial", "mid-unread"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evictionConfig := DefaultEvictionConfig()
+			evictionConfig.Strategy = tt.strategy
+			policy := NewEvictionPolicy(evictionConfig, interactions)
+			policy.now = func() time.Time { return now }
+
+			assert.Equal(t, tt.expected, victimIDs(policy.SelectVictims(documents, 2)))
+		})
+	}
+
+	t.Run("不修改输入顺序", func(t *testing.T) {
+		policy := NewEvictionPolicy(DefaultEvictionConfig(), nil)
+		policy.SelectVictims(documents, 2)
+		assert.Equal(t, "fresh-trivial", documents[0].ID)
+	})
+
+	t.Run("没有交互记录时按创建时间", func(t *testing.T) {
+		evictionConfig := DefaultEvictionConfig()
+		evictionConfig.Strategy = EvictionLeastInteracted
+		policy := NewEvictionPolicy(evictionConfig, nil)
+
+		assert.Equal(t, []string{"old-important"}, victimIDs(policy.SelectVictims(documents, 1)))
+	})
+}
+
+// TestSearchEngine_EnforceUserQuota 测试超出配额时软删除并在搜索中跳过
+func TestSearchEngine_EnforceUserQuota(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	engine := newTestSearchEngine(t, newTestEmbeddingService(t))
+	engine.store = newTestChromaClient(t, fake)
+	ctx := context.Background()
+
+	now := time.Now()
+	documents, _ := evictionCorpus(now)
+	for _, doc := range documents {
+		metadata := doc.Metadata
+		metadata["created_at"] = float64(doc.CreatedAt.Unix())
+		fake.put(doc.ID, "内容 "+doc.ID, []float32{0.1, 0.2, 0.3}, metadata)
+	}
+	fake.put("other-user", "其他用户的内容", []float32{0.1, 0.2, 0.3}, map[string]interface{}{"user_id": "user-2", "importance_score": 0.0})
+
+	evictionConfig := DefaultEvictionConfig()
+	evictionConfig.MaxDocumentsPerUser = 3
+	engine.evictionPolicy = NewEvictionPolicy(evictionConfig, nil)
+
+	t.Run("软删除重要性最低的文档", func(t *testing.T) {
+		report, err := engine.EnforceUserQuota(ctx, "user-1", "")
+		require.NoError(t, err)
+		assert.Equal(t, 5, report.Documents)
+		assert.Equal(t, []string{"fresh-trivial", "old-trivial"}, report.EvictedIDs)
+		assert.Equal(t, true, fake.records["old-trivial"].metadata[MetadataKeyDeleted])
+		assert.NotContains(t, fake.records["other-user"].metadata, MetadataKeyDeleted)
+	})
+
+	t.Run("已软删除的文档不计入配额", func(t *testing.T) {
+		report, err := engine.EnforceUserQuota(ctx, "user-1", "")
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Documents)
+		assert.Empty(t, report.EvictedIDs)
+	})
+
+	t.Run("搜索跳过软删除的文档", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "内容", TopK: 10, UserID: "user-1"})
+		require.NoError(t, err)
+
+		ids := make([]string, 0, len(response.Results))
+		for _, result := range response.Results {
+			ids = append(ids, result.DocumentID)
+		}
+		sort.Strings(ids)
+		assert.Equal(t, []string{"mid-read", "mid-unread", "old-important"}, ids)
+	})
+
+	t.Run("刚入库的文档不被淘汰", func(t *testing.T) {
+		fake.put("just-indexed", "刚入库的内容", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+			"user_id": "user-1", "importance_score": 0.0, "created_at": float64(now.Unix()),
+		})
+		report, err := engine.EnforceUserQuota(ctx, "user-1", "just-indexed")
+		require.NoError(t, err)
+		assert.Equal(t, 4, report.Documents)
+		assert.Len(t, report.EvictedIDs, 1)
+		assert.NotContains(t, report.EvictedIDs, "just-indexed")
+		assert.NotContains(t, fake.records["just-indexed"].metadata, MetadataKeyDeleted)
+	})
+
+	t.Run("未配置配额时不淘汰", func(t *testing.T) {
+		engine.evictionPolicy = NewEvictionPolicy(DefaultEvictionConfig(), nil)
+		report, err := engine.EnforceUserQuota(ctx, "user-2", "")
+		require.NoError(t, err)
+		assert.Empty(t, report.EvictedIDs)
+	})
+}
+
+// TestSearchEngine_PurgeEvicted 测试彻底删除软删除超过保留时长的文档
+func TestSearchEngine_PurgeEvicted(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryStore()
+	doc := func(id, userID string, deletedAt time.Time) *VectorDocument {
+		metadata := map[string]interface{}{"user_id": userID}
+		if !deletedAt.IsZero() {
+			metadata[MetadataKeyDeleted] = true
+			metadata[MetadataKeyDeletedAt] = deletedAt.Unix()
+		}
+		return &VectorDocument{ID: id, Content: id, Embedding: []float32{1, 0}, CreatedAt: now, Metadata: metadata}
+	}
+	require.NoError(t, store.AddDocuments(ctx, []*VectorDocument{
+		doc("active", "user-1", time.Time{}),
+		doc("expired", "user-1", now.Add(-8*24*time.Hour)),
+		doc("recent", "user-1", now.Add(-time.Hour)),
+		doc("other-expired", "user-2", now.Add(-30*24*time.Hour)),
+	}))
+
+	engine := newTestSearchEngine(t, new(MockEmbeddingService))
+	engine.store = store
+	engine.evictionPolicy = NewEvictionPolicy(DefaultEvictionConfig(), nil)
+
+	exists := func(id string) bool {
+		_, err := store.GetDocument(ctx, id)
+		return err == nil
+	}
+
+	t.Run("按用户清理", func(t *testing.T) {
+		report, err := engine.PurgeEvicted(ctx, "user-1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"expired"}, report.PurgedIDs)
+		assert.False(t, exists("expired"))
+		assert.True(t, exists("recent"))
+		assert.True(t, exists("active"))
+		assert.True(t, exists("other-expired"))
+	})
+
+	t.Run("全局清理", func(t *testing.T) {
+		report, err := engine.PurgeEvicted(ctx, "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"other-expired"}, report.PurgedIDs)
+		assert.True(t, exists("recent"))
+	})
+}
@@ -0,0 +1,212 @@
+package vector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"memoro/internal/errors"
+)
+
+// whereOperatorMinVersion 需要较新Chroma版本才支持的where运算符
+var whereOperatorMinVersion = map[string]string{
+	"$in":  "0.4.20",
+	"$nin": "0.4.20",
+}
+
+// normalizeWhere 将过滤条件转换为各Chroma版本都能正确解析的结构：
+// 每个子句只包含一个字段和一个运算符（范围条件拆成$gte和$lte两个子句），多个子句显式包裹在$and中
+func normalizeWhere(filter map[string]interface{}) map[string]interface{} {
+	clauses := whereClausesOf(filter)
+	switch len(clauses) {
+	case 0:
+		return map[string]interface{}{}
+	case 1:
+		return clauses[0]
+	default:
+		and := make([]interface{}, len(clauses))
+		for i, clause := range clauses {
+			and[i] = clause
+		}
+		return map[string]interface{}{"$and": and}
+	}
+}
+
+// whereClausesOf 将过滤条件拆分为单字段单运算符的子句（按字段名排序，已有的$and会被展开）
+func whereClausesOf(filter map[string]interface{}) []map[string]interface{} {
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	clauses := make([]map[string]interface{}, 0, len(filter))
+	for _, key := range keys {
+		condition := filter[key]
+
+		switch key {
+		case "$and":
+			for _, child := range whereList(condition) {
+				clauses = append(clauses, whereClausesOf(child)...)
+			}
+			continue
+		case "$or":
+			children := whereList(condition)
+			or := make([]interface{}, len(children))
+			for i, child := range children {
+				or[i] = normalizeWhere(child)
+			}
+			clauses = append(clauses, map[string]interface{}{"$or": or})
+			continue
+		}
+
+		cond, ok := condition.(map[string]interface{})
+		if !ok || len(cond) <= 1 {
+			clauses = append(clauses, map[string]interface{}{key: condition})
+			continue
+		}
+
+		ops := make([]string, 0, len(cond))
+		for op := range cond {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		for _, op := range ops {
+			clauses = append(clauses, map[string]interface{}{key: map[string]interface{}{op: cond[op]}})
+		}
+	}
+
+	return clauses
+}
+
+// whereList 获取$and/$or的子句列表
+func whereList(value interface{}) []map[string]interface{} {
+	switch list := value.(type) {
+	case []map[string]interface{}:
+		return list
+	case []interface{}:
+		clauses := make([]map[string]interface{}, 0, len(list))
+		for _, item := range list {
+			if clause, ok := item.(map[string]interface{}); ok {
+				clauses = append(clauses, clause)
+			}
+		}
+		return clauses
+	}
+	return nil
+}
+
+// validateWhereForVersion 校验规范化后的过滤条件：每个子句只有一个键，$and/$or至少包含两个子句，
+// 运算符和操作数类型合法，且运算符被检测到的Chroma版本支持（版本未知时不做版本检查）
+func validateWhereForVersion(where map[string]interface{}, version string) error {
+	if len(where) == 0 {
+		return nil
+	}
+	if len(where) > 1 {
+		return errors.ErrValidationFailed("filter", "each where clause must contain exactly one key, combine predicates with $and")
+	}
+
+	for key, condition := range where {
+		if key == "$and" || key == "$or" {
+			list, ok := condition.([]interface{})
+			if !ok {
+				return errors.ErrValidationFailed("filter."+key, "must be a list of clauses")
+			}
+			if len(list) < 2 {
+				return errors.ErrValidationFailed("filter."+key, "must contain at least two clauses")
+			}
+			for _, item := range list {
+				clause, ok := item.(map[string]interface{})
+				if !ok {
+					return errors.ErrValidationFailed("filter."+key, "clauses must be objects")
+				}
+				if err := validateWhereForVersion(clause, version); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(key, "$") {
+			return errors.ErrValidationFailed("filter", "unsupported logical operator: "+key)
+		}
+
+		cond, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if len(cond) != 1 {
+			return errors.ErrValidationFailed("filter."+key, "each field condition must contain exactly one operator")
+		}
+		for op, operand := range cond {
+			if err := validateWhereOperator(key, op, operand, version); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateWhereOperator 校验单个字段运算符及其操作数
+func validateWhereOperator(key, op string, operand interface{}, version string) error {
+	field := "filter." + key
+	switch op {
+	case "$eq", "$ne":
+	case "$gt", "$gte", "$lt", "$lte":
+		if _, ok := toFloat64(operand); !ok {
+			return errors.ErrValidationFailed(field, op+" requires a numeric operand")
+		}
+	case "$in", "$nin":
+		if len(toInterfaceSlice(operand)) == 0 {
+			return errors.ErrValidationFailed(field, op+" requires a non-empty list")
+		}
+	default:
+		return errors.ErrValidationFailed(field, "unsupported operator: "+op)
+	}
+
+	if minVersion, exists := whereOperatorMinVersion[op]; exists && !versionAtLeast(version, minVersion) {
+		return errors.ErrValidationFailed(field, fmt.Sprintf("operator %s requires Chroma %s or later, server is %s", op, minVersion, version))
+	}
+	return nil
+}
+
+// versionAtLeast 比较语义化版本号（只比较主、次、修订号），版本无法解析时视为满足
+func versionAtLeast(version, minVersion string) bool {
+	current, ok := parseVersion(version)
+	if !ok {
+		return true
+	}
+	required, ok := parseVersion(minVersion)
+	if !ok {
+		return true
+	}
+
+	for i := range current {
+		if current[i] != required[i] {
+			return current[i] > required[i]
+		}
+	}
+	return true
+}
+
+// parseVersion 解析形如"0.4.24"或"v1.0.0-rc1"的版本号
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	if version == "" {
+		return parts, false
+	}
+
+	for i, field := range strings.SplitN(version, ".", 3) {
+		number, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = number
+	}
+	return parts, true
+}
@@ -0,0 +1,92 @@
+package vector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/errors"
+	"memoro/internal/models"
+)
+
+// TestSearchEngine_BuildFilter 测试构建的过滤条件显式使用$and组合
+func TestSearchEngine_BuildFilter(t *testing.T) {
+	engine := newTestSearchEngine(t, newTestEmbeddingService(t))
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("多个条件包裹在$and中并拆分时间范围", func(t *testing.T) {
+		filter := engine.buildFilter(&SearchOptions{
+			UserID:       "user-1",
+			ContentTypes: []models.ContentType{models.ContentTypeText, models.ContentTypeLink},
+			TimeRange:    &TimeRange{StartTime: start, EndTime: end},
+		})
+
+		assert.Equal(t, map[string]interface{}{
+			"$and": []interface{}{
+				map[string]interface{}{"content_type": map[string]interface{}{"$in": []string{"text", "link"}}},
+				map[string]interface{}{"created_at": map[string]interface{}{"$gte": start.Unix()}},
+				map[string]interface{}{"created_at": map[string]interface{}{"$lte": end.Unix()}},
+				map[string]interface{}{"user_id": "user-1"},
+			},
+		}, filter)
+		assert.NoError(t, validateWhereForVersion(filter, "0.4.24"))
+	})
+
+	t.Run("单个条件不包裹", func(t *testing.T) {
+		assert.Equal(t, map[string]interface{}{"user_id": "user-1"}, engine.buildFilter(&SearchOptions{UserID: "user-1"}))
+		assert.Empty(t, engine.buildFilter(&SearchOptions{}))
+	})
+
+	t.Run("规范化是幂等的", func(t *testing.T) {
+		filter := engine.buildFilter(&SearchOptions{UserID: "user-1", ImportanceThreshold: 0.5})
+		assert.Equal(t, filter, normalizeWhere(filter))
+	})
+}
+
+// TestValidateWhereForVersion 测试按Chroma版本校验过滤条件
+func TestValidateWhereForVersion(t *testing.T) {
+	isValidationError := func(t *testing.T, err error) {
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeValidationFailed))
+	}
+
+	t.Run("结构校验", func(t *testing.T) {
+		isValidationError(t, validateWhereForVersion(map[string]interface{}{"user_id": "u", "content_type": "text"}, ""))
+		isValidationError(t, validateWhereForVersion(map[string]interface{}{"$and": []interface{}{map[string]interface{}{"user_id": "u"}}}, ""))
+		isValidationError(t, validateWhereForVersion(map[string]interface{}{"created_at": map[string]interface{}{"$gte": 1, "$lte": 2}}, ""))
+		isValidationError(t, validateWhereForVersion(map[string]interface{}{"created_at": map[string]interface{}{"$gte": "yesterday"}}, ""))
+		isValidationError(t, validateWhereForVersion(map[string]interface{}{"tags": map[string]interface{}{"$contains": "go"}}, ""))
+	})
+
+	t.Run("旧版本不支持$in", func(t *testing.T) {
+		where := map[string]interface{}{"tags": map[string]interface{}{"$in": []string{"go"}}}
+		isValidationError(t, validateWhereForVersion(where, "0.4.18"))
+		assert.NoError(t, validateWhereForVersion(where, "0.4.24"))
+		assert.NoError(t, validateWhereForVersion(where, "1.0.0"))
+		assert.NoError(t, validateWhereForVersion(where, ""))
+	})
+
+	t.Run("客户端在请求前规范化并校验", func(t *testing.T) {
+		fake := newFakeChromaServer(t)
+		fake.put("doc-1", "Go并发", nil, map[string]interface{}{"user_id": "user-1", "created_at": float64(100)})
+		fake.put("doc-2", "Go并发", nil, map[string]interface{}{"user_id": "user-1", "created_at": float64(300)})
+		client := newTestChromaClient(t, fake)
+		ctx := context.Background()
+
+		docs, err := client.GetDocumentsByFilter(ctx, map[string]interface{}{
+			"user_id":    "user-1",
+			"created_at": map[string]interface{}{"$gte": 50, "$lte": 200},
+		}, 10)
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		assert.Equal(t, "doc-1", docs[0].ID)
+
+		client.serverVersion = "0.4.18"
+		_, err = client.GetDocumentsByFilter(ctx, map[string]interface{}{"tags": map[string]interface{}{"$in": []string{"go"}}}, 10)
+		isValidationError(t, err)
+	})
+}
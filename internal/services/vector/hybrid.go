@@ -0,0 +1,218 @@
+package vector
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+)
+
+// 未配置时的混合推荐执行参数
+const (
+	defaultHybridMaxConcurrency = 4
+	defaultHybridTimeout        = 10 * time.Second
+)
+
+// hybridSettings 混合推荐设置
+type hybridSettings struct {
+	enabled        map[RecommendationType]bool // 启用的子策略，nil表示全部启用
+	maxConcurrency int                         // 同时执行的子策略数量上限
+	timeout        time.Duration               // 所有子策略共享的超时时间
+}
+
+// hybridSettingsFrom 从配置读取混合推荐设置，未配置的项使用默认值
+func hybridSettingsFrom(cfg *config.Config) hybridSettings {
+	settings := hybridSettings{
+		maxConcurrency: defaultHybridMaxConcurrency,
+		timeout:        defaultHybridTimeout,
+	}
+	if cfg == nil || cfg.VectorDB.Recommendation == nil {
+		return settings
+	}
+
+	hybrid := cfg.VectorDB.Recommendation.Hybrid
+	if len(hybrid.Strategies) > 0 {
+		settings.enabled = make(map[RecommendationType]bool, len(hybrid.Strategies))
+		for _, strategy := range hybrid.Strategies {
+			settings.enabled[RecommendationType(strategy)] = true
+		}
+	}
+	if hybrid.MaxConcurrency > 0 {
+		settings.maxConcurrency = hybrid.MaxConcurrency
+	}
+	if hybrid.Timeout > 0 {
+		settings.timeout = hybrid.Timeout
+	}
+	return settings
+}
+
+// isEnabled 子策略是否参与混合
+func (s hybridSettings) isEnabled(recType RecommendationType) bool {
+	return s.enabled == nil || s.enabled[recType]
+}
+
+// hybridStrategy 混合推荐的子策略
+type hybridStrategy struct {
+	recType   RecommendationType
+	weight    float64                               // 推荐分数权重
+	divisor   int                                   // 子策略推荐数量 = 请求数量 / divisor
+	applies   func(req *RecommendationRequest) bool // 请求是否满足子策略的执行条件，nil表示总是执行
+	recommend func(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error)
+}
+
+// HybridReport 混合推荐各子策略的执行情况
+type HybridReport struct {
+	Contributed []RecommendationType          `json:"contributed"`      // 提供了推荐结果的子策略
+	Failed      map[RecommendationType]string `json:"failed,omitempty"` // 失败或超时的子策略及原因
+}
+
+// hybridOutcome 子策略的执行结果
+type hybridOutcome struct {
+	index           int
+	recommendations []*RecommendationItem
+	err             error
+}
+
+// defaultHybridStrategies 默认的混合推荐子策略
+func (r *Recommender) defaultHybridStrategies() []*hybridStrategy {
+	return []*hybridStrategy{
+		{
+			recType:   RecommendationTypeSimilar,
+			weight:    0.3,
+			divisor:   2,
+			applies:   func(req *RecommendationRequest) bool { return req.SourceDocumentID != "" },
+			recommend: r.getSimilarRecommendations,
+		},
+		{
+			recType:   RecommendationTypePersonalized,
+			weight:    0.4,
+			divisor:   2,
+			applies:   func(req *RecommendationRequest) bool { return req.PersonalizationCtx != nil },
+			recommend: r.getPersonalizedRecommendations,
+		},
+		{
+			recType:   RecommendationTypeTrending,
+			weight:    0.2,
+			divisor:   3,
+			recommend: r.getTrendingRecommendations,
+		},
+		{
+			recType:   RecommendationTypeCollaborative,
+			weight:    0.1,
+			divisor:   4,
+			recommend: r.getCollaborativeRecommendations,
+		},
+	}
+}
+
+// getHybridRecommendations 获取混合推荐：启用的子策略在共享超时内并发执行（数量受限），
+// 单个子策略失败或超时不影响整体结果，只记录在执行报告中
+func (r *Recommender) getHybridRecommendations(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, *HybridReport, error) {
+	strategies := r.hybridStrategies
+	if len(strategies) == 0 {
+		strategies = r.defaultHybridStrategies()
+	}
+
+	selected := make([]*hybridStrategy, 0, len(strategies))
+	for _, strategy := range strategies {
+		if r.hybrid.isEnabled(strategy.recType) && (strategy.applies == nil || strategy.applies(req)) {
+			selected = append(selected, strategy)
+		}
+	}
+
+	maxConcurrency := r.hybrid.maxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultHybridMaxConcurrency
+	}
+	timeout := r.hybrid.timeout
+	if timeout <= 0 {
+		timeout = defaultHybridTimeout
+	}
+
+	r.logger.Debug("Getting hybrid recommendations", logger.Fields{
+		"strategies":      len(selected),
+		"max_concurrency": maxConcurrency,
+		"timeout":         timeout,
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// 通道有足够缓冲，超时后仍在执行的子策略不会阻塞
+	outcomes := make(chan hybridOutcome, len(selected))
+	semaphore := make(chan struct{}, maxConcurrency)
+	for i, strategy := range selected {
+		go func(index int, strategy *hybridStrategy) {
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				outcomes <- hybridOutcome{index: index, err: ctx.Err()}
+				return
+			}
+
+			subReq := *req
+			subReq.Type = strategy.recType
+			subReq.MaxRecommendations = req.MaxRecommendations / strategy.divisor
+			recommendations, err := strategy.recommend(ctx, &subReq)
+			outcomes <- hybridOutcome{index: index, recommendations: recommendations, err: err}
+		}(i, strategy)
+	}
+
+	results := make([]*hybridOutcome, len(selected))
+collect:
+	for received := 0; received < len(selected); received++ {
+		select {
+		case outcome := <-outcomes:
+			results[outcome.index] = &outcome
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	report := &HybridReport{Contributed: []RecommendationType{}}
+	var allRecommendations []*RecommendationItem
+	for i, strategy := range selected {
+		outcome := results[i]
+		var err error
+		switch {
+		case outcome == nil:
+			err = ctx.Err()
+		case outcome.err != nil:
+			err = outcome.err
+		}
+		if err != nil {
+			if report.Failed == nil {
+				report.Failed = make(map[RecommendationType]string)
+			}
+			report.Failed[strategy.recType] = err.Error()
+			r.logger.Warn("Hybrid recommendation strategy failed", logger.Fields{
+				"strategy": string(strategy.recType),
+				"error":    err.Error(),
+			})
+			continue
+		}
+		if len(outcome.recommendations) == 0 {
+			continue
+		}
+
+		for _, rec := range outcome.recommendations {
+			rec.RecommendationScore *= strategy.weight
+			rec.Explanation = r.addHybridExplanation(rec.Explanation, string(strategy.recType), strategy.weight)
+		}
+		allRecommendations = append(allRecommendations, outcome.recommendations...)
+		report.Contributed = append(report.Contributed, strategy.recType)
+	}
+
+	// 合并和去重
+	uniqueRecommendations := r.mergeAndDeduplicateRecommendations(allRecommendations)
+
+	// 按混合分数排序
+	sort.Slice(uniqueRecommendations, func(i, j int) bool {
+		return uniqueRecommendations[i].RecommendationScore > uniqueRecommendations[j].RecommendationScore
+	})
+
+	return uniqueRecommendations, report, nil
+}
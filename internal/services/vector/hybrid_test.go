@@ -0,0 +1,237 @@
+package vector
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+)
+
+// TestRecommender_Hybrid 测试混合推荐的子策略开关和并发执行
+func TestRecommender_Hybrid(t *testing.T) {
+	ctx := context.Background()
+
+	// newHybridRecommender 创建使用内存存储的推荐系统，协同过滤的模拟数据会推荐doc4
+	newHybridRecommender := func(t *testing.T, hybridConfig config.HybridRecommendationConfig) *Recommender {
+		store := NewMemoryStore()
+		require.NoError(t, store.AddDocuments(ctx, []*VectorDocument{
+			{ID: "doc4", Content: "相似用户喜欢的内容", Embedding: []float32{1, 0}, Metadata: map[string]interface{}{"user_id": "user-2"}, CreatedAt: time.Now()},
+			{ID: "doc-recent", Content: "最近的内容", Embedding: []float32{0, 1}, Metadata: map[string]interface{}{"user_id": "user-1"}, CreatedAt: time.Now()},
+		}))
+
+		engine := newTestSearchEngine(t, new(MockEmbeddingService))
+		engine.store = store
+		recommender := &Recommender{
+			searchEngine:   engine,
+			similarityCalc: NewSimilarityCalculator(),
+			ranker:         NewRanker(),
+			interactions:   NewInteractionStore(),
+			logger:         logger.NewLogger("recommender-test"),
+			hybrid: hybridSettingsFrom(&config.Config{
+				VectorDB: config.VectorDBConfig{
+					Recommendation: &config.RecommendationConfig{Hybrid: hybridConfig},
+				},
+			}),
+		}
+		recommender.trendingJob = NewTrendingJob(recommender.scanTrendingDocuments, recommender.interactions, DefaultTrendingJobConfig())
+		return recommender
+	}
+
+	request := func() *RecommendationRequest {
+		return &RecommendationRequest{
+			Type:               RecommendationTypeHybrid,
+			UserID:             "user-1",
+			MaxRecommendations: 12,
+		}
+	}
+
+	documentIDs := func(items []*RecommendationItem) []string {
+		ids := make([]string, len(items))
+		for i, item := range items {
+			ids[i] = item.DocumentID
+		}
+		return ids
+	}
+
+	t.Run("默认启用协同过滤", func(t *testing.T) {
+		recommender := newHybridRecommender(t, config.HybridRecommendationConfig{})
+
+		response, err := recommender.GetRecommendations(ctx, request())
+		require.NoError(t, err)
+
+		assert.Contains(t, documentIDs(response.Recommendations), "doc4")
+		report, ok := response.Metadata["hybrid"].(*HybridReport)
+		require.True(t, ok)
+		assert.Contains(t, report.Contributed, RecommendationTypeCollaborative)
+	})
+
+	t.Run("禁用协同过滤后不再贡献结果", func(t *testing.T) {
+		recommender := newHybridRecommender(t, config.HybridRecommendationConfig{
+			Strategies: []string{"similar", "personalized", "trending"},
+		})
+
+		response, err := recommender.GetRecommendations(ctx, request())
+		require.NoError(t, err)
+
+		assert.NotContains(t, documentIDs(response.Recommendations), "doc4")
+		report, ok := response.Metadata["hybrid"].(*HybridReport)
+		require.True(t, ok)
+		assert.NotContains(t, report.Contributed, RecommendationTypeCollaborative)
+		assert.NotContains(t, report.Failed, RecommendationTypeCollaborative)
+	})
+
+	t.Run("子策略并发执行，失败的子策略被记录但不影响结果", func(t *testing.T) {
+		recommender := newHybridRecommender(t, config.HybridRecommendationConfig{Timeout: 5 * time.Second})
+
+		// 每个子策略都等待其他子策略开始执行，串行执行时会等到超时
+		var started sync.WaitGroup
+		started.Add(3)
+		waitForOthers := func(ctx context.Context) error {
+			started.Done()
+			done := make(chan struct{})
+			go func() {
+				started.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		recommender.hybridStrategies = []*hybridStrategy{
+			{recType: RecommendationTypeSimilar, weight: 0.5, divisor: 1, recommend: func(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error) {
+				if err := waitForOthers(ctx); err != nil {
+					return nil, err
+				}
+				return []*RecommendationItem{{DocumentID: "a", RecommendationScore: 1}}, nil
+			}},
+			{recType: RecommendationTypeTrending, weight: 0.5, divisor: 1, recommend: func(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error) {
+				if err := waitForOthers(ctx); err != nil {
+					return nil, err
+				}
+				return []*RecommendationItem{{DocumentID: "b", RecommendationScore: 0.8}}, nil
+			}},
+			{recType: RecommendationTypeCollaborative, weight: 0.5, divisor: 1, recommend: func(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error) {
+				if err := waitForOthers(ctx); err != nil {
+					return nil, err
+				}
+				return nil, assert.AnError
+			}},
+		}
+
+		start := time.Now()
+		recommendations, report, err := recommender.getHybridRecommendations(ctx, request())
+		require.NoError(t, err)
+
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.Equal(t, []string{"a", "b"}, documentIDs(recommendations))
+		assert.Equal(t, []RecommendationType{RecommendationTypeSimilar, RecommendationTypeTrending}, report.Contributed)
+		assert.Contains(t, report.Failed, RecommendationTypeCollaborative)
+	})
+}
+
+// TestRecommender_HybridConfidence 测试混合推荐中不同策略的置信度归一化到0-1且可按阈值过滤
+func TestRecommender_HybridConfidence(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	store := NewMemoryStore()
+	require.NoError(t, store.AddDocuments(ctx, []*VectorDocument{
+		{ID: "source", Content: "源文档", Embedding: []float32{1, 0}, Metadata: map[string]interface{}{"user_id": "user-1"}, CreatedAt: now},
+		{ID: "near", Content: "相近的内容", Embedding: []float32{0.95, 0.05}, Metadata: map[string]interface{}{"user_id": "user-1"}, CreatedAt: now},
+		{ID: "opposite", Content: "相反的内容", Embedding: []float32{-1, 0.1}, Metadata: map[string]interface{}{"user_id": "user-1"}, CreatedAt: now},
+		{ID: "doc4", Content: "相似用户喜欢的内容", Embedding: []float32{0, 1}, Metadata: map[string]interface{}{"user_id": "user-2"}, CreatedAt: now},
+	}))
+
+	engine := newTestSearchEngine(t, new(MockEmbeddingService))
+	engine.store = store
+	recommender := &Recommender{
+		searchEngine:   engine,
+		similarityCalc: NewSimilarityCalculator(),
+		ranker:         NewRanker(),
+		interactions:   NewInteractionStore(),
+		logger:         logger.NewLogger("recommender-test"),
+		hybrid: hybridSettingsFrom(&config.Config{
+			VectorDB: config.VectorDBConfig{
+				Recommendation: &config.RecommendationConfig{
+					Hybrid: config.HybridRecommendationConfig{Strategies: []string{"similar", "trending", "collaborative"}},
+				},
+			},
+		}),
+	}
+	recommender.interactions.RecordInteraction("user-1", "near", now)
+	recommender.interactions.RecordInteraction("user-2", "opposite", now)
+	recommender.trendingJob = NewTrendingJob(recommender.scanTrendingDocuments, recommender.interactions, DefaultTrendingJobConfig())
+
+	request := func(minConfidence float64) *RecommendationRequest {
+		return &RecommendationRequest{
+			Type:                RecommendationTypeHybrid,
+			SourceDocumentID:    "source",
+			MaxRecommendations:  12,
+			IncludeExplanations: true,
+			MinConfidence:       minConfidence,
+		}
+	}
+
+	t.Run("各策略置信度在0到1之间并写入因子分解", func(t *testing.T) {
+		response, err := recommender.GetRecommendations(ctx, request(0))
+		require.NoError(t, err)
+
+		report, ok := response.Metadata["hybrid"].(*HybridReport)
+		require.True(t, ok)
+		assert.ElementsMatch(t, []RecommendationType{RecommendationTypeSimilar, RecommendationTypeTrending, RecommendationTypeCollaborative}, report.Contributed)
+
+		confidences := make(map[string]float64)
+		for _, rec := range response.Recommendations {
+			assert.GreaterOrEqual(t, rec.Confidence, 0.0, rec.DocumentID)
+			assert.LessOrEqual(t, rec.Confidence, 1.0, rec.DocumentID)
+			require.NotNil(t, rec.Explanation)
+			assert.Equal(t, rec.Confidence, rec.Explanation.FactorBreakdown[ExplanationFactorConfidence])
+			confidences[rec.DocumentID] = rec.Confidence
+		}
+		assert.Greater(t, confidences["near"], 0.9)
+	})
+
+	t.Run("按最小置信度过滤", func(t *testing.T) {
+		response, err := recommender.GetRecommendations(ctx, request(0.75))
+		require.NoError(t, err)
+
+		ids := make([]string, 0, len(response.Recommendations))
+		for _, rec := range response.Recommendations {
+			assert.GreaterOrEqual(t, rec.Confidence, 0.75)
+			ids = append(ids, rec.DocumentID)
+		}
+		assert.Contains(t, ids, "near")
+		assert.Equal(t, 0.75, response.Metadata["min_confidence"])
+		assert.Greater(t, response.Metadata["confidence_filtered"], 0)
+	})
+
+	t.Run("无关或负相关的相似推荐置信度为0", func(t *testing.T) {
+		recommendations, err := recommender.getSimilarRecommendations(ctx, &RecommendationRequest{SourceDocumentID: "source", MaxRecommendations: 5})
+		require.NoError(t, err)
+
+		confidences := make(map[string]float64)
+		for _, rec := range recommendations {
+			confidences[rec.DocumentID] = rec.Confidence
+		}
+		require.Contains(t, confidences, "doc4")
+		require.Contains(t, confidences, "opposite")
+		assert.InDelta(t, 0.0, confidences["doc4"], 1e-6) // 正交，相似度为0.5
+		assert.Equal(t, 0.0, confidences["opposite"])
+		assert.Greater(t, confidences["near"], 0.9)
+	})
+
+	t.Run("无效的最小置信度返回错误", func(t *testing.T) {
+		_, err := recommender.GetRecommendations(ctx, request(1.5))
+		assert.Error(t, err)
+	})
+}
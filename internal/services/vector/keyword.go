@@ -0,0 +1,281 @@
+package vector
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+)
+
+// SearchMode 检索模式
+type SearchMode string
+
+const (
+	SearchModeVector  SearchMode = "vector"  // 仅向量检索（稠密）
+	SearchModeHybrid  SearchMode = "hybrid"  // 向量检索与关键词检索按倒数排名融合
+	SearchModeKeyword SearchMode = "keyword" // 仅关键词检索（稀疏），相似度仍按查询向量计算
+)
+
+// IsValidSearchMode 检查检索模式是否有效
+func IsValidSearchMode(mode SearchMode) bool {
+	switch mode {
+	case SearchModeVector, SearchModeHybrid, SearchModeKeyword:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	defaultRRFK = 60 // 倒数排名融合的默认平滑常数
+
+	// BM25参数
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// hybridSearchSettings 混合检索设置
+type hybridSearchSettings struct {
+	defaultMode       SearchMode // 请求未指定时的检索模式
+	vectorWeight      float64    // 向量检索结果的融合权重
+	keywordWeight     float64    // 关键词检索结果的融合权重
+	rrfK              int        // 倒数排名融合的平滑常数
+	keywordCandidates int        // 关键词检索的候选文档数上限，0表示与向量检索相同
+}
+
+// hybridSearchSettingsFrom 从配置读取混合检索设置，未配置的项使用默认值
+func hybridSearchSettingsFrom(cfg *config.HybridSearchConfig) hybridSearchSettings {
+	settings := hybridSearchSettings{
+		defaultMode:   SearchModeVector,
+		vectorWeight:  1,
+		keywordWeight: 1,
+		rrfK:          defaultRRFK,
+	}
+	if cfg == nil {
+		return settings
+	}
+
+	if cfg.DefaultMode != "" {
+		settings.defaultMode = SearchMode(cfg.DefaultMode)
+	}
+	if cfg.VectorWeight > 0 {
+		settings.vectorWeight = cfg.VectorWeight
+	}
+	if cfg.KeywordWeight > 0 {
+		settings.keywordWeight = cfg.KeywordWeight
+	}
+	if cfg.RRFK > 0 {
+		settings.rrfK = cfg.RRFK
+	}
+	settings.keywordCandidates = cfg.KeywordCandidates
+	return settings
+}
+
+// keywordHit 关键词检索命中的文档及其BM25分数
+type keywordHit struct {
+	doc   *VectorDocument
+	score float64
+}
+
+// keywordSearch 按查询词检索候选文档并按BM25分数降序排列，没有可用的查询词时返回空
+func (se *SearchEngine) keywordSearch(ctx context.Context, options *SearchOptions, filter map[string]interface{}) ([]keywordHit, error) {
+	terms := se.queryTerms(options.Query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	limit := hybridSearchSettingsFrom(se.config.HybridSearch).keywordCandidates
+	if limit <= 0 {
+		limit = options.MaxResults
+	}
+
+	keywordQuery := &KeywordQuery{
+		Terms:       keywordQueryTerms(options.Query, terms),
+		Filter:      filter,
+		Limit:       limit,
+		IncludeText: true,
+	}
+	docs, err := se.store.KeywordSearch(ctx, keywordQuery)
+	if err != nil {
+		return nil, err
+	}
+	if options.IncludeArchived && se.archiveStore != nil {
+		archived, err := se.archiveStore.KeywordSearch(ctx, keywordQuery)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, archived...)
+	}
+
+	hits := scoreBM25(docs, terms)
+	if !options.wantsContent() {
+		for _, hit := range hits {
+			hit.doc.Content = ""
+		}
+	}
+	return hits, nil
+}
+
+// keywordQueryTerms 查询词及其在原始查询中的大小写形式（Chroma的$contains区分大小写）
+func keywordQueryTerms(query string, terms []string) []string {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	variants := append([]string(nil), terms...)
+	seen := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		seen[term] = true
+	}
+	for _, word := range strings.Fields(query) {
+		word = strings.TrimFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if wanted[strings.ToLower(word)] && !seen[word] {
+			seen[word] = true
+			variants = append(variants, word)
+		}
+	}
+	return variants
+}
+
+// scoreBM25 按BM25计算候选文档对查询词的分数（文档频率在候选集合内统计），只返回分数大于0的文档
+func scoreBM25(docs []*VectorDocument, terms []string) []keywordHit {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(docs))
+	lengths := make([]float64, len(docs))
+	totalLength := 0.0
+	docFreq := make(map[string]int, len(terms))
+	for i, doc := range docs {
+		texts[i] = keywordSearchText(doc)
+		lengths[i] = float64(len(strings.Fields(texts[i])))
+		totalLength += lengths[i]
+		for _, term := range terms {
+			if strings.Contains(texts[i], term) {
+				docFreq[term]++
+			}
+		}
+	}
+	avgLength := totalLength / float64(len(docs))
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	n := float64(len(docs))
+	hits := make([]keywordHit, 0, len(docs))
+	for i, doc := range docs {
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(strings.Count(texts[i], term))
+			if tf == 0 {
+				continue
+			}
+			df := float64(docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*lengths[i]/avgLength))
+		}
+		if score > 0 {
+			hits = append(hits, keywordHit{doc: doc, score: score})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].doc.ID < hits[j].doc.ID
+	})
+	return hits
+}
+
+// keywordSearchText 关键词检索匹配的文本（内容、标题、标签和关键词，小写）
+func keywordSearchText(doc *VectorDocument) string {
+	title, _ := doc.Metadata["title"].(string)
+	parts := []string{doc.Content, title}
+	parts = append(parts, metadataStrings(doc.Metadata["tags"])...)
+	parts = append(parts, metadataStrings(doc.Metadata["keywords"])...)
+	return strings.ToLower(strings.Join(parts, " "))
+}
+
+// fuseRankedLists 按加权倒数排名融合向量检索和关键词检索的结果：分数为各列表的 权重/(k+排名) 之和，
+// 返回按融合分数降序排列的文档以及每个文档的关键词分数和融合分数
+func fuseRankedLists(vectorDocs []*VectorDocument, keywordHits []keywordHit, settings hybridSearchSettings) ([]*VectorDocument, map[string]float64, map[string]float64) {
+	fused := make(map[string]float64, len(vectorDocs)+len(keywordHits))
+	keywordScores := make(map[string]float64, len(keywordHits))
+	docs := make(map[string]*VectorDocument, len(vectorDocs)+len(keywordHits))
+	order := make([]string, 0, len(vectorDocs)+len(keywordHits))
+
+	k := float64(settings.rrfK)
+	for rank, doc := range vectorDocs {
+		if _, exists := docs[doc.ID]; !exists {
+			docs[doc.ID] = doc
+			order = append(order, doc.ID)
+		}
+		fused[doc.ID] += settings.vectorWeight / (k + float64(rank+1))
+	}
+	for rank, hit := range keywordHits {
+		if existing, exists := docs[hit.doc.ID]; !exists {
+			docs[hit.doc.ID] = hit.doc
+			order = append(order, hit.doc.ID)
+		} else if len(existing.Embedding) == 0 && len(hit.doc.Embedding) > 0 {
+			// 向量检索结果可能不含向量，补上关键词检索返回的向量
+			existing.Embedding = hit.doc.Embedding
+		}
+		keywordScores[hit.doc.ID] = hit.score
+		fused[hit.doc.ID] += settings.keywordWeight / (k + float64(rank+1))
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return fused[order[i]] > fused[order[j]]
+	})
+
+	result := make([]*VectorDocument, 0, len(order))
+	for _, id := range order {
+		result = append(result, docs[id])
+	}
+	return result, keywordScores, fused
+}
+
+// retrieveCandidates 按检索模式获取候选文档：向量模式只做向量检索，关键词模式只做关键词检索，混合模式融合两者
+func (se *SearchEngine) retrieveCandidates(ctx context.Context, searchQuery *SearchQuery, options *SearchOptions) (*SearchResult, map[string]float64, map[string]float64, error) {
+	if options.Mode == SearchModeVector || options.Mode == "" {
+		vectorResults, err := se.vectorSearch(ctx, searchQuery, options)
+		return vectorResults, nil, nil, err
+	}
+
+	var vectorDocs []*VectorDocument
+	if options.Mode == SearchModeHybrid {
+		vectorResults, err := se.vectorSearch(ctx, searchQuery, options)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		vectorDocs = vectorResults.Documents
+	}
+
+	keywordHits, err := se.keywordSearch(ctx, options, searchQuery.Filter)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	fusedDocs, keywordScores, fusionScores := fuseRankedLists(vectorDocs, keywordHits, hybridSearchSettingsFrom(se.config.HybridSearch))
+	if len(fusedDocs) > options.MaxResults {
+		fusedDocs = fusedDocs[:options.MaxResults]
+	}
+
+	se.logger.Debug("Candidates fused from vector and keyword search", logger.Fields{
+		"mode":            string(options.Mode),
+		"vector_results":  len(vectorDocs),
+		"keyword_results": len(keywordHits),
+		"fused_results":   len(fusedDocs),
+	})
+
+	return &SearchResult{Documents: fusedDocs, TotalResults: len(fusedDocs)}, keywordScores, fusionScores, nil
+}
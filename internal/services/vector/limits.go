@@ -0,0 +1,84 @@
+package vector
+
+import (
+	"fmt"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+)
+
+// 未配置时的搜索规模上限
+const (
+	defaultMaxTopK               = 100
+	defaultMaxResultsCap         = 500
+	defaultEarlyTerminationScore = 0.85
+)
+
+// searchLimitsFrom 获取搜索规模上限（0使用默认值）
+func searchLimitsFrom(limits *config.SearchLimitsConfig) config.SearchLimitsConfig {
+	result := config.SearchLimitsConfig{
+		MaxTopK:               defaultMaxTopK,
+		MaxResults:            defaultMaxResultsCap,
+		EarlyTerminationScore: defaultEarlyTerminationScore,
+	}
+	if limits == nil {
+		return result
+	}
+
+	if limits.MaxTopK > 0 {
+		result.MaxTopK = limits.MaxTopK
+	}
+	if limits.MaxResults > 0 {
+		result.MaxResults = limits.MaxResults
+	}
+	if limits.EarlyTerminationScore > 0 {
+		result.EarlyTerminationScore = limits.EarlyTerminationScore
+	}
+	return result
+}
+
+// clampSearchOptions 将超出服务端上限的top_k和max_results截断到上限，并记录提示
+func (se *SearchEngine) clampSearchOptions(options *SearchOptions) {
+	limits := searchLimitsFrom(se.config.SearchLimits)
+	options.limitWarnings = nil
+
+	if options.TopK > limits.MaxTopK {
+		options.limitWarnings = append(options.limitWarnings,
+			fmt.Sprintf("top_k %d exceeds limit, clamped to %d", options.TopK, limits.MaxTopK))
+		options.TopK = limits.MaxTopK
+	}
+	if options.MaxResults > limits.MaxResults {
+		options.limitWarnings = append(options.limitWarnings,
+			fmt.Sprintf("max_results %d exceeds limit, clamped to %d", options.MaxResults, limits.MaxResults))
+		options.MaxResults = limits.MaxResults
+	}
+
+	if len(options.limitWarnings) > 0 {
+		se.logger.Warn("Search options clamped to server limits", logger.Fields{
+			"top_k":       options.TopK,
+			"max_results": options.MaxResults,
+			"warnings":    options.limitWarnings,
+		})
+	}
+}
+
+// highConfidenceCandidates 已有top_k个达到高置信阈值的结果时只返回这些结果，后续重排序不再处理其余低分结果
+func (se *SearchEngine) highConfidenceCandidates(results []*SearchResultItem, options *SearchOptions) ([]*SearchResultItem, bool) {
+	// 折叠重复项、折叠线程和保证内容类型数量需要扫描全部结果
+	if options.CollapseDuplicates || options.CollapseThreads || options.MinPerContentType > 0 {
+		return results, false
+	}
+
+	threshold := searchLimitsFrom(se.config.SearchLimits).EarlyTerminationScore
+	candidates := make([]*SearchResultItem, 0, options.TopK)
+	for _, result := range results {
+		if result.RelevanceScore >= threshold && result.Similarity >= float64(options.MinSimilarity) {
+			candidates = append(candidates, result)
+		}
+	}
+
+	if len(candidates) < options.TopK || len(candidates) == len(results) {
+		return results, false
+	}
+	return candidates, true
+}
@@ -0,0 +1,67 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+)
+
+// TestSearchEngine_SearchLimits 测试搜索规模上限和高置信提前终止
+func TestSearchEngine_SearchLimits(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	engine := newTestSearchEngine(t, newTestEmbeddingService(t))
+	engine.store = newTestChromaClient(t, fake)
+	engine.config.SearchLimits = &config.SearchLimitsConfig{MaxTopK: 5, MaxResults: 20, EarlyTerminationScore: 0.15}
+	ctx := context.Background()
+
+	// 3个文档包含查询词（关键词分数0.2），2个不包含
+	for i := 0; i < 5; i++ {
+		content := fmt.Sprintf("分布式系统笔记 %d", i)
+		if i < 3 {
+			content = fmt.Sprintf("raft consensus 笔记 %d", i)
+		}
+		fake.put(fmt.Sprintf("doc-%d", i), content, nil, map[string]interface{}{"user_id": "user-1"})
+	}
+
+	t.Run("超出上限时截断并在元数据中提示", func(t *testing.T) {
+		options := &SearchOptions{Query: "raft", TopK: 1000, MaxResults: 100000}
+		response, err := engine.Search(ctx, options)
+		require.NoError(t, err)
+
+		assert.Equal(t, 5, options.TopK)
+		assert.Equal(t, 20, options.MaxResults)
+		assert.Equal(t, true, response.Metadata["limits_clamped"])
+		assert.Len(t, response.Metadata["warnings"], 2)
+		assert.LessOrEqual(t, len(response.Results), 5)
+	})
+
+	t.Run("未超出上限时不提示", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "raft", TopK: 5})
+		require.NoError(t, err)
+		assert.NotContains(t, response.Metadata, "limits_clamped")
+	})
+
+	t.Run("已有足够高置信结果时只重排序这些结果", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "raft", TopK: 2, IncludeContent: true, EnableReranking: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, true, response.Metadata["early_terminated"])
+		assert.Equal(t, 3, response.Metadata["reranked_candidates"])
+		require.Len(t, response.Results, 2)
+		for _, result := range response.Results {
+			assert.Contains(t, result.Content, "raft")
+		}
+	})
+
+	t.Run("高置信结果不足时处理全部结果", func(t *testing.T) {
+		response, err := engine.Search(ctx, &SearchOptions{Query: "raft", TopK: 4, IncludeContent: true, EnableReranking: true})
+		require.NoError(t, err)
+		assert.NotContains(t, response.Metadata, "early_terminated")
+		assert.Len(t, response.Results, 4)
+	})
+}
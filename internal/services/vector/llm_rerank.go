@@ -0,0 +1,150 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+)
+
+// 未配置时的LLM重排序参数
+const (
+	defaultLLMRerankCandidates = 10
+	defaultLLMRerankDocChars   = 500
+)
+
+// llmRerankSystemPrompt 相关性打分提示词
+const llmRerankSystemPrompt = `你是搜索相关性评估专家。根据用户查询，为每个编号的候选文档给出0到1之间的相关性分数（1表示完全满足查询意图，0表示无关）。
+只返回JSON，格式为 {"scores":[分数1,分数2,...]}，分数顺序与文档编号一致，数量与文档数相同。`
+
+// RelevanceCompleter LLM重排序使用的补全接口（*llm.Client实现，调用计入用户token额度）
+type RelevanceCompleter interface {
+	SimpleCompletion(ctx context.Context, systemPrompt, userMessage string) (string, error)
+}
+
+// llmRerankResponse LLM返回的相关性分数
+type llmRerankResponse struct {
+	Scores []float64 `json:"scores"`
+}
+
+// SetRelevanceCompleter 设置LLM重排序使用的补全客户端，未设置时启用LLM重排序的搜索退回启发式排序
+func (se *SearchEngine) SetRelevanceCompleter(completer RelevanceCompleter) {
+	se.relevanceCompleter = completer
+}
+
+// llmRerankConfigFrom 获取LLM重排序配置（未配置时使用默认值）
+func llmRerankConfigFrom(cfg *config.LLMRerankConfig) config.LLMRerankConfig {
+	rerankConfig := config.LLMRerankConfig{}
+	if cfg != nil {
+		rerankConfig = *cfg
+	}
+	if rerankConfig.MaxCandidates <= 0 {
+		rerankConfig.MaxCandidates = defaultLLMRerankCandidates
+	}
+	if rerankConfig.MaxDocumentChars <= 0 {
+		rerankConfig.MaxDocumentChars = defaultLLMRerankDocChars
+	}
+	return rerankConfig
+}
+
+// llmRerank 让LLM为前N个结果打相关性分数并按分数重排（N受配置上限约束），其余结果保持原顺序。
+// LLM不可用、调用失败或返回无法解析时保持原顺序，返回重排的结果数（失败时为0）和错误
+func (se *SearchEngine) llmRerank(ctx context.Context, query string, results []*SearchResultItem) (int, error) {
+	if se.relevanceCompleter == nil {
+		return 0, errors.ErrConfigMissing("llm")
+	}
+	if len(results) < 2 {
+		return 0, nil
+	}
+
+	rerankConfig := llmRerankConfigFrom(se.config.LLMRerank)
+	candidates := results
+	if len(candidates) > rerankConfig.MaxCandidates {
+		candidates = candidates[:rerankConfig.MaxCandidates]
+	}
+
+	if rerankConfig.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rerankConfig.Timeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	answer, err := se.relevanceCompleter.SimpleCompletion(ctx, llmRerankSystemPrompt,
+		buildLLMRerankPrompt(query, candidates, rerankConfig.MaxDocumentChars))
+	if err != nil {
+		return 0, err
+	}
+
+	scores, err := parseLLMRerankScores(answer, len(candidates))
+	if err != nil {
+		return 0, err
+	}
+
+	for i, item := range candidates {
+		score := scores[i]
+		item.LLMRelevanceScore = &score
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return *candidates[i].LLMRelevanceScore > *candidates[j].LLMRelevanceScore
+	})
+
+	se.logger.Debug("LLM rerank completed", logger.Fields{
+		"query":      query,
+		"candidates": len(candidates),
+		"duration":   time.Since(startTime),
+	})
+
+	return len(candidates), nil
+}
+
+// buildLLMRerankPrompt 构建相关性打分的用户消息（文档内容按字符数截断）
+func buildLLMRerankPrompt(query string, candidates []*SearchResultItem, maxChars int) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "查询：%s\n\n候选文档：\n", query)
+	for i, item := range candidates {
+		text := item.Content
+		if text == "" {
+			text = item.ContentSummary
+		}
+		text = strings.Join(strings.Fields(text), " ")
+		if runes := []rune(text); len(runes) > maxChars {
+			text = string(runes[:maxChars]) + "..."
+		}
+		fmt.Fprintf(&builder, "[%d] %s\n", i+1, text)
+	}
+	return builder.String()
+}
+
+// parseLLMRerankScores 解析LLM返回的分数，数量不符时视为失败，分数截断到0-1
+func parseLLMRerankScores(answer string, count int) ([]float64, error) {
+	answer = strings.TrimSpace(answer)
+	answer = strings.TrimPrefix(answer, "```json")
+	answer = strings.TrimPrefix(answer, "```")
+	answer = strings.TrimSuffix(answer, "```")
+
+	var response llmRerankResponse
+	if err := json.Unmarshal([]byte(strings.TrimSpace(answer)), &response); err != nil {
+		return nil, errors.NewMemoroError(errors.ErrorTypeLLM, errors.ErrCodeLLMAPICall, "Invalid LLM rerank response").
+			WithCause(err)
+	}
+	if len(response.Scores) != count {
+		return nil, errors.NewMemoroError(errors.ErrorTypeLLM, errors.ErrCodeLLMAPICall, "Invalid LLM rerank response").
+			WithDetails(fmt.Sprintf("expected %d scores, got %d", count, len(response.Scores)))
+	}
+
+	for i, score := range response.Scores {
+		if score < 0 {
+			response.Scores[i] = 0
+		} else if score > 1 {
+			response.Scores[i] = 1
+		}
+	}
+	return response.Scores, nil
+}
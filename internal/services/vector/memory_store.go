@@ -0,0 +1,589 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"memoro/internal/errors"
+)
+
+// MemoryStore 内存向量存储，行为与Chroma保持一致（默认平方L2距离、where过滤、元数据按键合并），用于测试和无外部服务的场景
+type MemoryStore struct {
+	mu        sync.RWMutex
+	documents map[string]*VectorDocument
+	name      string
+	distance  DistanceFunction // 指定的距离函数，为空时按平方L2计算且不参与默认相似度推导
+}
+
+// MemoryStore 实现 VectorStore
+var _ VectorStore = (*MemoryStore)(nil)
+
+// MemoryStore 实现 DistanceReporter
+var _ DistanceReporter = (*MemoryStore)(nil)
+
+// NewMemoryStore 创建内存向量存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		documents: make(map[string]*VectorDocument),
+		name:      "memory",
+	}
+}
+
+// NewMemoryStoreWithDistance 创建使用指定距离函数的内存向量存储（与同样距离函数的Chroma集合一致）
+func NewMemoryStoreWithDistance(distance DistanceFunction) *MemoryStore {
+	store := NewMemoryStore()
+	store.distance = distance
+	return store
+}
+
+// DistanceFunction 获取指定的距离函数（未指定时为空）
+func (ms *MemoryStore) DistanceFunction() DistanceFunction {
+	return ms.distance
+}
+
+// Search 执行相似度搜索，按距离升序返回（距离相同时按ID排序），与Chroma查询一致返回向量
+func (ms *MemoryStore) Search(ctx context.Context, query *SearchQuery) (*SearchResult, error) {
+	if query == nil {
+		return nil, errors.ErrValidationFailed("query", "cannot be nil")
+	}
+	if len(query.QueryVector) == 0 {
+		return nil, errors.ErrValidationFailed("query", "query vector is required for search")
+	}
+	if err := validateWhere(query.Filter); err != nil {
+		return nil, err
+	}
+
+	topK := query.TopK
+	if topK <= 0 {
+		topK = 10
+	}
+
+	startTime := time.Now()
+
+	ms.mu.RLock()
+	documents := make([]*VectorDocument, 0, len(ms.documents))
+	for _, id := range ms.sortedIDs() {
+		stored := ms.documents[id]
+		if !matchesWhereFilter(stored.Metadata, query.Filter) {
+			continue
+		}
+
+		distance := vectorDistance(ms.distance, query.QueryVector, stored.Embedding)
+		if similarity, _ := distanceToSimilarity(ms.distance, distance); !meetsMinSimilarity(similarity, query.MinSimilarity) {
+			continue
+		}
+
+		doc := copyVectorDocument(stored)
+		doc.Distance = distance
+		if !query.IncludeText {
+			doc.Content = ""
+		}
+		documents = append(documents, doc)
+	}
+	ms.mu.RUnlock()
+
+	sort.SliceStable(documents, func(i, j int) bool { return documents[i].Distance < documents[j].Distance })
+	if len(documents) > topK {
+		documents = documents[:topK]
+	}
+
+	return &SearchResult{
+		Documents:    documents,
+		QueryTime:    time.Since(startTime),
+		TotalResults: len(documents),
+	}, nil
+}
+
+// AddDocument 添加文档
+func (ms *MemoryStore) AddDocument(ctx context.Context, doc *VectorDocument) error {
+	if doc == nil {
+		return errors.ErrValidationFailed("document", "cannot be nil")
+	}
+	return ms.AddDocuments(ctx, []*VectorDocument{doc})
+}
+
+// AddDocuments 批量添加文档，任一文档无效时整批不写入
+func (ms *MemoryStore) AddDocuments(ctx context.Context, docs []*VectorDocument) error {
+	if len(docs) == 0 {
+		return errors.ErrValidationFailed("documents", "cannot be empty")
+	}
+
+	for i, doc := range docs {
+		if doc == nil || doc.ID == "" {
+			return errors.ErrValidationFailed("document.id", fmt.Sprintf("document at index %d has empty ID", i))
+		}
+		if len(doc.Embedding) == 0 {
+			return errors.ErrValidationFailed("document.embedding", fmt.Sprintf("document at index %d has empty embedding", i))
+		}
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, doc := range docs {
+		// 与Chroma客户端一致：写入创建时间和内容长度
+		if doc.Metadata == nil {
+			doc.Metadata = make(map[string]interface{})
+		}
+		doc.Metadata["created_at"] = doc.CreatedAt.Unix()
+		doc.Metadata["content_length"] = len(doc.Content)
+
+		stored := copyVectorDocument(doc)
+		stored.Distance = 0
+		ms.documents[doc.ID] = stored
+	}
+
+	return nil
+}
+
+// GetDocument 根据ID获取文档
+func (ms *MemoryStore) GetDocument(ctx context.Context, id string) (*VectorDocument, error) {
+	if id == "" {
+		return nil, errors.ErrValidationFailed("id", "cannot be empty")
+	}
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	stored, exists := ms.documents[id]
+	if !exists {
+		return nil, errors.ErrResourceNotFound("document", id)
+	}
+	return copyVectorDocument(stored), nil
+}
+
+// GetDocuments 根据ID批量获取文档（不存在的ID会被忽略）
+func (ms *MemoryStore) GetDocuments(ctx context.Context, ids []string) ([]*VectorDocument, error) {
+	if len(ids) == 0 {
+		return nil, errors.ErrValidationFailed("ids", "cannot be empty")
+	}
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	documents := make([]*VectorDocument, 0, len(ids))
+	for _, id := range ids {
+		if stored, exists := ms.documents[id]; exists {
+			documents = append(documents, copyVectorDocument(stored))
+		}
+	}
+	return documents, nil
+}
+
+// GetDocumentsByFilter 按元数据过滤条件获取文档（不返回向量）
+func (ms *MemoryStore) GetDocumentsByFilter(ctx context.Context, filter map[string]interface{}, limit int) ([]*VectorDocument, error) {
+	if len(filter) == 0 {
+		return nil, errors.ErrValidationFailed("filter", "cannot be empty")
+	}
+	if err := validateWhere(filter); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	documents := make([]*VectorDocument, 0)
+	for _, id := range ms.sortedIDs() {
+		if len(documents) >= limit {
+			break
+		}
+		stored := ms.documents[id]
+		if !matchesWhereFilter(stored.Metadata, filter) {
+			continue
+		}
+		doc := copyVectorDocument(stored)
+		doc.Embedding = nil
+		documents = append(documents, doc)
+	}
+	return documents, nil
+}
+
+// KeywordSearch 获取内容、标题、标签或关键词包含任一关键词的文档（忽略大小写）
+func (ms *MemoryStore) KeywordSearch(ctx context.Context, query *KeywordQuery) ([]*VectorDocument, error) {
+	if query == nil || len(query.Terms) == 0 {
+		return nil, errors.ErrValidationFailed("terms", "cannot be empty")
+	}
+	if err := validateWhere(query.Filter); err != nil {
+		return nil, err
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	terms := make([]string, 0, len(query.Terms))
+	for _, term := range query.Terms {
+		if term = strings.ToLower(strings.TrimSpace(term)); term != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	documents := make([]*VectorDocument, 0)
+	for _, id := range ms.sortedIDs() {
+		if len(documents) >= limit {
+			break
+		}
+		stored := ms.documents[id]
+		if !matchesWhereFilter(stored.Metadata, query.Filter) {
+			continue
+		}
+		if !containsAnyTerm(keywordSearchText(stored), terms) {
+			continue
+		}
+		doc := copyVectorDocument(stored)
+		if !query.IncludeText {
+			doc.Content = ""
+		}
+		documents = append(documents, doc)
+	}
+	return documents, nil
+}
+
+// containsAnyTerm 检查文本是否包含任一关键词
+func containsAnyTerm(text string, terms []string) bool {
+	for _, term := range terms {
+		if strings.Contains(text, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetVectorDimension 获取已存储向量的维度（为空时返回0）
+func (ms *MemoryStore) GetVectorDimension(ctx context.Context) (int, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	ids := ms.sortedIDs()
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return len(ms.documents[ids[0]].Embedding), nil
+}
+
+// UpdateDocument 更新文档：非空的向量和内容被替换，元数据按键合并
+func (ms *MemoryStore) UpdateDocument(ctx context.Context, doc *VectorDocument) error {
+	if doc == nil {
+		return errors.ErrValidationFailed("document", "cannot be nil")
+	}
+	if doc.ID == "" {
+		return errors.ErrValidationFailed("document.id", "cannot be empty")
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	stored, exists := ms.documents[doc.ID]
+	if !exists {
+		return errors.ErrResourceNotFound("document", doc.ID)
+	}
+
+	if len(doc.Embedding) > 0 {
+		stored.Embedding = append([]float32(nil), doc.Embedding...)
+	}
+	if doc.Content != "" {
+		stored.Content = doc.Content
+	}
+	if doc.Metadata != nil {
+		// 与Chroma客户端一致：写入更新时间和内容长度
+		doc.Metadata["updated_at"] = time.Now().Unix()
+		doc.Metadata["content_length"] = len(doc.Content)
+		for key, value := range doc.Metadata {
+			stored.Metadata[key] = value
+		}
+	}
+
+	return nil
+}
+
+// UpdateDocumentMetadata 仅更新文档元数据（按键合并），不修改向量和内容
+func (ms *MemoryStore) UpdateDocumentMetadata(ctx context.Context, id string, metadata map[string]interface{}) error {
+	if id == "" {
+		return errors.ErrValidationFailed("id", "cannot be empty")
+	}
+	if len(metadata) == 0 {
+		return errors.ErrValidationFailed("metadata", "cannot be empty")
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	stored, exists := ms.documents[id]
+	if !exists {
+		return errors.ErrResourceNotFound("document", id)
+	}
+	for key, value := range metadata {
+		stored.Metadata[key] = value
+	}
+
+	return nil
+}
+
+// DeleteDocument 删除文档（与Chroma一致，删除不存在的文档不报错）
+func (ms *MemoryStore) DeleteDocument(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.ErrValidationFailed("id", "cannot be empty")
+	}
+
+	ms.mu.Lock()
+	delete(ms.documents, id)
+	ms.mu.Unlock()
+
+	return nil
+}
+
+// GetCollectionInfo 获取集合信息
+func (ms *MemoryStore) GetCollectionInfo(ctx context.Context) (map[string]interface{}, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return map[string]interface{}{
+		"collection_name": ms.name,
+		"document_count":  len(ms.documents),
+		"store":           "memory",
+	}, nil
+}
+
+// HealthCheck 健康检查
+func (ms *MemoryStore) HealthCheck(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Close 关闭存储
+func (ms *MemoryStore) Close() error {
+	return nil
+}
+
+// sortedIDs 按ID排序返回文档ID，保证结果顺序稳定（调用方需持有锁）
+func (ms *MemoryStore) sortedIDs() []string {
+	ids := make([]string, 0, len(ms.documents))
+	for id := range ms.documents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// copyVectorDocument 复制文档，避免调用方修改存储内容
+func copyVectorDocument(doc *VectorDocument) *VectorDocument {
+	copied := *doc
+	copied.Embedding = append([]float32(nil), doc.Embedding...)
+	copied.Metadata = make(map[string]interface{}, len(doc.Metadata))
+	for key, value := range doc.Metadata {
+		copied.Metadata[key] = value
+	}
+	if createdAt, ok := toFloat64(copied.Metadata["created_at"]); ok {
+		copied.CreatedAt = time.Unix(int64(createdAt), 0)
+	}
+	return &copied
+}
+
+// vectorDistance 按距离函数计算距离，与Chroma一致：余弦距离为1-余弦相似度，内积距离为1-点积
+func vectorDistance(distance DistanceFunction, a, b []float32) float32 {
+	switch distance {
+	case DistanceCosine, DistanceIP:
+		if len(a) != len(b) {
+			return 2
+		}
+		var dot, normA, normB float64
+		for i := range a {
+			dot += float64(a[i]) * float64(b[i])
+			normA += float64(a[i]) * float64(a[i])
+			normB += float64(b[i]) * float64(b[i])
+		}
+		if distance == DistanceIP {
+			return float32(1 - dot)
+		}
+		if normA == 0 || normB == 0 {
+			return 1
+		}
+		return float32(1 - dot/(math.Sqrt(normA)*math.Sqrt(normB)))
+	default:
+		return squaredL2Distance(a, b)
+	}
+}
+
+// squaredL2Distance 平方L2距离（Chroma默认距离），维度不一致时返回最大距离
+func squaredL2Distance(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 2
+	}
+	var sum float32
+	for i := range a {
+		diff := a[i] - b[i]
+		sum += diff * diff
+	}
+	return sum
+}
+
+// validateWhere 校验where过滤条件只使用支持的运算符（递归检查$and/$or子句）
+func validateWhere(where map[string]interface{}) error {
+	for key, condition := range where {
+		if key == "$and" || key == "$or" {
+			for _, clause := range whereList(condition) {
+				if err := validateWhere(clause); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		cond, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for op := range cond {
+			switch op {
+			case "$in", "$nin", "$eq", "$ne", "$gt", "$gte", "$lt", "$lte":
+			default:
+				return errors.ErrValidationFailed("filter."+key, "unsupported operator: "+op)
+			}
+		}
+	}
+	return nil
+}
+
+// matchesWhereFilter 检查元数据是否满足where过滤条件（支持$and/$or，列表值的$in按任一元素匹配）
+func matchesWhereFilter(metadata map[string]interface{}, where map[string]interface{}) bool {
+	for key, condition := range where {
+		switch key {
+		case "$and":
+			for _, clause := range whereList(condition) {
+				if !matchesWhereFilter(metadata, clause) {
+					return false
+				}
+			}
+			continue
+		case "$or":
+			matched := false
+			for _, clause := range whereList(condition) {
+				if matchesWhereFilter(metadata, clause) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+			continue
+		}
+
+		value, exists := metadata[key]
+		cond, ok := condition.(map[string]interface{})
+		if !ok {
+			if !exists || !metadataValueEqual(value, condition) {
+				return false
+			}
+			continue
+		}
+
+		for op, operand := range cond {
+			switch op {
+			case "$eq":
+				if !exists || !metadataValueEqual(value, operand) {
+					return false
+				}
+			case "$ne":
+				if exists && metadataValueEqual(value, operand) {
+					return false
+				}
+			case "$in":
+				if !exists || !metadataValueIn(value, operand) {
+					return false
+				}
+			case "$nin":
+				if exists && metadataValueIn(value, operand) {
+					return false
+				}
+			default:
+				number, ok := toFloat64(value)
+				bound, boundOK := toFloat64(operand)
+				if !exists || !ok || !boundOK || !compareNumbers(op, number, bound) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// compareNumbers 按比较运算符比较数值
+func compareNumbers(op string, value, bound float64) bool {
+	switch op {
+	case "$gt":
+		return value > bound
+	case "$gte":
+		return value >= bound
+	case "$lt":
+		return value < bound
+	case "$lte":
+		return value <= bound
+	}
+	return false
+}
+
+// metadataValueIn 检查值（或列表值中的任一元素）是否在候选列表中
+func metadataValueIn(value interface{}, candidates interface{}) bool {
+	for _, v := range toInterfaceSlice(value) {
+		for _, c := range toInterfaceSlice(candidates) {
+			if metadataValueEqual(v, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// metadataValueEqual 比较元数据值，数值按大小比较以兼容int和float
+func metadataValueEqual(a, b interface{}) bool {
+	if x, ok := toFloat64(a); ok {
+		y, ok := toFloat64(b)
+		return ok && x == y
+	}
+	return a == b
+}
+
+// toInterfaceSlice 将任意切片转换为[]interface{}，非切片值视为单元素列表
+func toInterfaceSlice(value interface{}) []interface{} {
+	if values, ok := value.([]interface{}); ok {
+		return values
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice {
+		return []interface{}{value}
+	}
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values
+}
+
+// toFloat64 将数值类型转换为float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,69 @@
+package vector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryStore 测试内存向量存储与Chroma一致的过滤和读写语义
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	require.NoError(t, store.AddDocuments(ctx, []*VectorDocument{
+		{ID: "doc-a", Content: "Go", Embedding: []float32{1, 0}, Metadata: map[string]interface{}{"tags": []string{"go", "backend"}, "importance_score": 0.8}},
+		{ID: "doc-b", Content: "Rust", Embedding: []float32{0, 1}, Metadata: map[string]interface{}{"tags": []string{"rust"}, "importance_score": 0.3}},
+	}))
+
+	t.Run("列表值的$in按任一元素匹配，数值比较兼容不同类型", func(t *testing.T) {
+		docs, err := store.GetDocumentsByFilter(ctx, map[string]interface{}{
+			"tags":             map[string]interface{}{"$in": []string{"backend"}},
+			"importance_score": map[string]interface{}{"$gte": 0.5},
+			"content_length":   2,
+		}, 10)
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		assert.Equal(t, "doc-a", docs[0].ID)
+		assert.Empty(t, docs[0].Embedding)
+	})
+
+	t.Run("不支持的运算符返回错误", func(t *testing.T) {
+		_, err := store.Search(ctx, &SearchQuery{
+			QueryVector: []float32{1, 0},
+			Filter:      map[string]interface{}{"tags": map[string]interface{}{"$regex": "go"}},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("返回副本，修改不影响存储", func(t *testing.T) {
+		doc, err := store.GetDocument(ctx, "doc-a")
+		require.NoError(t, err)
+		doc.Metadata["importance_score"] = 0.1
+
+		stored, err := store.GetDocument(ctx, "doc-a")
+		require.NoError(t, err)
+		assert.Equal(t, 0.8, stored.Metadata["importance_score"])
+	})
+
+	t.Run("不存在的文档", func(t *testing.T) {
+		_, err := store.GetDocument(ctx, "missing")
+		assert.Error(t, err)
+		assert.Error(t, store.UpdateDocumentMetadata(ctx, "missing", map[string]interface{}{"tags": "x"}))
+		assert.NoError(t, store.DeleteDocument(ctx, "missing"))
+	})
+
+	t.Run("余弦距离函数", func(t *testing.T) {
+		cosineStore := NewMemoryStoreWithDistance(DistanceCosine)
+		require.NoError(t, cosineStore.AddDocument(ctx, &VectorDocument{ID: "doc-a", Embedding: []float32{3, 4}}))
+
+		result, err := cosineStore.Search(ctx, &SearchQuery{QueryVector: []float32{1, 0}, TopK: 1})
+		require.NoError(t, err)
+		require.Len(t, result.Documents, 1)
+		// 余弦相似度0.6，距离1-0.6；平方L2距离会是20
+		assert.InDelta(t, 0.4, result.Documents[0].Distance, 1e-6)
+		assert.Equal(t, DistanceCosine, cosineStore.DistanceFunction())
+		assert.Empty(t, store.DistanceFunction())
+	})
+}
@@ -0,0 +1,322 @@
+package vector
+
+import (
+	"fmt"
+	"reflect"
+
+	"memoro/internal/errors"
+	"memoro/internal/models"
+)
+
+// CustomMetadataKey 调用方自定义元数据在ProcessedData中的键
+const CustomMetadataKey = "custom_metadata"
+
+// 处理完整性标记字段（索引时写入，用于查找缺少摘要、标签或向量的文档）
+const (
+	MetadataKeyHasSummary = "has_summary"
+	MetadataKeyHasTags    = "has_tags"
+	MetadataKeyIndexed    = "indexed"
+)
+
+// 软删除标记字段（超出配额被淘汰的文档保留在索引中，搜索时跳过）
+const (
+	MetadataKeyDeleted   = "deleted"
+	MetadataKeyDeletedAt = "deleted_at"
+)
+
+// notDeletedCondition 排除软删除文档的过滤条件（没有删除标记的文档同样匹配）
+func notDeletedCondition() map[string]interface{} {
+	return map[string]interface{}{"$ne": true}
+}
+
+// excludeDeleted 返回加入排除软删除文档条件后的过滤条件
+func excludeDeleted(filter map[string]interface{}) map[string]interface{} {
+	combined := make(map[string]interface{}, len(filter)+1)
+	for key, value := range filter {
+		combined[key] = value
+	}
+	combined[MetadataKeyDeleted] = notDeletedCondition()
+	return normalizeWhere(combined)
+}
+
+// isDeleted 检查文档是否因超出配额被软删除
+func isDeleted(doc *VectorDocument) bool {
+	deleted, _ := doc.Metadata[MetadataKeyDeleted].(bool)
+	return deleted
+}
+
+// 归档标记字段（冷存储中的文档带有这些字段，恢复到活跃集合时移除）
+const (
+	MetadataKeyArchived   = "archived"
+	MetadataKeyArchivedAt = "archived_at"
+)
+
+// MetadataKeyPinned 用户置顶标记字段（通过内容补丁设置，不需要重新向量化；与调用方自定义的同名字段冲突时以补丁设置的值为准）
+const MetadataKeyPinned = "pinned"
+
+// MetadataKeyLowQuality 提取质量过低（疑似乱码）的标记字段
+const MetadataKeyLowQuality = "low_quality"
+
+// MetadataKeyEmbeddingProvider 生成向量的服务字段（primary或备用服务名称）
+const MetadataKeyEmbeddingProvider = "embedding_provider"
+
+// MetadataKeyVectorNormalized 向量是否已L2归一化为单位长度（vector_db.normalize_vectors开启时索引的文档为true）
+const MetadataKeyVectorNormalized = "vector_normalized"
+
+// MetadataKeySummaryParagraph 段落摘要字段（搜索结果片段来源为summary且没有一句话摘要时使用）
+const MetadataKeySummaryParagraph = "summary_paragraph"
+
+// MetadataKeyThreadID 会话线程字段（同一线程的消息带有相同的thread_id）
+const MetadataKeyThreadID = "thread_id"
+
+// MetadataKeyThreadTimestamp 线程消息的创建时间（毫秒时间戳，created_at只精确到秒，用于线程内排序）
+const MetadataKeyThreadTimestamp = "thread_timestamp"
+
+// MetadataKeyThreadRollup 线程汇总文档标记（该文档由线程内消息聚合生成）
+const MetadataKeyThreadRollup = "thread_rollup"
+
+// MetadataKeySensitive 敏感内容标记（向量库中不保存原文和摘要，只保留向量和结构化元数据）
+const MetadataKeySensitive = "sensitive"
+
+// MetadataKeyLanguage 内容语言字段（提取时检测的语言代码，无法判断时不写入）
+const MetadataKeyLanguage = "language"
+
+// 实体字段（分类阶段抽取的实体列表，按任一元素匹配过滤）
+const (
+	MetadataKeyEntityPersons       = "entity_persons"
+	MetadataKeyEntityOrganizations = "entity_organizations"
+	MetadataKeyEntityLocations     = "entity_locations"
+	MetadataKeyEntityDates         = "entity_dates"
+)
+
+// entityMetadataKeys 实体字段列表
+var entityMetadataKeys = []string{
+	MetadataKeyEntityPersons,
+	MetadataKeyEntityOrganizations,
+	MetadataKeyEntityLocations,
+	MetadataKeyEntityDates,
+}
+
+// 链接预览字段（链接提取时从Open Graph等meta标签获取，用于渲染富卡片，缺失的字段不写入）
+const (
+	MetadataKeyPreviewImage  = "preview_image"
+	MetadataKeySiteName      = "site_name"
+	MetadataKeyFavicon       = "favicon_url"
+	MetadataKeyAuthor        = "author"
+	MetadataKeyPublishedTime = "published_time"
+)
+
+// LinkPreviewMetadataKeys 链接预览字段列表
+var LinkPreviewMetadataKeys = []string{
+	MetadataKeyPreviewImage,
+	MetadataKeySiteName,
+	MetadataKeyFavicon,
+	MetadataKeyAuthor,
+	MetadataKeyPublishedTime,
+}
+
+// reservedMetadataKeys 系统写入的元数据键，自定义元数据不能覆盖
+var reservedMetadataKeys = map[string]bool{
+	"content_id":        true,
+	"content_type":      true,
+	"user_id":           true,
+	"importance_score":  true,
+	"importance_source": true,
+	"tokens_used":       true,
+	"vector_dimension":  true,
+	"model":             true,
+	"tags":              true,
+	"summary_oneline":   true,
+	"title":             true,
+	"categories":        true,
+	"keywords":          true,
+	"created_at":        true,
+	"updated_at":        true,
+	"content_length":    true,
+
+	MetadataKeyHasSummary: true,
+	MetadataKeyHasTags:    true,
+	MetadataKeyIndexed:    true,
+	MetadataKeyDeleted:    true,
+	MetadataKeyDeletedAt:  true,
+	MetadataKeyArchived:   true,
+	MetadataKeyArchivedAt: true,
+	MetadataKeyLowQuality: true,
+	MetadataKeyLanguage:   true,
+	MetadataKeySensitive:  true,
+
+	MetadataKeyEmbeddingProvider: true,
+	MetadataKeyVectorNormalized:  true,
+	MetadataKeySummaryParagraph:  true,
+
+	MetadataKeyThreadID:        true,
+	MetadataKeyThreadRollup:    true,
+	MetadataKeyThreadTimestamp: true,
+
+	MetadataKeyEntityPersons:       true,
+	MetadataKeyEntityOrganizations: true,
+	MetadataKeyEntityLocations:     true,
+	MetadataKeyEntityDates:         true,
+
+	MetadataKeyPreviewImage:  true,
+	MetadataKeySiteName:      true,
+	MetadataKeyFavicon:       true,
+	MetadataKeyAuthor:        true,
+	MetadataKeyPublishedTime: true,
+}
+
+// ValidateCustomMetadata 验证自定义元数据：键不能为空或与系统字段冲突，值只能是Chroma支持的标量或标量数组
+func ValidateCustomMetadata(metadata map[string]interface{}) error {
+	for key, value := range metadata {
+		if key == "" {
+			return errors.ErrValidationFailed("metadata", "key cannot be empty")
+		}
+		if reservedMetadataKeys[key] {
+			return errors.ErrValidationFailed("metadata."+key, "is a reserved metadata key")
+		}
+		if !isMetadataValue(value) {
+			return errors.ErrValidationFailed("metadata."+key, fmt.Sprintf("unsupported value type %T (must be string, number, bool or an array of them)", value))
+		}
+	}
+	return nil
+}
+
+// ValidateMetadataFilters 验证元数据过滤条件：标量按等值匹配，数组按任一元素匹配
+func ValidateMetadataFilters(filters map[string]interface{}) error {
+	for key, value := range filters {
+		if key == "" {
+			return errors.ErrValidationFailed("metadata_filters", "key cannot be empty")
+		}
+		if !isMetadataValue(value) {
+			return errors.ErrValidationFailed("metadata_filters."+key, fmt.Sprintf("unsupported value type %T (must be string, number, bool or an array of them)", value))
+		}
+	}
+	return nil
+}
+
+// applyMetadataFilters 将自定义元数据过滤条件加入过滤器：数组按任一元素匹配，实体字段的单个值按包含匹配，
+// 其他标量按等值匹配；过滤器中已有的字段（内置过滤条件）不被覆盖
+func applyMetadataFilters(filter map[string]interface{}, filters map[string]interface{}) {
+	for key, value := range filters {
+		if _, exists := filter[key]; exists {
+			continue
+		}
+		if isMetadataArray(value) {
+			filter[key] = map[string]interface{}{
+				"$in": value,
+			}
+		} else if isEntityMetadataKey(key) {
+			// 实体字段存储为列表，单个值按包含匹配
+			filter[key] = map[string]interface{}{
+				"$in": []interface{}{value},
+			}
+		} else {
+			filter[key] = value
+		}
+	}
+}
+
+// matchesMetadataFilters 检查元数据是否满足自定义元数据过滤（用于没有经过向量库过滤的候选文档），条件为空时总是满足
+func matchesMetadataFilters(metadata map[string]interface{}, filters map[string]interface{}) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	where := make(map[string]interface{}, len(filters))
+	applyMetadataFilters(where, filters)
+	return matchesWhereFilter(metadata, where)
+}
+
+// copyMetadata 深拷贝元数据（嵌套的map和切片同样复制），nil时返回nil
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	if metadata == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		copied[key] = copyMetadataValue(value)
+	}
+	return copied
+}
+
+// copyMetadataValue 深拷贝元数据值中的map和常见切片类型，其他值原样返回
+func copyMetadataValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copyMetadata(v)
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyMetadataValue(item)
+		}
+		return copied
+	case []string:
+		return append([]string(nil), v...)
+	case []float64:
+		return append([]float64(nil), v...)
+	case []int:
+		return append([]int(nil), v...)
+	default:
+		return value
+	}
+}
+
+// isMetadataValue 检查值是否为Chroma支持的元数据类型
+func isMetadataValue(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		if v.Len() == 0 {
+			return false
+		}
+		for i := 0; i < v.Len(); i++ {
+			if !isScalarMetadataValue(v.Index(i).Interface()) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return isScalarMetadataValue(value)
+}
+
+// isScalarMetadataValue 检查值是否为字符串、数字或布尔值
+func isScalarMetadataValue(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// isEntityMetadataKey 检查元数据键是否为实体字段
+func isEntityMetadataKey(key string) bool {
+	for _, entityKey := range entityMetadataKeys {
+		if key == entityKey {
+			return true
+		}
+	}
+	return false
+}
+
+// isMetadataArray 检查元数据值是否为数组
+func isMetadataArray(value interface{}) bool {
+	kind := reflect.ValueOf(value).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// customMetadataOf 获取内容项中的自定义元数据
+func customMetadataOf(contentItem *models.ContentItem) map[string]interface{} {
+	custom, _ := contentItem.GetProcessedData()[CustomMetadataKey].(map[string]interface{})
+	return custom
+}
@@ -0,0 +1,41 @@
+package vector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateCustomMetadata 测试自定义元数据校验
+func TestValidateCustomMetadata(t *testing.T) {
+	t.Run("标量和标量数组有效", func(t *testing.T) {
+		assert.NoError(t, ValidateCustomMetadata(map[string]interface{}{
+			"source":     "slack",
+			"project_id": 42,
+			"weight":     0.5,
+			"pinned":     true,
+			"labels":     []string{"a", "b"},
+			"refs":       []interface{}{1, "x"},
+		}))
+		assert.NoError(t, ValidateCustomMetadata(nil))
+	})
+
+	t.Run("不支持的值类型", func(t *testing.T) {
+		for _, value := range []interface{}{
+			nil,
+			map[string]interface{}{"a": 1},
+			[]interface{}{},
+			[]interface{}{map[string]interface{}{"a": 1}},
+			struct{}{},
+		} {
+			err := ValidateCustomMetadata(map[string]interface{}{"field": value})
+			assert.Error(t, err, "value: %#v", value)
+		}
+	})
+
+	t.Run("不能覆盖系统字段", func(t *testing.T) {
+		err := ValidateCustomMetadata(map[string]interface{}{"user_id": "user-2"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "reserved")
+	})
+}
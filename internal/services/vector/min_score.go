@@ -0,0 +1,60 @@
+package vector
+
+import (
+	"memoro/internal/config"
+)
+
+// 推荐结果为空时响应元数据empty_reason的取值
+const (
+	EmptyReasonNoCandidates       = "no_candidates"        // 推荐策略没有找到候选文档（或候选均被排除）
+	EmptyReasonBelowMinScore      = "below_min_score"      // 候选的推荐分数均低于最小推荐分数
+	EmptyReasonBelowMinConfidence = "below_min_confidence" // 候选的置信度均低于最小置信度
+)
+
+// minScoresFrom 从配置读取各推荐类型的最小推荐分数，未配置时不过滤
+func minScoresFrom(cfg *config.Config) map[RecommendationType]float64 {
+	if cfg == nil || cfg.VectorDB.Recommendation == nil || len(cfg.VectorDB.Recommendation.MinScores) == 0 {
+		return nil
+	}
+
+	minScores := make(map[RecommendationType]float64, len(cfg.VectorDB.Recommendation.MinScores))
+	for recType, minScore := range cfg.VectorDB.Recommendation.MinScores {
+		minScores[RecommendationType(recType)] = minScore
+	}
+	return minScores
+}
+
+// minScoreFor 请求实际使用的最小推荐分数，请求未指定时使用该推荐类型的配置值
+func (r *Recommender) minScoreFor(req *RecommendationRequest) float64 {
+	if req.MinScore > 0 {
+		return req.MinScore
+	}
+	return r.minScores[req.Type]
+}
+
+// filterByScore 去掉推荐分数低于阈值的推荐，返回保留的推荐和去掉的数量
+func filterByScore(recommendations []*RecommendationItem, minScore float64) ([]*RecommendationItem, int) {
+	if minScore <= 0 {
+		return recommendations, 0
+	}
+
+	kept := make([]*RecommendationItem, 0, len(recommendations))
+	for _, rec := range recommendations {
+		if rec.RecommendationScore >= minScore {
+			kept = append(kept, rec)
+		}
+	}
+	return kept, len(recommendations) - len(kept)
+}
+
+// emptyReason 推荐结果为空时说明原因：没有候选，或候选均被分数/置信度阈值过滤
+func emptyReason(scoreFiltered, confidenceFiltered int) string {
+	switch {
+	case scoreFiltered > 0:
+		return EmptyReasonBelowMinScore
+	case confidenceFiltered > 0:
+		return EmptyReasonBelowMinConfidence
+	default:
+		return EmptyReasonNoCandidates
+	}
+}
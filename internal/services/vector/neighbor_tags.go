@@ -0,0 +1,143 @@
+package vector
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+)
+
+// defaultNeighborTagsTopK 未指定时参与标签投票的相似文档数量
+const defaultNeighborTagsTopK = 5
+
+// TagSuggestion 相似文档建议的标签
+type TagSuggestion struct {
+	Tag     string  `json:"tag"`     // 标签
+	Score   float64 `json:"score"`   // 按相似度加权的投票分数（0-1，占全部邻居相似度之和的比例）
+	Sources int     `json:"sources"` // 带有该标签的邻居数量
+}
+
+// SuggestNeighborTags 以已索引文档的向量查找同一用户最相似的已打标签文档，按相似度加权统计其标签（不调用向量化服务）
+func (se *SearchEngine) SuggestNeighborTags(ctx context.Context, documentID string, topK int, minSimilarity float32) ([]*TagSuggestion, error) {
+	if documentID == "" {
+		return nil, errors.ErrValidationFailed("document_id", "cannot be empty")
+	}
+	if topK <= 0 {
+		topK = defaultNeighborTagsTopK
+	}
+
+	sourceDoc, err := se.store.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sourceDoc.Embedding) == 0 {
+		return nil, errors.ErrValidationFailed("document", "has no embedding")
+	}
+
+	filter := make(map[string]interface{})
+	if userID, _ := sourceDoc.Metadata["user_id"].(string); userID != "" {
+		filter["user_id"] = userID
+	}
+
+	// 多取一些结果，跳过自身和未打标签的文档
+	searchResult, err := se.store.Search(ctx, &SearchQuery{
+		QueryVector:   sourceDoc.Embedding,
+		TopK:          topK*3 + 1,
+		Filter:        filter,
+		MinSimilarity: minSimilarity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64)
+	sources := make(map[string]int)
+	labels := make(map[string]string) // 小写标签 -> 首次出现的原始写法
+	totalSimilarity := 0.0
+	neighbors := 0
+	distance := storeDistanceFunction(se.store)
+
+	for _, doc := range searchResult.Documents {
+		if neighbors >= topK {
+			break
+		}
+		if doc.ID == documentID {
+			continue
+		}
+
+		tags := metadataStrings(doc.Metadata["tags"])
+		similarity := cosineFromDistance(distance, doc.Distance)
+		if len(tags) == 0 || similarity <= 0 {
+			continue
+		}
+
+		neighbors++
+		totalSimilarity += similarity
+
+		seen := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			key := strings.ToLower(strings.TrimSpace(tag))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, exists := labels[key]; !exists {
+				labels[key] = strings.TrimSpace(tag)
+			}
+			scores[key] += similarity
+			sources[key]++
+		}
+	}
+
+	suggestions := make([]*TagSuggestion, 0, len(scores))
+	for key, score := range scores {
+		suggestions = append(suggestions, &TagSuggestion{
+			Tag:     labels[key],
+			Score:   score / totalSimilarity,
+			Sources: sources[key],
+		})
+	}
+
+	// 按分数降序，分数相同时按标签排序保证稳定
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Tag < suggestions[j].Tag
+	})
+
+	se.logger.Debug("Neighbor tags suggested", logger.Fields{
+		"document_id": documentID,
+		"neighbors":   neighbors,
+		"suggestions": len(suggestions),
+	})
+
+	return suggestions, nil
+}
+
+// DocumentTags 获取文档元数据中的标签
+func DocumentTags(doc *VectorDocument) []string {
+	if doc == nil {
+		return nil
+	}
+	return metadataStrings(doc.Metadata["tags"])
+}
+
+// metadataStrings 将元数据中的列表值转换为字符串切片
+func metadataStrings(value interface{}) []string {
+	switch values := value.(type) {
+	case []string:
+		return values
+	case []interface{}:
+		result := make([]string, 0, len(values))
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package vector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSearchEngine_SuggestNeighborTags 测试从相似的已打标签文档建议标签
+func TestSearchEngine_SuggestNeighborTags(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	fake.put("new-k8s", "Kubernetes滚动更新与回滚", []float32{0.9, 0.1, 0.0}, map[string]interface{}{
+		"user_id": "user-1",
+	})
+	fake.put("helm", "使用Helm部署Kubernetes应用", []float32{0.85, 0.15, 0.0}, map[string]interface{}{
+		"user_id": "user-1",
+		"tags":    []interface{}{"devops", "kubernetes"},
+	})
+	fake.put("ci", "GitLab CI流水线配置", []float32{0.8, 0.2, 0.1}, map[string]interface{}{
+		"user_id": "user-1",
+		"tags":    []interface{}{"DevOps", "ci"},
+	})
+	fake.put("recipe", "红烧肉做法", []float32{0.0, 0.1, 0.9}, map[string]interface{}{
+		"user_id": "user-1",
+		"tags":    []interface{}{"烹饪"},
+	})
+	fake.put("other-user", "Kubernetes网络", []float32{0.9, 0.1, 0.0}, map[string]interface{}{
+		"user_id": "user-2",
+		"tags":    []interface{}{"network"},
+	})
+
+	embedder := new(MockEmbeddingService)
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = newTestChromaClient(t, fake)
+
+	suggestions, err := engine.SuggestNeighborTags(context.Background(), "new-k8s", 2, 0.5)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, suggestions)
+	assert.Equal(t, "devops", suggestions[0].Tag)
+	assert.Equal(t, 2, suggestions[0].Sources)
+	assert.InDelta(t, 1.0, suggestions[0].Score, 0.0001)
+
+	tags := make([]string, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		tags = append(tags, suggestion.Tag)
+		assert.Less(t, suggestion.Score, 1.0001)
+	}
+	assert.ElementsMatch(t, []string{"devops", "kubernetes", "ci"}, tags)
+
+	// 只使用已有向量，不调用向量化服务
+	assert.Empty(t, embedder.Calls)
+
+	t.Run("文档不存在", func(t *testing.T) {
+		_, err := engine.SuggestNeighborTags(context.Background(), "missing", 2, 0)
+		assert.Error(t, err)
+	})
+}
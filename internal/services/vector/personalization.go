@@ -0,0 +1,265 @@
+package vector
+
+import (
+	"context"
+	"sort"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+)
+
+const (
+	defaultPersonalizationMinInteractions = 3
+	defaultPersonalizationHistorySize     = 20
+	maxPreferredTags                      = 5
+)
+
+// PersonalizationFallback 交互历史不足以个性化时的降级方式
+type PersonalizationFallback string
+
+const (
+	PersonalizationFallbackAuto     PersonalizationFallback = "auto"     // 有源文档或查询时使用相关推荐，否则使用热门推荐
+	PersonalizationFallbackRelated  PersonalizationFallback = "related"  // 相关推荐
+	PersonalizationFallbackTrending PersonalizationFallback = "trending" // 热门推荐
+	PersonalizationFallbackNone     PersonalizationFallback = "none"     // 不降级，返回错误
+)
+
+// personalizationSettings 个性化推荐设置
+type personalizationSettings struct {
+	minInteractions int                     // 构建个性化上下文所需的最少交互次数
+	historySize     int                     // 参与构建的最近交互文档数量
+	fallback        PersonalizationFallback // 交互不足时的降级方式
+}
+
+// personalizationSettingsFrom 从配置读取个性化推荐设置，未配置的项使用默认值
+func personalizationSettingsFrom(cfg *config.Config) personalizationSettings {
+	settings := personalizationSettings{
+		minInteractions: defaultPersonalizationMinInteractions,
+		historySize:     defaultPersonalizationHistorySize,
+		fallback:        PersonalizationFallbackAuto,
+	}
+	if cfg == nil || cfg.VectorDB.Recommendation == nil {
+		return settings
+	}
+
+	personalization := cfg.VectorDB.Recommendation.Personalization
+	if personalization.MinInteractions > 0 {
+		settings.minInteractions = personalization.MinInteractions
+	}
+	if personalization.HistorySize > 0 {
+		settings.historySize = personalization.HistorySize
+	}
+	if personalization.Fallback != "" {
+		settings.fallback = PersonalizationFallback(personalization.Fallback)
+	}
+	return settings
+}
+
+// includeSeenFrom 从配置读取是否默认保留用户已交互过的文档
+func includeSeenFrom(cfg *config.Config) bool {
+	if cfg == nil || cfg.VectorDB.Recommendation == nil {
+		return false
+	}
+	return cfg.VectorDB.Recommendation.IncludeSeen
+}
+
+// personalizationOutcome 个性化推荐请求的处理结果，写入响应元数据
+type personalizationOutcome struct {
+	fallbackType RecommendationType // 降级使用的推荐类型，为空表示未降级
+	reason       string             // 降级原因
+}
+
+// metadata 响应元数据中的个性化字段
+func (o *personalizationOutcome) metadata() map[string]interface{} {
+	if o == nil || o.fallbackType == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"requested_type":           string(RecommendationTypePersonalized),
+		"personalization_fallback": string(o.fallbackType),
+		"fallback_reason":          o.reason,
+	}
+}
+
+// BuildPersonalizationContext 根据用户的交互历史构建个性化上下文，交互不足时返回nil
+func (r *Recommender) BuildPersonalizationContext(ctx context.Context, userID string) (*PersonalizationContext, error) {
+	if userID == "" || r.interactions == nil {
+		return nil, nil
+	}
+
+	recent, counts := r.interactions.UserHistory(userID, r.personalization.historySize)
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	if total < r.personalization.minInteractions {
+		return nil, nil
+	}
+
+	documents, err := r.searchEngine.store.GetDocuments(ctx, recent)
+	if err != nil {
+		return nil, err
+	}
+
+	maxCount := 0
+	for _, count := range counts {
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+
+	personalCtx := &PersonalizationContext{
+		UserID:             userID,
+		UserPreferences:    make(map[string]float64),
+		RecentInteractions: make([]string, 0, len(documents)),
+		InteractionHistory: make(map[string]float64, len(documents)),
+	}
+	tagWeights := make(map[string]float64)
+	typeWeights := make(map[string]float64)
+	for _, doc := range documents {
+		if deleted, _ := doc.Metadata[MetadataKeyDeleted].(bool); deleted {
+			continue
+		}
+		weight := float64(counts[doc.ID]) / float64(maxCount)
+		personalCtx.RecentInteractions = append(personalCtx.RecentInteractions, doc.ID)
+		personalCtx.InteractionHistory[doc.ID] = weight
+		personalCtx.UserPreferences[doc.ID] = weight
+
+		for _, tag := range metadataStrings(doc.Metadata["tags"]) {
+			tagWeights[tag] += weight
+		}
+		if contentType, ok := doc.Metadata["content_type"].(string); ok && contentType != "" {
+			typeWeights[contentType] += weight
+		}
+	}
+
+	// 交互的文档都已不存在时无法个性化
+	if len(personalCtx.RecentInteractions) == 0 {
+		return nil, nil
+	}
+
+	personalCtx.PreferredTags = topWeighted(tagWeights, maxPreferredTags)
+	for _, contentType := range topWeighted(typeWeights, 0) {
+		personalCtx.PreferredContentTypes = append(personalCtx.PreferredContentTypes, models.ContentType(contentType))
+	}
+
+	r.logger.Debug("Personalization context built from interaction history", logger.Fields{
+		"user_id":        userID,
+		"interactions":   total,
+		"documents":      len(personalCtx.RecentInteractions),
+		"preferred_tags": personalCtx.PreferredTags,
+	})
+
+	return personalCtx, nil
+}
+
+// resolvePersonalization 个性化请求未提供上下文时从交互历史构建，历史不足时按配置改为降级的推荐类型
+func (r *Recommender) resolvePersonalization(ctx context.Context, req *RecommendationRequest) (*personalizationOutcome, error) {
+	if req.Type != RecommendationTypePersonalized || req.PersonalizationCtx != nil {
+		return nil, nil
+	}
+
+	personalCtx, err := r.BuildPersonalizationContext(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if personalCtx != nil {
+		req.PersonalizationCtx = personalCtx
+		return nil, nil
+	}
+
+	outcome := &personalizationOutcome{reason: "insufficient interaction history for personalization"}
+	if req.UserID == "" {
+		outcome.reason = "user_id is required for personalization"
+	}
+
+	switch r.personalization.fallback {
+	case PersonalizationFallbackNone:
+		return nil, errors.ErrValidationFailed("personalization_context", outcome.reason)
+	case PersonalizationFallbackRelated:
+		outcome.fallbackType = RecommendationTypeRelated
+	case PersonalizationFallbackTrending:
+		outcome.fallbackType = RecommendationTypeTrending
+	default:
+		outcome.fallbackType = RecommendationTypeTrending
+		if req.SourceDocumentID != "" || req.SourceQuery != "" {
+			outcome.fallbackType = RecommendationTypeRelated
+		}
+	}
+
+	r.logger.Info("Personalized recommendations fell back", logger.Fields{
+		"user_id":  req.UserID,
+		"fallback": string(outcome.fallbackType),
+		"reason":   outcome.reason,
+	})
+
+	req.Type = outcome.fallbackType
+	return outcome, nil
+}
+
+// excludeSeenDocuments 将用户交互过的文档（交互记录和个性化上下文中的）加入请求的排除列表，
+// 请求或配置要求保留时跳过，返回新增的排除数量
+func (r *Recommender) excludeSeenDocuments(req *RecommendationRequest) int {
+	includeSeen := r.includeSeen
+	if req.IncludeSeen != nil {
+		includeSeen = *req.IncludeSeen
+	}
+	if includeSeen || req.UserID == "" {
+		return 0
+	}
+
+	seen := make(map[string]bool)
+	if r.interactions != nil {
+		recent, _ := r.interactions.UserHistory(req.UserID, 0)
+		for _, docID := range recent {
+			seen[docID] = true
+		}
+	}
+	if req.PersonalizationCtx != nil {
+		for _, docID := range req.PersonalizationCtx.RecentInteractions {
+			seen[docID] = true
+		}
+		for docID := range req.PersonalizationCtx.InteractionHistory {
+			seen[docID] = true
+		}
+	}
+	for _, docID := range req.ExcludeDocuments {
+		delete(seen, docID)
+	}
+	if len(seen) == 0 {
+		return 0
+	}
+
+	excluded := make([]string, 0, len(seen))
+	for docID := range seen {
+		excluded = append(excluded, docID)
+	}
+	sort.Strings(excluded)
+	req.ExcludeDocuments = append(req.ExcludeDocuments, excluded...)
+
+	r.logger.Debug("Excluding already seen documents from recommendations", logger.Fields{
+		"user_id":  req.UserID,
+		"excluded": len(excluded),
+	})
+	return len(excluded)
+}
+
+// topWeighted 按权重降序返回键（权重相同时按键排序），limit为0时返回全部
+func topWeighted(weights map[string]float64, limit int) []string {
+	keys := make([]string, 0, len(weights))
+	for key := range weights {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if weights[keys[i]] != weights[keys[j]] {
+			return weights[keys[i]] > weights[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
@@ -0,0 +1,204 @@
+package vector
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"memoro/internal/errors"
+)
+
+// 搜索结果可投影的字段（document_id始终返回）
+const (
+	FieldDocumentID      = "document_id"
+	FieldContent         = "content"
+	FieldSimilarity      = "similarity" // 相似度、距离、原始相似度和相似度类型
+	FieldRank            = "rank"
+	FieldRelevanceScore  = "relevance_score" // 综合相关性、LLM相关性、关键词和融合分数
+	FieldTitle           = "title"           // 元数据中的标题
+	FieldSummary         = "summary"         // 元数据中的一句话摘要
+	FieldTags            = "tags"            // 元数据中的标签
+	FieldMetadata        = "metadata"
+	FieldContentSummary  = "content_summary"
+	FieldMatchedKeywords = "matched_keywords"
+	FieldScoreBreakdown  = "score_breakdown"
+	FieldCreatedAt       = "created_at"
+)
+
+// projectionFields 支持的投影字段
+var projectionFields = map[string]bool{
+	FieldDocumentID:      true,
+	FieldContent:         true,
+	FieldSimilarity:      true,
+	FieldRank:            true,
+	FieldRelevanceScore:  true,
+	FieldTitle:           true,
+	FieldSummary:         true,
+	FieldTags:            true,
+	FieldMetadata:        true,
+	FieldContentSummary:  true,
+	FieldMatchedKeywords: true,
+	FieldScoreBreakdown:  true,
+	FieldCreatedAt:       true,
+}
+
+// projectedMetadataKeys 从元数据中取值的投影字段
+var projectedMetadataKeys = map[string]string{
+	FieldTitle:   "title",
+	FieldSummary: "summary_oneline",
+	FieldTags:    "tags",
+}
+
+// projectionJSONKeys 投影字段对应的结果JSON键（title、summary和tags从metadata中返回）
+var projectionJSONKeys = map[string][]string{
+	FieldDocumentID:      {"document_id"},
+	FieldContent:         {"content"},
+	FieldSimilarity:      {"similarity", "distance", "raw_similarity", "normalized_score", "similarity_type"},
+	FieldRank:            {"rank"},
+	FieldRelevanceScore:  {"relevance_score", "llm_relevance_score", "keyword_score", "fusion_score"},
+	FieldTitle:           {"metadata"},
+	FieldSummary:         {"metadata"},
+	FieldTags:            {"metadata"},
+	FieldMetadata:        {"metadata"},
+	FieldContentSummary:  {"content_summary"},
+	FieldMatchedKeywords: {"matched_keywords"},
+	FieldScoreBreakdown:  {"score_breakdown"},
+	FieldCreatedAt:       {"created_at"},
+}
+
+// unprojectedJSONKeys 指定投影时也始终返回的结果JSON键（文档ID和折叠信息）
+var unprojectedJSONKeys = []string{"document_id", "duplicate_of", "thread_messages"}
+
+// projectedJSONKeys 获取投影字段需要序列化的JSON键，未指定投影时返回nil
+func projectedJSONKeys(fields []string) map[string]bool {
+	if len(fields) == 0 {
+		return nil
+	}
+	keys := make(map[string]bool)
+	for _, key := range unprojectedJSONKeys {
+		keys[key] = true
+	}
+	for _, field := range fields {
+		for _, key := range projectionJSONKeys[field] {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// MarshalProjected 序列化v并只保留keys中的顶层JSON键，keys为nil时完整序列化
+func MarshalProjected(v interface{}, keys map[string]bool) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || keys == nil {
+		return data, err
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	for key := range object {
+		if !keys[key] {
+			delete(object, key)
+		}
+	}
+	return json.Marshal(object)
+}
+
+// MarshalJSON 指定投影时只序列化请求的字段，未请求的字段不以零值出现在响应中
+func (r SearchResultItem) MarshalJSON() ([]byte, error) {
+	type plain SearchResultItem
+	return MarshalProjected(plain(r), r.projectedKeys)
+}
+
+// ProjectedKeys 获取结果按投影序列化的JSON键，未指定投影时返回nil
+func (r *SearchResultItem) ProjectedKeys() map[string]bool {
+	return r.projectedKeys
+}
+
+// validateProjectionFields 验证投影字段
+func validateProjectionFields(fields []string) error {
+	for _, field := range fields {
+		if !projectionFields[field] {
+			return errors.ErrValidationFailed("fields", fmt.Sprintf("unknown field: %s", field))
+		}
+	}
+	return nil
+}
+
+// includesField 检查是否需要返回字段（未指定投影时返回全部字段）
+func (o *SearchOptions) includesField(field string) bool {
+	if len(o.Fields) == 0 {
+		return true
+	}
+	for _, f := range o.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsContent 检查是否需要从向量库获取原文：指定投影时只在请求content字段时获取，否则按IncludeContent
+func (o *SearchOptions) wantsContent() bool {
+	if len(o.Fields) == 0 {
+		return o.IncludeContent
+	}
+	return o.includesField(FieldContent)
+}
+
+// projectSearchResult 按投影字段清除未请求的结果字段并记录序列化的JSON键（排序完成后调用，不影响排序）
+func projectSearchResult(result *SearchResultItem, options *SearchOptions) {
+	if len(options.Fields) == 0 {
+		return
+	}
+	result.projectedKeys = projectedJSONKeys(options.Fields)
+
+	if !options.includesField(FieldContent) {
+		result.Content = ""
+	}
+	if !options.includesField(FieldSimilarity) {
+		result.Similarity = 0
+		result.Distance = 0
+		result.RawSimilarity = 0
+		result.NormalizedScore = 0
+		result.SimilarityType = ""
+	}
+	if !options.includesField(FieldRank) {
+		result.Rank = 0
+	}
+	if !options.includesField(FieldRelevanceScore) {
+		result.RelevanceScore = 0
+		result.LLMRelevanceScore = nil
+		result.KeywordScore = 0
+		result.FusionScore = 0
+	}
+	if !options.includesField(FieldContentSummary) {
+		result.ContentSummary = ""
+	}
+	if !options.includesField(FieldMatchedKeywords) {
+		result.MatchedKeywords = nil
+	}
+	if !options.includesField(FieldScoreBreakdown) {
+		result.ScoreBreakdown = nil
+	}
+	if !options.includesField(FieldCreatedAt) {
+		result.CreatedAt = time.Time{}
+	}
+
+	if !options.includesField(FieldMetadata) {
+		var projected map[string]interface{}
+		for field, key := range projectedMetadataKeys {
+			value, exists := result.Metadata[key]
+			if !exists || !options.includesField(field) {
+				continue
+			}
+			if projected == nil {
+				projected = make(map[string]interface{})
+			}
+			projected[key] = value
+		}
+		result.Metadata = projected
+	}
+	result.Embedding = nil
+}
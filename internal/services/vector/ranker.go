@@ -27,6 +27,21 @@ const (
 	RankingStrategyPersonalized RankingStrategy = "personalized" // 个性化排序
 )
 
+// IsValidRankingStrategy 检查排序策略是否有效
+func IsValidRankingStrategy(strategy RankingStrategy) bool {
+	switch strategy {
+	case RankingStrategySimilarity,
+		RankingStrategyRelevance,
+		RankingStrategyTime,
+		RankingStrategyImportance,
+		RankingStrategyHybrid,
+		RankingStrategyPersonalized:
+		return true
+	default:
+		return false
+	}
+}
+
 // RankingOptions 排序选项
 type RankingOptions struct {
 	Strategy           RankingStrategy         `json:"strategy"`                  // 排序策略
@@ -35,6 +50,9 @@ type RankingOptions struct {
 	BoostFactors       *BoostFactors           `json:"boost_factors,omitempty"`   // 增强因子
 	TimeDecay          *TimeDecayConfig        `json:"time_decay,omitempty"`      // 时间衰减配置
 	DiversitySettings  *DiversitySettings      `json:"diversity,omitempty"`       // 多样性设置
+
+	accessImportance   *accessImportance  // 查询时按访问频率和最近访问调整重要性（nil时只使用存储的重要性）
+	contentTypeWeights map[string]float64 // 按内容类型的相关性乘数（为空时不调整）
 }
 
 // RankingWeights 排序权重
@@ -97,14 +115,18 @@ type RankingResult struct {
 
 // ScoreBreakdown 分数分解
 type ScoreBreakdown struct {
-	DocumentID        string  `json:"document_id"`        // 文档ID
-	FinalScore        float64 `json:"final_score"`        // 最终分数
-	SimilarityScore   float64 `json:"similarity_score"`   // 相似度分数
-	KeywordScore      float64 `json:"keyword_score"`      // 关键词分数
-	ImportanceScore   float64 `json:"importance_score"`   // 重要性分数
-	FreshnessScore    float64 `json:"freshness_score"`    // 新鲜度分数
-	PersonalizedScore float64 `json:"personalized_score"` // 个性化分数
-	BoostScore        float64 `json:"boost_score"`        // 增强分数
+	DocumentID          string             `json:"document_id"`                     // 文档ID
+	FinalScore          float64            `json:"final_score"`                     // 最终分数
+	SimilarityScore     float64            `json:"similarity_score"`                // 相似度分数
+	KeywordScore        float64            `json:"keyword_score"`                   // 关键词分数
+	ImportanceScore     float64            `json:"importance_score"`                // 重要性分数（启用访问调整时为混合后的分数）
+	BaseImportanceScore float64            `json:"base_importance_score,omitempty"` // 存储的重要性分数（启用访问调整时）
+	AccessScore         float64            `json:"access_score,omitempty"`          // 访问频率和最近访问信号（启用访问调整时）
+	FreshnessScore      float64            `json:"freshness_score"`                 // 新鲜度分数
+	PersonalizedScore   float64            `json:"personalized_score"`              // 个性化分数
+	BoostScore          float64            `json:"boost_score"`                     // 增强分数
+	TagBoosts           map[string]float64 `json:"tag_boosts,omitempty"`            // 命中的标签增强（已计入增强分数）
+	ContentTypeWeight   float64            `json:"content_type_weight,omitempty"`   // 内容类型相关性乘数（配置了内容类型权重时，已计入最终分数）
 }
 
 // DiversityMetrics 多样性指标
@@ -225,6 +247,9 @@ func (r *Ranker) rankByRelevance(results []*SearchResultItem, options *RankingOp
 
 	// 计算分数分解
 	for i, result := range results {
+		boostScore := r.applyBoostFactors(result, options.BoostFactors)
+		result.RelevanceScore += boostScore
+
 		scoreBreakdown[i] = ScoreBreakdown{
 			DocumentID:      result.DocumentID,
 			FinalScore:      result.RelevanceScore,
@@ -232,6 +257,10 @@ func (r *Ranker) rankByRelevance(results []*SearchResultItem, options *RankingOp
 			KeywordScore:    float64(len(result.MatchedKeywords)),
 			ImportanceScore: r.extractImportanceScore(result.Metadata),
 			FreshnessScore:  r.calculateFreshnessScore(result.CreatedAt, options.TimeDecay),
+			BoostScore:      boostScore,
+			TagBoosts:       r.appliedTagBoosts(result, options.BoostFactors),
+
+			ContentTypeWeight: r.appliedContentTypeWeight(result, options),
 		}
 	}
 
@@ -269,21 +298,19 @@ func (r *Ranker) rankByTime(results []*SearchResultItem, options *RankingOptions
 func (r *Ranker) rankByImportance(results []*SearchResultItem, options *RankingOptions) ([]*SearchResultItem, []ScoreBreakdown, error) {
 	scoreBreakdown := make([]ScoreBreakdown, len(results))
 
+	importanceScores := make(map[*SearchResultItem]float64, len(results))
+
 	// 计算分数分解
 	for i, result := range results {
-		importance := r.extractImportanceScore(result.Metadata)
-		scoreBreakdown[i] = ScoreBreakdown{
-			DocumentID:      result.DocumentID,
-			FinalScore:      importance,
-			ImportanceScore: importance,
-		}
+		breakdown := r.importanceBreakdown(result, options.accessImportance)
+		breakdown.FinalScore = breakdown.ImportanceScore
+		scoreBreakdown[i] = breakdown
+		importanceScores[result] = breakdown.ImportanceScore
 	}
 
 	// 按重要性排序
 	sort.Slice(results, func(i, j int) bool {
-		importanceI := r.extractImportanceScore(results[i].Metadata)
-		importanceJ := r.extractImportanceScore(results[j].Metadata)
-		return importanceI > importanceJ
+		return importanceScores[results[i]] > importanceScores[results[j]]
 	})
 
 	return results, scoreBreakdown, nil
@@ -311,7 +338,8 @@ func (r *Ranker) rankByHybrid(results []*SearchResultItem, options *RankingOptio
 	for i, result := range results {
 		similarityScore := result.Similarity
 		keywordScore := r.calculateKeywordScore(result.MatchedKeywords)
-		importanceScore := r.extractImportanceScore(result.Metadata)
+		importance := r.importanceBreakdown(result, options.accessImportance)
+		importanceScore := importance.ImportanceScore
 		freshnessScore := r.calculateFreshnessScore(result.CreatedAt, options.TimeDecay)
 		personalizedScore := r.calculatePersonalizedScore(result, options.PersonalizationCtx)
 		contentTypeScore := r.calculateContentTypeScore(result.Metadata, options.PersonalizationCtx)
@@ -329,6 +357,11 @@ func (r *Ranker) rankByHybrid(results []*SearchResultItem, options *RankingOptio
 			contentTypeScore*weights.ContentType +
 			tagScore*weights.TagRelevance +
 			boostScore
+		// 内容类型相关性乘数
+		contentTypeWeight := r.appliedContentTypeWeight(result, options)
+		if contentTypeWeight > 0 {
+			finalScore *= contentTypeWeight
+		}
 
 		result.RelevanceScore = finalScore
 
@@ -341,6 +374,11 @@ func (r *Ranker) rankByHybrid(results []*SearchResultItem, options *RankingOptio
 			FreshnessScore:    freshnessScore,
 			PersonalizedScore: personalizedScore,
 			BoostScore:        boostScore,
+			TagBoosts:         r.appliedTagBoosts(result, options.BoostFactors),
+
+			BaseImportanceScore: importance.BaseImportanceScore,
+			AccessScore:         importance.AccessScore,
+			ContentTypeWeight:   contentTypeWeight,
 		}
 	}
 
@@ -407,6 +445,22 @@ func (r *Ranker) extractImportanceScore(metadata map[string]interface{}) float64
 	return 0.5 // 默认中等重要性
 }
 
+// importanceBreakdown 计算结果的重要性分数，启用访问调整时同时记录存储的重要性和访问信号
+func (r *Ranker) importanceBreakdown(result *SearchResultItem, access *accessImportance) ScoreBreakdown {
+	base := r.extractImportanceScore(result.Metadata)
+	if access == nil {
+		return ScoreBreakdown{DocumentID: result.DocumentID, ImportanceScore: base}
+	}
+
+	blended, accessScore := access.blend(result.DocumentID, base)
+	return ScoreBreakdown{
+		DocumentID:          result.DocumentID,
+		ImportanceScore:     blended,
+		BaseImportanceScore: base,
+		AccessScore:         accessScore,
+	}
+}
+
 // calculateFreshnessScore 计算新鲜度分数
 func (r *Ranker) calculateFreshnessScore(createdAt time.Time, timeDecay *TimeDecayConfig) float64 {
 	if timeDecay == nil || !timeDecay.Enabled {
@@ -565,22 +619,45 @@ func (r *Ranker) applyBoostFactors(result *SearchResultItem, boostFactors *Boost
 		}
 	}
 
-	// 标签增强
-	if tags, exists := result.Metadata["tags"]; exists {
-		if tagList, ok := tags.([]interface{}); ok {
-			for _, tag := range tagList {
-				if tagStr, ok := tag.(string); ok {
-					if tagBoost, exists := boostFactors.TagBoosts[tagStr]; exists {
-						boost += tagBoost
-					}
-				}
-			}
-		}
+	// 标签增强（负值降权）
+	for _, tagBoost := range r.appliedTagBoosts(result, boostFactors) {
+		boost += tagBoost
 	}
 
 	return boost
 }
 
+// appliedContentTypeWeight 获取结果的内容类型相关性乘数，未配置内容类型权重时返回0（不调整，也不出现在分数分解中）
+func (r *Ranker) appliedContentTypeWeight(result *SearchResultItem, options *RankingOptions) float64 {
+	if len(options.contentTypeWeights) == 0 {
+		return 0
+	}
+	return contentTypeWeight(options.contentTypeWeights, result.Metadata)
+}
+
+// appliedTagBoosts 获取结果标签命中的标签增强（标签忽略大小写，同一标签只计一次）
+func (r *Ranker) appliedTagBoosts(result *SearchResultItem, boostFactors *BoostFactors) map[string]float64 {
+	if boostFactors == nil || len(boostFactors.TagBoosts) == 0 {
+		return nil
+	}
+
+	var applied map[string]float64
+	for _, tag := range metadataStrings(result.Metadata["tags"]) {
+		tagBoost, exists := boostFactors.TagBoosts[tag]
+		if !exists {
+			tagBoost, exists = boostFactors.TagBoosts[strings.ToLower(tag)]
+		}
+		if !exists || tagBoost == 0 {
+			continue
+		}
+		if applied == nil {
+			applied = make(map[string]float64)
+		}
+		applied[strings.ToLower(tag)] = tagBoost
+	}
+	return applied
+}
+
 // applyDiversityFiltering 应用多样性过滤
 func (r *Ranker) applyDiversityFiltering(results []*SearchResultItem, settings *DiversitySettings) []*SearchResultItem {
 	if !settings.Enabled || len(results) <= 1 {
@@ -658,6 +735,74 @@ func (r *Ranker) applyDiversityFiltering(results []*SearchResultItem, settings *
 	return diverseResults
 }
 
+// guaranteeContentTypes 保证前top_k个结果中每种内容类型至少有MinPerContentType个（该类型有足够匹配时），
+// 按排名选出各类型的保底结果后用其余最靠前的结果补足top_k，入选结果保持原有相对顺序。
+// 未指定内容类型时对结果中出现的所有类型生效；低于最小相似度的结果不参与保底。返回调整后的结果和被提前的结果数
+func guaranteeContentTypes(results []*SearchResultItem, options *SearchOptions) ([]*SearchResultItem, int) {
+	topK := options.TopK
+	if options.MinPerContentType <= 0 || topK <= 0 || len(results) <= topK {
+		return results, 0
+	}
+
+	guaranteed := make(map[string]bool)
+	for _, contentType := range options.ContentTypes {
+		guaranteed[string(contentType)] = true
+	}
+	resultContentType := func(result *SearchResultItem) string {
+		contentType, _ := result.Metadata["content_type"].(string)
+		return contentType
+	}
+
+	// 按排名为各类型预留保底名额
+	selected := make([]bool, len(results))
+	typeCounts := make(map[string]int)
+	reserved := 0
+	for i, result := range results {
+		if reserved >= topK {
+			break
+		}
+		contentType := resultContentType(result)
+		if contentType == "" || (len(guaranteed) > 0 && !guaranteed[contentType]) {
+			continue
+		}
+		if result.Similarity < float64(options.MinSimilarity) || typeCounts[contentType] >= options.MinPerContentType {
+			continue
+		}
+		selected[i] = true
+		typeCounts[contentType]++
+		reserved++
+	}
+
+	// 其余名额按排名补足
+	for i := range results {
+		if reserved >= topK {
+			break
+		}
+		if !selected[i] {
+			selected[i] = true
+			reserved++
+		}
+	}
+
+	reordered := make([]*SearchResultItem, 0, len(results))
+	promoted := 0
+	for i, result := range results {
+		if selected[i] {
+			reordered = append(reordered, result)
+			if i >= topK {
+				promoted++
+			}
+		}
+	}
+	for i, result := range results {
+		if !selected[i] {
+			reordered = append(reordered, result)
+		}
+	}
+
+	return reordered, promoted
+}
+
 // calculateDiversityMetrics 计算多样性指标
 func (r *Ranker) calculateDiversityMetrics(results []*SearchResultItem) *DiversityMetrics {
 	if len(results) == 0 {
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"memoro/internal/config"
 	"memoro/internal/errors"
 	"memoro/internal/logger"
 	"memoro/internal/models"
@@ -18,7 +19,17 @@ type Recommender struct {
 	searchEngine   *SearchEngine
 	similarityCalc *SimilarityCalculator
 	ranker         *Ranker
+	interactions   *InteractionStore
+	trendingJob    *TrendingJob
 	logger         *logger.Logger
+
+	similarityFactors map[RecommendationType]float64 // 各推荐类型的最小相似度调整系数
+	hybrid            hybridSettings                 // 混合推荐设置
+	hybridStrategies  []*hybridStrategy              // 混合推荐的子策略，为空时使用默认策略
+	personalization   personalizationSettings        // 个性化推荐设置
+	minConfidence     float64                        // 默认的最小置信度（0表示不过滤）
+	includeSeen       bool                           // 默认是否保留用户已交互过的文档
+	minScores         map[RecommendationType]float64 // 各推荐类型的最小推荐分数（未配置的类型不过滤）
 }
 
 // RecommendationType 推荐类型
@@ -31,6 +42,13 @@ const (
 	RecommendationTypeTrending      RecommendationType = "trending"      // 热门推荐
 	RecommendationTypeCollaborative RecommendationType = "collaborative" // 协同过滤推荐
 	RecommendationTypeHybrid        RecommendationType = "hybrid"        // 混合推荐
+	RecommendationTypeTagBased      RecommendationType = "tag_based"     // 基于标签重叠的快速推荐（不生成向量）
+)
+
+// 标签推荐中标签与关键词重叠度的权重
+const (
+	tagBasedTagWeight     = 0.6
+	tagBasedKeywordWeight = 0.4
 )
 
 // RecommendationRequest 推荐请求
@@ -47,6 +65,16 @@ type RecommendationRequest struct {
 	DiversityEnabled    bool                    `json:"diversity_enabled"`            // 启用多样性
 	PersonalizationCtx  *PersonalizationContext `json:"personalization,omitempty"`    // 个性化上下文
 	IncludeExplanations bool                    `json:"include_explanations"`         // 包含推荐解释
+
+	TrendingWindow string `json:"trending_window,omitempty"` // 热门推荐使用的命名时间窗口，默认default
+
+	MinConfidence float64 `json:"min_confidence,omitempty"` // 最小归一化置信度（0-1），为空时使用配置默认值
+
+	IncludeSeen *bool `json:"include_seen,omitempty"` // 是否保留用户已交互过的文档（重温场景），为空时使用配置默认值
+
+	MinScore float64 `json:"min_score,omitempty"` // 最小推荐分数，低于该分数的推荐被丢弃，为空时使用该推荐类型的配置值
+
+	MetadataFilters map[string]interface{} `json:"metadata_filters,omitempty"` // 自定义元数据过滤（与搜索相同：标量等值匹配，数组匹配任一元素）
 }
 
 // RecommendationResponse 推荐响应
@@ -93,6 +121,39 @@ type TrendingAnalysis struct {
 	DocumentScores   map[string]float64 `json:"document_scores"`   // 文档热门分数
 	TimeWindow       time.Duration      `json:"time_window"`       // 时间窗口
 	InteractionCount map[string]int     `json:"interaction_count"` // 交互次数
+
+	InteractionVelocity map[string]float64 `json:"interaction_velocity,omitempty"` // 交互速度（每小时交互次数）
+}
+
+// defaultSimilarityFactors 各推荐类型默认的最小相似度调整系数
+var defaultSimilarityFactors = map[RecommendationType]float64{
+	RecommendationTypeSimilar:      1.0,
+	RecommendationTypeRelated:      0.7, // 降低阈值以获取更多相关内容
+	RecommendationTypePersonalized: 0.8,
+}
+
+// similarityFactorsFrom 从配置读取最小相似度调整系数，未配置的类型使用默认值
+func similarityFactorsFrom(cfg *config.Config) map[RecommendationType]float64 {
+	factors := make(map[RecommendationType]float64, len(defaultSimilarityFactors))
+	for recType, factor := range defaultSimilarityFactors {
+		factors[recType] = factor
+	}
+
+	if cfg == nil || cfg.VectorDB.Recommendation == nil {
+		return factors
+	}
+
+	configured := cfg.VectorDB.Recommendation.SimilarityFactors
+	if configured.Similar > 0 {
+		factors[RecommendationTypeSimilar] = configured.Similar
+	}
+	if configured.Related > 0 {
+		factors[RecommendationTypeRelated] = configured.Related
+	}
+	if configured.Personalized > 0 {
+		factors[RecommendationTypePersonalized] = configured.Personalized
+	}
+	return factors
 }
 
 // NewRecommender 创建推荐系统
@@ -102,6 +163,11 @@ func NewRecommender() (*Recommender, error) {
 		return nil, err
 	}
 
+	return newRecommender(searchEngine), nil
+}
+
+// newRecommender 创建使用指定搜索引擎的推荐系统，与搜索引擎共享交互记录
+func newRecommender(searchEngine *SearchEngine) *Recommender {
 	similarityCalc := NewSimilarityCalculator()
 	ranker := NewRanker()
 
@@ -109,12 +175,30 @@ func NewRecommender() (*Recommender, error) {
 		searchEngine:   searchEngine,
 		similarityCalc: similarityCalc,
 		ranker:         ranker,
+		interactions:   searchEngine.interactions,
 		logger:         logger.NewLogger("recommender"),
+
+		similarityFactors: similarityFactorsFrom(config.Get()),
+		hybrid:            hybridSettingsFrom(config.Get()),
+		personalization:   personalizationSettingsFrom(config.Get()),
+		minConfidence:     minConfidenceFrom(config.Get()),
+		includeSeen:       includeSeenFrom(config.Get()),
+		minScores:         minScoresFrom(config.Get()),
 	}
 
+	// 搜索排序按访问调整重要性时使用推荐系统记录的交互
+	if recommender.interactions == nil {
+		recommender.interactions = NewInteractionStore()
+		searchEngine.SetInteractionStore(recommender.interactions)
+	}
+
+	// 启动热门分数后台计算任务
+	recommender.trendingJob = NewTrendingJob(recommender.scanTrendingDocuments, recommender.interactions, trendingJobConfigFrom(config.Get()))
+	recommender.trendingJob.Start()
+
 	recommender.logger.Info("Recommender system initialized")
 
-	return recommender, nil
+	return recommender
 }
 
 // GetRecommendations 获取推荐
@@ -122,6 +206,15 @@ func (r *Recommender) GetRecommendations(ctx context.Context, req *Recommendatio
 	if req == nil {
 		return nil, errors.ErrValidationFailed("recommendation_request", "cannot be nil")
 	}
+	if req.MinConfidence < 0 || req.MinConfidence > 1 {
+		return nil, errors.ErrValidationFailed("min_confidence", "must be between 0 and 1")
+	}
+	if req.MinScore < 0 {
+		return nil, errors.ErrValidationFailed("min_score", "must not be negative")
+	}
+	if err := ValidateMetadataFilters(req.MetadataFilters); err != nil {
+		return nil, err
+	}
 
 	startTime := time.Now()
 
@@ -132,8 +225,25 @@ func (r *Recommender) GetRecommendations(ctx context.Context, req *Recommendatio
 		"max_recommendations": req.MaxRecommendations,
 	})
 
+	// 个性化请求未提供上下文时从交互历史构建，历史不足时降级
+	outcome, err := r.resolvePersonalization(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 默认排除用户已交互过的文档
+	excludedSeen := r.excludeSeenDocuments(req)
+
 	// 尝试从缓存获取推荐结果
 	if cachedRecommendations, found := r.searchEngine.cacheManager.GetRecommendation(req); found {
+		// 缓存之后新产生的交互同样需要排除
+		if excludedSeen > 0 {
+			cachedRecommendations = r.applyFiltering(cachedRecommendations, req)
+			for i, rec := range cachedRecommendations {
+				rec.Rank = i + 1
+			}
+		}
+
 		r.logger.Debug("Recommendation cache hit", logger.Fields{
 			"type":            string(req.Type),
 			"user_id":         req.UserID,
@@ -145,6 +255,7 @@ func (r *Recommender) GetRecommendations(ctx context.Context, req *Recommendatio
 			TotalFound:         len(cachedRecommendations),
 			ProcessTime:        time.Since(startTime),
 			RecommendationType: req.Type,
+			Metadata:           outcome.metadata(),
 		}, nil
 	}
 
@@ -158,7 +269,7 @@ func (r *Recommender) GetRecommendations(ctx context.Context, req *Recommendatio
 
 	// 根据推荐类型执行相应的推荐算法
 	var recommendations []*RecommendationItem
-	var err error
+	var hybridReport *HybridReport
 
 	switch req.Type {
 	case RecommendationTypeSimilar:
@@ -172,7 +283,9 @@ func (r *Recommender) GetRecommendations(ctx context.Context, req *Recommendatio
 	case RecommendationTypeCollaborative:
 		recommendations, err = r.getCollaborativeRecommendations(ctx, req)
 	case RecommendationTypeHybrid:
-		recommendations, err = r.getHybridRecommendations(ctx, req)
+		recommendations, hybridReport, err = r.getHybridRecommendations(ctx, req)
+	case RecommendationTypeTagBased:
+		recommendations, err = r.getTagBasedRecommendations(ctx, req)
 	default:
 		return nil, errors.ErrValidationFailed("recommendation_type", "unsupported type")
 	}
@@ -184,6 +297,15 @@ func (r *Recommender) GetRecommendations(ctx context.Context, req *Recommendatio
 	// 应用过滤和排除
 	recommendations = r.applyFiltering(recommendations, req)
 
+	// 去掉推荐分数过低的弱匹配，没有合格推荐时返回空列表而不是用低分结果填充
+	minScore := r.minScoreFor(req)
+	recommendations, scoreFiltered := filterByScore(recommendations, minScore)
+
+	// 归一化置信度并去掉低置信度的推荐
+	finalizeConfidence(recommendations)
+	minConfidence := r.minConfidenceFor(req)
+	recommendations, confidenceFiltered := filterByConfidence(recommendations, minConfidence)
+
 	// 应用多样性处理
 	if req.DiversityEnabled {
 		recommendations = r.applyDiversity(recommendations, req)
@@ -199,8 +321,10 @@ func (r *Recommender) GetRecommendations(ctx context.Context, req *Recommendatio
 		rec.Rank = i + 1
 	}
 
-	// 缓存推荐结果
-	r.searchEngine.cacheManager.SetRecommendation(req, recommendations)
+	// 缓存推荐结果（空结果不缓存，新内容索引后即可产生推荐，响应也保留为空的原因）
+	if len(recommendations) > 0 {
+		r.searchEngine.cacheManager.SetRecommendation(req, recommendations)
+	}
 
 	processTime := time.Since(startTime)
 
@@ -210,11 +334,39 @@ func (r *Recommender) GetRecommendations(ctx context.Context, req *Recommendatio
 		ProcessTime:        processTime,
 		RecommendationType: req.Type,
 		Metadata: map[string]interface{}{
-			"processing_time_ms": processTime.Milliseconds(),
-			"diversity_enabled":  req.DiversityEnabled,
-			"personalized":       req.PersonalizationCtx != nil,
+			"processing_time_ms":       processTime.Milliseconds(),
+			"diversity_enabled":        req.DiversityEnabled,
+			"personalized":             req.PersonalizationCtx != nil,
+			"effective_min_similarity": r.effectiveMinSimilarity(req.Type, req.MinSimilarity),
 		},
 	}
+	if minConfidence > 0 {
+		response.Metadata["min_confidence"] = minConfidence
+		response.Metadata["confidence_filtered"] = confidenceFiltered
+	}
+	if minScore > 0 {
+		response.Metadata["min_score"] = minScore
+		response.Metadata["score_filtered"] = scoreFiltered
+	}
+	if len(recommendations) == 0 {
+		response.Metadata["empty_reason"] = emptyReason(scoreFiltered, confidenceFiltered)
+	}
+	if req.Type == RecommendationTypeTrending {
+		window := req.TrendingWindow
+		if window == "" {
+			window = DefaultTrendingWindow
+		}
+		response.Metadata["trending_window"] = window
+	}
+	if excludedSeen > 0 {
+		response.Metadata["excluded_seen"] = excludedSeen
+	}
+	if hybridReport != nil {
+		response.Metadata["hybrid"] = hybridReport
+	}
+	for key, value := range outcome.metadata() {
+		response.Metadata[key] = value
+	}
 
 	r.logger.Info("Recommendations generated and cached", logger.Fields{
 		"type":         string(req.Type),
@@ -225,6 +377,17 @@ func (r *Recommender) GetRecommendations(ctx context.Context, req *Recommendatio
 	return response, nil
 }
 
+// effectiveMinSimilarity 计算推荐类型实际使用的最小相似度阈值
+func (r *Recommender) effectiveMinSimilarity(recType RecommendationType, minSimilarity float32) float32 {
+	factor, exists := r.similarityFactors[recType]
+	if !exists {
+		if factor, exists = defaultSimilarityFactors[recType]; !exists {
+			factor = 1.0
+		}
+	}
+	return minSimilarity * float32(factor)
+}
+
 // getSimilarRecommendations 获取相似内容推荐
 func (r *Recommender) getSimilarRecommendations(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error) {
 	if req.SourceDocumentID == "" {
@@ -236,21 +399,21 @@ func (r *Recommender) getSimilarRecommendations(ctx context.Context, req *Recomm
 	})
 
 	// 获取源文档
-	sourceDoc, err := r.searchEngine.chromaClient.GetDocument(ctx, req.SourceDocumentID)
+	sourceDoc, err := r.searchEngine.store.GetDocument(ctx, req.SourceDocumentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get source document: %w", err)
 	}
 
-	// 使用源文档的向量进行相似度搜索
+	// 使用源文档的向量进行相似度搜索，只比较同一服务生成的向量
 	searchQuery := &SearchQuery{
 		QueryVector:   sourceDoc.Embedding,
 		TopK:          req.MaxRecommendations * 2, // 获取更多结果用于过滤
 		IncludeText:   true,
-		MinSimilarity: req.MinSimilarity,
-		Filter:        r.buildSearchFilter(req),
+		MinSimilarity: r.effectiveMinSimilarity(RecommendationTypeSimilar, req.MinSimilarity),
+		Filter:        r.searchEngine.restrictToProvider(r.buildSearchFilter(req), documentEmbeddingProvider(sourceDoc)),
 	}
 
-	searchResult, err := r.searchEngine.chromaClient.Search(ctx, searchQuery)
+	searchResult, err := r.searchEngine.store.Search(ctx, searchQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -277,7 +440,7 @@ func (r *Recommender) getSimilarRecommendations(ctx context.Context, req *Recomm
 			DocumentID:          doc.ID,
 			Content:             doc.Content,
 			Similarity:          similarity,
-			Confidence:          similarity, // 对于相似推荐，置信度等于相似度
+			Confidence:          cosineConfidence(similarity), // 对于相似推荐，置信度为截断到0-1的余弦值
 			Metadata:            doc.Metadata,
 			RecommendationScore: similarity,
 			RelatedKeywords:     r.extractRelatedKeywords(sourceDoc, doc),
@@ -310,24 +473,27 @@ func (r *Recommender) getSimilarRecommendations(ctx context.Context, req *Recomm
 // getRelatedRecommendations 获取相关内容推荐
 func (r *Recommender) getRelatedRecommendations(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error) {
 	var queryVector []float32
+	var provider string // 生成查询向量的服务，只与同一服务生成的文档向量比较
 	var sourceKeywords []string
 
 	// 根据输入生成查询向量
 	if req.SourceDocumentID != "" {
 		// 基于源文档
-		sourceDoc, err := r.searchEngine.chromaClient.GetDocument(ctx, req.SourceDocumentID)
+		sourceDoc, err := r.searchEngine.store.GetDocument(ctx, req.SourceDocumentID)
 		if err != nil {
 			return nil, err
 		}
 		queryVector = sourceDoc.Embedding
+		provider = documentEmbeddingProvider(sourceDoc)
 		sourceKeywords = r.extractKeywordsFromMetadata(sourceDoc.Metadata)
 	} else if req.SourceQuery != "" {
 		// 基于查询文本
-		vector, err := r.searchEngine.generateQueryVector(ctx, req.SourceQuery, &SearchOptions{})
+		vector, vectorProvider, err := r.searchEngine.generateQueryVector(ctx, req.SourceQuery, &SearchOptions{})
 		if err != nil {
 			return nil, err
 		}
 		queryVector = vector
+		provider = vectorProvider
 		sourceKeywords = strings.Fields(strings.ToLower(req.SourceQuery))
 	} else {
 		return nil, errors.ErrValidationFailed("source", "either source_document_id or source_query is required")
@@ -338,11 +504,11 @@ func (r *Recommender) getRelatedRecommendations(ctx context.Context, req *Recomm
 		QueryVector:   queryVector,
 		TopK:          req.MaxRecommendations * 3, // 获取更多结果
 		IncludeText:   true,
-		MinSimilarity: req.MinSimilarity * 0.7, // 降低阈值以获取更多相关内容
-		Filter:        r.buildSearchFilter(req),
+		MinSimilarity: r.effectiveMinSimilarity(RecommendationTypeRelated, req.MinSimilarity),
+		Filter:        r.searchEngine.restrictToProvider(r.buildSearchFilter(req), provider),
 	}
 
-	searchResult, err := r.searchEngine.chromaClient.Search(ctx, searchQuery)
+	searchResult, err := r.searchEngine.store.Search(ctx, searchQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -358,14 +524,15 @@ func (r *Recommender) getRelatedRecommendations(ctx context.Context, req *Recomm
 		vectorSimilarity, _ := r.similarityCalc.CalculateCosineSimilarity(queryVector, doc.Embedding)
 		keywordSimilarity := r.calculateKeywordSimilarity(sourceKeywords, doc)
 
-		// 综合相关性分数
+		// 综合相关性分数（置信度使用截断后的向量相似度，与其他策略可比较）
 		relatednessScore := vectorSimilarity*0.7 + keywordSimilarity*0.3
+		confidence := cosineConfidence(vectorSimilarity)*0.7 + keywordSimilarity*0.3
 
 		recItem := &RecommendationItem{
 			DocumentID:          doc.ID,
 			Content:             doc.Content,
 			Similarity:          vectorSimilarity,
-			Confidence:          relatednessScore,
+			Confidence:          confidence,
 			Metadata:            doc.Metadata,
 			RecommendationScore: relatednessScore,
 			RelatedKeywords:     r.extractSharedKeywords(sourceKeywords, doc),
@@ -397,6 +564,115 @@ func (r *Recommender) getRelatedRecommendations(ctx context.Context, req *Recomm
 	return recommendations, nil
 }
 
+// getTagBasedRecommendations 获取基于标签重叠的推荐：按元数据过滤出共享标签或关键词的文档，不调用向量化服务
+func (r *Recommender) getTagBasedRecommendations(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error) {
+	if req.SourceDocumentID == "" {
+		return nil, errors.ErrValidationFailed("source_document_id", "required for tag based recommendations")
+	}
+
+	sourceDoc, err := r.searchEngine.store.GetDocument(ctx, req.SourceDocumentID)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceTags := r.extractTagsFromMetadata(sourceDoc.Metadata)
+	sourceKeywords := r.extractKeywordsFromMetadata(sourceDoc.Metadata)
+	if len(sourceTags) == 0 && len(sourceKeywords) == 0 {
+		return []*RecommendationItem{}, nil
+	}
+
+	r.logger.Debug("Getting tag based recommendations", logger.Fields{
+		"source_document_id": req.SourceDocumentID,
+		"source_tags":        len(sourceTags),
+		"source_keywords":    len(sourceKeywords),
+	})
+
+	// 有标签时按标签过滤，否则按关键词过滤；请求的元数据过滤已限定同一字段时两个条件同时生效
+	filter := r.buildSearchFilter(req)
+	termKey, terms := "tags", sourceTags
+	if len(sourceTags) == 0 {
+		termKey, terms = "keywords", sourceKeywords
+	}
+	termCondition := map[string]interface{}{"$in": terms}
+	if existing, exists := filter[termKey]; exists {
+		delete(filter, termKey)
+		filter["$and"] = []interface{}{
+			map[string]interface{}{termKey: existing},
+			map[string]interface{}{termKey: termCondition},
+		}
+	} else {
+		filter[termKey] = termCondition
+	}
+
+	candidates, err := r.searchEngine.store.GetDocumentsByFilter(ctx, filter, req.MaxRecommendations*3)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := make([]*RecommendationItem, 0)
+	for _, doc := range candidates {
+		// 排除源文档
+		if doc.ID == req.SourceDocumentID {
+			continue
+		}
+
+		tagSimilarity := termOverlap(sourceTags, r.extractTagsFromMetadata(doc.Metadata))
+		keywordSimilarity := r.calculateKeywordSimilarity(sourceKeywords, doc)
+
+		// 源文档缺少标签或关键词时只使用另一项
+		var overlapScore float64
+		switch {
+		case len(sourceTags) == 0:
+			overlapScore = keywordSimilarity
+		case len(sourceKeywords) == 0:
+			overlapScore = tagSimilarity
+		default:
+			overlapScore = tagSimilarity*tagBasedTagWeight + keywordSimilarity*tagBasedKeywordWeight
+		}
+		if overlapScore <= 0 {
+			continue
+		}
+
+		matchedTerms := append(sharedTerms(sourceTags, r.extractTagsFromMetadata(doc.Metadata)), r.extractSharedKeywords(sourceKeywords, doc)...)
+
+		recItem := &RecommendationItem{
+			DocumentID:          doc.ID,
+			Content:             doc.Content,
+			Similarity:          overlapScore,
+			Confidence:          overlapScore,
+			Metadata:            doc.Metadata,
+			RecommendationScore: overlapScore,
+			RelatedKeywords:     matchedTerms,
+			CreatedAt:           doc.CreatedAt,
+		}
+
+		// 添加推荐解释
+		if req.IncludeExplanations {
+			recItem.Explanation = &RecommendationExplanation{
+				Reason:          "Related content sharing tags and keywords",
+				SimilarityScore: overlapScore,
+				FactorBreakdown: map[string]float64{
+					"tag_similarity":     tagSimilarity,
+					"keyword_similarity": keywordSimilarity,
+				},
+				MatchedFeatures: matchedTerms,
+			}
+		}
+
+		recommendations = append(recommendations, recItem)
+	}
+
+	// 按重叠度排序，相同时较新的优先
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].RecommendationScore != recommendations[j].RecommendationScore {
+			return recommendations[i].RecommendationScore > recommendations[j].RecommendationScore
+		}
+		return recommendations[i].CreatedAt.After(recommendations[j].CreatedAt)
+	})
+
+	return recommendations, nil
+}
+
 // getPersonalizedRecommendations 获取个性化推荐
 func (r *Recommender) getPersonalizedRecommendations(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error) {
 	if req.PersonalizationCtx == nil {
@@ -419,14 +695,14 @@ func (r *Recommender) getPersonalizedRecommendations(ctx context.Context, req *R
 		}
 	} else if req.SourceQuery != "" {
 		// 基于查询文本
-		queryVector, err = r.searchEngine.generateQueryVector(ctx, req.SourceQuery, &SearchOptions{})
+		queryVector, _, err = r.searchEngine.generateQueryVector(ctx, req.SourceQuery, &SearchOptions{})
 		if err != nil {
 			return nil, err
 		}
 	} else {
 		// 使用用户偏好生成通用查询
 		queryText := r.buildPreferenceQuery(req.PersonalizationCtx)
-		queryVector, err = r.searchEngine.generateQueryVector(ctx, queryText, &SearchOptions{})
+		queryVector, _, err = r.searchEngine.generateQueryVector(ctx, queryText, &SearchOptions{})
 		if err != nil {
 			return nil, err
 		}
@@ -437,15 +713,22 @@ func (r *Recommender) getPersonalizedRecommendations(ctx context.Context, req *R
 	// The manually generated queryVector from above is kept for potential future use in advanced personalization
 	_ = queryVector // Acknowledge that we're not using it in this simplified version
 
+	queryText := req.SourceQuery
+	if queryText == "" {
+		queryText = r.buildPreferenceQuery(req.PersonalizationCtx)
+	}
+
 	searchOptions := &SearchOptions{
+		Query:           queryText,
 		TopK:            req.MaxRecommendations * 2,
-		MinSimilarity:   req.MinSimilarity * 0.8,
+		MinSimilarity:   r.effectiveMinSimilarity(RecommendationTypePersonalized, req.MinSimilarity),
 		ContentTypes:    req.ContentTypes,
 		UserID:          req.UserID,
 		IncludeContent:  true,
 		EnableReranking: true,
 		SimilarityType:  SimilarityTypeCosine,
 		MaxResults:      req.MaxRecommendations * 3,
+		MetadataFilters: req.MetadataFilters,
 	}
 
 	searchResponse, err := r.searchEngine.Search(ctx, searchOptions)
@@ -485,47 +768,58 @@ func (r *Recommender) getPersonalizedRecommendations(ctx context.Context, req *R
 	return recommendations, nil
 }
 
-// getTrendingRecommendations 获取热门推荐
+// getTrendingRecommendations 获取热门推荐（读取后台任务预计算的热门分数）
 func (r *Recommender) getTrendingRecommendations(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error) {
-	// 构建时间范围过滤（默认最近30天）
-	timeRange := req.TimeRange
-	if timeRange == nil {
-		now := time.Now()
-		timeRange = &TimeRange{
-			StartTime: now.AddDate(0, 0, -30), // 30天前
-			EndTime:   now,
+	// 尚未计算过时同步计算一次
+	if r.trendingJob.LastRun().IsZero() {
+		if err := r.trendingJob.RunNow(ctx, nil); err != nil {
+			return nil, err
 		}
 	}
 
-	// 搜索最近的内容
-	searchQuery := &SearchQuery{
-		TopK:        req.MaxRecommendations * 5, // 获取更多结果用于热门分析
-		IncludeText: true,
-		Filter: map[string]interface{}{
-			"created_at": map[string]interface{}{
-				"$gte": timeRange.StartTime.Unix(),
-				"$lte": timeRange.EndTime.Unix(),
-			},
-		},
+	window := req.TrendingWindow
+	if window == "" {
+		window = DefaultTrendingWindow
 	}
-
-	// 如果有用户ID，添加用户过滤
+	var trendingScores []*TrendingScore
+	exists := false
 	if req.UserID != "" {
-		searchQuery.Filter["user_id"] = req.UserID
+		// 全局计算只覆盖部分文档，按用户单独扫描
+		var err error
+		trendingScores, exists, err = r.trendingJob.UserWindowScores(ctx, req.UserID, window)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		trendingScores, exists = r.trendingJob.WindowScores(window)
 	}
-
-	searchResult, err := r.searchEngine.chromaClient.Search(ctx, searchQuery)
-	if err != nil {
-		return nil, err
+	if !exists {
+		return nil, errors.ErrValidationFailed("trending_window", fmt.Sprintf("unknown window: %s", window))
 	}
 
-	// 分析热门度
-	trendingAnalysis := r.analyzeTrending(searchResult.Documents, timeRange)
-
 	recommendations := make([]*RecommendationItem, 0)
-	for _, doc := range searchResult.Documents {
-		trendingScore, exists := trendingAnalysis.DocumentScores[doc.ID]
-		if !exists {
+	for _, trending := range trendingScores {
+		doc := trending.Document
+
+		// 热门分数计算后被软删除的文档
+		if isDeleted(doc) {
+			continue
+		}
+
+		// 如果有用户ID，只保留该用户的内容
+		if req.UserID != "" {
+			if userID, _ := doc.Metadata["user_id"].(string); userID != req.UserID {
+				continue
+			}
+		}
+
+		// 请求指定的时间范围
+		if req.TimeRange != nil && (doc.CreatedAt.Before(req.TimeRange.StartTime) || doc.CreatedAt.After(req.TimeRange.EndTime)) {
+			continue
+		}
+
+		// 热门分数由后台任务预计算，自定义元数据过滤在这里应用
+		if !matchesMetadataFilters(doc.Metadata, req.MetadataFilters) {
 			continue
 		}
 
@@ -533,9 +827,9 @@ func (r *Recommender) getTrendingRecommendations(ctx context.Context, req *Recom
 			DocumentID:          doc.ID,
 			Content:             doc.Content,
 			Similarity:          0.5, // 热门推荐不基于相似度
-			Confidence:          trendingScore,
+			Confidence:          trending.Score,
 			Metadata:            doc.Metadata,
-			RecommendationScore: trendingScore,
+			RecommendationScore: trending.Score,
 			RelatedKeywords:     r.extractTrendingKeywords(doc),
 			CreatedAt:           doc.CreatedAt,
 		}
@@ -546,8 +840,10 @@ func (r *Recommender) getTrendingRecommendations(ctx context.Context, req *Recom
 				Reason:          "Trending content based on recent activity and engagement",
 				SimilarityScore: 0,
 				FactorBreakdown: map[string]float64{
-					"trending_score": trendingScore,
-					"recency_bonus":  r.calculateRecencyBonus(doc.CreatedAt),
+					"trending_score":       trending.Score,
+					"interaction_count":    float64(trending.InteractionCount),
+					"interaction_velocity": trending.Velocity,
+					"recency_bonus":        r.calculateRecencyBonus(doc.CreatedAt),
 				},
 				MatchedFeatures: []string{"trending", "recent"},
 			}
@@ -556,14 +852,28 @@ func (r *Recommender) getTrendingRecommendations(ctx context.Context, req *Recom
 		recommendations = append(recommendations, recItem)
 	}
 
-	// 按热门分数排序
-	sort.Slice(recommendations, func(i, j int) bool {
-		return recommendations[i].RecommendationScore > recommendations[j].RecommendationScore
-	})
-
 	return recommendations, nil
 }
 
+// scanTrendingDocuments 扫描时间窗口内的文档用于热门计算（按元数据过滤，不需要查询向量），userID非空时只扫描该用户的文档
+func (r *Recommender) scanTrendingDocuments(ctx context.Context, userID string, timeRange *TimeRange, limit int) ([]*VectorDocument, error) {
+	filter := excludeDeleted(r.searchEngine.buildFilter(&SearchOptions{UserID: userID, TimeRange: timeRange}))
+	return r.searchEngine.store.GetDocumentsByFilter(ctx, filter, limit)
+}
+
+// RecordInteraction 记录用户与文档的交互
+func (r *Recommender) RecordInteraction(userID, documentID string) {
+	r.interactions.RecordInteraction(userID, documentID, time.Now())
+	if r.searchEngine != nil {
+		r.searchEngine.interactionRecorded()
+	}
+}
+
+// RefreshTrending 立即重新计算热门分数，progress非nil时上报进度百分比
+func (r *Recommender) RefreshTrending(ctx context.Context, progress func(percent float64)) error {
+	return r.trendingJob.RunNow(ctx, progress)
+}
+
 // getCollaborativeRecommendations 获取协同过滤推荐
 func (r *Recommender) getCollaborativeRecommendations(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error) {
 	// 简化的协同过滤实现
@@ -589,8 +899,11 @@ func (r *Recommender) getCollaborativeRecommendations(ctx context.Context, req *
 	recommendations := make([]*RecommendationItem, 0)
 	for docID, score := range recommendedDocs {
 		// 获取文档详情
-		doc, err := r.searchEngine.chromaClient.GetDocument(ctx, docID)
-		if err != nil {
+		doc, err := r.searchEngine.store.GetDocument(ctx, docID)
+		if err != nil || isDeleted(doc) {
+			continue
+		}
+		if !matchesMetadataFilters(doc.Metadata, req.MetadataFilters) {
 			continue
 		}
 
@@ -628,84 +941,13 @@ func (r *Recommender) getCollaborativeRecommendations(ctx context.Context, req *
 	return recommendations, nil
 }
 
-// getHybridRecommendations 获取混合推荐
-func (r *Recommender) getHybridRecommendations(ctx context.Context, req *RecommendationRequest) ([]*RecommendationItem, error) {
-	r.logger.Debug("Getting hybrid recommendations")
-
-	// 获取不同类型的推荐
-	var allRecommendations []*RecommendationItem
-
-	// 1. 相似内容推荐 (权重: 0.3)
-	if req.SourceDocumentID != "" {
-		similarReq := *req
-		similarReq.Type = RecommendationTypeSimilar
-		similarReq.MaxRecommendations = req.MaxRecommendations / 2
-		similarRecs, err := r.getSimilarRecommendations(ctx, &similarReq)
-		if err == nil {
-			for _, rec := range similarRecs {
-				rec.RecommendationScore *= 0.3
-				rec.Explanation = r.addHybridExplanation(rec.Explanation, "similar", 0.3)
-			}
-			allRecommendations = append(allRecommendations, similarRecs...)
-		}
-	}
-
-	// 2. 个性化推荐 (权重: 0.4)
-	if req.PersonalizationCtx != nil {
-		personalizedReq := *req
-		personalizedReq.Type = RecommendationTypePersonalized
-		personalizedReq.MaxRecommendations = req.MaxRecommendations / 2
-		personalizedRecs, err := r.getPersonalizedRecommendations(ctx, &personalizedReq)
-		if err == nil {
-			for _, rec := range personalizedRecs {
-				rec.RecommendationScore *= 0.4
-				rec.Explanation = r.addHybridExplanation(rec.Explanation, "personalized", 0.4)
-			}
-			allRecommendations = append(allRecommendations, personalizedRecs...)
-		}
-	}
-
-	// 3. 热门推荐 (权重: 0.2)
-	trendingReq := *req
-	trendingReq.Type = RecommendationTypeTrending
-	trendingReq.MaxRecommendations = req.MaxRecommendations / 3
-	trendingRecs, err := r.getTrendingRecommendations(ctx, &trendingReq)
-	if err == nil {
-		for _, rec := range trendingRecs {
-			rec.RecommendationScore *= 0.2
-			rec.Explanation = r.addHybridExplanation(rec.Explanation, "trending", 0.2)
-		}
-		allRecommendations = append(allRecommendations, trendingRecs...)
-	}
-
-	// 4. 协同过滤推荐 (权重: 0.1)
-	collaborativeReq := *req
-	collaborativeReq.Type = RecommendationTypeCollaborative
-	collaborativeReq.MaxRecommendations = req.MaxRecommendations / 4
-	collaborativeRecs, err := r.getCollaborativeRecommendations(ctx, &collaborativeReq)
-	if err == nil {
-		for _, rec := range collaborativeRecs {
-			rec.RecommendationScore *= 0.1
-			rec.Explanation = r.addHybridExplanation(rec.Explanation, "collaborative", 0.1)
-		}
-		allRecommendations = append(allRecommendations, collaborativeRecs...)
-	}
-
-	// 合并和去重
-	uniqueRecommendations := r.mergeAndDeduplicateRecommendations(allRecommendations)
-
-	// 按混合分数排序
-	sort.Slice(uniqueRecommendations, func(i, j int) bool {
-		return uniqueRecommendations[i].RecommendationScore > uniqueRecommendations[j].RecommendationScore
-	})
-
-	return uniqueRecommendations, nil
-}
-
 // 辅助函数实现
 
 func (r *Recommender) buildSearchFilter(req *RecommendationRequest) map[string]interface{} {
-	filter := make(map[string]interface{})
+	// 超出配额被软删除的文档不参与推荐
+	filter := map[string]interface{}{
+		MetadataKeyDeleted: notDeletedCondition(),
+	}
 
 	if req.UserID != "" {
 		filter["user_id"] = req.UserID
@@ -734,6 +976,9 @@ func (r *Recommender) buildSearchFilter(req *RecommendationRequest) map[string]i
 		}
 	}
 
+	// 自定义元数据过滤，将推荐范围限定在部分文档内
+	applyMetadataFilters(filter, req.MetadataFilters)
+
 	return filter
 }
 
@@ -769,6 +1014,13 @@ func (r *Recommender) extractKeywordsFromMetadata(metadata map[string]interface{
 	return []string{}
 }
 
+func (r *Recommender) extractTagsFromMetadata(metadata map[string]interface{}) []string {
+	if tags := metadataStrings(metadata["tags"]); tags != nil {
+		return tags
+	}
+	return []string{}
+}
+
 func (r *Recommender) extractMatchedFeatures(sourceDoc, targetDoc *VectorDocument) []string {
 	features := make([]string, 0)
 
@@ -789,45 +1041,36 @@ func (r *Recommender) extractMatchedFeatures(sourceDoc, targetDoc *VectorDocumen
 }
 
 func (r *Recommender) calculateKeywordSimilarity(sourceKeywords []string, doc *VectorDocument) float64 {
-	if len(sourceKeywords) == 0 {
-		return 0.0
-	}
+	return termOverlap(sourceKeywords, r.extractKeywordsFromMetadata(doc.Metadata))
+}
 
-	docKeywords := r.extractKeywordsFromMetadata(doc.Metadata)
-	if len(docKeywords) == 0 {
+// termOverlap 计算源词项在目标词项中出现的比例（忽略大小写）
+func termOverlap(sourceTerms, targetTerms []string) float64 {
+	if len(sourceTerms) == 0 || len(targetTerms) == 0 {
 		return 0.0
 	}
 
-	// 计算关键词重叠度
-	matches := 0
-	for _, sk := range sourceKeywords {
-		for _, dk := range docKeywords {
-			if strings.EqualFold(sk, dk) {
-				matches++
-				break
-			}
-		}
-	}
-
-	return float64(matches) / float64(len(sourceKeywords))
+	return float64(len(sharedTerms(sourceTerms, targetTerms))) / float64(len(sourceTerms))
 }
 
-func (r *Recommender) extractSharedKeywords(sourceKeywords []string, doc *VectorDocument) []string {
-	docKeywords := r.extractKeywordsFromMetadata(doc.Metadata)
+// sharedTerms 获取同时出现在目标词项中的源词项（忽略大小写）
+func sharedTerms(sourceTerms, targetTerms []string) []string {
 	shared := make([]string, 0)
-
-	for _, sk := range sourceKeywords {
-		for _, dk := range docKeywords {
-			if strings.EqualFold(sk, dk) {
-				shared = append(shared, sk)
+	for _, st := range sourceTerms {
+		for _, tt := range targetTerms {
+			if strings.EqualFold(st, tt) {
+				shared = append(shared, st)
 				break
 			}
 		}
 	}
-
 	return shared
 }
 
+func (r *Recommender) extractSharedKeywords(sourceKeywords []string, doc *VectorDocument) []string {
+	return sharedTerms(sourceKeywords, r.extractKeywordsFromMetadata(doc.Metadata))
+}
+
 func (r *Recommender) generatePersonalizedQueryVector(ctx context.Context, personalCtx *PersonalizationContext) ([]float32, error) {
 	// 基于用户最近交互的内容生成查询向量
 	if len(personalCtx.RecentInteractions) == 0 {
@@ -839,7 +1082,7 @@ func (r *Recommender) generatePersonalizedQueryVector(ctx context.Context, perso
 	validDocs := 0
 
 	for _, docID := range personalCtx.RecentInteractions {
-		doc, err := r.searchEngine.chromaClient.GetDocument(ctx, docID)
+		doc, err := r.searchEngine.store.GetDocument(ctx, docID)
 		if err != nil {
 			continue
 		}
@@ -976,45 +1219,6 @@ func (r *Recommender) createPersonalizedExplanation(searchItem *SearchResultItem
 	return explanation
 }
 
-func (r *Recommender) analyzeTrending(documents []*VectorDocument, timeRange *TimeRange) *TrendingAnalysis {
-	documentScores := make(map[string]float64)
-	interactionCount := make(map[string]int)
-
-	timeWindow := timeRange.EndTime.Sub(timeRange.StartTime)
-
-	for _, doc := range documents {
-		// 简单的热门分数计算
-		// 在实际应用中，这里会考虑更多因素，如点击率、分享数、评论数等
-
-		// 基于创建时间的新鲜度分数
-		age := timeRange.EndTime.Sub(doc.CreatedAt)
-		freshnessScore := 1.0 - (age.Hours() / timeWindow.Hours())
-		if freshnessScore < 0 {
-			freshnessScore = 0
-		}
-
-		// 基于重要性的分数
-		importance := 0.5 // 默认重要性
-		if importanceVal, exists := doc.Metadata["importance_score"]; exists {
-			if score, ok := importanceVal.(float64); ok {
-				importance = score / 10.0
-			}
-		}
-
-		// 计算综合热门分数
-		trendingScore := freshnessScore*0.6 + importance*0.4
-
-		documentScores[doc.ID] = trendingScore
-		interactionCount[doc.ID] = 1 // 简化的交互计数
-	}
-
-	return &TrendingAnalysis{
-		DocumentScores:   documentScores,
-		TimeWindow:       timeWindow,
-		InteractionCount: interactionCount,
-	}
-}
-
 func (r *Recommender) extractTrendingKeywords(doc *VectorDocument) []string {
 	keywords := r.extractKeywordsFromMetadata(doc.Metadata)
 
@@ -1096,11 +1300,8 @@ func (r *Recommender) mergeAndDeduplicateRecommendations(recommendations []*Reco
 	return result
 }
 
+// applyFiltering 排除请求指定的文档和线程汇总文档（汇总文档与线程内的消息内容重复）
 func (r *Recommender) applyFiltering(recommendations []*RecommendationItem, req *RecommendationRequest) []*RecommendationItem {
-	if len(req.ExcludeDocuments) == 0 {
-		return recommendations
-	}
-
 	excludeMap := make(map[string]bool)
 	for _, docID := range req.ExcludeDocuments {
 		excludeMap[docID] = true
@@ -1108,9 +1309,13 @@ func (r *Recommender) applyFiltering(recommendations []*RecommendationItem, req
 
 	filtered := make([]*RecommendationItem, 0)
 	for _, rec := range recommendations {
-		if !excludeMap[rec.DocumentID] {
-			filtered = append(filtered, rec)
+		if excludeMap[rec.DocumentID] {
+			continue
+		}
+		if rollup, _ := rec.Metadata[MetadataKeyThreadRollup].(bool); rollup {
+			continue
 		}
+		filtered = append(filtered, rec)
 	}
 
 	return filtered
@@ -1147,6 +1352,11 @@ func (r *Recommender) applyDiversity(recommendations []*RecommendationItem, req
 	return diverse
 }
 
+// FlushCache 清空推荐系统使用的缓存，返回各类缓存清除的条目数
+func (r *Recommender) FlushCache(cacheType string) (map[string]int, error) {
+	return r.searchEngine.FlushCache(cacheType)
+}
+
 // HealthCheck 健康检查
 func (r *Recommender) HealthCheck(ctx context.Context) error {
 	return r.searchEngine.HealthCheck(ctx)
@@ -1155,5 +1365,8 @@ func (r *Recommender) HealthCheck(ctx context.Context) error {
 // Close 关闭推荐系统
 func (r *Recommender) Close() error {
 	r.logger.Info("Closing recommender system")
+	if r.trendingJob != nil {
+		r.trendingJob.Stop()
+	}
 	return r.searchEngine.Close()
 }
@@ -0,0 +1,531 @@
+package vector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+)
+
+// newTestRecommender 创建使用模拟Chroma服务的推荐系统
+func newTestRecommender(t *testing.T, fake *fakeChromaServer, factors map[RecommendationType]float64) *Recommender {
+	engine := newTestSearchEngine(t, new(MockEmbeddingService))
+	engine.store = newTestChromaClient(t, fake)
+
+	return &Recommender{
+		searchEngine:      engine,
+		similarityCalc:    NewSimilarityCalculator(),
+		ranker:            NewRanker(),
+		interactions:      NewInteractionStore(),
+		logger:            logger.NewLogger("recommender-test"),
+		similarityFactors: factors,
+	}
+}
+
+// TestSimilarityFactorsFrom 测试从配置读取相似度调整系数
+func TestSimilarityFactorsFrom(t *testing.T) {
+	t.Run("未配置时使用默认系数", func(t *testing.T) {
+		factors := similarityFactorsFrom(&config.Config{})
+		assert.Equal(t, 1.0, factors[RecommendationTypeSimilar])
+		assert.Equal(t, 0.7, factors[RecommendationTypeRelated])
+		assert.Equal(t, 0.8, factors[RecommendationTypePersonalized])
+	})
+
+	t.Run("部分配置覆盖默认值", func(t *testing.T) {
+		factors := similarityFactorsFrom(&config.Config{
+			VectorDB: config.VectorDBConfig{
+				Recommendation: &config.RecommendationConfig{
+					SimilarityFactors: config.SimilarityFactorsConfig{Related: 0.5},
+				},
+			},
+		})
+		assert.Equal(t, 1.0, factors[RecommendationTypeSimilar])
+		assert.Equal(t, 0.5, factors[RecommendationTypeRelated])
+		assert.Equal(t, 0.8, factors[RecommendationTypePersonalized])
+	})
+}
+
+// TestRecommender_RelatedSimilarityFactor 测试相关推荐的相似度系数
+func TestRecommender_RelatedSimilarityFactor(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	fake.put("source", "Go并发编程", []float32{0.1, 0.2, 0.3}, map[string]interface{}{"user_id": "user-1"})
+	fake.put("close", "Go goroutine调度", []float32{0.1, 0.2, 0.3}, map[string]interface{}{"user_id": "user-1"})
+	fake.put("medium", "Go channel用法", []float32{0.1, 0.2, 0.3}, map[string]interface{}{"user_id": "user-1"})
+	fake.put("far", "分布式系统设计", []float32{0.1, 0.2, 0.3}, map[string]interface{}{"user_id": "user-1"})
+	fake.distances["source"] = 0
+	fake.distances["close"] = 0.2  // 相似度0.8
+	fake.distances["medium"] = 0.4 // 相似度0.6
+	fake.distances["far"] = 0.6    // 相似度0.4
+
+	request := func() *RecommendationRequest {
+		return &RecommendationRequest{
+			Type:               RecommendationTypeRelated,
+			UserID:             "user-1",
+			SourceDocumentID:   "source",
+			MaxRecommendations: 10,
+			MinSimilarity:      0.8,
+		}
+	}
+
+	documentIDs := func(response *RecommendationResponse) []string {
+		ids := make([]string, 0, len(response.Recommendations))
+		for _, rec := range response.Recommendations {
+			ids = append(ids, rec.DocumentID)
+		}
+		return ids
+	}
+
+	t.Run("默认系数", func(t *testing.T) {
+		recommender := newTestRecommender(t, fake, similarityFactorsFrom(&config.Config{}))
+
+		response, err := recommender.GetRecommendations(context.Background(), request())
+		require.NoError(t, err)
+
+		// 0.8 × 0.7 = 0.56
+		assert.ElementsMatch(t, []string{"close", "medium"}, documentIDs(response))
+		assert.InDelta(t, 0.56, response.Metadata["effective_min_similarity"], 0.0001)
+	})
+
+	t.Run("调低相关系数召回更多低相似度内容", func(t *testing.T) {
+		recommender := newTestRecommender(t, fake, map[RecommendationType]float64{
+			RecommendationTypeRelated: 0.4,
+		})
+
+		response, err := recommender.GetRecommendations(context.Background(), request())
+		require.NoError(t, err)
+
+		// 0.8 × 0.4 = 0.32
+		assert.ElementsMatch(t, []string{"close", "medium", "far"}, documentIDs(response))
+		assert.InDelta(t, 0.32, response.Metadata["effective_min_similarity"], 0.0001)
+	})
+
+	t.Run("未配置的类型回退默认系数", func(t *testing.T) {
+		recommender := newTestRecommender(t, fake, nil)
+		assert.InDelta(t, 0.64, recommender.effectiveMinSimilarity(RecommendationTypePersonalized, 0.8), 0.0001)
+		assert.InDelta(t, 0.8, recommender.effectiveMinSimilarity(RecommendationTypeTrending, 0.8), 0.0001)
+	})
+}
+
+// TestRecommender_TagBased 测试基于标签重叠的推荐不调用向量化服务
+func TestRecommender_TagBased(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	fake.put("source", "Go并发编程", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id":  "user-1",
+		"tags":     []interface{}{"go", "并发", "编程"},
+		"keywords": []interface{}{"goroutine", "channel"},
+	})
+	fake.put("both", "Go channel用法", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id":  "user-1",
+		"tags":     []interface{}{"Go", "并发"},
+		"keywords": []interface{}{"channel"},
+	})
+	fake.put("one-tag", "Go模块管理", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id": "user-1",
+		"tags":    []interface{}{"go", "工具"},
+	})
+	fake.put("unrelated", "红烧肉做法", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id": "user-1",
+		"tags":    []interface{}{"烹饪"},
+	})
+	fake.put("other-user", "Go并发模式", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id": "user-2",
+		"tags":    []interface{}{"go", "并发"},
+	})
+	// 超出配额被软删除的文档不参与推荐
+	fake.put("evicted", "Go channel与并发", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id":          "user-1",
+		"tags":             []interface{}{"go", "并发", "编程"},
+		MetadataKeyDeleted: true,
+	})
+	// 线程汇总文档与线程内的消息重复，不参与推荐
+	fake.put("thread-rollup", "Go channel用法\n\nGo并发讨论", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id":               "user-1",
+		"tags":                  []interface{}{"go", "并发", "编程"},
+		MetadataKeyThreadRollup: true,
+	})
+
+	embedder := new(MockEmbeddingService)
+	recommender := newTestRecommender(t, fake, nil)
+	recommender.searchEngine.embeddingService = embedder
+
+	response, err := recommender.GetRecommendations(context.Background(), &RecommendationRequest{
+		Type:                RecommendationTypeTagBased,
+		UserID:              "user-1",
+		SourceDocumentID:    "source",
+		MaxRecommendations:  10,
+		IncludeExplanations: true,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, response.Recommendations, 2)
+	assert.Equal(t, "both", response.Recommendations[0].DocumentID)
+	assert.Equal(t, "one-tag", response.Recommendations[1].DocumentID)
+	assert.Greater(t, response.Recommendations[0].RecommendationScore, response.Recommendations[1].RecommendationScore)
+	assert.ElementsMatch(t, []string{"go", "并发", "channel"}, response.Recommendations[0].RelatedKeywords)
+	assert.Contains(t, response.Recommendations[0].Explanation.FactorBreakdown, "tag_similarity")
+
+	// 全程未调用向量化服务
+	embedder.AssertNotCalled(t, "GenerateEmbedding", mock.Anything, mock.Anything)
+	assert.Empty(t, embedder.Calls)
+
+	t.Run("缺少源文档", func(t *testing.T) {
+		_, err := recommender.GetRecommendations(context.Background(), &RecommendationRequest{
+			Type:   RecommendationTypeTagBased,
+			UserID: "user-1",
+		})
+		assert.Error(t, err)
+	})
+}
+
+// TestRecommender_ExcludeSeen 测试推荐结果默认排除用户已交互过的文档
+func TestRecommender_ExcludeSeen(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	for _, id := range []string{"seen-1", "seen-2", "seen-3", "new-1", "new-2"} {
+		fake.put(id, "Go并发编程 "+id, []float32{0.1, 0.2, 0.3}, map[string]interface{}{"user_id": "user-1", "tags": "go"})
+		fake.distances[id] = 0.1
+	}
+
+	newRecommender := func(t *testing.T) *Recommender {
+		recommender := newTestRecommender(t, fake, nil)
+		embedder := new(MockEmbeddingService)
+		embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+			Return(&EmbeddingResult{Vector: []float32{0.1, 0.2, 0.3}, Dimension: 3}, nil)
+		recommender.searchEngine.embeddingService = embedder
+		recommender.personalization = personalizationSettings{minInteractions: 3, historySize: 20, fallback: PersonalizationFallbackNone}
+
+		for _, id := range []string{"seen-1", "seen-2", "seen-3"} {
+			recommender.RecordInteraction("user-1", id)
+		}
+		return recommender
+	}
+	request := func(includeSeen *bool) *RecommendationRequest {
+		return &RecommendationRequest{
+			Type:               RecommendationTypePersonalized,
+			UserID:             "user-1",
+			MaxRecommendations: 10,
+			IncludeSeen:        includeSeen,
+		}
+	}
+	documentIDs := func(response *RecommendationResponse) []string {
+		ids := make([]string, 0, len(response.Recommendations))
+		for _, rec := range response.Recommendations {
+			ids = append(ids, rec.DocumentID)
+		}
+		return ids
+	}
+
+	t.Run("默认排除已交互的文档", func(t *testing.T) {
+		response, err := newRecommender(t).GetRecommendations(context.Background(), request(nil))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"new-1", "new-2"}, documentIDs(response))
+		assert.Equal(t, 3, response.Metadata["excluded_seen"])
+	})
+
+	t.Run("请求要求保留时包含已交互的文档", func(t *testing.T) {
+		includeSeen := true
+		response, err := newRecommender(t).GetRecommendations(context.Background(), request(&includeSeen))
+		require.NoError(t, err)
+		assert.Subset(t, documentIDs(response), []string{"seen-1", "new-1"})
+		assert.NotContains(t, response.Metadata, "excluded_seen")
+	})
+
+	t.Run("配置默认保留时不排除", func(t *testing.T) {
+		recommender := newRecommender(t)
+		recommender.includeSeen = true
+		response, err := recommender.GetRecommendations(context.Background(), request(nil))
+		require.NoError(t, err)
+		assert.Contains(t, documentIDs(response), "seen-2")
+	})
+
+	t.Run("缓存命中后新交互的文档同样被排除", func(t *testing.T) {
+		recommender := newRecommender(t)
+		first, err := recommender.GetRecommendations(context.Background(), request(nil))
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"new-1", "new-2"}, documentIDs(first))
+
+		recommender.RecordInteraction("user-1", "new-1")
+		response, err := recommender.GetRecommendations(context.Background(), request(nil))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"new-2"}, documentIDs(response))
+		assert.Equal(t, 1, response.Recommendations[0].Rank)
+
+		// 缓存命中后的过滤和重新排名不修改缓存内容
+		cached, found := recommender.searchEngine.cacheManager.GetRecommendation(request(nil))
+		require.True(t, found)
+		require.Len(t, cached, 2)
+		for i, rec := range cached {
+			assert.Equal(t, i+1, rec.Rank)
+		}
+	})
+
+	t.Run("通过共享搜索引擎记录的交互同样被排除", func(t *testing.T) {
+		engine := newRecommender(t).searchEngine
+		engine.SetInteractionStore(NewInteractionStore())
+		recommender := engine.Recommender()
+		t.Cleanup(recommender.trendingJob.Stop)
+		assert.Same(t, recommender, engine.Recommender())
+		recommender.personalization = personalizationSettings{minInteractions: 3, historySize: 20, fallback: PersonalizationFallbackNone}
+
+		// 内容处理器查看内容时通过引擎记录交互
+		for _, id := range []string{"seen-1", "seen-2", "seen-3"} {
+			engine.RecordInteraction("user-1", id)
+		}
+		response, err := recommender.GetRecommendations(context.Background(), request(nil))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"new-1", "new-2"}, documentIDs(response))
+	})
+}
+
+// TestRecommender_MinScore 测试最小推荐分数：弱匹配被丢弃，没有合格推荐时返回带原因的空结果
+func TestRecommender_MinScore(t *testing.T) {
+	newFake := func(withCloseNeighbor bool) *fakeChromaServer {
+		fake := newFakeChromaServer(t)
+		fake.put("source", "Go并发编程", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+			"user_id": "user-1",
+			"tags":    []interface{}{"go", "并发", "编程"},
+		})
+		// 只共享一个标签的弱匹配
+		fake.put("weak-1", "Go写的菜谱网站", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+			"user_id": "user-1",
+			"tags":    []interface{}{"go", "烹饪", "旅行", "摄影"},
+		})
+		fake.put("weak-2", "编程书单", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+			"user_id": "user-1",
+			"tags":    []interface{}{"编程", "阅读", "写作", "管理"},
+		})
+		if withCloseNeighbor {
+			fake.put("close", "Go并发模式", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+				"user_id": "user-1",
+				"tags":    []interface{}{"go", "并发", "编程"},
+			})
+		}
+		return fake
+	}
+	request := func() *RecommendationRequest {
+		return &RecommendationRequest{
+			Type:               RecommendationTypeTagBased,
+			UserID:             "user-1",
+			SourceDocumentID:   "source",
+			MaxRecommendations: 10,
+		}
+	}
+	minScores := minScoresFrom(&config.Config{
+		VectorDB: config.VectorDBConfig{
+			Recommendation: &config.RecommendationConfig{
+				MinScores: map[string]float64{"tag_based": 0.5},
+			},
+		},
+	})
+
+	t.Run("没有足够相关的文档时返回带原因的空结果", func(t *testing.T) {
+		recommender := newTestRecommender(t, newFake(false), nil)
+		recommender.minScores = minScores
+
+		response, err := recommender.GetRecommendations(context.Background(), request())
+		require.NoError(t, err)
+		assert.NotNil(t, response.Recommendations)
+		assert.Empty(t, response.Recommendations)
+		assert.Equal(t, 0, response.TotalFound)
+		assert.Equal(t, EmptyReasonBelowMinScore, response.Metadata["empty_reason"])
+		assert.Equal(t, 0.5, response.Metadata["min_score"])
+		assert.Equal(t, 2, response.Metadata["score_filtered"])
+	})
+
+	t.Run("只保留达到最小分数的推荐", func(t *testing.T) {
+		recommender := newTestRecommender(t, newFake(true), nil)
+		recommender.minScores = minScores
+
+		response, err := recommender.GetRecommendations(context.Background(), request())
+		require.NoError(t, err)
+		require.Len(t, response.Recommendations, 1)
+		assert.Equal(t, "close", response.Recommendations[0].DocumentID)
+		assert.NotContains(t, response.Metadata, "empty_reason")
+	})
+
+	t.Run("未配置时不过滤弱匹配", func(t *testing.T) {
+		recommender := newTestRecommender(t, newFake(false), nil)
+
+		response, err := recommender.GetRecommendations(context.Background(), request())
+		require.NoError(t, err)
+		assert.Len(t, response.Recommendations, 2)
+		assert.NotContains(t, response.Metadata, "min_score")
+	})
+
+	t.Run("请求指定的最小分数优先于配置", func(t *testing.T) {
+		recommender := newTestRecommender(t, newFake(false), nil)
+		recommender.minScores = minScores
+
+		req := request()
+		req.MinScore = 0.1
+		response, err := recommender.GetRecommendations(context.Background(), req)
+		require.NoError(t, err)
+		assert.Len(t, response.Recommendations, 2)
+	})
+
+	t.Run("其他类型不受该类型阈值影响", func(t *testing.T) {
+		recommender := newTestRecommender(t, newFake(false), nil)
+		recommender.minScores = minScores
+		assert.Zero(t, recommender.minScoreFor(&RecommendationRequest{Type: RecommendationTypeSimilar}))
+	})
+}
+
+// TestRecommender_MetadataFilters 测试自定义元数据过滤将推荐限定在匹配的文档内
+func TestRecommender_MetadataFilters(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	fake.put("source", "Go并发编程", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id": "user-1",
+		"project": "memoro",
+	})
+	fake.put("same-project", "Go并发模式", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id": "user-1",
+		"project": "memoro",
+	})
+	fake.put("other-project", "Go并发陷阱", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id": "user-1",
+		"project": "gateway",
+	})
+	fake.put("no-project", "Go并发入门", []float32{0.1, 0.2, 0.3}, map[string]interface{}{
+		"user_id": "user-1",
+	})
+	for _, id := range []string{"same-project", "other-project", "no-project"} {
+		fake.distances[id] = 0.2 // 相似度0.8
+	}
+	recommender := newTestRecommender(t, fake, nil)
+
+	request := func(filters map[string]interface{}) *RecommendationRequest {
+		return &RecommendationRequest{
+			Type:               RecommendationTypeRelated,
+			UserID:             "user-1",
+			SourceDocumentID:   "source",
+			MaxRecommendations: 10,
+			MetadataFilters:    filters,
+		}
+	}
+	documentIDs := func(response *RecommendationResponse) []string {
+		ids := make([]string, len(response.Recommendations))
+		for i, item := range response.Recommendations {
+			ids[i] = item.DocumentID
+		}
+		return ids
+	}
+
+	t.Run("只返回匹配的文档", func(t *testing.T) {
+		response, err := recommender.GetRecommendations(context.Background(), request(map[string]interface{}{"project": "memoro"}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"same-project"}, documentIDs(response))
+	})
+
+	t.Run("数组匹配任一值", func(t *testing.T) {
+		response, err := recommender.GetRecommendations(context.Background(), request(map[string]interface{}{
+			"project": []interface{}{"memoro", "gateway"},
+		}))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"same-project", "other-project"}, documentIDs(response))
+	})
+
+	t.Run("不支持的值类型", func(t *testing.T) {
+		_, err := recommender.GetRecommendations(context.Background(), request(map[string]interface{}{
+			"project": map[string]interface{}{"$ne": "memoro"},
+		}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "metadata_filters.project")
+	})
+}
+
+// TestRecommender_MetadataFiltersAllStrategies 测试各推荐策略都应用自定义元数据过滤
+func TestRecommender_MetadataFiltersAllStrategies(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryStore()
+	require.NoError(t, store.AddDocuments(ctx, []*VectorDocument{
+		{ID: "source", Content: "Go并发编程", Embedding: []float32{1, 0}, CreatedAt: now, Metadata: map[string]interface{}{
+			"user_id": "user-1", "project": "memoro", "tags": []interface{}{"go", "并发"},
+		}},
+		{ID: "memoro-1", Content: "Go并发模式", Embedding: []float32{1, 0}, CreatedAt: now, Metadata: map[string]interface{}{
+			"user_id": "user-1", "project": "memoro", "tags": []interface{}{"go", "并发"},
+		}},
+		{ID: "evicted-1", Content: "Go并发旧文", Embedding: []float32{1, 0}, CreatedAt: now, Metadata: map[string]interface{}{
+			"user_id": "user-1", "project": "memoro", "tags": []interface{}{"go", "并发"}, MetadataKeyDeleted: true,
+		}},
+		{ID: "gateway-1", Content: "Go网关设计", Embedding: []float32{1, 0}, CreatedAt: now, Metadata: map[string]interface{}{
+			"user_id": "user-1", "project": "gateway", "tags": []interface{}{"go"},
+		}},
+		// 协同过滤的模拟数据推荐doc4和doc5
+		{ID: "doc4", Content: "相似用户喜欢的网关内容", Embedding: []float32{0, 1}, CreatedAt: now, Metadata: map[string]interface{}{
+			"user_id": "user-2", "project": "gateway",
+		}},
+		{ID: "doc5", Content: "相似用户喜欢的memoro内容", Embedding: []float32{0, 1}, CreatedAt: now, Metadata: map[string]interface{}{
+			"user_id": "user-2", "project": "memoro",
+		}},
+	}))
+
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{1, 0}, Dimension: 2}, nil)
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = store
+	recommender := &Recommender{
+		searchEngine:   engine,
+		similarityCalc: NewSimilarityCalculator(),
+		ranker:         NewRanker(),
+		interactions:   NewInteractionStore(),
+		logger:         logger.NewLogger("recommender-test"),
+	}
+	// 其他用户的交互产生热门分数，不影响user-1的已读排除
+	recommender.interactions.RecordInteraction("user-2", "memoro-1", now)
+	recommender.interactions.RecordInteraction("user-2", "gateway-1", now)
+	recommender.interactions.RecordInteraction("user-2", "evicted-1", now)
+	recommender.trendingJob = NewTrendingJob(recommender.scanTrendingDocuments, recommender.interactions, DefaultTrendingJobConfig())
+
+	recommend := func(t *testing.T, req *RecommendationRequest) []string {
+		req.MaxRecommendations = 10
+		response, err := recommender.GetRecommendations(ctx, req)
+		require.NoError(t, err)
+		ids := make([]string, len(response.Recommendations))
+		for i, item := range response.Recommendations {
+			ids[i] = item.DocumentID
+		}
+		return ids
+	}
+	memoroOnly := map[string]interface{}{"project": "memoro"}
+
+	t.Run("热门推荐", func(t *testing.T) {
+		ids := recommend(t, &RecommendationRequest{Type: RecommendationTypeTrending, UserID: "user-1", MetadataFilters: memoroOnly})
+		assert.Contains(t, ids, "memoro-1")
+		assert.NotContains(t, ids, "gateway-1")
+		assert.NotContains(t, ids, "evicted-1")
+	})
+
+	t.Run("个性化推荐", func(t *testing.T) {
+		ids := recommend(t, &RecommendationRequest{
+			Type:               RecommendationTypePersonalized,
+			UserID:             "user-1",
+			SourceQuery:        "Go",
+			PersonalizationCtx: &PersonalizationContext{UserID: "user-1"},
+			MetadataFilters:    memoroOnly,
+		})
+		assert.Contains(t, ids, "memoro-1")
+		assert.NotContains(t, ids, "gateway-1")
+	})
+
+	t.Run("协同过滤推荐", func(t *testing.T) {
+		ids := recommend(t, &RecommendationRequest{Type: RecommendationTypeCollaborative, UserID: "user-2", MetadataFilters: memoroOnly})
+		assert.Equal(t, []string{"doc5"}, ids)
+	})
+
+	t.Run("标签推荐与请求的标签过滤同时生效", func(t *testing.T) {
+		ids := recommend(t, &RecommendationRequest{
+			Type:             RecommendationTypeTagBased,
+			UserID:           "user-1",
+			SourceDocumentID: "source",
+			MetadataFilters:  map[string]interface{}{"tags": []interface{}{"并发"}},
+		})
+		assert.Equal(t, []string{"memoro-1"}, ids)
+	})
+}
@@ -0,0 +1,97 @@
+package vector
+
+import "sync/atomic"
+
+// indexVersion 集合版本，索引变更后递增；同一进程中的所有搜索引擎实例共享，
+// 通过某个实例写入后其他实例（如搜索API使用的引擎）缓存的结果同样失效
+var indexVersion atomic.Uint64
+
+// collectionVersion 当前集合版本，每次索引变更后递增，用于使缓存的搜索结果失效
+func (se *SearchEngine) collectionVersion() uint64 {
+	return indexVersion.Load()
+}
+
+// bumpCollectionVersion 索引写入、更新或删除后递增集合版本
+func (se *SearchEngine) bumpCollectionVersion() {
+	indexVersion.Add(1)
+}
+
+// interactionRecorded 记录交互后调用：启用按访问调整重要性时排序依赖访问记录，递增集合版本使缓存的搜索结果失效
+func (se *SearchEngine) interactionRecorded() {
+	if cfg := se.config.AccessImportance; cfg != nil && cfg.Weight > 0 {
+		se.bumpCollectionVersion()
+	}
+}
+
+// cachedSearchResult 获取与当前集合版本一致的缓存搜索结果
+func (se *SearchEngine) cachedSearchResult(key string, version uint64) (*SearchResponse, bool) {
+	if se.cacheManager == nil {
+		return nil, false
+	}
+
+	cached, ok := se.cacheManager.GetSearchResult(key, version)
+	if !ok {
+		return nil, false
+	}
+
+	response := snapshotSearchResponse(cached)
+	response.Metadata["cached"] = true
+	return response, true
+}
+
+// cacheSearchResult 缓存搜索结果的副本，避免调用方修改缓存内容
+func (se *SearchEngine) cacheSearchResult(key string, version uint64, response *SearchResponse) {
+	if se.cacheManager == nil || !se.cacheManager.SearchResultCacheEnabled() {
+		return
+	}
+	se.cacheManager.SetSearchResult(key, version, snapshotSearchResponse(response))
+}
+
+// snapshotSearchResponse 深拷贝搜索响应的结果和元数据，缓存与调用方互不共享可变数据
+func snapshotSearchResponse(response *SearchResponse) *SearchResponse {
+	copied := *response
+	copied.Results = make([]*SearchResultItem, len(response.Results))
+	for i, result := range response.Results {
+		copied.Results[i] = copySearchResultItem(result)
+	}
+	copied.Metadata = copyMetadata(response.Metadata)
+	if copied.Metadata == nil {
+		copied.Metadata = make(map[string]interface{}, 1)
+	}
+	return &copied
+}
+
+// copySearchResultItem 深拷贝搜索结果项
+func copySearchResultItem(result *SearchResultItem) *SearchResultItem {
+	if result == nil {
+		return nil
+	}
+	copied := *result
+	copied.Metadata = copyMetadata(result.Metadata)
+	copied.MatchedKeywords = append([]string(nil), result.MatchedKeywords...)
+	copied.DuplicateOf = append([]string(nil), result.DuplicateOf...)
+	copied.ThreadMessages = append([]string(nil), result.ThreadMessages...)
+	copied.Embedding = append([]float32(nil), result.Embedding...)
+	if result.ScoreBreakdown != nil {
+		breakdown := *result.ScoreBreakdown
+		breakdown.TagBoosts = copyFloatMap(result.ScoreBreakdown.TagBoosts)
+		copied.ScoreBreakdown = &breakdown
+	}
+	if result.LLMRelevanceScore != nil {
+		score := *result.LLMRelevanceScore
+		copied.LLMRelevanceScore = &score
+	}
+	return &copied
+}
+
+// copyFloatMap 复制分数映射，nil时返回nil
+func copyFloatMap(values map[string]float64) map[string]float64 {
+	if values == nil {
+		return nil
+	}
+	copied := make(map[string]float64, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,222 @@
+package vector
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"memoro/internal/services/language"
+)
+
+const (
+	// sampleHeadShare 采样时开头段落占用的预算比例
+	sampleHeadShare = 0.3
+	// sampleTailShare 采样时结尾段落占用的预算比例
+	sampleTailShare = 0.2
+	// sampleKeywordLimit 计算关键词密度时使用的高频词数量
+	sampleKeywordLimit = 20
+	// sampleSeparator 采样片段之间的分隔
+	sampleSeparator = " " + truncationMarker + " "
+)
+
+// sampleStopWords 计算关键词时忽略的常见英文词
+var sampleStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "that": true, "with": true, "this": true,
+	"are": true, "was": true, "from": true, "but": true, "not": true, "have": true,
+}
+
+// sampledSentence 中间部分的候选句子
+type sampledSentence struct {
+	index int
+	text  string
+	cost  float64
+	score float64
+}
+
+// sampleText 从长文本中抽取代表性片段作为向量化输入：开头段落、按关键词密度选出的中间句子和结尾段落，
+// 片段保持原文顺序；段落和句子不足以采样时退化为head_tail截断
+func sampleText(text string, maxTokens int) string {
+	budget := float64(maxTokens)
+
+	var head, tail string
+	var middle []string
+	if paragraphs := splitParagraphs(text); len(paragraphs) >= 3 {
+		head, tail = paragraphs[0], paragraphs[len(paragraphs)-1]
+		for _, paragraph := range paragraphs[1 : len(paragraphs)-1] {
+			middle = append(middle, splitSentences(paragraph)...)
+		}
+	} else {
+		sentences := splitSentences(text)
+		if len(sentences) < 3 {
+			return TruncateText(text, maxTokens, TruncationHeadTail)
+		}
+		head, tail = sentences[0], sentences[len(sentences)-1]
+		middle = sentences[1 : len(sentences)-1]
+	}
+
+	headRunes := []rune(head)
+	head = string(headRunes[:headCutIndex(headRunes, budget*sampleHeadShare)])
+	tailRunes := []rune(tail)
+	tail = string(tailRunes[tailCutIndex(tailRunes, budget*sampleTailShare):])
+
+	separatorCost := textTokenCost(sampleSeparator)
+	remaining := budget - textTokenCost(head) - textTokenCost(tail) - 2*separatorCost
+	selected := selectDenseSentences(middle, keywordsOf(text), remaining)
+	if len(selected) == 0 {
+		return head + sampleSeparator + tail
+	}
+	return head + sampleSeparator + strings.Join(selected, " ") + sampleSeparator + tail
+}
+
+// selectDenseSentences 按关键词密度（句中关键词按全文词频加权，除以句子词数）从高到低选取预算内的句子，按原文顺序返回
+func selectDenseSentences(sentences []string, keywords map[string]int, budget float64) []string {
+	candidates := make([]sampledSentence, 0, len(sentences))
+	for i, sentence := range sentences {
+		terms := sampleTerms(sentence)
+		if len(terms) == 0 {
+			continue
+		}
+		weight := 0
+		for _, term := range terms {
+			weight += keywords[term]
+		}
+		candidates = append(candidates, sampledSentence{
+			index: i,
+			text:  sentence,
+			cost:  textTokenCost(sentence) + textTokenCost(" "),
+			score: float64(weight) / float64(len(terms)),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	var picked []sampledSentence
+	for _, candidate := range candidates {
+		if candidate.cost > budget {
+			continue
+		}
+		picked = append(picked, candidate)
+		budget -= candidate.cost
+	}
+
+	sort.Slice(picked, func(i, j int) bool {
+		return picked[i].index < picked[j].index
+	})
+	selected := make([]string, len(picked))
+	for i, sentence := range picked {
+		selected[i] = sentence.text
+	}
+	return selected
+}
+
+// keywordsOf 统计全文词频，返回出现次数最多的关键词及其词频
+func keywordsOf(text string) map[string]int {
+	frequency := make(map[string]int)
+	for _, term := range sampleTerms(text) {
+		frequency[term]++
+	}
+
+	terms := make([]string, 0, len(frequency))
+	for term := range frequency {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if frequency[terms[i]] != frequency[terms[j]] {
+			return frequency[terms[i]] > frequency[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	if len(terms) > sampleKeywordLimit {
+		terms = terms[:sampleKeywordLimit]
+	}
+
+	keywords := make(map[string]int, len(terms))
+	for _, term := range terms {
+		keywords[term] = frequency[term]
+	}
+	return keywords
+}
+
+// sampleTerms 切分词项：英文按单词（小写，至少3个字符，忽略常见词），CJK按相邻二字组
+func sampleTerms(text string) []string {
+	var terms []string
+	var word []rune
+	var previousCJK rune
+
+	flushWord := func() {
+		if len(word) >= 3 {
+			if term := strings.ToLower(string(word)); !sampleStopWords[term] {
+				terms = append(terms, term)
+			}
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case language.IsCJKRune(r):
+			flushWord()
+			if previousCJK != 0 {
+				terms = append(terms, string([]rune{previousCJK, r}))
+			}
+			previousCJK = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flushWord()
+		}
+		previousCJK = 0
+	}
+	flushWord()
+	return terms
+}
+
+// splitParagraphs 按空行切分段落
+func splitParagraphs(text string) []string {
+	var paragraphs []string
+	for _, block := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if block = strings.TrimSpace(block); block != "" {
+			paragraphs = append(paragraphs, block)
+		}
+	}
+	return paragraphs
+}
+
+// splitSentences 按中英文句末标点和换行切分句子
+func splitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+
+	flush := func() {
+		if sentence := strings.TrimSpace(current.String()); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		current.Reset()
+	}
+
+	for _, r := range text {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+		switch r {
+		case '.', '!', '?', '。', '！', '？', '；':
+			flush()
+		}
+	}
+	flush()
+	return sentences
+}
+
+// textTokenCost 文本的token开销（未取整）
+func textTokenCost(text string) float64 {
+	var cost float64
+	for _, r := range text {
+		cost += runeTokenCost(r)
+	}
+	return cost
+}
@@ -59,14 +59,14 @@ func TestSearchEngine_CacheIntegration(t *testing.T) {
 		}
 
 		// 模拟第一次查询 - 应该调用embedding服务
-		vector1, err := searchEngine.generateQueryVector(context.Background(), query, options)
+		vector1, _, err := searchEngine.generateQueryVector(context.Background(), query, options)
 		require.NoError(t, err)
 		require.NotNil(t, vector1)
 		require.Greater(t, len(vector1), 0)
 
 		// 第二次相同查询 - 应该从缓存获取
 		startTime := time.Now()
-		vector2, err := searchEngine.generateQueryVector(context.Background(), query, options)
+		vector2, _, err := searchEngine.generateQueryVector(context.Background(), query, options)
 		cacheLatency := time.Since(startTime)
 
 		require.NoError(t, err)
@@ -94,13 +94,13 @@ func TestSearchEngine_CacheIntegration(t *testing.T) {
 		options3 := &SearchOptions{Query: baseQuery, TopK: 5, UserID: "user2"}  // 不同UserID
 
 		// 生成三个不同的查询向量
-		vector1, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options1)
+		vector1, _, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options1)
 		require.NoError(t, err)
 
-		vector2, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options2)
+		vector2, _, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options2)
 		require.NoError(t, err)
 
-		vector3, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options3)
+		vector3, _, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options3)
 		require.NoError(t, err)
 
 		// 验证向量都存在且被正确缓存
@@ -109,15 +109,15 @@ func TestSearchEngine_CacheIntegration(t *testing.T) {
 		assert.NotNil(t, vector3)
 
 		// 再次查询验证缓存命中
-		cachedVector1, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options1)
+		cachedVector1, _, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options1)
 		require.NoError(t, err)
 		assert.Equal(t, vector1, cachedVector1)
 
-		cachedVector2, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options2)
+		cachedVector2, _, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options2)
 		require.NoError(t, err)
 		assert.Equal(t, vector2, cachedVector2)
 
-		cachedVector3, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options3)
+		cachedVector3, _, err := searchEngine.generateQueryVector(context.Background(), baseQuery, options3)
 		require.NoError(t, err)
 		assert.Equal(t, vector3, cachedVector3)
 	})
@@ -4,13 +4,21 @@ import (
 	"math"
 	"sort"
 
+	"memoro/internal/config"
 	"memoro/internal/errors"
 	"memoro/internal/logger"
 )
 
+// 未配置时的相似度归一化参数
+const (
+	defaultVectorNorm     = 1.0
+	defaultManhattanScale = 1.0
+)
+
 // SimilarityCalculator 相似度计算器
 type SimilarityCalculator struct {
-	logger *logger.Logger
+	normalization config.SimilarityNormalizationConfig // 各相似度类型映射到0-1的参数
+	logger        *logger.Logger
 }
 
 // SimilarityType 相似度计算类型
@@ -40,13 +48,101 @@ type SimilarityMatrix struct {
 
 // NewSimilarityCalculator 创建相似度计算器
 func NewSimilarityCalculator() *SimilarityCalculator {
+	var normalization *config.SimilarityNormalizationConfig
+	if cfg := config.Get(); cfg != nil {
+		normalization = cfg.VectorDB.SimilarityNormalization
+	}
+
 	return &SimilarityCalculator{
-		logger: logger.NewLogger("similarity-calculator"),
+		normalization: similarityNormalizationFrom(normalization),
+		logger:        logger.NewLogger("similarity-calculator"),
+	}
+}
+
+// similarityNormalizationFrom 获取相似度归一化参数（0使用默认值）
+func similarityNormalizationFrom(normalization *config.SimilarityNormalizationConfig) config.SimilarityNormalizationConfig {
+	result := config.SimilarityNormalizationConfig{
+		VectorNorm:     defaultVectorNorm,
+		ManhattanScale: defaultManhattanScale,
 	}
+	if normalization == nil {
+		return result
+	}
+
+	if normalization.VectorNorm > 0 {
+		result.VectorNorm = normalization.VectorNorm
+	}
+	if normalization.ManhattanScale > 0 {
+		result.ManhattanScale = normalization.ManhattanScale
+	}
+	return result
 }
 
-// CalculateCosineSimilarity 计算余弦相似度
+// Normalize 将相似度类型的原始输出映射到0-1，分数越高越相似且保持原有排序
+// cosine: 余弦值[-1,1]线性映射；dot: 按向量模长换算为余弦值后同cosine（单位向量时与cosine一致，超出范围截断）；
+// euclidean: 由距离换算余弦值1-d²/(2·模长²)后同cosine；manhattan: 1/(1+距离/尺度)
+func (sc *SimilarityCalculator) Normalize(simType SimilarityType, raw float64) float64 {
+	if math.IsNaN(raw) {
+		return 0
+	}
+
+	normalization := sc.normalization
+	if normalization.VectorNorm <= 0 || normalization.ManhattanScale <= 0 {
+		normalization = similarityNormalizationFrom(&normalization)
+	}
+	norm := normalization.VectorNorm
+
+	var normalized float64
+	switch simType {
+	case SimilarityTypeCosine:
+		normalized = (raw + 1.0) / 2.0
+	case SimilarityTypeDotProduct:
+		normalized = (raw/(norm*norm) + 1.0) / 2.0
+	case SimilarityTypeEuclidean:
+		distance := math.Max(raw, 0) / norm
+		normalized = 1.0 - distance*distance/4.0
+	case SimilarityTypeManhattan:
+		normalized = 1.0 / (1.0 + math.Max(raw, 0)/normalization.ManhattanScale)
+	default:
+		normalized = raw
+	}
+
+	return clampUnit(normalized)
+}
+
+// distanceToSimilarity 按集合的距离函数将向量库返回的距离换算为余弦相似度，不做截断（最小相似度按实际值判断），
+// 第二个返回值表示换算结果为负
+func distanceToSimilarity(distance DistanceFunction, value float32) (float32, bool) {
+	similarity := float32(cosineFromDistance(distance, value))
+	return similarity, similarity < 0
+}
+
+// meetsMinSimilarity 检查相似度是否达到最小相似度（未设置最小相似度时不过滤）
+func meetsMinSimilarity(similarity, minSimilarity float32) bool {
+	return minSimilarity <= 0 || similarity >= minSimilarity
+}
+
+// clampUnit 将分数限制在[0,1]范围内
+func clampUnit(score float64) float64 {
+	return math.Max(0, math.Min(1, score))
+}
+
+// CalculateCosineSimilarity 计算余弦相似度（已归一化到0-1，零向量返回0）
 func (sc *SimilarityCalculator) CalculateCosineSimilarity(vector1, vector2 []float32) (float64, error) {
+	cosine, err := sc.CalculateCosine(vector1, vector2)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(cosine) {
+		return 0, nil
+	}
+
+	// 转换到[0, 1]范围
+	return sc.Normalize(SimilarityTypeCosine, cosine), nil
+}
+
+// CalculateCosine 计算原始余弦值[-1,1]，任一向量为零向量时返回NaN
+func (sc *SimilarityCalculator) CalculateCosine(vector1, vector2 []float32) (float64, error) {
 	if len(vector1) != len(vector2) {
 		return 0, errors.ErrValidationFailed("vectors", "dimensions must match")
 	}
@@ -74,21 +170,28 @@ func (sc *SimilarityCalculator) CalculateCosineSimilarity(vector1, vector2 []flo
 
 	// 避免除零
 	if norm1 == 0 || norm2 == 0 {
-		return 0, nil
+		return math.NaN(), nil
 	}
 
-	// 计算余弦相似度
-	similarity := dotProduct / (norm1 * norm2)
+	return dotProduct / (norm1 * norm2), nil
+}
 
-	// 确保结果在[-1, 1]范围内
-	if similarity > 1.0 {
-		similarity = 1.0
-	} else if similarity < -1.0 {
-		similarity = -1.0
+// normalizeL2 返回L2归一化为单位长度的向量副本，零向量或空向量无法归一化时原样返回false
+func normalizeL2(vector []float32) ([]float32, bool) {
+	norm := 0.0
+	for _, value := range vector {
+		norm += float64(value) * float64(value)
+	}
+	if norm == 0 || math.IsNaN(norm) || math.IsInf(norm, 0) {
+		return vector, false
 	}
 
-	// 转换到[0, 1]范围
-	return (similarity + 1.0) / 2.0, nil
+	norm = math.Sqrt(norm)
+	normalized := make([]float32, len(vector))
+	for i, value := range vector {
+		normalized[i] = float32(float64(value) / norm)
+	}
+	return normalized, true
 }
 
 // CalculateEuclideanDistance 计算欧氏距离
@@ -115,9 +218,7 @@ func (sc *SimilarityCalculator) CalculateEuclideanDistance(vector1, vector2 []fl
 
 // EuclideanDistanceToSimilarity 将欧氏距离转换为相似度
 func (sc *SimilarityCalculator) EuclideanDistanceToSimilarity(distance float64) float64 {
-	// 使用指数衰减函数将距离转换为相似度
-	// similarity = e^(-distance)
-	return math.Exp(-distance)
+	return sc.Normalize(SimilarityTypeEuclidean, distance)
 }
 
 // CalculateDotProductSimilarity 计算点积相似度
@@ -160,33 +261,52 @@ func (sc *SimilarityCalculator) CalculateManhattanDistance(vector1, vector2 []fl
 
 // ManhattanDistanceToSimilarity 将曼哈顿距离转换为相似度
 func (sc *SimilarityCalculator) ManhattanDistanceToSimilarity(distance float64) float64 {
-	// 使用反比例函数将距离转换为相似度
-	// similarity = 1 / (1 + distance)
-	return 1.0 / (1.0 + distance)
+	return sc.Normalize(SimilarityTypeManhattan, distance)
 }
 
-// CalculateSimilarity 根据类型计算相似度
+// CalculateSimilarity 根据类型计算相似度（已归一化到0-1）
 func (sc *SimilarityCalculator) CalculateSimilarity(vector1, vector2 []float32, simType SimilarityType) (float64, error) {
+	score, err := sc.CalculateScore(vector1, vector2, simType)
+	if err != nil {
+		return 0, err
+	}
+	return score.Normalized, nil
+}
+
+// SimilarityScore 相似度类型的原始输出及其归一化分数
+type SimilarityScore struct {
+	Raw        float64 // 原始值：余弦值、点积，或欧氏/曼哈顿距离
+	Normalized float64 // 归一化到0-1的分数，越高越相似
+}
+
+// CalculateScore 根据类型计算原始值和归一化分数（余弦遇到零向量时两者均为0）
+func (sc *SimilarityCalculator) CalculateScore(vector1, vector2 []float32, simType SimilarityType) (*SimilarityScore, error) {
+	var raw float64
+	var err error
+
 	switch simType {
 	case SimilarityTypeCosine:
-		return sc.CalculateCosineSimilarity(vector1, vector2)
-	case SimilarityTypeEuclidean:
-		distance, err := sc.CalculateEuclideanDistance(vector1, vector2)
-		if err != nil {
-			return 0, err
+		raw, err = sc.CalculateCosine(vector1, vector2)
+		if err == nil && math.IsNaN(raw) {
+			return &SimilarityScore{}, nil
 		}
-		return sc.EuclideanDistanceToSimilarity(distance), nil
+	case SimilarityTypeEuclidean:
+		raw, err = sc.CalculateEuclideanDistance(vector1, vector2)
 	case SimilarityTypeDotProduct:
-		return sc.CalculateDotProductSimilarity(vector1, vector2)
+		raw, err = sc.CalculateDotProductSimilarity(vector1, vector2)
 	case SimilarityTypeManhattan:
-		distance, err := sc.CalculateManhattanDistance(vector1, vector2)
-		if err != nil {
-			return 0, err
-		}
-		return sc.ManhattanDistanceToSimilarity(distance), nil
+		raw, err = sc.CalculateManhattanDistance(vector1, vector2)
 	default:
-		return 0, errors.ErrValidationFailed("similarity_type", "unsupported similarity type")
+		return nil, errors.ErrValidationFailed("similarity_type", "unsupported similarity type")
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &SimilarityScore{
+		Raw:        raw,
+		Normalized: sc.Normalize(simType, raw),
+	}, nil
 }
 
 // BatchCalculateSimilarity 批量计算相似度
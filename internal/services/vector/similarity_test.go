@@ -0,0 +1,66 @@
+package vector
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+)
+
+// TestSimilarityCalculator_Normalize 测试各相似度类型归一化到0-1并保持排序
+func TestSimilarityCalculator_Normalize(t *testing.T) {
+	calc := NewSimilarityCalculator()
+
+	// 原始值按相似程度从低到高排列（距离类型越小越相似）
+	rawByType := map[SimilarityType][]float64{
+		SimilarityTypeCosine:     {-1, -0.5, 0, 0.3, 0.9, 1},
+		SimilarityTypeDotProduct: {-5, -1, -0.2, 0, 0.7, 1, 12},
+		SimilarityTypeEuclidean:  {10, 2, 1.5, 0.8, 0.1, 0},
+		SimilarityTypeManhattan:  {100, 10, 3, 1, 0.2, 0},
+	}
+
+	for simType, raws := range rawByType {
+		t.Run(string(simType)+"分数在[0,1]内且保持排序", func(t *testing.T) {
+			previous := -1.0
+			for _, raw := range raws {
+				normalized := calc.Normalize(simType, raw)
+				assert.GreaterOrEqual(t, normalized, 0.0, "raw=%v", raw)
+				assert.LessOrEqual(t, normalized, 1.0, "raw=%v", raw)
+				assert.GreaterOrEqual(t, normalized, previous, "raw=%v", raw)
+				previous = normalized
+			}
+		})
+	}
+
+	t.Run("单位向量的点积和欧氏距离与余弦相似度一致", func(t *testing.T) {
+		query := []float32{0.6, 0.8, 0}
+		candidates := [][]float32{{1, 0, 0}, {0, 1, 0}, {0.6, 0.8, 0}, {0, 0, -1}}
+
+		for _, candidate := range candidates {
+			cosine, err := calc.CalculateSimilarity(query, candidate, SimilarityTypeCosine)
+			require.NoError(t, err)
+			dot, err := calc.CalculateSimilarity(query, candidate, SimilarityTypeDotProduct)
+			require.NoError(t, err)
+			euclidean, err := calc.CalculateSimilarity(query, candidate, SimilarityTypeEuclidean)
+			require.NoError(t, err)
+
+			assert.InDelta(t, cosine, dot, 1e-6)
+			assert.InDelta(t, cosine, euclidean, 1e-6)
+		}
+	})
+
+	t.Run("按配置的向量模长换算", func(t *testing.T) {
+		scaled := &SimilarityCalculator{
+			normalization: similarityNormalizationFrom(&config.SimilarityNormalizationConfig{VectorNorm: 2}),
+		}
+		assert.InDelta(t, 0.75, scaled.Normalize(SimilarityTypeDotProduct, 2), 1e-9)
+		assert.InDelta(t, 0.75, scaled.Normalize(SimilarityTypeEuclidean, 2), 1e-9)
+	})
+
+	t.Run("NaN映射为0", func(t *testing.T) {
+		assert.Equal(t, 0.0, calc.Normalize(SimilarityTypeCosine, math.NaN()))
+	})
+}
@@ -0,0 +1,41 @@
+package vector
+
+import "strings"
+
+// SnippetSource 搜索结果片段的来源
+type SnippetSource string
+
+const (
+	SnippetSourceComputed SnippetSource = "computed" // 从原文截取包含查询词的片段（需要获取原文）
+	SnippetSourceSummary  SnippetSource = "summary"  // 使用索引时保存的摘要，没有摘要时回退到computed
+)
+
+// IsValidSnippetSource 检查片段来源是否有效
+func IsValidSnippetSource(source SnippetSource) bool {
+	switch source {
+	case SnippetSourceComputed, SnippetSourceSummary:
+		return true
+	default:
+		return false
+	}
+}
+
+// storedSummarySnippet 从元数据中读取已保存的摘要作为片段：优先一句话摘要，其次段落摘要
+func storedSummarySnippet(metadata map[string]interface{}) string {
+	for _, key := range []string{"summary_oneline", MetadataKeySummaryParagraph} {
+		if summary, ok := metadata[key].(string); ok && strings.TrimSpace(summary) != "" {
+			return summary
+		}
+	}
+	return ""
+}
+
+// resultSnippet 按片段来源生成搜索结果片段，summary来源且有已保存的摘要时不使用原文
+func (se *SearchEngine) resultSnippet(content string, metadata map[string]interface{}, options *SearchOptions) string {
+	if options.SnippetSource == SnippetSourceSummary {
+		if snippet := storedSummarySnippet(metadata); snippet != "" {
+			return snippet
+		}
+	}
+	return se.generateContentSummary(content, options.Query)
+}
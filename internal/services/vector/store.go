@@ -0,0 +1,40 @@
+package vector
+
+import (
+	"context"
+)
+
+// VectorStore 向量存储接口，搜索引擎和推荐系统通过它访问向量数据库
+type VectorStore interface {
+	// Search 执行相似度搜索
+	Search(ctx context.Context, query *SearchQuery) (*SearchResult, error)
+	// AddDocument 添加文档
+	AddDocument(ctx context.Context, doc *VectorDocument) error
+	// AddDocuments 批量添加文档
+	AddDocuments(ctx context.Context, docs []*VectorDocument) error
+	// GetDocument 根据ID获取文档，不存在时返回资源不存在错误
+	GetDocument(ctx context.Context, id string) (*VectorDocument, error)
+	// GetDocuments 根据ID批量获取文档（不存在的ID会被忽略）
+	GetDocuments(ctx context.Context, ids []string) ([]*VectorDocument, error)
+	// GetDocumentsByFilter 按元数据过滤条件获取文档（不返回向量）
+	GetDocumentsByFilter(ctx context.Context, filter map[string]interface{}, limit int) ([]*VectorDocument, error)
+	// KeywordSearch 获取内容包含任一关键词的文档（返回向量，不计算相关性，由调用方排序）
+	KeywordSearch(ctx context.Context, query *KeywordQuery) ([]*VectorDocument, error)
+	// GetVectorDimension 获取已存储向量的维度（为空时返回0）
+	GetVectorDimension(ctx context.Context) (int, error)
+	// UpdateDocument 更新文档
+	UpdateDocument(ctx context.Context, doc *VectorDocument) error
+	// UpdateDocumentMetadata 仅更新文档元数据（按键合并），不修改向量和内容
+	UpdateDocumentMetadata(ctx context.Context, id string, metadata map[string]interface{}) error
+	// DeleteDocument 删除文档
+	DeleteDocument(ctx context.Context, id string) error
+	// GetCollectionInfo 获取集合信息
+	GetCollectionInfo(ctx context.Context) (map[string]interface{}, error)
+	// HealthCheck 健康检查
+	HealthCheck(ctx context.Context) error
+	// Close 关闭存储连接
+	Close() error
+}
+
+// ChromaClient 实现 VectorStore
+var _ VectorStore = (*ChromaClient)(nil)
@@ -0,0 +1,118 @@
+package vector
+
+import (
+	"context"
+	"sort"
+
+	"memoro/internal/errors"
+)
+
+// threadScanLimit 读取线程消息时单次读取的文档上限
+const threadScanLimit = 10000
+
+// ThreadMessages 获取用户会话线程中的消息文档（不含线程汇总文档和已删除文档），按创建时间升序，limit>0时只保留最近的limit条
+func (se *SearchEngine) ThreadMessages(ctx context.Context, userID, threadID string, limit int) ([]*VectorDocument, error) {
+	if userID == "" {
+		return nil, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+	if threadID == "" {
+		return nil, errors.ErrValidationFailed("thread_id", "cannot be empty")
+	}
+
+	documents, err := se.store.GetDocumentsByFilter(ctx, map[string]interface{}{
+		"user_id":           userID,
+		MetadataKeyThreadID: threadID,
+	}, threadScanLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*VectorDocument, 0, len(documents))
+	for _, doc := range documents {
+		if rollup, _ := doc.Metadata[MetadataKeyThreadRollup].(bool); rollup {
+			continue
+		}
+		if deleted, _ := doc.Metadata[MetadataKeyDeleted].(bool); deleted {
+			continue
+		}
+		messages = append(messages, doc)
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		left, right := threadTimestamp(messages[i]), threadTimestamp(messages[j])
+		if left != right {
+			return left < right
+		}
+		return messages[i].ID < messages[j].ID
+	})
+
+	if limit > 0 && len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages, nil
+}
+
+// threadTimestamp 获取线程消息的毫秒时间戳，缺少时使用秒级创建时间
+func threadTimestamp(doc *VectorDocument) int64 {
+	if timestamp, ok := toFloat64(doc.Metadata[MetadataKeyThreadTimestamp]); ok {
+		return int64(timestamp)
+	}
+	return doc.CreatedAt.UnixMilli()
+}
+
+// collapseThreads 将同一会话线程的结果折叠为一个结果：有线程汇总文档时以其为代表，否则以排名最靠前的消息为代表，
+// 代表结果占据该线程排名最靠前的位置，返回折叠后的结果和被折叠的消息数量
+func collapseThreads(results []*SearchResultItem) ([]*SearchResultItem, int) {
+	representatives := make(map[string]*SearchResultItem)
+	for _, result := range results {
+		threadID := resultThreadID(result)
+		if threadID == "" {
+			continue
+		}
+		current, exists := representatives[threadID]
+		if !exists || (isThreadRollup(result) && !isThreadRollup(current)) {
+			representatives[threadID] = result
+		}
+	}
+	if len(representatives) == 0 {
+		return results, 0
+	}
+
+	collapsed := make([]*SearchResultItem, 0, len(results))
+	placed := make(map[string]bool, len(representatives))
+	collapsedCount := 0
+	for _, result := range results {
+		threadID := resultThreadID(result)
+		if threadID == "" {
+			collapsed = append(collapsed, result)
+			continue
+		}
+
+		representative := representatives[threadID]
+		if !isThreadRollup(result) {
+			representative.ThreadMessages = append(representative.ThreadMessages, result.DocumentID)
+		}
+		if result != representative {
+			collapsedCount++
+		}
+
+		if !placed[threadID] {
+			collapsed = append(collapsed, representative)
+			placed[threadID] = true
+		}
+	}
+
+	return collapsed, collapsedCount
+}
+
+// resultThreadID 获取搜索结果所属的会话线程ID
+func resultThreadID(result *SearchResultItem) string {
+	threadID, _ := result.Metadata[MetadataKeyThreadID].(string)
+	return threadID
+}
+
+// isThreadRollup 判断搜索结果是否为线程汇总文档
+func isThreadRollup(result *SearchResultItem) bool {
+	rollup, _ := result.Metadata[MetadataKeyThreadRollup].(bool)
+	return rollup
+}
@@ -0,0 +1,733 @@
+package vector
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+)
+
+// DefaultTrendingWindow 请求未指定时使用的热门时间窗口名称
+const DefaultTrendingWindow = "default"
+
+// TrendingDecayCurve 热门新鲜度衰减曲线
+type TrendingDecayCurve string
+
+const (
+	TrendingDecayLinear      TrendingDecayCurve = "linear"      // 线性衰减，窗口末尾降为0
+	TrendingDecayExponential TrendingDecayCurve = "exponential" // 指数衰减，DecayScale为半衰期
+	TrendingDecayGaussian    TrendingDecayCurve = "gaussian"    // 高斯衰减，DecayScale为标准差
+)
+
+// TrendingWindow 热门时间窗口及其衰减方式
+type TrendingWindow struct {
+	TimeWindow time.Duration      // 统计时间窗口
+	DecayCurve TrendingDecayCurve // 新鲜度衰减曲线
+	DecayScale time.Duration      // 衰减尺度，为0时取时间窗口的1/4
+}
+
+// TrendingJobConfig 热门分数后台任务配置
+type TrendingJobConfig struct {
+	RefreshInterval time.Duration      // 重新计算间隔
+	TimeWindow      time.Duration      // 默认窗口的统计时间窗口
+	DecayCurve      TrendingDecayCurve // 默认窗口的衰减曲线
+	DecayScale      time.Duration      // 默认窗口的衰减尺度
+	MaxDocuments    int                // 每次扫描的最大文档数
+
+	Windows                map[string]TrendingWindow // 额外的命名时间窗口
+	UseInteractionVelocity bool                      // 参与度是否计入交互速度
+	VelocityWindow         time.Duration             // 交互速度统计窗口
+
+	AccessImportance *config.AccessImportanceConfig // 重要性与访问信号的混合配置（nil时只使用存储的重要性）
+}
+
+// DefaultTrendingJobConfig 默认热门分数任务配置
+func DefaultTrendingJobConfig() TrendingJobConfig {
+	return TrendingJobConfig{
+		RefreshInterval: 15 * time.Minute,    // 15分钟重新计算一次
+		TimeWindow:      30 * 24 * time.Hour, // 最近30天
+		DecayCurve:      TrendingDecayLinear,
+		MaxDocuments:    500,
+		Windows: map[string]TrendingWindow{
+			"hot": { // 当下热门
+				TimeWindow: 24 * time.Hour,
+				DecayCurve: TrendingDecayExponential,
+				DecayScale: 6 * time.Hour,
+			},
+			"week": { // 本周热门
+				TimeWindow: 7 * 24 * time.Hour,
+				DecayCurve: TrendingDecayExponential,
+				DecayScale: 2 * 24 * time.Hour,
+			},
+		},
+		VelocityWindow: 24 * time.Hour,
+	}
+}
+
+// windows 返回包含默认窗口在内的全部时间窗口
+func (c TrendingJobConfig) windows() map[string]TrendingWindow {
+	windows := make(map[string]TrendingWindow, len(c.Windows)+1)
+	for name, window := range c.Windows {
+		windows[name] = window
+	}
+	windows[DefaultTrendingWindow] = TrendingWindow{
+		TimeWindow: c.TimeWindow,
+		DecayCurve: c.DecayCurve,
+		DecayScale: c.DecayScale,
+	}
+	return windows
+}
+
+// trendingJobConfigFrom 从全局配置加载热门分数任务配置
+func trendingJobConfigFrom(cfg *config.Config) TrendingJobConfig {
+	jobConfig := DefaultTrendingJobConfig()
+	if cfg != nil && cfg.VectorDB.Trending != nil {
+		if cfg.VectorDB.Trending.RefreshInterval > 0 {
+			jobConfig.RefreshInterval = cfg.VectorDB.Trending.RefreshInterval
+		}
+		if cfg.VectorDB.Trending.TimeWindow > 0 {
+			jobConfig.TimeWindow = cfg.VectorDB.Trending.TimeWindow
+		}
+		if cfg.VectorDB.Trending.MaxDocuments > 0 {
+			jobConfig.MaxDocuments = cfg.VectorDB.Trending.MaxDocuments
+		}
+		if cfg.VectorDB.Trending.DecayCurve != "" {
+			jobConfig.DecayCurve = TrendingDecayCurve(cfg.VectorDB.Trending.DecayCurve)
+		}
+		if cfg.VectorDB.Trending.DecayScale > 0 {
+			jobConfig.DecayScale = cfg.VectorDB.Trending.DecayScale
+		}
+		if cfg.VectorDB.Trending.VelocityWindow > 0 {
+			jobConfig.VelocityWindow = cfg.VectorDB.Trending.VelocityWindow
+		}
+		jobConfig.UseInteractionVelocity = cfg.VectorDB.Trending.UseInteractionVelocity
+		for name, window := range cfg.VectorDB.Trending.Windows {
+			jobConfig.Windows[name] = TrendingWindow{
+				TimeWindow: window.TimeWindow,
+				DecayCurve: TrendingDecayCurve(window.DecayCurve),
+				DecayScale: window.DecayScale,
+			}
+		}
+	}
+	if cfg != nil {
+		jobConfig.AccessImportance = cfg.VectorDB.AccessImportance
+	}
+	return jobConfig
+}
+
+// interactionBucketSize 交互记录按该时长分桶，整桶落在统计范围内时直接使用桶内汇总
+const interactionBucketSize = time.Hour
+
+// defaultInteractionRetention 未配置时交互记录的保留时长（默认配置下最长的统计窗口）
+const defaultInteractionRetention = 30 * 24 * time.Hour
+
+// InteractionStore 用户交互记录存储（内存实现）。
+// 记录按小时分桶，超过保留时长（各统计窗口中最长的一个）的桶被丢弃，内存和扫描开销不随运行时间增长
+type InteractionStore struct {
+	mu        sync.RWMutex
+	buckets   []*interactionBucket // 按起始时间升序
+	retention time.Duration
+	now       func() time.Time
+}
+
+// interactionBucket 一个时间桶内的交互记录和按文档汇总的访问统计
+type interactionBucket struct {
+	start        time.Time
+	interactions []*Interaction         // 按记录顺序
+	documents    map[string]*AccessStat // 文档ID -> 桶内交互次数和最近交互时间
+}
+
+// Interaction 用户交互记录
+type Interaction struct {
+	UserID     string    `json:"user_id"`     // 用户ID
+	DocumentID string    `json:"document_id"` // 文档ID
+	Timestamp  time.Time `json:"timestamp"`   // 交互时间
+}
+
+// NewInteractionStore 创建交互记录存储（使用默认保留时长）
+func NewInteractionStore() *InteractionStore {
+	return NewInteractionStoreWithRetention(defaultInteractionRetention)
+}
+
+// NewInteractionStoreWithConfig 创建交互记录存储，保留时长取配置中最长的统计窗口
+func NewInteractionStoreWithConfig(cfg *config.Config) *InteractionStore {
+	return NewInteractionStoreWithRetention(interactionRetentionFrom(cfg))
+}
+
+// NewInteractionStoreWithRetention 创建指定保留时长的交互记录存储
+func NewInteractionStoreWithRetention(retention time.Duration) *InteractionStore {
+	if retention <= 0 {
+		retention = defaultInteractionRetention
+	}
+	return &InteractionStore{
+		buckets:   make([]*interactionBucket, 0),
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// interactionRetentionFrom 计算交互记录需要保留的时长：热门窗口、交互速度窗口和访问信号窗口中最长的一个
+func interactionRetentionFrom(cfg *config.Config) time.Duration {
+	retention := defaultAccessImportanceWindow
+	if cfg != nil && cfg.VectorDB.AccessImportance != nil && cfg.VectorDB.AccessImportance.Window > retention {
+		retention = cfg.VectorDB.AccessImportance.Window
+	}
+
+	jobConfig := trendingJobConfigFrom(cfg)
+	for _, window := range jobConfig.windows() {
+		if window.TimeWindow > retention {
+			retention = window.TimeWindow
+		}
+	}
+	if jobConfig.UseInteractionVelocity && jobConfig.VelocityWindow > retention {
+		retention = jobConfig.VelocityWindow
+	}
+	return retention
+}
+
+// RecordInteraction 记录一次用户交互，早于保留时长的交互被忽略
+func (s *InteractionStore) RecordInteraction(userID, documentID string, timestamp time.Time) {
+	if documentID == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if timestamp.IsZero() {
+		timestamp = now
+	}
+	cutoff := now.Add(-s.retention)
+	if timestamp.Before(cutoff) {
+		return
+	}
+
+	bucket := s.bucketFor(timestamp)
+	bucket.interactions = append(bucket.interactions, &Interaction{
+		UserID:     userID,
+		DocumentID: documentID,
+		Timestamp:  timestamp,
+	})
+	stat, exists := bucket.documents[documentID]
+	if !exists {
+		stat = &AccessStat{}
+		bucket.documents[documentID] = stat
+	}
+	stat.Count++
+	if timestamp.After(stat.LastAccess) {
+		stat.LastAccess = timestamp
+	}
+
+	s.prune(cutoff)
+}
+
+// bucketFor 获取时间点所在的桶，不存在时按起始时间顺序插入（调用方持有写锁）
+func (s *InteractionStore) bucketFor(timestamp time.Time) *interactionBucket {
+	start := timestamp.Truncate(interactionBucketSize)
+
+	// 交互通常按时间顺序到达，目标桶一般是最后一个
+	index := sort.Search(len(s.buckets), func(i int) bool {
+		return !s.buckets[i].start.Before(start)
+	})
+	if index < len(s.buckets) && s.buckets[index].start.Equal(start) {
+		return s.buckets[index]
+	}
+
+	bucket := &interactionBucket{
+		start:        start,
+		interactions: make([]*Interaction, 0, 1),
+		documents:    make(map[string]*AccessStat),
+	}
+	s.buckets = append(s.buckets, nil)
+	copy(s.buckets[index+1:], s.buckets[index:])
+	s.buckets[index] = bucket
+	return bucket
+}
+
+// prune 丢弃整桶早于cutoff的交互记录（调用方持有写锁）
+func (s *InteractionStore) prune(cutoff time.Time) {
+	expired := 0
+	for expired < len(s.buckets) && !s.buckets[expired].start.Add(interactionBucketSize).After(cutoff) {
+		expired++
+	}
+	if expired > 0 {
+		s.buckets = append(s.buckets[:0:0], s.buckets[expired:]...)
+	}
+}
+
+// eachSince 按时间顺序遍历since之后的交互：整桶都在范围内时只回调桶内汇总，
+// 跨越since的桶逐条回调交互记录（调用方持有读锁）
+func (s *InteractionStore) eachSince(since time.Time, onBucket func(*interactionBucket), onInteraction func(*Interaction)) {
+	for _, bucket := range s.buckets {
+		if !bucket.start.Add(interactionBucketSize).After(since) {
+			continue
+		}
+		if !bucket.start.Before(since) {
+			onBucket(bucket)
+			continue
+		}
+		for _, interaction := range bucket.interactions {
+			if !interaction.Timestamp.Before(since) {
+				onInteraction(interaction)
+			}
+		}
+	}
+}
+
+// CountsSince 统计指定时间之后每个文档的交互次数
+func (s *InteractionStore) CountsSince(since time.Time) map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	s.eachSince(since, func(bucket *interactionBucket) {
+		for documentID, stat := range bucket.documents {
+			counts[documentID] += stat.Count
+		}
+	}, func(interaction *Interaction) {
+		counts[interaction.DocumentID]++
+	})
+	return counts
+}
+
+// LastInteractions 获取保留时长内每个文档最近一次交互的时间
+func (s *InteractionStore) LastInteractions() map[string]time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	last := make(map[string]time.Time)
+	for _, bucket := range s.buckets {
+		for documentID, stat := range bucket.documents {
+			if stat.LastAccess.After(last[documentID]) {
+				last[documentID] = stat.LastAccess
+			}
+		}
+	}
+	return last
+}
+
+// AccessStat 文档的访问统计
+type AccessStat struct {
+	Count      int       // 统计窗口内的访问次数
+	LastAccess time.Time // 最近一次访问时间
+}
+
+// AccessStats 统计指定时间之后每个文档的访问次数和最近访问时间，userID为空时统计全部用户
+func (s *InteractionStore) AccessStats(userID string, since time.Time) map[string]*AccessStat {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make(map[string]*AccessStat)
+	add := func(documentID string, count int, lastAccess time.Time) {
+		stat, exists := stats[documentID]
+		if !exists {
+			stat = &AccessStat{}
+			stats[documentID] = stat
+		}
+		stat.Count += count
+		if lastAccess.After(stat.LastAccess) {
+			stat.LastAccess = lastAccess
+		}
+	}
+	addInteraction := func(interaction *Interaction) {
+		if userID != "" && interaction.UserID != userID {
+			return
+		}
+		add(interaction.DocumentID, 1, interaction.Timestamp)
+	}
+
+	s.eachSince(since, func(bucket *interactionBucket) {
+		// 桶内汇总不区分用户，按用户统计时逐条扫描
+		if userID != "" {
+			for _, interaction := range bucket.interactions {
+				addInteraction(interaction)
+			}
+			return
+		}
+		for documentID, stat := range bucket.documents {
+			add(documentID, stat.Count, stat.LastAccess)
+		}
+	}, addInteraction)
+	return stats
+}
+
+// UserHistory 获取用户最近交互的文档ID（按最近交互时间倒序、去重，最多limit个）和每个文档的交互次数
+func (s *InteractionStore) UserHistory(userID string, limit int) ([]string, map[string]int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recent := make([]string, 0)
+	counts := make(map[string]int)
+	for b := len(s.buckets) - 1; b >= 0; b-- {
+		interactions := s.buckets[b].interactions
+		for i := len(interactions) - 1; i >= 0; i-- {
+			interaction := interactions[i]
+			if interaction.UserID != userID {
+				continue
+			}
+			if counts[interaction.DocumentID] == 0 && (limit <= 0 || len(recent) < limit) {
+				recent = append(recent, interaction.DocumentID)
+			}
+			counts[interaction.DocumentID]++
+		}
+	}
+	return recent, counts
+}
+
+// TrendingDocumentSource 热门计算的文档来源，userID为空时扫描全部用户的文档
+type TrendingDocumentSource func(ctx context.Context, userID string, timeRange *TimeRange, limit int) ([]*VectorDocument, error)
+
+// TrendingScore 预计算的文档热门分数
+type TrendingScore struct {
+	Document         *VectorDocument `json:"document"`          // 文档
+	Score            float64         `json:"score"`             // 热门分数
+	InteractionCount int             `json:"interaction_count"` // 时间窗口内交互次数
+	Velocity         float64         `json:"velocity"`          // 交互速度（每小时交互次数）
+}
+
+// TrendingJob 定期重新计算热门分数的后台任务
+type TrendingJob struct {
+	source       TrendingDocumentSource
+	interactions *InteractionStore
+	config       TrendingJobConfig
+	logger       *logger.Logger
+
+	mu         sync.RWMutex
+	scores     map[string][]*TrendingScore            // 按时间窗口名称存储
+	userScores map[string]map[string][]*TrendingScore // 用户ID -> 按时间窗口名称存储，每次全局计算后清空
+	lastRun    time.Time
+	runMutex   sync.Mutex
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewTrendingJob 创建热门分数后台任务
+func NewTrendingJob(source TrendingDocumentSource, interactions *InteractionStore, jobConfig TrendingJobConfig) *TrendingJob {
+	if interactions == nil {
+		interactions = NewInteractionStore()
+	}
+
+	return &TrendingJob{
+		source:       source,
+		interactions: interactions,
+		config:       jobConfig,
+		logger:       logger.NewLogger("trending-job"),
+		scores:       make(map[string][]*TrendingScore),
+		userScores:   make(map[string]map[string][]*TrendingScore),
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// Start 启动定期计算协程
+func (j *TrendingJob) Start() {
+	if j.config.RefreshInterval <= 0 {
+		return
+	}
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		ticker := time.NewTicker(j.config.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), j.config.RefreshInterval)
+				if err := j.RunNow(ctx, nil); err != nil {
+					j.logger.Warn("Trending refresh failed", logger.Fields{
+						"error": err.Error(),
+					})
+				}
+				cancel()
+			case <-j.stopChan:
+				return
+			}
+		}
+	}()
+
+	j.logger.Info("Trending job started", logger.Fields{
+		"refresh_interval": j.config.RefreshInterval,
+		"time_window":      j.config.TimeWindow,
+		"max_documents":    j.config.MaxDocuments,
+	})
+}
+
+// Stop 停止定期计算协程
+func (j *TrendingJob) Stop() {
+	j.stopOnce.Do(func() {
+		close(j.stopChan)
+	})
+	j.wg.Wait()
+}
+
+// RunNow 立即重新计算热门分数，同时清空按用户计算的分数；progress非nil时按已计算的时间窗口上报进度百分比
+func (j *TrendingJob) RunNow(ctx context.Context, progress func(percent float64)) error {
+	j.runMutex.Lock()
+	defer j.runMutex.Unlock()
+
+	startTime := time.Now()
+	allScores, interactionTotal, err := j.compute(ctx, "", startTime, progress)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	j.scores = allScores
+	j.userScores = make(map[string]map[string][]*TrendingScore)
+	j.lastRun = startTime
+	j.mu.Unlock()
+
+	j.logger.Debug("Trending scores recomputed", logger.Fields{
+		"documents":    len(allScores[DefaultTrendingWindow]),
+		"windows":      len(allScores),
+		"interactions": interactionTotal,
+		"duration_ms":  time.Since(startTime).Milliseconds(),
+	})
+
+	return nil
+}
+
+// compute 扫描文档并计算各时间窗口的热门分数，userID非空时只扫描该用户的文档；
+// 扫描文档计为一步，之后每个时间窗口计为一步上报进度
+func (j *TrendingJob) compute(ctx context.Context, userID string, startTime time.Time, progress func(percent float64)) (map[string][]*TrendingScore, int, error) {
+	windows := j.config.windows()
+
+	// 按最长的时间窗口扫描一次文档，各窗口再按自身范围筛选
+	longest := time.Duration(0)
+	for _, window := range windows {
+		if window.TimeWindow > longest {
+			longest = window.TimeWindow
+		}
+	}
+
+	documents, err := j.source(ctx, userID, &TimeRange{
+		StartTime: startTime.Add(-longest),
+		EndTime:   startTime,
+	}, j.config.MaxDocuments)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var velocities map[string]float64
+	if j.config.UseInteractionVelocity && j.config.VelocityWindow > 0 {
+		velocities = make(map[string]float64)
+		for documentID, count := range j.interactions.CountsSince(startTime.Add(-j.config.VelocityWindow)) {
+			velocities[documentID] = float64(count) / j.config.VelocityWindow.Hours()
+		}
+	}
+
+	access := newAccessImportance(j.config.AccessImportance, j.interactions, "", startTime)
+
+	steps := len(windows) + 1
+	reportProgress(progress, 1, steps)
+
+	allScores := make(map[string][]*TrendingScore, len(windows))
+	interactionTotal := 0
+	for name, window := range windows {
+		timeRange := &TimeRange{
+			StartTime: startTime.Add(-window.TimeWindow),
+			EndTime:   startTime,
+		}
+
+		windowDocuments := make([]*VectorDocument, 0, len(documents))
+		for _, doc := range documents {
+			if !doc.CreatedAt.Before(timeRange.StartTime) {
+				windowDocuments = append(windowDocuments, doc)
+			}
+		}
+
+		interactionCounts := j.interactions.CountsSince(timeRange.StartTime)
+		if name == DefaultTrendingWindow {
+			interactionTotal = len(interactionCounts)
+		}
+		analysis := analyzeTrending(windowDocuments, timeRange, interactionCounts, velocities, window, access)
+
+		scores := make([]*TrendingScore, 0, len(analysis.DocumentScores))
+		for _, doc := range windowDocuments {
+			score, exists := analysis.DocumentScores[doc.ID]
+			if !exists {
+				continue
+			}
+			scores = append(scores, &TrendingScore{
+				Document:         doc,
+				Score:            score,
+				InteractionCount: analysis.InteractionCount[doc.ID],
+				Velocity:         analysis.InteractionVelocity[doc.ID],
+			})
+		}
+
+		sort.SliceStable(scores, func(a, b int) bool {
+			return scores[a].Score > scores[b].Score
+		})
+		allScores[name] = scores
+		reportProgress(progress, len(allScores)+1, steps)
+	}
+
+	return allScores, interactionTotal, nil
+}
+
+// Scores 获取默认时间窗口预计算的热门分数（按分数降序）
+func (j *TrendingJob) Scores() []*TrendingScore {
+	scores, _ := j.WindowScores(DefaultTrendingWindow)
+	return scores
+}
+
+// WindowScores 获取指定时间窗口预计算的热门分数（按分数降序），窗口未配置时返回false
+func (j *TrendingJob) WindowScores(window string) ([]*TrendingScore, bool) {
+	if !j.HasWindow(window) {
+		return nil, false
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	scores := make([]*TrendingScore, len(j.scores[window]))
+	copy(scores, j.scores[window])
+	return scores, true
+}
+
+// UserWindowScores 获取指定用户在时间窗口内的热门分数（按分数降序）。
+// 全局计算只扫描MaxDocuments篇文档，会漏掉大部分用户的内容，因此按用户过滤扫描，
+// 结果缓存到下一次全局计算；窗口未配置时返回false
+func (j *TrendingJob) UserWindowScores(ctx context.Context, userID, window string) ([]*TrendingScore, bool, error) {
+	if !j.HasWindow(window) {
+		return nil, false, nil
+	}
+
+	j.mu.RLock()
+	cached, exists := j.userScores[userID]
+	j.mu.RUnlock()
+
+	if !exists {
+		j.runMutex.Lock()
+		j.mu.RLock()
+		cached, exists = j.userScores[userID]
+		j.mu.RUnlock()
+		if !exists {
+			allScores, _, err := j.compute(ctx, userID, time.Now(), nil)
+			if err != nil {
+				j.runMutex.Unlock()
+				return nil, true, err
+			}
+			j.mu.Lock()
+			j.userScores[userID] = allScores
+			j.mu.Unlock()
+			cached = allScores
+		}
+		j.runMutex.Unlock()
+	}
+
+	scores := make([]*TrendingScore, len(cached[window]))
+	copy(scores, cached[window])
+	return scores, true, nil
+}
+
+// HasWindow 检查时间窗口是否已配置
+func (j *TrendingJob) HasWindow(window string) bool {
+	if window == DefaultTrendingWindow {
+		return true
+	}
+	_, exists := j.config.Windows[window]
+	return exists
+}
+
+// LastRun 获取最近一次计算时间
+func (j *TrendingJob) LastRun() time.Time {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.lastRun
+}
+
+// analyzeTrending 根据新鲜度衰减、重要性和交互参与度计算热门分数（velocities非空时参与度计入交互速度，access非空时重要性混合访问信号）
+func analyzeTrending(documents []*VectorDocument, timeRange *TimeRange, interactionCounts map[string]int, velocities map[string]float64, window TrendingWindow, access *accessImportance) *TrendingAnalysis {
+	documentScores := make(map[string]float64)
+	interactionCount := make(map[string]int)
+	interactionVelocity := make(map[string]float64)
+
+	timeWindow := timeRange.EndTime.Sub(timeRange.StartTime)
+
+	maxInteractions := 0
+	maxVelocity := 0.0
+	for _, doc := range documents {
+		if count := interactionCounts[doc.ID]; count > maxInteractions {
+			maxInteractions = count
+		}
+		if velocity := velocities[doc.ID]; velocity > maxVelocity {
+			maxVelocity = velocity
+		}
+	}
+
+	for _, doc := range documents {
+		// 基于创建时间的新鲜度分数
+		freshnessScore := decayScore(timeRange.EndTime.Sub(doc.CreatedAt), timeWindow, window.DecayCurve, window.DecayScale)
+
+		// 基于重要性的分数
+		importance := 0.5 // 默认重要性
+		if importanceVal, exists := doc.Metadata["importance_score"]; exists {
+			if score, ok := importanceVal.(float64); ok {
+				importance = score / 10.0
+			}
+		}
+		importance, _ = access.blend(doc.ID, importance)
+
+		// 基于交互次数的参与度分数（按窗口内最大交互次数归一化）
+		engagementScore := 0.0
+		if maxInteractions > 0 {
+			engagementScore = float64(interactionCounts[doc.ID]) / float64(maxInteractions)
+		}
+
+		// 交互速度与交互次数各占参与度的一半
+		if velocities != nil {
+			velocityScore := 0.0
+			if maxVelocity > 0 {
+				velocityScore = velocities[doc.ID] / maxVelocity
+			}
+			engagementScore = engagementScore*0.5 + velocityScore*0.5
+			interactionVelocity[doc.ID] = velocities[doc.ID]
+		}
+
+		// 计算综合热门分数
+		trendingScore := freshnessScore*0.3 + importance*0.2 + engagementScore*0.5
+
+		documentScores[doc.ID] = trendingScore
+		interactionCount[doc.ID] = interactionCounts[doc.ID]
+	}
+
+	return &TrendingAnalysis{
+		DocumentScores:      documentScores,
+		TimeWindow:          timeWindow,
+		InteractionCount:    interactionCount,
+		InteractionVelocity: interactionVelocity,
+	}
+}
+
+// decayScore 按衰减曲线计算新鲜度分数（0-1）
+func decayScore(age, timeWindow time.Duration, curve TrendingDecayCurve, scale time.Duration) float64 {
+	if age <= 0 {
+		return 1.0
+	}
+	if timeWindow <= 0 {
+		return 0
+	}
+	if scale <= 0 {
+		scale = timeWindow / 4
+	}
+
+	switch curve {
+	case TrendingDecayExponential:
+		return math.Exp(-math.Ln2 * age.Hours() / scale.Hours())
+	case TrendingDecayGaussian:
+		ratio := age.Hours() / scale.Hours()
+		return math.Exp(-ratio * ratio / 2)
+	default:
+		return math.Max(0, 1.0-age.Hours()/timeWindow.Hours())
+	}
+}
@@ -0,0 +1,370 @@
+package vector
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/logger"
+)
+
+// newTestTrendingRecommender 创建使用固定文档来源的推荐系统
+func newTestTrendingRecommender(documents []*VectorDocument, jobConfig TrendingJobConfig) *Recommender {
+	interactions := NewInteractionStore()
+	source := func(ctx context.Context, userID string, timeRange *TimeRange, limit int) ([]*VectorDocument, error) {
+		matched := make([]*VectorDocument, 0, len(documents))
+		for _, doc := range documents {
+			if userID == "" || doc.Metadata["user_id"] == userID {
+				matched = append(matched, doc)
+			}
+			if limit > 0 && len(matched) == limit {
+				break
+			}
+		}
+		return matched, nil
+	}
+
+	return &Recommender{
+		similarityCalc: NewSimilarityCalculator(),
+		ranker:         NewRanker(),
+		interactions:   interactions,
+		trendingJob:    NewTrendingJob(source, interactions, jobConfig),
+		logger:         logger.NewLogger("recommender-test"),
+	}
+}
+
+// buildTrendingDocuments 构造热门测试文档
+func buildTrendingDocuments() []*VectorDocument {
+	now := time.Now()
+	return []*VectorDocument{
+		{
+			ID:        "doc-fresh",
+			Content:   "最新发布的内容",
+			Metadata:  map[string]interface{}{"user_id": "user-1"},
+			CreatedAt: now.Add(-1 * time.Hour),
+		},
+		{
+			ID:        "doc-popular",
+			Content:   "十天前发布但被频繁访问的内容",
+			Metadata:  map[string]interface{}{"user_id": "user-1"},
+			CreatedAt: now.Add(-10 * 24 * time.Hour),
+		},
+	}
+}
+
+// TestTrendingJob_ReflectsInteractions 测试热门推荐反映交互记录
+func TestTrendingJob_ReflectsInteractions(t *testing.T) {
+	ctx := context.Background()
+	req := &RecommendationRequest{
+		Type:                RecommendationTypeTrending,
+		MaxRecommendations:  10,
+		IncludeExplanations: true,
+	}
+
+	t.Run("无交互时新内容优先", func(t *testing.T) {
+		recommender := newTestTrendingRecommender(buildTrendingDocuments(), DefaultTrendingJobConfig())
+
+		recs, err := recommender.getTrendingRecommendations(ctx, req)
+		require.NoError(t, err)
+		require.Len(t, recs, 2)
+		assert.Equal(t, "doc-fresh", recs[0].DocumentID)
+	})
+
+	t.Run("任务运行后反映交互次数", func(t *testing.T) {
+		recommender := newTestTrendingRecommender(buildTrendingDocuments(), DefaultTrendingJobConfig())
+		require.NoError(t, recommender.RefreshTrending(ctx, nil))
+
+		for i := 0; i < 5; i++ {
+			recommender.RecordInteraction("user-2", "doc-popular")
+		}
+
+		// 任务重新运行前仍读取旧分数
+		recs, err := recommender.getTrendingRecommendations(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, "doc-fresh", recs[0].DocumentID)
+
+		require.NoError(t, recommender.RefreshTrending(ctx, nil))
+
+		recs, err = recommender.getTrendingRecommendations(ctx, req)
+		require.NoError(t, err)
+		require.Len(t, recs, 2)
+		assert.Equal(t, "doc-popular", recs[0].DocumentID)
+		assert.Equal(t, 5.0, recs[0].Explanation.FactorBreakdown["interaction_count"])
+	})
+
+	t.Run("按扫描和时间窗口上报进度", func(t *testing.T) {
+		recommender := newTestTrendingRecommender(buildTrendingDocuments(), DefaultTrendingJobConfig())
+
+		var progress []float64
+		require.NoError(t, recommender.RefreshTrending(ctx, func(percent float64) {
+			progress = append(progress, percent)
+		}))
+		require.NotEmpty(t, progress)
+		assert.IsNonDecreasing(t, progress)
+		assert.Equal(t, 100.0, progress[len(progress)-1])
+	})
+
+	t.Run("忽略时间窗口外的交互", func(t *testing.T) {
+		jobConfig := DefaultTrendingJobConfig()
+		jobConfig.TimeWindow = 30 * 24 * time.Hour
+		recommender := newTestTrendingRecommender(buildTrendingDocuments(), jobConfig)
+
+		recommender.interactions.RecordInteraction("user-2", "doc-popular", time.Now().Add(-60*24*time.Hour))
+		require.NoError(t, recommender.RefreshTrending(ctx, nil))
+
+		scores := recommender.trendingJob.Scores()
+		require.Len(t, scores, 2)
+		assert.Equal(t, "doc-fresh", scores[0].Document.ID)
+		assert.Equal(t, 0, scores[1].InteractionCount)
+	})
+
+	t.Run("按用户过滤", func(t *testing.T) {
+		recommender := newTestTrendingRecommender(buildTrendingDocuments(), DefaultTrendingJobConfig())
+
+		recs, err := recommender.getTrendingRecommendations(ctx, &RecommendationRequest{
+			Type:   RecommendationTypeTrending,
+			UserID: "user-3",
+		})
+		require.NoError(t, err)
+		assert.Empty(t, recs)
+	})
+}
+
+// TestTrendingJob_PerUserScan 测试按用户的热门推荐不受全局扫描上限影响
+func TestTrendingJob_PerUserScan(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	documents := make([]*VectorDocument, 0)
+	for _, id := range []string{"a-1", "a-2", "a-3"} {
+		documents = append(documents, &VectorDocument{ID: id, Metadata: map[string]interface{}{"user_id": "user-a"}, CreatedAt: now.Add(-time.Hour)})
+	}
+	documents = append(documents, &VectorDocument{ID: "b-1", Metadata: map[string]interface{}{"user_id": "user-b"}, CreatedAt: now.Add(-time.Hour)})
+
+	jobConfig := DefaultTrendingJobConfig()
+	jobConfig.MaxDocuments = 2
+	recommender := newTestTrendingRecommender(documents, jobConfig)
+	require.NoError(t, recommender.RefreshTrending(ctx, nil))
+
+	// 全局扫描只读到user-a的前两篇文档
+	for _, score := range recommender.trendingJob.Scores() {
+		assert.NotEqual(t, "b-1", score.Document.ID)
+	}
+
+	recs, err := recommender.getTrendingRecommendations(ctx, &RecommendationRequest{
+		Type:   RecommendationTypeTrending,
+		UserID: "user-b",
+	})
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, "b-1", recs[0].DocumentID)
+
+	t.Run("全局重新计算后按用户重新扫描", func(t *testing.T) {
+		recommender.RecordInteraction("user-a", "b-1")
+		require.NoError(t, recommender.RefreshTrending(ctx, nil))
+
+		recs, err := recommender.getTrendingRecommendations(ctx, &RecommendationRequest{
+			Type:                RecommendationTypeTrending,
+			UserID:              "user-b",
+			IncludeExplanations: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, recs, 1)
+		assert.Equal(t, 1.0, recs[0].Explanation.FactorBreakdown["interaction_count"])
+	})
+}
+
+// TestInteractionStore_Retention 测试交互记录分桶统计和超过保留时长后的清理
+func TestInteractionStore_Retention(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+	store := NewInteractionStoreWithRetention(48 * time.Hour)
+	store.now = func() time.Time { return now }
+
+	store.RecordInteraction("user-1", "doc-1", now.Add(-40*time.Hour))
+	store.RecordInteraction("user-1", "doc-1", now.Add(-10*time.Minute))
+	store.RecordInteraction("user-2", "doc-1", now.Add(-20*time.Minute))
+	store.RecordInteraction("user-1", "doc-2", now.Add(-5*time.Minute))
+	store.RecordInteraction("user-1", "doc-old", now.Add(-72*time.Hour))
+
+	t.Run("早于保留时长的交互被忽略", func(t *testing.T) {
+		assert.NotContains(t, store.LastInteractions(), "doc-old")
+	})
+
+	t.Run("跨桶的统计范围精确到单条记录", func(t *testing.T) {
+		assert.Equal(t, map[string]int{"doc-1": 1, "doc-2": 1}, store.CountsSince(now.Add(-15*time.Minute)))
+		assert.Equal(t, map[string]int{"doc-1": 3, "doc-2": 1}, store.CountsSince(now.Add(-48*time.Hour)))
+
+		stats := store.AccessStats("user-1", now.Add(-48*time.Hour))
+		assert.Equal(t, 2, stats["doc-1"].Count)
+		assert.Equal(t, now.Add(-10*time.Minute), stats["doc-1"].LastAccess)
+	})
+
+	t.Run("按最近交互时间返回用户历史", func(t *testing.T) {
+		recent, counts := store.UserHistory("user-1", 0)
+		assert.Equal(t, []string{"doc-2", "doc-1"}, recent)
+		assert.Equal(t, 2, counts["doc-1"])
+	})
+
+	t.Run("时间推移后清理过期的桶", func(t *testing.T) {
+		now = now.Add(10 * time.Hour)
+		store.RecordInteraction("user-1", "doc-3", now)
+
+		assert.Len(t, store.buckets, 2)
+		_, counts := store.UserHistory("user-1", 0)
+		assert.Equal(t, 1, counts["doc-1"])
+		assert.Equal(t, 1, counts["doc-3"])
+	})
+}
+
+// TestTrendingJob_PeriodicRefresh 测试后台定期刷新
+func TestTrendingJob_PeriodicRefresh(t *testing.T) {
+	jobConfig := DefaultTrendingJobConfig()
+	jobConfig.RefreshInterval = 20 * time.Millisecond
+	recommender := newTestTrendingRecommender(buildTrendingDocuments(), jobConfig)
+
+	recommender.trendingJob.Start()
+	defer recommender.trendingJob.Stop()
+
+	recommender.RecordInteraction("user-2", "doc-popular")
+
+	assert.Eventually(t, func() bool {
+		scores := recommender.trendingJob.Scores()
+		return len(scores) == 2 && scores[0].Document.ID == "doc-popular"
+	}, time.Second, 10*time.Millisecond)
+}
+
+// TestTrendingJob_DecayCurves 测试不同衰减曲线下的热门排名
+func TestTrendingJob_DecayCurves(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	documents := []*VectorDocument{
+		{
+			ID:        "doc-important",
+			Content:   "半个月前发布的重要内容",
+			Metadata:  map[string]interface{}{"user_id": "user-1", "importance_score": 10.0},
+			CreatedAt: now.Add(-15 * 24 * time.Hour),
+		},
+		{
+			ID:        "doc-just-now",
+			Content:   "刚刚发布的普通内容",
+			Metadata:  map[string]interface{}{"user_id": "user-1", "importance_score": 0.0},
+			CreatedAt: now.Add(-1 * time.Hour),
+		},
+	}
+
+	rank := func(t *testing.T, jobConfig TrendingJobConfig) []string {
+		recommender := newTestTrendingRecommender(documents, jobConfig)
+		require.NoError(t, recommender.RefreshTrending(ctx, nil))
+
+		ids := make([]string, 0)
+		for _, score := range recommender.trendingJob.Scores() {
+			ids = append(ids, score.Document.ID)
+		}
+		return ids
+	}
+
+	t.Run("线性衰减下重要内容领先", func(t *testing.T) {
+		jobConfig := DefaultTrendingJobConfig()
+		jobConfig.DecayCurve = TrendingDecayLinear
+
+		assert.Equal(t, []string{"doc-important", "doc-just-now"}, rank(t, jobConfig))
+	})
+
+	t.Run("指数衰减下新内容领先", func(t *testing.T) {
+		jobConfig := DefaultTrendingJobConfig()
+		jobConfig.DecayCurve = TrendingDecayExponential
+		jobConfig.DecayScale = 48 * time.Hour
+
+		assert.Equal(t, []string{"doc-just-now", "doc-important"}, rank(t, jobConfig))
+	})
+
+	t.Run("衰减曲线取值", func(t *testing.T) {
+		window := 30 * 24 * time.Hour
+		assert.Equal(t, 1.0, decayScore(0, window, TrendingDecayLinear, 0))
+		assert.InDelta(t, 0.5, decayScore(15*24*time.Hour, window, TrendingDecayLinear, 0), 0.0001)
+		assert.InDelta(t, 0.5, decayScore(48*time.Hour, window, TrendingDecayExponential, 48*time.Hour), 0.0001)
+		assert.InDelta(t, math.Exp(-0.5), decayScore(48*time.Hour, window, TrendingDecayGaussian, 48*time.Hour), 0.0001)
+		assert.Equal(t, 0.0, decayScore(60*24*time.Hour, window, TrendingDecayLinear, 0))
+	})
+}
+
+// TestTrendingJob_NamedWindows 测试按名称选择热门时间窗口
+func TestTrendingJob_NamedWindows(t *testing.T) {
+	ctx := context.Background()
+	recommender := newTestTrendingRecommender(buildTrendingDocuments(), DefaultTrendingJobConfig())
+
+	t.Run("hot窗口只包含最近内容", func(t *testing.T) {
+		recs, err := recommender.getTrendingRecommendations(ctx, &RecommendationRequest{
+			Type:           RecommendationTypeTrending,
+			TrendingWindow: "hot",
+		})
+		require.NoError(t, err)
+		require.Len(t, recs, 1)
+		assert.Equal(t, "doc-fresh", recs[0].DocumentID)
+	})
+
+	t.Run("默认窗口包含全部内容", func(t *testing.T) {
+		recs, err := recommender.getTrendingRecommendations(ctx, &RecommendationRequest{
+			Type: RecommendationTypeTrending,
+		})
+		require.NoError(t, err)
+		assert.Len(t, recs, 2)
+	})
+
+	t.Run("未知窗口返回错误", func(t *testing.T) {
+		_, err := recommender.getTrendingRecommendations(ctx, &RecommendationRequest{
+			Type:           RecommendationTypeTrending,
+			TrendingWindow: "decade",
+		})
+		assert.Error(t, err)
+	})
+}
+
+// TestTrendingJob_InteractionVelocity 测试参与度计入交互速度
+func TestTrendingJob_InteractionVelocity(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	documents := []*VectorDocument{
+		{
+			ID:        "doc-cooling",
+			Content:   "几天前被集中访问的内容",
+			Metadata:  map[string]interface{}{"user_id": "user-1"},
+			CreatedAt: now.Add(-5 * 24 * time.Hour),
+		},
+		{
+			ID:        "doc-rising",
+			Content:   "正在被频繁访问的内容",
+			Metadata:  map[string]interface{}{"user_id": "user-1"},
+			CreatedAt: now.Add(-12 * 24 * time.Hour),
+		},
+	}
+
+	newRecommender := func(useVelocity bool) *Recommender {
+		jobConfig := DefaultTrendingJobConfig()
+		jobConfig.UseInteractionVelocity = useVelocity
+		recommender := newTestTrendingRecommender(documents, jobConfig)
+		for i := 0; i < 4; i++ {
+			recommender.interactions.RecordInteraction("user-2", "doc-cooling", now.Add(-3*24*time.Hour))
+			recommender.interactions.RecordInteraction("user-2", "doc-rising", now.Add(-1*time.Hour))
+		}
+		require.NoError(t, recommender.RefreshTrending(ctx, nil))
+		return recommender
+	}
+
+	t.Run("仅按交互次数时新内容领先", func(t *testing.T) {
+		scores := newRecommender(false).trendingJob.Scores()
+		require.Len(t, scores, 2)
+		assert.Equal(t, "doc-cooling", scores[0].Document.ID)
+		assert.Zero(t, scores[0].Velocity)
+	})
+
+	t.Run("计入交互速度后正在上升的内容领先", func(t *testing.T) {
+		scores := newRecommender(true).trendingJob.Scores()
+		require.Len(t, scores, 2)
+		assert.Equal(t, "doc-rising", scores[0].Document.ID)
+		assert.InDelta(t, 4.0/24.0, scores[0].Velocity, 0.0001)
+	})
+}
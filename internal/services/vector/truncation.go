@@ -0,0 +1,103 @@
+package vector
+
+import (
+	"memoro/internal/services/language"
+)
+
+// TruncationStrategy 向量化文本截断策略
+type TruncationStrategy string
+
+const (
+	TruncationHead     TruncationStrategy = "head"      // 保留开头，丢弃结尾
+	TruncationTail     TruncationStrategy = "tail"      // 保留结尾，丢弃开头
+	TruncationHeadTail TruncationStrategy = "head_tail" // 保留开头和结尾，丢弃中间
+	TruncationSample   TruncationStrategy = "sample"    // 采样开头段落、关键词密集的中间句子和结尾段落
+)
+
+const (
+	// truncationMarker 截断位置标记
+	truncationMarker = "..."
+	// cjkTokensPerRune CJK字符的平均token数
+	cjkTokensPerRune = 1.0
+	// latinRunesPerToken 非CJK字符平均每个token的字符数
+	latinRunesPerToken = 4.0
+)
+
+// IsValidTruncationStrategy 检查截断策略是否有效
+func IsValidTruncationStrategy(strategy TruncationStrategy) bool {
+	switch strategy {
+	case TruncationHead, TruncationTail, TruncationHeadTail, TruncationSample:
+		return true
+	default:
+		return false
+	}
+}
+
+// EstimateTokens 估算文本的token数量（CJK字符按字计数，其他字符按4字符/token估算）
+func EstimateTokens(text string) int {
+	var tokens float64
+	for _, r := range text {
+		tokens += runeTokenCost(r)
+	}
+	return int(tokens + 0.999)
+}
+
+// TruncateText 按策略将文本截断到指定token预算内
+func TruncateText(text string, maxTokens int, strategy TruncationStrategy) string {
+	if maxTokens <= 0 || EstimateTokens(text) <= maxTokens {
+		return text
+	}
+
+	runes := []rune(text)
+	budget := float64(maxTokens)
+
+	switch strategy {
+	case TruncationTail:
+		start := tailCutIndex(runes, budget)
+		return truncationMarker + string(runes[start:])
+	case TruncationHeadTail:
+		headEnd := headCutIndex(runes, budget/2)
+		tailStart := tailCutIndex(runes, budget/2)
+		if tailStart < headEnd {
+			tailStart = headEnd
+		}
+		return string(runes[:headEnd]) + " " + truncationMarker + " " + string(runes[tailStart:])
+	case TruncationSample:
+		return sampleText(text, maxTokens)
+	default:
+		end := headCutIndex(runes, budget)
+		return string(runes[:end]) + truncationMarker
+	}
+}
+
+// headCutIndex 计算从开头保留的字符截止位置
+func headCutIndex(runes []rune, budget float64) int {
+	var used float64
+	for i, r := range runes {
+		used += runeTokenCost(r)
+		if used > budget {
+			return i
+		}
+	}
+	return len(runes)
+}
+
+// tailCutIndex 计算从结尾保留的字符起始位置
+func tailCutIndex(runes []rune, budget float64) int {
+	var used float64
+	for i := len(runes) - 1; i >= 0; i-- {
+		used += runeTokenCost(runes[i])
+		if used > budget {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// runeTokenCost 单个字符的token开销
+func runeTokenCost(r rune) float64 {
+	if language.IsCJKRune(r) {
+		return cjkTokensPerRune
+	}
+	return 1.0 / latinRunesPerToken
+}
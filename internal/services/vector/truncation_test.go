@@ -0,0 +1,158 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+)
+
+// buildLongMixedText 构造中英文混合的长文本（开头、中间、结尾可区分）
+func buildLongMixedText() string {
+	intro := "INTRO: 本文介绍向量检索的基本原理。"
+	middle := strings.Repeat("中间部分的细节描述 middle details filler text. ", 200)
+	conclusion := "结论：混合截断保留了开头与结尾 CONCLUSION"
+	return intro + middle + conclusion
+}
+
+// TestEstimateTokens 测试token估算
+func TestEstimateTokens(t *testing.T) {
+	t.Run("英文按4字符估算", func(t *testing.T) {
+		assert.Equal(t, 4, EstimateTokens("abcdefghijklmnop"))
+	})
+
+	t.Run("中文按字估算", func(t *testing.T) {
+		assert.Equal(t, 6, EstimateTokens("向量检索原理"))
+	})
+
+	t.Run("中英文混合", func(t *testing.T) {
+		// 4个汉字 + 8个英文字符
+		assert.Equal(t, 6, EstimateTokens("向量检索abcdefgh"))
+	})
+
+	t.Run("中文估算高于字节长度除以4", func(t *testing.T) {
+		text := strings.Repeat("知识管理", 100)
+		assert.Greater(t, EstimateTokens(text), len(text)/4)
+	})
+}
+
+// TestTruncateText 测试截断策略
+func TestTruncateText(t *testing.T) {
+	text := buildLongMixedText()
+	maxTokens := 100
+	require.Greater(t, EstimateTokens(text), maxTokens)
+
+	t.Run("head保留开头", func(t *testing.T) {
+		truncated := TruncateText(text, maxTokens, TruncationHead)
+
+		assert.True(t, strings.HasPrefix(truncated, "INTRO"))
+		assert.NotContains(t, truncated, "CONCLUSION")
+		assert.LessOrEqual(t, EstimateTokens(truncated), maxTokens+1)
+	})
+
+	t.Run("tail保留结尾", func(t *testing.T) {
+		truncated := TruncateText(text, maxTokens, TruncationTail)
+
+		assert.True(t, strings.HasSuffix(truncated, "CONCLUSION"))
+		assert.NotContains(t, truncated, "INTRO")
+		assert.LessOrEqual(t, EstimateTokens(truncated), maxTokens+1)
+	})
+
+	t.Run("head_tail保留开头和结尾", func(t *testing.T) {
+		truncated := TruncateText(text, maxTokens, TruncationHeadTail)
+
+		assert.True(t, strings.HasPrefix(truncated, "INTRO"))
+		assert.True(t, strings.HasSuffix(truncated, "CONCLUSION"))
+		assert.Contains(t, truncated, " ... ")
+		assert.LessOrEqual(t, EstimateTokens(truncated), maxTokens+2)
+	})
+
+	t.Run("未超出预算不截断", func(t *testing.T) {
+		short := "短文本 short text"
+		assert.Equal(t, short, TruncateText(short, maxTokens, TruncationHeadTail))
+	})
+
+	t.Run("截断不破坏UTF-8字符", func(t *testing.T) {
+		truncated := TruncateText(strings.Repeat("汉", 500), 33, TruncationHead)
+		assert.Equal(t, strings.Repeat("汉", 33)+"...", truncated)
+	})
+}
+
+// buildLongDocument 构造多段落长文档，中间段落混有关键词密集的句子和无关句子
+func buildLongDocument() string {
+	fillers := []string{"Weather notes about lunch.", "Travel plans for the weekend trip.", "Coffee machine was repaired again.", "Parking lot closes early today."}
+	paragraphs := []string{"INTRO: 本文介绍向量检索如何为知识库建立索引。"}
+	for i := 0; i < 40; i++ {
+		paragraphs = append(paragraphs, fmt.Sprintf("%s MID-%d vector retrieval index ranks vector embeddings.", fillers[i%len(fillers)], i))
+	}
+	paragraphs = append(paragraphs, "结论：向量检索的索引质量决定召回效果 CONCLUSION")
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// TestTruncateText_Sample 测试采样策略覆盖长文档的开头、中间和结尾
+func TestTruncateText_Sample(t *testing.T) {
+	maxTokens := 120
+
+	t.Run("采样包含开头中间和结尾的内容", func(t *testing.T) {
+		text := buildLongDocument()
+		require.Greater(t, EstimateTokens(text), maxTokens)
+
+		sampled := TruncateText(text, maxTokens, TruncationSample)
+
+		assert.True(t, strings.HasPrefix(sampled, "INTRO"))
+		assert.True(t, strings.HasSuffix(sampled, "CONCLUSION"))
+		assert.Contains(t, sampled, "MID-")
+		assert.LessOrEqual(t, EstimateTokens(sampled), maxTokens+1)
+	})
+
+	t.Run("中间部分优先选择关键词密集的句子", func(t *testing.T) {
+		sampled := TruncateText(buildLongDocument(), maxTokens, TruncationSample)
+
+		fillers := 0
+		for _, filler := range []string{"Weather", "Travel", "Coffee", "Parking"} {
+			fillers += strings.Count(sampled, filler)
+		}
+		assert.Greater(t, strings.Count(sampled, "vector retrieval index"), fillers)
+	})
+
+	t.Run("句子不足时退化为head_tail", func(t *testing.T) {
+		text := strings.Repeat("知识管理", 100)
+		assert.Equal(t, TruncateText(text, 50, TruncationHeadTail), TruncateText(text, 50, TruncationSample))
+	})
+}
+
+// TestEmbeddingService_TruncationOverride 测试请求级截断策略覆盖
+func TestEmbeddingService_TruncationOverride(t *testing.T) {
+	service := &EmbeddingService{
+		truncationStrategy: TruncationHead,
+		logger:             logger.NewLogger("embedding-service-test"),
+	}
+	text := buildLongMixedText()
+
+	t.Run("使用服务默认策略", func(t *testing.T) {
+		truncated := service.truncateText(text, 100, "")
+		assert.True(t, strings.HasPrefix(truncated, "INTRO"))
+		assert.NotContains(t, truncated, "CONCLUSION")
+	})
+
+	t.Run("请求策略覆盖默认值", func(t *testing.T) {
+		truncated := service.truncateText(text, 100, TruncationTail)
+		assert.True(t, strings.HasSuffix(truncated, "CONCLUSION"))
+	})
+
+	t.Run("无效的请求策略被拒绝", func(t *testing.T) {
+		_, err := service.GenerateEmbedding(context.Background(), &EmbeddingRequest{
+			Text:               text,
+			MaxTokens:          100,
+			TruncationStrategy: "middle",
+		})
+		require.Error(t, err)
+		assert.True(t, err.(*errors.MemoroError).IsCode(errors.ErrCodeValidationFailed))
+	})
+}
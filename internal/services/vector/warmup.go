@@ -0,0 +1,169 @@
+package vector
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"memoro/internal/config"
+	"memoro/internal/logger"
+)
+
+// WarmupConfig 搜索引擎预热配置
+type WarmupConfig struct {
+	Enabled         bool          // 是否启用预热
+	Queries         []string      // 预先生成查询向量的常用查询
+	RecentDocuments int           // 预取最近文档元数据的数量
+	RecentWindow    time.Duration // 最近文档的时间范围
+	Timeout         time.Duration // 预热总超时
+	RequestInterval time.Duration // 两次向量化请求之间的最小间隔（遵守LLM速率限制）
+}
+
+// WarmupReport 预热结果
+type WarmupReport struct {
+	QueriesWarmed       int           `json:"queries_warmed"`       // 新生成并缓存的查询向量数
+	QueriesCached       int           `json:"queries_cached"`       // 已在缓存中而跳过的查询数
+	QueriesFailed       int           `json:"queries_failed"`       // 生成失败的查询数
+	DocumentsPrefetched int           `json:"documents_prefetched"` // 预取的最近文档数
+	Duration            time.Duration `json:"duration"`             // 耗时
+}
+
+// DefaultWarmupConfig 默认预热配置（默认关闭）
+func DefaultWarmupConfig() WarmupConfig {
+	return WarmupConfig{
+		RecentWindow: 7 * 24 * time.Hour,
+		Timeout:      5 * time.Minute,
+	}
+}
+
+// warmupConfigFrom 从全局配置读取预热配置，请求间隔由LLM每分钟请求数限制换算
+func warmupConfigFrom(cfg *config.Config) WarmupConfig {
+	warmupConfig := DefaultWarmupConfig()
+	if cfg == nil {
+		return warmupConfig
+	}
+
+	if rpm := cfg.LLM.RateLimit.RequestsPerMinute; rpm > 0 {
+		warmupConfig.RequestInterval = time.Minute / time.Duration(rpm)
+	}
+
+	if warmup := cfg.VectorDB.Warmup; warmup != nil {
+		warmupConfig.Enabled = warmup.Enabled
+		warmupConfig.Queries = warmup.Queries
+		warmupConfig.RecentDocuments = warmup.RecentDocuments
+		if warmup.RecentWindow > 0 {
+			warmupConfig.RecentWindow = warmup.RecentWindow
+		}
+		if warmup.Timeout > 0 {
+			warmupConfig.Timeout = warmup.Timeout
+		}
+	}
+	return warmupConfig
+}
+
+// startWarmup 在后台执行预热，不阻塞启动（Close时取消）
+func (se *SearchEngine) startWarmup(warmupConfig WarmupConfig) {
+	if !warmupConfig.Enabled || (len(warmupConfig.Queries) == 0 && warmupConfig.RecentDocuments <= 0) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), warmupConfig.Timeout)
+	se.warmupCancel = cancel
+
+	go func() {
+		defer cancel()
+		se.Warmup(ctx, warmupConfig)
+	}()
+}
+
+// Warmup 预先生成常用查询的查询向量并预取最近文档的元数据
+func (se *SearchEngine) Warmup(ctx context.Context, warmupConfig WarmupConfig) *WarmupReport {
+	startTime := time.Now()
+	report := &WarmupReport{}
+
+	se.logger.Info("Search engine warmup started", logger.Fields{
+		"queries":          len(warmupConfig.Queries),
+		"recent_documents": warmupConfig.RecentDocuments,
+		"request_interval": warmupConfig.RequestInterval,
+	})
+
+	var lastRequest time.Time
+	for _, query := range warmupConfig.Queries {
+		if strings.TrimSpace(query) == "" {
+			continue
+		}
+
+		// 与Search使用相同的默认选项和预处理，保证缓存键一致
+		options := &SearchOptions{Query: query}
+		if err := se.applySearchDefaults(options); err != nil {
+			report.QueriesFailed++
+			continue
+		}
+		processedQuery := se.preprocessQuery(query)
+
+		if se.cacheManager.HasQueryVector(processedQuery, options) {
+			report.QueriesCached++
+			continue
+		}
+
+		// 遵守速率限制
+		if wait := warmupConfig.RequestInterval - time.Since(lastRequest); !lastRequest.IsZero() && wait > 0 {
+			select {
+			case <-ctx.Done():
+				se.logWarmupDone(report, startTime, ctx.Err())
+				return report
+			case <-time.After(wait):
+			}
+		}
+		lastRequest = time.Now()
+
+		if _, _, err := se.generateQueryVector(ctx, processedQuery, options); err != nil {
+			report.QueriesFailed++
+			se.logger.Warn("Warmup query failed", logger.Fields{
+				"query": query,
+				"error": err.Error(),
+			})
+			if ctx.Err() != nil {
+				se.logWarmupDone(report, startTime, ctx.Err())
+				return report
+			}
+			continue
+		}
+		report.QueriesWarmed++
+	}
+
+	if warmupConfig.RecentDocuments > 0 {
+		documents, err := se.store.GetDocumentsByFilter(ctx, map[string]interface{}{
+			"created_at": map[string]interface{}{
+				"$gte": time.Now().Add(-warmupConfig.RecentWindow).Unix(),
+			},
+		}, warmupConfig.RecentDocuments)
+		if err != nil {
+			se.logger.Warn("Warmup document prefetch failed", logger.Fields{"error": err.Error()})
+		} else {
+			report.DocumentsPrefetched = len(documents)
+		}
+	}
+
+	se.logWarmupDone(report, startTime, nil)
+	return report
+}
+
+// logWarmupDone 记录预热结果
+func (se *SearchEngine) logWarmupDone(report *WarmupReport, startTime time.Time, err error) {
+	report.Duration = time.Since(startTime)
+
+	fields := logger.Fields{
+		"queries_warmed":       report.QueriesWarmed,
+		"queries_cached":       report.QueriesCached,
+		"queries_failed":       report.QueriesFailed,
+		"documents_prefetched": report.DocumentsPrefetched,
+		"duration":             report.Duration,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		se.logger.Warn("Search engine warmup interrupted", fields)
+		return
+	}
+	se.logger.Info("Search engine warmup completed", fields)
+}
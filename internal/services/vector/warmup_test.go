@@ -0,0 +1,87 @@
+package vector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"memoro/internal/config"
+)
+
+// TestSearchEngine_Warmup 测试预热后常用查询命中查询向量缓存
+func TestSearchEngine_Warmup(t *testing.T) {
+	fake := newFakeChromaServer(t)
+	now := float64(time.Now().Unix())
+	fake.put("recent-1", "Go并发编程", []float32{0.1, 0.2, 0.3}, map[string]interface{}{"user_id": "user-1", "created_at": now})
+	fake.put("recent-2", "Rust所有权", []float32{0.3, 0.2, 0.1}, map[string]interface{}{"user_id": "user-1", "created_at": now})
+	fake.put("old", "旧笔记", []float32{0.2, 0.2, 0.2}, map[string]interface{}{"user_id": "user-1", "created_at": float64(1600000000)})
+
+	embedder := new(MockEmbeddingService)
+	embedder.On("GenerateEmbedding", mock.Anything, mock.Anything).
+		Return(&EmbeddingResult{Vector: []float32{0.1, 0.2, 0.3}, Dimension: 3}, nil)
+
+	engine := newTestSearchEngine(t, embedder)
+	engine.store = newTestChromaClient(t, fake)
+
+	report := engine.Warmup(context.Background(), WarmupConfig{
+		Enabled:         true,
+		Queries:         []string{"Go 并发", "  "},
+		RecentDocuments: 10,
+		RecentWindow:    24 * time.Hour,
+	})
+	assert.Equal(t, 1, report.QueriesWarmed)
+	assert.Equal(t, 0, report.QueriesFailed)
+	assert.Equal(t, 2, report.DocumentsPrefetched)
+	embedder.AssertNumberOfCalls(t, "GenerateEmbedding", 1)
+
+	// 相同查询的搜索命中缓存，不再调用向量化服务
+	_, err := engine.Search(context.Background(), &SearchOptions{Query: "Go 并发"})
+	require.NoError(t, err)
+	embedder.AssertNumberOfCalls(t, "GenerateEmbedding", 1)
+	assert.Equal(t, int64(1), engine.cacheManager.GetStats().QueryVectorHits)
+
+	t.Run("已缓存的查询不重复生成", func(t *testing.T) {
+		report := engine.Warmup(context.Background(), WarmupConfig{Enabled: true, Queries: []string{"Go 并发"}})
+		assert.Equal(t, 0, report.QueriesWarmed)
+		assert.Equal(t, 1, report.QueriesCached)
+		embedder.AssertNumberOfCalls(t, "GenerateEmbedding", 1)
+	})
+
+	t.Run("按速率限制间隔发送请求", func(t *testing.T) {
+		start := time.Now()
+		report := engine.Warmup(context.Background(), WarmupConfig{
+			Enabled:         true,
+			Queries:         []string{"Rust 所有权", "分布式 系统"},
+			RequestInterval: 100 * time.Millisecond,
+		})
+		assert.Equal(t, 2, report.QueriesWarmed)
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("未启用时不预热", func(t *testing.T) {
+		engine.startWarmup(WarmupConfig{Queries: []string{"Kubernetes"}})
+		assert.Nil(t, engine.warmupCancel)
+	})
+}
+
+// TestWarmupConfigFrom 测试预热配置读取
+func TestWarmupConfigFrom(t *testing.T) {
+	warmupConfig := warmupConfigFrom(&config.Config{
+		LLM: config.LLMConfig{RateLimit: config.RateLimitConfig{RequestsPerMinute: 120}},
+		VectorDB: config.VectorDBConfig{
+			Warmup: &config.WarmupConfig{Enabled: true, Queries: []string{"Go"}, RecentDocuments: 50},
+		},
+	})
+
+	assert.True(t, warmupConfig.Enabled)
+	assert.Equal(t, []string{"Go"}, warmupConfig.Queries)
+	assert.Equal(t, 50, warmupConfig.RecentDocuments)
+	assert.Equal(t, 500*time.Millisecond, warmupConfig.RequestInterval)
+	assert.Equal(t, 7*24*time.Hour, warmupConfig.RecentWindow)
+
+	assert.False(t, warmupConfigFrom(&config.Config{}).Enabled)
+}
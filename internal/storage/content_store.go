@@ -0,0 +1,309 @@
+package storage
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+	"memoro/internal/models"
+)
+
+// ContentStore 内容项持久化存储（SQLite，内容数据的权威来源）
+type ContentStore struct {
+	db         *gorm.DB
+	config     config.DatabaseConfig
+	encryption *models.ContentEncryption // 内容静态加密设置（未启用时为nil）
+	logger     *logger.Logger
+}
+
+// NewContentStore 根据全局配置创建内容存储
+func NewContentStore() (*ContentStore, error) {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil, errors.ErrConfigMissing("database config")
+	}
+
+	return OpenContentStore(cfg.Database)
+}
+
+// OpenContentStore 按数据库配置打开内容存储
+func OpenContentStore(dbConfig config.DatabaseConfig) (*ContentStore, error) {
+	if dbConfig.Type != "sqlite" {
+		return nil, errors.ErrConfigInvalid("database.type", "only 'sqlite' is supported")
+	}
+	if dbConfig.Path == "" {
+		return nil, errors.ErrConfigMissing("database.path")
+	}
+
+	storeLogger := logger.NewLogger("content-store")
+
+	encryption, err := newContentEncryption(dbConfig.Encryption)
+	if err != nil {
+		storeLogger.Error("Failed to load content encryption key", logger.Fields{
+			"key_provider": dbConfig.Encryption.KeyProvider,
+			"error":        err.Error(),
+		})
+		return nil, err
+	}
+
+	db, err := gorm.Open(sqlite.Open(dbConfig.Path), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		memoErr := errors.ErrDatabaseConnection(dbConfig.Path, err)
+		storeLogger.LogMemoroError(memoErr, "Failed to open database")
+		return nil, memoErr
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, errors.ErrDatabaseConnection(dbConfig.Path, err)
+	}
+
+	// 内存数据库每个连接都是独立的库，只能使用单连接
+	if dbConfig.Path == ":memory:" {
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		if dbConfig.MaxOpenConns > 0 {
+			sqlDB.SetMaxOpenConns(dbConfig.MaxOpenConns)
+		}
+		if dbConfig.MaxIdleConns > 0 {
+			sqlDB.SetMaxIdleConns(dbConfig.MaxIdleConns)
+		}
+		if dbConfig.ConnMaxLifetime > 0 {
+			sqlDB.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
+		}
+		if dbConfig.ConnMaxIdleTime > 0 {
+			sqlDB.SetConnMaxIdleTime(dbConfig.ConnMaxIdleTime)
+		}
+	}
+
+	if dbConfig.AutoMigrate {
+		if err := db.AutoMigrate(&models.ContentItem{}, &models.SearchScope{}, &models.FeedState{}, &models.ImportJob{}); err != nil {
+			memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to migrate content table").
+				WithCause(err)
+			storeLogger.LogMemoroError(memoErr, "Database migration failed")
+			return nil, memoErr
+		}
+	}
+
+	storeLogger.Info("Content store initialized", logger.Fields{
+		"path":         dbConfig.Path,
+		"auto_migrate": dbConfig.AutoMigrate,
+		"encryption":   encryption != nil,
+	})
+
+	return &ContentStore{
+		db:         db,
+		config:     dbConfig,
+		encryption: encryption,
+		logger:     storeLogger,
+	}, nil
+}
+
+// EncryptionEnabled 是否配置了内容静态加密（敏感内容只能保存到启用加密的存储）
+func (s *ContentStore) EncryptionEnabled() bool {
+	return s.encryption != nil
+}
+
+// contentContext 返回携带内容加密设置的上下文，供ContentItem的GORM钩子加解密
+func (s *ContentStore) contentContext(ctx context.Context) context.Context {
+	if s.encryption == nil {
+		return ctx
+	}
+	return models.WithContentEncryption(ctx, s.encryption)
+}
+
+// Save 保存内容项（存在时覆盖）
+func (s *ContentStore) Save(ctx context.Context, item *models.ContentItem) error {
+	if item == nil {
+		return errors.ErrValidationFailed("content_item", "cannot be nil")
+	}
+
+	err := s.db.WithContext(s.contentContext(ctx)).Save(item).Error
+	// 保存时加密的字段恢复为明文，调用方继续使用的内容项不含密文
+	if s.encryption != nil {
+		if restoreErr := item.RestorePlaintext(s.encryption); restoreErr != nil && err == nil {
+			err = restoreErr
+		}
+	}
+	if err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to save content item").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"content_id": item.ID,
+			})
+		s.logger.LogMemoroError(memoErr, "Content save failed")
+		return memoErr
+	}
+
+	return nil
+}
+
+// Get 根据ID获取内容项
+func (s *ContentStore) Get(ctx context.Context, id string) (*models.ContentItem, error) {
+	if id == "" {
+		return nil, errors.ErrValidationFailed("id", "cannot be empty")
+	}
+
+	var item models.ContentItem
+	if err := s.db.WithContext(s.contentContext(ctx)).First(&item, "id = ?", id).Error; err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.ErrResourceNotFound("content", id)
+		}
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to query content item").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"content_id": id,
+			})
+		s.logger.LogMemoroError(memoErr, "Content query failed")
+		return nil, memoErr
+	}
+
+	return &item, nil
+}
+
+// ListByUser 按创建时间倒序列出用户的内容项，before不为零值时只返回早于该时间的内容（游标分页）
+func (s *ContentStore) ListByUser(ctx context.Context, userID string, limit int, before time.Time) ([]*models.ContentItem, error) {
+	if userID == "" {
+		return nil, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+	if limit <= 0 {
+		return nil, errors.ErrValidationFailed("limit", "must be positive")
+	}
+
+	// 线程汇总文档与线程内的消息内容重复，不列出
+	query := s.db.WithContext(s.contentContext(ctx)).
+		Where("user_id = ?", userID).
+		Where("id NOT LIKE ?", models.ThreadDocumentIDPrefix+"%")
+	if !before.IsZero() {
+		query = query.Where("created_at < ?", before)
+	}
+
+	var items []*models.ContentItem
+	if err := query.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&items).Error; err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to list content items").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"user_id": userID,
+			})
+		s.logger.LogMemoroError(memoErr, "Content list failed")
+		return nil, memoErr
+	}
+
+	return items, nil
+}
+
+// FindByCanonicalURL 查找用户最早保存的同一规范URL的内容项，不存在时返回资源不存在错误
+func (s *ContentStore) FindByCanonicalURL(ctx context.Context, userID, canonicalURL string) (*models.ContentItem, error) {
+	if userID == "" {
+		return nil, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+	if canonicalURL == "" {
+		return nil, errors.ErrValidationFailed("canonical_url", "cannot be empty")
+	}
+
+	var item models.ContentItem
+	err := s.db.WithContext(s.contentContext(ctx)).
+		Where("user_id = ? AND canonical_url = ?", userID, canonicalURL).
+		Order("created_at ASC").
+		First(&item).Error
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.ErrResourceNotFound("content", canonicalURL)
+		}
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to query content by canonical URL").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"user_id": userID,
+			})
+		s.logger.LogMemoroError(memoErr, "Canonical URL query failed")
+		return nil, memoErr
+	}
+
+	return &item, nil
+}
+
+// ListByTag 列出用户带有指定标签的全部内容项（包括未索引和已归档的内容）
+func (s *ContentStore) ListByTag(ctx context.Context, userID, tag string) ([]*models.ContentItem, error) {
+	if userID == "" {
+		return nil, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+	if tag == "" {
+		return nil, errors.ErrValidationFailed("tag", "cannot be empty")
+	}
+
+	var items []*models.ContentItem
+	err := s.db.WithContext(s.contentContext(ctx)).
+		Where("user_id = ?", userID).
+		Where("tags != '' AND json_valid(tags)").
+		Where("EXISTS (SELECT 1 FROM json_each(content_items.tags) WHERE json_each.value = ?)", tag).
+		Order("created_at ASC").
+		Find(&items).Error
+	if err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to list content items by tag").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"user_id": userID,
+				"tag":     tag,
+			})
+		s.logger.LogMemoroError(memoErr, "Tag query failed")
+		return nil, memoErr
+	}
+
+	return items, nil
+}
+
+// LinkURLs 列出用户已保存链接的原始URL和规范URL（来自处理数据中的来源信息和规范URL列，加密的处理数据只取规范URL列），供导入时跳过已保存的链接
+func (s *ContentStore) LinkURLs(ctx context.Context, userID string) ([]string, error) {
+	if userID == "" {
+		return nil, errors.ErrValidationFailed("user_id", "cannot be empty")
+	}
+
+	var rows []struct {
+		OriginalURL  *string
+		CanonicalURL *string
+	}
+	err := s.db.WithContext(ctx).
+		Model(&models.ContentItem{}).
+		Select("CASE WHEN processed_data = '' OR processed_data LIKE ? THEN NULL ELSE json_extract(processed_data, '$.provenance.original_url') END AS original_url, "+
+			"COALESCE(NULLIF(canonical_url, ''), CASE WHEN processed_data = '' OR processed_data LIKE ? THEN NULL ELSE json_extract(processed_data, '$.provenance.canonical_url') END) AS canonical_url",
+			models.EncryptedValuePrefix+"%", models.EncryptedValuePrefix+"%").
+		Where("user_id = ? AND type = ?", userID, models.ContentTypeLink).
+		Scan(&rows).Error
+	if err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to list link URLs").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"user_id": userID,
+			})
+		s.logger.LogMemoroError(memoErr, "Link URL query failed")
+		return nil, memoErr
+	}
+
+	urls := make([]string, 0, len(rows)*2)
+	for _, row := range rows {
+		for _, value := range []*string{row.OriginalURL, row.CanonicalURL} {
+			if value != nil && *value != "" {
+				urls = append(urls, *value)
+			}
+		}
+	}
+	return urls, nil
+}
+
+// Close 关闭存储
+func (s *ContentStore) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	stderrors "errors"
+	"os"
+	"strings"
+	"sync"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/models"
+)
+
+// defaultKeyEnv env密钥来源默认读取的环境变量
+const defaultKeyEnv = "MEMORO_ENCRYPTION_KEY"
+
+// KeyProvider 内容加密密钥提供者（环境变量、KMS等）
+type KeyProvider interface {
+	EncryptionKey(ctx context.Context) ([]byte, error)
+}
+
+// KeyProviderFactory 按加密配置创建密钥提供者
+type KeyProviderFactory func(cfg config.EncryptionConfig) (KeyProvider, error)
+
+var (
+	keyProvidersMu sync.RWMutex
+	keyProviders   = map[string]KeyProviderFactory{
+		"env": func(cfg config.EncryptionConfig) (KeyProvider, error) {
+			return &EnvKeyProvider{Variable: cfg.KeyEnv}, nil
+		},
+	}
+)
+
+// RegisterKeyProvider 注册密钥提供者（如KMS），需在打开内容存储前调用，通过database.encryption.key_provider选用
+func RegisterKeyProvider(name string, factory KeyProviderFactory) {
+	keyProvidersMu.Lock()
+	defer keyProvidersMu.Unlock()
+	keyProviders[name] = factory
+}
+
+// EnvKeyProvider 从环境变量读取base64或十六进制编码的AES密钥
+type EnvKeyProvider struct {
+	Variable string // 环境变量名（为空时使用MEMORO_ENCRYPTION_KEY）
+}
+
+// EncryptionKey 读取并解码环境变量中的密钥
+func (p *EnvKeyProvider) EncryptionKey(ctx context.Context) ([]byte, error) {
+	variable := p.Variable
+	if variable == "" {
+		variable = defaultKeyEnv
+	}
+
+	value := strings.TrimSpace(os.Getenv(variable))
+	if value == "" {
+		return nil, errors.ErrConfigMissing(variable)
+	}
+	if key, err := hex.DecodeString(value); err == nil {
+		return key, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.ErrConfigInvalid(variable, "must be a base64 or hex encoded key")
+	}
+	return key, nil
+}
+
+// aesGCMCipher AES-GCM内容加密器，密文为base64编码的随机nonce和加密结果
+type aesGCMCipher struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMCipher 使用16、24或32字节的密钥创建AES-GCM内容加密器
+func NewAESGCMCipher(key []byte) (models.ContentCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.ErrConfigInvalid("database.encryption", "key must be 16, 24 or 32 bytes")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesGCMCipher{aead: aead}, nil
+}
+
+// Encrypt 加密明文
+func (c *aesGCMCipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密密文（密钥不匹配或密文被篡改时返回错误）
+func (c *aesGCMCipher) Decrypt(ciphertext string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", stderrors.New("ciphertext too short")
+	}
+	nonce, sealed := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// newContentEncryption 按配置从密钥提供者加载密钥并创建内容加密设置，未启用时返回nil
+func newContentEncryption(cfg config.EncryptionConfig) (*models.ContentEncryption, error) {
+	if cfg.Mode == "" || cfg.Mode == "off" {
+		return nil, nil
+	}
+
+	name := cfg.KeyProvider
+	if name == "" {
+		name = "env"
+	}
+	keyProvidersMu.RLock()
+	factory, exists := keyProviders[name]
+	keyProvidersMu.RUnlock()
+	if !exists {
+		return nil, errors.ErrConfigInvalid("database.encryption.key_provider", "unknown key provider: "+name)
+	}
+
+	provider, err := factory(cfg)
+	if err != nil {
+		return nil, err
+	}
+	key, err := provider.EncryptionKey(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	contentCipher, err := NewAESGCMCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ContentEncryption{
+		Cipher:           contentCipher,
+		EncryptAll:       cfg.Mode == "all",
+		EncryptSummaries: cfg.EncryptSummaries,
+	}, nil
+}
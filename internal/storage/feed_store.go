@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	stderrors "errors"
+
+	"gorm.io/gorm"
+
+	"memoro/internal/errors"
+	"memoro/internal/models"
+)
+
+// SaveFeedState 保存订阅源的采集进度（存在时覆盖）
+func (s *ContentStore) SaveFeedState(ctx context.Context, state *models.FeedState) error {
+	if state == nil {
+		return errors.ErrValidationFailed("feed_state", "cannot be nil")
+	}
+	if state.FeedURL == "" {
+		return errors.ErrValidationFailed("feed_url", "cannot be empty")
+	}
+
+	if err := s.db.WithContext(ctx).Save(state).Error; err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to save feed state").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"feed_url": state.FeedURL,
+			})
+		s.logger.LogMemoroError(memoErr, "Feed state save failed")
+		return memoErr
+	}
+
+	return nil
+}
+
+// GetFeedState 获取订阅源的采集进度，从未采集过时返回资源不存在错误
+func (s *ContentStore) GetFeedState(ctx context.Context, feedURL string) (*models.FeedState, error) {
+	var state models.FeedState
+	if err := s.db.WithContext(ctx).First(&state, "feed_url = ?", feedURL).Error; err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.ErrResourceNotFound("feed_state", feedURL)
+		}
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to query feed state").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"feed_url": feedURL,
+			})
+		s.logger.LogMemoroError(memoErr, "Feed state query failed")
+		return nil, memoErr
+	}
+
+	return &state, nil
+}
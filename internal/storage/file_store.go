@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"memoro/internal/config"
+	"memoro/internal/errors"
+	"memoro/internal/logger"
+)
+
+// defaultMaxFileSize 未配置时的上传文件大小上限（50MB）
+const defaultMaxFileSize = 50 << 20
+
+// uploadDirName 上传文件在存储目录下的子目录
+const uploadDirName = "uploads"
+
+// FileStore 上传文件存储：按存储配置检查大小和类型，文件保存在storage.file_path/uploads下
+type FileStore struct {
+	dir          string
+	maxSize      int64
+	allowedTypes []string
+	logger       *logger.Logger
+}
+
+// NewFileStore 根据全局配置创建上传文件存储
+func NewFileStore() (*FileStore, error) {
+	cfg := config.Get()
+	if cfg == nil {
+		return nil, errors.ErrConfigMissing("storage config")
+	}
+
+	return OpenFileStore(cfg.Storage)
+}
+
+// OpenFileStore 按存储配置创建上传文件存储
+func OpenFileStore(storageConfig config.StorageConfig) (*FileStore, error) {
+	if storageConfig.FilePath == "" {
+		return nil, errors.ErrConfigMissing("storage.file_path")
+	}
+
+	maxSize := int64(defaultMaxFileSize)
+	if storageConfig.MaxFileSize != "" {
+		size, err := config.ParseByteSize(storageConfig.MaxFileSize)
+		if err != nil || size <= 0 {
+			return nil, errors.ErrConfigInvalid("storage.max_file_size", "must be a positive size such as 512KB or 50MB")
+		}
+		maxSize = size
+	}
+
+	allowedTypes := make([]string, 0, len(storageConfig.AllowedTypes))
+	for _, allowed := range storageConfig.AllowedTypes {
+		if allowed = strings.ToLower(strings.TrimSpace(allowed)); allowed != "" {
+			allowedTypes = append(allowedTypes, allowed)
+		}
+	}
+
+	return &FileStore{
+		dir:          filepath.Join(storageConfig.FilePath, uploadDirName),
+		maxSize:      maxSize,
+		allowedTypes: allowedTypes,
+		logger:       logger.NewLogger("file-store"),
+	}, nil
+}
+
+// MaxFileSize 上传文件大小上限（字节）
+func (fs *FileStore) MaxFileSize() int64 {
+	return fs.maxSize
+}
+
+// DetectMIMEType 根据文件内容检测MIME类型，无法识别时按扩展名判断
+func DetectMIMEType(filename string, data []byte) string {
+	detected := http.DetectContentType(data)
+	if detected != "application/octet-stream" {
+		return strings.TrimSpace(strings.Split(detected, ";")[0])
+	}
+	if byExtension := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))); byExtension != "" {
+		return strings.TrimSpace(strings.Split(byExtension, ";")[0])
+	}
+	return detected
+}
+
+// IsAllowed 检查文件类型是否允许上传（按MIME类型、MIME通配或扩展名匹配），未配置时全部允许
+func (fs *FileStore) IsAllowed(mimeType, filename string) bool {
+	if len(fs.allowedTypes) == 0 {
+		return true
+	}
+
+	mimeType = strings.ToLower(mimeType)
+	extension := strings.ToLower(filepath.Ext(filename))
+	for _, allowed := range fs.allowedTypes {
+		switch {
+		case allowed == mimeType:
+			return true
+		case strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(allowed, "*")):
+			return true
+		case extension != "" && (allowed == extension || "."+allowed == extension):
+			return true
+		}
+	}
+	return false
+}
+
+// Save 保存上传文件，返回文件路径（文件名前加唯一前缀，避免重名覆盖）
+func (fs *FileStore) Save(filename string, data []byte) (string, error) {
+	if int64(len(data)) > fs.maxSize {
+		return "", errors.ErrValidationFailed("file", fmt.Sprintf("file too large (max %d bytes)", fs.maxSize))
+	}
+	if err := os.MkdirAll(fs.dir, 0o755); err != nil {
+		return "", errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to create upload directory").
+			WithCause(err)
+	}
+
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(filepath.Base(filename))
+	path := filepath.Join(fs.dir, fmt.Sprintf("%s-%s", uuid.New().String(), name))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to save uploaded file").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"filename": filename,
+			})
+	}
+
+	fs.logger.Debug("Uploaded file saved", logger.Fields{
+		"filename": filename,
+		"path":     path,
+		"size":     len(data),
+	})
+	return path, nil
+}
+
+// Remove 删除已保存的上传文件（提交处理失败时清理）
+func (fs *FileStore) Remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.NewMemoroError(errors.ErrorTypeSystem, errors.ErrCodeSystemGeneric, "Failed to remove uploaded file").
+			WithCause(err)
+	}
+	return nil
+}
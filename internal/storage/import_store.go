@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	stderrors "errors"
+
+	"gorm.io/gorm"
+
+	"memoro/internal/errors"
+	"memoro/internal/models"
+)
+
+// SaveImportJob 保存导入任务及其进度（存在时覆盖）
+func (s *ContentStore) SaveImportJob(ctx context.Context, job *models.ImportJob) error {
+	if job == nil {
+		return errors.ErrValidationFailed("import_job", "cannot be nil")
+	}
+	if job.ID == "" {
+		return errors.ErrValidationFailed("id", "cannot be empty")
+	}
+
+	if err := s.db.WithContext(ctx).Save(job).Error; err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to save import job").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"job_id": job.ID,
+			})
+		s.logger.LogMemoroError(memoErr, "Import job save failed")
+		return memoErr
+	}
+
+	return nil
+}
+
+// GetImportJob 获取导入任务，不存在时返回资源不存在错误
+func (s *ContentStore) GetImportJob(ctx context.Context, id string) (*models.ImportJob, error) {
+	var job models.ImportJob
+	if err := s.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.ErrResourceNotFound("import_job", id)
+		}
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to query import job").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"job_id": id,
+			})
+		s.logger.LogMemoroError(memoErr, "Import job query failed")
+		return nil, memoErr
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	stderrors "errors"
+
+	"gorm.io/gorm"
+
+	"memoro/internal/errors"
+	"memoro/internal/models"
+)
+
+// SaveSearchScope 保存搜索范围（同一用户的同名范围覆盖过滤条件，保留ID和创建时间）
+func (s *ContentStore) SaveSearchScope(ctx context.Context, scope *models.SearchScope) error {
+	if scope == nil {
+		return errors.ErrValidationFailed("search_scope", "cannot be nil")
+	}
+
+	existing, err := s.GetSearchScope(ctx, scope.UserID, scope.Name)
+	if err == nil {
+		scope.ID = existing.ID
+		scope.CreatedAt = existing.CreatedAt
+	} else if memoErr, ok := err.(*errors.MemoroError); !ok || !memoErr.IsCode(errors.ErrCodeResourceNotFound) {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Save(scope).Error; err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to save search scope").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"user_id": scope.UserID,
+				"name":    scope.Name,
+			})
+		s.logger.LogMemoroError(memoErr, "Search scope save failed")
+		return memoErr
+	}
+
+	return nil
+}
+
+// GetSearchScope 获取用户的指定搜索范围
+func (s *ContentStore) GetSearchScope(ctx context.Context, userID, name string) (*models.SearchScope, error) {
+	var scope models.SearchScope
+	if err := s.db.WithContext(ctx).First(&scope, "user_id = ? AND name = ?", userID, name).Error; err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.ErrResourceNotFound("search_scope", name)
+		}
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to query search scope").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"user_id": userID,
+				"name":    name,
+			})
+		s.logger.LogMemoroError(memoErr, "Search scope query failed")
+		return nil, memoErr
+	}
+
+	return &scope, nil
+}
+
+// ListSearchScopes 按名称列出用户的搜索范围
+func (s *ContentStore) ListSearchScopes(ctx context.Context, userID string) ([]*models.SearchScope, error) {
+	var scopes []*models.SearchScope
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("name").Find(&scopes).Error; err != nil {
+		memoErr := errors.NewMemoroError(errors.ErrorTypeDatabase, errors.ErrCodeDatabaseQuery, "Failed to list search scopes").
+			WithCause(err).
+			WithContext(map[string]interface{}{
+				"user_id": userID,
+			})
+		s.logger.LogMemoroError(memoErr, "Search scope list failed")
+		return nil, memoErr
+	}
+
+	return scopes, nil
+}
@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mdp/qrterminal/v3"
@@ -18,7 +19,9 @@ import (
 type Client struct {
 	baseURL  string
 	adminKey string
-	authKey  string
+
+	mu      sync.Mutex
+	authKey string // 已生成的授权码（多次登录复用，二维码获取失败时重新生成）
 }
 
 // NewClient 创建新的微信客户端
@@ -39,27 +42,52 @@ func getAdminKey() string {
 
 // Login 执行完整的登录流程
 func (c *Client) Login() (string, error) {
-	// 生成授权码
-	authKey, err := c.generateAuthKey()
-	if err != nil {
-		return "", fmt.Errorf("生成授权码失败: %v", err)
-	}
-	c.authKey = authKey
-
-	// 获取登录二维码
-	qrCode, uuid, err := c.getLoginQrCode()
+	session, err := c.StartLogin()
 	if err != nil {
-		return "", fmt.Errorf("获取二维码失败: %v", err)
+		return "", err
 	}
 
 	// 显示二维码
 	fmt.Println("\n📲 请使用微信扫描下方二维码:")
 	fmt.Println("==================================")
-	qrterminal.Generate(qrCode, qrterminal.M, os.Stdout)
+	qrterminal.Generate(session.QRCode, qrterminal.M, os.Stdout)
 	fmt.Println("==================================")
 
 	// 等待登录
-	wxid, err := c.waitForLogin(uuid)
+	return c.WaitForLogin(session)
+}
+
+// StartLogin 获取授权码和登录二维码，不等待扫码（用于HTTP API等非终端场景）
+func (c *Client) StartLogin() (*LoginSession, error) {
+	// 获取授权码（已有时复用，避免每次登录都生成新的授权码）
+	authKey, err := c.currentAuthKey()
+	if err != nil {
+		return nil, fmt.Errorf("生成授权码失败: %v", err)
+	}
+
+	// 获取登录二维码
+	qrCode, uuid, err := c.getLoginQrCode(authKey)
+	if err != nil {
+		// 授权码可能已过期，下次登录重新生成
+		c.resetAuthKey(authKey)
+		return nil, fmt.Errorf("获取二维码失败: %v", err)
+	}
+
+	return &LoginSession{
+		Token:     authKey,
+		QRCode:    qrCode,
+		UUID:      uuid,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// WaitForLogin 等待指定登录会话扫码完成，返回wxid
+func (c *Client) WaitForLogin(session *LoginSession) (string, error) {
+	if session == nil {
+		return "", fmt.Errorf("登录会话为空")
+	}
+
+	wxid, err := c.waitForLogin(session.Token, session.UUID)
 	if err != nil {
 		return "", fmt.Errorf("登录失败: %v", err)
 	}
@@ -67,6 +95,32 @@ func (c *Client) Login() (string, error) {
 	return wxid, nil
 }
 
+// currentAuthKey 获取已生成的授权码，尚未生成时生成一个
+func (c *Client) currentAuthKey() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.authKey != "" {
+		return c.authKey, nil
+	}
+	authKey, err := c.generateAuthKey()
+	if err != nil {
+		return "", err
+	}
+	c.authKey = authKey
+	return authKey, nil
+}
+
+// resetAuthKey 丢弃失效的授权码（已被替换时不处理）
+func (c *Client) resetAuthKey(authKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.authKey == authKey {
+		c.authKey = ""
+	}
+}
+
 // generateAuthKey 生成授权码
 func (c *Client) generateAuthKey() (string, error) {
 	url := c.baseURL + "/admin/GenAuthKey1?key=" + c.adminKey
@@ -117,9 +171,9 @@ func (c *Client) generateAuthKey() (string, error) {
 	return authResp.Data[0], nil
 }
 
-// getLoginQrCode 获取登录二维码
-func (c *Client) getLoginQrCode() (string, string, error) {
-	apiUrl := c.baseURL + "/login/GetLoginQrCodeNew?key=" + c.authKey
+// getLoginQrCode 使用授权码获取登录二维码
+func (c *Client) getLoginQrCode(authKey string) (string, string, error) {
+	apiUrl := c.baseURL + "/login/GetLoginQrCodeNew?key=" + authKey
 
 	reqData := GetLoginQrCodeRequest{
 		Check: false,
@@ -177,12 +231,12 @@ func (c *Client) getLoginQrCode() (string, string, error) {
 	return actualQrData, uuid, nil
 }
 
-// waitForLogin 等待登录完成
-func (c *Client) waitForLogin(uuid string) (string, error) {
+// waitForLogin 等待使用该授权码的二维码登录完成
+func (c *Client) waitForLogin(authKey, uuid string) (string, error) {
 	url := c.baseURL + "/login/CheckLoginStatus"
 
 	reqData := CheckLoginStatusRequest{
-		AuthKey: c.authKey,
+		AuthKey: authKey,
 		UUID:    uuid,
 	}
 
@@ -1,5 +1,7 @@
 package wechat
 
+import "time"
+
 // GenAuthKeyRequest 生成授权码请求
 type GenAuthKeyRequest struct {
 	Count int `json:"Count"`
@@ -52,6 +54,14 @@ type CheckLoginStatusResponse struct {
 	Text string `json:"Text"`
 }
 
+// LoginSession 登录会话（二维码和授权码）
+type LoginSession struct {
+	Token     string    `json:"token"`      // 本次登录的授权码
+	QRCode    string    `json:"qr_code"`    // 二维码内容（供扫码的URL）
+	UUID      string    `json:"uuid"`       // 二维码UUID，用于查询扫码状态
+	CreatedAt time.Time `json:"created_at"` // 创建时间
+}
+
 // 登录状态常量
 const (
 	LoginStatusWaiting = 1 // 等待扫码
@@ -73,7 +73,7 @@ func main() {
 	if err != nil {
 		log.Fatal("内容处理器初始化失败:", err)
 	}
-	defer processor.Close()
+	defer processor.Close(context.Background())
 
 	// 3. 初始化微信登录状态监控
 	fmt.Println("📱 初始化微信登录状态监控...")
@@ -28,7 +28,7 @@ func TestEndToEndContentProcessing(t *testing.T) {
 
 	processor, err := content.NewProcessor()
 	require.NoError(t, err)
-	defer processor.Close()
+	defer processor.Close(context.Background())
 
 	t.Run("文本内容完整处理流程", func(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
@@ -307,7 +307,7 @@ func TestContentProcessingEdgeCases(t *testing.T) {
 
 	processor, err := content.NewProcessor()
 	require.NoError(t, err)
-	defer processor.Close()
+	defer processor.Close(context.Background())
 
 	t.Run("空内容处理", func(t *testing.T) {
 		request := &content.ProcessingRequest{
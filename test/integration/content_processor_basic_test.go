@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -37,7 +38,7 @@ func TestContentProcessorInitialization(t *testing.T) {
 		}
 		
 		// 关闭处理器
-		err = processor.Close()
+		err = processor.Close(context.Background())
 		assert.NoError(t, err, "处理器关闭应该成功")
 		
 		t.Logf("✅ 处理器初始化和关闭测试完成")
@@ -110,7 +111,7 @@ func TestProcessingRequestValidation(t *testing.T) {
 		t.Skipf("Skipping validation test due to missing LLM configuration: %v", err)
 		return
 	}
-	defer processor.Close()
+	defer processor.Close(context.Background())
 
 	t.Run("空内容验证", func(t *testing.T) {
 		request := &content.ProcessingRequest{
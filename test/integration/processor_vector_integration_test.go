@@ -24,7 +24,7 @@ func TestProcessorVectorIntegration(t *testing.T) {
 		// 1. 创建处理器
 		processor, err := content.NewProcessor()
 		require.NoError(t, err, "Failed to create processor")
-		defer processor.Close()
+		defer processor.Close(context.Background())
 
 		// 2. 准备测试请求
 		request := &content.ProcessingRequest{
@@ -94,7 +94,7 @@ func TestProcessorVectorIntegration(t *testing.T) {
 		// 测试不启用向量化的情况
 		processor, err := content.NewProcessor()
 		require.NoError(t, err, "Failed to create processor")
-		defer processor.Close()
+		defer processor.Close(context.Background())
 
 		request := &content.ProcessingRequest{
 			ID:          "test-no-vector-001",
@@ -124,7 +124,7 @@ func TestProcessorVectorIntegration(t *testing.T) {
 		// 测试批量向量索引
 		processor, err := content.NewProcessor()
 		require.NoError(t, err, "Failed to create processor")
-		defer processor.Close()
+		defer processor.Close(context.Background())
 
 		// 创建多个测试请求
 		requests := []*content.ProcessingRequest{
@@ -192,7 +192,7 @@ func TestProcessorRecommendationIntegration(t *testing.T) {
 	t.Run("ContentBasedRecommendations", func(t *testing.T) {
 		processor, err := content.NewProcessor()
 		require.NoError(t, err, "Failed to create processor")
-		defer processor.Close()
+		defer processor.Close(context.Background())
 
 		// 1. 先索引一些内容
 		contents := []string{
@@ -74,7 +74,7 @@ func (suite *SearchRecommendationE2ETestSuite) TearDownSuite() {
 		suite.server.Close()
 	}
 	if suite.contentProcessor != nil {
-		suite.contentProcessor.Close()
+		suite.contentProcessor.Close(context.Background())
 	}
 }
 
@@ -378,7 +378,7 @@ func (im *IntegrationManager) GetRecommendations(ctx context.Context, request *c
 // Close 关闭集成管理器
 func (im *IntegrationManager) Close() error {
 	if im.processor != nil {
-		im.processor.Close()
+		im.processor.Close(context.Background())
 	}
 	if im.wechatClient != nil {
 		im.wechatClient.Disconnect()